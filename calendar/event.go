@@ -93,18 +93,23 @@ func (e *Event) Parse(input string) error {
 	return errors.New("calendar event doesn't match any well known pattern")
 }
 
-// Next returns the next schedule for this event
+// Next returns the next schedule for this event, in the time zone of the "from" time.
+//
+// Like cron, the event behaves predictably across daylight saving time transitions:
+//   - a time skipped when clocks are turned forward triggers once, right after the transition
+//   - a time repeated when clocks are turned back triggers only once (on its first occurrence)
 func (e *Event) Next(from time.Time) time.Time {
 	// start from time and increment of 1 minute each time
 	next := from.Truncate(time.Minute) // truncate all the seconds
+	previous := next.Add(-time.Minute)
 	// should stop in 2 years time to avoid an infinite loop
 	endYear := from.Year() + 2
 	for next.Year() <= endYear {
-		if e.match(next) {
+		if (e.match(next) && !isRepeatedTime(next)) || e.matchSkippedTime(previous, next) {
 			return next
 		}
 		// increment 1 minute
-		next = next.Add(time.Minute)
+		previous, next = next, next.Add(time.Minute)
 	}
 	return time.Time{}
 }
@@ -126,14 +131,14 @@ func (e *Event) AsTime() (time.Time, bool) {
 }
 
 // GetAllInBetween returns all activation times of the event in between these two dates.
-// the minimum increment is 1 minute
+// the minimum increment is 1 minute. The activation times are in the location of the start date
 func (e *Event) GetAllInBetween(start, end time.Time) []time.Time {
 	// align time to the minute (zeroing the seconds)
 	start = start.Truncate(time.Minute)
 	end = end.Truncate(time.Minute)
 	recurrences := []time.Time{}
 	next := e.Next(start)
-	for next.Before(end) {
+	for !next.IsZero() && next.Before(end) {
 		recurrences = append(recurrences, next)
 		next = e.Next(next.Add(time.Minute))
 	}
@@ -206,6 +211,34 @@ func (e *Event) match(currentTime time.Time) bool {
 	return true
 }
 
+// matchSkippedTime returns true if a time skipped by the clock in between the two consecutive minutes would trigger the event
+func (e *Event) matchSkippedTime(previous, next time.Time) bool {
+	skipped := wallClock(previous).Add(time.Minute)
+	end := wallClock(next)
+	for ; skipped.Before(end); skipped = skipped.Add(time.Minute) {
+		if e.match(skipped) {
+			return true
+		}
+	}
+	return false
+}
+
+// wallClock returns the date and time displayed by the clock, without time zone
+func wallClock(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, time.UTC)
+}
+
+// isRepeatedTime returns true when the clock already displayed the same time before, when it was turned back
+func isRepeatedTime(t time.Time) bool {
+	_, offset := t.Zone()
+	_, earlierOffset := t.Add(-12 * time.Hour).Zone()
+	if earlierOffset <= offset {
+		return false
+	}
+	earlier := t.Add(-time.Duration(earlierOffset-offset) * time.Second)
+	return wallClock(earlier).Equal(wallClock(t))
+}
+
 func numbersToWeekdays(weekdays string) string {
 	for day := minDay; day < maxDay; day++ {
 		weekdays = strings.ReplaceAll(weekdays, fmt.Sprintf("%02d", day), capitalize(shortWeekDay[day]))
@@ -3,6 +3,7 @@ package calendar
 import (
 	"testing"
 	"time"
+	_ "time/tzdata"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -272,6 +273,84 @@ func TestEventsInBetweenTwoDates(t *testing.T) {
 	}
 }
 
+func TestNextTriggerInTimezone(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	require.NoError(t, err)
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	event := NewEvent()
+	require.NoError(t, event.Parse("*-*-* 02:30"))
+
+	from := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2024, 6, 2, 0, 30, 0, 0, time.UTC), event.Next(from.In(paris)).UTC())
+	assert.Equal(t, time.Date(2024, 6, 2, 6, 30, 0, 0, time.UTC), event.Next(from.In(newYork)).UTC())
+}
+
+func TestNextTriggerOnDaylightSavingTime(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	require.NoError(t, err)
+
+	testData := []struct {
+		name     string
+		event    string
+		from     time.Time
+		expected []time.Time
+	}{
+		{
+			// clocks are turned forward from 02:00 to 03:00 on 31 March 2024
+			name:  "skipped time runs after the transition",
+			event: "*-*-* 02:30",
+			from:  time.Date(2024, 3, 30, 12, 0, 0, 0, paris),
+			expected: []time.Time{
+				time.Date(2024, 3, 31, 3, 0, 0, 0, paris),
+				time.Date(2024, 4, 1, 2, 30, 0, 0, paris),
+			},
+		},
+		{
+			name:  "time outside of the transition",
+			event: "*-*-* 03:30",
+			from:  time.Date(2024, 3, 30, 12, 0, 0, 0, paris),
+			expected: []time.Time{
+				time.Date(2024, 3, 31, 3, 30, 0, 0, paris),
+				time.Date(2024, 4, 1, 3, 30, 0, 0, paris),
+			},
+		},
+		{
+			// clocks are turned back from 03:00 to 02:00 on 27 October 2024
+			name:  "repeated time runs once",
+			event: "*-*-* 02:30",
+			from:  time.Date(2024, 10, 26, 12, 0, 0, 0, paris),
+			expected: []time.Time{
+				time.Date(2024, 10, 27, 0, 30, 0, 0, time.UTC), // 02:30 CEST
+				time.Date(2024, 10, 28, 1, 30, 0, 0, time.UTC), // 02:30 CET
+			},
+		},
+		{
+			name:  "hourly during the repeated hour",
+			event: "*-*-* *:00",
+			from:  time.Date(2024, 10, 26, 23, 30, 0, 0, time.UTC),
+			expected: []time.Time{
+				time.Date(2024, 10, 27, 0, 0, 0, 0, time.UTC), // 02:00 CEST
+				time.Date(2024, 10, 27, 2, 0, 0, 0, time.UTC), // 03:00 CET
+			},
+		},
+	}
+
+	for _, testItem := range testData {
+		t.Run(testItem.name, func(t *testing.T) {
+			event := NewEvent()
+			require.NoError(t, event.Parse(testItem.event))
+			next := testItem.from.In(paris)
+			for _, expected := range testItem.expected {
+				next = event.Next(next)
+				assert.Truef(t, expected.Equal(next), "expected %s but found %s", expected, next)
+				next = next.Add(time.Minute)
+			}
+		})
+	}
+}
+
 func mustParseTime(input string) time.Time {
 	output, err := time.Parse("2006-01-02 15:04:05", input)
 	if err != nil {
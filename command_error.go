@@ -10,6 +10,7 @@ type commandError struct {
 	scd    shellCommandDefinition
 	stderr string
 	err    error
+	hook   bool // the command is a hook (run-before, run-after or run-after-fail)
 }
 
 func newCommandError(command shellCommandDefinition, stderr string, err error) *commandError {
@@ -11,17 +11,21 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/creativeprojects/clog"
 	"github.com/creativeprojects/resticprofile/config"
 	"github.com/creativeprojects/resticprofile/config/jsonschema"
 	"github.com/creativeprojects/resticprofile/constants"
+	"github.com/creativeprojects/resticprofile/monitor/collector"
+	"github.com/creativeprojects/resticprofile/monitor/status"
 	"github.com/creativeprojects/resticprofile/platform"
 	"github.com/creativeprojects/resticprofile/remote"
 	"github.com/creativeprojects/resticprofile/restic"
 	"github.com/creativeprojects/resticprofile/schedule"
 	"github.com/creativeprojects/resticprofile/term"
 	"github.com/creativeprojects/resticprofile/util/templates"
+	"github.com/creativeprojects/resticprofile/webhook"
 	"github.com/creativeprojects/resticprofile/win"
 	"golang.org/x/exp/slices"
 )
@@ -30,6 +34,8 @@ var (
 	ownCommands = NewOwnCommands()
 )
 
+const scheduleRunNow = "run-now"
+
 func init() {
 	ownCommands.Register(getOwnCommands())
 
@@ -47,6 +53,7 @@ func getOwnCommands() []ownCommand {
 			longDescription:   "The \"help\" command prints commandline help on resticprofile flags & commands (own and restic) including information on flags passed to restic from various profiles defined in the current configuration.\n\nHelp on a specific command is displayed by adding the command name as argument after \"help\", e.g. use \"resticprofile help version\" to get details on the \"version\" command.",
 			action:            displayHelpCommand,
 			needConfiguration: false,
+			readOnly:          true,
 		},
 		{
 			name:              "version",
@@ -54,7 +61,11 @@ func getOwnCommands() []ownCommand {
 			longDescription:   "The \"version\" command displays brief or detailed version information",
 			action:            displayVersion,
 			needConfiguration: false,
-			flags:             map[string]string{"-v, --verbose": "display detailed version information"},
+			readOnly:          true,
+			flags: map[string]string{
+				"-v, --verbose": "display detailed version information",
+				"--json":        "display version information (including restic version) in JSON format",
+			},
 		},
 		{
 			name:              "profiles",
@@ -62,31 +73,76 @@ func getOwnCommands() []ownCommand {
 			longDescription:   "The \"profiles\" command prints brief information on all profiles and groups that are declared in the configuration file",
 			action:            displayProfilesCommand,
 			needConfiguration: true,
+			readOnly:          true,
+			flags: map[string]string{
+				"--json": "display profiles and groups in JSON format",
+				"--all":  "also display the abstract profiles",
+			},
 		},
 		{
 			name:              "show",
 			description:       "show all the details of the current profile",
-			longDescription:   "The \"show\" command prints the effective configuration of the selected profile.\n\nThe effective profile configuration is built by loading all includes, applying inheritance, mixins, templates and variables and parsing the result.",
+			longDescription:   "The \"show\" command prints the effective configuration of the selected profile.\n\nThe effective profile configuration is built by loading all includes, applying inheritance, mixins, templates and variables and parsing the result.\n\nWith \"--format script\", the run of the profile is printed as a shell script instead (useful for debugging or to run the commands on another host). Confidential values are masked unless \"--secrets\" is set.",
 			action:            showProfile,
 			needConfiguration: true,
+			readOnly:          true,
+			flags: map[string]string{
+				"--origin":                "show where each value comes from (configuration file, parent profile, mixin or default value)",
+				"--format script":         "show the run of the profile as a shell script (environment, hooks and restic commands)",
+				"--shell <sh|powershell>": "shell of the script (default is sh, or powershell on Windows)",
+				"--command <command>":     "command run by the script (default is the default command of the global section)",
+				"--secrets":               "show the confidential values in the script instead of masking them",
+			},
+		},
+		{
+			name:              "estimate",
+			description:       "estimate the number of files and the size of the backup source of a profile",
+			longDescription:   "The \"estimate\" command walks the backup source of the selected profile, applying the exclusion flags of the backup section (exclude, iexclude, exclude-file, iexclude-file, exclude-caches, exclude-if-present and exclude-larger-than), and displays the number of files and their total size.\n\nWith \"--dry-run\", it also runs \"restic backup --dry-run\" to display how much data would be added to the repository after deduplication.",
+			action:            estimateBackup,
+			needConfiguration: true,
+			readOnly:          true,
+			hide:              false,
+			flags:             map[string]string{"--dry-run": "also run \"restic backup --dry-run\" for an estimate of the data added to the repository"},
 		},
 		{
 			name:              "random-key",
 			description:       "generate a cryptographically secure random key to use as a restic keyfile",
 			action:            randomKey,
 			needConfiguration: false,
+			readOnly:          true,
 			hide:              true,
 		},
+		{
+			name:              "cleanup",
+			description:       "remove the temporary files left behind by resticprofile processes that are no longer running",
+			longDescription:   "The \"cleanup\" command removes the temporary directories (scripts, lists of files, etc.) of resticprofile processes that didn't terminate properly. These directories are also removed before running a profile.\n\nUse \"--dry-run\" to display the directories without removing them.",
+			action:            cleanupCommand,
+			needConfiguration: false,
+			hide:              false,
+		},
+		{
+			name:              "check-install",
+			description:       "verify the installation of resticprofile (binaries, scheduler, elevation, fuse and directories)",
+			longDescription:   "The \"check-install\" command verifies the permissions of the resticprofile binary, that restic and the scheduler are available, that system schedules can be created (root, sudo or UAC elevation), that FUSE is installed for \"restic mount\", and that the temporary, cache, log, lock and status directories of the selected profile (or of all profiles) are writable.\n\nEach problem is displayed with a remediation for the current platform. The command fails when one of the checks is an error.",
+			action:            checkInstallCommand,
+			needConfiguration: false,
+			readOnly:          true,
+			hide:              false,
+			flags:             map[string]string{"--all": "check the directories of all profiles"},
+		},
 		{
 			name:              "schedule",
 			description:       "schedule jobs from a profile (or of all profiles)",
-			longDescription:   "The \"schedule\" command registers declared schedules of the selected profile (or of all profiles) as scheduled jobs within the scheduling service of the operating system",
+			longDescription:   "The \"schedule\" command registers declared schedules of the selected profile (or of all profiles) as scheduled jobs within the scheduling service of the operating system.\n\nUse \"schedule run-now [profile.]command\" to run a scheduled job immediately, with the same command line and environment as the scheduler.\n\nUse \"schedule env-diff\" to compare the current environment with the environment of the scheduled jobs.\n\nUse \"schedule simulate --from YYYY-MM-DD --to YYYY-MM-DD\" to list the runs the schedules of all profiles would trigger in the time range, with the runs conflicting with \"maintenance-windows\" or with another run on the same repository.",
 			action:            createSchedule,
 			needConfiguration: true,
 			hide:              false,
 			flags: map[string]string{
-				"--no-start": "don't start the timer/service (systemd/launch only)",
-				"--all":      "add all scheduled jobs of all profiles",
+				"--no-start":            "don't start the timer/service (systemd/launch only)",
+				"--all":                 "add all scheduled jobs of all profiles",
+				"--from <date>":         "simulate: first day of the simulation (defaults to today)",
+				"--to <date>":           "simulate: day after the simulation (defaults to 7 days after --from)",
+				"--duration <duration>": "simulate: expected duration of a run without history (defaults to 30m)",
 			},
 		},
 		{
@@ -104,20 +160,216 @@ func getOwnCommands() []ownCommand {
 			longDescription:   "The \"status\" command prints all declared schedules of the selected profile (or of all profiles) and shows the status of related scheduled jobs in the scheduling service of the operating system",
 			action:            statusSchedule,
 			needConfiguration: true,
+			readOnly:          true,
 			hide:              false,
 			flags:             map[string]string{"--all": "display the status of all scheduled jobs of all profiles"},
 		},
+		{
+			name:              "schedules",
+			description:       "display the schedules declared in a profile (or in all profiles)",
+			longDescription:   "The \"schedules\" command prints the schedules declared in the configuration of the selected profile (or of all profiles), without querying the scheduling service of the operating system. With the --json flag, the schedules and their next run time are printed in JSON format for other tools to consume.",
+			action:            displaySchedulesCommand,
+			needConfiguration: true,
+			readOnly:          true,
+			hide:              false,
+			flags: map[string]string{
+				"--all":  "display the schedules of all profiles",
+				"--json": "display the schedules (with next run time) in JSON format",
+			},
+		},
+		{
+			name:              "unmount",
+			description:       "stop a restic mount running in the background (started with \"mount --background\")",
+			longDescription:   "The \"unmount\" command stops the restic mount that was started in the background with \"resticprofile [profile].mount --background [mountpoint]\" for the selected profile (or of all profiles). Background mounts require a \"status-file\" in the profile.",
+			action:            unmountCommand,
+			needConfiguration: true,
+			hide:              false,
+			flags:             map[string]string{"--all": "stop background mounts of all profiles"},
+		},
+		{
+			name:              "history",
+			description:       "display the history of the commands run by a profile (list, show or prune)",
+			longDescription:   "The \"history\" command displays the commands recorded in the \"history-file\" of the selected profile.\n\nUse \"history list\" (default) to display the latest entries, \"history show [ID]\" to display the details of one entry and \"history prune\" to remove old entries.",
+			action:            historyCommand,
+			needConfiguration: true,
+			readOnly:          true,
+			hide:              false,
+			flags: map[string]string{
+				"--command <name>":        "list: only display the entries of this command",
+				"--limit <count>":         "list: number of entries to display (defaults to 20, 0 for all)",
+				"--json":                  "list and show: display the entries in JSON format",
+				"--older-than <duration>": "prune: remove entries older than the duration (defaults to \"history-retention\")",
+			},
+		},
+		{
+			name:              "trend",
+			description:       "display the size and the duration of the backups of a profile over time",
+			longDescription:   "The \"trend\" command displays a chart of the size and the duration of the successful backups recorded in the \"history-file\" of the profile (the selected profile, or the profile given as argument). A backup at least twice as large or as long as the previous one is flagged as an anomaly.",
+			action:            trendCommand,
+			needConfiguration: true,
+			readOnly:          true,
+			hide:              false,
+			flags: map[string]string{
+				"--limit <count>": "number of backups to display (defaults to 30, 0 for all)",
+				"--html":          "write an HTML page with the charts instead of the text chart",
+				"--json":          "display the backups in JSON format",
+			},
+		},
+		{
+			name:              "audit",
+			description:       "verify the signatures of the audit log",
+			longDescription:   "The \"audit verify\" command checks the HMAC chain of the \"audit-log\" file with the key of \"audit-log-key-file\" (both in the global section): it fails when an entry was modified, inserted or removed.",
+			action:            auditLogCommand,
+			needConfiguration: true,
+			readOnly:          true,
+			hide:              false,
+			flags: map[string]string{
+				"--file <file>": "verify this audit log instead of the \"audit-log\" of the configuration",
+			},
+		},
+		{
+			name:              "heartbeat",
+			description:       "verify the heartbeat of a profile (last successful run and ping)",
+			longDescription:   "The \"heartbeat\" command verifies the heartbeat chain of the selected profile. For each command with a \"heartbeat\" monitoring section, it displays the last successful run (from the \"history-file\" or the \"status-file\"), reports a missed heartbeat when the run is older than \"heartbeat-max-age\" (or the interval of the schedule), then sends a ping to each heartbeat URL.",
+			action:            heartbeatCommand,
+			needConfiguration: true,
+			readOnly:          false,
+			hide:              false,
+			flags: map[string]string{
+				"--no-ping": "only verify the last successful run, do not send the pings",
+			},
+		},
+		{
+			name:              "quota",
+			description:       "display the usage of the quota of a profile, and unblock its backups",
+			longDescription:   "The \"quota\" command displays the last usage of the repository checked against the \"max-repo-size\" of the \"quota\" section of the selected profile. When the backups are blocked by an exceeded quota (\"block-backup\"), the \"--ack\" flag acknowledges the quota and allows the next backups to run.",
+			action:            quotaCommand,
+			needConfiguration: true,
+			readOnly:          false,
+			hide:              false,
+			flags: map[string]string{
+				"--ack": "acknowledge the exceeded quota: the next backups are no longer blocked",
+			},
+		},
+		{
+			name:              "migrate-repo",
+			description:       "move all the snapshots of a profile to a new repository (init, copy and verify)",
+			longDescription:   "The \"migrate-repo\" command moves the repository of a profile to a new location: it initializes the new repository with the same chunker parameters (to keep the deduplication), copies all the snapshots, checks the new repository and displays the change to make in the configuration file.\n\nThe configuration file is not modified. The password of the new repository defaults to the password of the profile.",
+			action:            migrateRepoCommand,
+			needConfiguration: true,
+			readOnly:          false,
+			hide:              false,
+			flags: map[string]string{
+				"--to <repository>":          "location of the new repository",
+				"--password-file <file>":     "password file of the new repository",
+				"--password-command <shell>": "command returning the password of the new repository",
+				"--resume":                   "the new repository is already initialized: continue the copy",
+				"--no-verify":                "do not check the new repository after the copy",
+			},
+		},
+		{
+			name:              "doc",
+			description:       "generate the markdown documentation of a profile (or of all profiles)",
+			longDescription:   "The \"doc\" command prints the documentation of the selected profile (or of all profiles) in markdown format, from the resolved configuration: description, repository, sources and exclusions, retention policy, schedules (with their next run), monitoring endpoints and hooks.\n\nConfidential values (like the passwords in the URLs) are masked.",
+			action:            docCommand,
+			needConfiguration: true,
+			readOnly:          true,
+			hide:              false,
+			flags:             map[string]string{"--all": "document all the profiles"},
+		},
+		{
+			name:              "import",
+			description:       "translate the configuration of another backup tool (borgmatic) into a resticprofile configuration",
+			longDescription:   "The \"import borgmatic <file>\" command prints a resticprofile configuration (version 2) translated from a borgmatic configuration file: source directories, repositories, exclusions, retention, consistency checks, hooks and healthchecks.\n\nThe options which cannot be translated are listed at the top of the configuration. Restic cannot read the borg repositories: the repositories of the generated profiles must be initialized with restic.",
+			action:            importCommand,
+			needConfiguration: false,
+			readOnly:          true,
+			hide:              false,
+			flags:             map[string]string{"--profile <name>": "name of the generated profile (defaults to \"" + defaultImportProfile + "\")"},
+		},
+		{
+			name:              "config",
+			description:       "display the hash of the configuration files (config hash)",
+			longDescription:   "The \"config hash\" command prints a deterministic hash of all the configuration files (main file and includes). The hash only depends on the content of the files and their loading order.\n\nUse the hash with the \"--expect-config-hash\" flag to refuse running a configuration that was changed.",
+			action:            configCommand,
+			needConfiguration: true,
+			readOnly:          true,
+			hide:              false,
+			flags: map[string]string{
+				"--templates": "hash the configuration files after executing their templates",
+				"--files":     "also display the hash of each configuration file",
+			},
+		},
+		{
+			name:              "collector",
+			description:       "run the server collecting the summaries sent by resticprofile on other hosts",
+			longDescription:   "The \"collector\" command starts an HTTP server receiving the summary of the commands run by the profiles having a \"collector-url\". The server displays the latest status of all hosts on an HTML page, and in JSON format on " + collector.APIPath + ". Set the environment variable " + collectorPasswordEnv + " to require HTTP basic authentication.",
+			action:            collectorCommand,
+			needConfiguration: false,
+			hide:              false,
+			flags: map[string]string{
+				"--listen <address>": "address to listen to (defaults to \"" + defaultCollectorListen + "\")",
+				"--store <file>":     "bbolt database keeping the latest runs (defaults to \"" + defaultCollectorStore + "\")",
+			},
+		},
+		{
+			name:              "webhook",
+			description:       "run the server receiving the signed requests triggering the commands of the profiles",
+			longDescription:   "The \"webhook\" command starts an HTTP server running a command of a profile on \"POST " + webhook.RunPath + "<profile>.<command>\". The requests must be signed with an HMAC of the secret key of \"webhook-secret-file\" (or of the environment variable " + webhookSecretEnv + "), and the command must be in the \"webhook-allow\" list of the global section.",
+			action:            webhookCommand,
+			needConfiguration: true,
+			hide:              false,
+			flags: map[string]string{
+				"--listen <address>": "address to listen to (defaults to \"webhook-listen\" or \"" + defaultWebhookListen + "\")",
+			},
+		},
+		{
+			name:              "daemon",
+			description:       "run the schedules of all the profiles in the foreground, without the scheduler of the system",
+			longDescription:   "The \"daemon\" command keeps running and starts the scheduled commands of all the profiles by itself, one at a time, which is useful where systemd or cron are not available (like in a container). The configuration file is reloaded on SIGHUP, and the state of the schedules (next run, running command) is saved in the \"status-file\" of the profiles.",
+			action:            daemonCommand,
+			needConfiguration: true,
+			hide:              false,
+		},
+		{
+			name:              "rest-server",
+			description:       "generate the resources of a rest-server hosting the repositories (htpasswd, tls or systemd)",
+			longDescription:   "The \"rest-server\" command helps running a rest-server next to resticprofile.\n\nUse \"rest-server htpasswd\" to generate the users of the \"rest:\" repositories of the profiles, \"rest-server tls\" to generate a self-signed certificate and \"rest-server systemd\" to display the systemd service running the server.",
+			action:            restServerCommand,
+			needConfiguration: false,
+			readOnly:          false,
+			hide:              false,
+			flags: map[string]string{
+				"--all":              "htpasswd: generate the users of all the profiles",
+				"--file <path>":      "htpasswd: add or update the users in this htpasswd file instead of displaying them",
+				"--host <names>":     "tls: comma separated host names or IP addresses of the server",
+				"--dir <path>":       "tls: directory of the certificate and private key (defaults to the current directory)",
+				"--days <count>":     "tls: validity of the certificate (defaults to " + strconv.Itoa(defaultRestServerTLSDays) + " days)",
+				"--force":            "tls: replace an existing certificate",
+				"--path <path>":      "systemd: directory of the repositories (defaults to \"" + defaultRestServerPath + "\")",
+				"--listen <address>": "systemd: address to listen to (defaults to \"" + defaultRestServerListen + "\")",
+				"--user <name>":      "systemd: user running the server (defaults to \"" + defaultRestServerUser + "\")",
+				"--htpasswd <path>":  "systemd: htpasswd file (defaults to \".htpasswd\" in the path of the repositories)",
+				"--tls-dir <path>":   "systemd: directory of the certificate generated by \"rest-server tls\"",
+				"--binary <path>":    "systemd: path of the rest-server binary",
+				"--append-only":      "systemd: only allow adding data to the repositories",
+				"--no-private-repos": "systemd: allow each user to access all the repositories",
+			},
+		},
 		{
 			name:              "generate",
 			description:       "generate resources such as random key, bash/zsh completion scripts, etc.",
 			longDescription:   "The \"generate\" command is used to create various resources and print them to stdout",
 			action:            generateCommand,
 			needConfiguration: false,
+			readOnly:          true,
 			hide:              false,
 			flags: map[string]string{
 				"--random-key [size]":                            "generate a cryptographically secure random key to use as a restic keyfile (size defaults to 1024 when omitted)",
 				"--config-reference [--version 0.15] [template]": "generate a config file reference from a go template (defaults to the built-in markdown template when omitted)",
 				"--json-schema [--version 0.15] [v1|v2]":         "generate a JSON schema that validates resticprofile configuration files in YAML or JSON format",
+				"--example [name] [flags]":                       "generate an example configuration (lists examples when name is omitted), flags are --profile, --repository, --password-file, --source and --schedule",
+				"--status-schema":                                "generate the JSON schema of the status file",
 				"--bash-completion":                              "generate a shell completion script for bash",
 				"--zsh-completion":                               "generate a shell completion script for zsh",
 			},
@@ -128,6 +380,7 @@ func getOwnCommands() []ownCommand {
 			description:       "create commandline completion results based on given args",
 			action:            completeCommand,
 			needConfiguration: false,
+			readOnly:          true,
 			hide:              true,
 		},
 		{
@@ -206,8 +459,12 @@ func generateCommand(output io.Writer, request commandRequest) (err error) {
 		_, err = fmt.Fprintln(output, bashCompletionScript)
 	} else if slices.Contains(args, "--config-reference") {
 		err = generateConfigReference(output, config, flags, args[slices.Index(args, "--config-reference")+1:])
+	} else if slices.Contains(args, "--example") {
+		err = generateExample(output, args[slices.Index(args, "--example")+1:])
 	} else if slices.Contains(args, "--json-schema") {
 		err = generateJsonSchema(output, config, flags, args[slices.Index(args, "--json-schema")+1:])
+	} else if slices.Contains(args, "--status-schema") {
+		_, err = output.Write(status.Schema())
 	} else if slices.Contains(args, "--random-key") {
 		request.flags.resticArgs = args[slices.Index(args, "--random-key"):]
 		err = randomKey(output, request)
@@ -291,7 +548,29 @@ func showProfile(output io.Writer, request commandRequest) error {
 	if err != nil {
 		return fmt.Errorf("cannot load global section: %w", err)
 	}
-	err = config.ShowStruct(output, global, constants.SectionConfigurationGlobal)
+	options, err := parseShowArgs(request.args)
+	if err != nil {
+		return newExitCodeError(constants.ExitCodeCommandLine, err)
+	}
+	if options.format == showFormatScript {
+		profile, err := c.GetProfile(flags.name)
+		if err != nil {
+			if errors.Is(err, config.ErrNotFound) {
+				return fmt.Errorf("profile '%s' not found", flags.name)
+			}
+			return fmt.Errorf("cannot load profile '%s': %w", flags.name, err)
+		}
+		return showScript(output, global, profile, options)
+	}
+	showOrigins := options.origin
+	if showOrigins {
+		var origins config.ValueOrigins
+		if origins, err = c.GetGlobalOrigins(); err == nil {
+			err = config.ShowStructWithOrigins(output, global, constants.SectionConfigurationGlobal, origins)
+		}
+	} else {
+		err = config.ShowStruct(output, global, constants.SectionConfigurationGlobal)
+	}
 	if err != nil {
 		return fmt.Errorf("cannot show global section: %w", err)
 	}
@@ -309,7 +588,22 @@ func showProfile(output io.Writer, request commandRequest) error {
 	// Display deprecation notice
 	displayProfileDeprecationNotices(profile)
 
-	err = config.ShowStruct(output, profile, "profile "+flags.name)
+	// Display invalid repository options, and the flags not supported by the restic version set in the global section
+	if global.ResticVersion != "" {
+		if err = profile.SetResticVersion(global.ResticVersion); err != nil {
+			clog.Warningf("restic version %q is no valid semver: %s", global.ResticVersion, err.Error())
+		}
+	}
+	displayDeprecationNotices(getCompatibilityNotices(profile, global.ResticVersion))
+
+	if showOrigins {
+		var origins config.ValueOrigins
+		if origins, err = c.GetProfileOrigins(flags.name); err == nil {
+			err = config.ShowStructWithOrigins(output, profile, "profile "+flags.name, origins)
+		}
+	} else {
+		err = config.ShowStruct(output, profile, "profile "+flags.name)
+	}
 	if err != nil {
 		return fmt.Errorf("cannot show profile '%s': %w", flags.name, err)
 	}
@@ -330,6 +624,38 @@ func showSchedules(output io.Writer, schedulesConfig []*config.ScheduleConfig) {
 	}
 }
 
+// displaySchedulesCommand displays the schedules declared in the selected profile (or in all profiles)
+func displaySchedulesCommand(output io.Writer, request commandRequest) error {
+	defer request.config.DisplayConfigurationIssues()
+
+	if hasJSONFlag(request.args) {
+		return displaySchedulesJSON(output, request)
+	}
+	schedules, err := getDeclaredSchedules(request)
+	if err != nil {
+		return err
+	}
+	showSchedules(output, schedules)
+	return nil
+}
+
+// getDeclaredSchedules returns the schedules declared in the selected profile (or in all profiles with the "--all" flag)
+func getDeclaredSchedules(request commandRequest) ([]*config.ScheduleConfig, error) {
+	profileNames := []string{request.flags.name}
+	if slices.Contains(request.args, "--all") {
+		profileNames = selectProfiles(request.config, request.flags, request.args)
+	}
+	schedules := make([]*config.ScheduleConfig, 0)
+	for _, profileName := range profileNames {
+		profile, err := request.config.GetProfile(profileName)
+		if err != nil {
+			return nil, fmt.Errorf("cannot load profile '%s': %w", profileName, err)
+		}
+		schedules = append(schedules, profile.Schedules()...)
+	}
+	return schedules, nil
+}
+
 // randomKey simply display a base64'd random key to the console
 func randomKey(output io.Writer, request commandRequest) error {
 	var err error
@@ -366,7 +692,12 @@ func selectProfiles(c *config.Config, flags commandLineFlags, args []string) []s
 
 	// Check for --all or groups
 	if slices.Contains(args, "--all") {
-		profiles = c.GetProfileNames()
+		// abstract profiles only exist to be inherited
+		for _, name := range c.GetProfileNames() {
+			if !c.IsAbstractProfile(name) {
+				profiles = append(profiles, name)
+			}
+		}
 
 	} else if !c.HasProfile(flags.name) {
 		if names, err := c.GetProfileGroup(flags.name); err == nil && names != nil {
@@ -389,19 +720,46 @@ func flagsForProfile(flags commandLineFlags, profileName string) commandLineFlag
 }
 
 // createSchedule accepts one argument from the commandline: --no-start
-func createSchedule(_ io.Writer, request commandRequest) error {
+func createSchedule(output io.Writer, request commandRequest) error {
 	c := request.config
 	flags := request.flags
 	args := request.args
 
 	defer c.DisplayConfigurationIssues()
 
+	if len(args) > 0 && args[0] == scheduleRunNow {
+		return runScheduleNow(request)
+	}
+	if len(args) > 0 && args[0] == scheduleSimulate {
+		options, err := parseSimulateArgs(args[1:], time.Now())
+		if err != nil {
+			return err
+		}
+		return simulateSchedules(output, c, options)
+	}
+	if len(args) > 0 && args[0] == scheduleEnvDiff {
+		scheduler, profile, _, err := getScheduleJobs(c, flags)
+		if err != nil {
+			return err
+		}
+		global, err := c.GetGlobalSection()
+		if err != nil {
+			return err
+		}
+		return displayScheduleEnvDiff(output, scheduler, profile, global.PathPrepend)
+	}
+
 	type profileJobs struct {
 		scheduler schedule.SchedulerConfig
 		profile   string
 		jobs      []*config.ScheduleConfig
 	}
 
+	if flags.config == configFromStdin {
+		// the scheduled jobs would have no configuration file to load
+		return errors.New("cannot schedule profiles of a configuration read from stdin")
+	}
+
 	allJobs := make([]profileJobs, 0, 1)
 
 	// Step 1: Collect all jobs of all selected profiles
@@ -409,6 +767,9 @@ func createSchedule(_ io.Writer, request commandRequest) error {
 		profileFlags := flagsForProfile(flags, profileName)
 
 		scheduler, profile, jobs, err := getScheduleJobs(c, profileFlags)
+		if err == nil {
+			err = checkAbstractProfile(profile, "scheduled")
+		}
 		if err == nil {
 			err = requireScheduleJobs(jobs, profileFlags)
 
@@ -467,6 +828,34 @@ func removeSchedule(_ io.Writer, request commandRequest) error {
 	return nil
 }
 
+// runScheduleNow runs a scheduled job of a profile immediately: "schedule run-now [profile.]command"
+func runScheduleNow(request commandRequest) error {
+	flags := request.flags
+	if len(request.args) < 2 {
+		return fmt.Errorf("missing job to run: %s [profile.]command", scheduleRunNow)
+	}
+	command := request.args[1]
+	if profileName, commandName, found := strings.Cut(command, "."); found {
+		flags.name, command = profileName, commandName
+	}
+
+	scheduler, profile, schedules, err := getScheduleJobs(request.config, flags)
+	if err == nil {
+		err = checkAbstractProfile(profile, "run")
+	}
+	if err != nil {
+		return err
+	}
+	displayProfileDeprecationNotices(profile)
+
+	for _, scheduleConfig := range schedules {
+		if scheduleConfig.SubTitle == command {
+			return runScheduledJob(scheduler, scheduleConfig)
+		}
+	}
+	return fmt.Errorf("no schedule found for command '%s' in profile '%s'", command, flags.name)
+}
+
 func statusSchedule(w io.Writer, request commandRequest) error {
 	c := request.config
 	flags := request.flags
@@ -534,6 +923,14 @@ func getScheduleJobs(c *config.Config, flags commandLineFlags) (schedule.Schedul
 	return schedule.NewSchedulerConfig(global), profile, profile.Schedules(), nil
 }
 
+// checkAbstractProfile returns an error when the profile is abstract (it only exists to be inherited)
+func checkAbstractProfile(profile *config.Profile, action string) error {
+	if profile.Abstract {
+		return fmt.Errorf("profile '%s' is abstract: it can be inherited but cannot be %s", profile.Name, action)
+	}
+	return nil
+}
+
 func requireScheduleJobs(schedules []*config.ScheduleConfig, flags commandLineFlags) error {
 	if len(schedules) == 0 {
 		return fmt.Errorf("no schedule found for profile '%s'", flags.name)
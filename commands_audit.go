@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/creativeprojects/resticprofile/monitor/audit"
+)
+
+// auditLogCommand verifies the HMAC chain of the audit log
+func auditLogCommand(output io.Writer, request commandRequest) error {
+	c := request.config
+	args := request.args
+
+	global, err := c.GetGlobalSection()
+	if err != nil {
+		return fmt.Errorf("cannot load global configuration: %w", err)
+	}
+
+	action := "verify"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		action, args = args[0], args[1:]
+	}
+	if action != "verify" {
+		return fmt.Errorf("unknown audit action %q (expected verify)", action)
+	}
+
+	filename := global.AuditLog
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--file":
+			if i+1 >= len(args) {
+				return fmt.Errorf("missing value for %s", args[i])
+			}
+			filename = args[i+1]
+			i++
+		default:
+			return fmt.Errorf("unknown flag %s for audit", args[i])
+		}
+	}
+	if filename == "" {
+		return errors.New("no audit-log in the global section")
+	}
+	if global.AuditLogKeyFile == "" {
+		return errors.New("no audit-log-key-file in the global section: the audit log is not signed")
+	}
+	key, err := loadAuditKey(global.AuditLogKeyFile)
+	if err != nil {
+		return fmt.Errorf("cannot load the key of the audit log: %w", err)
+	}
+	count, err := audit.Verify(filename, key)
+	if err != nil {
+		return fmt.Errorf("audit log %q failed the verification after %d valid entries: %w", filename, count, err)
+	}
+	fmt.Fprintf(output, "audit log %q: %d entries verified\n", filename, count)
+	return nil
+}
+
+// loadAuditKey returns the content of the key file without the trailing spaces and new lines (no key when the file name is empty)
+func loadAuditKey(filename string) ([]byte, error) {
+	if filename == "" {
+		return nil, nil
+	}
+	key, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	key = bytes.TrimSpace(key)
+	if len(key) == 0 {
+		return nil, fmt.Errorf("%s: empty key", filename)
+	}
+	return key, nil
+}
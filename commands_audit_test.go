@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/monitor/audit"
+	"github.com/creativeprojects/resticprofile/platform"
+	"github.com/creativeprojects/resticprofile/util/redact"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readAuditLog(t *testing.T, filename string) []audit.Entry {
+	t.Helper()
+	content, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	entries := make([]audit.Entry, 0)
+	for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n") {
+		entry := audit.Entry{}
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestAuditShellCommand(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "audit.log")
+	auditLog = audit.NewLog(filename, []byte("key"))
+	defer func() { auditLog = nil }()
+	redact.Add("secret", "×××")
+	defer redact.Clear()
+
+	env := []string{"PROFILE_NAME=other", "PROFILE_NAME=profile", "PROFILE_COMMAND=backup"}
+	command := newShellCommand("echo", []string{"secret", "value"}, env, nil, false, nil, nil)
+	command.publicArgs = []string{"×××", "value"}
+	command.stdout = &bytes.Buffer{}
+	_, _, err := runShellCommand(command)
+	require.NoError(t, err)
+
+	command = newShellCommand("exit 3", nil, env, nil, false, nil, nil)
+	_, _, err = runShellCommand(command)
+	require.Error(t, err)
+
+	// nothing is executed in dry-run
+	command = newShellCommand("echo", nil, env, nil, true, nil, nil)
+	_, _, err = runShellCommand(command)
+	require.NoError(t, err)
+
+	entries := readAuditLog(t, filename)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "profile", entries[0].Profile)
+	assert.Equal(t, "backup", entries[0].Command)
+	assert.Equal(t, "echo", entries[0].Executable)
+	assert.Equal(t, []string{"×××", "value"}, entries[0].Args)
+	assert.Equal(t, 0, entries[0].ExitCode)
+	assert.Empty(t, entries[0].Error)
+	assert.Equal(t, os.Getpid(), entries[0].PID)
+
+	assert.Equal(t, "exit 3", entries[1].Executable)
+	assert.Equal(t, 3, entries[1].ExitCode)
+	assert.NotEmpty(t, entries[1].Error)
+
+	count, err := audit.Verify(filename, []byte("key"))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+}
+
+func TestAuditExternalCommand(t *testing.T) {
+	if platform.IsWindows() {
+		t.Skip("shell command")
+	}
+	filename := filepath.Join(t.TempDir(), "audit.log")
+	auditLog = audit.NewLog(filename, nil)
+	defer func() { auditLog = nil }()
+
+	start := time.Now()
+	err := exec.Command("sh", "-c", "exit 2").Run()
+	require.Error(t, err)
+	auditExternalCommand("cryptsetup", []string{"open", "/dev/sdb1", "backup"}, start, err)
+
+	entries := readAuditLog(t, filename)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "cryptsetup", entries[0].Executable)
+	assert.Equal(t, []string{"open", "/dev/sdb1", "backup"}, entries[0].Args)
+	assert.Equal(t, 2, entries[0].ExitCode)
+}
+
+func TestAuditVerifyCommand(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "audit.log")
+	keyFile := filepath.Join(dir, "audit.key")
+	require.NoError(t, os.WriteFile(keyFile, []byte("key\n"), 0600))
+	key, err := loadAuditKey(keyFile)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("key"), key)
+
+	log := audit.NewLog(filename, key)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, log.Add(audit.NewEntry()))
+	}
+
+	cfg, err := config.Load(bytes.NewBufferString(`
+version = "1"
+[global]
+audit-log = "`+filepath.ToSlash(filename)+`"
+audit-log-key-file = "`+filepath.ToSlash(keyFile)+`"
+`), "toml")
+	require.NoError(t, err)
+
+	output := &bytes.Buffer{}
+	err = auditLogCommand(output, commandRequest{config: cfg, args: []string{"verify"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "audit log \""+filepath.ToSlash(filename)+"\": 3 entries verified\n", output.String())
+
+	other := filepath.Join(dir, "other.log")
+	require.NoError(t, audit.NewLog(other, []byte("another key")).Add(audit.NewEntry()))
+	err = auditLogCommand(output, commandRequest{config: cfg, args: []string{"verify", "--file", other}})
+	assert.ErrorContains(t, err, "after 0 valid entries: line 1: invalid signature")
+
+	err = auditLogCommand(output, commandRequest{config: cfg, args: []string{"rotate"}})
+	assert.ErrorContains(t, err, "unknown audit action")
+}
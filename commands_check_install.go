@@ -0,0 +1,369 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"syscall"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/constants"
+	"github.com/creativeprojects/resticprofile/dial"
+	"github.com/creativeprojects/resticprofile/filesearch"
+	"github.com/creativeprojects/resticprofile/platform"
+	"github.com/creativeprojects/resticprofile/schedule"
+	"github.com/creativeprojects/resticprofile/win"
+)
+
+// Result of an installation check
+const (
+	installCheckOK      = "ok"
+	installCheckWarning = "warning"
+	installCheckError   = "error"
+)
+
+// installCheck is the result of one verification of "check-install"
+type installCheck struct {
+	status  string
+	name    string
+	message string
+	remedy  string // what to do when the status is not ok
+}
+
+// installDir is a directory resticprofile needs to write into
+type installDir struct {
+	purpose string
+	path    string
+}
+
+// checkInstallCommand verifies the installation of resticprofile: binaries, scheduler, elevation, fuse and directories
+func checkInstallCommand(output io.Writer, request commandRequest) error {
+	all := false
+	for _, arg := range request.args {
+		if arg != "--all" {
+			return fmt.Errorf("unknown flag %s for check-install", arg)
+		}
+		all = true
+	}
+
+	checks := []installCheck{checkExecutable()}
+	global := config.NewGlobal()
+	var profiles []*config.Profile
+	c, err := loadInstallConfiguration(request.flags)
+	if err != nil {
+		checks = append(checks, installCheck{
+			status:  installCheckWarning,
+			name:    "configuration",
+			message: err.Error(),
+			remedy:  "create a configuration file (\"resticprofile generate --example\") or select one with --config",
+		})
+	} else {
+		checks = append(checks, installCheck{status: installCheckOK, name: "configuration", message: c.GetConfigFile()})
+		if global, err = c.GetGlobalSection(); err != nil {
+			return fmt.Errorf("cannot load global configuration: %w", err)
+		}
+		profiles = installProfiles(c, request.flags.name, all)
+	}
+
+	checks = append(checks,
+		checkResticBinary(global.ResticBinary),
+		checkScheduler(global),
+		checkElevation(),
+		checkFuse(),
+	)
+	for _, dir := range installDirs(request.flags, profiles) {
+		checks = append(checks, checkWritableDir(dir))
+	}
+	return displayInstallChecks(output, checks)
+}
+
+// loadInstallConfiguration loads the configuration file when there is one
+func loadInstallConfiguration(flags commandLineFlags) (*config.Config, error) {
+	configFile, err := filesearch.FindConfigurationFile(flags.config)
+	if err != nil {
+		return nil, err
+	}
+	c, err := config.LoadFile(configFile, flags.format)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load configuration file: %w", err)
+	}
+	return c, nil
+}
+
+// installProfiles returns the selected profile, or all the profiles
+func installProfiles(c *config.Config, name string, all bool) []*config.Profile {
+	names := []string{name}
+	if all {
+		names = c.GetProfileNames()
+		sort.Strings(names)
+	}
+	profiles := make([]*config.Profile, 0, len(names))
+	for _, name := range names {
+		if profile, err := c.GetProfile(name); err == nil && profile != nil {
+			profiles = append(profiles, profile)
+		}
+	}
+	return profiles
+}
+
+// checkExecutable verifies the resticprofile binary can be run, and cannot be replaced by another user
+func checkExecutable() installCheck {
+	check := installCheck{name: "resticprofile binary"}
+	executable, err := os.Executable()
+	if err == nil {
+		executable, err = filepath.EvalSymlinks(executable)
+	}
+	var info fs.FileInfo
+	if err == nil {
+		info, err = os.Stat(executable)
+	}
+	if err != nil {
+		check.status, check.message = installCheckError, err.Error()
+		return check
+	}
+	check.status, check.message = installCheckOK, executable
+	if platform.IsWindows() {
+		return check
+	}
+	if info.Mode().Perm()&0o111 == 0 {
+		check.status = installCheckError
+		check.message = executable + " is not executable"
+		check.remedy = fmt.Sprintf("chmod +x %q", executable)
+	} else if info.Mode().Perm()&0o002 != 0 {
+		check.status = installCheckError
+		check.message = executable + " can be modified by any user"
+		check.remedy = fmt.Sprintf("chmod o-w %q", executable)
+	}
+	return check
+}
+
+// checkResticBinary verifies restic can be found
+func checkResticBinary(location string) installCheck {
+	check := installCheck{name: "restic binary"}
+	binary, err := filesearch.FindResticBinary(location)
+	if err != nil {
+		check.status, check.message = installCheckError, err.Error()
+		check.remedy = "install restic (https://restic.readthedocs.io/en/stable/020_installation.html) or set \"restic-binary\" in the global section"
+		return check
+	}
+	if path, err := exec.LookPath(binary); err == nil {
+		binary = path
+	}
+	check.status, check.message = installCheckOK, binary
+	return check
+}
+
+// checkScheduler verifies the scheduler selected in the global section is available
+func checkScheduler(global *config.Global) installCheck {
+	schedulerConfig := schedule.NewSchedulerConfig(global)
+	name := schedule.SchedulerType(schedulerConfig)
+	check := installCheck{name: "scheduler", status: installCheckOK, message: name}
+
+	handler := schedule.NewHandler(schedulerConfig)
+	if err := handler.Init(); err != nil {
+		check.status, check.message = installCheckWarning, fmt.Sprintf("%s: %s", name, err)
+		switch name {
+		case constants.SchedulerSystemd:
+			check.remedy = "schedules need systemd: install it, or select \"crond\" with the \"scheduler\" setting of the global section"
+		case constants.SchedulerCrond:
+			check.remedy = "schedules need a cron daemon: install cron (with the crontab command), or set the path of crontab in the \"scheduler\" setting (\"crond:/path/to/crontab\")"
+		case constants.SchedulerCrontab:
+			check.remedy = "schedules need a cron daemon: install cron, and make sure the directory of the crontab file exists (\"crontab:/etc/cron.d/resticprofile\")"
+		case constants.SchedulerWindows:
+			check.remedy = "schedules need the Task Scheduler service: make sure it is running (services.msc)"
+		default:
+			check.remedy = "schedules need the " + name + " scheduler"
+		}
+		return check
+	}
+	handler.Close()
+	return check
+}
+
+// checkElevation verifies resticprofile can get the privileges needed by the system schedules
+func checkElevation() installCheck {
+	check := installCheck{name: "elevation", status: installCheckOK}
+	if platform.IsWindows() {
+		if win.IsElevated() {
+			check.message = "running as administrator"
+		} else {
+			check.message = "administrator rights will be requested (UAC) for system schedules"
+		}
+		return check
+	}
+	if os.Geteuid() == 0 {
+		check.message = "running as root"
+		return check
+	}
+	if sudo, err := exec.LookPath("sudo"); err == nil {
+		check.message = "system schedules can be created with " + sudo
+		return check
+	}
+	check.status, check.message = installCheckWarning, "not running as root and sudo is not available"
+	check.remedy = "install sudo, or run resticprofile as root to create system schedules"
+	return check
+}
+
+// checkFuse verifies the FUSE support needed by "restic mount"
+func checkFuse() installCheck {
+	check := installCheck{name: "fuse (mount)", status: installCheckOK}
+	switch runtime.GOOS {
+	case "windows":
+		check.status, check.message = installCheckWarning, "restic mount is not available on Windows"
+	case "darwin":
+		for _, path := range []string{"/Library/Filesystems/macfuse.fs", "/Library/Filesystems/osxfuse.fs", "/Library/Filesystems/fuse-t.fs"} {
+			if _, err := os.Stat(path); err == nil {
+				check.message = path
+				return check
+			}
+		}
+		check.status, check.message = installCheckWarning, "macFUSE is not installed"
+		check.remedy = "install macFUSE (https://osxfuse.github.io/) or FUSE-T (https://www.fuse-t.org/) to use restic mount"
+	default:
+		if _, err := os.Stat("/dev/fuse"); err != nil {
+			check.status, check.message = installCheckWarning, "/dev/fuse is not available"
+			if runtime.GOOS == "linux" {
+				check.remedy = "install fuse (e.g. \"apt install fuse3\") and load the kernel module (\"modprobe fuse\") to use restic mount"
+			} else {
+				check.remedy = "load the fuse kernel module (e.g. \"kldload fusefs\") to use restic mount"
+			}
+			return check
+		}
+		for _, binary := range []string{"fusermount3", "fusermount"} {
+			if path, err := exec.LookPath(binary); err == nil {
+				check.message = path
+				return check
+			}
+		}
+		if runtime.GOOS == "linux" {
+			check.status, check.message = installCheckWarning, "fusermount is not installed"
+			check.remedy = "install fuse (e.g. \"apt install fuse3\") to unmount restic mounts as a user"
+			return check
+		}
+		check.message = "/dev/fuse"
+	}
+	return check
+}
+
+// installDirs returns the directories resticprofile and restic write into: temporary files, cache, logs, locks and status
+func installDirs(flags commandLineFlags, profiles []*config.Profile) []installDir {
+	dirs := []installDir{{purpose: "temporary directory", path: os.TempDir()}}
+	caches := make([]string, 0, len(profiles))
+	for _, profile := range profiles {
+		if profile.CacheDir != "" {
+			caches = append(caches, profile.CacheDir)
+		}
+	}
+	if len(caches) == 0 {
+		caches = append(caches, defaultResticCacheDir())
+	}
+	for _, cache := range caches {
+		if cache != "" {
+			dirs = append(dirs, installDir{purpose: "restic cache", path: cache})
+		}
+	}
+
+	addFile := func(purpose, file string) {
+		if file == "" {
+			return
+		}
+		if _, _, isURL := dial.GetAddr(file); isURL {
+			return
+		}
+		dirs = append(dirs, installDir{purpose: purpose, path: filepath.Dir(file)})
+	}
+	addFile("log", flags.log)
+	for _, profile := range profiles {
+		addFile("lock of profile '"+profile.Name+"'", profile.Lock)
+		addFile("status file of profile '"+profile.Name+"'", profile.StatusFile)
+		addFile("history of profile '"+profile.Name+"'", profile.HistoryFile)
+		schedules := profile.Schedules()
+		sort.Slice(schedules, func(i, j int) bool { return schedules[i].SubTitle < schedules[j].SubTitle })
+		for _, schedule := range schedules {
+			addFile("log of schedule "+schedule.Title+"/"+schedule.SubTitle, schedule.Log)
+		}
+	}
+	return dirs
+}
+
+// defaultResticCacheDir returns the cache directory restic uses when none is configured
+func defaultResticCacheDir() string {
+	if dir := os.Getenv("RESTIC_CACHE_DIR"); dir != "" {
+		return dir
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "restic")
+}
+
+// checkWritableDir verifies a file can be created in the directory (or in its closest existing parent when it doesn't exist yet)
+func checkWritableDir(dir installDir) installCheck {
+	check := installCheck{name: dir.purpose, message: dir.path}
+	existing := filepath.Clean(dir.path)
+	for {
+		info, err := os.Stat(existing)
+		if err == nil {
+			if !info.IsDir() {
+				check.status = installCheckError
+				check.message = existing + " is not a directory"
+				check.remedy = "remove the file or change the path in the configuration"
+				return check
+			}
+			break
+		}
+		parent := filepath.Dir(existing)
+		if !(errors.Is(err, fs.ErrNotExist) || errors.Is(err, syscall.ENOTDIR)) || parent == existing {
+			check.status, check.message = installCheckError, err.Error()
+			return check
+		}
+		existing = parent
+	}
+	file, err := os.CreateTemp(existing, ".resticprofile-check-*")
+	if err != nil {
+		check.status = installCheckError
+		check.message = fmt.Sprintf("%s is not writable: %s", existing, err)
+		if platform.IsWindows() {
+			check.remedy = "give write access to the folder (Properties > Security), or change the path in the configuration"
+		} else {
+			check.remedy = fmt.Sprintf("fix the owner or permissions of %q (chown/chmod), or change the path in the configuration", existing)
+		}
+		return check
+	}
+	_ = file.Close()
+	_ = os.Remove(file.Name())
+	check.status = installCheckOK
+	if existing != filepath.Clean(dir.path) {
+		check.message = dir.path + " (will be created)"
+	}
+	return check
+}
+
+// displayInstallChecks writes the result of the checks, and returns an error when at least one of them failed
+func displayInstallChecks(output io.Writer, checks []installCheck) error {
+	failed, warnings := 0, 0
+	for _, check := range checks {
+		switch check.status {
+		case installCheckError:
+			failed++
+		case installCheckWarning:
+			warnings++
+		}
+		fmt.Fprintf(output, "%-10s %s: %s\n", "["+check.status+"]", check.name, check.message)
+		if check.remedy != "" && check.status != installCheckOK {
+			fmt.Fprintf(output, "%-10s -> %s\n", "", check.remedy)
+		}
+	}
+	fmt.Fprintf(output, "\n%d check(s), %d warning(s), %d error(s)\n", len(checks), warnings, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d installation check(s) failed", failed)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/platform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckWritableDir(t *testing.T) {
+	dir := t.TempDir()
+
+	check := checkWritableDir(installDir{purpose: "test", path: dir})
+	assert.Equal(t, installCheckOK, check.status)
+	assert.Equal(t, dir, check.message)
+
+	missing := filepath.Join(dir, "sub", "dir")
+	check = checkWritableDir(installDir{purpose: "test", path: missing})
+	assert.Equal(t, installCheckOK, check.status)
+	assert.Equal(t, missing+" (will be created)", check.message)
+	assert.NoDirExists(t, missing)
+
+	file := filepath.Join(dir, "file")
+	require.NoError(t, os.WriteFile(file, []byte{}, 0o600))
+	check = checkWritableDir(installDir{purpose: "test", path: filepath.Join(file, "sub")})
+	assert.Equal(t, installCheckError, check.status)
+	assert.Contains(t, check.message, "is not a directory")
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "no file left behind")
+}
+
+func TestCheckReadOnlyDir(t *testing.T) {
+	if platform.IsWindows() || os.Geteuid() == 0 {
+		t.Skip("directory permissions are not enforced")
+	}
+	dir := filepath.Join(t.TempDir(), "readonly")
+	require.NoError(t, os.Mkdir(dir, 0o500))
+
+	check := checkWritableDir(installDir{purpose: "test", path: dir})
+	assert.Equal(t, installCheckError, check.status)
+	assert.Contains(t, check.remedy, "chmod")
+}
+
+func TestInstallDirs(t *testing.T) {
+	profile := config.NewProfile(nil, "name")
+	profile.Lock = "/var/lock/resticprofile/name.lock"
+	profile.StatusFile = "/var/lib/resticprofile/status.json"
+	profile.CacheDir = "/var/cache/restic"
+	flags := commandLineFlags{log: "udp://localhost:514"}
+
+	dirs := installDirs(flags, []*config.Profile{profile})
+	assert.Equal(t, []installDir{
+		{purpose: "temporary directory", path: os.TempDir()},
+		{purpose: "restic cache", path: "/var/cache/restic"},
+		{purpose: "lock of profile 'name'", path: filepath.Dir(profile.Lock)},
+		{purpose: "status file of profile 'name'", path: filepath.Dir(profile.StatusFile)},
+	}, dirs)
+
+	flags.log = "/var/log/resticprofile.log"
+	dirs = installDirs(flags, nil)
+	assert.Contains(t, dirs, installDir{purpose: "log", path: filepath.Dir(flags.log)})
+}
+
+func TestDisplayInstallChecks(t *testing.T) {
+	output := &bytes.Buffer{}
+	err := displayInstallChecks(output, []installCheck{
+		{status: installCheckOK, name: "first", message: "fine", remedy: "not displayed"},
+		{status: installCheckWarning, name: "second", message: "missing", remedy: "install it"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "[ok]       first: fine\n[warning]  second: missing\n           -> install it\n\n2 check(s), 1 warning(s), 0 error(s)\n", output.String())
+
+	output.Reset()
+	err = displayInstallChecks(output, []installCheck{{status: installCheckError, name: "third", message: "denied"}})
+	assert.EqualError(t, err, "1 installation check(s) failed")
+	assert.Contains(t, output.String(), "[error]    third: denied\n")
+}
+
+func TestCheckInstallUnknownFlag(t *testing.T) {
+	err := checkInstallCommand(&bytes.Buffer{}, commandRequest{args: []string{"--unknown"}})
+	assert.EqualError(t, err, "unknown flag --unknown for check-install")
+}
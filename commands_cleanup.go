@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/util"
+)
+
+// cleanupCommand removes the temporary files left behind by resticprofile processes that are no longer running
+func cleanupCommand(output io.Writer, request commandRequest) error {
+	if request.flags.dryRun {
+		for _, dir := range util.StaleTempDirs(processExists) {
+			fmt.Fprintf(output, "would remove %s\n", dir)
+		}
+		return nil
+	}
+	removed, err := util.RemoveStaleTempDirs(processExists)
+	for _, dir := range removed {
+		fmt.Fprintf(output, "removed %s\n", dir)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot remove temporary directory: %w", err)
+	}
+	if len(removed) == 0 {
+		fmt.Fprintln(output, "nothing to clean up")
+	}
+	return nil
+}
+
+// removeStaleTempDirs removes the temporary files of previous runs that didn't terminate properly (e.g. killed)
+func removeStaleTempDirs() {
+	removed, err := util.RemoveStaleTempDirs(processExists)
+	for _, dir := range removed {
+		clog.Debugf("removed temporary directory of a previous run: %s", dir)
+	}
+	if err != nil {
+		clog.Warningf("cannot remove temporary directory of a previous run: %s", err)
+	}
+}
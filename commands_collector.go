@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/monitor/collector"
+)
+
+const (
+	defaultCollectorListen   = ":8090"
+	defaultCollectorStore    = "collector.db"
+	collectorPasswordEnv     = "RESTICPROFILE_COLLECTOR_PASSWORD"
+	collectorShutdownTimeout = 10 * time.Second
+)
+
+// collectorCommand runs the HTTP server receiving the summaries sent by the hosts of a fleet
+func collectorCommand(_ io.Writer, request commandRequest) error {
+	listen := defaultCollectorListen
+	storeFile := defaultCollectorStore
+	password := os.Getenv(collectorPasswordEnv)
+
+	args := request.args
+	for i := 0; i < len(args); i++ {
+		if i+1 >= len(args) {
+			return fmt.Errorf("missing value for %s", args[i])
+		}
+		value := args[i+1]
+		switch args[i] {
+		case "--listen":
+			listen = value
+		case "--store":
+			storeFile = value
+		default:
+			return fmt.Errorf("unknown flag %s for collector", args[i])
+		}
+		i++
+	}
+
+	store := collector.NewStore(storeFile)
+	if err := store.Load(); err != nil {
+		return err
+	}
+	defer store.Close()
+	server := &http.Server{
+		Addr:              listen,
+		Handler:           collector.NewServer(store, password),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), collectorShutdownTimeout)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	if password == "" {
+		clog.Warningf("collector: no authentication (set %s to require a password)", collectorPasswordEnv)
+	}
+	clog.Infof("collector: listening on %s, saving runs to %q", listen, storeFile)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("collector: %w", err)
+	}
+	clog.Info("collector: stopped")
+	return nil
+}
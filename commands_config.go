@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"golang.org/x/exp/slices"
+)
+
+// configCommand displays information about the configuration files ("config hash")
+func configCommand(output io.Writer, request commandRequest) error {
+	args := request.args
+	action := ""
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		action, args = args[0], args[1:]
+	}
+	switch action {
+	case "hash":
+		return displayConfigHash(output, request, slices.Contains(args, "--templates"), slices.Contains(args, "--files"))
+	case "":
+		return fmt.Errorf("missing config action (expected hash)")
+	default:
+		return fmt.Errorf("unknown config action %q (expected hash)", action)
+	}
+}
+
+// displayConfigHash prints the hash of the configuration files, and optionally the hash of each file
+func displayConfigHash(output io.Writer, request commandRequest, rendered, files bool) error {
+	c := request.config
+	hash, err := c.GetHash(rendered)
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintln(output, hash)
+	if !files {
+		return nil
+	}
+	hashes, err := c.GetFileHashes(rendered)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(output, 0, 0, 2, ' ', 0)
+	for _, file := range hashes {
+		_, _ = fmt.Fprintf(w, "  %s\t%s\n", file.Hash, file.Name)
+	}
+	return w.Flush()
+}
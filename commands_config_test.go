@@ -0,0 +1,40 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigHashCommand(t *testing.T) {
+	cfg, err := config.Load(bytes.NewBufferString("[profile]\nrepository = \"local:/{{ .Profile.Name }}\"\n"), "toml")
+	require.NoError(t, err)
+
+	run := func(args ...string) (string, error) {
+		buffer := &bytes.Buffer{}
+		err := configCommand(buffer, commandRequest{config: cfg, args: args})
+		return buffer.String(), err
+	}
+
+	output, err := run("hash")
+	require.NoError(t, err)
+	assert.Regexp(t, `^sha256:[0-9a-f]{64}\n$`, output)
+	hash := strings.TrimSpace(output)
+	assert.NoError(t, cfg.CheckHash(hash))
+
+	output, err = run("hash", "--templates", "--files")
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	require.Len(t, lines, 2)
+	assert.Regexp(t, `^sha256-rendered:[0-9a-f]{64}$`, lines[0])
+	assert.Regexp(t, `^\s+[0-9a-f]{64}`, lines[1])
+
+	_, err = run()
+	assert.ErrorContains(t, err, "missing config action")
+	_, err = run("other")
+	assert.ErrorContains(t, err, "unknown config action")
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/util/shutdown"
+)
+
+const daemonUnmountHookTag = "daemon-unmount-hook"
+
+// daemonCommand runs the schedules of all the profiles in the foreground, without the scheduler of the operating system.
+// The configuration file is reloaded on SIGHUP, and the state of the schedules is saved in the status file of the profiles.
+func daemonCommand(_ io.Writer, request commandRequest) error {
+	if len(request.args) > 0 {
+		return fmt.Errorf("unknown flag %s for daemon", request.args[0])
+	}
+	scheduler, err := newContainerScheduler(request.config)
+	if err != nil {
+		return err
+	}
+
+	reaper := newOrphanReaper()
+	if os.Getpid() == 1 {
+		clog.Debug("running as PID 1: orphaned processes will be reaped")
+		reaper.start()
+	}
+	scheduler.start = containerJobCommand(request.flags, nil, "", reaper)
+	scheduler.saveStatus = true
+	// the background mounts started by the jobs are stopped with the daemon (using the last configuration loaded)
+	current := &atomic.Pointer[config.Config]{}
+	current.Store(request.config)
+	load := newDaemonLoader(request.config.GetConfigFile(), request.flags.format)
+	scheduler.load = func() (*config.Config, error) {
+		c, err := load()
+		if err == nil {
+			current.Store(c)
+		}
+		return c, err
+	}
+	shutdown.AddHook(func() { unmountAll(current.Load()) }, daemonUnmountHookTag)
+
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+	scheduler.reload = hangup
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	clog.Infof("daemon started with PID %d", os.Getpid())
+	if err = scheduler.run(ctx); err != nil {
+		return err
+	}
+	clog.Info("daemon stopped")
+	return nil
+}
+
+// newDaemonLoader returns a function loading the configuration file again
+func newDaemonLoader(configFile, format string) func() (*config.Config, error) {
+	return func() (*config.Config, error) {
+		if configFile == "" {
+			return nil, errors.New("the configuration was not loaded from a file")
+		}
+		return config.LoadFile(configFile, format)
+	}
+}
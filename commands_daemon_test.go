@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/monitor/status"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDaemonReloadConfig(t *testing.T) {
+	scheduler := newTestContainerScheduler(t)
+	require.Len(t, scheduler.jobs, 2)
+	backup := findContainerJob(scheduler, "home/backup")
+	require.NotNil(t, backup)
+	backup.Runs = 3
+	backup.Next = time.Now()
+
+	scheduler.load = func() (*config.Config, error) {
+		return config.Load(bytes.NewBufferString(`
+version: "2"
+profiles:
+  home:
+    backup:
+      schedule: "*-*-* 04:00"
+  web:
+    backup:
+      schedule: hourly
+`), config.FormatYAML)
+	}
+	scheduler.reloadConfig()
+
+	require.Len(t, scheduler.jobs, 2)
+	assert.Same(t, backup, findContainerJob(scheduler, "home/backup"))
+	assert.Equal(t, 3, backup.Runs)
+	assert.True(t, backup.Next.IsZero())
+	assert.Equal(t, []string{"*-*-* 04:00"}, backup.schedule.Schedules)
+	assert.NotNil(t, findContainerJob(scheduler, "web/backup"))
+
+	// the schedules are kept when the configuration cannot be loaded
+	scheduler.load = func() (*config.Config, error) {
+		return nil, errors.New("invalid configuration")
+	}
+	scheduler.reloadConfig()
+	require.Len(t, scheduler.jobs, 2)
+	assert.Same(t, backup, findContainerJob(scheduler, "home/backup"))
+}
+
+func findContainerJob(scheduler *containerScheduler, name string) *containerJob {
+	for _, job := range scheduler.jobs {
+		if job.Profile+"/"+job.Command == name {
+			return job
+		}
+	}
+	return nil
+}
+
+func TestDaemonRunReloadsOnSignal(t *testing.T) {
+	scheduler := newTestContainerScheduler(t)
+	loaded := make(chan struct{})
+	scheduler.load = func() (*config.Config, error) {
+		close(loaded)
+		return nil, errors.New("not needed")
+	}
+	reload := make(chan os.Signal, 1)
+	reload <- syscall.SIGHUP
+	scheduler.reload = reload
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- scheduler.run(ctx) }()
+
+	select {
+	case <-loaded:
+	case <-time.After(5 * time.Second):
+		t.Fatal("configuration not reloaded")
+	}
+	cancel()
+	assert.NoError(t, <-done)
+}
+
+func TestDaemonWriteStatus(t *testing.T) {
+	statusFile := filepath.Join(t.TempDir(), "status.json")
+	c, err := config.Load(bytes.NewBufferString(`
+version: "2"
+profiles:
+  home:
+    status-file: "`+filepath.ToSlash(statusFile)+`"
+    backup:
+      schedule: "*-*-* 02:00"
+    check:
+      schedule: "Mon 03:00"
+`), config.FormatYAML)
+	require.NoError(t, err)
+	scheduler, err := newContainerScheduler(c)
+	require.NoError(t, err)
+
+	// nothing is saved in container mode
+	scheduler.writeStatus(false)
+	assert.NoFileExists(t, statusFile)
+
+	scheduler.saveStatus = true
+	now := time.Date(2026, time.March, 2, 1, 0, 0, 0, time.Local)
+	job := scheduler.nextJob(now)
+	require.NotNil(t, job)
+	scheduler.running = job
+	scheduler.writeStatus(false)
+
+	daemon := status.NewStatus(statusFile).Load().Profile("home").Daemon
+	require.NotNil(t, daemon)
+	assert.Equal(t, os.Getpid(), daemon.PID)
+	assert.WithinDuration(t, scheduler.started, daemon.Started, time.Second)
+	require.Len(t, daemon.Schedules, 2)
+	assert.True(t, daemon.Schedules["backup"].Running)
+	assert.True(t, daemon.Schedules["backup"].Next.Equal(time.Date(2026, time.March, 2, 2, 0, 0, 0, time.Local)))
+	assert.False(t, daemon.Schedules["check"].Running)
+
+	scheduler.writeStatus(true)
+	assert.Nil(t, status.NewStatus(statusFile).Load().Profile("home").Daemon)
+}
+
+func TestDaemonCommand(t *testing.T) {
+	c, err := config.Load(bytes.NewBufferString(`version: "2"`), config.FormatYAML)
+	require.NoError(t, err)
+
+	err = daemonCommand(nil, commandRequest{config: c, args: []string{"--unknown"}})
+	assert.ErrorContains(t, err, "unknown flag --unknown")
+
+	err = daemonCommand(nil, commandRequest{config: c})
+	assert.ErrorContains(t, err, "no schedule found")
+
+	_, err = newDaemonLoader("", "")()
+	assert.ErrorContains(t, err, "not loaded from a file")
+}
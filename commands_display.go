@@ -19,6 +19,8 @@ import (
 	"github.com/creativeprojects/resticprofile/util/collect"
 	"github.com/fatih/color"
 	"github.com/mattn/go-colorable"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
 )
 
 var (
@@ -259,6 +261,9 @@ func displayHelpCommand(output io.Writer, request commandRequest) error {
 }
 
 func displayVersion(output io.Writer, request commandRequest) error {
+	if hasJSONFlag(request.args) {
+		return displayVersionJSON(output, request)
+	}
 	out, closer := displayWriter(output, request.flags)
 	defer closer()
 
@@ -290,16 +295,20 @@ func displayVersion(output io.Writer, request commandRequest) error {
 }
 
 func displayProfilesCommand(output io.Writer, request commandRequest) error {
-	displayProfiles(output, request.config, request.flags)
+	showAbstract := slices.Contains(request.args, "--all")
+	if hasJSONFlag(request.args) {
+		return displayProfilesJSON(output, request.config, showAbstract)
+	}
+	displayProfiles(output, request.config, request.flags, showAbstract)
 	displayGroups(output, request.config, request.flags)
 	return nil
 }
 
-func displayProfiles(output io.Writer, configuration *config.Config, flags commandLineFlags) {
+func displayProfiles(output io.Writer, configuration *config.Config, flags commandLineFlags, showAbstract bool) {
 	out, closer := displayWriter(output, flags)
 	defer closer()
 
-	profiles := configuration.GetProfiles()
+	profiles := getDisplayedProfiles(configuration, showAbstract)
 	keys := sortedProfileKeys(profiles)
 	if len(profiles) == 0 {
 		out("\nThere's no available profile in the configuration\n")
@@ -308,16 +317,29 @@ func displayProfiles(output io.Writer, configuration *config.Config, flags comma
 		for _, name := range keys {
 			sections := profiles[name].DefinedCommands()
 			sort.Strings(sections)
+			description := profiles[name].Description
+			if profiles[name].Abstract {
+				description = strings.TrimSpace("(abstract) " + description)
+			}
 			if len(sections) == 0 {
-				out("\t%s:\t(n/a)\t%s\n", name, profiles[name].Description)
+				out("\t%s:\t(n/a)\t%s\n", name, description)
 			} else {
-				out("\t%s:\t(%s)\t%s\n", name, ansiCyan(strings.Join(sections, ", ")), profiles[name].Description)
+				out("\t%s:\t(%s)\t%s\n", name, ansiCyan(strings.Join(sections, ", ")), description)
 			}
 		}
 	}
 	out("\n")
 }
 
+// getDisplayedProfiles returns the profiles of the configuration, without the abstract profiles unless showAbstract is set
+func getDisplayedProfiles(configuration *config.Config, showAbstract bool) map[string]*config.Profile {
+	profiles := configuration.GetProfiles()
+	if !showAbstract {
+		maps.DeleteFunc(profiles, func(_ string, profile *config.Profile) bool { return profile.Abstract })
+	}
+	return profiles
+}
+
 func displayGroups(output io.Writer, configuration *config.Config, flags commandLineFlags) {
 	out, closer := displayWriter(output, flags)
 	defer closer()
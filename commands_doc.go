@@ -0,0 +1,355 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/constants"
+	"golang.org/x/exp/maps"
+)
+
+// docCommand renders the Markdown documentation of the selected profile (or of all profiles)
+// from the resolved configuration: description, sources, retention, schedules, monitoring and hooks
+func docCommand(output io.Writer, request commandRequest) error {
+	for _, arg := range request.args {
+		if arg != "--all" {
+			return fmt.Errorf("unknown flag %s for doc", arg)
+		}
+	}
+	c := request.config
+	names := selectProfiles(c, request.flags, request.args)
+	sort.Strings(names)
+
+	profiles := make([]*config.Profile, 0, len(names))
+	for _, name := range names {
+		profile, err := c.GetProfile(name)
+		if err != nil {
+			if errors.Is(err, config.ErrNotFound) {
+				return fmt.Errorf("profile '%s' not found", name)
+			}
+			return fmt.Errorf("cannot load profile '%s': %w", name, err)
+		}
+		profiles = append(profiles, profile)
+	}
+	return writeProfilesDoc(output, c.GetConfigFile(), profiles, time.Now())
+}
+
+// writeProfilesDoc writes the documentation of the profiles. Confidential values are masked.
+func writeProfilesDoc(output io.Writer, configFile string, profiles []*config.Profile, now time.Time) error {
+	doc := &markdownWriter{output: output}
+	doc.line("# Backup profiles")
+	doc.line("")
+	doc.linef("Generated by resticprofile %s from %s on %s.", version, mdCode(configFile), now.Format("2006-01-02 15:04 MST"))
+	for _, profile := range profiles {
+		doc.line("")
+		writeProfileDoc(doc, profile, now)
+	}
+	return doc.err
+}
+
+func writeProfileDoc(doc *markdownWriter, profile *config.Profile, now time.Time) {
+	doc.linef("## Profile %s", mdCode(profile.Name))
+	if profile.Description != "" {
+		doc.line("")
+		doc.line(profile.Description)
+	}
+
+	doc.line("")
+	doc.line("| Setting | Value |")
+	doc.line("|---------|-------|")
+	doc.row("Repository", mdCode(profile.Repository.String()))
+	if profile.RepositoryFile != "" {
+		doc.row("Repository file", mdCode(profile.RepositoryFile))
+	}
+	for _, name := range profile.GetRepositoryNames() {
+		if repository := profile.GetRepository(name); repository != nil {
+			location := repository.Repository.String()
+			if location == "" {
+				location = repository.RepositoryFile
+			}
+			mode := config.RepositoryModeBackup
+			if repository.IsCopy() {
+				mode = config.RepositoryModeCopy
+			}
+			doc.row("Repository "+mdCode(name), mdCode(location)+" ("+mode+")")
+		}
+	}
+	switch {
+	case profile.PasswordFile != "":
+		doc.row("Password", "file "+mdCode(profile.PasswordFile))
+	case profile.PasswordCommand != "":
+		doc.row("Password", "command "+mdCode(profile.PasswordCommand))
+	}
+	if profile.Inherit != "" {
+		doc.row("Inherits", mdCode(profile.Inherit))
+	}
+	if len(profile.Labels) > 0 {
+		labels := make([]string, 0, len(profile.Labels))
+		for _, name := range sortedKeys(profile.Labels) {
+			labels = append(labels, mdCode(name+"="+profile.Labels[name]))
+		}
+		doc.row("Labels", strings.Join(labels, " "))
+	}
+	if commands := profile.DefinedCommands(); len(commands) > 0 {
+		doc.row("Commands", strings.Join(commands, ", "))
+	}
+
+	writeSourcesDoc(doc, profile)
+	writeRetentionDoc(doc, profile)
+	writeSchedulesDoc(doc, profile, now)
+	writeMonitoringDoc(doc, profile)
+	writeHooksDoc(doc, profile)
+}
+
+func writeSourcesDoc(doc *markdownWriter, profile *config.Profile) {
+	if profile.Backup == nil {
+		return
+	}
+	doc.line("")
+	doc.line("### Sources")
+	doc.line("")
+	sources := profile.GetBackupSource()
+	if profile.Backup.UseStdin || len(profile.Backup.StdinCommand) > 0 {
+		for _, command := range profile.Backup.StdinCommand {
+			doc.linef("* standard output of %s", mdCode(command))
+		}
+		if len(profile.Backup.StdinCommand) == 0 {
+			doc.line("* standard input")
+		}
+	}
+	for _, source := range sources {
+		doc.linef("* %s", mdCode(source))
+	}
+	for _, source := range profile.Backup.SourceVerbatim {
+		doc.linef("* %s", mdCode(source))
+	}
+	for _, file := range profile.Backup.FilesFrom {
+		doc.linef("* paths listed in %s", mdCode(file))
+	}
+	if len(sources)+len(profile.Backup.SourceVerbatim)+len(profile.Backup.FilesFrom)+len(profile.Backup.StdinCommand) == 0 && !profile.Backup.UseStdin {
+		doc.line("* current directory")
+	}
+
+	excludes := make([]string, 0, len(profile.Backup.Exclude)+len(profile.Backup.Iexclude)+len(profile.Backup.ExcludeFile))
+	for _, exclude := range profile.Backup.Exclude {
+		excludes = append(excludes, mdCode(exclude))
+	}
+	for _, exclude := range profile.Backup.Iexclude {
+		excludes = append(excludes, mdCode(exclude)+" (case insensitive)")
+	}
+	for _, file := range profile.Backup.ExcludeFile {
+		excludes = append(excludes, "patterns of "+mdCode(file))
+	}
+	if profile.Backup.ExcludeFromGitignore {
+		excludes = append(excludes, "rules of the `.gitignore` files of the sources")
+	}
+	for _, file := range profile.Backup.ExcludeBorgPatterns {
+		excludes = append(excludes, "borg patterns of "+mdCode(file))
+	}
+	if len(excludes) > 0 {
+		doc.line("")
+		doc.line("Excluded:")
+		doc.line("")
+		for _, exclude := range excludes {
+			doc.linef("* %s", exclude)
+		}
+	}
+}
+
+// retentionFlag returns true for the flags of the retention section describing the retention policy
+func retentionFlag(name string) bool {
+	return strings.HasPrefix(name, "keep-") || name == "group-by" || name == constants.CommandPrune
+}
+
+func writeRetentionDoc(doc *markdownWriter, profile *config.Profile) {
+	if profile.Retention == nil {
+		return
+	}
+	flags := profile.GetRetentionFlags().ToMap()
+	names := make([]string, 0, len(flags))
+	for _, name := range maps.Keys(flags) {
+		if retentionFlag(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	if len(names) == 0 {
+		return
+	}
+	doc.line("")
+	doc.line("### Retention")
+	doc.line("")
+	switch {
+	case profile.Retention.BeforeBackup && profile.Retention.AfterBackup:
+		doc.line("Applied before and after each backup.")
+		doc.line("")
+	case profile.Retention.BeforeBackup:
+		doc.line("Applied before each backup.")
+		doc.line("")
+	case profile.Retention.AfterBackup:
+		doc.line("Applied after each successful backup.")
+		doc.line("")
+	}
+	doc.line("| Rule | Value |")
+	doc.line("|------|-------|")
+	for _, name := range names {
+		doc.row(name, mdCode(strings.Join(flags[name], ", ")))
+	}
+}
+
+func writeSchedulesDoc(doc *markdownWriter, profile *config.Profile, now time.Time) {
+	schedules := profile.Schedules()
+	if len(schedules) == 0 {
+		return
+	}
+	sort.Slice(schedules, func(i, j int) bool { return schedules[i].SubTitle < schedules[j].SubTitle })
+	doc.line("")
+	doc.line("### Schedules")
+	doc.line("")
+	doc.line("| Command | Schedule | Permission | Next run |")
+	doc.line("|---------|----------|------------|----------|")
+	for _, schedule := range schedules {
+		times := make([]string, 0, len(schedule.Schedules))
+		for _, definition := range schedule.Schedules {
+			times = append(times, mdCode(definition))
+		}
+		permission := schedule.Permission
+		if permission == "" {
+			permission = "auto"
+		}
+		next := "-"
+		if run := newScheduleJSON(schedule, now).Next; run != nil {
+			next = run.Format("2006-01-02 15:04")
+		}
+		doc.linef("| %s | %s | %s | %s |", schedule.SubTitle, strings.Join(times, " "), permission, next)
+	}
+}
+
+func writeMonitoringDoc(doc *markdownWriter, profile *config.Profile) {
+	var rows [][2]string
+	add := func(name, value string) {
+		if value != "" {
+			rows = append(rows, [2]string{name, mdCode(value)})
+		}
+	}
+	add("Status file", profile.StatusFile)
+	add("History file", profile.HistoryFile)
+	add("Prometheus file", profile.PrometheusSaveToFile)
+	add("Prometheus push gateway", profile.PrometheusPush.String())
+	add("Collector", profile.CollectorURL.String())
+	add("OpenTelemetry", profile.OtelEndpoint.String())
+	if profile.MQTT != nil {
+		add("MQTT broker", profile.MQTT.Broker.String())
+	}
+
+	for _, command := range profile.DefinedCommands() {
+		monitoring := profile.GetMonitoringSections(command)
+		for _, target := range []struct {
+			name     string
+			sections []config.SendMonitoringSection
+		}{
+			{"send-before", monitoring.SendBefore},
+			{"send-after", monitoring.SendAfter},
+			{"send-after-fail", monitoring.SendAfterFail},
+			{"send-finally", monitoring.SendFinally},
+			{"heartbeat", monitoring.Heartbeat},
+		} {
+			for _, section := range target.sections {
+				method := section.Method
+				if method == "" {
+					method = "GET"
+				}
+				rows = append(rows, [2]string{command + " " + target.name, method + " " + mdCode(section.URL.String())})
+			}
+		}
+		if !monitoring.Healthchecks.IsEmpty() {
+			url := monitoring.Healthchecks.GetPingURL(profile.Name, command, config.HealthchecksPingSuccess)
+			rows = append(rows, [2]string{command + " healthchecks", mdCode(url.String())})
+		}
+	}
+	if len(rows) == 0 {
+		return
+	}
+	doc.line("")
+	doc.line("### Monitoring")
+	doc.line("")
+	doc.line("| Target | Endpoint |")
+	doc.line("|--------|----------|")
+	for _, row := range rows {
+		doc.row(row[0], row[1])
+	}
+}
+
+func writeHooksDoc(doc *markdownWriter, profile *config.Profile) {
+	var rows [][2]string
+	addHooks := func(location string, hooks config.RunShellCommandsSection) {
+		for _, hook := range []struct {
+			name     string
+			commands []string
+		}{
+			{"run-before", hooks.RunBefore},
+			{"run-after", hooks.RunAfter},
+			{"run-after-fail", hooks.RunAfterFail},
+			{"run-finally", hooks.RunFinally},
+		} {
+			for _, command := range hook.commands {
+				rows = append(rows, [2]string{strings.TrimSpace(location + " " + hook.name), mdCode(command)})
+			}
+		}
+	}
+	addHooks("profile", profile.RunShellCommandsSection)
+	for _, command := range profile.DefinedCommands() {
+		if section, found := config.GetSectionWith[config.RunShellCommands](profile, command); found {
+			addHooks(command, *section.GetRunShellCommands())
+		}
+	}
+	if len(rows) == 0 {
+		return
+	}
+	doc.line("")
+	doc.line("### Hooks")
+	doc.line("")
+	doc.line("| Hook | Command |")
+	doc.line("|------|---------|")
+	for _, row := range rows {
+		doc.row(row[0], row[1])
+	}
+}
+
+// markdownWriter writes lines of markdown, keeping the first error
+type markdownWriter struct {
+	output io.Writer
+	err    error
+}
+
+func (w *markdownWriter) line(text string) {
+	if w.err == nil {
+		_, w.err = fmt.Fprintln(w.output, text)
+	}
+}
+
+func (w *markdownWriter) linef(format string, args ...any) {
+	w.line(fmt.Sprintf(format, args...))
+}
+
+func (w *markdownWriter) row(name, value string) {
+	w.linef("| %s | %s |", name, value)
+}
+
+// mdCode returns the value as inline code, escaping the characters breaking a table
+func mdCode(value string) string {
+	if value == "" {
+		return "-"
+	}
+	value = strings.ReplaceAll(value, "|", "\\|")
+	value = strings.ReplaceAll(value, "\n", " ")
+	if strings.Contains(value, "`") {
+		return "`` " + value + " ``"
+	}
+	return "`" + value + "`"
+}
@@ -0,0 +1,312 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/constants"
+	"github.com/creativeprojects/resticprofile/filesearch"
+	"github.com/creativeprojects/resticprofile/secret"
+	"github.com/creativeprojects/resticprofile/shell"
+	"github.com/creativeprojects/resticprofile/util"
+)
+
+// cacheDirTag is the signature of the CACHEDIR.TAG files excluded by "exclude-caches"
+const cacheDirTag = "Signature: 8a477f597d28d172789f06886806bc55"
+
+// sourceEstimate is the result of walking the backup source of a profile
+type sourceEstimate struct {
+	files    int
+	dirs     int
+	bytes    uint64
+	excluded int
+	errors   int
+}
+
+// estimateFilter applies the exclusion flags of the backup section, the way restic does
+type estimateFilter struct {
+	exclude         []string
+	iexclude        []string
+	excludeIfExists []string
+	excludeCaches   bool
+	excludeLarger   int64
+}
+
+// estimateBackup walks the backup source of the profile and displays the number of files and the total size
+// that restic would read. With "--dry-run", it also runs "restic backup --dry-run" for a dedup-aware estimate.
+func estimateBackup(output io.Writer, request commandRequest) error {
+	c := request.config
+	flags := request.flags
+
+	profile, err := c.GetProfile(flags.name)
+	if err != nil {
+		if errors.Is(err, config.ErrNotFound) {
+			return fmt.Errorf("profile '%s' not found", flags.name)
+		}
+		return fmt.Errorf("cannot load profile '%s': %w", flags.name, err)
+	}
+	dryRun := false
+	for _, arg := range request.args {
+		if arg != "--dry-run" {
+			return fmt.Errorf("unknown flag %s for estimate", arg)
+		}
+		dryRun = true
+	}
+	if profile.Backup != nil && profile.Backup.UseStdin {
+		return fmt.Errorf("profile '%s' backs up stdin: its size cannot be estimated", profile.Name)
+	}
+	sources := append([]string{}, profile.GetBackupSource()...)
+	if profile.Backup != nil {
+		sources = append(append(sources, profile.Backup.SourceVerbatim...), profile.Backup.SourceRaw...)
+	}
+	if len(sources) == 0 {
+		return fmt.Errorf("profile '%s' has no backup source", profile.Name)
+	}
+
+	filter, err := newEstimateFilter(profile.GetCommandFlags(constants.CommandBackup))
+	if err != nil {
+		return err
+	}
+	patterns, err := convertedExcludes(profile.Name, profile.Backup, profile.GetBackupSource())
+	if err != nil {
+		return err
+	}
+	filter.exclude = append(filter.exclude, patterns...)
+	estimate := estimateSources(sources, filter)
+	fmt.Fprintf(output, "Backup source of profile '%s':\n", profile.Name)
+	fmt.Fprintf(output, "  files:       %d\n", estimate.files)
+	fmt.Fprintf(output, "  directories: %d\n", estimate.dirs)
+	fmt.Fprintf(output, "  total size:  %s\n", util.FormatBytes(estimate.bytes))
+	fmt.Fprintf(output, "  excluded:    %d file(s) or directory(ies)\n", estimate.excluded)
+	if estimate.errors > 0 {
+		fmt.Fprintf(output, "  unreadable:  %d file(s) or directory(ies)\n", estimate.errors)
+	}
+
+	if !dryRun {
+		return nil
+	}
+	global, err := c.GetGlobalSection()
+	if err != nil {
+		return fmt.Errorf("cannot load global section: %w", err)
+	}
+	resticBinary, err := filesearch.FindResticBinary(global.ResticBinary)
+	if err != nil {
+		return fmt.Errorf("cannot find restic: %w", err)
+	}
+	if err = profile.ResolveSecrets(secret.DefaultResolver()); err != nil {
+		return err
+	}
+	config.RedactConfidentialValues(profile)
+	fmt.Fprintf(output, "\nRunning restic backup --dry-run for an estimate of the data added to the repository:\n")
+	wrapper := newResticWrapper(global, resticBinary, false, profile, constants.CommandBackup, []string{"--dry-run"}, nil)
+	args := profile.GetCommandFlags(constants.CommandBackup)
+	cleanup, err := wrapper.generateExcludeFile(args)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	rCommand := wrapper.prepareCommand(constants.CommandBackup, args, true)
+	rCommand.stdout = output
+	_, _, err = runShellCommand(rCommand)
+	return err
+}
+
+// newEstimateFilter creates the filter from the flags of the backup command
+func newEstimateFilter(args *shell.Args) (*estimateFilter, error) {
+	values := func(name string) (values []string) {
+		list, _ := args.Get(name)
+		for _, arg := range list {
+			values = append(values, arg.Value())
+		}
+		return
+	}
+	filter := &estimateFilter{
+		exclude:         values("exclude"),
+		iexclude:        values("iexclude"),
+		excludeIfExists: values("exclude-if-present"),
+	}
+	for name, target := range map[string]*[]string{"exclude-file": &filter.exclude, "iexclude-file": &filter.iexclude} {
+		for _, filename := range values(name) {
+			patterns, err := readExcludeFile(filename)
+			if err != nil {
+				return nil, err
+			}
+			*target = append(*target, patterns...)
+		}
+	}
+	if _, found := args.Get("exclude-caches"); found {
+		filter.excludeCaches = true
+	}
+	if sizes := values("exclude-larger-than"); len(sizes) > 0 {
+		size, err := parseResticSize(sizes[len(sizes)-1])
+		if err != nil {
+			return nil, err
+		}
+		filter.excludeLarger = size
+	}
+	return filter, nil
+}
+
+// readExcludeFile returns the patterns of an exclude file: empty lines and comments are ignored and environment variables are expanded
+func readExcludeFile(filename string) (patterns []string, err error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read exclude file: %w", err)
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, os.ExpandEnv(line))
+	}
+	return patterns, scanner.Err()
+}
+
+// parseResticSize parses a size in the format of restic (bytes, or with a suffix k, M, G or T)
+func parseResticSize(value string) (int64, error) {
+	value = strings.TrimSpace(value)
+	multiplier := int64(1)
+	if value != "" {
+		switch strings.ToUpper(value[len(value)-1:]) {
+		case "K":
+			multiplier = 1 << 10
+		case "M":
+			multiplier = 1 << 20
+		case "G":
+			multiplier = 1 << 30
+		case "T":
+			multiplier = 1 << 40
+		}
+		if multiplier > 1 {
+			value = value[:len(value)-1]
+		}
+	}
+	size, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || size < 0 {
+		return 0, fmt.Errorf("invalid size %q", value)
+	}
+	return size * multiplier, nil
+}
+
+// estimateSources walks the sources and counts the files and directories not excluded by the filter
+func estimateSources(sources []string, filter *estimateFilter) (estimate sourceEstimate) {
+	for _, source := range sources {
+		_ = filepath.WalkDir(source, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				clog.Debugf("cannot read %s: %s", path, err)
+				estimate.errors++
+				return nil
+			}
+			if filter.excludes(path, entry) {
+				estimate.excluded++
+				if entry.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if entry.IsDir() {
+				estimate.dirs++
+				return nil
+			}
+			estimate.files++
+			if entry.Type().IsRegular() {
+				if info, err := entry.Info(); err == nil {
+					estimate.bytes += uint64(info.Size())
+				}
+			}
+			return nil
+		})
+	}
+	return
+}
+
+// excludes returns true when the file or directory is excluded from the backup
+func (f *estimateFilter) excludes(path string, entry fs.DirEntry) bool {
+	if matchExcludePatterns(f.exclude, path, false) || matchExcludePatterns(f.iexclude, path, true) {
+		return true
+	}
+	if entry.IsDir() {
+		if f.excludeCaches && isCacheDir(path) {
+			return true
+		}
+		for _, name := range f.excludeIfExists {
+			if _, err := os.Lstat(filepath.Join(path, name)); err == nil {
+				return true
+			}
+		}
+	} else if f.excludeLarger > 0 && entry.Type().IsRegular() {
+		if info, err := entry.Info(); err == nil && info.Size() > f.excludeLarger {
+			return true
+		}
+	}
+	return false
+}
+
+func isCacheDir(dir string) bool {
+	content, err := os.ReadFile(filepath.Join(dir, "CACHEDIR.TAG"))
+	return err == nil && strings.HasPrefix(string(content), cacheDirTag)
+}
+
+// matchExcludePatterns returns true when the path is excluded by the patterns. A pattern starting with "!" includes
+// the paths it matches again, and the last matching pattern wins.
+func matchExcludePatterns(patterns []string, path string, ignoreCase bool) (excluded bool) {
+	if ignoreCase {
+		path = strings.ToLower(path)
+	}
+	for _, pattern := range patterns {
+		negate := strings.HasPrefix(pattern, "!")
+		pattern = strings.TrimPrefix(pattern, "!")
+		if ignoreCase {
+			pattern = strings.ToLower(pattern)
+		}
+		if matchExcludePattern(pattern, path) {
+			excluded = !negate
+		}
+	}
+	return
+}
+
+// matchExcludePattern matches the path against a restic pattern: a pattern not starting with a separator
+// can match the end of the path, and "**" matches any number of directories
+func matchExcludePattern(pattern, path string) bool {
+	pattern = filepath.ToSlash(filepath.Clean(pattern))
+	path = filepath.ToSlash(filepath.Clean(path))
+	patternParts := strings.Split(strings.TrimPrefix(pattern, "/"), "/")
+	if !strings.HasPrefix(pattern, "/") {
+		patternParts = append([]string{"**"}, patternParts...)
+	}
+	return matchPathParts(patternParts, strings.Split(strings.TrimPrefix(path, "/"), "/"))
+}
+
+func matchPathParts(patternParts, pathParts []string) bool {
+	if len(patternParts) == 0 {
+		return len(pathParts) == 0
+	}
+	if patternParts[0] == "**" {
+		for i := 0; i <= len(pathParts); i++ {
+			if matchPathParts(patternParts[1:], pathParts[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(pathParts) == 0 {
+		return false
+	}
+	if matched, err := filepath.Match(patternParts[0], pathParts[0]); err != nil || !matched {
+		return false
+	}
+	return matchPathParts(patternParts[1:], pathParts[1:])
+}
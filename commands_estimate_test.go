@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchExcludePattern(t *testing.T) {
+	testCases := []struct {
+		pattern string
+		path    string
+		match   bool
+	}{
+		{"*.tmp", "/home/user/file.tmp", true},
+		{"*.tmp", "/home/user/file.txt", false},
+		{"node_modules", "/home/user/project/node_modules", true},
+		{"project/node_modules", "/home/user/project/node_modules", true},
+		{"/home/user/project", "/home/user/project", true},
+		{"/user/project", "/home/user/project", false},
+		{"/home/**/cache", "/home/user/.local/cache", true},
+		{"/home/**/cache", "/home/cache", true},
+		{"/home/*/cache", "/home/user/.local/cache", false},
+		{"/home/user", "/home/user/project", false}, // children are not walked when the directory is excluded
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.pattern+" "+testCase.path, func(t *testing.T) {
+			assert.Equal(t, testCase.match, matchExcludePattern(testCase.pattern, testCase.path))
+		})
+	}
+
+	assert.True(t, matchExcludePatterns([]string{"*.TMP"}, "/home/file.tmp", true))
+	assert.False(t, matchExcludePatterns([]string{"*.TMP"}, "/home/file.tmp", false))
+	assert.False(t, matchExcludePatterns([]string{"*.tmp", "!keep.tmp"}, "/home/keep.tmp", false))
+	assert.True(t, matchExcludePatterns([]string{"*.tmp", "!keep.tmp"}, "/home/other.tmp", false))
+}
+
+func TestParseResticSize(t *testing.T) {
+	for value, expected := range map[string]int64{"100": 100, "2k": 2048, "1M": 1 << 20, "3G": 3 << 30, "1t": 1 << 40} {
+		size, err := parseResticSize(value)
+		require.NoError(t, err, value)
+		assert.Equal(t, expected, size, value)
+	}
+	for _, value := range []string{"", "M", "1X", "-1k"} {
+		_, err := parseResticSize(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestEstimateBackup(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]int{
+		"source/a.txt":              10,
+		"source/b.tmp":              20,
+		"source/Sub/c.TXT":          30,
+		"source/sub2/big.bin":       5000,
+		"source/cache/CACHEDIR.TAG": len(cacheDirTag),
+		"source/cache/data":         40,
+		"source/skip/.nobackup":     0,
+		"source/skip/data":          50,
+		"source/logs/app.log":       60,
+		"excludes.txt":              0,
+	}
+	for name, size := range files {
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(filename), 0o700))
+		content := strings.Repeat("x", size)
+		if strings.HasSuffix(name, "CACHEDIR.TAG") {
+			content = cacheDirTag
+		}
+		require.NoError(t, os.WriteFile(filename, []byte(content), 0o600))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "excludes.txt"), []byte("# comment\n\nlogs\n"), 0o600))
+
+	testConfig := fmt.Sprintf(`
+[profile]
+repository = "local:/backup"
+[profile.backup]
+source = [%q]
+exclude = ["*.tmp"]
+iexclude = ["sub"]
+exclude-file = [%q]
+exclude-caches = true
+exclude-if-present = ".nobackup"
+exclude-larger-than = "1k"
+[piped.backup]
+stdin = true
+`, filepath.Join(dir, "source"), filepath.Join(dir, "excludes.txt"))
+	c, err := config.Load(bytes.NewBufferString(testConfig), "toml")
+	require.NoError(t, err)
+
+	buffer := &bytes.Buffer{}
+	err = estimateBackup(buffer, commandRequest{config: c, flags: commandLineFlags{name: "profile"}})
+	require.NoError(t, err)
+	assert.Contains(t, buffer.String(), "files:       1\n")
+	assert.Contains(t, buffer.String(), "directories: 2\n") // source and sub2
+	assert.Contains(t, buffer.String(), "total size:  10 B\n")
+	assert.Contains(t, buffer.String(), "excluded:    6 ")
+
+	err = estimateBackup(buffer, commandRequest{config: c, flags: commandLineFlags{name: "piped"}})
+	assert.ErrorContains(t, err, "backs up stdin")
+	err = estimateBackup(buffer, commandRequest{config: c, flags: commandLineFlags{name: "profile"}, args: []string{"--unknown"}})
+	assert.Error(t, err)
+}
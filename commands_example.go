@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/creativeprojects/resticprofile/util/templates"
+)
+
+//go:embed contrib/templates/examples/*.yaml
+var exampleTemplates embed.FS
+
+const exampleTemplatesDir = "contrib/templates/examples"
+
+// exampleData contains the parameters of an example configuration
+type exampleData struct {
+	Profile      string
+	Repository   string
+	PasswordFile string
+	Source       []string
+	Schedule     string
+}
+
+// exampleDefaults contains the default values of each example
+var exampleDefaults = map[string]exampleData{
+	"s3-systemd": {
+		Profile:      "default",
+		Repository:   "s3:s3.amazonaws.com/bucket-name/restic",
+		PasswordFile: "/etc/resticprofile/password.txt",
+		Source:       []string{"/etc", "/home", "/root", "/var/lib"},
+		Schedule:     "*-*-* 01:30",
+	},
+	"b2-launchd": {
+		Profile:      "default",
+		Repository:   "b2:bucket-name:restic",
+		PasswordFile: "password.txt",
+		Source:       []string{"{{ .Env.HOME }}"},
+		Schedule:     "hourly",
+	},
+	"local-windows": {
+		Profile:      "default",
+		Repository:   "D:/restic",
+		PasswordFile: "password.txt",
+		Source:       []string{"{{ .Env.USERPROFILE }}"},
+		Schedule:     "*-*-* 12:30",
+	},
+}
+
+// listExamples returns the names of the examples available
+func listExamples() (names []string) {
+	for name := range exampleDefaults {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return
+}
+
+// generateExample writes an example configuration built from a template compiled into the binary
+func generateExample(output io.Writer, args []string) error {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		_, err := fmt.Fprintf(output, "available examples: %s\n", strings.Join(listExamples(), ", "))
+		return err
+	}
+	name := args[0]
+	data, found := exampleDefaults[name]
+	if !found {
+		return fmt.Errorf("unknown example %q, available examples are: %s", name, strings.Join(listExamples(), ", "))
+	}
+
+	var sources []string
+	for i := 1; i < len(args); i++ {
+		if i+1 >= len(args) {
+			return fmt.Errorf("missing value for %s", args[i])
+		}
+		value := args[i+1]
+		switch args[i] {
+		case "--profile":
+			data.Profile = value
+		case "--repository":
+			data.Repository = value
+		case "--password-file":
+			data.PasswordFile = value
+		case "--source":
+			sources = append(sources, value)
+		case "--schedule":
+			data.Schedule = value
+		default:
+			return fmt.Errorf("unknown flag %s for example %q", args[i], name)
+		}
+		i++
+	}
+	if len(sources) > 0 {
+		data.Source = sources
+	}
+
+	filename := path.Join(exampleTemplatesDir, name+".yaml")
+	// the generated configuration can contain templates itself: use different delimiters
+	tpl, err := templates.New(name, map[string]any{"quote": yamlQuote}).Delims("[[", "]]").ParseFS(exampleTemplates, filename)
+	if err != nil {
+		return fmt.Errorf("parsing failed: %w", err)
+	}
+	return tpl.ExecuteTemplate(output, path.Base(filename), data)
+}
+
+// yamlQuote returns the value as a YAML double-quoted string: a JSON string is a valid YAML scalar whatever it contains
+func yamlQuote(value string) (string, error) {
+	buffer := &bytes.Buffer{}
+	encoder := json.NewEncoder(buffer)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buffer.String()), nil
+}
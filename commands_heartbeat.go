@@ -0,0 +1,182 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/creativeprojects/resticprofile/calendar"
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/constants"
+	"github.com/creativeprojects/resticprofile/monitor/history"
+	"github.com/creativeprojects/resticprofile/monitor/hook"
+	"github.com/creativeprojects/resticprofile/monitor/status"
+	"github.com/creativeprojects/resticprofile/util"
+)
+
+const (
+	// heartbeatGrace is added to the interval of the schedule, for the time a run takes
+	heartbeatGrace = time.Hour
+	// heartbeatScheduleWindow is the period of the schedule used to find its longest interval
+	heartbeatScheduleWindow = 35 * 24 * time.Hour
+)
+
+// heartbeatCommand verifies the heartbeat chain of the selected profile: the time since the last successful run
+// of each command with a heartbeat, then a ping to each heartbeat URL (unless "--no-ping")
+func heartbeatCommand(output io.Writer, request commandRequest) error {
+	c := request.config
+	flags := request.flags
+
+	ping := true
+	for _, arg := range request.args {
+		if arg != "--no-ping" {
+			return fmt.Errorf("unknown flag %s for heartbeat", arg)
+		}
+		ping = false
+	}
+	global, err := c.GetGlobalSection()
+	if err != nil {
+		return fmt.Errorf("cannot load global configuration: %w", err)
+	}
+	profile, err := c.GetProfile(flags.name)
+	if err != nil {
+		if errors.Is(err, config.ErrNotFound) {
+			return fmt.Errorf("profile '%s' not found", flags.name)
+		}
+		return fmt.Errorf("cannot load profile '%s': %w", flags.name, err)
+	}
+	commands := heartbeatCommands(profile)
+	if len(commands) == 0 {
+		return fmt.Errorf("profile '%s' has no heartbeat", profile.Name)
+	}
+
+	sender := hook.NewSender(global.CACertificates, "resticprofile/"+version, global.SenderTimeout, flags.dryRun)
+	now := time.Now()
+	failed := 0
+	for _, command := range commands {
+		monitoring := profile.GetMonitoringSections(command)
+		if !checkLastHeartbeat(output, profile, command, monitoring.HeartbeatAge, now) {
+			failed++
+		}
+		if !ping {
+			continue
+		}
+		for _, section := range monitoring.Heartbeat {
+			err = sender.Send(section, hook.Context{ProfileName: profile.Name, ProfileCommand: command})
+			if err != nil {
+				failed++
+				fmt.Fprintf(output, "  ping %s: %s\n", section.URL.String(), err)
+				continue
+			}
+			fmt.Fprintf(output, "  ping %s: ok\n", section.URL.String())
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d heartbeat check(s) failed", failed)
+	}
+	return nil
+}
+
+// heartbeatCommands returns the sorted names of the commands of the profile having a heartbeat
+func heartbeatCommands(profile *config.Profile) []string {
+	commands := make([]string, 0)
+	for command, section := range config.GetSectionsWith[config.Monitoring](profile) {
+		if len(section.GetSendMonitoring().Heartbeat) > 0 {
+			commands = append(commands, command)
+		}
+	}
+	sort.Strings(commands)
+	return commands
+}
+
+// checkLastHeartbeat displays the time since the last successful run of the command, and returns false when it's older than expected
+func checkLastHeartbeat(output io.Writer, profile *config.Profile, command string, maxAge time.Duration, now time.Time) bool {
+	if maxAge <= 0 {
+		maxAge = scheduleMaxInterval(profile, command, now)
+	}
+	last, found := lastSuccessfulRun(profile, command)
+	switch {
+	case !found && profile.HistoryFile == "" && profile.StatusFile == "":
+		fmt.Fprintf(output, "%s: no \"history-file\" nor \"status-file\" to find the last successful run\n", command)
+		return true
+	case !found:
+		fmt.Fprintf(output, "%s: no successful run found\n", command)
+		return maxAge <= 0
+	}
+	age := now.Sub(last).Truncate(time.Second)
+	fmt.Fprintf(output, "%s: last successful run %s (%s ago)", command, last.Format(time.RFC3339), util.FormatDuration(age))
+	if maxAge > 0 && age > maxAge {
+		fmt.Fprintf(output, ", MISSED: expected every %s\n", util.FormatDuration(maxAge))
+		return false
+	}
+	fmt.Fprintln(output)
+	return true
+}
+
+// lastSuccessfulRun returns the time of the last successful run of the command, from the history file or from the status file
+func lastSuccessfulRun(profile *config.Profile, command string) (time.Time, bool) {
+	if profile.HistoryFile != "" {
+		entries, err := history.NewHistory(profile.HistoryFile).List(history.Filter{Profile: profile.Name, Command: command})
+		if err == nil {
+			for index := len(entries) - 1; index >= 0; index-- {
+				if entries[index].Success {
+					return entries[index].Time, true
+				}
+			}
+		}
+	}
+	if profile.StatusFile != "" {
+		var last *status.CommandStatus
+		profileStatus := status.NewStatus(profile.StatusFile).Load().Profile(profile.Name)
+		switch command {
+		case constants.CommandBackup:
+			if profileStatus.Backup != nil {
+				last = &profileStatus.Backup.CommandStatus
+			}
+		case constants.CommandCheck:
+			last = profileStatus.Check
+		case constants.CommandForget, constants.SectionConfigurationRetention:
+			last = profileStatus.Retention
+		}
+		if last != nil && last.Success {
+			return last.Time, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// scheduleMaxInterval returns the longest interval between two runs of the schedule of the command (plus a grace period),
+// or zero when the command has no schedule
+func scheduleMaxInterval(profile *config.Profile, command string, now time.Time) time.Duration {
+	for _, schedule := range profile.Schedules() {
+		if schedule.SubTitle != command {
+			continue
+		}
+		location, err := schedule.GetTimezone()
+		if err != nil {
+			return 0
+		}
+		var runs []time.Time
+		for _, definition := range schedule.Schedules {
+			event := calendar.NewEvent()
+			if err = event.Parse(definition); err != nil {
+				return 0
+			}
+			runs = append(runs, event.GetAllInBetween(now.Add(-heartbeatScheduleWindow).In(location), now.In(location))...)
+		}
+		sort.Slice(runs, func(i, j int) bool { return runs[i].Before(runs[j]) })
+		longest := time.Duration(0)
+		for index := 1; index < len(runs); index++ {
+			if interval := runs[index].Sub(runs[index-1]); interval > longest {
+				longest = interval
+			}
+		}
+		if longest == 0 {
+			return 0
+		}
+		return longest + heartbeatGrace
+	}
+	return 0
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/monitor/history"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeartbeatCommands(t *testing.T) {
+	profile := config.NewProfile(nil, "name")
+	profile.Backup = &config.BackupSection{}
+	profile.Backup.Heartbeat = []config.SendMonitoringSection{{URL: config.NewConfidentialValue("http://localhost")}}
+	profile.Check = &config.SectionWithScheduleAndMonitoring{}
+
+	assert.Equal(t, []string{"backup"}, heartbeatCommands(profile))
+}
+
+func TestScheduleMaxInterval(t *testing.T) {
+	cfg, err := config.Load(bytes.NewBufferString(`
+version = "1"
+[name.backup]
+schedule = ["*-*-* 02:00", "*-*-* 14:00"]
+`), "toml")
+	require.NoError(t, err)
+	profile, err := cfg.GetProfile("name")
+	require.NoError(t, err)
+	now := time.Date(2026, time.March, 10, 12, 0, 0, 0, time.Local)
+
+	assert.Equal(t, 12*time.Hour+heartbeatGrace, scheduleMaxInterval(profile, "backup", now))
+	assert.Zero(t, scheduleMaxInterval(profile, "check", now))
+}
+
+func TestCheckLastHeartbeat(t *testing.T) {
+	profile := config.NewProfile(nil, "name")
+	profile.HistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	now := time.Now()
+	output := &bytes.Buffer{}
+
+	assert.False(t, checkLastHeartbeat(output, profile, "backup", time.Hour, now))
+	assert.Equal(t, "backup: no successful run found\n", output.String())
+
+	h := history.NewHistory(profile.HistoryFile)
+	require.NoError(t, h.Add(history.Entry{Time: now.Add(-2 * time.Hour), Profile: "name", Command: "backup", Success: true}))
+	require.NoError(t, h.Add(history.Entry{Time: now.Add(-time.Minute), Profile: "name", Command: "backup", Success: false}))
+
+	output.Reset()
+	assert.False(t, checkLastHeartbeat(output, profile, "backup", time.Hour, now))
+	assert.Contains(t, output.String(), "(2h0m0s ago), MISSED: expected every 1h0m0s\n")
+
+	output.Reset()
+	assert.True(t, checkLastHeartbeat(output, profile, "backup", 3*time.Hour, now))
+	assert.NotContains(t, output.String(), "MISSED")
+}
+
+func TestHeartbeatCommandPing(t *testing.T) {
+	pings := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pings++
+	}))
+	defer server.Close()
+
+	cfg, err := config.Load(bytes.NewBufferString(`
+version = "1"
+[[name.backup.heartbeat]]
+url = "`+server.URL+`"
+`), "toml")
+	require.NoError(t, err)
+
+	output := &bytes.Buffer{}
+	err = heartbeatCommand(output, commandRequest{config: cfg, flags: commandLineFlags{name: "name"}})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, pings)
+	assert.Contains(t, output.String(), "ping "+server.URL+": ok")
+
+	err = heartbeatCommand(output, commandRequest{config: cfg, flags: commandLineFlags{name: "name"}, args: []string{"--no-ping"}})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, pings)
+
+	err = heartbeatCommand(output, commandRequest{config: cfg, flags: commandLineFlags{name: "other"}})
+	assert.EqualError(t, err, "profile 'other' not found")
+
+	err = heartbeatCommand(output, commandRequest{config: cfg, args: []string{"--unknown"}})
+	assert.EqualError(t, err, "unknown flag --unknown for heartbeat")
+}
+
+func TestSendHeartbeatOnlyOnSuccess(t *testing.T) {
+	pings := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pings++
+	}))
+	defer server.Close()
+
+	profile := config.NewProfile(nil, "name")
+	profile.Backup = &config.BackupSection{}
+	profile.Backup.Heartbeat = []config.SendMonitoringSection{{URL: config.NewConfidentialValue(server.URL)}}
+
+	wrapper := newResticWrapper(nil, mockBinary, false, profile, "backup", nil, nil)
+	require.NoError(t, wrapper.runProfile())
+	assert.Equal(t, 1, pings)
+
+	profile.Backup.RunBefore = []string{"exit 1"}
+	wrapper = newResticWrapper(nil, mockBinary, false, profile, "backup", nil, nil)
+	require.Error(t, wrapper.runProfile())
+	assert.Equal(t, 1, pings)
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/monitor/history"
+	"github.com/creativeprojects/resticprofile/util"
+)
+
+const defaultHistoryLimit = 20
+
+// historyCommand lists, shows or prunes the history of the commands run by a profile
+func historyCommand(output io.Writer, request commandRequest) error {
+	c := request.config
+	flags := request.flags
+	args := request.args
+
+	defer c.DisplayConfigurationIssues()
+
+	profile, err := c.GetProfile(flags.name)
+	if err != nil {
+		if errors.Is(err, config.ErrNotFound) {
+			return fmt.Errorf("profile '%s' not found", flags.name)
+		}
+		return fmt.Errorf("cannot load profile '%s': %w", flags.name, err)
+	}
+	if profile.HistoryFile == "" {
+		return fmt.Errorf("profile '%s' has no history-file", profile.Name)
+	}
+	store := history.NewHistory(profile.HistoryFile)
+
+	action := "list"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		action, args = args[0], args[1:]
+	}
+	switch action {
+	case "list":
+		return listHistory(output, store, profile, args)
+	case "show":
+		return showHistory(output, store, profile, args)
+	case "prune":
+		if flags.readOnly {
+			return errors.New("history prune is not allowed in read-only mode")
+		}
+		return pruneHistory(output, store, profile, args)
+	default:
+		return fmt.Errorf("unknown history action %q (expected list, show or prune)", action)
+	}
+}
+
+func listHistory(output io.Writer, store *history.History, profile *config.Profile, args []string) error {
+	filter := history.Filter{Profile: profile.Name}
+	limit := defaultHistoryLimit
+	asJSON := false
+	for i := 0; i < len(args); i++ {
+		if args[i] == jsonFlag {
+			asJSON = true
+			continue
+		}
+		if i+1 >= len(args) {
+			return fmt.Errorf("missing value for %s", args[i])
+		}
+		value := args[i+1]
+		switch args[i] {
+		case "--command":
+			filter.Command = value
+		case "--limit":
+			var err error
+			if limit, err = strconv.Atoi(value); err != nil || limit < 0 {
+				return fmt.Errorf("invalid limit: %q", value)
+			}
+		default:
+			return fmt.Errorf("unknown flag %s for history list", args[i])
+		}
+		i++
+	}
+
+	entries, err := store.List(filter)
+	if err != nil {
+		return err
+	}
+	first := 0
+	if limit > 0 && len(entries) > limit {
+		first = len(entries) - limit
+	}
+	if asJSON {
+		return writeJSON(output, entries[first:])
+	}
+	if len(entries) == 0 {
+		_, err = fmt.Fprintf(output, "no history for profile '%s'\n", profile.Name)
+		return err
+	}
+
+	w := tabwriter.NewWriter(output, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "ID\tTime\tCommand\tResult\tDuration\tAdded")
+	for index := first; index < len(entries); index++ {
+		entry := entries[index]
+		added := ""
+		if entry.BytesAdded > 0 || entry.FilesNew > 0 {
+			added = util.FormatBytes(entry.BytesAdded)
+		}
+		_, _ = fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n",
+			index+1,
+			entry.Time.Format("2006-01-02 15:04:05"),
+			entry.Command,
+			historyResult(entry),
+			util.FormatDuration((time.Duration(entry.Duration * float64(time.Second))).Round(time.Second)),
+			added)
+	}
+	return w.Flush()
+}
+
+func showHistory(output io.Writer, store *history.History, profile *config.Profile, args []string) error {
+	if len(args) == 0 {
+		return errors.New("missing history ID (from the history list)")
+	}
+	entries, err := store.List(history.Filter{Profile: profile.Name})
+	if err != nil {
+		return err
+	}
+	id, err := strconv.Atoi(args[0])
+	if err != nil || id < 1 || id > len(entries) {
+		return fmt.Errorf("history ID %q not found", args[0])
+	}
+	entry := entries[id-1]
+	if len(args) > 1 && args[1] == jsonFlag {
+		return writeJSON(output, entry)
+	}
+
+	w := tabwriter.NewWriter(output, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(w, "Profile:\t%s\n", entry.Profile)
+	_, _ = fmt.Fprintf(w, "Command:\t%s\n", entry.Command)
+	_, _ = fmt.Fprintf(w, "Time:\t%s\n", entry.Time.Format(time.RFC3339))
+	_, _ = fmt.Fprintf(w, "Duration:\t%s\n", util.FormatDuration(time.Duration(entry.Duration*float64(time.Second)).Round(time.Millisecond)))
+	_, _ = fmt.Fprintf(w, "Result:\t%s\n", historyResult(entry))
+	if entry.Error != "" {
+		_, _ = fmt.Fprintf(w, "Error:\t%s\n", entry.Error)
+	}
+	if entry.FilesTotal > 0 || entry.BytesTotal > 0 {
+		_, _ = fmt.Fprintf(w, "Files:\t%d new, %d changed, %d total\n", entry.FilesNew, entry.FilesChanged, entry.FilesTotal)
+		_, _ = fmt.Fprintf(w, "Bytes:\t%s added, %s total\n", util.FormatBytes(entry.BytesAdded), util.FormatBytes(entry.BytesTotal))
+	}
+	if entry.ConfigHash != "" {
+		_, _ = fmt.Fprintf(w, "Configuration:\t%s\n", entry.ConfigHash)
+	}
+	if err = w.Flush(); err != nil {
+		return err
+	}
+	if entry.Stderr != "" {
+		_, err = fmt.Fprintf(output, "\nError output:\n%s\n", strings.TrimRight(entry.Stderr, "\n"))
+	}
+	return err
+}
+
+func pruneHistory(output io.Writer, store *history.History, profile *config.Profile, args []string) error {
+	retention := profile.HistoryRetention
+	if len(args) > 0 {
+		if args[0] != "--older-than" || len(args) < 2 {
+			return errors.New("expected flag --older-than <duration>")
+		}
+		var err error
+		if retention, err = time.ParseDuration(args[1]); err != nil || retention <= 0 {
+			return fmt.Errorf("invalid duration: %q", args[1])
+		}
+	}
+	if retention <= 0 {
+		return fmt.Errorf("profile '%s' has no history-retention, please specify --older-than <duration>", profile.Name)
+	}
+	removed, err := store.Prune(time.Now().Add(-retention))
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(output, "removed %d entries older than %s\n", removed, retention)
+	return err
+}
+
+func historyResult(entry history.Entry) string {
+	switch {
+	case entry.Success:
+		return "success"
+	case entry.Warning:
+		return "warning"
+	default:
+		return "error"
+	}
+}
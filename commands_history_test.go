@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/monitor/history"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistoryCommand(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "history.jsonl")
+	testConfig := fmt.Sprintf(`
+[profile]
+history-file = %q
+[other]
+`, filename)
+	cfg, err := config.Load(bytes.NewBufferString(testConfig), "toml")
+	require.NoError(t, err)
+
+	store := history.NewHistory(filename)
+	now := time.Now()
+	require.NoError(t, store.Add(history.Entry{Time: now.Add(-72 * time.Hour), Profile: "profile", Command: "backup", Success: true, Duration: 12, BytesAdded: 2048, FilesNew: 1}))
+	require.NoError(t, store.Add(history.Entry{Time: now.Add(-time.Hour), Profile: "profile", Command: "check", Error: "exit status 1", Stderr: "repository is locked"}))
+	require.NoError(t, store.Add(history.Entry{Time: now, Profile: "another", Command: "backup", Success: true}))
+
+	run := func(name string, args ...string) (string, error) {
+		buffer := &bytes.Buffer{}
+		err := historyCommand(buffer, commandRequest{config: cfg, flags: commandLineFlags{name: name}, args: args})
+		return buffer.String(), err
+	}
+
+	t.Run("list", func(t *testing.T) {
+		output, err := run("profile")
+		require.NoError(t, err)
+		lines := strings.Split(strings.TrimSpace(output), "\n")
+		require.Len(t, lines, 3)
+		assert.Regexp(t, `^1\s+.+\s+backup\s+success\s+12s\s+2\.0 KiB$`, lines[1])
+		assert.Regexp(t, `^2\s+.+\s+check\s+error\s+0s`, lines[2])
+
+		output, err = run("profile", "list", "--command", "check", "--limit", "1")
+		require.NoError(t, err)
+		assert.Equal(t, 2, strings.Count(output, "\n"))
+	})
+
+	t.Run("show", func(t *testing.T) {
+		output, err := run("profile", "show", "2")
+		require.NoError(t, err)
+		assert.Contains(t, output, "exit status 1")
+		assert.Contains(t, output, "repository is locked")
+
+		_, err = run("profile", "show", "3")
+		assert.Error(t, err)
+	})
+
+	t.Run("prune", func(t *testing.T) {
+		_, err := run("profile", "prune")
+		assert.ErrorContains(t, err, "no history-retention")
+
+		output, err := run("profile", "prune", "--older-than", "24h")
+		require.NoError(t, err)
+		assert.Equal(t, "removed 1 entries older than 24h0m0s\n", output)
+	})
+
+	t.Run("no history", func(t *testing.T) {
+		_, err := run("other")
+		assert.ErrorContains(t, err, "has no history-file")
+	})
+}
@@ -0,0 +1,399 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/exclude"
+	"gopkg.in/yaml.v3"
+)
+
+const defaultImportProfile = "default"
+
+// importCommand translates the configuration of another backup tool into a resticprofile configuration ("import borgmatic <file>")
+func importCommand(output io.Writer, request commandRequest) error {
+	args := request.args
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return errors.New("missing import format (expected borgmatic)")
+	}
+	format, args := args[0], args[1:]
+	if format != "borgmatic" {
+		return fmt.Errorf("unknown import format %q (expected borgmatic)", format)
+	}
+	filename, profileName := "", defaultImportProfile
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--profile":
+			if i+1 >= len(args) {
+				return errors.New("missing value for --profile")
+			}
+			i++
+			profileName = args[i]
+		case strings.HasPrefix(args[i], "-"):
+			return fmt.Errorf("unknown flag %s for import", args[i])
+		case filename == "":
+			filename = args[i]
+		default:
+			return fmt.Errorf("unexpected argument %q", args[i])
+		}
+	}
+	if filename == "" {
+		return errors.New("missing borgmatic configuration file")
+	}
+	file, err := os.Open(filename)
+	if err != nil {
+		return fmt.Errorf("cannot read borgmatic configuration: %w", err)
+	}
+	defer file.Close()
+	return importBorgmatic(output, file, filename, profileName)
+}
+
+// borgmaticConfig contains the options of borgmatic translated into resticprofile. The options are read
+// at the root of the file (borgmatic >= 1.8) or in the sections of the older versions (location, storage, retention, consistency and hooks).
+type borgmaticConfig struct {
+	SourceDirectories     []string              `yaml:"source_directories"`
+	Repositories          []borgmaticRepository `yaml:"repositories"`
+	Patterns              []string              `yaml:"patterns"`
+	PatternsFrom          []string              `yaml:"patterns_from"`
+	ExcludePatterns       []string              `yaml:"exclude_patterns"`
+	ExcludeFrom           []string              `yaml:"exclude_from"`
+	ExcludeCaches         bool                  `yaml:"exclude_caches"`
+	ExcludeIfPresent      stringList            `yaml:"exclude_if_present"`
+	ExcludeNodump         bool                  `yaml:"exclude_nodump"`
+	OneFileSystem         bool                  `yaml:"one_file_system"`
+	EncryptionPasscommand string                `yaml:"encryption_passcommand"`
+	EncryptionPassphrase  string                `yaml:"encryption_passphrase"`
+	KeepWithin            string                `yaml:"keep_within"`
+	KeepSecondly          int                   `yaml:"keep_secondly"`
+	KeepMinutely          int                   `yaml:"keep_minutely"`
+	KeepHourly            int                   `yaml:"keep_hourly"`
+	KeepDaily             int                   `yaml:"keep_daily"`
+	KeepWeekly            int                   `yaml:"keep_weekly"`
+	KeepMonthly           int                   `yaml:"keep_monthly"`
+	KeepYearly            int                   `yaml:"keep_yearly"`
+	Checks                []borgmaticCheck      `yaml:"checks"`
+	BeforeEverything      []string              `yaml:"before_everything"`
+	BeforeActions         []string              `yaml:"before_actions"`
+	BeforeBackup          []string              `yaml:"before_backup"`
+	AfterBackup           []string              `yaml:"after_backup"`
+	AfterActions          []string              `yaml:"after_actions"`
+	AfterEverything       []string              `yaml:"after_everything"`
+	OnError               []string              `yaml:"on_error"`
+	Healthchecks          borgmaticHealthchecks `yaml:"healthchecks"`
+}
+
+// borgmaticSections are the sections of the configuration of borgmatic before version 1.8
+var borgmaticSections = []string{"location", "storage", "retention", "consistency", "hooks"}
+
+// borgmaticRepository is a repository given as a path, or as a path with a label
+type borgmaticRepository struct {
+	Path  string `yaml:"path"`
+	Label string `yaml:"label"`
+}
+
+func (r *borgmaticRepository) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return node.Decode(&r.Path)
+	}
+	type repository borgmaticRepository
+	return node.Decode((*repository)(r))
+}
+
+// borgmaticCheck is a consistency check given by its name, or as a map with a name
+type borgmaticCheck struct {
+	Name string `yaml:"name"`
+}
+
+func (c *borgmaticCheck) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return node.Decode(&c.Name)
+	}
+	type check borgmaticCheck
+	return node.Decode((*check)(c))
+}
+
+// borgmaticHealthchecks is the healthchecks hook given as a ping URL, or as a map with a ping URL
+type borgmaticHealthchecks struct {
+	PingURL string `yaml:"ping_url"`
+}
+
+func (h *borgmaticHealthchecks) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return node.Decode(&h.PingURL)
+	}
+	type healthchecks borgmaticHealthchecks
+	return node.Decode((*healthchecks)(h))
+}
+
+// stringList is a list of strings which can also be given as a single string
+type stringList []string
+
+func (l *stringList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*l = stringList{node.Value}
+		return nil
+	}
+	return node.Decode((*[]string)(l))
+}
+
+// importedConfig is the resticprofile configuration (version 2) generated from the borgmatic configuration
+type importedConfig struct {
+	Version  string                      `yaml:"version"`
+	Profiles map[string]*importedProfile `yaml:"profiles"`
+	Groups   map[string]*importedGroup   `yaml:"groups,omitempty"`
+}
+
+type importedGroup struct {
+	Profiles []string `yaml:"profiles"`
+}
+
+type importedProfile struct {
+	Inherit         string             `yaml:"inherit,omitempty"`
+	Repository      string             `yaml:"repository"`
+	PasswordCommand string             `yaml:"password-command,omitempty"`
+	Env             map[string]string  `yaml:"env,omitempty"`
+	RunBefore       []string           `yaml:"run-before,omitempty"`
+	RunAfter        []string           `yaml:"run-after,omitempty"`
+	RunAfterFail    []string           `yaml:"run-after-fail,omitempty"`
+	Backup          *importedBackup    `yaml:"backup,omitempty"`
+	Retention       *importedRetention `yaml:"retention,omitempty"`
+	Check           *importedCheck     `yaml:"check,omitempty"`
+}
+
+type importedBackup struct {
+	Source              []string              `yaml:"source,omitempty"`
+	Exclude             []string              `yaml:"exclude,omitempty"`
+	ExcludeBorgPatterns []string              `yaml:"exclude-borg-patterns,omitempty"`
+	ExcludeCaches       bool                  `yaml:"exclude-caches,omitempty"`
+	ExcludeIfPresent    []string              `yaml:"exclude-if-present,omitempty"`
+	OneFileSystem       bool                  `yaml:"one-file-system,omitempty"`
+	CheckAfter          bool                  `yaml:"check-after,omitempty"`
+	RunBefore           []string              `yaml:"run-before,omitempty"`
+	RunAfter            []string              `yaml:"run-after,omitempty"`
+	Healthchecks        *importedHealthchecks `yaml:"healthchecks,omitempty"`
+}
+
+type importedHealthchecks struct {
+	UUID   string `yaml:"uuid"`
+	Server string `yaml:"server,omitempty"`
+}
+
+type importedRetention struct {
+	AfterBackup bool   `yaml:"after-backup"`
+	Prune       bool   `yaml:"prune"`
+	KeepHourly  int    `yaml:"keep-hourly,omitempty"`
+	KeepDaily   int    `yaml:"keep-daily,omitempty"`
+	KeepWeekly  int    `yaml:"keep-weekly,omitempty"`
+	KeepMonthly int    `yaml:"keep-monthly,omitempty"`
+	KeepYearly  int    `yaml:"keep-yearly,omitempty"`
+	KeepWithin  string `yaml:"keep-within,omitempty"`
+}
+
+type importedCheck struct {
+	ReadData bool `yaml:"read-data,omitempty"`
+}
+
+// importBorgmatic writes the resticprofile configuration translated from the borgmatic configuration.
+// The options which cannot be translated are listed in comments at the top of the configuration.
+func importBorgmatic(output io.Writer, reader io.Reader, filename, profileName string) error {
+	borgmatic, err := readBorgmatic(reader)
+	if err != nil {
+		return fmt.Errorf("cannot parse borgmatic configuration %q: %w", filename, err)
+	}
+	imported, warnings := translateBorgmatic(borgmatic, profileName)
+
+	_, _ = fmt.Fprintf(output, "# resticprofile configuration imported from borgmatic configuration %q\n", filename)
+	_, _ = fmt.Fprintln(output, "# restic cannot read borg repositories: initialize the new repositories with \"resticprofile init\"")
+	for _, warning := range warnings {
+		_, _ = fmt.Fprintf(output, "# WARNING: %s\n", warning)
+	}
+	encoder := yaml.NewEncoder(output)
+	encoder.SetIndent(2)
+	if err = encoder.Encode(imported); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// readBorgmatic reads the options at the root of the configuration, then the options of the sections of the older versions
+func readBorgmatic(reader io.Reader) (*borgmaticConfig, error) {
+	root := yaml.Node{}
+	if err := yaml.NewDecoder(reader).Decode(&root); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("empty configuration")
+		}
+		return nil, err
+	}
+	borgmatic := &borgmaticConfig{}
+	if err := root.Decode(borgmatic); err != nil {
+		return nil, err
+	}
+	sections := map[string]yaml.Node{}
+	if err := root.Decode(&sections); err != nil {
+		return nil, err
+	}
+	for _, name := range borgmaticSections {
+		if section, found := sections[name]; found && section.Kind == yaml.MappingNode {
+			if err := section.Decode(borgmatic); err != nil {
+				return nil, fmt.Errorf("section %s: %w", name, err)
+			}
+		}
+	}
+	return borgmatic, nil
+}
+
+// translateBorgmatic returns the configuration of the profile translated from borgmatic. When borgmatic backs up to
+// more than one repository, one profile is generated per repository (inheriting from the first one), and a group runs them all.
+func translateBorgmatic(borgmatic *borgmaticConfig, profileName string) (*importedConfig, []string) {
+	var warnings []string
+	profile := &importedProfile{
+		PasswordCommand: borgmatic.EncryptionPasscommand,
+		RunBefore:       append(append([]string{}, borgmatic.BeforeEverything...), borgmatic.BeforeActions...),
+		RunAfter:        append(append([]string{}, borgmatic.AfterActions...), borgmatic.AfterEverything...),
+		RunAfterFail:    borgmatic.OnError,
+	}
+	if borgmatic.EncryptionPassphrase != "" {
+		profile.Env = map[string]string{"RESTIC_PASSWORD": borgmatic.EncryptionPassphrase}
+		warnings = append(warnings, "the passphrase is kept in the configuration: consider moving it to a \"password-file\"")
+	}
+
+	backup := &importedBackup{
+		Source:              borgmatic.SourceDirectories,
+		ExcludeBorgPatterns: append(append([]string{}, borgmatic.ExcludeFrom...), borgmatic.PatternsFrom...),
+		ExcludeCaches:       borgmatic.ExcludeCaches,
+		ExcludeIfPresent:    borgmatic.ExcludeIfPresent,
+		OneFileSystem:       borgmatic.OneFileSystem,
+		RunBefore:           borgmatic.BeforeBackup,
+		RunAfter:            borgmatic.AfterBackup,
+	}
+	if len(borgmatic.ExcludePatterns)+len(borgmatic.Patterns) > 0 {
+		patterns := strings.Join(append(append([]string{}, borgmatic.ExcludePatterns...), borgmatic.Patterns...), "\n")
+		converted, patternWarnings, _ := exclude.Borg(strings.NewReader(patterns))
+		backup.Exclude = converted
+		for _, warning := range patternWarnings {
+			warnings = append(warnings, "exclude patterns "+warning)
+		}
+	}
+	if borgmatic.ExcludeNodump {
+		warnings = append(warnings, "\"exclude_nodump\" is not supported by restic")
+	}
+	if borgmatic.Healthchecks.PingURL != "" {
+		server, uuid := splitHealthchecksURL(borgmatic.Healthchecks.PingURL)
+		backup.Healthchecks = &importedHealthchecks{UUID: uuid, Server: server}
+	}
+	profile.Backup = backup
+
+	retention := &importedRetention{
+		AfterBackup: true,
+		Prune:       true,
+		KeepHourly:  borgmatic.KeepHourly,
+		KeepDaily:   borgmatic.KeepDaily,
+		KeepWeekly:  borgmatic.KeepWeekly,
+		KeepMonthly: borgmatic.KeepMonthly,
+		KeepYearly:  borgmatic.KeepYearly,
+		KeepWithin:  borgmatic.KeepWithin,
+	}
+	if *retention != (importedRetention{AfterBackup: true, Prune: true}) {
+		profile.Retention = retention
+	}
+	if borgmatic.KeepSecondly+borgmatic.KeepMinutely > 0 {
+		warnings = append(warnings, "\"keep_secondly\" and \"keep_minutely\" are not supported by restic")
+	}
+
+	for _, check := range borgmatic.Checks {
+		switch check.Name {
+		case "disabled":
+			profile.Check = nil
+			backup.CheckAfter = false
+		case "repository", "archives", "extract":
+			if profile.Check == nil {
+				profile.Check = &importedCheck{}
+			}
+			backup.CheckAfter = true
+		case "data":
+			profile.Check = &importedCheck{ReadData: true}
+			backup.CheckAfter = true
+		default:
+			warnings = append(warnings, fmt.Sprintf("check %q is not supported", check.Name))
+		}
+	}
+
+	imported := &importedConfig{
+		Version:  "2",
+		Profiles: map[string]*importedProfile{profileName: profile},
+	}
+	if len(borgmatic.Repositories) == 0 {
+		warnings = append(warnings, "no repository found")
+		return imported, warnings
+	}
+	profile.Repository = resticRepository(borgmatic.Repositories[0].Path)
+	if len(borgmatic.Repositories) > 1 {
+		group := &importedGroup{Profiles: []string{profileName}}
+		for i, repository := range borgmatic.Repositories[1:] {
+			name := profileName + "-" + strconv.Itoa(i+2)
+			if repository.Label != "" {
+				name = profileName + "-" + repository.Label
+			}
+			imported.Profiles[name] = &importedProfile{Inherit: profileName, Repository: resticRepository(repository.Path)}
+			group.Profiles = append(group.Profiles, name)
+		}
+		imported.Groups = map[string]*importedGroup{profileName + "-all": group}
+	}
+	return imported, warnings
+}
+
+// resticRepository translates the location of a borg repository into the location of a restic repository:
+// the remote repositories accessed with ssh are translated into sftp repositories
+func resticRepository(location string) string {
+	if strings.HasPrefix(location, "ssh://") {
+		remote, err := url.Parse(location)
+		if err != nil {
+			return location
+		}
+		user := ""
+		if remote.User != nil {
+			user = remote.User.Username() + "@"
+		}
+		// borg paths starting with "/./" or "/~/" are relative to the home directory
+		path, relative := strings.TrimPrefix(remote.Path, "/"), false
+		for _, prefix := range []string{"./", "~/"} {
+			if strings.HasPrefix(path, prefix) {
+				path, relative = strings.TrimPrefix(path, prefix), true
+			}
+		}
+		if remote.Port() != "" {
+			if !relative {
+				path = "/" + path
+			}
+			return "sftp://" + user + remote.Host + "/" + path
+		}
+		if !relative {
+			path = "/" + path
+		}
+		return "sftp:" + user + remote.Hostname() + ":" + path
+	}
+	if host, path, found := strings.Cut(location, ":"); found && strings.Contains(host, "@") && !strings.Contains(host, "/") {
+		return "sftp:" + host + ":" + path
+	}
+	return location
+}
+
+// splitHealthchecksURL returns the server and the UUID of a ping URL of healthchecks.io (the server is empty for the default one)
+func splitHealthchecksURL(pingURL string) (server, uuid string) {
+	pingURL = strings.TrimSuffix(pingURL, "/")
+	index := strings.LastIndex(pingURL, "/")
+	if index < 0 {
+		return "", pingURL
+	}
+	server, uuid = pingURL[:index], pingURL[index+1:]
+	if server == config.DefaultHealthchecksServer {
+		server = ""
+	}
+	return
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const borgmaticConfig18 = `
+source_directories:
+  - /home
+  - /etc
+repositories:
+  - path: ssh://user@backup.example.com/./home.borg
+    label: remote
+  - path: /mnt/usb/home.borg
+    label: usb
+exclude_patterns:
+  - "*.pyc"
+  - home/*/.cache
+  - re:\.bak$
+exclude_from:
+  - /etc/borgmatic/excludes
+exclude_caches: true
+exclude_if_present:
+  - .nobackup
+encryption_passcommand: cat /etc/borgmatic/passphrase
+keep_daily: 7
+keep_monthly: 6
+keep_within: 2d
+checks:
+  - name: repository
+  - name: data
+before_backup:
+  - echo "starting"
+on_error:
+  - echo "error"
+healthchecks:
+  ping_url: https://hc-ping.com/5bf66975-d4c7-4bf5-bcc8-b8d8a82ea278
+`
+
+const borgmaticConfigLegacy = `
+location:
+  source_directories:
+    - /srv
+  repositories:
+    - backup@nas:/volume1/borg
+  exclude_if_present: .nobackup
+storage:
+  encryption_passphrase: secret
+retention:
+  keep_weekly: 4
+  keep_minutely: 60
+consistency:
+  checks:
+    - repository
+hooks:
+  after_backup:
+    - echo "done"
+  healthchecks: https://healthchecks.example.com/ping/uuid
+`
+
+func TestImportBorgmatic(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	require.NoError(t, importBorgmatic(buffer, strings.NewReader(borgmaticConfig18), "config.yaml", "home"))
+	content := buffer.String()
+
+	assert.Contains(t, content, "# WARNING: exclude patterns line 3: regular expression")
+	c, err := config.Load(bytes.NewBufferString(content), config.FormatYAML)
+	require.NoError(t, err)
+
+	profile, err := c.GetProfile("home")
+	require.NoError(t, err)
+	assert.Equal(t, "sftp:user@backup.example.com:home.borg", profile.Repository.Value())
+	assert.Equal(t, "cat /etc/borgmatic/passphrase", profile.PasswordCommand)
+	assert.Equal(t, []string{`echo "error"`}, profile.RunAfterFail)
+	require.NotNil(t, profile.Backup)
+	assert.Equal(t, []string{"/home", "/etc"}, profile.Backup.Source)
+	assert.Equal(t, []string{"*.pyc", "/home/*/.cache"}, profile.Backup.Exclude)
+	assert.Equal(t, []string{"/etc/borgmatic/excludes"}, profile.Backup.ExcludeBorgPatterns)
+	assert.True(t, profile.Backup.ExcludeCaches)
+	assert.Equal(t, []string{".nobackup"}, profile.Backup.ExcludeIfPresent)
+	assert.True(t, profile.Backup.CheckAfter)
+	assert.Equal(t, []string{`echo "starting"`}, profile.Backup.RunBefore)
+	require.NotNil(t, profile.Backup.Healthchecks)
+	assert.Equal(t, "5bf66975-d4c7-4bf5-bcc8-b8d8a82ea278", profile.Backup.Healthchecks.UUID.Value())
+	assert.Empty(t, profile.Backup.Healthchecks.Server)
+	require.NotNil(t, profile.Retention)
+	assert.True(t, profile.Retention.AfterBackup)
+	flags := profile.GetRetentionFlags().ToMap()
+	assert.Equal(t, []string{"7"}, flags["keep-daily"])
+	assert.Equal(t, []string{"6"}, flags["keep-monthly"])
+	assert.Equal(t, []string{"2d"}, flags["keep-within"])
+	assert.Contains(t, profile.GetCommandFlags("check").ToMap(), "read-data")
+
+	usb, err := c.GetProfile("home-usb")
+	require.NoError(t, err)
+	assert.Equal(t, "/mnt/usb/home.borg", usb.Repository.Value())
+	assert.Equal(t, []string{"/home", "/etc"}, usb.Backup.Source)
+
+	group, err := c.GetProfileGroup("home-all")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"home", "home-usb"}, group.Profiles)
+}
+
+func TestImportBorgmaticLegacy(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	require.NoError(t, importBorgmatic(buffer, strings.NewReader(borgmaticConfigLegacy), "config.yaml", "default"))
+	content := buffer.String()
+
+	assert.Contains(t, content, "# WARNING: the passphrase is kept in the configuration")
+	assert.Contains(t, content, "# WARNING: \"keep_secondly\" and \"keep_minutely\" are not supported by restic")
+	assert.NotContains(t, content, "groups:")
+	c, err := config.Load(bytes.NewBufferString(content), config.FormatYAML)
+	require.NoError(t, err)
+
+	profile, err := c.GetProfile("default")
+	require.NoError(t, err)
+	assert.Equal(t, "sftp:backup@nas:/volume1/borg", profile.Repository.Value())
+	assert.Equal(t, "secret", profile.Environment["restic_password"].Value())
+	assert.Equal(t, []string{"/srv"}, profile.Backup.Source)
+	assert.Equal(t, []string{".nobackup"}, profile.Backup.ExcludeIfPresent)
+	assert.Equal(t, []string{`echo "done"`}, profile.Backup.RunAfter)
+	assert.Equal(t, "https://healthchecks.example.com/ping", profile.Backup.Healthchecks.Server)
+	assert.Equal(t, "uuid", profile.Backup.Healthchecks.UUID.Value())
+	assert.Equal(t, []string{"4"}, profile.GetRetentionFlags().ToMap()["keep-weekly"])
+	assert.NotNil(t, profile.Check)
+}
+
+func TestResticRepository(t *testing.T) {
+	for _, fixture := range []struct{ borg, restic string }{
+		{"/mnt/backup", "/mnt/backup"},
+		{"relative/repo", "relative/repo"},
+		{"ssh://user@host/./repo", "sftp:user@host:repo"},
+		{"ssh://user@host/~/repo", "sftp:user@host:repo"},
+		{"ssh://user@host/srv/repo", "sftp:user@host:/srv/repo"},
+		{"ssh://user@host:2222/./repo", "sftp://user@host:2222/repo"},
+		{"ssh://user@host:2222/srv/repo", "sftp://user@host:2222//srv/repo"},
+		{"user@host:repo", "sftp:user@host:repo"},
+	} {
+		assert.Equal(t, fixture.restic, resticRepository(fixture.borg), fixture.borg)
+	}
+}
+
+func TestImportCommand(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(filename, []byte("repositories: [/backup]\n"), 0o600))
+
+	buffer := &bytes.Buffer{}
+	require.NoError(t, importCommand(buffer, commandRequest{args: []string{"borgmatic", filename, "--profile", "nas"}}))
+	assert.Contains(t, buffer.String(), "profiles:\n  nas:\n    repository: /backup\n")
+
+	for _, fixture := range []struct {
+		args []string
+		err  string
+	}{
+		{nil, "missing import format (expected borgmatic)"},
+		{[]string{"borg"}, `unknown import format "borg" (expected borgmatic)`},
+		{[]string{"borgmatic"}, "missing borgmatic configuration file"},
+		{[]string{"borgmatic", filename, "--json"}, "unknown flag --json for import"},
+		{[]string{"borgmatic", filename, "--profile"}, "missing value for --profile"},
+		{[]string{"borgmatic", filename, "other"}, `unexpected argument "other"`},
+	} {
+		assert.EqualError(t, importCommand(buffer, commandRequest{args: fixture.args}), fixture.err)
+	}
+	err := importCommand(buffer, commandRequest{args: []string{"borgmatic", filename + ".missing"}})
+	assert.ErrorContains(t, err, "cannot read borgmatic configuration")
+}
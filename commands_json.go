@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"runtime"
+	"sort"
+	"time"
+
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/calendar"
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/filesearch"
+	"github.com/creativeprojects/resticprofile/restic"
+	"golang.org/x/exp/slices"
+)
+
+const jsonFlag = "--json"
+
+// hasJSONFlag returns true when the "--json" flag is present after the command
+func hasJSONFlag(args []string) bool {
+	return slices.Contains(args, jsonFlag)
+}
+
+type versionJSON struct {
+	Version       string `json:"version"`
+	Commit        string `json:"commit"`
+	Date          string `json:"date"`
+	BuiltBy       string `json:"built_by"`
+	OS            string `json:"os"`
+	Arch          string `json:"arch"`
+	GoVersion     string `json:"go_version"`
+	ResticBinary  string `json:"restic_binary,omitempty"`
+	ResticVersion string `json:"restic_version,omitempty"`
+}
+
+type profileJSON struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Abstract    bool     `json:"abstract,omitempty"`
+	Sections    []string `json:"sections"`
+}
+
+type groupJSON struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Profiles    []string `json:"profiles"`
+}
+
+type profilesJSON struct {
+	Profiles []profileJSON `json:"profiles"`
+	Groups   []groupJSON   `json:"groups"`
+}
+
+type scheduleJSON struct {
+	Profile    string     `json:"profile"`
+	Command    string     `json:"command"`
+	Schedules  []string   `json:"schedules"`
+	Permission string     `json:"permission,omitempty"`
+	Priority   string     `json:"priority,omitempty"`
+	LockMode   string     `json:"lock_mode,omitempty"`
+	Log        string     `json:"log,omitempty"`
+	Next       *time.Time `json:"next,omitempty"`
+}
+
+func writeJSON(output io.Writer, data any) error {
+	encoder := json.NewEncoder(output)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(data)
+}
+
+// displayVersionJSON displays the version of resticprofile, and the version of restic when it can be found
+func displayVersionJSON(output io.Writer, request commandRequest) error {
+	info := versionJSON{
+		Version:   version,
+		Commit:    commit,
+		Date:      date,
+		BuiltBy:   builtBy,
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+		GoVersion: runtime.Version(),
+	}
+
+	binary := ""
+	if request.config != nil {
+		if global, err := request.config.GetGlobalSection(); err == nil {
+			binary = global.ResticBinary
+		}
+	}
+	if resticBinary, err := filesearch.FindResticBinary(binary); err == nil {
+		info.ResticBinary = resticBinary
+		if info.ResticVersion, err = restic.GetVersion(resticBinary); err != nil {
+			clog.Debugf("cannot get restic version: %s", err)
+		}
+	}
+	return writeJSON(output, info)
+}
+
+// displayProfilesJSON displays the profiles and groups of the configuration
+func displayProfilesJSON(output io.Writer, configuration *config.Config, showAbstract bool) error {
+	result := profilesJSON{
+		Profiles: make([]profileJSON, 0),
+		Groups:   make([]groupJSON, 0),
+	}
+
+	profiles := getDisplayedProfiles(configuration, showAbstract)
+	for _, name := range sortedProfileKeys(profiles) {
+		sections := profiles[name].DefinedCommands()
+		sort.Strings(sections)
+		result.Profiles = append(result.Profiles, profileJSON{
+			Name:        name,
+			Description: profiles[name].Description,
+			Abstract:    profiles[name].Abstract,
+			Sections:    append(make([]string, 0, len(sections)), sections...),
+		})
+	}
+
+	groups := configuration.GetProfileGroups()
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		result.Groups = append(result.Groups, groupJSON{
+			Name:        name,
+			Description: groups[name].Description,
+			Profiles:    append(make([]string, 0, len(groups[name].Profiles)), groups[name].Profiles...),
+		})
+	}
+	return writeJSON(output, result)
+}
+
+// displaySchedulesJSON displays the schedules declared in the selected profile (or in all profiles)
+func displaySchedulesJSON(output io.Writer, request commandRequest) error {
+	schedules, err := getDeclaredSchedules(request)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	result := make([]scheduleJSON, 0, len(schedules))
+	for _, schedule := range schedules {
+		result = append(result, newScheduleJSON(schedule, now))
+	}
+	return writeJSON(output, result)
+}
+
+func newScheduleJSON(schedule *config.ScheduleConfig, now time.Time) scheduleJSON {
+	result := scheduleJSON{
+		Profile:    schedule.Title,
+		Command:    schedule.SubTitle,
+		Schedules:  append(make([]string, 0, len(schedule.Schedules)), schedule.Schedules...),
+		Permission: schedule.Permission,
+		Priority:   schedule.Priority,
+		LockMode:   schedule.LockMode,
+		Log:        schedule.Log,
+	}
+	for _, input := range schedule.Schedules {
+		event := calendar.NewEvent()
+		if err := event.Parse(input); err != nil {
+			continue
+		}
+		if next := event.Next(now); !next.IsZero() && (result.Next == nil || next.Before(*result.Next)) {
+			result.Next = &next
+		}
+	}
+	return result
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/constants"
+	"github.com/creativeprojects/resticprofile/filesearch"
+	"github.com/creativeprojects/resticprofile/restic"
+	"github.com/creativeprojects/resticprofile/secret"
+	"github.com/creativeprojects/resticprofile/shell"
+)
+
+// repoMigration moves all the snapshots of the repository of a profile to a new repository
+type repoMigration struct {
+	output  io.Writer
+	profile *config.Profile
+	// wrapper runs the commands with the source repository, and the new repository as copy destination
+	wrapper *resticWrapper
+	// target runs the commands on the new repository
+	target *resticWrapper
+	dryRun bool
+	resume bool
+	verify bool
+}
+
+// migrateRepoCommand initializes a new repository with the chunker parameters of the repository of the profile,
+// copies all the snapshots, verifies the new repository and proposes the change of configuration
+func migrateRepoCommand(output io.Writer, request commandRequest) error {
+	c := request.config
+	flags := request.flags
+	args := request.args
+
+	name := flags.name
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+	destination := config.CopySection{}
+	resume, verify := false, true
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--resume":
+			resume = true
+			continue
+		case "--no-verify":
+			verify = false
+			continue
+		}
+		if i+1 >= len(args) {
+			return fmt.Errorf("missing value for %s", args[i])
+		}
+		value := args[i+1]
+		switch args[i] {
+		case "--to":
+			destination.Repository = config.NewConfidentialValue(value)
+		case "--password-file":
+			destination.PasswordFile = value
+		case "--password-command":
+			destination.PasswordCommand = value
+		default:
+			return fmt.Errorf("unknown flag %s for migrate-repo", args[i])
+		}
+		i++
+	}
+	if destination.Repository.Value() == "" {
+		return errors.New("missing --to: location of the new repository")
+	}
+
+	global, err := c.GetGlobalSection()
+	if err != nil {
+		return fmt.Errorf("cannot load global configuration: %w", err)
+	}
+	profile, err := c.GetProfile(name)
+	if err != nil {
+		if errors.Is(err, config.ErrNotFound) {
+			return fmt.Errorf("profile '%s' not found", name)
+		}
+		return fmt.Errorf("cannot load profile '%s': %w", name, err)
+	}
+	if profile.Repository.Value() == "" && profile.RepositoryFile == "" {
+		return fmt.Errorf("profile '%s' has no repository", name)
+	}
+	if err = profile.ResolveSecrets(secret.DefaultResolver()); err != nil {
+		return err
+	}
+	config.RedactConfidentialValues(profile)
+	resticBinary, err := filesearch.FindResticBinary(global.ResticBinary)
+	if err != nil {
+		return fmt.Errorf("cannot find restic: %w", err)
+	}
+	if global.ResticVersion == "" {
+		if global.ResticVersion, err = restic.GetVersion(resticBinary); err != nil {
+			clog.Warningf("assuming restic is at latest known version ; %s", err.Error())
+			global.ResticVersion = restic.AnyVersion
+		}
+	}
+	if err = profile.SetResticVersion(global.ResticVersion); err != nil {
+		clog.Warningf("restic version %q is no valid semver: %s", global.ResticVersion, err.Error())
+	}
+
+	migration := newRepoMigration(output, global, resticBinary, flags.dryRun, profile, destination)
+	migration.resume = resume
+	migration.verify = verify
+	if err = migration.run(); err != nil {
+		return err
+	}
+	migration.proposeConfiguration(c.GetConfigFile())
+	return nil
+}
+
+// newRepoMigration prepares the migration of the repository of the profile to the destination.
+// The password of the new repository defaults to the password of the profile.
+func newRepoMigration(output io.Writer, global *config.Global, resticBinary string, dryRun bool, profile *config.Profile, destination config.CopySection) *repoMigration {
+	if destination.PasswordFile == "" && destination.PasswordCommand == "" {
+		destination.PasswordFile = profile.PasswordFile
+		destination.PasswordCommand = profile.PasswordCommand
+	}
+	source := *profile
+	source.Copy = &destination
+
+	target := *profile
+	target.Repository = destination.Repository
+	target.RepositoryFile = ""
+	target.PasswordFile = destination.PasswordFile
+	target.PasswordCommand = destination.PasswordCommand
+	target.KeyHint = ""
+
+	return &repoMigration{
+		output:  output,
+		profile: profile,
+		wrapper: newResticWrapper(global, resticBinary, dryRun, &source, constants.CommandCopy, nil, nil),
+		target:  newResticWrapper(global, resticBinary, dryRun, &target, constants.CommandCheck, nil, nil),
+		dryRun:  dryRun,
+		verify:  true,
+	}
+}
+
+// run initializes the new repository, copies the snapshots and verifies the result
+func (m *repoMigration) run() error {
+	name := m.profile.Name
+	sourceSnapshots, err := m.countSnapshots(m.wrapper)
+	if err != nil {
+		return fmt.Errorf("cannot list the snapshots of profile '%s': %w", name, err)
+	}
+	fmt.Fprintf(m.output, "[1/4] %d snapshot(s) in the repository of profile '%s'\n", sourceSnapshots, name)
+
+	if m.resume {
+		fmt.Fprintf(m.output, "[2/4] resuming: the new repository is already initialized\n")
+	} else {
+		fmt.Fprintf(m.output, "[2/4] initializing the new repository with the chunker parameters of profile '%s'\n", name)
+		rCommand := m.wrapper.prepareCommand(constants.CommandInit, m.wrapper.profile.GetCopyInitializeFlags(), false)
+		_, stderr, err := runShellCommand(rCommand)
+		if err != nil {
+			return newCommandError(rCommand, stderr, fmt.Errorf("cannot initialize the new repository (use --resume if it's already initialized): %w", err))
+		}
+	}
+
+	fmt.Fprintf(m.output, "[3/4] copying the snapshots\n")
+	rCommand := m.wrapper.prepareCommand(constants.CommandCopy, m.wrapper.profile.GetCommandFlags(constants.CommandCopy), false)
+	_, stderr, err := runShellCommand(rCommand)
+	if err != nil {
+		return newCommandError(rCommand, stderr, fmt.Errorf("cannot copy the snapshots (run again with --resume to continue): %w", err))
+	}
+
+	if !m.verify {
+		fmt.Fprintf(m.output, "[4/4] verification skipped\n")
+		return nil
+	}
+	fmt.Fprintf(m.output, "[4/4] verifying the new repository\n")
+	rCommand = m.target.prepareCommand(constants.CommandCheck, m.target.profile.GetCommonFlags(), false)
+	_, stderr, err = runShellCommand(rCommand)
+	if err != nil {
+		return newCommandError(rCommand, stderr, fmt.Errorf("the new repository failed the check: %w", err))
+	}
+	targetSnapshots, err := m.countSnapshots(m.target)
+	if err != nil {
+		return fmt.Errorf("cannot list the snapshots of the new repository: %w", err)
+	}
+	if !m.dryRun && targetSnapshots < sourceSnapshots {
+		return fmt.Errorf("the new repository has %d snapshot(s) instead of %d", targetSnapshots, sourceSnapshots)
+	}
+	fmt.Fprintf(m.output, "migration complete: %d snapshot(s) in the new repository\n", targetSnapshots)
+	return nil
+}
+
+// countSnapshots returns the number of snapshots of the repository of the wrapper (always 0 in dry-run)
+func (m *repoMigration) countSnapshots(wrapper *resticWrapper) (int, error) {
+	args := wrapper.profile.GetCommonFlags()
+	args.AddFlag("json", "", shell.ArgConfigEscape)
+	buffer := &bytes.Buffer{}
+	rCommand := wrapper.prepareCommand(constants.CommandSnapshots, args, false)
+	rCommand.stdout = buffer
+	if _, stderr, err := runShellCommand(rCommand); err != nil {
+		return 0, newCommandError(rCommand, stderr, err)
+	}
+	if m.dryRun {
+		return 0, nil
+	}
+	snapshots := make([]json.RawMessage, 0)
+	if err := json.Unmarshal(bytes.TrimSpace(buffer.Bytes()), &snapshots); err != nil {
+		return 0, fmt.Errorf("cannot parse snapshots: %w", err)
+	}
+	return len(snapshots), nil
+}
+
+// proposeConfiguration displays the change of the configuration file to use the new repository.
+// The configuration file is not modified.
+func (m *repoMigration) proposeConfiguration(configFile string) {
+	target := m.target.profile
+	fmt.Fprintf(m.output, "\nto use the new repository, change the configuration of profile '%s':\n", m.profile.Name)
+	location := ""
+	if m.profile.Repository.Value() != "" {
+		location = findConfigurationLine(configFile, m.profile.Repository.Value())
+	}
+	if location != "" {
+		fmt.Fprintf(m.output, "  %s\n", location)
+	}
+	if m.profile.RepositoryFile != "" {
+		fmt.Fprintf(m.output, "  - repository-file = %q\n", m.profile.RepositoryFile)
+	} else {
+		fmt.Fprintf(m.output, "  - repository = %q\n", m.profile.Repository.String())
+	}
+	fmt.Fprintf(m.output, "  + repository = %q\n", target.Repository.String())
+	if target.PasswordFile != m.profile.PasswordFile {
+		fmt.Fprintf(m.output, "  + password-file = %q\n", target.PasswordFile)
+	}
+	if target.PasswordCommand != m.profile.PasswordCommand {
+		fmt.Fprintf(m.output, "  + password-command = %q\n", target.PasswordCommand)
+	}
+	fmt.Fprintf(m.output, "keep the old repository until the new one has been used successfully\n")
+}
+
+// findConfigurationLine returns "file:line" of the first line of the configuration file containing the value
+func findConfigurationLine(configFile, value string) string {
+	file, err := os.Open(configFile)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.Contains(scanner.Text(), value) {
+			return fmt.Sprintf("%s:%d", configFile, line)
+		}
+	}
+	return ""
+}
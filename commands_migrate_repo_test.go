@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/platform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRestic creates a script recording its arguments, and listing two snapshots
+func fakeRestic(t *testing.T) (binary, log string) {
+	t.Helper()
+	if platform.IsWindows() {
+		t.Skip("shell script")
+	}
+	dir := t.TempDir()
+	binary = filepath.Join(dir, "restic")
+	log = filepath.Join(dir, "commands.log")
+	script := "#!/bin/sh\necho \"$@\" >> " + log + "\nif [ \"$1\" = snapshots ]; then echo '[{\"id\":\"a\"},{\"id\":\"b\"}]'; fi\n"
+	require.NoError(t, os.WriteFile(binary, []byte(script), 0o700))
+	return
+}
+
+func TestRepoMigration(t *testing.T) {
+	binary, log := fakeRestic(t)
+	profile := config.NewProfile(nil, "name")
+	profile.Repository = config.NewConfidentialValue("/old/repo")
+	profile.PasswordFile = "key"
+	require.NoError(t, profile.SetResticVersion("0.16.0"))
+
+	output := &bytes.Buffer{}
+	destination := config.CopySection{Repository: config.NewConfidentialValue("/new/repo")}
+	migration := newRepoMigration(output, &config.Global{}, binary, false, profile, destination)
+	require.NoError(t, migration.run())
+
+	content, err := os.ReadFile(log)
+	require.NoError(t, err)
+	commands := strings.Split(strings.TrimSpace(string(content)), "\n")
+	require.Len(t, commands, 5)
+	assert.Equal(t, "snapshots --json --password-file=key --repo=/old/repo", commands[0])
+	assert.True(t, strings.HasPrefix(commands[1], "init "))
+	assert.Contains(t, commands[1], "--copy-chunker-params")
+	assert.Contains(t, commands[1], "--from-repo=/old/repo")
+	assert.Contains(t, commands[1], "--repo=/new/repo")
+	assert.True(t, strings.HasPrefix(commands[2], "copy "))
+	assert.Contains(t, commands[2], "--from-repo=/old/repo")
+	assert.Contains(t, commands[2], "--repo=/new/repo")
+	assert.Equal(t, "check --password-file=key --repo=/new/repo", commands[3])
+	assert.Equal(t, "snapshots --json --password-file=key --repo=/new/repo", commands[4])
+	assert.Contains(t, output.String(), "migration complete: 2 snapshot(s) in the new repository\n")
+	assert.Equal(t, "/old/repo", profile.Repository.Value(), "profile is not modified")
+}
+
+func TestRepoMigrationResume(t *testing.T) {
+	binary, log := fakeRestic(t)
+	profile := config.NewProfile(nil, "name")
+	profile.Repository = config.NewConfidentialValue("/old/repo")
+	require.NoError(t, profile.SetResticVersion("0.16.0"))
+
+	destination := config.CopySection{Repository: config.NewConfidentialValue("/new/repo"), PasswordFile: "new-key"}
+	migration := newRepoMigration(&bytes.Buffer{}, &config.Global{}, binary, false, profile, destination)
+	migration.resume = true
+	migration.verify = false
+	require.NoError(t, migration.run())
+
+	content, err := os.ReadFile(log)
+	require.NoError(t, err)
+	commands := strings.Split(strings.TrimSpace(string(content)), "\n")
+	require.Len(t, commands, 2)
+	assert.True(t, strings.HasPrefix(commands[1], "copy "))
+	assert.Contains(t, commands[1], "--password-file=new-key")
+}
+
+func TestProposeConfiguration(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "profiles.toml")
+	require.NoError(t, os.WriteFile(configFile, []byte("[name]\nrepository = \"/old/repo\"\n"), 0o600))
+	profile := config.NewProfile(nil, "name")
+	profile.Repository = config.NewConfidentialValue("/old/repo")
+
+	output := &bytes.Buffer{}
+	destination := config.CopySection{Repository: config.NewConfidentialValue("/new/repo"), PasswordFile: "new-key"}
+	migration := newRepoMigration(output, &config.Global{}, mockBinary, false, profile, destination)
+	migration.proposeConfiguration(configFile)
+	assert.Contains(t, output.String(), "  "+configFile+":2\n"+
+		"  - repository = \"/old/repo\"\n"+
+		"  + repository = \"/new/repo\"\n"+
+		"  + password-file = \"new-key\"\n")
+}
+
+func TestMigrateRepoFlags(t *testing.T) {
+	err := migrateRepoCommand(&bytes.Buffer{}, commandRequest{args: []string{"name"}})
+	assert.EqualError(t, err, "missing --to: location of the new repository")
+
+	err = migrateRepoCommand(&bytes.Buffer{}, commandRequest{args: []string{"name", "--unknown", "value"}})
+	assert.EqualError(t, err, "unknown flag --unknown for migrate-repo")
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/monitor/status"
+	"golang.org/x/exp/slices"
+)
+
+// unmountTimeout is the maximum time to wait for restic to unmount after an interrupt
+const unmountTimeout = 30 * time.Second
+
+// unmountCommand stops restic mount running in the background for the selected profile(s)
+func unmountCommand(_ io.Writer, request commandRequest) error {
+	c := request.config
+	flags := request.flags
+	args := request.args
+
+	defer c.DisplayConfigurationIssues()
+
+	var errs []error
+	for _, profileName := range selectProfiles(c, flags, args) {
+		profile, err := c.GetProfile(profileName)
+		if err != nil {
+			if errors.Is(err, config.ErrNotFound) {
+				return fmt.Errorf("profile '%s' not found", profileName)
+			}
+			return fmt.Errorf("cannot load profile '%s': %w", profileName, err)
+		}
+		if err = unmountProfile(profile); err != nil {
+			if slices.Contains(args, "--all") {
+				// keep going with the other profiles
+				clog.Error(err)
+				errs = append(errs, err)
+				continue
+			}
+			return err
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%d profile(s) failed to unmount", len(errs))
+	}
+	return nil
+}
+
+// unmountProfile terminates the background mount of a profile (if any) and removes it from the status file
+func unmountProfile(profile *config.Profile) error {
+	if profile.StatusFile == "" {
+		return nil
+	}
+	state := status.NewStatus(profile.StatusFile).Load()
+	mount := state.Profile(profile.Name).Mount
+	if mount == nil {
+		clog.Debugf("profile '%s' is not mounted in background", profile.Name)
+		return nil
+	}
+
+	if processExists(mount.PID) {
+		clog.Infof("profile '%s': unmounting %q (pid %d)", profile.Name, mount.Mountpoint, mount.PID)
+		if err := stopMountProcess(mount.PID); err != nil {
+			return fmt.Errorf("unmount on profile '%s': %w", profile.Name, err)
+		}
+	} else {
+		clog.Warningf("profile '%s': mount process %d is no longer running", profile.Name, mount.PID)
+	}
+
+	if mount.LogFile != "" {
+		_ = os.Remove(mount.LogFile)
+	}
+	err := state.Update(func(state *status.Status) {
+		state.Profile(profile.Name).MountStopped()
+	})
+	if err != nil {
+		clog.Warningf("saving status file '%s': %v", profile.StatusFile, err)
+	}
+	return nil
+}
+
+// unmountAll stops all background mounts of all profiles in the configuration
+func unmountAll(c *config.Config) {
+	for _, profile := range c.GetProfiles() {
+		if err := unmountProfile(profile); err != nil {
+			clog.Error(err)
+		}
+	}
+}
+
+// stopMountProcess interrupts restic mount (which unmounts cleanly) and kills it if it didn't stop in time
+func stopMountProcess(pid int) error {
+	if err := interruptProcessGroup(pid); err != nil {
+		return err
+	}
+	deadline := time.Now().Add(unmountTimeout)
+	for time.Now().Before(deadline) {
+		if !processExists(pid) {
+			return nil
+		}
+		time.Sleep(250 * time.Millisecond)
+	}
+	clog.Warningf("mount process %d did not stop after %s, killing it", pid, unmountTimeout)
+	return killProcessGroup(pid)
+}
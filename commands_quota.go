@@ -0,0 +1,62 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/creativeprojects/resticprofile/config"
+)
+
+// quotaCommand displays the last usage of the quota of the selected profile, and unblocks the backups with "--ack"
+func quotaCommand(output io.Writer, request commandRequest) error {
+	flags := request.flags
+
+	ack := false
+	for _, arg := range request.args {
+		if arg != "--ack" {
+			return fmt.Errorf("unknown flag %s for quota", arg)
+		}
+		ack = true
+	}
+	profile, err := request.config.GetProfile(flags.name)
+	if err != nil {
+		if errors.Is(err, config.ErrNotFound) {
+			return fmt.Errorf("profile '%s' not found", flags.name)
+		}
+		return fmt.Errorf("cannot load profile '%s': %w", flags.name, err)
+	}
+	if profile.Quota.IsEmpty() {
+		return fmt.Errorf("profile '%s' has no quota", profile.Name)
+	}
+
+	filename := getQuotaStateFile(profile)
+	state, err := loadQuotaState(filename)
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		fmt.Fprintf(output, "profile '%s': the quota was never checked\n", profile.Name)
+		return nil
+	}
+	fmt.Fprintf(output, "profile '%s': the repository used %s on %s: %s\n",
+		profile.Name, state, state.Time.Format("2006-01-02 15:04:05"), state.Status)
+
+	switch {
+	case !state.Blocked:
+		if ack {
+			fmt.Fprintln(output, "the backups are not blocked")
+		}
+	case ack && flags.dryRun:
+		fmt.Fprintln(output, "dry-run: the backups would no longer be blocked")
+	case ack:
+		state.Blocked = false
+		if err = saveQuotaState(filename, state); err != nil {
+			return err
+		}
+		fmt.Fprintln(output, "the backups are no longer blocked")
+	default:
+		fmt.Fprintln(output, "the backups are blocked: run the command again with --ack once some space is freed in the repository")
+	}
+	return nil
+}
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/constants"
+	"github.com/creativeprojects/resticprofile/restserver"
+)
+
+const (
+	defaultRestServerBinary  = "/usr/local/bin/rest-server"
+	defaultRestServerPath    = "/srv/restic"
+	defaultRestServerListen  = ":8000"
+	defaultRestServerUser    = "restic"
+	defaultRestServerTLSDays = 3650
+)
+
+// restServerCommand generates the resources of a rest-server hosting the repositories of the profiles
+func restServerCommand(output io.Writer, request commandRequest) error {
+	args := request.args
+	action := ""
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		action, args = args[0], args[1:]
+	}
+	switch action {
+	case "htpasswd":
+		return restServerHtpasswd(output, request.flags, args)
+	case "tls":
+		return restServerTLS(output, args)
+	case "systemd":
+		return restServerSystemd(output, args)
+	default:
+		return fmt.Errorf("unknown rest-server action %q (expected htpasswd, tls or systemd)", action)
+	}
+}
+
+// restServerHtpasswd generates the htpasswd entries of the users of the rest repositories of the profiles
+func restServerHtpasswd(output io.Writer, flags commandLineFlags, args []string) error {
+	all := false
+	filename := ""
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--all":
+			all = true
+		case "--file":
+			if i+1 >= len(args) {
+				return fmt.Errorf("missing value for %s", args[i])
+			}
+			i++
+			filename = args[i]
+		default:
+			return fmt.Errorf("unknown flag %s for rest-server htpasswd", args[i])
+		}
+	}
+	c, err := loadInstallConfiguration(flags)
+	if err != nil {
+		return err
+	}
+	users, err := restServerUsers(installProfiles(c, flags.name, all))
+	if err != nil {
+		return err
+	}
+	if len(users) == 0 {
+		return errors.New("no profile with a rest-server repository (\"rest:\" URL)")
+	}
+
+	if filename == "" {
+		content, err := restserver.MergeHtpasswd(nil, users)
+		if err != nil {
+			return err
+		}
+		_, err = output.Write(content)
+		return err
+	}
+	content, err := os.ReadFile(filename)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("cannot read htpasswd file: %w", err)
+	}
+	content, err = restserver.MergeHtpasswd(content, users)
+	if err != nil {
+		return err
+	}
+	if err = os.WriteFile(filename, content, 0o600); err != nil {
+		return fmt.Errorf("cannot write htpasswd file: %w", err)
+	}
+	fmt.Fprintf(output, "%d user(s) saved in %s\n", len(users), filename)
+	return nil
+}
+
+// restServerUsers returns the users of the rest repositories of the profiles. The user is taken from the URL
+// of the repository or the environment of the profile (defaults to the profile name). A password is generated when none is set.
+func restServerUsers(profiles []*config.Profile) ([]restserver.User, error) {
+	users := make([]restserver.User, 0, len(profiles))
+	owners := make(map[string]string, len(profiles))
+	for _, profile := range profiles {
+		repository, ok := restserver.ParseRepository(profile.Repository.Value())
+		if !ok {
+			continue
+		}
+		user := restserver.User{
+			Username: firstNonEmpty(repository.Username, profileEnv(profile, constants.EnvResticRestUsername), profile.Name),
+			Password: firstNonEmpty(repository.Password, profileEnv(profile, constants.EnvResticRestPassword)),
+		}
+		if user.Password == "" {
+			password, err := randomPassword()
+			if err != nil {
+				return nil, err
+			}
+			user.Password = password
+			clog.Warningf("profile '%s': no rest-server password, generated %q: set it as %q in the \"env\" section of the profile",
+				profile.Name, password, constants.EnvResticRestPassword)
+		}
+		if path := strings.Trim(repository.Path, "/"); path != user.Username && !strings.HasPrefix(path, user.Username+"/") {
+			clog.Warningf("profile '%s': repository path %q is not under %q, it cannot be reached with \"--private-repos\"",
+				profile.Name, repository.Path, "/"+user.Username+"/")
+		}
+		if owner, found := owners[user.Username]; found {
+			for _, existing := range users {
+				if existing.Username == user.Username && existing.Password != user.Password {
+					return nil, fmt.Errorf("user %q has different passwords in profiles '%s' and '%s'", user.Username, owner, profile.Name)
+				}
+			}
+			continue
+		}
+		owners[user.Username] = profile.Name
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+// profileEnv returns the value of an environment variable of the profile
+func profileEnv(profile *config.Profile, name string) string {
+	for key, value := range profile.Environment {
+		if strings.EqualFold(key, name) {
+			return value.Value()
+		}
+	}
+	return ""
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func randomPassword() (string, error) {
+	buffer := make([]byte, 18)
+	if _, err := rand.Read(buffer); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buffer), nil
+}
+
+// restServerTLS generates a self-signed certificate for the rest-server
+func restServerTLS(output io.Writer, args []string) error {
+	var hosts []string
+	dir := "."
+	days := defaultRestServerTLSDays
+	force := false
+	for i := 0; i < len(args); i++ {
+		if args[i] == "--force" {
+			force = true
+			continue
+		}
+		if i+1 >= len(args) {
+			return fmt.Errorf("missing value for %s", args[i])
+		}
+		value := args[i+1]
+		switch args[i] {
+		case "--host":
+			hosts = append(hosts, strings.Split(value, ",")...)
+		case "--dir":
+			dir = value
+		case "--days":
+			var err error
+			if days, err = strconv.Atoi(value); err != nil || days < 1 {
+				return fmt.Errorf("invalid number of days: %q", value)
+			}
+		default:
+			return fmt.Errorf("unknown flag %s for rest-server tls", args[i])
+		}
+		i++
+	}
+	if len(hosts) == 0 {
+		return errors.New("missing --host: name(s) or IP address(es) of the rest-server")
+	}
+	certificateFile := filepath.Join(dir, restserver.CertificateFile)
+	keyFile := filepath.Join(dir, restserver.PrivateKeyFile)
+	if !force {
+		for _, file := range []string{certificateFile, keyFile} {
+			if _, err := os.Stat(file); err == nil {
+				return fmt.Errorf("%s already exists (use --force to replace it)", file)
+			}
+		}
+	}
+	certificate, key, err := restserver.GenerateCertificate(hosts, time.Duration(days)*24*time.Hour)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	if err = os.WriteFile(keyFile, key, 0o600); err != nil {
+		return fmt.Errorf("cannot write private key: %w", err)
+	}
+	if err = os.WriteFile(certificateFile, certificate, 0o644); err != nil {
+		return fmt.Errorf("cannot write certificate: %w", err)
+	}
+	fmt.Fprintf(output, "certificate for %s saved in %s (private key in %s)\n", strings.Join(hosts, ", "), certificateFile, keyFile)
+	fmt.Fprintf(output, "copy the certificate to the clients and add \"cacert = %q\" to their profiles\n", certificateFile)
+	return nil
+}
+
+// restServerSystemd displays the systemd service running the rest-server
+func restServerSystemd(output io.Writer, args []string) error {
+	options := restserver.UnitOptions{
+		Binary:       defaultRestServerBinary,
+		Path:         defaultRestServerPath,
+		Listen:       defaultRestServerListen,
+		User:         defaultRestServerUser,
+		PrivateRepos: true,
+	}
+	if binary, err := exec.LookPath("rest-server"); err == nil {
+		if binary, err = filepath.Abs(binary); err == nil {
+			options.Binary = binary
+		}
+	}
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--append-only":
+			options.AppendOnly = true
+			continue
+		case "--no-private-repos":
+			options.PrivateRepos = false
+			continue
+		}
+		if i+1 >= len(args) {
+			return fmt.Errorf("missing value for %s", args[i])
+		}
+		value := args[i+1]
+		switch args[i] {
+		case "--binary":
+			options.Binary = value
+		case "--path":
+			options.Path = value
+		case "--listen":
+			options.Listen = value
+		case "--user":
+			options.User = value
+		case "--htpasswd":
+			options.Htpasswd = value
+		case "--tls-dir":
+			options.TLSDir = value
+		default:
+			return fmt.Errorf("unknown flag %s for rest-server systemd", args[i])
+		}
+		i++
+	}
+	return restserver.WriteUnit(output, options)
+}
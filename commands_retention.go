@@ -0,0 +1,266 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/creativeprojects/resticprofile/calendar"
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/constants"
+	"github.com/creativeprojects/resticprofile/shell"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	retentionSimulate         = "simulate"
+	defaultSimulationDays     = 365
+	defaultSimulationSchedule = "daily"
+)
+
+// isRetentionSimulation returns true when the command line is "[profile.]retention simulate"
+func isRetentionSimulation(command string, args []string) bool {
+	return command == constants.SectionConfigurationRetention && len(args) > 0 && args[0] == retentionSimulate
+}
+
+// simulateRetention synthesizes the snapshots created by the backup schedule and displays which ones
+// would be kept by the retention policy of the profile
+func simulateRetention(output io.Writer, request commandRequest) error {
+	c := request.config
+	flags := request.flags
+	args := request.args
+
+	profile, err := c.GetProfile(flags.name)
+	if err != nil {
+		if errors.Is(err, config.ErrNotFound) {
+			return fmt.Errorf("profile '%s' not found", flags.name)
+		}
+		return fmt.Errorf("cannot load profile '%s': %w", flags.name, err)
+	}
+
+	days := defaultSimulationDays
+	if slices.Contains(args, "--days") {
+		index := slices.Index(args, "--days")
+		if index+1 >= len(args) {
+			return errors.New("missing value for --days")
+		}
+		if days, err = strconv.Atoi(args[index+1]); err != nil || days < 1 {
+			return fmt.Errorf("invalid number of days: %q", args[index+1])
+		}
+	}
+
+	schedules := []string{defaultSimulationSchedule}
+	if profile.Backup != nil && len(profile.Backup.Schedule) > 0 {
+		schedules = profile.Backup.Schedule
+	}
+
+	policy, err := newRetentionPolicy(profile.GetRetentionFlags())
+	if err != nil {
+		return err
+	}
+	if policy.empty() {
+		return fmt.Errorf("profile '%s' has no keep-* retention policy", profile.Name)
+	}
+
+	end := time.Now().Truncate(time.Minute)
+	start := end.AddDate(0, 0, -days)
+	snapshots, err := simulateSnapshots(schedules, start, end)
+	if err != nil {
+		return err
+	}
+
+	kept := policy.apply(snapshots)
+	fmt.Fprintf(output, "Simulated %d snapshots over %d days (schedule: %s), %d would be kept:\n\n",
+		len(snapshots), days, strings.Join(schedules, ", "), len(kept))
+
+	w := tabwriter.NewWriter(output, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "  Time\tAge\tReasons")
+	for _, snapshot := range kept {
+		_, _ = fmt.Fprintf(w, "  %s\t%s\t%s\n",
+			snapshot.time.Format("2006-01-02 15:04 Mon"),
+			formatAge(end.Sub(snapshot.time)),
+			strings.Join(snapshot.reasons, ", "))
+	}
+	_ = w.Flush()
+	fmt.Fprintln(output, "")
+	return nil
+}
+
+// simulateSnapshots returns all the times (newest first) the schedules would trigger in between start and end
+func simulateSnapshots(schedules []string, start, end time.Time) (snapshots []time.Time, err error) {
+	for _, schedule := range schedules {
+		event := calendar.NewEvent()
+		if err = event.Parse(schedule); err != nil {
+			return nil, fmt.Errorf("invalid schedule %q: %w", schedule, err)
+		}
+		snapshots = append(snapshots, event.GetAllInBetween(start, end)...)
+	}
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].After(snapshots[j]) })
+	return slices.Compact(snapshots), nil
+}
+
+func formatAge(age time.Duration) string {
+	days := int(age.Hours()) / 24
+	if days > 0 {
+		return fmt.Sprintf("%dd", days)
+	}
+	return age.Truncate(time.Minute).String()
+}
+
+// retentionBucket is one of the keep-[last|hourly|daily|weekly|monthly|yearly] rules of restic
+type retentionBucket struct {
+	name   string
+	count  int // -1 for unlimited
+	within *resticDuration
+	value  func(time.Time) int
+}
+
+// retentionPolicy mimics the snapshot selection of "restic forget"
+type retentionPolicy struct {
+	buckets []retentionBucket
+	within  *resticDuration
+}
+
+type keptSnapshot struct {
+	time    time.Time
+	reasons []string
+}
+
+func newRetentionPolicy(args *shell.Args) (policy retentionPolicy, err error) {
+	getInt := func(name string) (int, error) {
+		if values, found := args.Get(name); found && len(values) > 0 {
+			value := values[len(values)-1].Value()
+			if value == "unlimited" {
+				return -1, nil
+			}
+			return strconv.Atoi(value)
+		}
+		return 0, nil
+	}
+	getDuration := func(name string) (*resticDuration, error) {
+		if values, found := args.Get(name); found && len(values) > 0 {
+			return parseResticDuration(values[len(values)-1].Value())
+		}
+		return nil, nil
+	}
+
+	buckets := []retentionBucket{
+		{name: "last", value: nil},
+		{name: "hourly", value: func(t time.Time) int { return t.Year()*1000000 + int(t.Month())*10000 + t.Day()*100 + t.Hour() }},
+		{name: "daily", value: func(t time.Time) int { return t.Year()*10000 + int(t.Month())*100 + t.Day() }},
+		{name: "weekly", value: func(t time.Time) int { year, week := t.ISOWeek(); return year*100 + week }},
+		{name: "monthly", value: func(t time.Time) int { return t.Year()*100 + int(t.Month()) }},
+		{name: "yearly", value: func(t time.Time) int { return t.Year() }},
+	}
+	for _, bucket := range buckets {
+		if bucket.count, err = getInt("keep-" + bucket.name); err != nil {
+			return policy, fmt.Errorf("invalid value for keep-%s: %w", bucket.name, err)
+		}
+		if bucket.name != "last" {
+			if bucket.within, err = getDuration("keep-within-" + bucket.name); err != nil {
+				return policy, err
+			}
+		}
+		if bucket.count != 0 || bucket.within != nil {
+			policy.buckets = append(policy.buckets, bucket)
+		}
+	}
+	if policy.within, err = getDuration("keep-within"); err != nil {
+		return
+	}
+	return
+}
+
+func (p retentionPolicy) empty() bool {
+	return len(p.buckets) == 0 && p.within == nil
+}
+
+// apply returns the snapshots kept by the policy, snapshots must be sorted newest first
+func (p retentionPolicy) apply(snapshots []time.Time) (kept []keptSnapshot) {
+	if len(snapshots) == 0 {
+		return
+	}
+	latest := snapshots[0]
+	counts := make([]int, len(p.buckets))
+	lastValues := make([]int, len(p.buckets))
+	lastWithinValues := make([]int, len(p.buckets))
+	for i, bucket := range p.buckets {
+		counts[i] = bucket.count
+		lastValues[i] = -1
+		lastWithinValues[i] = -1
+	}
+
+	for index, snapshot := range snapshots {
+		var reasons []string
+		for i, bucket := range p.buckets {
+			value := index // "last" keeps every snapshot
+			if bucket.value != nil {
+				value = bucket.value(snapshot)
+			}
+			if counts[i] != 0 && value != lastValues[i] {
+				reasons = append(reasons, bucket.name)
+				lastValues[i] = value
+				if counts[i] > 0 {
+					counts[i]--
+				}
+			}
+			if bucket.within != nil && bucket.within.after(latest, snapshot) && value != lastWithinValues[i] {
+				reasons = append(reasons, "within "+bucket.name)
+				lastWithinValues[i] = value
+			}
+		}
+		if p.within != nil && p.within.after(latest, snapshot) {
+			reasons = append(reasons, "within "+p.within.String())
+		}
+		if len(reasons) > 0 {
+			kept = append(kept, keptSnapshot{time: snapshot, reasons: reasons})
+		}
+	}
+	return
+}
+
+// resticDuration is the duration format used by restic in keep-within: "1y2m3d4h"
+type resticDuration struct {
+	years, months, days, hours int
+	input                      string
+}
+
+var resticDurationPattern = regexp.MustCompile(`(\d+)([ymdh])`)
+
+func parseResticDuration(input string) (*resticDuration, error) {
+	duration := &resticDuration{input: input}
+	matches := resticDurationPattern.FindAllStringSubmatch(input, -1)
+	if len(matches) == 0 || resticDurationPattern.ReplaceAllString(input, "") != "" {
+		return nil, fmt.Errorf("invalid duration %q (expected format like 1y2m3d4h)", input)
+	}
+	for _, match := range matches {
+		value, _ := strconv.Atoi(match[1])
+		switch match[2] {
+		case "y":
+			duration.years += value
+		case "m":
+			duration.months += value
+		case "d":
+			duration.days += value
+		case "h":
+			duration.hours += value
+		}
+	}
+	return duration, nil
+}
+
+// after returns true when the snapshot is in the duration before latest
+func (d *resticDuration) after(latest, snapshot time.Time) bool {
+	limit := latest.AddDate(-d.years, -d.months, -d.days).Add(time.Duration(-d.hours) * time.Hour)
+	return snapshot.After(limit)
+}
+
+func (d *resticDuration) String() string {
+	return d.input
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/creativeprojects/resticprofile/shell"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsRetentionSimulation(t *testing.T) {
+	assert.True(t, isRetentionSimulation("retention", []string{"simulate"}))
+	assert.True(t, isRetentionSimulation("retention", []string{"simulate", "--days", "10"}))
+	assert.False(t, isRetentionSimulation("retention", nil))
+	assert.False(t, isRetentionSimulation("forget", []string{"simulate"}))
+}
+
+func TestParseResticDuration(t *testing.T) {
+	duration, err := parseResticDuration("1y2m3d4h")
+	require.NoError(t, err)
+	assert.Equal(t, resticDuration{years: 1, months: 2, days: 3, hours: 4, input: "1y2m3d4h"}, *duration)
+
+	_, err = parseResticDuration("3 weeks")
+	assert.Error(t, err)
+	_, err = parseResticDuration("")
+	assert.Error(t, err)
+}
+
+func TestSimulateSnapshots(t *testing.T) {
+	end := time.Date(2023, 3, 1, 0, 0, 0, 0, time.Local)
+	snapshots, err := simulateSnapshots([]string{"*-*-* 02:00", "daily"}, end.AddDate(0, 0, -10), end)
+	require.NoError(t, err)
+	assert.Len(t, snapshots, 20)
+	assert.True(t, snapshots[0].After(snapshots[1]))
+
+	_, err = simulateSnapshots([]string{"invalid"}, end.AddDate(0, 0, -10), end)
+	assert.Error(t, err)
+}
+
+func TestRetentionPolicy(t *testing.T) {
+	args := shell.NewArgs()
+	args.AddFlag("keep-last", "2", shell.ArgConfigEscape)
+	args.AddFlag("keep-daily", "3", shell.ArgConfigEscape)
+	args.AddFlag("keep-monthly", "unlimited", shell.ArgConfigEscape)
+	policy, err := newRetentionPolicy(args)
+	require.NoError(t, err)
+	require.False(t, policy.empty())
+
+	// 3 snapshots per day over 3 months
+	end := time.Date(2023, 3, 31, 23, 0, 0, 0, time.Local)
+	snapshots, err := simulateSnapshots([]string{"*-*-* 06,12,18:00"}, end.AddDate(0, -3, 0), end)
+	require.NoError(t, err)
+
+	kept := policy.apply(snapshots)
+	// 2 last + 1 daily (2 others shared with the last) + 3 monthly (1 shared)
+	require.Len(t, kept, 6)
+	assert.Equal(t, []string{"last", "daily", "monthly"}, kept[0].reasons)
+	assert.Equal(t, []string{"last"}, kept[1].reasons)
+	assert.Equal(t, []string{"daily"}, kept[2].reasons)
+	assert.Equal(t, []string{"daily"}, kept[3].reasons)
+	assert.Equal(t, []string{"monthly"}, kept[4].reasons)
+	assert.Equal(t, []string{"monthly"}, kept[5].reasons)
+}
+
+func TestRetentionPolicyEmpty(t *testing.T) {
+	args := shell.NewArgs()
+	args.AddFlag("path", "/home", shell.ArgConfigEscape)
+	policy, err := newRetentionPolicy(args)
+	require.NoError(t, err)
+	assert.True(t, policy.empty())
+
+	args.AddFlag("keep-daily", "many", shell.ArgConfigEscape)
+	_, err = newRetentionPolicy(args)
+	assert.Error(t, err)
+}
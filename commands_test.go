@@ -2,16 +2,23 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"os"
+	"runtime"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/constants"
 	"github.com/creativeprojects/resticprofile/schedule"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
 )
 
 func fakeCommands() *OwnCommands {
@@ -22,6 +29,7 @@ func fakeCommands() *OwnCommands {
 			description:       "first first",
 			action:            firstCommand,
 			needConfiguration: false,
+			readOnly:          true,
 		},
 		{
 			name:              "second",
@@ -91,6 +99,18 @@ func TestRunOwnCommand(t *testing.T) {
 	assert.EqualError(t, fakeCommands().Run(nil, "another one", commandLineFlags{}, nil), "command not found: another one")
 }
 
+func TestRunOwnCommandInReadOnlyMode(t *testing.T) {
+	flags := commandLineFlags{readOnly: true}
+	assert.EqualError(t, fakeCommands().Run(nil, "first", flags, nil), "first")
+	assert.EqualError(t, fakeCommands().Run(nil, "second", flags, nil), `command "second" is not allowed in read-only mode`)
+
+	for _, command := range getOwnCommands() {
+		if slices.Contains([]string{"schedule", "unschedule", "unmount", "collector", "rest-server"}, command.name) {
+			assert.False(t, command.readOnly, command.name)
+		}
+	}
+}
+
 func TestPanicCommand(t *testing.T) {
 	assert.Panics(t, func() {
 		_ = panicCommand(nil, commandRequest{})
@@ -187,6 +207,42 @@ schedule = "daily"
 	}
 }
 
+func TestScheduledJobArgs(t *testing.T) {
+	scheduleConfig := &config.ScheduleConfig{
+		Title:      "profile",
+		SubTitle:   "retention",
+		ConfigFile: "config.yaml",
+		Log:        "backup.log",
+		LockMode:   constants.ScheduleLockModeOptionIgnore,
+	}
+	assert.Equal(t,
+		[]string{"--no-ansi", "--config", "config.yaml", "--name", "profile", "--log", "backup.log", "--no-lock", "--schedule-name", "profile/retention", "forget"},
+		scheduledJobArgs(scheduleConfig))
+
+	scheduleConfig.Log = ""
+	scheduleConfig.LockMode = ""
+	scheduleConfig.LockWait = time.Minute
+	assert.Equal(t,
+		[]string{"--no-ansi", "--config", "config.yaml", "--name", "profile", "--lock-wait", "1m0s", "--schedule-name", "profile/retention", "forget"},
+		scheduledJobArgs(scheduleConfig))
+}
+
+func TestRunScheduleNowErrors(t *testing.T) {
+	testConfig := `
+[default.check]
+schedule = "daily"
+`
+	cfg, err := config.Load(bytes.NewBufferString(testConfig), "toml")
+	require.NoError(t, err)
+
+	run := func(name string, args ...string) error {
+		return createSchedule(io.Discard, commandRequest{config: cfg, flags: commandLineFlags{name: name}, args: args})
+	}
+	assert.ErrorContains(t, run("default", "run-now"), "missing job to run")
+	assert.EqualError(t, run("default", "run-now", "backup"), "no schedule found for command 'backup' in profile 'default'")
+	assert.EqualError(t, run("default", "run-now", "other.check"), "profile 'other' not found")
+}
+
 func TestSelectProfiles(t *testing.T) {
 	testConfig := `
 [global]
@@ -201,6 +257,10 @@ _ = 0
 _ = 0
 [3rd.backup]
 _ = 0
+[base]
+abstract = true
+[base.backup]
+schedule = "daily"
 `
 	allProfiles := []string{"default", "2nd", "3rd"}
 
@@ -225,6 +285,123 @@ _ = 0
 	assert.ElementsMatch(t, []string{"non-existing"}, selectProfiles(cfg, commandLineFlags{name: "non-existing"}, nil))
 }
 
+func TestAbstractProfiles(t *testing.T) {
+	testConfig := `
+version = "2"
+[profiles.base]
+abstract = true
+description = "base profile"
+[profiles.base.backup]
+schedule = "daily"
+[profiles.profile]
+inherit = "base"
+`
+	cfg, err := config.Load(bytes.NewBufferString(testConfig), "toml")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"profile"}, maps.Keys(getDisplayedProfiles(cfg, false)))
+	assert.ElementsMatch(t, []string{"base", "profile"}, maps.Keys(getDisplayedProfiles(cfg, true)))
+
+	buffer := &bytes.Buffer{}
+	displayProfiles(buffer, cfg, commandLineFlags{}, true)
+	assert.Contains(t, buffer.String(), "(abstract) base profile")
+
+	base, err := cfg.GetProfile("base")
+	require.NoError(t, err)
+	assert.EqualError(t, checkAbstractProfile(base, "scheduled"), "profile 'base' is abstract: it can be inherited but cannot be scheduled")
+
+	profile, err := cfg.GetProfile("profile")
+	require.NoError(t, err)
+	assert.NoError(t, checkAbstractProfile(profile, "scheduled"))
+	assert.Len(t, profile.Schedules(), 1)
+
+	err = createSchedule(io.Discard, commandRequest{config: cfg, flags: commandLineFlags{name: "base"}})
+	assert.ErrorContains(t, err, "is abstract")
+}
+
+func TestJSONOutput(t *testing.T) {
+	testConfig := `
+[groups]
+all = ["first", "second"]
+[first]
+description = "first profile"
+[first.backup]
+schedule = "daily"
+[first.check]
+schedule = ["weekly", "monthly"]
+[second.backup]
+_ = 0
+`
+	cfg, err := config.Load(bytes.NewBufferString(testConfig), "toml")
+	require.NoError(t, err)
+
+	t.Run("profiles", func(t *testing.T) {
+		buffer := &bytes.Buffer{}
+		err := displayProfilesCommand(buffer, commandRequest{config: cfg, args: []string{"--json"}})
+		require.NoError(t, err)
+
+		result := profilesJSON{}
+		require.NoError(t, json.Unmarshal(buffer.Bytes(), &result))
+		assert.Equal(t, []profileJSON{
+			{Name: "first", Description: "first profile", Sections: []string{"backup", "check"}},
+			{Name: "second", Sections: []string{"backup"}},
+		}, result.Profiles)
+		assert.Equal(t, []groupJSON{{Name: "all", Profiles: []string{"first", "second"}}}, result.Groups)
+	})
+
+	t.Run("schedules", func(t *testing.T) {
+		buffer := &bytes.Buffer{}
+		err := displaySchedulesCommand(buffer, commandRequest{config: cfg, flags: commandLineFlags{name: "first"}, args: []string{"--json"}})
+		require.NoError(t, err)
+
+		result := make([]scheduleJSON, 0)
+		require.NoError(t, json.Unmarshal(buffer.Bytes(), &result))
+		require.Len(t, result, 2)
+		for _, schedule := range result {
+			assert.Equal(t, "first", schedule.Profile)
+			assert.NotNil(t, schedule.Next)
+		}
+		commands := []string{result[0].Command, result[1].Command}
+		assert.ElementsMatch(t, []string{"backup", "check"}, commands)
+	})
+
+	t.Run("schedules of all profiles", func(t *testing.T) {
+		buffer := &bytes.Buffer{}
+		err := displaySchedulesCommand(buffer, commandRequest{config: cfg, flags: commandLineFlags{name: "second"}, args: []string{"--all", "--json"}})
+		require.NoError(t, err)
+
+		result := make([]scheduleJSON, 0)
+		require.NoError(t, json.Unmarshal(buffer.Bytes(), &result))
+		assert.Len(t, result, 2)
+	})
+
+	t.Run("schedules as text", func(t *testing.T) {
+		buffer := &bytes.Buffer{}
+		err := displaySchedulesCommand(buffer, commandRequest{config: cfg, flags: commandLineFlags{name: "first"}})
+		require.NoError(t, err)
+		assert.Contains(t, buffer.String(), "schedule first-backup")
+		assert.Contains(t, buffer.String(), "schedule first-check")
+	})
+
+	t.Run("next", func(t *testing.T) {
+		now := time.Date(2023, 5, 10, 12, 30, 0, 0, time.Local)
+		schedule := newScheduleJSON(&config.ScheduleConfig{Title: "p", SubTitle: "backup", Schedules: []string{"*-*-* 20:00", "*-*-* 14:00", "invalid"}}, now)
+		require.NotNil(t, schedule.Next)
+		assert.Equal(t, time.Date(2023, 5, 10, 14, 0, 0, 0, time.Local), *schedule.Next)
+	})
+
+	t.Run("version", func(t *testing.T) {
+		buffer := &bytes.Buffer{}
+		err := displayVersion(buffer, commandRequest{args: []string{"--json"}})
+		require.NoError(t, err)
+
+		result := versionJSON{}
+		require.NoError(t, json.Unmarshal(buffer.Bytes(), &result))
+		assert.Equal(t, version, result.Version)
+		assert.Equal(t, runtime.GOOS, result.OS)
+	})
+}
+
 func TestFlagsForProfile(t *testing.T) {
 	flags := commandLineFlags{name: "_"}
 	profileFlags := flagsForProfile(flags, "test")
@@ -313,6 +490,51 @@ func TestGenerateCommand(t *testing.T) {
 		assert.Equal(t, 684, len(strings.TrimSpace(buffer.String())))
 	})
 
+	t.Run("--example", func(t *testing.T) {
+		buffer.Reset()
+		assert.NoError(t, generateCommand(buffer, commandRequest{args: []string{"--example"}}))
+		assert.Equal(t, "available examples: b2-launchd, local-windows, s3-systemd\n", buffer.String())
+
+		for _, name := range listExamples() {
+			buffer.Reset()
+			args := []string{"--example", name, "--profile", "test", "--source", "/one", "--source", "/two"}
+			assert.NoError(t, generateCommand(buffer, commandRequest{args: args}))
+			assert.Contains(t, buffer.String(), "\n      - \"/one\"\n      - \"/two\"\n")
+
+			c, err := config.Load(strings.NewReader(buffer.String()), "yaml")
+			require.NoError(t, err)
+			profile, err := c.GetProfile("test")
+			require.NoError(t, err)
+			assert.Equal(t, []string{"/one", "/two"}, profile.Backup.Source)
+			assert.NotEmpty(t, profile.Backup.Schedule)
+		}
+
+		t.Run("special characters", func(t *testing.T) {
+			buffer.Reset()
+			args := []string{"--example", "s3-systemd",
+				"--profile", "my: profile",
+				"--repository", `s3:host/bucket # "main"`,
+				"--password-file", "/etc/pass'word: #1",
+				"--source", `/home/"user"`,
+				"--schedule", "*-*-* 01:30 # daily",
+			}
+			require.NoError(t, generateCommand(buffer, commandRequest{args: args}))
+
+			c, err := config.Load(strings.NewReader(buffer.String()), "yaml")
+			require.NoError(t, err)
+			profile, err := c.GetProfile("my: profile")
+			require.NoError(t, err)
+			assert.Equal(t, `s3:host/bucket # "main"`, profile.Repository.Value())
+			assert.Equal(t, "/etc/pass'word: #1", profile.PasswordFile)
+			assert.Equal(t, []string{`/home/"user"`}, profile.Backup.Source)
+			assert.Equal(t, []string{"*-*-* 01:30 # daily"}, profile.Backup.Schedule)
+		})
+
+		assert.Error(t, generateCommand(buffer, commandRequest{args: []string{"--example", "unknown"}}))
+		assert.Error(t, generateCommand(buffer, commandRequest{args: []string{"--example", "s3-systemd", "--source"}}))
+		assert.Error(t, generateCommand(buffer, commandRequest{args: []string{"--example", "s3-systemd", "--invalid", "value"}}))
+	})
+
 	t.Run("invalid-option", func(t *testing.T) {
 		buffer.Reset()
 		opts := []string{"", "invalid", "--unknown"}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/constants"
+	"github.com/creativeprojects/resticprofile/monitor/history"
+	"github.com/creativeprojects/resticprofile/util"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	defaultTrendLimit  = 30
+	trendAnomalyFactor = 2   // a value at least twice the previous one is flagged as an anomaly
+	trendBarWidth      = 30  // width of the bars of the text chart
+	trendChartWidth    = 800 // size of the charts of the HTML page
+	trendChartHeight   = 200
+	trendChartPadding  = 10
+
+	trendSizeDoubled     = "size doubled"
+	trendDurationDoubled = "duration doubled"
+)
+
+//go:embed contrib/templates/trend.gohtml
+var trendPage string
+
+var trendTemplate = template.Must(template.New("trend").Funcs(template.FuncMap{
+	"formatBytes": util.FormatBytes,
+	"formatSeconds": func(seconds float64) string {
+		return util.FormatDuration(time.Duration(seconds * float64(time.Second)).Round(time.Second))
+	},
+	"join": strings.Join,
+}).Parse(trendPage))
+
+// trendPoint is one backup of the trend
+type trendPoint struct {
+	Time           time.Time `json:"time"`
+	Size           uint64    `json:"size"`
+	Added          uint64    `json:"added"`
+	Duration       float64   `json:"duration"`
+	RepositorySize uint64    `json:"repository_size,omitempty"`
+	Anomalies      []string  `json:"anomalies,omitempty"`
+}
+
+// trendCommand displays the size and the duration of the backups of a profile over time, from its history file
+func trendCommand(output io.Writer, request commandRequest) error {
+	c := request.config
+	flags := request.flags
+	args := request.args
+
+	defer c.DisplayConfigurationIssues()
+
+	name := flags.name
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+	limit := defaultTrendLimit
+	asJSON, asHTML := false, false
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case jsonFlag:
+			asJSON = true
+		case "--html":
+			asHTML = true
+		case "--limit":
+			if i+1 >= len(args) {
+				return fmt.Errorf("missing value for %s", args[i])
+			}
+			var err error
+			if limit, err = strconv.Atoi(args[i+1]); err != nil || limit < 0 {
+				return fmt.Errorf("invalid limit: %q", args[i+1])
+			}
+			i++
+		default:
+			return fmt.Errorf("unknown flag %s for trend", args[i])
+		}
+	}
+
+	profile, err := c.GetProfile(name)
+	if err != nil {
+		if errors.Is(err, config.ErrNotFound) {
+			return fmt.Errorf("profile '%s' not found", name)
+		}
+		return fmt.Errorf("cannot load profile '%s': %w", name, err)
+	}
+	if profile.HistoryFile == "" {
+		return fmt.Errorf("profile '%s' has no history-file", profile.Name)
+	}
+	entries, err := history.NewHistory(profile.HistoryFile).List(history.Filter{Profile: profile.Name, Command: constants.CommandBackup})
+	if err != nil {
+		return err
+	}
+	points := trendPoints(entries)
+	if limit > 0 && len(points) > limit {
+		points = points[len(points)-limit:]
+	}
+
+	switch {
+	case asJSON:
+		return writeJSON(output, points)
+	case asHTML:
+		return writeTrendHTML(output, profile.Name, points)
+	default:
+		return writeTrendText(output, profile.Name, points)
+	}
+}
+
+// trendPoints returns the successful backups of the history (with or without warnings), flagging the sudden
+// increases of size or duration compared with the previous backup
+func trendPoints(entries []history.Entry) []trendPoint {
+	points := make([]trendPoint, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.Success && !entry.Warning {
+			continue
+		}
+		point := trendPoint{
+			Time:           entry.Time,
+			Size:           entry.BytesTotal,
+			Added:          entry.BytesAdded,
+			Duration:       entry.Duration,
+			RepositorySize: entry.RepositorySize,
+		}
+		if len(points) > 0 {
+			previous := points[len(points)-1]
+			if previous.Size > 0 && point.Size >= previous.Size*trendAnomalyFactor {
+				point.Anomalies = append(point.Anomalies, trendSizeDoubled)
+			}
+			if previous.Duration > 0 && point.Duration >= previous.Duration*trendAnomalyFactor {
+				point.Anomalies = append(point.Anomalies, trendDurationDoubled)
+			}
+		}
+		points = append(points, point)
+	}
+	return points
+}
+
+// trendMaximums returns the largest size and duration of the points
+func trendMaximums(points []trendPoint) (size uint64, duration float64) {
+	for _, point := range points {
+		if point.Size > size {
+			size = point.Size
+		}
+		if point.Duration > duration {
+			duration = point.Duration
+		}
+	}
+	return
+}
+
+// trendBar returns a bar of the text chart, proportional to the maximum value
+func trendBar(value, maximum float64) string {
+	if maximum <= 0 {
+		return ""
+	}
+	return strings.Repeat("#", int(value/maximum*trendBarWidth+0.5))
+}
+
+func writeTrendText(output io.Writer, profileName string, points []trendPoint) error {
+	if len(points) == 0 {
+		_, err := fmt.Fprintf(output, "no successful backup in the history of profile '%s'\n", profileName)
+		return err
+	}
+	_, _ = fmt.Fprintf(output, "Backups of profile '%s':\n\n", profileName)
+
+	maxSize, maxDuration := trendMaximums(points)
+	anomalies := 0
+	w := tabwriter.NewWriter(output, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "Time\tSize\t\tDuration\t\tAnomaly")
+	for _, point := range points {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			point.Time.Format("2006-01-02 15:04"),
+			util.FormatBytes(point.Size),
+			trendBar(float64(point.Size), float64(maxSize)),
+			util.FormatDuration(time.Duration(point.Duration*float64(time.Second)).Round(time.Second)),
+			trendBar(point.Duration, maxDuration),
+			strings.Join(point.Anomalies, ", "),
+		)
+		if len(point.Anomalies) > 0 {
+			anomalies++
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if anomalies > 0 {
+		_, _ = fmt.Fprintf(output, "\n%d backup(s) at least %d times larger or longer than the previous one\n", anomalies, trendAnomalyFactor)
+	}
+	return nil
+}
+
+// trendChart is an SVG line chart of the HTML page
+type trendChart struct {
+	Title   string
+	Maximum string
+	Width   int
+	Height  int
+	Line    string
+	Marks   []trendMark
+}
+
+// trendMark is a point of the chart
+type trendMark struct {
+	X, Y    float64
+	Label   string
+	Anomaly bool
+}
+
+// newTrendChart places the values on the chart, the maximum value at the top
+func newTrendChart(title, maximum string, points []trendPoint, value func(trendPoint) float64, anomaly string) trendChart {
+	chart := trendChart{Title: title, Maximum: maximum, Width: trendChartWidth, Height: trendChartHeight}
+	top := 0.0
+	for _, point := range points {
+		if value(point) > top {
+			top = value(point)
+		}
+	}
+	coordinates := make([]string, 0, len(points))
+	for index, point := range points {
+		x := float64(trendChartWidth) / 2
+		if len(points) > 1 {
+			x = trendChartPadding + float64(index)*float64(trendChartWidth-2*trendChartPadding)/float64(len(points)-1)
+		}
+		y := float64(trendChartHeight - trendChartPadding)
+		if top > 0 {
+			y -= value(point) / top * float64(trendChartHeight-2*trendChartPadding)
+		}
+		coordinates = append(coordinates, fmt.Sprintf("%.1f,%.1f", x, y))
+		chart.Marks = append(chart.Marks, trendMark{
+			X:       x,
+			Y:       y,
+			Label:   point.Time.Format("2006-01-02 15:04"),
+			Anomaly: slices.Contains(point.Anomalies, anomaly),
+		})
+	}
+	chart.Line = strings.Join(coordinates, " ")
+	return chart
+}
+
+func writeTrendHTML(output io.Writer, profileName string, points []trendPoint) error {
+	maxSize, maxDuration := trendMaximums(points)
+	data := struct {
+		Profile string
+		Charts  []trendChart
+		Points  []trendPoint
+	}{
+		Profile: profileName,
+		Points:  points,
+	}
+	if len(points) > 0 {
+		data.Charts = []trendChart{
+			newTrendChart("Size", util.FormatBytes(maxSize), points, func(point trendPoint) float64 { return float64(point.Size) }, trendSizeDoubled),
+			newTrendChart("Duration", util.FormatDuration(time.Duration(maxDuration*float64(time.Second)).Round(time.Second)), points, func(point trendPoint) float64 { return point.Duration }, trendDurationDoubled),
+		}
+	}
+	return trendTemplate.Execute(output, data)
+}
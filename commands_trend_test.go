@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/monitor/history"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrendPoints(t *testing.T) {
+	now := time.Now()
+	points := trendPoints([]history.Entry{
+		{Time: now.Add(-4 * time.Hour), Command: "backup", Success: true, BytesTotal: 1000, Duration: 10},
+		{Time: now.Add(-3 * time.Hour), Command: "backup", Error: "failed", BytesTotal: 5000, Duration: 1},
+		{Time: now.Add(-2 * time.Hour), Command: "backup", Warning: true, BytesTotal: 1500, Duration: 25},
+		{Time: now.Add(-time.Hour), Command: "backup", Success: true, BytesTotal: 3000, Duration: 30},
+		{Time: now, Command: "backup", Success: true, BytesTotal: 3100, Duration: 30},
+	})
+	require.Len(t, points, 4)
+	assert.Empty(t, points[0].Anomalies)
+	assert.Equal(t, []string{trendDurationDoubled}, points[1].Anomalies)
+	assert.Equal(t, []string{trendSizeDoubled}, points[2].Anomalies)
+	assert.Empty(t, points[3].Anomalies)
+}
+
+func TestTrendCommand(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "history.jsonl")
+	testConfig := fmt.Sprintf(`
+[profile]
+history-file = %q
+[other]
+`, filename)
+	cfg, err := config.Load(bytes.NewBufferString(testConfig), "toml")
+	require.NoError(t, err)
+
+	store := history.NewHistory(filename)
+	now := time.Now()
+	require.NoError(t, store.Add(history.Entry{Time: now.Add(-48 * time.Hour), Profile: "profile", Command: "backup", Success: true, Duration: 60, BytesTotal: 1024 * 1024}))
+	require.NoError(t, store.Add(history.Entry{Time: now.Add(-24 * time.Hour), Profile: "profile", Command: "check", Success: true, Duration: 5}))
+	require.NoError(t, store.Add(history.Entry{Time: now, Profile: "profile", Command: "backup", Success: true, Duration: 90, BytesTotal: 3 * 1024 * 1024, RepositorySize: 2048}))
+
+	run := func(name string, args ...string) (string, error) {
+		buffer := &bytes.Buffer{}
+		err := trendCommand(buffer, commandRequest{config: cfg, flags: commandLineFlags{name: name}, args: args})
+		return buffer.String(), err
+	}
+
+	t.Run("text", func(t *testing.T) {
+		output, err := run("default", "profile")
+		require.NoError(t, err)
+		lines := strings.Split(strings.TrimSpace(output), "\n")
+		require.Len(t, lines, 7)
+		assert.Equal(t, "Backups of profile 'profile':", lines[0])
+		assert.Regexp(t, `^Time\s+Size\s+Duration\s+Anomaly$`, lines[2])
+		assert.Regexp(t, `\s1\.0 MiB\s+#{10}\s+1m0s\s+#{20}\s*$`, lines[3])
+		assert.Regexp(t, `\s3\.0 MiB\s+#{30}\s+1m30s\s+#{30}\s+size doubled$`, lines[4])
+		assert.Equal(t, "1 backup(s) at least 2 times larger or longer than the previous one", lines[6])
+	})
+
+	t.Run("json", func(t *testing.T) {
+		output, err := run("profile", "--json", "--limit", "1")
+		require.NoError(t, err)
+		points := []trendPoint{}
+		require.NoError(t, json.Unmarshal([]byte(output), &points))
+		require.Len(t, points, 1)
+		assert.Equal(t, uint64(2048), points[0].RepositorySize)
+		assert.Equal(t, []string{trendSizeDoubled}, points[0].Anomalies)
+	})
+
+	t.Run("html", func(t *testing.T) {
+		output, err := run("profile", "--html")
+		require.NoError(t, err)
+		assert.Contains(t, output, "<h1>Backups of profile profile</h1>")
+		assert.Contains(t, output, `<polyline points="10.0,130.0 790.0,10.0"/>`)
+		assert.Contains(t, output, `class="anomaly"`)
+		assert.Contains(t, output, "<td>size doubled</td>")
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		output, err := run("other")
+		assert.ErrorContains(t, err, "has no history-file")
+		assert.Empty(t, output)
+
+		_, err = run("missing")
+		assert.EqualError(t, err, "profile 'missing' not found")
+
+		_, err = run("profile", "--limit")
+		assert.EqualError(t, err, "missing value for --limit")
+
+		_, err = run("profile", "--limit", "-1")
+		assert.EqualError(t, err, `invalid limit: "-1"`)
+
+		_, err = run("profile", "--csv")
+		assert.EqualError(t, err, "unknown flag --csv for trend")
+	})
+}
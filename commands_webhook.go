@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/term"
+	"github.com/creativeprojects/resticprofile/webhook"
+)
+
+const (
+	defaultWebhookListen   = ":8091"
+	webhookSecretEnv       = "RESTICPROFILE_WEBHOOK_SECRET"
+	webhookShutdownTimeout = 10 * time.Second
+)
+
+// webhookCommand runs the HTTP server receiving the signed requests triggering the commands of the profiles
+func webhookCommand(_ io.Writer, request commandRequest) error {
+	global, err := request.config.GetGlobalSection()
+	if err != nil {
+		return fmt.Errorf("cannot load global section: %w", err)
+	}
+	listen := global.WebhookListen
+	if listen == "" {
+		listen = defaultWebhookListen
+	}
+	args := request.args
+	for i := 0; i < len(args); i++ {
+		if i+1 >= len(args) {
+			return fmt.Errorf("missing value for %s", args[i])
+		}
+		switch args[i] {
+		case "--listen":
+			listen = args[i+1]
+		default:
+			return fmt.Errorf("unknown flag %s for webhook", args[i])
+		}
+		i++
+	}
+
+	secret, err := getWebhookSecret(global)
+	if err != nil {
+		return err
+	}
+	networks, err := webhook.ParseNetworks(global.WebhookAllowFrom)
+	if err != nil {
+		return fmt.Errorf("webhook-allow-from: %w", err)
+	}
+	if len(global.WebhookAllow) == 0 {
+		clog.Warning("webhook: \"webhook-allow\" is empty, no command can be triggered")
+	}
+	handler, err := webhook.NewServer(webhook.Options{
+		Secret:    secret,
+		Allow:     global.WebhookAllow,
+		AllowFrom: networks,
+		Exists: func(name string) bool {
+			return request.config.HasProfile(name) || request.config.HasProfileGroup(name)
+		},
+		Runner: newWebhookRunner(request.config.GetConfigFile()),
+	})
+	if err != nil {
+		return fmt.Errorf("webhook: %w", err)
+	}
+	server := &http.Server{
+		Addr:              listen,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), webhookShutdownTimeout)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	clog.Infof("webhook: listening on %s", listen)
+	if err = server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("webhook: %w", err)
+	}
+	clog.Info("webhook: waiting for the running commands")
+	handler.Wait()
+	clog.Info("webhook: stopped")
+	return nil
+}
+
+// getWebhookSecret returns the secret key from the environment, or from the file of "webhook-secret-file"
+func getWebhookSecret(global *config.Global) ([]byte, error) {
+	if secret := os.Getenv(webhookSecretEnv); secret != "" {
+		return []byte(secret), nil
+	}
+	if global.WebhookSecretFile == "" {
+		return nil, fmt.Errorf("webhook: a secret is required, set \"webhook-secret-file\" in the global section or the environment variable %s", webhookSecretEnv)
+	}
+	content, err := os.ReadFile(global.WebhookSecretFile)
+	if err != nil {
+		return nil, fmt.Errorf("webhook: cannot read the secret: %w", err)
+	}
+	secret := bytes.TrimSpace(content)
+	if len(secret) == 0 {
+		return nil, fmt.Errorf("webhook: the secret file %q is empty", global.WebhookSecretFile)
+	}
+	return secret, nil
+}
+
+// newWebhookRunner returns a runner starting the command of the profile in a new resticprofile process
+func newWebhookRunner(configFile string) webhook.Runner {
+	return func(profile, command string) error {
+		binary, err := os.Executable()
+		if err != nil {
+			return err
+		}
+		args := []string{"--config", configFile, "--name", profile, command}
+		clog.Debugf("webhook: %s %s", binary, strings.Join(args, " "))
+		cmd := exec.Command(binary, args...)
+		cmd.Stdout = term.GetOutput()
+		cmd.Stderr = term.GetErrorOutput()
+		return cmd.Run()
+	}
+}
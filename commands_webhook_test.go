@@ -0,0 +1,37 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetWebhookSecret(t *testing.T) {
+	t.Setenv(webhookSecretEnv, "")
+	global := config.NewGlobal()
+
+	_, err := getWebhookSecret(global)
+	assert.ErrorContains(t, err, "a secret is required")
+
+	global.WebhookSecretFile = filepath.Join(t.TempDir(), "secret")
+	_, err = getWebhookSecret(global)
+	assert.ErrorContains(t, err, "cannot read the secret")
+
+	require.NoError(t, os.WriteFile(global.WebhookSecretFile, []byte(" \n"), 0o600))
+	_, err = getWebhookSecret(global)
+	assert.ErrorContains(t, err, "is empty")
+
+	require.NoError(t, os.WriteFile(global.WebhookSecretFile, []byte("from-file\n"), 0o600))
+	secret, err := getWebhookSecret(global)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("from-file"), secret)
+
+	t.Setenv(webhookSecretEnv, "from-env")
+	secret, err = getWebhookSecret(global)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("from-env"), secret)
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/restic"
+)
+
+// getCompatibilityNotices returns a message for each flag of the profile that is not supported by the restic version,
+// for each invalid repository option (compression and pack size), for each flag set twice (other flags shadowing an option),
+// for each issue with the exclusion options of the backup (exclude-caches, exclude-if-present and one-file-system),
+// for each invalid setting of the restic process (max-memory) and of the quota of the repository.
+// Unsupported flags are not checked when the version is unknown or more recent than the versions known by resticprofile.
+func getCompatibilityNotices(profile *config.Profile, resticVersion string) (notices []string) {
+	if profile == nil {
+		return
+	}
+	if restic.KnowsVersion(resticVersion) {
+		for _, err := range profile.GetUnsupportedFlags() {
+			notices = append(notices, "profile '"+profile.Name+"': "+err.Error())
+		}
+	}
+	issues := append(profile.GetRepositoryOptionIssues(), profile.GetShadowedFlags()...)
+	issues = append(issues, profile.GetBackupOptionIssues()...)
+	issues = append(issues, profile.GetProcessIssues()...)
+	issues = append(issues, profile.GetQuotaIssues()...)
+	issues = append(issues, profile.GetAnomalyIssues()...)
+	issues = append(issues, profile.GetPasswordVaultIssues()...)
+	issues = append(issues, profile.GetMQTTIssues()...)
+	for _, issue := range issues {
+		notices = append(notices, "profile '"+profile.Name+"': "+issue)
+	}
+	return
+}
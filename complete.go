@@ -11,6 +11,7 @@ import (
 	"github.com/creativeprojects/resticprofile/config"
 	"github.com/creativeprojects/resticprofile/filesearch"
 	"github.com/spf13/pflag"
+	"golang.org/x/exp/slices"
 )
 
 const (
@@ -27,6 +28,7 @@ type Completer struct {
 	flagsInArgs           []*pflag.Flag
 	ownCommands           []ownCommand
 	profiles              []string
+	labels                []string
 	enableProfilePrefixes bool
 }
 
@@ -48,6 +50,7 @@ func (c *Completer) init(args []string, ownCommands []ownCommand) {
 	c.flagsInArgs = nil
 	c.ownCommands = ownCommands
 	c.profiles = nil
+	c.labels = nil
 	c.enableProfilePrefixes = !nameFlagFound
 }
 
@@ -100,7 +103,19 @@ func (c *Completer) completeFlagSetValue(flag *pflag.Flag, word string) (complet
 	case "theme":
 		list = []string{"dark", "light", "none"}
 
-	case "config":
+	case "profiles":
+		list = c.listLabels()
+
+	case "exit-code-from":
+		list = exitCodeFromValues
+
+	case "expect-config-hash":
+		list = []string{config.SourceHashPrefix, config.RenderedHashPrefix}
+
+	case "health-listen":
+		list = []string{defaultHealthListen, "127.0.0.1" + defaultHealthListen}
+
+	case "config", "config-dir":
 		fallthrough
 	case "log":
 		completions = []string{RequestFileCompletion}
@@ -110,28 +125,37 @@ func (c *Completer) completeFlagSetValue(flag *pflag.Flag, word string) (complet
 	return
 }
 
+// loadConfiguration loads the configuration file from the flags, or returns nil when it cannot be loaded
+func (c *Completer) loadConfiguration() *config.Config {
+	filename := ""
+	format := ""
+	if configFlag := c.flags.Lookup("config"); configFlag != nil {
+		filename = configFlag.Value.String()
+	}
+	if formatFlag := c.flags.Lookup("format"); formatFlag != nil {
+		format = formatFlag.Value.String()
+	}
+
+	file, err := filesearch.FindConfigurationFile(filename)
+	if err != nil {
+		clog.Debug(err)
+		return nil
+	}
+	conf, err := config.LoadFile(file, format)
+	if err != nil {
+		clog.Debug(err)
+		return nil
+	}
+	return conf
+}
+
 func (c *Completer) listProfileNames() (list []string) {
 	if c.profiles == nil {
-		filename := ""
-		format := ""
-		if configFlag := c.flags.Lookup("config"); configFlag != nil {
-			filename = configFlag.Value.String()
-		}
-		if formatFlag := c.flags.Lookup("format"); formatFlag != nil {
-			format = formatFlag.Value.String()
-		}
-
-		if file, err := filesearch.FindConfigurationFile(filename); err == nil {
-			if conf, err := config.LoadFile(file, format); err == nil {
-				list = append(list, conf.GetProfileNames()...)
-				for name, _ := range conf.GetProfileGroups() {
-					list = append(list, name)
-				}
-			} else {
-				clog.Debug(err)
+		if conf := c.loadConfiguration(); conf != nil {
+			list = append(list, conf.GetProfileNames()...)
+			for name, _ := range conf.GetProfileGroups() {
+				list = append(list, name)
 			}
-		} else {
-			clog.Debug(err)
 		}
 
 		if list == nil {
@@ -146,6 +170,28 @@ func (c *Completer) listProfileNames() (list []string) {
 	return
 }
 
+// listLabels returns the labels of the profiles ("key=value")
+func (c *Completer) listLabels() []string {
+	if c.labels == nil {
+		c.labels = make([]string, 0)
+		if conf := c.loadConfiguration(); conf != nil {
+			for _, name := range conf.GetProfileNames() {
+				profile, err := conf.GetProfile(name)
+				if err != nil || conf.IsAbstractProfile(name) {
+					continue
+				}
+				for key, value := range profile.Labels {
+					if label := key + "=" + value; !slices.Contains(c.labels, label) {
+						c.labels = append(c.labels, label)
+					}
+				}
+			}
+		}
+		sort.Strings(c.labels)
+	}
+	return c.labels
+}
+
 func (c *Completer) completeProfileNamePrefixes(word string) (completions []string) {
 	if c.enableProfilePrefixes {
 		for _, profile := range c.listProfileNames() {
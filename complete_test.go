@@ -6,6 +6,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/creativeprojects/resticprofile/util/collect"
 	"github.com/spf13/pflag"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -58,7 +59,12 @@ func TestCompleter(t *testing.T) {
 
 			t.Run("ReturnsSpecificFlags", func(t *testing.T) {
 				flagSet.VisitAll(func(flag *pflag.Flag) {
-					expected := flagCompletion(flag, false)
+					var expected []string
+					flagSet.VisitAll(func(other *pflag.Flag) {
+						if !other.Hidden && strings.HasPrefix(other.Name, flag.Name) {
+							expected = append(expected, flagCompletion(other, false)...)
+						}
+					})
 					if flag.Hidden {
 						expected = nil
 					}
@@ -221,7 +227,9 @@ func TestCompleter(t *testing.T) {
 
 			t.Run("SpecificCommands", func(t *testing.T) {
 				for _, command := range commands {
-					assert.Equal(t, []string{command}, completer.completeOwnCommands(command))
+					// "schedule" also completes to "schedules"
+					expected := collect.All(commands, func(c string) bool { return strings.HasPrefix(c, command) })
+					assert.Equal(t, expected, completer.completeOwnCommands(command))
 				}
 			})
 		})
@@ -252,7 +260,7 @@ func TestCompleter(t *testing.T) {
 		}{
 			// Can complete by prefix
 			{args: []string{"ful"}, expected: []string{"full-backup.", RequestResticCompletion}},
-			{args: []string{"sched"}, expected: []string{"schedule", RequestResticCompletion}},
+			{args: []string{"sched"}, expected: []string{"schedule", "schedules", RequestResticCompletion}},
 			{args: []string{"unsch"}, expected: []string{"unschedule", RequestResticCompletion}},
 			{args: []string{"--nam"}, expected: []string{"--name"}},
 			{args: []string{"--theme", "d"}, expected: []string{"dark"}},
@@ -260,19 +268,19 @@ func TestCompleter(t *testing.T) {
 			// Can complete shorthand
 			{args: []string{"-c"}, expected: []string{RequestFileCompletion}},
 			{args: []string{"-l"}, expected: []string{RequestFileCompletion}},
-			{args: []string{"self-update", "-"}, expected: []string{"--quiet", "-q"}},
+			{args: []string{"self-update", "-"}, expected: []string{"--channel", "--check-only", "--checksums", "--from-file", "--proxy", "--public-key", "--quiet", "-q"}},
 			{args: []string{"self-update", "-q"}, expected: nil},
 
 			// Can completion commands after flags
-			{args: []string{"--verbose", "schedule", "-"}, expected: []string{"--all", "--no-start"}},
-			{args: []string{"--log", "file", "schedule", "-"}, expected: []string{"--all", "--no-start"}},
+			{args: []string{"--verbose", "schedule", "-"}, expected: []string{"--all", "--duration", "--from", "--no-start", "--to"}},
+			{args: []string{"--log", "file", "schedule", "-"}, expected: []string{"--all", "--duration", "--from", "--no-start", "--to"}},
 
 			// Flags are returned only once
 			{args: []string{"--verb"}, expected: []string{"--verbose"}},
 			{args: []string{"--verb", "--verb"}, expected: []string{"--verbose"}},
 			{args: []string{"--verbose", "--verb"}, expected: nil},
-			{args: []string{"schedule", "-"}, expected: []string{"--all", "--no-start"}},
-			{args: []string{"schedule", "--all", "-"}, expected: []string{"--no-start"}},
+			{args: []string{"schedule", "-"}, expected: []string{"--all", "--duration", "--from", "--no-start", "--to"}},
+			{args: []string{"schedule", "--all", "-"}, expected: []string{"--duration", "--from", "--no-start", "--to"}},
 
 			// Exact command match returns nothing (no duplication)
 			{args: []string{"schedule"}, expected: nil},
@@ -288,9 +296,9 @@ func TestCompleter(t *testing.T) {
 			{args: []string{"__POS:2", "--log", "out.log", "--verbose", "schedule", "-"}, expected: []string{RequestFileCompletion}},
 			{args: []string{"__POS:4", "--log", "out.log", "--verbose", "schedule", "-"}, expected: nil},
 			{args: []string{"__POS:4", "--log", "out.log", "--verbose", "schedule"}, expected: nil},
-			{args: []string{"__POS:5", "--log", "out.log", "--verbose", "schedule", "-"}, expected: []string{"--all", "--no-start"}},
-			{args: []string{"__POS:5", "--log", "out.log", "--verbose", "schedule"}, expected: []string{"--all", "--no-start"}},
-			{args: []string{"__POS:INVALID", "--log", "out.log", "--verbose", "schedule", "-"}, expected: []string{"--all", "--no-start"}},
+			{args: []string{"__POS:5", "--log", "out.log", "--verbose", "schedule", "-"}, expected: []string{"--all", "--duration", "--from", "--no-start", "--to"}},
+			{args: []string{"__POS:5", "--log", "out.log", "--verbose", "schedule"}, expected: []string{"--all", "--duration", "--from", "--no-start", "--to"}},
+			{args: []string{"__POS:INVALID", "--log", "out.log", "--verbose", "schedule", "-"}, expected: []string{"--all", "--duration", "--from", "--no-start", "--to"}},
 			{args: []string{"__POS:INVALID", "--log", "out.log", "--verbose", "schedule"}, expected: nil},
 
 			// Unknown is delegated to restic
@@ -300,10 +308,10 @@ func TestCompleter(t *testing.T) {
 			{args: []string{"--log", "file", "unknown-cmd", "--a-flag"}, expected: []string{RequestResticCompletion}},
 
 			// Adds profile prefixes (for existing profiles, when name flag is not set)
-			{args: []string{"unknown-profile.schedul"}, expected: []string{"schedule", RequestResticCompletion}},
-			{args: []string{"full-backup.schedul"}, expected: []string{"full-backup.schedule", "full-backup." + RequestResticCompletion}},
-			{args: []string{"--name", "something", "full-backup.schedul"}, expected: []string{"schedule", RequestResticCompletion}},
-			{args: []string{"-n", "something", "full-backup.schedul"}, expected: []string{"schedule", RequestResticCompletion}},
+			{args: []string{"unknown-profile.schedul"}, expected: []string{"schedule", "schedules", RequestResticCompletion}},
+			{args: []string{"full-backup.schedul"}, expected: []string{"full-backup.schedule", "full-backup.schedules", "full-backup." + RequestResticCompletion}},
+			{args: []string{"--name", "something", "full-backup.schedul"}, expected: []string{"schedule", "schedules", RequestResticCompletion}},
+			{args: []string{"-n", "something", "full-backup.schedul"}, expected: []string{"schedule", "schedules", RequestResticCompletion}},
 			{args: []string{"full-backup.unknown-cmd"}, expected: []string{"full-backup." + RequestResticCompletion}},
 
 			// Regression: Flag value completion does not complete flags matching value names
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Default settings of the detection of anomalies
+const (
+	defaultAnomalyWindow     = 10
+	defaultAnomalyMinBackups = 3
+	defaultAnomalyDeviation  = 50
+	defaultAnomalyMaxChanged = 50
+)
+
+// AnomalySection compares the statistics of each new snapshot with the rolling averages of the previous backups
+type AnomalySection struct {
+	Window         int                     `mapstructure:"window" default:"10" range:"[1:]" description:"Number of previous backups in the rolling averages"`
+	MinBackups     int                     `mapstructure:"min-backups" default:"3" range:"[1:]" description:"Number of previous backups needed before detecting anomalies"`
+	FilesDeviation string                  `mapstructure:"files-deviation" default:"50%" examples:"30%;50%;100%" description:"Difference with the average number of files over which an anomaly is raised (0 to disable)"`
+	SizeDeviation  string                  `mapstructure:"size-deviation" default:"50%" examples:"30%;50%;100%" description:"Difference with the average size over which an anomaly is raised (0 to disable)"`
+	MaxChanged     string                  `mapstructure:"max-changed" default:"50%" examples:"25%;50%;75%" description:"Share of new and modified files in the snapshot over which an anomaly is raised (0 to disable)"`
+	RunOnAnomaly   []string                `mapstructure:"run-on-anomaly" description:"Run shell command(s) when an anomaly is detected (ANOMALY, ANOMALY_FILES_AVERAGE and ANOMALY_SIZE_AVERAGE are set)"`
+	SendOnAnomaly  []SendMonitoringSection `mapstructure:"send-on-anomaly" description:"Send HTTP request(s) when an anomaly is detected - see https://creativeprojects.github.io/resticprofile/configuration/anomaly/"`
+	StateFile      string                  `mapstructure:"state-file" description:"File keeping the statistics of the previous backups (in the cache directory of the user by default)"`
+}
+
+func (a *AnomalySection) IsEmpty() bool { return a == nil }
+
+func (a *AnomalySection) setRootPath(rootPath string) {
+	a.StateFile = fixPath(a.StateFile, expandEnv, absolutePrefix(rootPath))
+	for index, section := range a.SendOnAnomaly {
+		a.SendOnAnomaly[index].BodyTemplate = fixPath(section.BodyTemplate, expandEnv, absolutePrefix(rootPath))
+	}
+}
+
+// GetWindow returns the number of previous backups in the rolling averages
+func (a *AnomalySection) GetWindow() int {
+	if a == nil || a.Window <= 0 {
+		return defaultAnomalyWindow
+	}
+	return a.Window
+}
+
+// GetMinBackups returns the number of previous backups needed before detecting anomalies (never more than the window)
+func (a *AnomalySection) GetMinBackups() int {
+	minBackups := defaultAnomalyMinBackups
+	if a != nil && a.MinBackups > 0 {
+		minBackups = a.MinBackups
+	}
+	if window := a.GetWindow(); minBackups > window {
+		return window
+	}
+	return minBackups
+}
+
+// GetFilesDeviation returns the difference (in percent) with the average number of files raising an anomaly (0 when disabled)
+func (a *AnomalySection) GetFilesDeviation() (float64, error) {
+	if a == nil {
+		return defaultAnomalyDeviation, nil
+	}
+	return parseAnomalyPercent(a.FilesDeviation, defaultAnomalyDeviation, false)
+}
+
+// GetSizeDeviation returns the difference (in percent) with the average size raising an anomaly (0 when disabled)
+func (a *AnomalySection) GetSizeDeviation() (float64, error) {
+	if a == nil {
+		return defaultAnomalyDeviation, nil
+	}
+	return parseAnomalyPercent(a.SizeDeviation, defaultAnomalyDeviation, false)
+}
+
+// GetMaxChanged returns the share (in percent) of new and modified files raising an anomaly (0 when disabled)
+func (a *AnomalySection) GetMaxChanged() (float64, error) {
+	if a == nil {
+		return defaultAnomalyMaxChanged, nil
+	}
+	return parseAnomalyPercent(a.MaxChanged, defaultAnomalyMaxChanged, true)
+}
+
+// parseAnomalyPercent reads a percentage ("50%" or "50"), returning the default value when not set
+func parseAnomalyPercent(value string, defaultValue float64, upTo100 bool) (float64, error) {
+	if strings.TrimSpace(value) == "" {
+		return defaultValue, nil
+	}
+	number := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(value), "%"))
+	percent, err := strconv.ParseFloat(number, 64)
+	if err != nil || percent < 0 || (upTo100 && percent > 100) {
+		return 0, fmt.Errorf("invalid percentage %q", value)
+	}
+	return percent, nil
+}
+
+// GetAnomalyIssues returns the issues with the detection of anomalies of the profile
+func (p *Profile) GetAnomalyIssues() (issues []string) {
+	if p.Anomaly == nil {
+		return
+	}
+	if _, err := p.Anomaly.GetFilesDeviation(); err != nil {
+		issues = append(issues, fmt.Sprintf("anomaly: files-deviation: %s", err))
+	}
+	if _, err := p.Anomaly.GetSizeDeviation(); err != nil {
+		issues = append(issues, fmt.Sprintf("anomaly: size-deviation: %s", err))
+	}
+	if _, err := p.Anomaly.GetMaxChanged(); err != nil {
+		issues = append(issues, fmt.Sprintf("anomaly: max-changed: %s", err))
+	}
+	return
+}
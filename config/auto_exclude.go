@@ -0,0 +1,109 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/shell"
+	"github.com/creativeprojects/resticprofile/util/bools"
+)
+
+const (
+	resticCacheDirEnv = "RESTIC_CACHE_DIR"
+	localPrefix       = "local:"
+)
+
+// remoteRepository matches the repositories that are not on the local filesystem ("sftp:", "rest:", "s3:", etc.)
+var remoteRepository = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9]+:`)
+
+func (b *BackupSection) getCommandFlags(profile *Profile) (flags *shell.Args) {
+	flags = profile.GetCommonFlags()
+	addArgsFromStruct(flags, b)
+	addArgsFromOtherFlags(flags, profile, b)
+	b.removeUnsupportedFlags(flags)
+
+	if b.MergeSets {
+		for _, name := range b.setNames() {
+			if set := b.Sets[name]; set != nil {
+				set.addFlags(flags)
+			}
+		}
+	}
+	if bools.IsTrueOrUndefined(b.AutoExclude) {
+		excludes := profile.autoExcludes(flags)
+		for _, path := range excludes {
+			clog.Debugf("profile '%s': excluding %q from the backup", profile.Name, path)
+		}
+		// same type as the "exclude" field of the section
+		appendFlagValues(flags, "exclude", excludes, shell.ArgConfigKeepGlobQuote)
+	}
+	return
+}
+
+// autoExcludes returns the restic cache directory and the local repositories found inside the backup source
+func (p *Profile) autoExcludes(flags *shell.Args) (excludes []string) {
+	candidates := make([]string, 0, 3)
+	if _, noCache := flags.Get("no-cache"); !noCache {
+		candidates = append(candidates, p.resticCacheDir(flags))
+	}
+	candidates = append(candidates, localRepositoryPath(p.Repository.Value()))
+	if p.Copy != nil {
+		candidates = append(candidates, localRepositoryPath(p.Copy.Repository.Value()))
+	}
+
+	for _, candidate := range candidates {
+		if candidate == "" {
+			continue
+		}
+		for _, source := range p.Backup.allSources() {
+			if isInside(candidate, source) {
+				excludes = append(excludes, candidate)
+				break
+			}
+		}
+	}
+	return
+}
+
+// resticCacheDir returns the cache directory used by restic
+func (p *Profile) resticCacheDir(flags *shell.Args) string {
+	if values, found := flags.Get("cache-dir"); found && len(values) > 0 {
+		return absolutePath(values[len(values)-1].Value())
+	}
+	for name, value := range p.Environment {
+		if strings.EqualFold(name, resticCacheDirEnv) && value.Value() != "" {
+			return absolutePath(value.Value())
+		}
+	}
+	if dir := os.Getenv(resticCacheDirEnv); dir != "" {
+		return absolutePath(dir)
+	}
+	if dir, err := os.UserCacheDir(); err == nil {
+		return filepath.Join(dir, "restic")
+	}
+	return ""
+}
+
+// localRepositoryPath returns the path of a repository on the local filesystem, or an empty string
+func localRepositoryPath(repository string) string {
+	if strings.HasPrefix(repository, localPrefix) {
+		return absolutePath(strings.TrimPrefix(repository, localPrefix))
+	}
+	if repository == "" || (remoteRepository.MatchString(repository) && !filepath.IsAbs(repository)) {
+		return ""
+	}
+	return absolutePath(repository)
+}
+
+// isInside returns true when the path is inside the source directory (but is not the source itself)
+func isInside(path, source string) bool {
+	source = absolutePath(source)
+	relative, err := filepath.Rel(source, path)
+	if err != nil || relative == "." {
+		return false
+	}
+	return relative != ".." && !strings.HasPrefix(relative, ".."+string(filepath.Separator))
+}
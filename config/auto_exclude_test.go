@@ -0,0 +1,64 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalRepositoryPath(t *testing.T) {
+	root, err := filepath.Abs("/backup")
+	require.NoError(t, err)
+	assert.Equal(t, root, localRepositoryPath("local:/backup"))
+	assert.Equal(t, root, localRepositoryPath(root))
+	assert.Equal(t, "", localRepositoryPath(""))
+	assert.Equal(t, "", localRepositoryPath("sftp:user@host:/backup"))
+	assert.Equal(t, "", localRepositoryPath("rest:https://host/"))
+	assert.Equal(t, "", localRepositoryPath("s3:s3.amazonaws.com/bucket"))
+}
+
+func TestIsInside(t *testing.T) {
+	source, err := filepath.Abs("/home")
+	require.NoError(t, err)
+	assert.True(t, isInside(filepath.Join(source, "user", ".cache", "restic"), source))
+	assert.False(t, isInside(source, source))
+	assert.False(t, isInside(filepath.Join(source+"2", "repo"), source))
+	assert.False(t, isInside(filepath.Dir(source), source))
+}
+
+func TestAutoExclude(t *testing.T) {
+	dir := t.TempDir()
+	cache := filepath.Join(dir, "cache")
+	t.Setenv(resticCacheDirEnv, cache)
+
+	load := func(t *testing.T, extra string) []string {
+		t.Helper()
+		content := fmt.Sprintf(`
+[profile]
+repository = "local:%s"
+[profile.copy]
+repository = "sftp:user@host:%s"
+[profile.backup]
+source = [%q]
+%s
+`, filepath.ToSlash(filepath.Join(dir, "repo")), filepath.ToSlash(filepath.Join(dir, "copy")), filepath.ToSlash(dir), extra)
+		c, err := Load(bytes.NewBufferString(content), FormatTOML)
+		require.NoError(t, err)
+		profile, err := c.GetProfile("profile")
+		require.NoError(t, err)
+		excludes, _ := profile.GetCommandFlags("backup").Get("exclude")
+		values := make([]string, 0, len(excludes))
+		for _, arg := range excludes {
+			values = append(values, arg.Value())
+		}
+		return values
+	}
+
+	assert.Equal(t, []string{cache, filepath.Join(dir, "repo")}, load(t, ""))
+	assert.Equal(t, []string{"*.tmp", filepath.Join(dir, "repo")}, load(t, "exclude = [\"*.tmp\"]\nno-cache = true"))
+	assert.Empty(t, load(t, "auto-exclude = false"))
+}
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/creativeprojects/resticprofile/platform"
+	"github.com/creativeprojects/resticprofile/shell"
+)
+
+// cacheDirTagFile is the name of the file marking a cache directory (see "exclude-caches")
+const cacheDirTagFile = "CACHEDIR.TAG"
+
+// maxMountPointsDisplayed is the number of mount points listed in the "one-file-system" issue
+const maxMountPointsDisplayed = 3
+
+var (
+	// isWindows can be replaced in tests
+	isWindows = platform.IsWindows
+	// getMountPoints returns the mount points of the system (can be replaced in tests)
+	getMountPoints = mountPoints
+)
+
+// GetBackupOptionIssues returns the issues with the exclude-caches, exclude-if-present and one-file-system options of the backup
+func (p *Profile) GetBackupOptionIssues() (issues []string) {
+	backup := p.Backup
+	if backup == nil {
+		return
+	}
+	for _, name := range backup.ExcludeIfPresent {
+		filename, _, _ := strings.Cut(name, ":")
+		if filename == "" {
+			issues = append(issues, fmt.Sprintf("invalid exclude-if-present %q: missing file name", name))
+		} else if strings.ContainsAny(filename, `/\`) {
+			issues = append(issues, fmt.Sprintf("invalid exclude-if-present %q: expected a file name, not a path", name))
+		} else if filename == cacheDirTagFile && backup.ExcludeCaches {
+			issues = append(issues, fmt.Sprintf("exclude-if-present %q is redundant with exclude-caches", name))
+		}
+	}
+
+	if len(backup.Sets) > 0 {
+		if !backup.MergeSets && len(backup.Source) > 0 {
+			issues = append(issues, "the source of the backup section is ignored when using backup sets without merge-sets: add it to a set instead")
+		}
+		for _, name := range backup.setNames() {
+			if set := backup.Sets[name]; set == nil || len(set.Source) == 0 {
+				issues = append(issues, fmt.Sprintf("backup set %q has no source", name))
+			}
+		}
+	}
+
+	if backup.UseStdin {
+		if backup.ExcludeCaches || len(backup.ExcludeIfPresent) > 0 || backup.OneFileSystem {
+			issues = append(issues, "exclude-caches, exclude-if-present and one-file-system have no effect when the backup reads from stdin")
+		}
+		return
+	}
+
+	if backup.OneFileSystem {
+		if isWindows() {
+			issues = append(issues, "one-file-system is not supported by restic on Windows: the option is ignored")
+		} else if mounts := backup.mountPointsInSource(); len(mounts) > 0 {
+			displayed := mounts
+			if len(displayed) > maxMountPointsDisplayed {
+				displayed = append(displayed[:maxMountPointsDisplayed:maxMountPointsDisplayed], "...")
+			}
+			issues = append(issues, fmt.Sprintf("one-file-system: %d filesystem(s) mounted inside the backup source won't be saved (including bind mounts): %s",
+				len(mounts), strings.Join(displayed, ", ")))
+		}
+	}
+	return
+}
+
+// mountPointsInSource returns the mount points found inside the sources of the backup
+func (b *BackupSection) mountPointsInSource() (found []string) {
+	mounts, err := getMountPoints()
+	if err != nil {
+		return
+	}
+	for _, mount := range mounts {
+		for _, source := range b.allSources() {
+			if isInside(filepath.Clean(mount), source) {
+				found = append(found, mount)
+				break
+			}
+		}
+	}
+	return
+}
+
+// removeUnsupportedFlags removes the flags not supported by restic on this platform
+func (b *BackupSection) removeUnsupportedFlags(flags *shell.Args) {
+	if b.OneFileSystem && isWindows() {
+		flags.Remove("one-file-system")
+	}
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetBackupOptionIssues(t *testing.T) {
+	defer func(previous func() bool) { isWindows = previous }(isWindows)
+	defer func(previous func() ([]string, error)) { getMountPoints = previous }(getMountPoints)
+	isWindows = func() bool { return false }
+	source, _ := filepath.Abs("/home")
+	getMountPoints = func() ([]string, error) {
+		return []string{"/", source, filepath.Join(source, "shared"), filepath.Join(source, "user", "nfs")}, nil
+	}
+
+	testCases := []struct {
+		name    string
+		backup  *BackupSection
+		windows bool
+		issues  []string
+	}{
+		{name: "no backup"},
+		{name: "valid", backup: &BackupSection{ExcludeCaches: true, ExcludeIfPresent: []string{".nobackup", "marker:header"}}},
+		{
+			name:   "invalid names",
+			backup: &BackupSection{ExcludeCaches: true, ExcludeIfPresent: []string{":header", "dir/.nobackup", "CACHEDIR.TAG"}},
+			issues: []string{
+				`invalid exclude-if-present ":header": missing file name`,
+				`invalid exclude-if-present "dir/.nobackup": expected a file name, not a path`,
+				`exclude-if-present "CACHEDIR.TAG" is redundant with exclude-caches`,
+			},
+		},
+		{
+			name:   "stdin",
+			backup: &BackupSection{UseStdin: true, OneFileSystem: true},
+			issues: []string{"exclude-caches, exclude-if-present and one-file-system have no effect when the backup reads from stdin"},
+		},
+		{
+			name:    "windows",
+			backup:  &BackupSection{OneFileSystem: true, Source: []string{source}},
+			windows: true,
+			issues:  []string{"one-file-system is not supported by restic on Windows: the option is ignored"},
+		},
+		{
+			name:   "mount points",
+			backup: &BackupSection{OneFileSystem: true, Source: []string{source}},
+			issues: []string{"one-file-system: 2 filesystem(s) mounted inside the backup source won't be saved (including bind mounts): " +
+				filepath.Join(source, "shared") + ", " + filepath.Join(source, "user", "nfs")},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			isWindows = func() bool { return testCase.windows }
+			profile := NewProfile(nil, "profile")
+			profile.Backup = testCase.backup
+			assert.Equal(t, testCase.issues, profile.GetBackupOptionIssues())
+		})
+	}
+
+	t.Run("mount points not available", func(t *testing.T) {
+		getMountPoints = func() ([]string, error) { return nil, errors.New("not available") }
+		profile := NewProfile(nil, "profile")
+		profile.Backup = &BackupSection{OneFileSystem: true, Source: []string{source}}
+		assert.Empty(t, profile.GetBackupOptionIssues())
+	})
+}
+
+func TestOneFileSystemIgnoredOnWindows(t *testing.T) {
+	defer func(previous func() bool) { isWindows = previous }(isWindows)
+	profile := NewProfile(nil, "profile")
+	profile.Backup = &BackupSection{OneFileSystem: true, ExcludeCaches: true, ExcludeIfPresent: []string{".nobackup"}}
+
+	isWindows = func() bool { return false }
+	flags := profile.GetCommandFlags("backup").ToMap()
+	assert.Contains(t, flags, "one-file-system")
+	assert.Contains(t, flags, "exclude-caches")
+	assert.Equal(t, []string{".nobackup"}, flags["exclude-if-present"])
+
+	isWindows = func() bool { return true }
+	flags = profile.GetCommandFlags("backup").ToMap()
+	assert.NotContains(t, flags, "one-file-system")
+	assert.Contains(t, flags, "exclude-caches")
+}
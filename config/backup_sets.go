@@ -0,0 +1,100 @@
+package config
+
+import (
+	"sort"
+
+	"github.com/creativeprojects/resticprofile/constants"
+	"github.com/creativeprojects/resticprofile/shell"
+	"golang.org/x/exp/maps"
+)
+
+// BackupSetSection is a named group of sources of the backup, with its own exclusions and tags
+type BackupSetSection struct {
+	unresolvedSource []string
+	Source           []string `mapstructure:"source" description:"The paths to backup in this set"`
+	Exclude          []string `mapstructure:"exclude" description:"Exclude patterns of this set (added to the \"exclude\" of the backup section)"`
+	Iexclude         []string `mapstructure:"iexclude" description:"Case insensitive exclude patterns of this set (added to the \"iexclude\" of the backup section)"`
+	ExcludeFile      []string `mapstructure:"exclude-file" description:"Files of exclude patterns of this set (added to the \"exclude-file\" of the backup section)"`
+	Tag              []string `mapstructure:"tag" description:"Tags of the snapshot of this set (added to the \"tag\" of the backup section)"`
+}
+
+func (s *BackupSetSection) resolve(p *Profile) {
+	if s.unresolvedSource == nil {
+		s.unresolvedSource = s.Source
+	}
+	s.Source = p.resolveSourcePath(s.unresolvedSource...)
+}
+
+func (s *BackupSetSection) setRootPath(rootPath string) {
+	s.ExcludeFile = fixPaths(s.ExcludeFile, expandEnv, absolutePrefix(rootPath))
+	s.Exclude = fixPaths(s.Exclude, expandEnv)
+	s.Iexclude = fixPaths(s.Iexclude, expandEnv)
+}
+
+// addFlags adds the exclusions and the tags of the set to the flags of the backup
+func (s *BackupSetSection) addFlags(flags *shell.Args) {
+	appendFlagValues(flags, "exclude", s.Exclude, shell.ArgConfigKeepGlobQuote)
+	appendFlagValues(flags, "iexclude", s.Iexclude, shell.ArgConfigKeepGlobQuote)
+	appendFlagValues(flags, "exclude-file", s.ExcludeFile, shell.ArgConfigEscape)
+	appendFlagValues(flags, constants.ParameterTag, s.Tag, shell.ArgConfigEscape)
+}
+
+// setNames returns the names of the sets, sorted
+func (b *BackupSection) setNames() []string {
+	names := maps.Keys(b.Sets)
+	sort.Strings(names)
+	return names
+}
+
+// GetBackupSetNames returns the names of the backup sets to run one after the other (sorted).
+// It returns nil when there's no set, or when the sets are merged in a single backup.
+func (p *Profile) GetBackupSetNames() []string {
+	if p.Backup == nil || len(p.Backup.Sets) == 0 || p.Backup.MergeSets {
+		return nil
+	}
+	return p.Backup.setNames()
+}
+
+// GetBackupSetFlags returns the flags of the backup of a set
+func (p *Profile) GetBackupSetFlags(name string) *shell.Args {
+	flags := p.GetCommandFlags(constants.CommandBackup)
+	if set, found := p.Backup.Sets[name]; found && set != nil {
+		set.addFlags(flags)
+	}
+	return flags
+}
+
+// GetBackupSetSource returns the directories to backup in a set
+func (p *Profile) GetBackupSetSource(name string) []string {
+	if p.Backup == nil {
+		return nil
+	}
+	if set, found := p.Backup.Sets[name]; found && set != nil {
+		return set.Source
+	}
+	return nil
+}
+
+// allSources returns the sources of the backup section and of all the sets
+func (b *BackupSection) allSources() []string {
+	sources := append([]string{}, b.Source...)
+	for _, name := range b.setNames() {
+		if set := b.Sets[name]; set != nil {
+			sources = append(sources, set.Source...)
+		}
+	}
+	return sources
+}
+
+// appendFlagValues adds the values after the values already set for the flag
+func appendFlagValues(flags *shell.Args, name string, values []string, argType shell.ArgType) {
+	if len(values) == 0 {
+		return
+	}
+	existing, _ := flags.Get(name)
+	all := make([]string, 0, len(existing)+len(values))
+	for _, arg := range existing {
+		all = append(all, arg.Value())
+	}
+	flags.AddFlags(name, append(all, values...), argType)
+}
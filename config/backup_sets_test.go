@@ -0,0 +1,94 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/creativeprojects/resticprofile/shell"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func flagValues(flags *shell.Args, name string) []string {
+	args, _ := flags.Get(name)
+	values := make([]string, 0, len(args))
+	for _, arg := range args {
+		values = append(values, arg.Value())
+	}
+	return values
+}
+
+func TestBackupSets(t *testing.T) {
+	dir := t.TempDir()
+
+	load := func(t *testing.T, extra string) *Profile {
+		t.Helper()
+		content := fmt.Sprintf(`
+[profile]
+repository = "sftp:user@host:/backup"
+[profile.backup]
+exclude = ["*.tmp"]
+tag = ["daily"]
+auto-exclude = false
+%s
+[profile.backup.sets.documents]
+source = [%q]
+exclude = ["*.bak"]
+tag = ["documents"]
+[profile.backup.sets.photos]
+source = [%q]
+tag = ["photos"]
+`, extra, filepath.ToSlash(filepath.Join(dir, "documents")), filepath.ToSlash(filepath.Join(dir, "photos")))
+		c, err := Load(bytes.NewBufferString(content), FormatTOML)
+		require.NoError(t, err)
+		profile, err := c.GetProfile("profile")
+		require.NoError(t, err)
+		return profile
+	}
+
+	t.Run("one backup per set", func(t *testing.T) {
+		profile := load(t, "")
+		assert.Equal(t, []string{"documents", "photos"}, profile.GetBackupSetNames())
+
+		flags := profile.GetBackupSetFlags("documents")
+		assert.Equal(t, []string{"*.tmp", "*.bak"}, flagValues(flags, "exclude"))
+		assert.Equal(t, []string{"daily", "documents"}, flagValues(flags, "tag"))
+		assert.Equal(t, []string{filepath.Join(dir, "documents")}, profile.GetBackupSetSource("documents"))
+
+		flags = profile.GetBackupSetFlags("photos")
+		assert.Equal(t, []string{"*.tmp"}, flagValues(flags, "exclude"))
+		assert.Equal(t, []string{"daily", "photos"}, flagValues(flags, "tag"))
+		assert.Equal(t, []string{filepath.Join(dir, "photos")}, profile.GetBackupSetSource("photos"))
+		assert.Empty(t, profile.GetBackupSetSource("unknown"))
+	})
+
+	t.Run("merged sets", func(t *testing.T) {
+		profile := load(t, "merge-sets = true")
+		assert.Empty(t, profile.GetBackupSetNames())
+
+		flags := profile.GetCommandFlags("backup")
+		assert.Equal(t, []string{"*.tmp", "*.bak"}, flagValues(flags, "exclude"))
+		assert.Equal(t, []string{"daily", "documents", "photos"}, flagValues(flags, "tag"))
+		assert.Equal(t, []string{filepath.Join(dir, "documents"), filepath.Join(dir, "photos")}, profile.GetBackupSource())
+	})
+}
+
+func TestBackupSetIssues(t *testing.T) {
+	profile := NewProfile(nil, "name")
+	profile.Backup = &BackupSection{
+		Source: []string{"/home"},
+		Sets: map[string]*BackupSetSection{
+			"empty": {},
+			"etc":   {Source: []string{"/etc"}},
+		},
+	}
+	issues := profile.GetBackupOptionIssues()
+	assert.Contains(t, issues, "the source of the backup section is ignored when using backup sets without merge-sets: add it to a set instead")
+	assert.Contains(t, issues, `backup set "empty" has no source`)
+	assert.NotContains(t, issues, `backup set "etc" has no source`)
+
+	profile.Backup.MergeSets = true
+	assert.NotContains(t, profile.GetBackupOptionIssues(), "the source of the backup section is ignored when using backup sets without merge-sets: add it to a set instead")
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+)
+
+// CommandAliasSection defines a custom command of a profile, running a sequence of commands (with fixed flags)
+type CommandAliasSection struct {
+	ScheduleBaseSection     `mapstructure:",squash"`
+	SendMonitoringSections  `mapstructure:",squash"`
+	RunShellCommandsSection `mapstructure:",squash"`
+	Run                     []string `mapstructure:"run" examples:"backup;forget --prune;check --read-data-subset=5%" description:"Commands to run in sequence, each one can be followed by fixed flags"`
+}
+
+func (a *CommandAliasSection) IsEmpty() bool { return a == nil || len(a.Run) == 0 }
+
+func (a *CommandAliasSection) setRootPath(p *Profile, rootPath string) {
+	a.SendMonitoringSections.setRootPath(p, rootPath)
+}
+
+// Steps returns the command and the fixed flags of each step of the alias
+func (a *CommandAliasSection) Steps() (steps [][]string) {
+	if a == nil {
+		return
+	}
+	for _, run := range a.Run {
+		if fields := strings.Fields(run); len(fields) > 0 {
+			steps = append(steps, fields)
+		}
+	}
+	return
+}
+
+// commandAliasDecoder allows to declare an alias with the list of commands only: "nightly = ['backup', 'forget']"
+func commandAliasDecoder() func(from reflect.Type, to reflect.Type, data any) (any, error) {
+	aliasType := reflect.TypeOf(CommandAliasSection{})
+
+	return func(from reflect.Type, to reflect.Type, data any) (any, error) {
+		if to != aliasType {
+			return data, nil
+		}
+		switch from.Kind() {
+		case reflect.String:
+			return map[string]any{"run": data}, nil
+		case reflect.Slice, reflect.Array:
+			if from.Elem().Kind() == reflect.Map {
+				return data, nil
+			}
+			return map[string]any{"run": data}, nil
+		default:
+			return data, nil
+		}
+	}
+}
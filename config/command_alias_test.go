@@ -0,0 +1,53 @@
+package config
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommandAliasSteps(t *testing.T) {
+	alias := &CommandAliasSection{Run: []string{"backup", " forget  --prune ", ""}}
+	assert.Equal(t, [][]string{{"backup"}, {"forget", "--prune"}}, alias.Steps())
+	assert.Nil(t, (*CommandAliasSection)(nil).Steps())
+}
+
+func TestLoadCommandAliases(t *testing.T) {
+	content := `
+[profile]
+repository = "test"
+
+[profile.commands]
+short = ["backup", "forget --prune"]
+single = "check"
+
+[profile.commands.long]
+run = ["backup", "check"]
+schedule = "daily"
+
+[profile.commands.check]
+run = ["snapshots"]
+`
+	c, err := Load(bytes.NewBufferString(content), FormatTOML)
+	require.NoError(t, err)
+	profile, err := c.GetProfile("profile")
+	require.NoError(t, err)
+
+	require.NotNil(t, profile.GetCommandAlias("short"))
+	assert.Equal(t, []string{"backup", "forget --prune"}, profile.GetCommandAlias("short").Run)
+	require.NotNil(t, profile.GetCommandAlias("single"))
+	assert.Equal(t, []string{"check"}, profile.GetCommandAlias("single").Run)
+	require.NotNil(t, profile.GetCommandAlias("long"))
+	assert.Equal(t, []string{"daily"}, profile.GetCommandAlias("long").Schedule)
+
+	// an alias cannot replace a restic command
+	assert.Nil(t, profile.GetCommandAlias("check"))
+	assert.Nil(t, profile.GetCommandAlias("unknown"))
+
+	schedules := profile.Schedules()
+	require.Len(t, schedules, 1)
+	assert.Equal(t, "long", schedules[0].SubTitle)
+	assert.Contains(t, profile.DefinedCommands(), "short")
+}
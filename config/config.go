@@ -2,11 +2,13 @@ package config
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"runtime"
 	"sort"
 	"strings"
 	"sync"
@@ -27,12 +29,18 @@ type Config struct {
 	format          string
 	configFile      string
 	includeFiles    []string
+	defaultsFile    string       // machine-wide defaults file
+	defaults        *viper.Viper // settings of the defaults file
 	viper           *viper.Viper
 	mixinUses       []map[string][]*mixinUse
 	mixins          map[string]*mixin
 	groups          map[string]Group
+	foreach         map[string][]string // names of the profiles generated by each profile declaring "foreach"
 	sourceTemplates *template.Template
+	sourceHashes    map[string][sha256.Size]byte // hash of each configuration file, before executing templates
+	defaultsHash    [sha256.Size]byte            // hash of the defaults file (it has no template)
 	version         Version
+	remoteChain     []string // remote profiles ("file#profile") being inherited, to detect circular references
 	issues          struct {
 		changedPaths  map[string][]string // 'path' items that had been changed to absolute paths
 		failedSection map[string]error    // profile sections that failed to get parsed or resolved
@@ -40,10 +48,15 @@ type Config struct {
 }
 
 var (
-	configOption = viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
-		mapstructure.StringToTimeDurationHookFunc(),
-		confidentialValueDecoder(),
-	))
+	configOption = func(lists *resolvedLists) viper.DecoderConfigOption {
+		return viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+			listOperatorsDecoder(lists),
+			mapstructure.StringToTimeDurationHookFunc(),
+			confidentialValueDecoder(),
+			commandAliasDecoder(),
+			scriptDecoder(),
+		))
+	}
 
 	rootPathMessage = sync.Once{}
 )
@@ -72,6 +85,11 @@ func LoadFile(configFile, format string) (config *Config, err error) {
 	config = newConfig(format)
 	config.configFile = configFile
 
+	// Load machine-wide defaults (layered under the configuration)
+	if err = config.loadDefaults(); err != nil {
+		return
+	}
+
 	readAndAdd := func(configFile string, replace bool) error {
 		clog.Debugf("loading: %s", configFile)
 		file, fileErr := os.Open(configFile)
@@ -119,6 +137,17 @@ func LoadFile(configFile, format string) (config *Config, err error) {
 	return
 }
 
+// LoadReader loads the configuration from a reader (like stdin) with the machine-wide defaults.
+// The relative paths of the configuration are relative to the current directory.
+func LoadReader(input io.Reader, format string) (config *Config, err error) {
+	config = newConfig(format)
+	if err = config.loadDefaults(); err != nil {
+		return
+	}
+	err = config.addTemplate(input, config.configFile, true)
+	return
+}
+
 // Load configuration from reader
 // This should only be used for unit tests
 func Load(input io.Reader, format string) (config *Config, err error) {
@@ -161,16 +190,18 @@ func (c *Config) addTemplate(input io.Reader, name string, replace bool) error {
 
 	var source *template.Template
 	if c.sourceTemplates == nil || replace {
-		source = templates.New(c.templateName(name))
+		source = templates.New(c.templateName(name), configFileFuncs(c.configFile))
 		c.sourceTemplates = source
+		c.sourceHashes = make(map[string][sha256.Size]byte)
 	} else {
 		source = c.sourceTemplates.New(c.templateName(name))
 	}
 
-	_, err = source.Parse(inputString.String())
+	_, err = source.Parse(escapeRunTimeTemplates(inputString.String()))
 	if err != nil {
 		return fmt.Errorf("cannot compile %w", err)
 	}
+	c.sourceHashes[name] = sha256.Sum256([]byte(inputString.String()))
 
 	if replace {
 		err = c.loadTemplates()
@@ -199,7 +230,9 @@ func (c *Config) load(input io.Reader, format string, replace bool) (err error)
 	err = vp.ReadConfig(input)
 
 	if err == nil && vp != c.viper {
-		err = c.viper.MergeConfigMap(vp.AllSettings())
+		settings := vp.AllSettings()
+		resolveIncludedListOperators(c.viper, settings)
+		err = c.viper.MergeConfigMap(settings)
 	}
 
 	if err == nil && c.GetVersion() >= Version02 {
@@ -303,6 +336,21 @@ func (c *Config) reloadTemplates(data TemplateData) error {
 		}
 	}
 
+	// Generate the profiles declared with "foreach"
+	if err == nil {
+		err = c.expandForeachProfiles()
+	}
+
+	// Layer the machine-wide defaults under the configuration
+	if err == nil {
+		err = c.applyDefaults()
+	}
+
+	// Override with the RESTICPROFILE_* environment variables
+	if err == nil {
+		err = c.applyEnvironmentOverrides()
+	}
+
 	// Load mixins and apply outside of profiles
 	if err == nil && c.GetVersion() >= Version02 {
 		c.mixins = parseMixins(c.viper)
@@ -312,27 +360,32 @@ func (c *Config) reloadTemplates(data TemplateData) error {
 	return err
 }
 
+// GetConfigurationIssues returns the issues in the configuration for all profiles previously returned by GetProfile
+func (c *Config) GetConfigurationIssues() (issues []string) {
+	if len(c.issues.changedPaths) > 0 {
+		issues = append(issues, c.changedPathsMessage())
+	}
+	if len(c.issues.failedSection) > 0 {
+		names := maps.Keys(c.issues.failedSection)
+		sort.Strings(names)
+		for _, name := range names {
+			issues = append(issues, c.failedSectionMessage(name))
+		}
+	}
+	return
+}
+
 // DisplayConfigurationIssues logs issues in the configuration for all profiles previously returned by GetProfile
 func (c *Config) DisplayConfigurationIssues() {
 	if len(c.issues.changedPaths) > 0 {
-		var msg []string
-		for path, resolved := range c.issues.changedPaths {
-			msg = append(msg, fmt.Sprintf(`> %s changes to "%s"`, path, strings.Join(resolved, `", "`)))
-		}
-		sort.Strings(msg)
-		msg = append([]string{
-			"the configuration contains relative 'path' items which may lead to unstable results in restic " +
-				"commands that select snapshots. Consider using absolute paths in 'path' (and 'source') or use " +
-				"'tag' instead of 'path' (path = false) to select snapshots for restic commands. Affected paths:",
-		}, msg...)
-		clog.Info(strings.Join(msg, fmt.Sprintln()))
+		clog.Info(c.changedPathsMessage())
 	}
 
 	if len(c.issues.failedSection) > 0 {
 		names := maps.Keys(c.issues.failedSection)
 		sort.Strings(names)
 		for _, name := range names {
-			clog.Errorf("Failed parsing profile section %q: %s", name, c.issues.failedSection[name].Error())
+			clog.Error(c.failedSectionMessage(name))
 		}
 	}
 
@@ -341,6 +394,24 @@ func (c *Config) DisplayConfigurationIssues() {
 	c.issues.failedSection = nil
 }
 
+func (c *Config) changedPathsMessage() string {
+	var msg []string
+	for path, resolved := range c.issues.changedPaths {
+		msg = append(msg, fmt.Sprintf(`> %s changes to "%s"`, path, strings.Join(resolved, `", "`)))
+	}
+	sort.Strings(msg)
+	msg = append([]string{
+		"the configuration contains relative 'path' items which may lead to unstable results in restic " +
+			"commands that select snapshots. Consider using absolute paths in 'path' (and 'source') or use " +
+			"'tag' instead of 'path' (path = false) to select snapshots for restic commands. Affected paths:",
+	}, msg...)
+	return strings.Join(msg, fmt.Sprintln())
+}
+
+func (c *Config) failedSectionMessage(name string) string {
+	return fmt.Sprintf("Failed parsing profile section %q: %s", name, c.issues.failedSection[name].Error())
+}
+
 func (c *Config) reportChangedPath(resolvedPath, path, origin string) {
 	if c.issues.changedPaths == nil {
 		c.issues.changedPaths = make(map[string][]string)
@@ -394,6 +465,11 @@ func (c *Config) HasProfile(profileKey string) bool {
 	return c.IsSet(c.getProfilePath(profileKey))
 }
 
+// IsAbstractProfile returns true if the profile only exists to be inherited by other profiles
+func (c *Config) IsAbstractProfile(profileKey string) bool {
+	return c.viper.GetBool(c.flatKey(c.getProfilePath(profileKey), constants.SectionConfigurationAbstract))
+}
+
 // GetProfileNames returns all profile names defined in the configuration
 func (c *Config) GetProfileNames() (names []string) {
 	if c.GetVersion() <= Version01 {
@@ -513,6 +589,10 @@ func (c *Config) loadGroups() (err error) {
 		if c.IsSet(constants.SectionConfigurationGroups) {
 			groups := map[string]Group{}
 			if err = c.unmarshalKey(constants.SectionConfigurationGroups, &groups); err == nil {
+				for name, group := range groups {
+					group.Profiles = c.expandForeachNames(group.Profiles)
+					groups[name] = group
+				}
 				c.groups = groups
 			}
 		}
@@ -541,6 +621,11 @@ func (c *Config) GetProfile(profileKey string) (profile *Profile, err error) {
 	}
 
 	c.postProcessProfile(profile)
+
+	// the role of the credentials is checked before any command can run
+	if err = profile.ValidateRole(); err != nil {
+		profile = nil
+	}
 	return
 }
 
@@ -570,11 +655,14 @@ func (c *Config) applyProfileInheritanceAndMixins(profileName string) (err error
 
 	if inherit := c.viper.GetString(c.flatKey(profilePath, constants.SectionConfigurationInherit)); len(inherit) > 0 {
 
-		inheritPath := c.getProfilePath(inherit)
-		if !c.IsSet(inheritPath) {
+		var parent map[string]any
+		if isRemoteProfile(inherit) {
+			parent, err = c.getRemoteProfileSettings(inherit, profileName)
+		} else if inheritPath := c.getProfilePath(inherit); !c.IsSet(inheritPath) {
 			err = ErrNotFound
 		} else {
 			err = c.applyProfileInheritanceAndMixins(inherit) // recursive inheritance, the deepest first
+			parent = c.viper.GetStringMap(inheritPath)
 		}
 
 		if err == nil {
@@ -582,16 +670,22 @@ func (c *Config) applyProfileInheritanceAndMixins(profileName string) (err error
 			mergedProfile := viper.NewWithOptions(viper.KeyDelimiter(c.keyDelim))
 
 			// init with parent (excluding some fields that must never be inherited)
-			parent := c.viper.GetStringMap(inheritPath)
+			// (copy of the map: viper returns the map of its own configuration)
+			parent = maps.Clone(parent)
+			delete(parent, constants.SectionConfigurationAbstract)
 			delete(parent, constants.SectionConfigurationDescription)
 			delete(parent, constants.SectionConfigurationMixinUse)
 			delete(parent, constants.SectionConfigurationInherit)
+			delete(parent, constants.SectionConfigurationForeach)
+			delete(parent, constants.SectionConfigurationForeachFile)
 
 			if err = mergedProfile.MergeConfigMap(parent); err == nil {
 				// Merge derived onto parent (removing "inherit" instruction to ensure it is done only once)
-				derived := c.viper.GetStringMap(profilePath)
+				derived, _ := appendInheritedHooks(c.viper.GetStringMap(profilePath), func(section string) string {
+					return getInheritHooksMode(parent, section)
+				})
 				derived[constants.SectionConfigurationInherit] = ""
-				revolveAppendToListKeys(mergedProfile, derived)
+				resolveListOperators(mergedProfile, derived)
 
 				err = mergedProfile.MergeConfigMap(derived)
 			}
@@ -626,13 +720,34 @@ func (c *Config) getProfile(profileKey string) (profile *Profile, err error) {
 	}
 
 	profile = NewProfile(c, profileKey)
-	err = c.unmarshalKey(c.getProfilePath(profileKey), profile)
+	err = c.unmarshalProfile(profileKey, profile)
 	if err != nil {
 		profile = nil
 	}
 	return
 }
 
+// unmarshalProfile decodes the profile with the sections of the current operating system merged (like "backup.windows")
+func (c *Config) unmarshalProfile(profileKey string, profile *Profile) error {
+	return c.unmarshalProfileContent(profileKey, profile, nil)
+}
+
+// unmarshalProfileContent decodes the profile like unmarshalProfile, after an optional transformation of its content
+// (returning true when the content was changed)
+func (c *Config) unmarshalProfileContent(profileKey string, profile *Profile, transform func(map[string]any) (map[string]any, bool)) error {
+	profilePath := c.getProfilePath(profileKey)
+	content := c.viper.GetStringMap(profilePath)
+	transformed := false
+	if transform != nil {
+		content, transformed = transform(content)
+	}
+	content, found := applyOSSections(content, runtime.GOOS)
+	if !found && !transformed {
+		return c.unmarshalKey(profilePath, profile)
+	}
+	return c.decode(content, profile)
+}
+
 // getProfilePath returns the key prefixed with "profiles" if the configuration file version is >= 2
 func (c *Config) getProfilePath(key string) string {
 	if c.GetVersion() <= Version01 {
@@ -676,15 +791,22 @@ func (c *Config) getSchedule(key string) (Schedule, error) {
 	if err != nil {
 		return schedule, err
 	}
+	schedule.Profiles = c.expandForeachNames(schedule.Profiles)
+	for _, profileName := range schedule.Profiles {
+		if c.IsAbstractProfile(profileName) {
+			return schedule, fmt.Errorf("schedule '%s' cannot reference profile '%s' which is abstract", key, profileName)
+		}
+	}
 	return schedule, nil
 }
 
-// unmarshalConfig returns the decoder config options depending on the configuration version and format
-func (c *Config) unmarshalConfig() viper.DecoderConfigOption {
+// unmarshalConfig returns the decoder config options depending on the configuration version and format.
+// The lists resolved by list operators are added to lists, to be set once the decoding is done.
+func (c *Config) unmarshalConfig(lists *resolvedLists) viper.DecoderConfigOption {
 	if c.GetVersion() == Version01 {
-		return c.unmarshalConfigV1()
+		return c.unmarshalConfigV1(lists)
 	} else {
-		return configOption
+		return configOption(lists)
 	}
 }
 
@@ -694,18 +816,30 @@ func (c *Config) unmarshalKey(key string, rawVal interface{}) error {
 		return fmt.Errorf("HCL format is not supported in version %d, please use version 1 or another file format", c.GetVersion())
 	}
 
-	return c.viper.UnmarshalKey(key, rawVal, c.unmarshalConfig())
+	lists := new(resolvedLists)
+	if err := c.viper.UnmarshalKey(key, rawVal, c.unmarshalConfig(lists)); err != nil {
+		return err
+	}
+	return lists.set(c.decode)
 }
 
-// newUnmarshaller returns a configured unmarshaler for output
-func (c *Config) newUnmarshaller(output any) (*mapstructure.Decoder, error) {
+// decode decodes input into output with the right decoder config options
+func (c *Config) decode(input, output any) error {
+	lists := new(resolvedLists)
 	conf := &mapstructure.DecoderConfig{
 		Result:           output,
 		WeaklyTypedInput: true,
 	}
-	c.unmarshalConfig()(conf)
+	c.unmarshalConfig(lists)(conf)
 
-	return mapstructure.NewDecoder(conf)
+	decoder, err := mapstructure.NewDecoder(conf)
+	if err != nil {
+		return err
+	}
+	if err = decoder.Decode(input); err != nil {
+		return err
+	}
+	return lists.set(c.decode)
 }
 
 // traceConfig sends a log of level trace to show the resulting configuration after resolving the template
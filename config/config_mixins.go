@@ -3,7 +3,6 @@ package config
 import (
 	"fmt"
 	"os"
-	"regexp"
 	"strings"
 
 	"github.com/creativeprojects/clog"
@@ -215,7 +214,7 @@ func applyMixins(config *viper.Viper, keyDelimiter string, mixinUses map[string]
 		for _, use := range uses {
 			if mi, found := mixins[use.Name]; found {
 				content := mi.Resolve(use.Variables)
-				revolveAppendToListKeys(config.Sub(configKey), content)
+				resolveListOperators(config.Sub(configKey), content)
 				err = mergeConfigMap(config, configKey, keyDelimiter, content)
 			} else {
 				err = fmt.Errorf("undefined mixin \"%s\"", use.Name)
@@ -234,80 +233,3 @@ func applyMixins(config *viper.Viper, keyDelimiter string, mixinUses map[string]
 	}
 	return
 }
-
-type mixinAppendToKeyType int
-
-const (
-	mixinNoAppend mixinAppendToKeyType = iota
-	mixinAppend
-	mixinPrepend
-)
-
-var (
-	mixinPrependKeyRegex = regexp.MustCompile(`(?i)^(.+)__PREPEND$|^\.\.\.(.+)$`)
-	mixinAppendKeyRegex  = regexp.MustCompile(`(?i)^(.+)__APPEND$|^(.+)\.\.\.$`)
-)
-
-func parseAppendToListKey(key string) (targetKey string, operation mixinAppendToKeyType) {
-	var match []string
-	if match = mixinAppendKeyRegex.FindStringSubmatch(key); len(match) > 1 {
-		operation = mixinAppend
-	} else if match = mixinPrependKeyRegex.FindStringSubmatch(key); len(match) > 1 {
-		operation = mixinPrepend
-	}
-
-	if len(match) > 1 {
-		for _, targetKey = range match[1:] {
-			if len(targetKey) > 0 {
-				break
-			}
-		}
-	}
-
-	if len(targetKey) == 0 {
-		operation = mixinNoAppend
-	}
-
-	return
-}
-
-// revolveAppendToListKeys resolves "key__APPEND" and "key__PREPEND" in content using config as base
-func revolveAppendToListKeys(config *viper.Viper, content map[string]interface{}) {
-	for name, value := range content {
-		targetName, operation := parseAppendToListKey(name)
-
-		if operation == mixinNoAppend {
-			if child, ok := value.(map[string]interface{}); ok {
-				var cc *viper.Viper
-				if config != nil {
-					cc = config.Sub(name)
-				}
-				revolveAppendToListKeys(cc, child)
-			}
-			continue
-		} else {
-			delete(content, name)
-		}
-
-		sourceValue := content[targetName]
-		if sourceValue == nil && config != nil {
-			sourceValue = config.Get(targetName)
-		}
-
-		var source, appendable []interface{}
-		if source = cast.ToSlice(sourceValue); source == nil && sourceValue != nil {
-			source = []interface{}{sourceValue}
-		}
-
-		if appendable = cast.ToSlice(value); appendable == nil && value != nil {
-			appendable = []interface{}{value}
-		}
-
-		switch operation {
-		case mixinAppend:
-			content[targetName] = append(source, appendable...)
-		case mixinPrepend:
-			content[targetName] = append(appendable, source...)
-		}
-	}
-}
@@ -230,7 +230,7 @@ func TestRevolveAppendToListKeys(t *testing.T) {
 	for i, test := range tests {
 		t.Run(fmt.Sprintf("#%d_%s", i, test.name), func(t *testing.T) {
 			v := load(t, test.config)
-			revolveAppendToListKeys(v, test.mixin)
+			resolveListOperators(v, test.mixin)
 			assert.Equal(t, test.expected, test.mixin)
 		})
 	}
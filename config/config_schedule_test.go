@@ -56,3 +56,21 @@ func TestGetScheduleSectionsOnV1(t *testing.T) {
 	c := newConfig("toml")
 	assert.Panics(t, func() { c.GetScheduleSections() })
 }
+
+func TestGetScheduleSectionsWithAbstractProfile(t *testing.T) {
+	testConfig := `
+version = 2
+[profiles.base]
+abstract = true
+[profiles.profile]
+inherit = "base"
+[schedules.sname]
+profiles = ["profile", "base"]
+schedule = "daily"
+`
+	c, err := Load(bytes.NewBufferString(testConfig), FormatTOML)
+	require.NoError(t, err)
+
+	_, err = c.GetScheduleSections()
+	assert.EqualError(t, err, "schedule 'sname' cannot reference profile 'base' which is abstract")
+}
@@ -26,16 +26,26 @@ import (
 // For that matter, viper creates a slice of maps instead of a map for the other configuration file formats
 // This configOptionV1HCL deals with the slice to merge it into a single map
 var (
-	configOptionV1 = viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
-		mapstructure.StringToTimeDurationHookFunc(),
-		confidentialValueDecoder(),
-	))
-
-	configOptionV1HCL = viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
-		mapstructure.StringToTimeDurationHookFunc(),
-		confidentialValueDecoder(),
-		sliceOfMapsToMapHookFunc(),
-	))
+	configOptionV1 = func(lists *resolvedLists) viper.DecoderConfigOption {
+		return viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+			listOperatorsDecoder(lists),
+			mapstructure.StringToTimeDurationHookFunc(),
+			confidentialValueDecoder(),
+			commandAliasDecoder(),
+			scriptDecoder(),
+		))
+	}
+
+	configOptionV1HCL = func(lists *resolvedLists) viper.DecoderConfigOption {
+		return viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+			mapstructure.StringToTimeDurationHookFunc(),
+			confidentialValueDecoder(),
+			sliceOfMapsToMapHookFunc(),
+			listOperatorsDecoder(lists),
+			commandAliasDecoder(),
+			scriptDecoder(),
+		))
+	}
 )
 
 // getProfileNamesV1 returns all profile names defined in the configuration version 1
@@ -44,7 +54,8 @@ func (c *Config) getProfileNamesV1() (names []string) {
 
 	names = make([]string, 0)
 	for sectionKey := range c.viper.AllSettings() {
-		if sectionKey == constants.SectionConfigurationGlobal ||
+		if sectionKey == constants.ParameterVersion ||
+			sectionKey == constants.SectionConfigurationGlobal ||
 			sectionKey == constants.SectionConfigurationGroups ||
 			sectionKey == constants.SectionConfigurationIncludes {
 			continue
@@ -67,7 +78,7 @@ func (c *Config) loadGroupsV1() (err error) {
 				for groupName, group := range groups {
 					c.groups[groupName] = Group{
 						Description:     "",
-						Profiles:        group,
+						Profiles:        c.expandForeachNames(group),
 						ContinueOnError: nil,
 					}
 				}
@@ -87,7 +98,7 @@ func (c *Config) getProfileV1(profileKey string) (profile *Profile, err error) {
 	}
 
 	profile = NewProfile(c, profileKey)
-	err = c.unmarshalKey(c.getProfilePath(profileKey), profile)
+	err = c.unmarshalProfile(profileKey, profile)
 	if err != nil {
 		return nil, err
 	}
@@ -102,10 +113,16 @@ func (c *Config) getProfileV1(profileKey string) (profile *Profile, err error) {
 			}
 			return nil, err
 		}
-		// It doesn't make sense to inherit the Description field
+		// It doesn't make sense to inherit the Description, Abstract and Foreach fields
 		profile.Description = ""
+		profile.Abstract = false
+		profile.Foreach = nil
+		profile.ForeachFile = ""
 		// Reload this profile onto the inherited one
-		err = c.unmarshalKey(c.getProfilePath(profileKey), profile)
+		parent := profile
+		err = c.unmarshalProfileContent(profileKey, profile, func(content map[string]any) (map[string]any, bool) {
+			return appendInheritedHooks(content, parent.getInheritHooksMode)
+		})
 		if err != nil {
 			return nil, err
 		}
@@ -124,6 +141,9 @@ func (c *Config) getSchedulesV1() ([]*ScheduleConfig, error) {
 	}
 	schedules := []*ScheduleConfig{}
 	for _, profileName := range profiles {
+		if c.IsAbstractProfile(profileName) {
+			continue
+		}
 		profile, err := c.GetProfile(profileName)
 		if err != nil {
 			return nil, fmt.Errorf("cannot load profile %q: %w", profileName, err)
@@ -135,13 +155,13 @@ func (c *Config) getSchedulesV1() ([]*ScheduleConfig, error) {
 }
 
 // unmarshalConfigV1 returns the viper.DecoderConfigOption to use for V1 configuration files
-func (c *Config) unmarshalConfigV1() viper.DecoderConfigOption {
+func (c *Config) unmarshalConfigV1(lists *resolvedLists) viper.DecoderConfigOption {
 	c.requireVersion(Version01)
 
 	if c.format == "hcl" {
-		return configOptionV1HCL
+		return configOptionV1HCL(lists)
 	} else {
-		return configOptionV1
+		return configOptionV1(lists)
 	}
 }
 
@@ -0,0 +1,116 @@
+package config
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/constants"
+	"github.com/creativeprojects/resticprofile/filesearch"
+	"github.com/spf13/viper"
+)
+
+// findDefaultsFile returns the machine-wide defaults file (can be replaced in tests)
+var findDefaultsFile = filesearch.FindDefaultsFile
+
+// loadDefaults loads the machine-wide defaults file, layered under the configuration by applyDefaults
+func (c *Config) loadDefaults() error {
+	filename := findDefaultsFile()
+	if filename == "" {
+		return nil
+	}
+	if same, err := filepath.Abs(filename); err == nil {
+		if config, err := filepath.Abs(c.configFile); err == nil && same == config {
+			return nil
+		}
+	}
+	clog.Debugf("loading machine-wide defaults: %s", filename)
+
+	format := formatFromExtension(filename)
+	if format == "conf" {
+		format = FormatTOML
+	}
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("cannot load machine-wide defaults %s: %w", filename, err)
+	}
+	vp := viper.NewWithOptions(viper.KeyDelimiter(c.keyDelim))
+	vp.SetConfigType(format)
+	if err = vp.ReadConfig(bytes.NewReader(content)); err != nil {
+		return fmt.Errorf("cannot load machine-wide defaults %s: %w", filename, err)
+	}
+	c.defaultsFile = filename
+	c.defaultsHash = sha256.Sum256(content)
+	c.defaults = vp
+	return nil
+}
+
+// applyDefaults sets the values of the machine-wide defaults file that are not set in the configuration:
+//   - the sections of the defaults file (global, profiles, groups, etc.) are layered under the same sections of the configuration.
+//     When the files have different versions, only the global section is layered.
+//   - the "all-profiles" section of the defaults file is layered under every profile that doesn't inherit from another one.
+func (c *Config) applyDefaults() error {
+	if c.defaults == nil {
+		return nil
+	}
+	layer := make(map[string]any)
+	sameVersion := ParseVersion(c.defaults.GetString(constants.ParameterVersion)) == c.GetVersion()
+
+	for key, value := range c.defaults.AllSettings() {
+		switch key {
+		case constants.ParameterVersion, constants.SectionConfigurationIncludes, constants.SectionConfigurationAllProfiles:
+			continue
+		case constants.SectionConfigurationGlobal:
+		default:
+			if !sameVersion {
+				continue
+			}
+		}
+		c.collectUnsetValues([]string{key}, value, layer)
+	}
+
+	if allProfiles, ok := c.defaults.Get(constants.SectionConfigurationAllProfiles).(map[string]any); ok {
+		for _, profileName := range c.GetProfileNames() {
+			profilePath := c.getProfilePath(profileName)
+			if c.viper.IsSet(c.flatKey(profilePath, constants.SectionConfigurationInherit)) {
+				continue // the defaults come from the parent profile
+			}
+			c.collectUnsetValues(strings.Split(profilePath, c.keyDelim), allProfiles, layer)
+		}
+	}
+
+	if len(layer) == 0 {
+		return nil
+	}
+	return c.viper.MergeConfigMap(layer)
+}
+
+// collectUnsetValues adds the values that are not set in the configuration into the layer
+func (c *Config) collectUnsetValues(path []string, value any, layer map[string]any) {
+	if values, ok := value.(map[string]any); ok && len(values) > 0 {
+		for key, value := range values {
+			c.collectUnsetValues(append(path[:len(path):len(path)], key), value, layer)
+		}
+		return
+	}
+	if c.viper.IsSet(c.flatKey(path...)) {
+		return
+	}
+	for i := 1; i < len(path); i++ {
+		if parent := c.viper.Get(c.flatKey(path[:i]...)); parent != nil {
+			if _, isMap := parent.(map[string]any); !isMap {
+				return // a value of another type is set in the configuration
+			}
+		}
+	}
+	setLayerValue(layer, path, value)
+}
+
+// GetDefaultsFile returns the machine-wide defaults file layered under the configuration, if any
+func (c *Config) GetDefaultsFile() string {
+	return c.defaultsFile
+}
@@ -0,0 +1,124 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loadWithDefaults(t *testing.T, defaults, defaultsName, configuration, configName string) *Config {
+	t.Helper()
+	dir := t.TempDir()
+	defaultsFile := filepath.Join(dir, defaultsName)
+	configFile := filepath.Join(dir, configName)
+	require.NoError(t, os.WriteFile(defaultsFile, []byte(defaults), 0o600))
+	require.NoError(t, os.WriteFile(configFile, []byte(configuration), 0o600))
+
+	defer func(find func() string) { findDefaultsFile = find }(findDefaultsFile)
+	findDefaultsFile = func() string { return defaultsFile }
+
+	c, err := LoadFile(configFile, "")
+	require.NoError(t, err)
+	assert.Equal(t, defaultsFile, c.GetDefaultsFile())
+	return c
+}
+
+func TestMachineWideDefaults(t *testing.T) {
+	defaults := `
+version: "2"
+global:
+  priority: low
+  min-memory: 500
+all-profiles:
+  cache-dir: /var/cache/restic
+  status-file: /var/lib/resticprofile/status.json
+  backup:
+    send-after:
+      - url: https://monitoring.example.com/ping
+profiles:
+  home:
+    description: from defaults
+`
+	configuration := `
+version: "2"
+global:
+  min-memory: 200
+profiles:
+  home:
+    repository: "local:/backup"
+    cache-dir: /home/user/.cache/restic
+  child:
+    inherit: home
+    backup:
+      source: /data
+`
+	c := loadWithDefaults(t, defaults, "defaults.yaml", configuration, "profiles.yaml")
+
+	global, err := c.GetGlobalSection()
+	require.NoError(t, err)
+	assert.Equal(t, "low", global.Priority)
+	assert.Equal(t, uint64(200), global.MinMemory)
+
+	origins, err := c.GetGlobalOrigins()
+	require.NoError(t, err)
+	assert.Equal(t, c.GetDefaultsFile(), origins.Get("priority"))
+	assert.NotEqual(t, c.GetDefaultsFile(), origins.Get("min-memory"))
+
+	home, err := c.GetProfile("home")
+	require.NoError(t, err)
+	assert.Equal(t, "/home/user/.cache/restic", home.CacheDir)
+	assert.Equal(t, "/var/lib/resticprofile/status.json", home.StatusFile)
+	assert.Equal(t, "from defaults", home.Description)
+	require.NotNil(t, home.Backup)
+	require.Len(t, home.Backup.SendAfter, 1)
+	assert.Equal(t, "https://monitoring.example.com/ping", home.Backup.SendAfter[0].URL.Value())
+
+	// inherited from the parent profile, not from the defaults
+	child, err := c.GetProfile("child")
+	require.NoError(t, err)
+	assert.Equal(t, "/home/user/.cache/restic", child.CacheDir)
+	assert.Equal(t, "/var/lib/resticprofile/status.json", child.StatusFile)
+}
+
+func TestMachineWideDefaultsWithOtherVersion(t *testing.T) {
+	defaults := `
+version = "2"
+[global]
+priority = "low"
+[all-profiles]
+cache-dir = "/var/cache/restic"
+[profiles.other]
+repository = "local:/other"
+`
+	configuration := `
+[home]
+repository = "local:/backup"
+`
+	c := loadWithDefaults(t, defaults, "defaults.toml", configuration, "profiles.toml")
+
+	assert.Equal(t, Version01, c.GetVersion())
+	assert.False(t, c.HasProfile("profiles"))
+	assert.Equal(t, []string{"home"}, c.GetProfileNames())
+
+	global, err := c.GetGlobalSection()
+	require.NoError(t, err)
+	assert.Equal(t, "low", global.Priority)
+
+	home, err := c.GetProfile("home")
+	require.NoError(t, err)
+	assert.Equal(t, "/var/cache/restic", home.CacheDir)
+}
+
+func TestNoMachineWideDefaults(t *testing.T) {
+	defer func(find func() string) { findDefaultsFile = find }(findDefaultsFile)
+	findDefaultsFile = func() string { return "" }
+
+	configFile := filepath.Join(t.TempDir(), "profiles.toml")
+	require.NoError(t, os.WriteFile(configFile, []byte("[home]\nrepository = \"local:/backup\"\n"), 0o600))
+	c, err := LoadFile(configFile, "")
+	require.NoError(t, err)
+	assert.Empty(t, c.GetDefaultsFile())
+}
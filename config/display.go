@@ -16,6 +16,7 @@ type Display struct {
 	topLevel string
 	writer   io.Writer
 	entries  []Entry
+	origins  ValueOrigins
 }
 
 func newDisplay(name string, w io.Writer) *Display {
@@ -67,7 +68,11 @@ func (d *Display) Flush() {
 			continue
 		}
 		if len(entry.values) > 0 {
-			fmt.Fprintf(tabWriter, "%s%s:\t%s\n", prefix, entry.key, cleanupControlCharacters(entry.values[0]))
+			if d.origins != nil {
+				fmt.Fprintf(tabWriter, "%s%s:\t%s\t%s\n", prefix, entry.key, cleanupControlCharacters(entry.values[0]), d.origin(entry))
+			} else {
+				fmt.Fprintf(tabWriter, "%s%s:\t%s\n", prefix, entry.key, cleanupControlCharacters(entry.values[0]))
+			}
 		}
 		if len(entry.values) > 1 {
 			for i := 1; i < len(entry.values); i++ {
@@ -78,6 +83,18 @@ func (d *Display) Flush() {
 	tabWriter.Flush()
 }
 
+// origin returns the origin of the entry value as a comment
+func (d *Display) origin(entry Entry) string {
+	path := entry.key
+	if entry.section != "" {
+		path = entry.section + "." + entry.key
+	}
+	if origin := d.origins.Get(path); origin != "" {
+		return "# " + origin
+	}
+	return ""
+}
+
 // Entry of configuration to display to the console
 type Entry struct {
 	section string
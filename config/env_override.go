@@ -0,0 +1,137 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/constants"
+)
+
+const (
+	// EnvOverridePrefix is the prefix of the environment variables overriding the configuration
+	EnvOverridePrefix = "RESTICPROFILE_"
+	// EnvVariablePrefix is the prefix of the environment variables available as {{ .Vars.NAME }} in the configuration templates
+	EnvVariablePrefix = EnvOverridePrefix + "VAR_"
+	// envGlobalPrefix is the prefix of the environment variables overriding the global section
+	envGlobalPrefix = EnvOverridePrefix + "GLOBAL_"
+)
+
+// overrideEnvironment returns the environment variables (can be replaced in tests)
+var overrideEnvironment = os.Environ
+
+// templateVariables returns the RESTICPROFILE_VAR_* environment variables, without the prefix
+func templateVariables() map[string]string {
+	vars := make(map[string]string)
+	for _, variable := range overrideEnvironment() {
+		name, value, _ := strings.Cut(variable, "=")
+		if name = strings.ToUpper(name); strings.HasPrefix(name, EnvVariablePrefix) && len(name) > len(EnvVariablePrefix) {
+			vars[strings.TrimPrefix(name, EnvVariablePrefix)] = value
+		}
+	}
+	return vars
+}
+
+// applyEnvironmentOverrides sets the values of the RESTICPROFILE_* environment variables on top of the configuration:
+//   - RESTICPROFILE_GLOBAL_<KEY> sets a key of the global section
+//   - RESTICPROFILE_<PROFILE>_<KEY> sets a key of a profile
+//   - RESTICPROFILE_<PROFILE>_<SECTION>_<KEY> sets a key of a section of a profile
+//
+// Names are case-insensitive and a "_" matches a "-" (or a "_") in the profile name, the section name and the key.
+// A value starting with "[" is decoded as a JSON list.
+func (c *Config) applyEnvironmentOverrides() error {
+	layer := make(map[string]any)
+	variables := overrideEnvironment()
+	sort.Strings(variables)
+	for _, variable := range variables {
+		name, value, _ := strings.Cut(variable, "=")
+		name = strings.ToUpper(name)
+		if !strings.HasPrefix(name, EnvOverridePrefix) || strings.HasPrefix(name, EnvVariablePrefix) {
+			continue
+		}
+		path := c.overridePath(name)
+		if len(path) == 0 {
+			clog.Tracef("environment variable %s doesn't match any configuration key", name)
+			continue
+		}
+		clog.Debugf("environment variable %s overrides %q", name, strings.Join(path, "."))
+		setLayerValue(layer, path, overrideValue(value))
+	}
+	if len(layer) == 0 {
+		return nil
+	}
+	return c.viper.MergeConfigMap(layer)
+}
+
+// overridePath returns the path of the configuration key of the environment variable (nil when it doesn't match any profile)
+func (c *Config) overridePath(name string) []string {
+	if strings.HasPrefix(name, envGlobalPrefix) {
+		key := envToKey(strings.TrimPrefix(name, envGlobalPrefix))
+		if key == "" {
+			return nil
+		}
+		return []string{constants.SectionConfigurationGlobal, key}
+	}
+	rest := strings.TrimPrefix(name, EnvOverridePrefix)
+
+	// longest profile name first: "web_data" must be preferred to "web"
+	profileNames := c.GetProfileNames()
+	sort.Slice(profileNames, func(i, j int) bool { return len(profileNames[i]) > len(profileNames[j]) })
+	for _, profileName := range profileNames {
+		prefix := envName(profileName) + "_"
+		if !strings.HasPrefix(rest, prefix) {
+			continue
+		}
+		path := strings.Split(c.getProfilePath(profileName), c.keyDelim)
+		rest = strings.TrimPrefix(rest, prefix)
+		for _, section := range NewProfileInfo(false).Sections() {
+			if prefix := envName(section) + "_"; strings.HasPrefix(rest, prefix) {
+				path = append(path, section)
+				rest = strings.TrimPrefix(rest, prefix)
+				break
+			}
+		}
+		if key := envToKey(rest); key != "" {
+			return append(path, key)
+		}
+		return nil
+	}
+	return nil
+}
+
+// envName returns the name of a profile, section or key in an environment variable
+func envName(name string) string {
+	return strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// envToKey returns the configuration key of the end of an environment variable name
+func envToKey(name string) string {
+	return strings.ToLower(strings.ReplaceAll(name, "_", "-"))
+}
+
+// overrideValue returns the value of an environment variable, decoding a JSON list
+func overrideValue(value string) any {
+	if strings.HasPrefix(strings.TrimSpace(value), "[") {
+		list := make([]any, 0)
+		if err := json.Unmarshal([]byte(value), &list); err == nil {
+			return list
+		}
+	}
+	return value
+}
+
+// setLayerValue sets the value at the path, creating the intermediate maps
+func setLayerValue(layer map[string]any, path []string, value any) {
+	target := layer
+	for _, key := range path[:len(path)-1] {
+		child, ok := target[key].(map[string]any)
+		if !ok {
+			child = make(map[string]any)
+			target[key] = child
+		}
+		target = child
+	}
+	target[path[len(path)-1]] = value
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loadWithEnvironment(t *testing.T, env []string, configuration, format string) *Config {
+	t.Helper()
+	environ := overrideEnvironment
+	t.Cleanup(func() { overrideEnvironment = environ })
+	overrideEnvironment = func() []string { return env }
+
+	c, err := Load(bytes.NewBufferString(configuration), format)
+	require.NoError(t, err)
+	return c
+}
+
+func TestEnvironmentOverrides(t *testing.T) {
+	env := []string{
+		"RESTICPROFILE_GLOBAL_MIN_MEMORY=500",
+		"RESTICPROFILE_HOME_REPOSITORY=local:/other",
+		"RESTICPROFILE_HOME_BACKUP_TAG=[\"one\", \"two\"]",
+		"RESTICPROFILE_HOME_BACKUP_CHECK_BEFORE=true",
+		"resticprofile_web_data_retention_keep_last=5",
+		"RESTICPROFILE_UNKNOWN_REPOSITORY=local:/unknown",
+		"RESTICPROFILE_VAR_NAME=value",
+		"HOME_REPOSITORY=local:/not-an-override",
+	}
+	configuration := `
+version: "2"
+global:
+  min-memory: 200
+profiles:
+  home:
+    repository: "local:/backup"
+    backup:
+      tag: home
+  web:
+    repository: "local:/web"
+  web-data:
+    inherit: web
+`
+	c := loadWithEnvironment(t, env, configuration, FormatYAML)
+
+	global, err := c.GetGlobalSection()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(500), global.MinMemory)
+
+	home, err := c.GetProfile("home")
+	require.NoError(t, err)
+	assert.Equal(t, "local:/other", home.Repository.Value())
+	assert.Equal(t, []any{"one", "two"}, home.Backup.OtherFlags["tag"])
+	assert.True(t, home.Backup.CheckBefore)
+
+	web, err := c.GetProfile("web")
+	require.NoError(t, err)
+	assert.Nil(t, web.Retention)
+
+	webData, err := c.GetProfile("web-data")
+	require.NoError(t, err)
+	require.NotNil(t, webData.Retention)
+	assert.Equal(t, "5", webData.Retention.OtherFlags["keep-last"])
+	assert.Equal(t, "local:/web", webData.Repository.Value())
+
+	assert.ElementsMatch(t, []string{"home", "web", "web-data"}, c.GetProfileNames())
+}
+
+func TestEnvironmentOverridesV1(t *testing.T) {
+	env := []string{"RESTICPROFILE_HOME_PASSWORD_FILE=other.key"}
+	configuration := `
+[home]
+password-file = "key"
+`
+	c := loadWithEnvironment(t, env, configuration, FormatTOML)
+	home, err := c.GetProfile("home")
+	require.NoError(t, err)
+	assert.Equal(t, "other.key", home.PasswordFile)
+}
+
+func TestTemplateVariables(t *testing.T) {
+	env := []string{"RESTICPROFILE_VAR_REPO=local:/from-env", "RESTICPROFILE_VAR_=ignored"}
+	configuration := `
+version: "2"
+profiles:
+  home:
+    repository: '{{ or .Vars.REPO "local:/default" }}'
+    password-file: '{{ or .Vars.PASSWORD_FILE "key" }}'
+`
+	c := loadWithEnvironment(t, env, configuration, FormatYAML)
+	home, err := c.GetProfile("home")
+	require.NoError(t, err)
+	assert.Equal(t, "local:/from-env", home.Repository.Value())
+	assert.Equal(t, "key", home.PasswordFile)
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/creativeprojects/resticprofile/constants"
 	"github.com/creativeprojects/resticprofile/shell"
 	"github.com/creativeprojects/resticprofile/util"
+	"golang.org/x/exp/maps"
 )
 
 var (
@@ -63,9 +64,34 @@ func argAliasesFromStruct(section any) (aliases map[string]string) {
 	return aliases
 }
 
+// argOptionsFromStruct returns the option name of each field of the section (having a value) per restic flag name
+func argOptionsFromStruct(section any) (options map[string]string) {
+	options = make(map[string]string)
+	valueOf, isNil := util.UnpackValue(reflect.ValueOf(section))
+	if isNil || valueOf.Kind() != reflect.Struct {
+		return
+	}
+	typeOf := valueOf.Type()
+	for i := 0; i < typeOf.NumField(); i++ {
+		field := typeOf.Field(i)
+		if argument, ok := field.Tag.Lookup("argument"); ok && argument != "" {
+			if _, ok = stringifyConfidentialValue(valueOf.Field(i)); ok {
+				options[argument] = argument
+				if name, ok := field.Tag.Lookup("mapstructure"); ok && name != "" {
+					options[argument] = name
+				}
+			}
+		}
+	}
+	return
+}
+
 func addArgsFromMap(args *shell.Args, argAliases map[string]string, argsMap map[string]any) {
-	// Add other args
-	for name, value := range argsMap {
+	// Add other args (sorted to keep the same result when a flag is set by its name and by an alias)
+	names := maps.Keys(argsMap)
+	sort.Strings(names)
+	for _, name := range names {
+		value := argsMap[name]
 		if name == constants.SectionConfigurationMixinUse {
 			continue
 		}
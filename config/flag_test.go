@@ -200,6 +200,16 @@ func TestGetArgAliasesFromStruct(t *testing.T) {
 	}
 }
 
+func TestAddArgsFromMapIsStable(t *testing.T) {
+	aliases := map[string]string{"unsigned-int": "u-int"}
+	argsMap := map[string]any{"u-int": 1, "unsigned-int": 2, "b": true, "a": "x"}
+	for i := 0; i < 20; i++ {
+		args := shell.NewArgs()
+		addArgsFromMap(args, aliases, argsMap)
+		assert.Equal(t, []string{"--a=x", "--b", "--u-int=2"}, args.GetAll())
+	}
+}
+
 func BenchmarkFormatInt(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		var value int64 = 123456
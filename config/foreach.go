@@ -0,0 +1,178 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/constants"
+	"github.com/spf13/cast"
+	"golang.org/x/exp/maps"
+)
+
+// foreachItemVariable is the variable replaced by the item in the values of a profile expanded with "foreach"
+const foreachItemVariable = "ITEM"
+
+// invalidProfileNameChars matches the characters replaced in the item to build the name of a generated profile
+var invalidProfileNameChars = regexp.MustCompile(`[^a-z0-9_-]+`)
+
+// expandForeachProfiles generates one profile per item of the profiles declaring "foreach" or "foreach-file".
+// The generated profiles are named "<profile>-<item>" and every "${ITEM}" in their values is replaced by the item.
+// The declaring profile becomes abstract: it is only used as the template of the generated profiles.
+func (c *Config) expandForeachProfiles() error {
+	c.foreach = nil
+	names := c.GetProfileNames()
+	sort.Strings(names)
+
+	for _, name := range names {
+		profilePath := c.getProfilePath(name)
+		content := c.viper.GetStringMap(profilePath)
+		_, hasItems := content[constants.SectionConfigurationForeach]
+		_, hasFile := content[constants.SectionConfigurationForeachFile]
+		if !hasItems && !hasFile {
+			continue
+		}
+
+		items, err := c.getForeachItems(content)
+		if err != nil {
+			return fmt.Errorf("cannot expand profile '%s': %w", name, err)
+		}
+		if len(items) == 0 {
+			clog.Warningf("profile '%s': foreach has no item, no profile is generated", name)
+		}
+
+		template := maps.Clone(content)
+		delete(template, constants.SectionConfigurationForeach)
+		delete(template, constants.SectionConfigurationForeachFile)
+		delete(template, constants.SectionConfigurationAbstract)
+
+		generated := make([]string, 0, len(items))
+		for _, item := range items {
+			generatedName := foreachProfileName(name, item)
+			if generatedName == name || c.HasProfile(generatedName) {
+				return fmt.Errorf("cannot expand profile '%s': profile '%s' already exists", name, generatedName)
+			}
+			variables := map[string]any{foreachItemVariable: item}
+			generatedContent := (&mixin{Source: template}).Resolve(variables)
+			if err = mergeConfigMap(c.viper, c.getProfilePath(generatedName), c.keyDelim, generatedContent); err != nil {
+				return err
+			}
+			c.copyMixinUses(profilePath, c.getProfilePath(generatedName), variables)
+			generated = append(generated, generatedName)
+		}
+		clog.Tracef("profile '%s' expanded into %s", name, strings.Join(generated, ", "))
+
+		abstract := map[string]any{constants.SectionConfigurationAbstract: true}
+		if err = mergeConfigMap(c.viper, profilePath, c.keyDelim, abstract); err != nil {
+			return err
+		}
+		if c.foreach == nil {
+			c.foreach = make(map[string][]string)
+		}
+		c.foreach[name] = generated
+	}
+	return nil
+}
+
+// getForeachItems returns the items of "foreach" followed by the items of "foreach-file" (without duplicates)
+func (c *Config) getForeachItems(content map[string]any) (items []string, err error) {
+	if value, found := content[constants.SectionConfigurationForeach]; found {
+		if items, err = cast.ToStringSliceE(value); err != nil {
+			return nil, fmt.Errorf("invalid foreach: %w", err)
+		}
+	}
+	if value, found := content[constants.SectionConfigurationForeachFile]; found {
+		filename := cast.ToString(value)
+		if filename != "" && !filepath.IsAbs(filename) {
+			filename = filepath.Join(filepath.Dir(c.configFile), filename)
+		}
+		var lines []string
+		if lines, err = readForeachFile(filename); err != nil {
+			return nil, err
+		}
+		items = append(items, lines...)
+	}
+
+	unique := make([]string, 0, len(items))
+	seen := make(map[string]bool, len(items))
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		if item == "" || seen[item] {
+			continue
+		}
+		seen[item] = true
+		unique = append(unique, item)
+	}
+	return unique, nil
+}
+
+// readForeachFile returns the lines of the file, ignoring empty lines and comments (starting with #)
+func readForeachFile(filename string) (lines []string, err error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read foreach-file: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("cannot read foreach-file: %w", err)
+	}
+	return
+}
+
+// foreachProfileName returns the name of the profile generated for the item ("/var/lib/mysql" gives "name-var-lib-mysql")
+func foreachProfileName(name, item string) string {
+	suffix := strings.Trim(invalidProfileNameChars.ReplaceAllString(strings.ToLower(item), "-"), "-")
+	if suffix == "" {
+		return name
+	}
+	return name + "-" + suffix
+}
+
+// copyMixinUses declares the mixin uses of the profile template (and of its sections) in the generated profile
+func (c *Config) copyMixinUses(templatePath, generatedPath string, variables map[string]any) {
+	for _, allUses := range c.mixinUses {
+		for key, uses := range allUses {
+			if key != templatePath && !strings.HasPrefix(key, templatePath+c.keyDelim) {
+				continue
+			}
+			copies := make([]*mixinUse, len(uses))
+			for i, use := range uses {
+				copies[i] = &mixinUse{
+					Name:      use.Name,
+					Variables: (&mixin{Source: use.Variables}).Resolve(variables),
+				}
+			}
+			allUses[generatedPath+strings.TrimPrefix(key, templatePath)] = copies
+		}
+	}
+}
+
+// expandForeachNames replaces the names of the profiles declaring "foreach" by the names of the profiles they generate
+func (c *Config) expandForeachNames(names []string) []string {
+	if len(c.foreach) == 0 {
+		return names
+	}
+	expanded := make([]string, 0, len(names))
+	for _, name := range names {
+		if generated, found := c.foreach[name]; found {
+			expanded = append(expanded, generated...)
+		} else {
+			expanded = append(expanded, name)
+		}
+	}
+	return expanded
+}
@@ -0,0 +1,159 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestForeachProfileName(t *testing.T) {
+	testData := []struct {
+		item, expected string
+	}{
+		{"app", "db-app"},
+		{"Billing", "db-billing"},
+		{"/var/lib/mysql", "db-var-lib-mysql"},
+		{"my.host:22", "db-my-host-22"},
+		{"///", "db"},
+	}
+	for _, testItem := range testData {
+		t.Run(testItem.item, func(t *testing.T) {
+			assert.Equal(t, testItem.expected, foreachProfileName("db", testItem.item))
+		})
+	}
+}
+
+func TestForeachProfiles(t *testing.T) {
+	content := `
+version = "2"
+
+[profiles.base]
+password-file = "key"
+
+[profiles.db]
+inherit = "base"
+foreach = ["app", "billing"]
+repository = "local:/backup/${ITEM}"
+description = "database ${ITEM}"
+
+[profiles.db.backup]
+source = "/dumps/${ITEM}"
+run-before = "dump ${item} ${HOME}"
+schedule = "daily"
+
+[groups.databases]
+profiles = ["db", "other"]
+
+[schedules.nightly]
+profiles = ["db"]
+schedule = "02:00"
+`
+	c, err := Load(bytes.NewBufferString(content), FormatTOML)
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []string{"base", "db", "db-app", "db-billing"}, c.GetProfileNames())
+	assert.True(t, c.IsAbstractProfile("db"))
+	assert.False(t, c.IsAbstractProfile("db-app"))
+
+	for _, item := range []string{"app", "billing"} {
+		t.Run(item, func(t *testing.T) {
+			profile, err := c.GetProfile("db-" + item)
+			require.NoError(t, err)
+			assert.Equal(t, "local:/backup/"+item, profile.Repository.Value())
+			assert.Equal(t, "database "+item, profile.Description)
+			assert.Equal(t, "key", profile.PasswordFile)
+			assert.Empty(t, profile.Foreach)
+			require.NotNil(t, profile.Backup)
+			assert.Equal(t, []string{"/dumps/" + item}, profile.Backup.Source)
+			assert.Equal(t, []string{"dump " + item + " ${HOME}"}, profile.Backup.RunBefore)
+			assert.Equal(t, []string{"daily"}, profile.Backup.Schedule)
+			assert.NotContains(t, profile.OtherFlags, "foreach")
+		})
+	}
+
+	group, err := c.GetProfileGroup("databases")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"db-app", "db-billing", "other"}, group.Profiles)
+
+	schedules, err := c.GetScheduleSections()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"db-app", "db-billing"}, schedules["nightly"].Profiles)
+}
+
+func TestForeachFile(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "items.txt"), []byte("# databases\nfirst\n\n  second  \nfirst\n"), 0o600))
+	configFile := filepath.Join(dir, "profiles.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte(`
+version: 1
+db:
+  foreach: zero
+  foreach-file: items.txt
+  repository: "local:/backup/${ITEM}"
+`), 0o600))
+
+	c, err := LoadFile(configFile, "")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"db", "db-zero", "db-first", "db-second"}, c.GetProfileNames())
+
+	profile, err := c.GetProfile("db-second")
+	require.NoError(t, err)
+	assert.Equal(t, "local:/backup/second", profile.Repository.Value())
+}
+
+func TestForeachErrors(t *testing.T) {
+	testData := []struct {
+		name, content, message string
+	}{
+		{
+			name: "missing file",
+			content: `
+version = "2"
+[profiles.db]
+foreach-file = "does-not-exist.txt"
+`,
+			message: "cannot expand profile 'db': cannot read foreach-file",
+		},
+		{
+			name: "existing profile",
+			content: `
+version = "2"
+[profiles.db]
+foreach = ["app"]
+[profiles.db-app]
+repository = "local:/backup"
+`,
+			message: "cannot expand profile 'db': profile 'db-app' already exists",
+		},
+	}
+	for _, testItem := range testData {
+		t.Run(testItem.name, func(t *testing.T) {
+			_, err := Load(bytes.NewBufferString(testItem.content), FormatTOML)
+			require.Error(t, err)
+			assert.ErrorContains(t, err, testItem.message)
+		})
+	}
+}
+
+func TestForeachWithMixins(t *testing.T) {
+	content := `
+version = "2"
+
+[mixins.repo]
+repository = "local:/backup/${name}"
+
+[profiles.db]
+foreach = ["app"]
+use = [{name = "repo", vars = {name = "${ITEM}"}}]
+`
+	c, err := Load(bytes.NewBufferString(content), FormatTOML)
+	require.NoError(t, err)
+
+	profile, err := c.GetProfile("db-app")
+	require.NoError(t, err)
+	assert.Equal(t, "local:/backup/app", profile.Repository.Value())
+}
@@ -1,6 +1,9 @@
 package config
 
 import (
+	"path"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/creativeprojects/resticprofile/constants"
@@ -8,28 +11,43 @@ import (
 
 // Global holds the configuration from the global section
 type Global struct {
-	IONice               bool          `mapstructure:"ionice" default:"false" description:"Enables setting the unix IO priority class and level for resticprofile and child processes (only on unix OS)."`
-	IONiceClass          int           `mapstructure:"ionice-class" default:"2" range:"[1:3]" description:"Sets the unix \"ionice-class\" to apply when \"ionice\" is enabled"`
-	IONiceLevel          int           `mapstructure:"ionice-level" default:"0" range:"[0:7]" description:"Sets the unix \"ionice-level\" to apply when \"ionice\" is enabled"`
-	Nice                 int           `mapstructure:"nice" default:"0" range:"[-20:19]" description:"Sets the unix \"nice\" value for resticprofile and child processes (on any OS)"`
-	Priority             string        `mapstructure:"priority" default:"normal" enum:"idle;background;low;normal;high;highest" description:"Sets process priority class for resticprofile and child processes (on any OS)"`
-	DefaultCommand       string        `mapstructure:"default-command" default:"snapshots" description:"The restic or resticprofile command to use when no command was specified"`
-	Initialize           bool          `mapstructure:"initialize" default:"false" description:"Initialize a repository if missing"`
-	ResticBinary         string        `mapstructure:"restic-binary" description:"Full path of the restic executable (detected if not set)"`
-	ResticVersion        string        // not configurable at the moment. To be set after ResticBinary is known.
-	FilterResticFlags    bool          `mapstructure:"restic-arguments-filter" default:"true" description:"Remove unknown flags instead of passing all configured flags to restic"`
-	ResticLockRetryAfter time.Duration `mapstructure:"restic-lock-retry-after" default:"1m" description:"Time to wait before trying to get a lock on a restic repositoey - see https://creativeprojects.github.io/resticprofile/usage/locks/"`
-	ResticStaleLockAge   time.Duration `mapstructure:"restic-stale-lock-age" default:"2h" description:"The age an unused lock on a restic repository must have at least before resiticprofile attempts to unlock - see https://creativeprojects.github.io/resticprofile/usage/locks/"`
-	ShellBinary          []string      `mapstructure:"shell" default:"auto" examples:"sh;bash;pwsh;powershell;cmd" description:"The shell that is used to run commands (default is OS specific)"`
-	MinMemory            uint64        `mapstructure:"min-memory" default:"100" description:"Minimum available memory (in MB) required to run any commands - see https://creativeprojects.github.io/resticprofile/usage/memory/"`
-	Scheduler            string        `mapstructure:"scheduler" description:"Leave blank for the default scheduler or use \"crond\" to select cron on supported operating systems"`
-	LegacyArguments      bool          `mapstructure:"legacy-arguments" default:"false" deprecated:"0.20.0" description:"Legacy, broken arguments mode of resticprofile before version 0.15"`
-	SystemdUnitTemplate  string        `mapstructure:"systemd-unit-template" default:"" description:"File containing the go template to generate a systemd unit - see https://creativeprojects.github.io/resticprofile/schedules/systemd/"`
-	SystemdTimerTemplate string        `mapstructure:"systemd-timer-template" default:"" description:"File containing the go template to generate a systemd timer - see https://creativeprojects.github.io/resticprofile/schedules/systemd/"`
-	SenderTimeout        time.Duration `mapstructure:"send-timeout" default:"30s" examples:"15s;30s;2m30s" description:"Timeout when sending messages to a webhook - see https://creativeprojects.github.io/resticprofile/configuration/http_hooks/"`
-	CACertificates       []string      `mapstructure:"ca-certificates" description:"Path to PEM encoded certificates to trust in addition to system certificates when resticprofile sends to a webhook - see https://creativeprojects.github.io/resticprofile/configuration/http_hooks/"`
-	PreventSleep         bool          `mapstructure:"prevent-sleep" default:"false" description:"Prevent the system from sleeping while running commands - see https://creativeprojects.github.io/resticprofile/configuration/sleep/"`
-	GroupContinueOnError bool          `mapstructure:"group-continue-on-error" default:"false" description:"Enable groups to continue with the next profile(s) instead of stopping at the first failure"`
+	IONice               bool                           `mapstructure:"ionice" default:"false" description:"Enables setting the unix IO priority class and level for resticprofile and child processes (only on unix OS)."`
+	IONiceClass          int                            `mapstructure:"ionice-class" default:"2" range:"[1:3]" description:"Sets the unix \"ionice-class\" to apply when \"ionice\" is enabled"`
+	IONiceLevel          int                            `mapstructure:"ionice-level" default:"0" range:"[0:7]" description:"Sets the unix \"ionice-level\" to apply when \"ionice\" is enabled"`
+	Nice                 int                            `mapstructure:"nice" default:"0" range:"[-20:19]" description:"Sets the unix \"nice\" value for resticprofile and child processes (on any OS)"`
+	Priority             string                         `mapstructure:"priority" default:"normal" enum:"idle;background;low;normal;high;highest" description:"Sets process priority class for resticprofile and child processes (on any OS)"`
+	DefaultCommand       string                         `mapstructure:"default-command" default:"snapshots" description:"The restic or resticprofile command to use when no command was specified"`
+	Initialize           bool                           `mapstructure:"initialize" default:"false" description:"Initialize a repository if missing"`
+	ResticBinary         string                         `mapstructure:"restic-binary" description:"Full path of the restic executable (detected if not set)"`
+	ResticVersion        string                         `mapstructure:"restic-version" pattern:"^(|[0-9]+\\.[0-9]+(\\.[0-9]+)?)$" examples:"0.14;0.15;0.16" description:"Version of restic to use for the flags instead of the version detected from restic-binary (pinned version) - see https://creativeprojects.github.io/resticprofile/configuration/restic_version/"`
+	FilterResticFlags    bool                           `mapstructure:"restic-arguments-filter" default:"true" description:"Remove unknown flags instead of passing all configured flags to restic"`
+	ResticLockRetryAfter time.Duration                  `mapstructure:"restic-lock-retry-after" default:"1m" description:"Time to wait before trying to get a lock on a restic repositoey - see https://creativeprojects.github.io/resticprofile/usage/locks/"`
+	ResticStaleLockAge   time.Duration                  `mapstructure:"restic-stale-lock-age" default:"2h" description:"The age an unused lock on a restic repository must have at least before resiticprofile attempts to unlock - see https://creativeprojects.github.io/resticprofile/usage/locks/"`
+	PathPrepend          []string                       `mapstructure:"path-prepend" description:"Directories to add at the beginning of the PATH of resticprofile and all the commands it starts - see https://creativeprojects.github.io/resticprofile/configuration/path/"`
+	ShellBinary          []string                       `mapstructure:"shell" default:"auto" examples:"sh;bash;pwsh;powershell;cmd" description:"The shell that is used to run commands (default is OS specific)"`
+	MinMemory            uint64                         `mapstructure:"min-memory" default:"100" description:"Minimum available memory (in MB) required to run any commands - see https://creativeprojects.github.io/resticprofile/usage/memory/"`
+	CapturedOutputLimit  int                            `mapstructure:"captured-output-limit" default:"64" description:"Maximum size (in KB) of the error output of a command kept in memory for notifications, status and history: only the beginning and the end of a larger output are kept (0 for no limit)"`
+	SizeUnits            string                         `mapstructure:"size-units" default:"iec" enum:"iec;si" description:"Units of the sizes displayed in summaries, reports and notifications: binary \"iec\" (KiB, MiB) or decimal \"si\" (kB, MB)"`
+	DurationStyle        string                         `mapstructure:"duration-style" default:"compact" enum:"compact;long;clock" description:"Style of the durations displayed in summaries, reports and notifications: \"compact\" (1h2m3s), \"long\" (1 hour 2 minutes 3 seconds) or \"clock\" (1:02:03)"`
+	Scheduler            string                         `mapstructure:"scheduler" description:"Leave blank for the default scheduler or use \"crond\" to select cron on supported operating systems (\"crontab\" writes a system crontab file instead when followed by its path). Parameters of the scheduler can follow the name after a colon (e.g. \"crond:/usr/bin/crontab\" or \"crontab:/etc/cron.d/resticprofile\")"`
+	MaintenanceWindows   []string                       `mapstructure:"maintenance-windows" examples:"Sun *-*-* 02:00 for 2h;*-*-01 00:00 for 6h" description:"Time ranges when the repositories are not available, as a calendar event followed by \"for\" and a duration: \"schedule simulate\" flags the runs in these windows - see https://creativeprojects.github.io/resticprofile/schedules/commands/"`
+	LegacyArguments      bool                           `mapstructure:"legacy-arguments" default:"false" deprecated:"0.20.0" description:"Legacy, broken arguments mode of resticprofile before version 0.15"`
+	FailOnDeprecation    bool                           `mapstructure:"fail-on-deprecation" default:"false" description:"Fail running a profile when its configuration uses deprecated options - see https://creativeprojects.github.io/resticprofile/configuration/warnings/"`
+	SystemdUnitTemplate  string                         `mapstructure:"systemd-unit-template" default:"" description:"File containing the go template to generate a systemd unit - see https://creativeprojects.github.io/resticprofile/schedules/systemd/"`
+	SystemdTimerTemplate string                         `mapstructure:"systemd-timer-template" default:"" description:"File containing the go template to generate a systemd timer - see https://creativeprojects.github.io/resticprofile/schedules/systemd/"`
+	SenderTimeout        time.Duration                  `mapstructure:"send-timeout" default:"30s" examples:"15s;30s;2m30s" description:"Timeout when sending messages to a webhook - see https://creativeprojects.github.io/resticprofile/configuration/http_hooks/"`
+	CACertificates       []string                       `mapstructure:"ca-certificates" description:"Path to PEM encoded certificates to trust in addition to system certificates when resticprofile sends to a webhook - see https://creativeprojects.github.io/resticprofile/configuration/http_hooks/"`
+	PreventSleep         bool                           `mapstructure:"prevent-sleep" default:"false" description:"Prevent the system from sleeping while running commands - see https://creativeprojects.github.io/resticprofile/configuration/sleep/"`
+	GroupContinueOnError bool                           `mapstructure:"group-continue-on-error" default:"false" description:"Enable groups to continue with the next profile(s) instead of stopping at the first failure"`
+	DefaultProfileByHost map[string]string              `mapstructure:"default-profile-by-host" description:"Profile (or group) to use when no profile name is given on the command line, by host name pattern (e.g. \"web-*\" = \"web\") - see https://creativeprojects.github.io/resticprofile/usage/"`
+	StatusKeyFile        string                         `mapstructure:"status-encryption-key-file" description:"File containing a random key (at least 16 bytes) to encrypt the status and history files with AES-256-GCM - see https://creativeprojects.github.io/resticprofile/status/"`
+	AuditLog             string                         `mapstructure:"audit-log" description:"Append a JSON line to this file for every command started by resticprofile (restic and hooks), with the confidential values masked - see https://creativeprojects.github.io/resticprofile/status/audit/"`
+	AuditLogKeyFile      string                         `mapstructure:"audit-log-key-file" description:"File containing a secret key to sign each line of the audit log with an HMAC chained to the previous line - see https://creativeprojects.github.io/resticprofile/status/audit/"`
+	StorageCosts         map[string]*StorageCostSection `mapstructure:"storage-costs" description:"Prices of the storage per type of backend (\"s3\", \"b2\", \"azure\", \"gs\", \"sftp\", \"rest\", \"local\", etc.), to estimate the monthly cost of the repositories - see https://creativeprojects.github.io/resticprofile/status/storage_cost/"`
+	WebhookListen        string                         `mapstructure:"webhook-listen" default:":8091" description:"Address the \"webhook\" command listens to - see https://creativeprojects.github.io/resticprofile/usage/webhook/"`
+	WebhookSecretFile    string                         `mapstructure:"webhook-secret-file" description:"File containing the secret key verifying the HMAC signature of the requests received by the \"webhook\" command - see https://creativeprojects.github.io/resticprofile/usage/webhook/"`
+	WebhookAllow         []string                       `mapstructure:"webhook-allow" examples:"db.backup;*.copy" description:"Commands of the profiles that can be triggered by the \"webhook\" command, as \"profile.command\" patterns - see https://creativeprojects.github.io/resticprofile/usage/webhook/"`
+	WebhookAllowFrom     []string                       `mapstructure:"webhook-allow-from" examples:"10.0.0.0/8;192.168.1.10" description:"IP addresses or networks (CIDR) of the clients allowed to send requests to the \"webhook\" command (any client when empty)"`
 }
 
 // NewGlobal instantiates a new Global with default values
@@ -44,14 +62,43 @@ func NewGlobal() *Global {
 		ResticStaleLockAge:   constants.DefaultResticStaleLockAge,
 		MinMemory:            constants.DefaultMinMemory,
 		SenderTimeout:        constants.DefaultSenderTimeout,
+		CapturedOutputLimit:  constants.DefaultCapturedOutputLimit,
 	}
 }
 
 func (p *Global) SetRootPath(rootPath string) {
 	p.SystemdUnitTemplate = fixPath(p.SystemdUnitTemplate, expandEnv, absolutePrefix(rootPath))
 	p.SystemdTimerTemplate = fixPath(p.SystemdTimerTemplate, expandEnv, absolutePrefix(rootPath))
+	p.StatusKeyFile = fixPath(p.StatusKeyFile, expandEnv, expandUserHome, absolutePrefix(rootPath))
+	p.AuditLog = fixPath(p.AuditLog, expandEnv, expandUserHome, absolutePrefix(rootPath))
+	p.AuditLogKeyFile = fixPath(p.AuditLogKeyFile, expandEnv, expandUserHome, absolutePrefix(rootPath))
+	p.WebhookSecretFile = fixPath(p.WebhookSecretFile, expandEnv, expandUserHome, absolutePrefix(rootPath))
+
+	p.PathPrepend = fixPaths(p.PathPrepend, expandEnv, expandUserHome, absolutePrefix(rootPath))
 
 	for index, file := range p.CACertificates {
 		p.CACertificates[index] = fixPath(file, expandEnv, absolutePrefix(rootPath))
 	}
 }
+
+// GetDefaultProfileForHost returns the profile name declared in default-profile-by-host for the first pattern matching the host name.
+// Patterns are glob patterns (like "web-*") matched case-insensitively; the longest patterns are tried first.
+func (p *Global) GetDefaultProfileForHost(hostname string) (profileName, pattern string, found bool) {
+	patterns := make([]string, 0, len(p.DefaultProfileByHost))
+	for pattern := range p.DefaultProfileByHost {
+		patterns = append(patterns, pattern)
+	}
+	sort.Slice(patterns, func(i, j int) bool {
+		if len(patterns[i]) != len(patterns[j]) {
+			return len(patterns[i]) > len(patterns[j])
+		}
+		return patterns[i] < patterns[j]
+	})
+	hostname = strings.ToLower(hostname)
+	for _, pattern := range patterns {
+		if matched, err := path.Match(strings.ToLower(pattern), hostname); err == nil && matched {
+			return p.DefaultProfileByHost[pattern], pattern, true
+		}
+	}
+	return "", "", false
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/creativeprojects/resticprofile/constants"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestEmptyGlobalSection(t *testing.T) {
@@ -84,3 +85,34 @@ func getGlobalSection(configString string) (*Global, error) {
 	}
 	return global, nil
 }
+
+func TestDefaultProfileByHost(t *testing.T) {
+	configString := `[global.default-profile-by-host]
+"web-*" = "web"
+"web-test-*" = "web-test"
+"db-0?" = "database"
+"*.example.com" = "Example"
+`
+	global, err := getGlobalSection(configString)
+	require.NoError(t, err)
+
+	testData := []struct {
+		hostname, profile, pattern string
+	}{
+		{"web-01", "web", "web-*"},
+		{"WEB-02", "web", "web-*"},
+		{"web-test-01", "web-test", "web-test-*"},
+		{"db-01", "database", "db-0?"},
+		{"db-10", "", ""},
+		{"host.example.com", "Example", "*.example.com"},
+		{"laptop", "", ""},
+	}
+	for _, testItem := range testData {
+		t.Run(testItem.hostname, func(t *testing.T) {
+			profile, pattern, found := global.GetDefaultProfileForHost(testItem.hostname)
+			assert.Equal(t, testItem.profile != "", found)
+			assert.Equal(t, testItem.profile, profile)
+			assert.Equal(t, testItem.pattern, pattern)
+		})
+	}
+}
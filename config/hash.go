@@ -0,0 +1,114 @@
+package config
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+const (
+	// SourceHashPrefix starts the hash of the configuration files as they are on disk
+	SourceHashPrefix = "sha256:"
+	// RenderedHashPrefix starts the hash of the configuration files after executing their templates
+	RenderedHashPrefix = "sha256-rendered:"
+)
+
+// FileHash is the hash of one configuration file
+type FileHash struct {
+	Name string
+	Hash string
+}
+
+// GetFileHashes returns the SHA-256 of each configuration file, main file first then the includes in loading order,
+// then the machine-wide defaults file and the files of the remote profiles ("file#profile"). When rendered is true,
+// the hashes are calculated on the files after executing their templates (for the default profile).
+// The defaults file has no template: its hash is the same in both cases.
+func (c *Config) GetFileHashes(rendered bool) ([]FileHash, error) {
+	visited := make(map[string]bool)
+	if absolute, err := filepath.Abs(c.configFile); err == nil {
+		visited[absolute] = true
+	}
+	return c.fileHashes(rendered, visited)
+}
+
+// fileHashes returns the hashes of the configuration files. visited contains the remote files already hashed.
+func (c *Config) fileHashes(rendered bool, visited map[string]bool) ([]FileHash, error) {
+	if c.sourceTemplates == nil {
+		return nil, errors.New("no configuration loaded")
+	}
+	names := append([]string{c.configFile}, c.includeFiles...)
+	hashes := make([]FileHash, 0, len(names))
+	data := newTemplateData(c.configFile, "default", "")
+	buffer := &bytes.Buffer{}
+	for _, name := range names {
+		sum, found := c.sourceHashes[name]
+		if rendered {
+			buffer.Reset()
+			if err := c.sourceTemplates.ExecuteTemplate(buffer, c.templateName(name), data); err != nil {
+				return nil, fmt.Errorf("cannot execute %w", err)
+			}
+			sum, found = sha256.Sum256(buffer.Bytes()), true
+		}
+		if !found {
+			return nil, fmt.Errorf("configuration file %q was not loaded", name)
+		}
+		hashes = append(hashes, FileHash{Name: name, Hash: hex.EncodeToString(sum[:])})
+	}
+	if c.defaultsFile != "" {
+		hashes = append(hashes, FileHash{Name: c.defaultsFile, Hash: hex.EncodeToString(c.defaultsHash[:])})
+	}
+	remoteHashes, err := c.remoteFileHashes(rendered, visited)
+	if err != nil {
+		return nil, err
+	}
+	for _, remote := range remoteHashes {
+		if !slices.ContainsFunc(hashes, func(hash FileHash) bool { return hash.Name == remote.Name }) {
+			hashes = append(hashes, remote)
+		}
+	}
+	return hashes, nil
+}
+
+// GetHash returns a deterministic hash of the whole set of configuration files (main file, includes, defaults file
+// and files of the remote profiles).
+// It only depends on the content of the files and their loading order: moving the files to another directory
+// doesn't change the hash. The hash starts with SourceHashPrefix, or RenderedHashPrefix when rendered is true.
+func (c *Config) GetHash(rendered bool) (string, error) {
+	files, err := c.GetFileHashes(rendered)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.New()
+	for index, file := range files {
+		_, _ = fmt.Fprintf(hash, "%d:%s\n", index, file.Hash)
+	}
+	prefix := SourceHashPrefix
+	if rendered {
+		prefix = RenderedHashPrefix
+	}
+	return prefix + hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// CheckHash returns an error when the hash of the configuration is not the expected one.
+// The prefix of the expected hash selects the hash of the files on disk or after executing the templates.
+func (c *Config) CheckHash(expected string) error {
+	expected = strings.ToLower(strings.TrimSpace(expected))
+	rendered := strings.HasPrefix(expected, RenderedHashPrefix)
+	if !rendered && !strings.HasPrefix(expected, SourceHashPrefix) {
+		expected = SourceHashPrefix + expected
+	}
+	actual, err := c.GetHash(rendered)
+	if err != nil {
+		return err
+	}
+	if actual != expected {
+		return fmt.Errorf("configuration hash %s doesn't match the expected hash %s", actual, expected)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeHashTestFiles(t *testing.T, dir, profile string) string {
+	t.Helper()
+	main := filepath.Join(dir, "profiles.yaml")
+	require.NoError(t, os.WriteFile(main, []byte("version: 1\nincludes: include.yaml\n"+profile), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "include.yaml"), []byte("other:\n  repository: local:/other\n"), 0o600))
+	return main
+}
+
+func TestConfigHash(t *testing.T) {
+	profile := "default:\n  repository: \"local:/{{ .Profile.Name }}\"\n"
+	config, err := LoadFile(writeHashTestFiles(t, t.TempDir(), profile), "")
+	require.NoError(t, err)
+
+	hash, err := config.GetHash(false)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hash, SourceHashPrefix))
+	assert.Len(t, hash, len(SourceHashPrefix)+64)
+
+	files, err := config.GetFileHashes(false)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	assert.Equal(t, "profiles.yaml", filepath.Base(files[0].Name))
+	assert.Equal(t, "include.yaml", filepath.Base(files[1].Name))
+
+	rendered, err := config.GetHash(true)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(rendered, RenderedHashPrefix))
+	assert.NotEqual(t, strings.TrimPrefix(hash, SourceHashPrefix), strings.TrimPrefix(rendered, RenderedHashPrefix))
+
+	t.Run("same files in another directory", func(t *testing.T) {
+		other, err := LoadFile(writeHashTestFiles(t, t.TempDir(), profile), "")
+		require.NoError(t, err)
+		otherHash, err := other.GetHash(false)
+		require.NoError(t, err)
+		assert.Equal(t, hash, otherHash)
+	})
+
+	t.Run("changed file", func(t *testing.T) {
+		other, err := LoadFile(writeHashTestFiles(t, t.TempDir(), profile+"  initialize: true\n"), "")
+		require.NoError(t, err)
+		otherHash, err := other.GetHash(false)
+		require.NoError(t, err)
+		assert.NotEqual(t, hash, otherHash)
+	})
+
+	t.Run("check hash", func(t *testing.T) {
+		assert.NoError(t, config.CheckHash(hash))
+		assert.NoError(t, config.CheckHash(strings.ToUpper(strings.TrimPrefix(hash, SourceHashPrefix))))
+		assert.NoError(t, config.CheckHash(rendered))
+		assert.ErrorContains(t, config.CheckHash(SourceHashPrefix+"00"), "doesn't match the expected hash")
+		assert.Error(t, config.CheckHash(RenderedHashPrefix+strings.TrimPrefix(hash, SourceHashPrefix)))
+	})
+}
+
+func TestConfigHashWithoutFile(t *testing.T) {
+	config := newConfig("toml")
+	_, err := config.GetHash(false)
+	assert.Error(t, err)
+}
+
+func TestConfigHashWithDefaultsFile(t *testing.T) {
+	configuration := "version: \"2\"\nprofiles:\n  default:\n    repository: local:/backup\n"
+	c := loadWithDefaults(t, "global:\n  priority: low\n", "defaults.yaml", configuration, "profiles.yaml")
+
+	files, err := c.GetFileHashes(false)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	assert.Equal(t, "defaults.yaml", filepath.Base(files[1].Name))
+	rendered, err := c.GetFileHashes(true)
+	require.NoError(t, err)
+	assert.Equal(t, files[1], rendered[1])
+
+	hash, err := c.GetHash(false)
+	require.NoError(t, err)
+	other := loadWithDefaults(t, "global:\n  priority: background\n", "defaults.yaml", configuration, "profiles.yaml")
+	otherHash, err := other.GetHash(false)
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, otherHash)
+}
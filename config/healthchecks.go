@@ -0,0 +1,84 @@
+package config
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Default values of the healthchecks section
+const (
+	DefaultHealthchecksServer  = "https://hc-ping.com"
+	DefaultHealthchecksLogSize = 10
+)
+
+// Pings of a check of healthchecks.io
+const (
+	HealthchecksPingSuccess = ""
+	HealthchecksPingStart   = "start"
+	HealthchecksPingFail    = "fail"
+	HealthchecksPingLog     = "log"
+)
+
+// HealthchecksSection pings a check of healthchecks.io when a command starts, succeeds or fails
+type HealthchecksSection struct {
+	UUID    ConfidentialValue `mapstructure:"uuid" examples:"5bf66975-d4c7-4bf5-bcc8-b8d8a82ea278" description:"UUID of the check (the last part of its ping URL)"`
+	PingKey ConfidentialValue `mapstructure:"ping-key" description:"Ping key of the project, to ping the check by its slug instead of its UUID"`
+	Slug    string            `mapstructure:"slug" description:"Slug of the check pinged with the \"ping-key\" (defaults to \"<profile>-<command>\")"`
+	Create  bool              `mapstructure:"create" description:"Create the check of the slug on its first ping (with the \"ping-key\" only)"`
+	Server  string            `mapstructure:"server" format:"uri" default:"https://hc-ping.com" description:"URL of the ping endpoint of a self-hosted healthchecks server"`
+	LogSize int               `mapstructure:"log-size" default:"10" description:"Maximum size (in KB) of the summary and output sent with the pings"`
+	SkipTLS bool              `mapstructure:"skip-tls-verification" description:"Enables insecure TLS (without verification), see also \"global.ca-certificates\""`
+}
+
+func (h *HealthchecksSection) IsEmpty() bool {
+	return h == nil || (h.UUID.Value() == "" && h.PingKey.Value() == "")
+}
+
+// GetLogSize returns the maximum size (in bytes) of the body of the pings
+func (h *HealthchecksSection) GetLogSize() int {
+	if h.LogSize > 0 {
+		return h.LogSize * 1024
+	}
+	return DefaultHealthchecksLogSize * 1024
+}
+
+var invalidSlugCharacters = regexp.MustCompile(`[^a-z0-9_-]+`)
+
+// GetSlug returns the slug of the check of the command
+func (h *HealthchecksSection) GetSlug(profileName, command string) string {
+	if h.Slug != "" {
+		return h.Slug
+	}
+	return invalidSlugCharacters.ReplaceAllString(strings.ToLower(profileName+"-"+command), "-")
+}
+
+// GetPingURL returns the URL of the ping (start, fail, log or success) of the check of the command.
+// The UUID and the ping key are hidden from the public representation of the URL.
+func (h *HealthchecksSection) GetPingURL(profileName, command, ping string) ConfidentialValue {
+	server := strings.TrimSuffix(h.Server, "/")
+	if server == "" {
+		server = DefaultHealthchecksServer
+	}
+	var confidential, public []string
+	if h.UUID.Value() != "" {
+		confidential = []string{server, h.UUID.Value()}
+		public = []string{server, h.UUID.String()}
+	} else {
+		slug := url.PathEscape(h.GetSlug(profileName, command))
+		confidential = []string{server, h.PingKey.Value(), slug}
+		public = []string{server, h.PingKey.String(), slug}
+	}
+	if ping != HealthchecksPingSuccess {
+		confidential = append(confidential, ping)
+		public = append(public, ping)
+	}
+	query := ""
+	if h.Create && h.UUID.Value() == "" {
+		query = "?create=1"
+	}
+	return ConfidentialValue{
+		public:       strings.Join(public, "/") + query,
+		confidential: strings.Join(confidential, "/") + query,
+	}
+}
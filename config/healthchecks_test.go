@@ -0,0 +1,41 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealthchecksPingURL(t *testing.T) {
+	check := &HealthchecksSection{UUID: NewConfidentialValue("5bf66975")}
+	check.UUID.hideValue()
+	url := check.GetPingURL("home", "backup", HealthchecksPingStart)
+	assert.Equal(t, "https://hc-ping.com/5bf66975/start", url.Value())
+	assert.Equal(t, "https://hc-ping.com/"+ConfidentialReplacement+"/start", url.String())
+	assert.Equal(t, "https://hc-ping.com/5bf66975", check.GetPingURL("home", "backup", HealthchecksPingSuccess).Value())
+
+	check = &HealthchecksSection{PingKey: NewConfidentialValue("key"), Create: true, Server: "https://hc.example.com/ping/"}
+	assert.Equal(t, "https://hc.example.com/ping/key/my-home-backup/fail?create=1", check.GetPingURL("My Home", "backup", HealthchecksPingFail).Value())
+	check.Slug = "nightly"
+	assert.Equal(t, "https://hc.example.com/ping/key/nightly?create=1", check.GetPingURL("My Home", "backup", HealthchecksPingSuccess).Value())
+}
+
+func TestHealthchecksIsEmpty(t *testing.T) {
+	var check *HealthchecksSection
+	assert.True(t, check.IsEmpty())
+	assert.True(t, (&HealthchecksSection{Server: "https://hc.example.com"}).IsEmpty())
+	assert.False(t, (&HealthchecksSection{PingKey: NewConfidentialValue("key")}).IsEmpty())
+	assert.Equal(t, 10*1024, (&HealthchecksSection{}).GetLogSize())
+}
+
+func TestHealthchecksConfidential(t *testing.T) {
+	testConfig := `
+[profile.backup.healthchecks]
+uuid = "5bf66975-d4c7-4bf5-bcc8-b8d8a82ea278"
+`
+	profile, err := getProfile("toml", testConfig, "profile", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "5bf66975-d4c7-4bf5-bcc8-b8d8a82ea278", profile.Backup.Healthchecks.UUID.Value())
+	assert.Equal(t, ConfidentialReplacement, profile.Backup.Healthchecks.UUID.String())
+	assert.Empty(t, profile.Backup.Healthchecks.PingKey.String())
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"strings"
+
+	"golang.org/x/exp/maps"
+)
+
+// Values of "inherit-hooks"
+const (
+	InheritHooksReplace = "replace"
+	InheritHooksAppend  = "append"
+)
+
+const inheritHooksKey = "inherit-hooks"
+
+// inheritableHooks are the lists of hooks concatenated to the inherited ones with "inherit-hooks = append"
+var inheritableHooks = []string{"run-before", "run-after", "run-after-fail", "run-finally", "run-on-disk-full"}
+
+// appendInheritedHooks returns the content of a derived profile where the hooks of the profile and of its sections are
+// declared with the append list operator ("run-before...") when their "inherit-hooks" mode is "append".
+// The mode is read from the section of the derived profile, then from the same section of the parent (parentMode
+// receives an empty name for the profile itself). The content is copied before any change.
+func appendInheritedHooks(content map[string]any, parentMode func(section string) string) (result map[string]any, changed bool) {
+	result, changed = appendHooks(content, "", parentMode)
+	for name, value := range content {
+		if section, ok := value.(map[string]any); ok {
+			if section, sectionChanged := appendHooks(section, name, parentMode); sectionChanged {
+				if !changed {
+					result, changed = maps.Clone(content), true
+				}
+				result[name] = section
+			}
+		}
+	}
+	return
+}
+
+func appendHooks(content map[string]any, section string, parentMode func(section string) string) (result map[string]any, changed bool) {
+	result = content
+	mode, _ := content[inheritHooksKey].(string)
+	if mode == "" && parentMode != nil {
+		mode = parentMode(section)
+	}
+	if !strings.EqualFold(mode, InheritHooksAppend) {
+		return
+	}
+	for _, key := range inheritableHooks {
+		if value, found := content[key]; found {
+			if !changed {
+				result, changed = maps.Clone(content), true
+			}
+			delete(result, key)
+			result[key+"..."] = value
+		}
+	}
+	return
+}
+
+// getInheritHooksMode returns the "inherit-hooks" mode declared in a section of a profile map (empty name for the profile)
+func getInheritHooksMode(profile map[string]any, section string) string {
+	if section != "" {
+		profile, _ = profile[section].(map[string]any)
+	}
+	mode, _ := profile[inheritHooksKey].(string)
+	return mode
+}
+
+// getInheritHooksMode returns the "inherit-hooks" mode of a section of the profile (empty name for the profile)
+func (p *Profile) getInheritHooksMode(section string) string {
+	if section == "" {
+		return p.InheritHooks
+	}
+	if s, ok := GetSectionWith[RunShellCommands](p, section); ok {
+		if hooks := s.GetRunShellCommands(); hooks != nil {
+			return hooks.InheritHooks
+		}
+	}
+	return ""
+}
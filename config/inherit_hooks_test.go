@@ -0,0 +1,125 @@
+package config
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInheritHooks(t *testing.T) {
+	v1 := `
+[parent]
+run-before = "parent before"
+run-finally = "parent finally"
+[parent.backup]
+run-before = "parent backup before"
+run-after = "parent backup after"
+`
+	v2 := `
+version = "2"
+[profiles.parent]
+run-before = "parent before"
+run-finally = "parent finally"
+[profiles.parent.backup]
+run-before = "parent backup before"
+run-after = "parent backup after"
+`
+	testData := []struct {
+		name                          string
+		child, parentMode             string
+		profileBefore, profileFinally []string
+		backupBefore, backupAfter     []string
+	}{
+		{
+			name: "replace by default",
+			child: `
+run-before = "child before"
+[backup]
+run-before = "child backup before"
+`,
+			profileBefore:  []string{"child before"},
+			profileFinally: []string{"parent finally"},
+			backupBefore:   []string{"child backup before"},
+			backupAfter:    []string{"parent backup after"},
+		},
+		{
+			name: "append in the profile only",
+			child: `
+inherit-hooks = "append"
+run-before = "child before"
+[backup]
+run-before = "child backup before"
+`,
+			profileBefore:  []string{"parent before", "child before"},
+			profileFinally: []string{"parent finally"},
+			backupBefore:   []string{"child backup before"},
+			backupAfter:    []string{"parent backup after"},
+		},
+		{
+			name: "append in the section",
+			child: `
+run-before = "child before"
+[backup]
+inherit-hooks = "append"
+run-before = "child backup before"
+run-after = ["child backup after"]
+`,
+			profileBefore:  []string{"child before"},
+			profileFinally: []string{"parent finally"},
+			backupBefore:   []string{"parent backup before", "child backup before"},
+			backupAfter:    []string{"parent backup after", "child backup after"},
+		},
+		{
+			name: "append declared in the parent",
+			child: `
+run-before = "child before"
+[backup]
+run-before = "child backup before"
+`,
+			parentMode:     `inherit-hooks = "append"`,
+			profileBefore:  []string{"parent before", "child before"},
+			profileFinally: []string{"parent finally"},
+			backupBefore:   []string{"child backup before"},
+			backupAfter:    []string{"parent backup after"},
+		},
+		{
+			name: "replace in the child",
+			child: `
+inherit-hooks = "replace"
+run-before = "child before"
+`,
+			parentMode:     `inherit-hooks = "append"`,
+			profileBefore:  []string{"child before"},
+			profileFinally: []string{"parent finally"},
+			backupBefore:   []string{"parent backup before"},
+			backupAfter:    []string{"parent backup after"},
+		},
+	}
+
+	for _, version := range []struct{ name, parent, prefix string }{{"v1", v1, ""}, {"v2", v2, "profiles."}} {
+		for _, test := range testData {
+			t.Run(version.name+" "+test.name, func(t *testing.T) {
+				// the mode of the parent is declared at the top of the parent profile
+				parent := version.parent
+				if test.parentMode != "" {
+					parent = strings.Replace(parent, "run-before = \"parent before\"", test.parentMode+"\nrun-before = \"parent before\"", 1)
+				}
+				child := "\n[" + version.prefix + "child]\ninherit = \"parent\"\n" +
+					strings.Replace(test.child, "[backup]", "["+version.prefix+"child.backup]", 1)
+				c, err := Load(bytes.NewBufferString(parent+child), FormatTOML)
+				require.NoError(t, err)
+				profile, err := c.GetProfile("child")
+				require.NoError(t, err)
+
+				assert.Equal(t, test.profileBefore, profile.RunBefore)
+				assert.Equal(t, test.profileFinally, profile.RunFinally)
+				require.NotNil(t, profile.Backup)
+				assert.Equal(t, test.backupBefore, profile.Backup.RunBefore)
+				assert.Equal(t, test.backupAfter, profile.Backup.RunAfter)
+			})
+		}
+	}
+}
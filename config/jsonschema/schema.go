@@ -277,6 +277,7 @@ func schemaForProfile(profile config.ProfileInfo) (object *schemaObject) {
 			profileType.Properties[sectionName] = schemaForPropertySet(info)
 		}
 	}
+	schemaWithOSSections(profileType, profile.Sections())
 
 	object = newSchemaObject()
 	object.Description = "restic profile declarations"
@@ -284,6 +285,25 @@ func schemaForProfile(profile config.ProfileInfo) (object *schemaObject) {
 	return
 }
 
+// schemaWithOSSections adds the sections only used on one operating system (e.g. "linux") to the profile and its sections.
+// Their content isn't validated, as it would multiply the size of the schema.
+func schemaWithOSSections(profileType *schemaObject, sections []string) {
+	osType := newSchemaObject()
+	osType.AdditionalProperties = true
+	osType.describe("", "settings only used on the operating system of the same name - see https://creativeprojects.github.io/resticprofile/configuration/operating_system/")
+	add := func(object *schemaObject) {
+		for _, osName := range config.OperatingSystems {
+			object.Properties[osName] = osType
+		}
+	}
+	add(profileType)
+	for _, sectionName := range sections {
+		if section, ok := profileType.Properties[sectionName].(*schemaObject); ok {
+			add(section)
+		}
+	}
+}
+
 const (
 	version1Pattern = `^(1|)$`
 	version2Pattern = `^([2-9]|[1-9][0-9]+)$`
@@ -383,6 +403,8 @@ func applyListAppendSchema(target SchemaType) {
 					strings.HasSuffix(name, "...") ||
 					strings.HasSuffix(name, "__APPEND") ||
 					strings.HasSuffix(name, "__PREPEND") ||
+					strings.HasSuffix(name, "__REPLACE") ||
+					strings.HasSuffix(name, "__REMOVE") ||
 					name == constants.SectionConfigurationMixinUse {
 					continue
 				}
@@ -401,6 +423,8 @@ func applyListAppendSchema(target SchemaType) {
 					object.Properties[name+"..."] = schemaType
 					object.Properties[name+"__PREPEND"] = schemaType
 					object.Properties["..."+name] = schemaType
+					object.Properties[name+"__REPLACE"] = schemaType
+					object.Properties[name+"__REMOVE"] = schemaType
 				}
 			}
 		}
@@ -410,6 +434,7 @@ func applyListAppendSchema(target SchemaType) {
 
 func schemaForConfigV1(profileInfo config.ProfileInfo) (object *schemaObject) {
 	object = schemaForProfile(profileInfo)
+	applyListAppendSchema(object)
 
 	// exclude non-profile properties from profile-schema
 	profilesPattern := fmt.Sprintf(`^(?!%s).*$`, strings.Join([]string{
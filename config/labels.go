@@ -0,0 +1,59 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// LabelSelector selects the profiles having all the labels of the selector.
+// An empty value matches a profile having the label, whatever its value.
+type LabelSelector map[string]string
+
+// ParseLabelSelector parses a list of labels separated by commas: "key=value" or "key" (any value)
+func ParseLabelSelector(selector string) (LabelSelector, error) {
+	labels := make(LabelSelector)
+	for _, item := range strings.Split(selector, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(item), "=")
+		key, value = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)
+		if key == "" {
+			return nil, fmt.Errorf("invalid label selector %q: expected key=value[,key=value...]", selector)
+		}
+		labels[key] = value
+	}
+	return labels, nil
+}
+
+// Matches returns true when the labels contain all the labels of the selector
+func (s LabelSelector) Matches(labels map[string]string) bool {
+	for key, expected := range s {
+		value, found := labels[key]
+		if !found || (expected != "" && value != expected) {
+			return false
+		}
+	}
+	return true
+}
+
+// SelectProfilesByLabels returns the names (sorted) of the profiles matching the label selector. Abstract profiles are never selected.
+func (c *Config) SelectProfilesByLabels(selector string) ([]string, error) {
+	labels, err := ParseLabelSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0)
+	for _, name := range c.GetProfileNames() {
+		if c.IsAbstractProfile(name) {
+			continue
+		}
+		profile, err := c.GetProfile(name)
+		if err != nil {
+			return nil, err
+		}
+		if labels.Matches(profile.Labels) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
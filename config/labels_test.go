@@ -0,0 +1,71 @@
+package config
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLabelSelector(t *testing.T) {
+	selector, err := ParseLabelSelector("env=prod, Site = paris,backup")
+	require.NoError(t, err)
+	assert.Equal(t, LabelSelector{"env": "prod", "site": "paris", "backup": ""}, selector)
+
+	for _, invalid := range []string{"", "=prod", "env=prod,,site=paris"} {
+		_, err = ParseLabelSelector(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestLabelSelectorMatches(t *testing.T) {
+	selector := LabelSelector{"env": "prod", "backup": ""}
+	assert.True(t, selector.Matches(map[string]string{"env": "prod", "backup": "daily"}))
+	assert.True(t, selector.Matches(map[string]string{"env": "prod", "backup": "", "site": "paris"}))
+	assert.False(t, selector.Matches(map[string]string{"env": "test", "backup": "daily"}))
+	assert.False(t, selector.Matches(map[string]string{"env": "prod"}))
+	assert.False(t, selector.Matches(nil))
+}
+
+func TestSelectProfilesByLabels(t *testing.T) {
+	content := `
+version: "2"
+profiles:
+  base:
+    abstract: true
+    labels:
+      env: prod
+  documents:
+    inherit: base
+    labels:
+      site: paris
+  photos:
+    inherit: base
+  test:
+    labels:
+      env: test
+`
+	c, err := Load(bytes.NewBufferString(content), FormatYAML)
+	require.NoError(t, err)
+
+	fixtures := []struct {
+		selector string
+		expected []string
+	}{
+		{selector: "env=prod", expected: []string{"documents", "photos"}},
+		{selector: "env", expected: []string{"documents", "photos", "test"}},
+		{selector: "env=prod,site=paris", expected: []string{"documents"}},
+		{selector: "env=staging", expected: []string{}},
+	}
+	for _, fixture := range fixtures {
+		t.Run(fixture.selector, func(t *testing.T) {
+			profiles, err := c.SelectProfilesByLabels(fixture.selector)
+			require.NoError(t, err)
+			assert.Equal(t, fixture.expected, profiles)
+		})
+	}
+
+	_, err = c.SelectProfilesByLabels("=invalid")
+	assert.Error(t, err)
+}
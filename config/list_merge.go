@@ -0,0 +1,296 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/cast"
+	"github.com/spf13/viper"
+	"golang.org/x/exp/slices"
+)
+
+// listOperator is a merge operation on a list property, declared with a suffix (or a prefix) on the property name
+type listOperator int
+
+const (
+	listNoOperator listOperator = iota
+	// operators are applied in this order when more than one is used on the same property
+	listReplace
+	listRemove
+	listPrepend
+	listAppend
+)
+
+// String returns the name of the list operator
+func (o listOperator) String() string {
+	switch o {
+	case listReplace:
+		return "replace"
+	case listRemove:
+		return "remove"
+	case listPrepend:
+		return "prepend"
+	case listAppend:
+		return "append"
+	}
+	return ""
+}
+
+var listOperatorKeyRegex = map[listOperator]*regexp.Regexp{
+	listReplace: regexp.MustCompile(`(?i)^(.+)__REPLACE$`),
+	listRemove:  regexp.MustCompile(`(?i)^(.+)__REMOVE$`),
+	listPrepend: regexp.MustCompile(`(?i)^(.+)__PREPEND$|^\.\.\.(.+)$`),
+	listAppend:  regexp.MustCompile(`(?i)^(.+)__APPEND$|^(.+)\.\.\.$`),
+}
+
+// parseListOperatorKey returns the name of the target property and the operator declared by key
+func parseListOperatorKey(key string) (targetKey string, operation listOperator) {
+	for _, op := range []listOperator{listReplace, listRemove, listAppend, listPrepend} {
+		if match := listOperatorKeyRegex[op].FindStringSubmatch(key); len(match) > 1 {
+			for _, targetKey = range match[1:] {
+				if len(targetKey) > 0 {
+					return targetKey, op
+				}
+			}
+		}
+	}
+	return "", listNoOperator
+}
+
+// listOperatorKey is a key with a list operator in a configuration map
+type listOperatorKey struct {
+	key, target string
+	operation   listOperator
+}
+
+// collectListOperatorKeys returns the keys with list operators of content, sorted in the order they must be applied
+func collectListOperatorKeys(content map[string]any) (keys []listOperatorKey) {
+	for key := range content {
+		if target, operation := parseListOperatorKey(key); operation != listNoOperator {
+			keys = append(keys, listOperatorKey{key: key, target: target, operation: operation})
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].operation != keys[j].operation {
+			return keys[i].operation < keys[j].operation
+		}
+		return keys[i].key < keys[j].key
+	})
+	return
+}
+
+// toList converts a slice of any type to []any, and a single value to a list with one item
+func toList(value any) (list []any) {
+	if list = cast.ToSlice(value); list == nil && value != nil {
+		if rv := reflect.ValueOf(value); rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+			list = make([]any, rv.Len())
+			for i := range list {
+				list[i] = rv.Index(i).Interface()
+			}
+		} else {
+			list = []any{value}
+		}
+	}
+	return
+}
+
+// applyListOperator returns the list resulting of applying operation with value to source
+func applyListOperator(operation listOperator, source, value any) []any {
+	sourceList, valueList := toList(source), toList(value)
+	switch operation {
+	case listReplace:
+		return valueList
+	case listRemove:
+		remove := make([]string, 0, len(valueList))
+		for _, item := range valueList {
+			remove = append(remove, fmt.Sprint(item))
+		}
+		result := make([]any, 0, len(sourceList))
+		for _, item := range sourceList {
+			if !slices.Contains(remove, fmt.Sprint(item)) {
+				result = append(result, item)
+			}
+		}
+		return result
+	case listPrepend:
+		return append(valueList, sourceList...)
+	case listAppend:
+		return append(sourceList, valueList...)
+	}
+	return sourceList
+}
+
+// resolveListOperators resolves keys with list operators ("key__APPEND", "key...", "key__REMOVE", etc.) in content using config as base
+func resolveListOperators(config *viper.Viper, content map[string]any) {
+	for name, value := range content {
+		if child, ok := value.(map[string]any); ok {
+			// list operators of sections for an operating system are resolved when the section is merged
+			if _, operation := parseListOperatorKey(name); operation == listNoOperator && !slices.Contains(OperatingSystems, name) {
+				var cc *viper.Viper
+				if config != nil {
+					cc = config.Sub(name)
+				}
+				resolveListOperators(cc, child)
+			}
+		}
+	}
+
+	for _, op := range collectListOperatorKeys(content) {
+		sourceValue, found := content[op.target]
+		if !found && config != nil {
+			sourceValue = config.Get(op.target)
+		}
+		content[op.target] = applyListOperator(op.operation, sourceValue, content[op.key])
+		delete(content, op.key)
+	}
+}
+
+// resolveIncludedListOperators resolves keys with list operators of an included file.
+// Operators are only resolved when the target property exists in config. Otherwise they are merged with the
+// same operator from config (if any) and kept for later (inheritance or decoding into the profile).
+func resolveIncludedListOperators(config *viper.Viper, content map[string]any) {
+	for name, value := range content {
+		if child, ok := value.(map[string]any); ok {
+			if _, operation := parseListOperatorKey(name); operation == listNoOperator && config != nil {
+				if cc := config.Sub(name); cc != nil {
+					resolveIncludedListOperators(cc, child)
+				}
+			}
+		}
+	}
+	if config == nil {
+		return
+	}
+
+	for _, op := range collectListOperatorKeys(content) {
+		if _, found := content[op.target]; found {
+			continue // resolved later against the value declared in the same file
+		}
+		if config.IsSet(op.target) {
+			content[op.target] = applyListOperator(op.operation, config.Get(op.target), content[op.key])
+			delete(content, op.key)
+		} else if config.IsSet(op.key) {
+			switch op.operation {
+			case listRemove, listAppend:
+				content[op.key] = applyListOperator(listAppend, config.Get(op.key), content[op.key])
+			case listPrepend:
+				content[op.key] = applyListOperator(listPrepend, config.Get(op.key), content[op.key])
+			}
+		}
+	}
+}
+
+// resolvedLists are the lists resolved by list operators for the slice fields of a decoded struct. The decoder would
+// merge a resolved list by index with the items already in the field: the fields are set once the decoding is done.
+type resolvedLists struct {
+	fields []reflect.Value
+	lists  []any
+}
+
+func (r *resolvedLists) add(field reflect.Value, list any) {
+	r.fields = append(r.fields, field)
+	r.lists = append(r.lists, list)
+}
+
+// set replaces the content of the fields with the resolved lists, decoded with decode
+func (r *resolvedLists) set(decode func(input, output any) error) error {
+	for i, field := range r.fields {
+		value := reflect.New(field.Type())
+		if err := decode(r.lists[i], value.Interface()); err != nil {
+			return err
+		}
+		field.Set(value.Elem())
+	}
+	return nil
+}
+
+// listOperatorsDecoder resolves the keys with list operators left when decoding a map into a struct:
+// the operators apply to the value already decoded in the struct (e.g. from a parent profile in configuration version 1).
+// The hook doesn't change the struct: the resolved lists of the slice fields are added to lists, to be set after decoding.
+func listOperatorsDecoder(lists *resolvedLists) mapstructure.DecodeHookFuncValue {
+	return func(from reflect.Value, to reflect.Value) (any, error) {
+		content, ok := from.Interface().(map[string]any)
+		if !ok || len(content) == 0 {
+			return from.Interface(), nil
+		}
+		for to.Kind() == reflect.Pointer || (to.Kind() == reflect.Interface && !to.IsNil()) {
+			if to.IsNil() {
+				to = reflect.Zero(to.Type().Elem())
+			} else {
+				to = to.Elem()
+			}
+		}
+		if to.Kind() != reflect.Struct {
+			return from.Interface(), nil
+		}
+		operators := collectListOperatorKeys(content)
+		if len(operators) == 0 {
+			return from.Interface(), nil
+		}
+
+		resolved := make(map[string]any, len(content))
+		for key, value := range content {
+			resolved[key] = value
+		}
+		targets := make(map[string]reflect.Value)
+		for _, op := range operators {
+			sourceValue, found := resolved[op.target]
+			field, remain := findFieldByKey(to, op.target)
+			if !found {
+				if field.IsValid() {
+					sourceValue = field.Interface()
+				} else if remain.IsValid() && remain.Len() > 0 {
+					if value := remain.MapIndex(reflect.ValueOf(op.target)); value.IsValid() {
+						sourceValue = value.Interface()
+					}
+				}
+			}
+			if field.IsValid() && field.CanSet() && field.Kind() == reflect.Slice {
+				targets[op.target] = field
+			}
+			resolved[op.target] = applyListOperator(op.operation, sourceValue, resolved[op.key])
+			delete(resolved, op.key)
+		}
+		if lists != nil {
+			for target, field := range targets {
+				lists.add(field, resolved[target])
+			}
+		}
+		return resolved, nil
+	}
+}
+
+// findFieldByKey returns the field of the struct value decoded from key (or the ",remain" map when no field matches)
+func findFieldByKey(value reflect.Value, key string) (field, remain reflect.Value) {
+	for i := 0; i < value.NumField(); i++ {
+		structField := value.Type().Field(i)
+		if !structField.IsExported() {
+			continue
+		}
+		name, options, _ := strings.Cut(structField.Tag.Get("mapstructure"), ",")
+		switch {
+		case options == "squash" || (structField.Anonymous && name == ""):
+			if embedded := value.Field(i); embedded.Kind() == reflect.Struct {
+				if f, r := findFieldByKey(embedded, key); f.IsValid() {
+					return f, r
+				} else if r.IsValid() {
+					remain = r
+				}
+			}
+		case options == "remain":
+			remain = value.Field(i)
+		default:
+			if name == "" {
+				name = structField.Name
+			}
+			if strings.EqualFold(name, key) {
+				return value.Field(i), remain
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,268 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseListOperatorKey(t *testing.T) {
+	tests := []struct {
+		key, target string
+		operation   listOperator
+	}{
+		{key: "exclude"},
+		{key: "..."},
+		{key: "__APPEND"},
+		{key: "exclude...", target: "exclude", operation: listAppend},
+		{key: "exclude__APPEND", target: "exclude", operation: listAppend},
+		{key: "...exclude", target: "exclude", operation: listPrepend},
+		{key: "exclude__prepend", target: "exclude", operation: listPrepend},
+		{key: "exclude__REPLACE", target: "exclude", operation: listReplace},
+		{key: "exclude__Remove", target: "exclude", operation: listRemove},
+	}
+	for _, test := range tests {
+		t.Run(test.key, func(t *testing.T) {
+			target, operation := parseListOperatorKey(test.key)
+			assert.Equal(t, test.target, target)
+			assert.Equal(t, test.operation, operation)
+		})
+	}
+}
+
+func TestApplyListOperator(t *testing.T) {
+	source := []string{"a", "b", "c"}
+	tests := []struct {
+		operation listOperator
+		source    any
+		value     any
+		expected  []any
+	}{
+		{operation: listAppend, source: source, value: "d", expected: []any{"a", "b", "c", "d"}},
+		{operation: listAppend, source: nil, value: []any{"d", "e"}, expected: []any{"d", "e"}},
+		{operation: listPrepend, source: source, value: "d", expected: []any{"d", "a", "b", "c"}},
+		{operation: listPrepend, source: "a", value: []any{"d", "e"}, expected: []any{"d", "e", "a"}},
+		{operation: listReplace, source: source, value: "d", expected: []any{"d"}},
+		{operation: listRemove, source: source, value: "b", expected: []any{"a", "c"}},
+		{operation: listRemove, source: source, value: []any{"a", "c", "x"}, expected: []any{"b"}},
+		{operation: listRemove, source: []any{1, 2, 3}, value: "2", expected: []any{1, 3}},
+		{operation: listRemove, source: nil, value: "b", expected: []any{}},
+	}
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("#%d", i), func(t *testing.T) {
+			assert.Equal(t, test.expected, applyListOperator(test.operation, test.source, test.value))
+		})
+	}
+}
+
+func TestListOperatorsWithInheritance(t *testing.T) {
+	expected := []string{"z", "a", "c", "d"}
+	tests := []struct {
+		format  string
+		content string
+	}{
+		{format: "toml", content: `version = "1"
+[parent.backup]
+exclude = ["a", "b", "c"]
+[child]
+inherit = "parent"
+[child.backup]
+exclude__REMOVE = "b"
+"exclude..." = "d"
+"...exclude" = "z"
+`},
+		{format: "yaml", content: `version: "1"
+parent:
+  backup:
+    exclude: [a, b, c]
+child:
+  inherit: parent
+  backup:
+    exclude__REMOVE: b
+    exclude...: d
+    ...exclude: z
+`},
+		{format: "json", content: `{"version": "1",
+"parent": {"backup": {"exclude": ["a", "b", "c"]}},
+"child": {"inherit": "parent", "backup": {"exclude__REMOVE": "b", "exclude...": "d", "...exclude": "z"}}}
+`},
+		{format: "hcl", content: `
+"parent" = {
+  "backup" = {
+    exclude = ["a", "b", "c"]
+  }
+}
+"child" = {
+  inherit = "parent"
+  "backup" = {
+    exclude__REMOVE = "b"
+    exclude__APPEND = "d"
+    exclude__PREPEND = "z"
+  }
+}
+`},
+		{format: "toml", content: `version = "2"
+[profiles.parent.backup]
+exclude = ["a", "b", "c"]
+[profiles.child]
+inherit = "parent"
+[profiles.child.backup]
+exclude__REMOVE = "b"
+"exclude..." = "d"
+"...exclude" = "z"
+`},
+		{format: "yaml", content: `version: "2"
+profiles:
+  parent:
+    backup:
+      exclude: [a, b, c]
+  child:
+    inherit: parent
+    backup:
+      exclude__REMOVE: b
+      exclude...: d
+      ...exclude: z
+`},
+		{format: "json", content: `{"version": "2", "profiles": {
+"parent": {"backup": {"exclude": ["a", "b", "c"]}},
+"child": {"inherit": "parent", "backup": {"exclude__REMOVE": "b", "exclude...": "d", "...exclude": "z"}}}}
+`},
+	}
+	for _, test := range tests {
+		t.Run(test.format, func(t *testing.T) {
+			profile, err := getResolvedProfile(test.format, test.content, "child")
+			require.NoError(t, err)
+			require.NotNil(t, profile.Backup)
+			assert.Equal(t, expected, profile.Backup.Exclude)
+			assert.Empty(t, profile.Backup.OtherFlags)
+
+			parent, err := getResolvedProfile(test.format, test.content, "parent")
+			require.NoError(t, err)
+			assert.Equal(t, []string{"a", "b", "c"}, parent.Backup.Exclude)
+		})
+	}
+}
+
+func TestListOperatorReplaceInVersion1(t *testing.T) {
+	content := `
+[parent.backup]
+exclude = ["a", "b", "c"]
+[merged]
+inherit = "parent"
+[merged.backup]
+exclude = ["d"]
+[replaced]
+inherit = "parent"
+[replaced.backup]
+exclude__REPLACE = ["d"]
+`
+	profile, err := getResolvedProfile("toml", content, "merged")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"d", "b", "c"}, profile.Backup.Exclude)
+
+	profile, err = getResolvedProfile("toml", content, "replaced")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"d"}, profile.Backup.Exclude)
+}
+
+func TestListOperatorsDecoderDoesNotChangeStruct(t *testing.T) {
+	section := &BackupSection{Exclude: []string{"a", "b", "c"}}
+	lists := new(resolvedLists)
+	hook := listOperatorsDecoder(lists)
+
+	content := map[string]any{"exclude__REMOVE": []any{"b"}, "stdin": true}
+	resolved, err := hook(reflect.ValueOf(content), reflect.ValueOf(section))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"exclude": []any{"a", "c"}, "stdin": true}, resolved)
+	assert.Equal(t, []string{"a", "b", "c"}, section.Exclude)
+	assert.Len(t, content, 2)
+
+	require.NoError(t, lists.set(func(input, output any) error { return mapstructure.Decode(input, output) }))
+	assert.Equal(t, []string{"a", "c"}, section.Exclude)
+}
+
+func TestListOperatorsWithoutBase(t *testing.T) {
+	content := `version: "2"
+profiles:
+  profile:
+    ...tag: first
+    backup:
+      exclude...: [a, b]
+      exclude__REMOVE: c
+`
+	profile, err := getResolvedProfile("yaml", content, "profile")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, profile.Backup.Exclude)
+	assert.Equal(t, []any{"first"}, profile.OtherFlags["tag"])
+}
+
+func TestListOperatorsWithMixins(t *testing.T) {
+	content := `version: "2"
+mixins:
+  no-cache:
+    exclude__REMOVE: cache
+    exclude__APPEND: "*.tmp"
+profiles:
+  profile:
+    backup:
+      exclude: [cache, logs]
+      use: no-cache
+`
+	profile, err := getResolvedProfile("yaml", content, "profile")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"logs", "*.tmp"}, profile.Backup.Exclude)
+}
+
+func TestListOperatorsWithIncludes(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		filename := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(filename, []byte(content), 0o600))
+		return filename
+	}
+
+	configFile := write("profiles.yaml", `version: "2"
+includes: ["*.inc.yaml", "*.inc.toml"]
+profiles:
+  parent:
+    backup:
+      exclude: [a, b]
+  child:
+    inherit: parent
+    backup:
+      exclude...: c
+  profile:
+    backup:
+      exclude: [a, b]
+`)
+	write("1.inc.yaml", `version: "2"
+profiles:
+  child:
+    backup:
+      exclude...: d
+  profile:
+    backup:
+      exclude__REMOVE: a
+`)
+	write("2.inc.toml", `version = "2"
+[profiles.profile.backup]
+"exclude..." = "c"
+`)
+
+	config, err := LoadFile(configFile, "")
+	require.NoError(t, err)
+
+	profile, err := config.GetProfile("profile")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b", "c"}, profile.Backup.Exclude)
+
+	child, err := config.GetProfile("child")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c", "d"}, child.Backup.Exclude)
+}
@@ -0,0 +1,21 @@
+//go:build linux
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnescapeMountPoint(t *testing.T) {
+	assert.Equal(t, "/mnt/backup", unescapeMountPoint("/mnt/backup"))
+	assert.Equal(t, "/mnt/my backup", unescapeMountPoint(`/mnt/my\040backup`))
+	assert.Equal(t, `/mnt/end\04`, unescapeMountPoint(`/mnt/end\04`))
+}
+
+func TestMountPoints(t *testing.T) {
+	mounts, err := mountPoints()
+	assert.NoError(t, err)
+	assert.Contains(t, mounts, "/")
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package config
+
+import "errors"
+
+// mountPoints is only available on Linux
+func mountPoints() ([]string, error) {
+	return nil, errors.New("mount points are not available on this platform")
+}
@@ -0,0 +1,241 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/creativeprojects/resticprofile/constants"
+	"github.com/spf13/viper"
+	"golang.org/x/exp/maps"
+)
+
+// OriginDefault is the origin of a value that is not set in the configuration but has a default value
+const OriginDefault = "default"
+
+// ValueOrigins tells where the values of a section come from, by property path in lowercase (e.g. "backup.exclude")
+type ValueOrigins map[string]string
+
+// Get returns the origin of the value at path, or of its closest parent when the value has no origin on its own
+func (o ValueOrigins) Get(path string) string {
+	path = strings.ToLower(path)
+	for path != "" {
+		if origin, found := o[path]; found {
+			return origin
+		}
+		index := strings.LastIndex(path, ".")
+		if index < 0 {
+			break
+		}
+		path = path[:index]
+	}
+	return ""
+}
+
+// configFileSettings is the configuration of a single file, before merging it with the others
+type configFileSettings struct {
+	name  string
+	viper *viper.Viper
+}
+
+// GetGlobalOrigins returns where the values of the global section come from: a configuration file, a mixin or a default value
+func (c *Config) GetGlobalOrigins() (ValueOrigins, error) {
+	files, err := c.loadFileSettings("")
+	if err != nil {
+		return nil, err
+	}
+	if c.defaults != nil {
+		// machine-wide defaults are layered under the configuration files
+		files = append([]configFileSettings{{name: c.defaultsFile, viper: c.defaults}}, files...)
+	}
+	origins := make(ValueOrigins)
+	c.sectionOrigins(files, constants.SectionConfigurationGlobal, origins)
+	addDefaultOrigins(origins, "", NewGlobalInfo())
+	return origins, nil
+}
+
+// GetProfileOrigins returns where the values of the profile come from: a configuration file, a parent profile, a mixin or a default value
+func (c *Config) GetProfileOrigins(profileName string) (ValueOrigins, error) {
+	files, err := c.loadFileSettings(profileName)
+	if err != nil {
+		return nil, err
+	}
+	origins, err := c.profileOrigins(files, profileName, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	info := NewProfileInfo(false)
+	addDefaultOrigins(origins, "", info)
+	for _, name := range info.Sections() {
+		addDefaultOrigins(origins, name+".", info.SectionInfo(name))
+	}
+	return origins, nil
+}
+
+func (c *Config) profileOrigins(files []configFileSettings, profileName string, visited []string) (ValueOrigins, error) {
+	for _, name := range visited {
+		if name == profileName {
+			return nil, fmt.Errorf("circular inheritance of profile '%s'", profileName)
+		}
+	}
+	visited = append(visited, profileName)
+
+	profilePath := c.getProfilePath(profileName)
+	inherit := ""
+	for _, file := range files {
+		if value := file.viper.GetString(c.flatKey(profilePath, constants.SectionConfigurationInherit)); value != "" {
+			inherit = value
+		}
+	}
+
+	origins := make(ValueOrigins)
+	if inherit != "" {
+		parent, err := c.profileOrigins(files, inherit, visited)
+		if err != nil {
+			return nil, err
+		}
+		for path, origin := range parent {
+			if path == constants.SectionConfigurationDescription || path == constants.SectionConfigurationInherit {
+				continue
+			}
+			if !strings.HasPrefix(origin, "profile '") {
+				origin = fmt.Sprintf("profile '%s' (%s)", inherit, origin)
+			}
+			origins[path] = origin
+		}
+	}
+
+	c.sectionOrigins(files, profilePath, origins)
+	return origins, nil
+}
+
+// sectionOrigins adds to origins the values set in the section by the configuration files and by mixins
+func (c *Config) sectionOrigins(files []configFileSettings, sectionPath string, origins ValueOrigins) {
+	set := func(path []string, origin string) {
+		last := len(path) - 1
+		target, operation := parseListOperatorKey(path[last])
+		if operation == listNoOperator {
+			origins[strings.Join(path, ".")] = origin
+			return
+		}
+		key := strings.Join(append(path[:last:last], target), ".")
+		origin = fmt.Sprintf("%s in %s", operation, origin)
+		if previous, found := origins[key]; found {
+			origin = previous + ", " + origin
+		}
+		origins[key] = origin
+	}
+	prefix := sectionPath + c.keyDelim
+
+	// values declared in the files (what comes later overrides what was declared before)
+	for _, file := range files {
+		keys := file.viper.AllKeys()
+		sort.Strings(keys)
+		for _, key := range keys {
+			if !strings.HasPrefix(key, prefix) {
+				continue
+			}
+			path := strings.Split(strings.TrimPrefix(key, prefix), c.keyDelim)
+			if path[len(path)-1] == constants.SectionConfigurationMixinUse {
+				continue
+			}
+			set(path, file.name)
+		}
+	}
+
+	// mixins override the values declared in the files
+	if c.GetVersion() < Version02 {
+		return
+	}
+	for _, file := range files {
+		allUses, err := collectAllMixinUses(file.viper, c.keyDelim)
+		if err != nil {
+			continue
+		}
+		for useKey, uses := range allUses {
+			if useKey != sectionPath && !strings.HasPrefix(useKey, prefix) {
+				continue
+			}
+			var location []string
+			if useKey != sectionPath {
+				location = strings.Split(strings.TrimPrefix(useKey, prefix), c.keyDelim)
+			}
+			for _, use := range uses {
+				if mi, found := c.mixins[use.Name]; found {
+					for _, path := range flattenKeys(mi.Source, nil) {
+						set(append(location[:len(location):len(location)], path...), fmt.Sprintf("mixin '%s'", use.Name))
+					}
+				}
+			}
+		}
+	}
+}
+
+// loadFileSettings loads each configuration file (main file then includes) separately, as they would be for the profile
+func (c *Config) loadFileSettings(profileName string) (files []configFileSettings, err error) {
+	if c.sourceTemplates == nil {
+		return nil, errors.New("no available template to execute, please load it first")
+	}
+	data := newTemplateData(c.configFile, profileName, "")
+	buffer := &bytes.Buffer{}
+
+	names := append([]string{c.configFile}, c.includeFiles...)
+	for i, name := range names {
+		format := c.format
+		if i > 0 {
+			format = formatFromExtension(name)
+		}
+		if format == "conf" {
+			format = "toml"
+		}
+
+		buffer.Reset()
+		if err = c.sourceTemplates.ExecuteTemplate(buffer, c.templateName(name), data); err != nil {
+			return nil, fmt.Errorf("cannot execute %w", err)
+		}
+		vp := viper.NewWithOptions(viper.KeyDelimiter(c.keyDelim))
+		vp.SetConfigType(format)
+		if err = vp.ReadConfig(buffer); err != nil {
+			return nil, fmt.Errorf("cannot parse %s configuration: %w", format, err)
+		}
+		if name == "" {
+			name = "configuration"
+		}
+		files = append(files, configFileSettings{name: name, viper: vp})
+	}
+	return
+}
+
+// flattenKeys returns the paths of all the values in a tree of maps
+func flattenKeys(tree map[string]any, prefix []string) (paths [][]string) {
+	keys := maps.Keys(tree)
+	sort.Strings(keys)
+	for _, key := range keys {
+		path := append(prefix[:len(prefix):len(prefix)], strings.ToLower(key))
+		if child, ok := tree[key].(map[string]any); ok && len(child) > 0 {
+			paths = append(paths, flattenKeys(child, path)...)
+		} else {
+			paths = append(paths, path)
+		}
+	}
+	return
+}
+
+// addDefaultOrigins marks the properties with a default value that are not set in the configuration
+func addDefaultOrigins(origins ValueOrigins, prefix string, set PropertySet) {
+	if set == nil {
+		return
+	}
+	for _, name := range set.Properties() {
+		info := set.PropertyInfo(name)
+		if info == nil || info.IsOption() || len(info.DefaultValue()) == 0 {
+			continue
+		}
+		if _, found := origins[prefix+name]; !found {
+			origins[prefix+name] = OriginDefault
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValueOriginsGet(t *testing.T) {
+	origins := ValueOrigins{
+		"repository":     "profiles.yaml",
+		"backup.source":  "include.yaml",
+		"stream-error":   "mixin 'errors'",
+		"backup.exclude": OriginDefault,
+	}
+	assert.Equal(t, "profiles.yaml", origins.Get("repository"))
+	assert.Equal(t, "include.yaml", origins.Get("Backup.Source"))
+	assert.Equal(t, "mixin 'errors'", origins.Get("stream-error.pattern"))
+	assert.Equal(t, "", origins.Get("backup"))
+	assert.Equal(t, "", origins.Get("backup.tag"))
+}
+
+func TestGetProfileOrigins(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		filename := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(filename, []byte(content), 0o600))
+		return filename
+	}
+	configFile := write("profiles.yaml", `version: "2"
+includes: ["include.yaml"]
+mixins:
+  tmp:
+    exclude...: "*.tmp"
+    tag: mixin
+profiles:
+  parent:
+    repository: local:/backup
+    backup:
+      exclude: [a, b]
+      source: /home
+  child:
+    inherit: parent
+    description: child profile
+    backup:
+      use: tmp
+      exclude__REMOVE: a
+`)
+	write("include.yaml", `version: "2"
+profiles:
+  child:
+    password-file: key
+`)
+
+	config, err := LoadFile(configFile, "")
+	require.NoError(t, err)
+	origins, err := config.GetProfileOrigins("child")
+	require.NoError(t, err)
+
+	includeFile := filepath.Join(dir, "include.yaml")
+	assert.Equal(t, configFile, origins.Get("description"))
+	assert.Equal(t, "profile 'parent' ("+configFile+")", origins.Get("repository"))
+	assert.Equal(t, includeFile, origins.Get("password-file"))
+	assert.Equal(t, "profile 'parent' ("+configFile+")", origins.Get("backup.source"))
+	assert.Equal(t, "profile 'parent' ("+configFile+"), remove in "+configFile+", append in mixin 'tmp'", origins.Get("backup.exclude"))
+	assert.Equal(t, "mixin 'tmp'", origins.Get("backup.tag"))
+	assert.Equal(t, OriginDefault, origins.Get("backup.schedule-priority"))
+
+	parent, err := config.GetProfileOrigins("parent")
+	require.NoError(t, err)
+	assert.Equal(t, configFile, parent.Get("backup.exclude"))
+	assert.Equal(t, "", parent.Get("description"))
+	assert.Equal(t, "", parent.Get("password-file"))
+}
+
+func TestGetProfileOriginsVersion1(t *testing.T) {
+	config, err := Load(bytes.NewBufferString(`
+[global]
+priority = "low"
+[parent]
+repository = "local:/backup"
+[child]
+inherit = "parent"
+password-file = "key"
+`), "toml")
+	require.NoError(t, err)
+
+	origins, err := config.GetProfileOrigins("child")
+	require.NoError(t, err)
+	assert.Equal(t, "profile 'parent' (configuration)", origins.Get("repository"))
+	assert.Equal(t, "configuration", origins.Get("password-file"))
+
+	global, err := config.GetGlobalOrigins()
+	require.NoError(t, err)
+	assert.Equal(t, "configuration", global.Get("priority"))
+	assert.Equal(t, OriginDefault, global.Get("min-memory"))
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+// OperatingSystems are the names of the sections that only apply to one operating system (values of runtime.GOOS).
+// They can be declared in a profile ("profile.windows") or in a section of a profile ("profile.backup.linux").
+var OperatingSystems = []string{"aix", "android", "darwin", "dragonfly", "freebsd", "illumos", "ios", "linux", "netbsd", "openbsd", "plan9", "solaris", "windows"}
+
+// applyOSSections returns a copy of the profile content where the sections of the operating system goos are merged
+// into their parent, and the sections of the other operating systems are removed.
+// The content is returned unchanged (and found is false) when it has no section for any operating system.
+func applyOSSections(content map[string]any, goos string) (result map[string]any, found bool) {
+	return applyOSSectionsAtDepth(content, goos, 1)
+}
+
+func applyOSSectionsAtDepth(content map[string]any, goos string, depth int) (result map[string]any, found bool) {
+	result = content
+	copyOnWrite := func() {
+		if !found {
+			result, found = maps.Clone(content), true
+		}
+	}
+
+	// sections of the operating systems declared in sections of the profile
+	if depth > 0 {
+		for key, value := range content {
+			if section, ok := value.(map[string]any); ok && !slices.Contains(OperatingSystems, key) {
+				if section, changed := applyOSSectionsAtDepth(section, goos, depth-1); changed {
+					copyOnWrite()
+					result[key] = section
+				}
+			}
+		}
+	}
+
+	for _, name := range OperatingSystems {
+		section, ok := content[name].(map[string]any)
+		if !ok {
+			continue
+		}
+		copyOnWrite()
+		delete(result, name)
+		if name == goos {
+			if depth > 0 {
+				// sections of the profile declared in the section of the operating system
+				section, _ = applyOSSectionsAtDepth(section, goos, depth-1)
+			}
+			result = mergeOSSection(result, section)
+		}
+	}
+	return
+}
+
+// mergeOSSection returns a copy of base with the content of the section merged into it. List operators of the
+// section (like "source..." to append to the source) are applied on the lists of base.
+func mergeOSSection(base, section map[string]any) map[string]any {
+	result := maps.Clone(base)
+	section = maps.Clone(section)
+	for _, op := range collectListOperatorKeys(section) {
+		sourceValue, found := section[op.target]
+		if !found {
+			sourceValue = result[op.target]
+		}
+		section[op.target] = applyListOperator(op.operation, sourceValue, section[op.key])
+		delete(section, op.key)
+	}
+	for key, value := range section {
+		if child, ok := value.(map[string]any); ok {
+			if baseChild, ok := result[key].(map[string]any); ok {
+				result[key] = mergeOSSection(baseChild, child)
+				continue
+			}
+		}
+		result[key] = value
+	}
+	return result
+}
@@ -0,0 +1,114 @@
+package config
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyOSSections(t *testing.T) {
+	content := map[string]any{
+		"repository": "local:/backup",
+		"backup": map[string]any{
+			"source":  []any{"/home"},
+			"exclude": []any{"*.tmp"},
+			"linux": map[string]any{
+				"source":     []any{"/home", "/etc"},
+				"exclude...": []any{"/proc"},
+			},
+			"windows": map[string]any{
+				"source": []any{`C:\Users`},
+			},
+		},
+		"windows": map[string]any{
+			"repository": `local:D:\backup`,
+			"backup": map[string]any{
+				"use-fs-snapshot": true,
+			},
+		},
+	}
+
+	linux, found := applyOSSections(content, "linux")
+	assert.True(t, found)
+	assert.Equal(t, map[string]any{
+		"repository": "local:/backup",
+		"backup": map[string]any{
+			"source":  []any{"/home", "/etc"},
+			"exclude": []any{"*.tmp", "/proc"},
+		},
+	}, linux)
+
+	windows, found := applyOSSections(content, "windows")
+	assert.True(t, found)
+	assert.Equal(t, map[string]any{
+		"repository": `local:D:\backup`,
+		"backup": map[string]any{
+			"source":          []any{`C:\Users`},
+			"exclude":         []any{"*.tmp"},
+			"use-fs-snapshot": true,
+		},
+	}, windows)
+
+	darwin, found := applyOSSections(content, "darwin")
+	assert.True(t, found)
+	assert.Equal(t, map[string]any{
+		"repository": "local:/backup",
+		"backup": map[string]any{
+			"source":  []any{"/home"},
+			"exclude": []any{"*.tmp"},
+		},
+	}, darwin)
+
+	// the original content is not modified
+	assert.Contains(t, content, "windows")
+	assert.Contains(t, content["backup"], "linux")
+}
+
+func TestApplyOSSectionsWithoutOSSection(t *testing.T) {
+	content := map[string]any{
+		"backup": map[string]any{"source": "/home"},
+		"linux":  "not a section",
+	}
+	result, found := applyOSSections(content, "linux")
+	assert.False(t, found)
+	assert.Equal(t, content, result)
+}
+
+func TestProfileWithOSSections(t *testing.T) {
+	otherOS := "windows"
+	if runtime.GOOS == otherOS {
+		otherOS = "linux"
+	}
+	runForVersions(t, func(t *testing.T, version, prefix string) {
+		testConfig := version + `
+[` + prefix + `parent]
+repository = "parent"
+[` + prefix + `parent.backup]
+source = ["/parent"]
+[` + prefix + `parent.backup.` + runtime.GOOS + `]
+exclude = ["parent-` + runtime.GOOS + `"]
+
+[` + prefix + `profile]
+inherit = "parent"
+[` + prefix + `profile.` + runtime.GOOS + `]
+repository = "` + runtime.GOOS + `"
+[` + prefix + `profile.` + otherOS + `]
+repository = "` + otherOS + `"
+[` + prefix + `profile.backup.` + runtime.GOOS + `]
+source = ["/` + runtime.GOOS + `"]
+[` + prefix + `profile.backup.` + otherOS + `]
+source = ["/` + otherOS + `"]
+`
+		profile, err := getProfile("toml", testConfig, "profile", "")
+		require.NoError(t, err)
+		assert.Equal(t, runtime.GOOS, profile.Repository.String())
+		require.NotNil(t, profile.Backup)
+		assert.Equal(t, []string{"/" + runtime.GOOS}, profile.Backup.Source)
+		assert.Equal(t, []string{"parent-" + runtime.GOOS}, profile.Backup.Exclude)
+		assert.NotContains(t, profile.Backup.OtherFlags, runtime.GOOS)
+		assert.NotContains(t, profile.Backup.OtherFlags, otherOS)
+		assert.NotContains(t, profile.OtherFlags, otherOS)
+	})
+}
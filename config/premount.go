@@ -0,0 +1,48 @@
+package config
+
+import (
+	"time"
+
+	"github.com/creativeprojects/resticprofile/volume"
+)
+
+// PremountSection is an encrypted or network volume mounted before running the profile, and unmounted at the end of the run
+type PremountSection struct {
+	Type            string            `mapstructure:"type" enum:"cifs;nfs;sshfs;cryptsetup" description:"Type of volume to mount"`
+	Source          string            `mapstructure:"source" examples:"//server/share;server:/export;user@host:/path;/dev/sdb1" description:"Network share, remote path or encrypted device to mount"`
+	Target          string            `mapstructure:"target" description:"Directory where the volume is mounted (optional with cryptsetup, to only open the encrypted device)"`
+	Options         []string          `mapstructure:"options" examples:"ro;vers=4;noatime;reconnect" description:"Mount options (passed with -o)"`
+	Username        string            `mapstructure:"username" description:"User name of the cifs share"`
+	Password        ConfidentialValue `mapstructure:"password" description:"Password of the cifs share or sshfs user, or passphrase of the cryptsetup device (never passed on the command line)"`
+	CredentialsFile string            `mapstructure:"credentials-file" description:"Credentials file of the cifs share (instead of username and password)"`
+	IdentityFile    string            `mapstructure:"identity-file" description:"SSH private key of the sshfs volume"`
+	KeyFile         string            `mapstructure:"key-file" description:"Key file of the cryptsetup device"`
+	Name            string            `mapstructure:"name" description:"Name of the device opened by cryptsetup in /dev/mapper (default is \"resticprofile-\" followed by the name of the source device)"`
+	Retries         int               `mapstructure:"retries" range:"[0:]" description:"Number of times to retry mounting the volume after a failure"`
+	RetryDelay      time.Duration     `mapstructure:"retry-delay" default:"5s" description:"Time to wait before retrying to mount the volume"`
+}
+
+func (p *PremountSection) setRootPath(rootPath string) {
+	p.Target = fixPath(p.Target, expandEnv, expandUserHome, absolutePrefix(rootPath))
+	p.CredentialsFile = fixPath(p.CredentialsFile, expandEnv, expandUserHome, absolutePrefix(rootPath))
+	p.IdentityFile = fixPath(p.IdentityFile, expandEnv, expandUserHome, absolutePrefix(rootPath))
+	p.KeyFile = fixPath(p.KeyFile, expandEnv, expandUserHome, absolutePrefix(rootPath))
+}
+
+// GetVolume returns the volume to mount
+func (p *PremountSection) GetVolume() *volume.Volume {
+	return &volume.Volume{
+		Type:            p.Type,
+		Source:          p.Source,
+		Target:          p.Target,
+		Options:         p.Options,
+		Username:        p.Username,
+		Password:        p.Password.Value(),
+		CredentialsFile: p.CredentialsFile,
+		IdentityFile:    p.IdentityFile,
+		KeyFile:         p.KeyFile,
+		Name:            p.Name,
+		Retries:         p.Retries,
+		RetryDelay:      p.RetryDelay,
+	}
+}
@@ -0,0 +1,54 @@
+package config
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadPremount(t *testing.T) {
+	content := `
+[profile]
+repository = "test"
+
+[[profile.premount]]
+type = "cifs"
+source = "//nas/backup"
+target = "mnt/nas"
+username = "backup"
+password = "secret"
+options = ["vers=3.0"]
+retries = 3
+
+[[profile.premount]]
+type = "cryptsetup"
+source = "/dev/sdb1"
+key-file = "luks.key"
+`
+	c, err := Load(bytes.NewBufferString(content), FormatTOML)
+	require.NoError(t, err)
+	profile, err := c.GetProfile("profile")
+	require.NoError(t, err)
+	profile.SetRootPath("/root/path")
+
+	require.Len(t, profile.Premount, 2)
+	cifs := profile.Premount[0].GetVolume()
+	assert.Equal(t, "cifs", cifs.Type)
+	assert.Equal(t, "//nas/backup", cifs.Source)
+	assert.Equal(t, filepath.FromSlash("/root/path/mnt/nas"), cifs.Target)
+	assert.Equal(t, "secret", cifs.Password)
+	assert.Equal(t, []string{"vers=3.0"}, cifs.Options)
+	assert.Equal(t, 3, cifs.Retries)
+
+	crypt := profile.Premount[1].GetVolume()
+	assert.Empty(t, crypt.Target)
+	assert.Equal(t, filepath.FromSlash("/root/path/luks.key"), crypt.KeyFile)
+	assert.NoError(t, crypt.Validate())
+
+	ProcessConfidentialValues(profile)
+	assert.Equal(t, ConfidentialReplacement, profile.Premount[0].Password.String())
+	assert.Equal(t, "", profile.Premount[1].Password.String())
+}
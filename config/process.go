@@ -0,0 +1,71 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/creativeprojects/resticprofile/util"
+)
+
+// ProcessSettings provides access to the settings of the restic process started for the commands of a section
+type ProcessSettings interface {
+	GetProcessSettings() *ProcessSection
+}
+
+// ProcessSection contains the settings applied to the restic process (only on Linux, except for the memory limit)
+type ProcessSection struct {
+	OOMScoreAdjust  *int   `mapstructure:"oom-score-adjust" range:"[-1000:1000]" description:"Adjust the score used by the Linux kernel to pick the process to kill when running out of memory: a positive value makes restic more likely to be killed than the other services (negative values need privileges)"`
+	SchedulingClass string `mapstructure:"scheduling-class" enum:"normal;batch;idle" description:"Linux CPU scheduling class of the restic process: \"batch\" for non-interactive work, \"idle\" to run only when the CPU has nothing else to do"`
+	MaxMemory       string `mapstructure:"max-memory" examples:"512M;2G" description:"Interrupt restic when the memory it uses (resident set size) goes over this size - see https://creativeprojects.github.io/resticprofile/configuration/process/"`
+}
+
+func (s *ProcessSection) GetProcessSettings() *ProcessSection { return s }
+
+// GetMaxMemory returns the memory limit of the process in bytes (0 when there's no limit)
+func (s *ProcessSection) GetMaxMemory() (uint64, error) {
+	if s.MaxMemory == "" {
+		return 0, nil
+	}
+	return util.ParseBytes(s.MaxMemory)
+}
+
+// GetCommandProcessSettings returns the settings of the restic process for a command: the settings
+// of the section of the command override the ones of the profile
+func (p *Profile) GetCommandProcessSettings(command string) (settings ProcessSection) {
+	settings = p.ProcessSection
+	if section, ok := GetSectionWith[ProcessSettings](p, command); ok {
+		if s := section.GetProcessSettings(); s != nil {
+			if s.OOMScoreAdjust != nil {
+				settings.OOMScoreAdjust = s.OOMScoreAdjust
+			}
+			if s.SchedulingClass != "" {
+				settings.SchedulingClass = s.SchedulingClass
+			}
+			if s.MaxMemory != "" {
+				settings.MaxMemory = s.MaxMemory
+			}
+		}
+	}
+	return
+}
+
+// GetProcessIssues returns the invalid settings of the restic process found in the profile and its sections
+func (p *Profile) GetProcessIssues() (issues []string) {
+	if _, err := p.ProcessSection.GetMaxMemory(); err != nil {
+		issues = append(issues, fmt.Sprintf("max-memory: %s", err))
+	}
+	sections := GetDeclaredSectionsWith[ProcessSettings](p)
+	names := make([]string, 0, len(sections))
+	for name, section := range sections {
+		if !isEmpty(section) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, err := sections[name].GetProcessSettings().GetMaxMemory(); err != nil {
+			issues = append(issues, fmt.Sprintf("max-memory of section %s: %s", name, err))
+		}
+	}
+	return
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetCommandProcessSettings(t *testing.T) {
+	content := `
+[profile]
+oom-score-adjust = 500
+scheduling-class = "batch"
+[profile.backup]
+source = ["/home"]
+[profile.prune]
+oom-score-adjust = 1000
+scheduling-class = "idle"
+max-memory = "2G"
+[profile.check]
+scheduling-class = "normal"
+[profile.snapshots]
+oom-score-adjust = 0
+`
+	c, err := Load(bytes.NewBufferString(content), FormatTOML)
+	require.NoError(t, err)
+	profile, err := c.GetProfile("profile")
+	require.NoError(t, err)
+
+	score := func(settings ProcessSection) int {
+		require.NotNil(t, settings.OOMScoreAdjust)
+		return *settings.OOMScoreAdjust
+	}
+
+	settings := profile.GetCommandProcessSettings("backup")
+	assert.Equal(t, 500, score(settings))
+	assert.Equal(t, "batch", settings.SchedulingClass)
+
+	settings = profile.GetCommandProcessSettings("prune")
+	assert.Equal(t, 1000, score(settings))
+	assert.Equal(t, "idle", settings.SchedulingClass)
+	maxMemory, err := settings.GetMaxMemory()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2<<30), maxMemory)
+
+	settings = profile.GetCommandProcessSettings("check")
+	assert.Equal(t, 500, score(settings))
+	assert.Equal(t, "normal", settings.SchedulingClass)
+
+	settings = profile.GetCommandProcessSettings("snapshots")
+	assert.Equal(t, 0, score(settings))
+	assert.Equal(t, "batch", settings.SchedulingClass)
+
+	// the settings are not sent to restic
+	assert.NotContains(t, profile.GetCommandFlags("prune").GetAll(), "--oom-score-adjust=1000")
+	assert.Empty(t, profile.GetCommandFlags("snapshots").GetAll())
+}
+
+func TestGetProcessIssues(t *testing.T) {
+	profile := NewProfile(nil, "name")
+	assert.Empty(t, profile.GetProcessIssues())
+
+	profile.MaxMemory = "lots"
+	profile.Prune = &SectionWithScheduleAndMonitoring{ProcessSection: ProcessSection{MaxMemory: "2X"}}
+	profile.Check = &SectionWithScheduleAndMonitoring{ProcessSection: ProcessSection{MaxMemory: "512M"}}
+	assert.Equal(t, []string{
+		`max-memory: invalid size "lots"`,
+		`max-memory of section prune: invalid size "2X"`,
+	}, profile.GetProcessIssues())
+}
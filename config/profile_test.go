@@ -177,6 +177,33 @@ quiet = false
 	})
 }
 
+func TestAbstractProfileIsNotInherited(t *testing.T) {
+	runForVersions(t, func(t *testing.T, version, prefix string) {
+		testConfig := version + `
+[` + prefix + `base]
+abstract = true
+repository = "base"
+
+[` + prefix + `profile]
+inherit = "base"
+`
+		c, err := Load(bytes.NewBufferString(testConfig), "toml")
+		require.NoError(t, err)
+		assert.True(t, c.IsAbstractProfile("base"))
+		assert.False(t, c.IsAbstractProfile("profile"))
+		assert.False(t, c.IsAbstractProfile("unknown"))
+
+		base, err := getProfile("toml", testConfig, "base", "")
+		require.NoError(t, err)
+		assert.True(t, base.Abstract)
+
+		profile, err := getProfile("toml", testConfig, "profile", "")
+		require.NoError(t, err)
+		assert.False(t, profile.Abstract)
+		assert.Equal(t, "base", profile.Repository.String())
+	})
+}
+
 func TestInheritanceAppendToList(t *testing.T) {
 	testConfig := `
 version = 2
@@ -1131,6 +1158,126 @@ func TestSetRootPathOnMonitoringSections(t *testing.T) {
 	assert.Equal(t, "root/file", sections.SendFinally[0].BodyTemplate)
 }
 
+func TestGetUnsupportedFlags(t *testing.T) {
+	profile := NewProfile(nil, "name")
+	profile.Repository = NewConfidentialValue("repo")
+	profile.Backup = &BackupSection{}
+	profile.Backup.OtherFlags = map[string]any{"read-concurrency": 4, "unknown-flag": true}
+	profile.Copy = &CopySection{}
+
+	messages := func() (list []string) {
+		for _, err := range profile.GetUnsupportedFlags() {
+			list = append(list, err.Error())
+		}
+		return
+	}
+
+	require.NoError(t, profile.SetResticVersion(""))
+	assert.Equal(t, []string{`unknown flag --unknown-flag for command "backup"`}, messages())
+
+	require.NoError(t, profile.SetResticVersion("0.16"))
+	assert.Equal(t, []string{`unknown flag --unknown-flag for command "backup"`}, messages())
+
+	require.NoError(t, profile.SetResticVersion("0.9"))
+	assert.Equal(t, []string{
+		`flag --read-concurrency of command "backup" requires restic 0.15.0 or newer (restic version is 0.9.0)`,
+		`unknown flag --unknown-flag for command "backup"`,
+		`command "copy" requires restic 0.10.0 or newer (restic version is 0.9.0)`,
+	}, messages())
+}
+
+func TestGetShadowedFlags(t *testing.T) {
+	profile := NewProfile(nil, "name")
+	assert.Empty(t, profile.GetShadowedFlags())
+
+	profile.Repository = NewConfidentialValue("local:/backup")
+	profile.OtherFlags = map[string]any{"repo": "rest:http://host/", "no-cache": true, "use": "mixin"}
+	profile.Backup = &BackupSection{}
+	profile.Backup.OtherFlags = map[string]any{"r": "s3:host/bucket", "verbose": 2}
+	profile.Check = &SectionWithScheduleAndMonitoring{}
+	profile.Check.OtherFlags = map[string]any{"repository": "sftp:host:/backup", "cache-dir": nil}
+
+	assert.Equal(t, []string{
+		`flag "repo" conflicts with option "repository": only one of them should be set`,
+		`flag "r" in section "backup" conflicts with option "repository": only one of them should be set`,
+		`flag "repository" in section "check" conflicts with option "repository": only one of them should be set`,
+	}, profile.GetShadowedFlags())
+
+	profile.Repository = NewConfidentialValue("")
+	profile.Verbose = 1
+	assert.Equal(t, []string{
+		`flag "verbose" in section "backup" conflicts with option "verbose": only one of them should be set`,
+	}, profile.GetShadowedFlags())
+}
+
+func TestCompressionAndPackSizeFlags(t *testing.T) {
+	profile := NewProfile(nil, "name")
+	profile.Compression = "max"
+	profile.PackSize = 64
+	profile.Backup = &BackupSection{}
+
+	flags := profile.GetCommandFlags(constants.CommandBackup).ToMap()
+	assert.Equal(t, []string{"max"}, flags["compression"])
+	assert.Equal(t, []string{"64"}, flags["pack-size"])
+
+	require.NoError(t, profile.SetResticVersion("0.13"))
+	assert.Len(t, profile.GetUnsupportedFlags(), 2)
+	require.NoError(t, profile.SetResticVersion("0.14"))
+	assert.Empty(t, profile.GetUnsupportedFlags())
+}
+
+func TestUseJSONSummary(t *testing.T) {
+	profile := NewProfile(nil, "name")
+	profile.Backup = &BackupSection{ExtendedStatus: true}
+	assert.True(t, profile.UseJSONSummary(constants.CommandBackup))
+	assert.False(t, profile.UseJSONSummary(constants.CommandForget))
+	assert.False(t, profile.UseJSONSummary(constants.CommandCheck))
+
+	profile.JSONSummary = true
+	assert.True(t, profile.UseJSONSummary(constants.CommandForget))
+	assert.True(t, profile.UseJSONSummary(constants.CommandCheck))
+	assert.False(t, profile.UseJSONSummary(constants.CommandPrune))
+
+	require.NoError(t, profile.SetResticVersion("0.16.4"))
+	assert.True(t, profile.UseJSONSummary(constants.CommandForget))
+	assert.False(t, profile.UseJSONSummary(constants.CommandCheck))
+	require.NoError(t, profile.SetResticVersion("0.17.0"))
+	assert.True(t, profile.UseJSONSummary(constants.CommandCheck))
+}
+
+func TestGetRepositoryOptionIssues(t *testing.T) {
+	tests := []struct {
+		compression, repositoryVersion, resticVersion string
+		packSize                                      int
+		issues                                        []string
+	}{
+		{},
+		{compression: "auto", packSize: 16},
+		{compression: "MAX", repositoryVersion: "2"},
+		{compression: "auto", repositoryVersion: "1"},
+		{compression: "fastest", resticVersion: "0.16"},
+		{compression: "fastest", issues: nil}, // unknown restic version
+		{compression: "better", resticVersion: "0.15", issues: []string{`compression mode "better" requires restic 0.16 or newer (restic version is 0.15.0)`}},
+		{compression: "fast", issues: []string{`invalid compression mode "fast": expected one of auto, off, fastest, better, max`}},
+		{compression: "max", repositoryVersion: "1", issues: []string{`compression mode "max" is not available for repository format version 1 (set in the init section)`}},
+		{packSize: 3, issues: []string{`invalid pack-size 3: restic accepts a size between 4 and 128 MiB`}},
+		{packSize: 129, issues: []string{`invalid pack-size 129: restic accepts a size between 4 and 128 MiB`}},
+		{packSize: 128},
+	}
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%s-%d-%s-%s", test.compression, test.packSize, test.repositoryVersion, test.resticVersion), func(t *testing.T) {
+			profile := NewProfile(nil, "name")
+			profile.Compression = test.compression
+			profile.PackSize = test.packSize
+			if test.repositoryVersion != "" {
+				profile.Init = &InitSection{RepositoryVersion: test.repositoryVersion}
+			}
+			require.NoError(t, profile.SetResticVersion(test.resticVersion))
+			assert.Equal(t, test.issues, profile.GetRepositoryOptionIssues())
+		})
+	}
+}
+
 func TestGetInitStructFields(t *testing.T) {
 	init := &InitSection{
 		FromKeyHint:         "key-hint",
@@ -1158,6 +1305,11 @@ func TestGetInitStructFields(t *testing.T) {
 		}, init.getCommandFlags(profile).ToMap())
 	})
 
+	t.Run("restic 0.13", func(t *testing.T) {
+		require.NoError(t, profile.SetResticVersion("0.13.1"))
+		assert.Contains(t, init.getCommandFlags(profile).ToMap(), "repo2")
+	})
+
 	t.Run("restic>=14", func(t *testing.T) {
 		require.NoError(t, profile.SetResticVersion(resticVersion14.Original()))
 		assert.Equal(t, map[string][]string{
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/creativeprojects/resticprofile/util"
+)
+
+// defaultQuotaWarnAt is the usage of the quota (in percent) over which a warning is reported
+const defaultQuotaWarnAt = 80
+
+// QuotaSection limits the size of the repository, checked with "restic stats" after each backup
+type QuotaSection struct {
+	MaxRepoSize string   `mapstructure:"max-repo-size" examples:"500G;2T" description:"Maximum size of the data stored in the repository (\"restic stats --mode raw-data\"), checked after each backup"`
+	WarnAt      string   `mapstructure:"warn-at" default:"80%" examples:"75%;90%" description:"Usage of max-repo-size over which a warning is reported"`
+	BlockBackup bool     `mapstructure:"block-backup" description:"Refuse to run the next backups once max-repo-size is reached, until the quota is acknowledged with \"resticprofile quota --ack\""`
+	RunOnQuota  []string `mapstructure:"run-on-quota" description:"Run shell command(s) when the usage of the repository goes over warn-at or max-repo-size (QUOTA_STATUS, QUOTA_USED, QUOTA_LIMIT and QUOTA_PERCENT are set)"`
+	StateFile   string   `mapstructure:"state-file" description:"File keeping the last usage of the repository and the block of the backups (in the cache directory of the user by default)"`
+}
+
+func (q *QuotaSection) IsEmpty() bool { return q == nil || q.MaxRepoSize == "" }
+
+func (q *QuotaSection) setRootPath(rootPath string) {
+	q.StateFile = fixPath(q.StateFile, expandEnv, absolutePrefix(rootPath))
+}
+
+// GetMaxRepoSize returns the maximum size of the repository in bytes (0 when there's no quota)
+func (q *QuotaSection) GetMaxRepoSize() (uint64, error) {
+	if q.IsEmpty() {
+		return 0, nil
+	}
+	return util.ParseBytes(q.MaxRepoSize)
+}
+
+// GetWarnAt returns the usage of the quota (in percent) over which a warning is reported (80% when not specified)
+func (q *QuotaSection) GetWarnAt() (float64, error) {
+	if q == nil || q.WarnAt == "" {
+		return defaultQuotaWarnAt, nil
+	}
+	value := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(q.WarnAt), "%"))
+	percent, err := strconv.ParseFloat(value, 64)
+	if err != nil || percent <= 0 || percent > 100 {
+		return 0, fmt.Errorf("invalid percentage %q", q.WarnAt)
+	}
+	return percent, nil
+}
+
+// GetQuotaIssues returns the issues with the quota of the profile
+func (p *Profile) GetQuotaIssues() (issues []string) {
+	if p.Quota == nil {
+		return
+	}
+	if p.Quota.MaxRepoSize == "" {
+		if p.Quota.WarnAt != "" || p.Quota.BlockBackup || len(p.Quota.RunOnQuota) > 0 {
+			issues = append(issues, "quota: max-repo-size is not set, the quota of the repository is not checked")
+		}
+		return
+	}
+	if size, err := p.Quota.GetMaxRepoSize(); err != nil {
+		issues = append(issues, fmt.Sprintf("quota: max-repo-size: %s", err))
+	} else if size == 0 {
+		issues = append(issues, "quota: max-repo-size cannot be zero")
+	}
+	if _, err := p.Quota.GetWarnAt(); err != nil {
+		issues = append(issues, fmt.Sprintf("quota: warn-at: %s", err))
+	}
+	return
+}
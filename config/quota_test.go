@@ -0,0 +1,75 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuotaWarnAt(t *testing.T) {
+	testData := []struct {
+		warnAt   string
+		expected float64
+		valid    bool
+	}{
+		{"", 80, true},
+		{"75%", 75, true},
+		{" 90 % ", 90, true},
+		{"95", 95, true},
+		{"0%", 0, false},
+		{"120%", 0, false},
+		{"half", 0, false},
+	}
+	for _, testItem := range testData {
+		t.Run(testItem.warnAt, func(t *testing.T) {
+			quota := &QuotaSection{MaxRepoSize: "1G", WarnAt: testItem.warnAt}
+			percent, err := quota.GetWarnAt()
+			if !testItem.valid {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, testItem.expected, percent)
+		})
+	}
+}
+
+func TestQuotaIssues(t *testing.T) {
+	testData := []struct {
+		name   string
+		quota  *QuotaSection
+		issues []string
+	}{
+		{name: "no quota"},
+		{name: "valid", quota: &QuotaSection{MaxRepoSize: "500G", WarnAt: "90%"}},
+		{name: "no size", quota: &QuotaSection{BlockBackup: true}, issues: []string{"quota: max-repo-size is not set, the quota of the repository is not checked"}},
+		{name: "zero", quota: &QuotaSection{MaxRepoSize: "0"}, issues: []string{"quota: max-repo-size cannot be zero"}},
+		{name: "invalid", quota: &QuotaSection{MaxRepoSize: "lots", WarnAt: "most"}, issues: []string{
+			"quota: max-repo-size: invalid size \"lots\"",
+			"quota: warn-at: invalid percentage \"most\"",
+		}},
+	}
+	for _, testItem := range testData {
+		t.Run(testItem.name, func(t *testing.T) {
+			profile := NewProfile(nil, "name")
+			profile.Quota = testItem.quota
+			assert.Equal(t, testItem.issues, profile.GetQuotaIssues())
+		})
+	}
+}
+
+func TestLoadQuota(t *testing.T) {
+	profile, err := getResolvedProfile("toml", `
+[name.quota]
+max-repo-size = "500G"
+warn-at = "90%"
+state-file = "quota.json"
+`, "name")
+	require.NoError(t, err)
+	require.NotNil(t, profile.Quota)
+	size, err := profile.Quota.GetMaxRepoSize()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(500*1024*1024*1024), size)
+	assert.NotContains(t, profile.OtherFlags, "quota")
+}
@@ -0,0 +1,116 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/creativeprojects/resticprofile/constants"
+	"golang.org/x/exp/slices"
+)
+
+// remoteProfileSeparator separates the configuration file from the profile name in "inherit" (e.g. "common.yaml#base")
+const remoteProfileSeparator = "#"
+
+// isRemoteProfile returns true when the inherited profile is defined in another configuration file
+func isRemoteProfile(inherit string) bool {
+	return strings.Contains(inherit, remoteProfileSeparator)
+}
+
+// splitRemoteProfile returns the configuration file (relative to the current configuration file) and the profile name
+func (c *Config) splitRemoteProfile(inherit string) (file, name string, err error) {
+	file, name, _ = strings.Cut(inherit, remoteProfileSeparator)
+	file, name = strings.TrimSpace(file), strings.TrimSpace(name)
+	if file == "" || name == "" {
+		return "", "", fmt.Errorf("invalid remote profile %q: expected \"file%sprofile\"", inherit, remoteProfileSeparator)
+	}
+	if !filepath.IsAbs(file) && c.configFile != "" {
+		file = filepath.Join(filepath.Dir(c.configFile), file)
+	}
+	return
+}
+
+// remoteFiles returns the configuration files of the remote profiles inherited by the profiles (absolute paths, sorted)
+func (c *Config) remoteFiles() (files []string, err error) {
+	for _, profileName := range c.GetProfileNames() {
+		inherit := c.viper.GetString(c.flatKey(c.getProfilePath(profileName), constants.SectionConfigurationInherit))
+		if !isRemoteProfile(inherit) {
+			continue
+		}
+		file, _, err := c.splitRemoteProfile(inherit)
+		if err != nil {
+			return nil, err
+		}
+		if absolute, err := filepath.Abs(file); err == nil {
+			file = absolute
+		}
+		if !slices.Contains(files, file) {
+			files = append(files, file)
+		}
+	}
+	sort.Strings(files)
+	return
+}
+
+// remoteFileHashes returns the hashes of the configuration files of the remote profiles, and of the files they load.
+// The files are found from the configuration, not from the profiles loaded so far: the hash is checked before loading any profile.
+func (c *Config) remoteFileHashes(rendered bool, visited map[string]bool) ([]FileHash, error) {
+	files, err := c.remoteFiles()
+	if err != nil {
+		return nil, err
+	}
+	hashes := make([]FileHash, 0)
+	for _, file := range files {
+		if visited[file] {
+			continue
+		}
+		visited[file] = true
+		remote, err := LoadFile(file, "")
+		if err != nil {
+			return nil, fmt.Errorf("cannot load remote profile file %q: %w", file, err)
+		}
+		remoteHashes, err := remote.fileHashes(rendered, visited)
+		if err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, remoteHashes...)
+	}
+	return hashes, nil
+}
+
+// getRemoteProfileSettings loads the configuration file of a remote profile ("file#profile") and returns the settings
+// of the profile, after its own inheritance and mixins. The templates of the remote file are executed for the profile inheriting it.
+func (c *Config) getRemoteProfileSettings(inherit, profileName string) (map[string]any, error) {
+	file, name, err := c.splitRemoteProfile(inherit)
+	if err != nil {
+		return nil, err
+	}
+	if absolute, err := filepath.Abs(file); err == nil {
+		file = absolute
+	}
+	key := file + remoteProfileSeparator + name
+	if slices.Contains(c.remoteChain, key) {
+		return nil, fmt.Errorf("circular inheritance of remote profile %q", inherit)
+	}
+
+	remote, err := LoadFile(file, "")
+	if err != nil {
+		return nil, fmt.Errorf("cannot load remote profile %q: %w", inherit, err)
+	}
+	if remote.GetVersion() < Version02 {
+		return nil, fmt.Errorf("cannot load remote profile %q: the configuration file must use version 2", inherit)
+	}
+	remote.remoteChain = append(slices.Clone(c.remoteChain), key)
+
+	if err = remote.reloadTemplates(newTemplateData(remote.configFile, profileName, "")); err != nil {
+		return nil, fmt.Errorf("cannot load remote profile %q: %w", inherit, err)
+	}
+	if !remote.HasProfile(name) {
+		return nil, fmt.Errorf("remote profile %q not found", inherit)
+	}
+	if err = remote.applyProfileInheritanceAndMixins(name); err != nil {
+		return nil, fmt.Errorf("cannot load remote profile %q: %w", inherit, err)
+	}
+	return remote.viper.GetStringMap(remote.getProfilePath(name)), nil
+}
@@ -0,0 +1,148 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeRemoteProfileFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		filename := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(filename), 0o700))
+		require.NoError(t, os.WriteFile(filename, []byte(content), 0o600))
+	}
+	return dir
+}
+
+func TestInheritRemoteProfile(t *testing.T) {
+	dir := writeRemoteProfileFiles(t, map[string]string{
+		"org/common.yaml": `
+version: "2"
+profiles:
+  base:
+    abstract: true
+    inherit: defaults.yaml#defaults
+    repository: "local:/backup/{{ .Profile.Name }}"
+    backup:
+      tag: [org]
+`,
+		"org/defaults.yaml": `
+version: "2"
+profiles:
+  defaults:
+    password-file: key
+    backup:
+      exclude-caches: true
+`,
+		"profiles.yaml": `
+version: "2"
+profiles:
+  host:
+    inherit: org/common.yaml#base
+    description: host backup
+    backup:
+      source: home
+`,
+	})
+
+	c, err := LoadFile(filepath.Join(dir, "profiles.yaml"), "")
+	require.NoError(t, err)
+	assert.False(t, c.IsAbstractProfile("host"))
+
+	profile, err := c.GetProfile("host")
+	require.NoError(t, err)
+	assert.Equal(t, "local:/backup/host", profile.Repository.String())
+	assert.Equal(t, "host backup", profile.Description)
+	assert.Equal(t, filepath.Join(dir, "key"), profile.PasswordFile)
+	require.NotNil(t, profile.Backup)
+	assert.Equal(t, []any{"org"}, profile.Backup.OtherFlags["tag"])
+	assert.True(t, profile.Backup.ExcludeCaches)
+	assert.Equal(t, []string{"home"}, profile.Backup.Source)
+}
+
+func TestInheritRemoteProfileErrors(t *testing.T) {
+	dir := writeRemoteProfileFiles(t, map[string]string{
+		"loop.yaml": `
+version: "2"
+profiles:
+  first:
+    inherit: profiles.yaml#loop
+`,
+		"v1.yaml": `
+base:
+  repository: local:/backup
+`,
+		"profiles.yaml": `
+version: "2"
+profiles:
+  missing-file:
+    inherit: none.yaml#base
+  missing-profile:
+    inherit: loop.yaml#none
+  invalid:
+    inherit: loop.yaml#
+  version1:
+    inherit: v1.yaml#base
+  loop:
+    inherit: loop.yaml#first
+`,
+	})
+
+	c, err := LoadFile(filepath.Join(dir, "profiles.yaml"), "")
+	require.NoError(t, err)
+
+	fixtures := map[string]string{
+		"missing-file":    `cannot load remote profile "none.yaml#base"`,
+		"missing-profile": `remote profile "loop.yaml#none" not found`,
+		"invalid":         `invalid remote profile "loop.yaml#"`,
+		"version1":        "the configuration file must use version 2",
+		"loop":            "circular inheritance",
+	}
+	for name, expected := range fixtures {
+		t.Run(name, func(t *testing.T) {
+			_, err := c.GetProfile(name)
+			require.Error(t, err)
+			assert.ErrorContains(t, err, expected)
+		})
+	}
+}
+
+func TestRemoteProfileFilesInHash(t *testing.T) {
+	files := map[string]string{
+		"org/common.yaml":   "version: \"2\"\nprofiles:\n  base:\n    inherit: defaults.yaml#defaults\n    repository: local:/backup\n",
+		"org/defaults.yaml": "version: \"2\"\nprofiles:\n  defaults:\n    password-file: key\n",
+		"profiles.yaml":     "version: \"2\"\nprofiles:\n  host:\n    inherit: org/common.yaml#base\n  other:\n    inherit: org/common.yaml#base\n  loop:\n    inherit: profiles.yaml#host\n",
+	}
+	dir := writeRemoteProfileFiles(t, files)
+	c, err := LoadFile(filepath.Join(dir, "profiles.yaml"), "")
+	require.NoError(t, err)
+
+	// the hash doesn't depend on the profiles loaded before
+	hashes, err := c.GetFileHashes(false)
+	require.NoError(t, err)
+	names := make([]string, 0, len(hashes))
+	for _, hash := range hashes {
+		rel, err := filepath.Rel(dir, hash.Name)
+		require.NoError(t, err)
+		names = append(names, filepath.ToSlash(rel))
+	}
+	assert.Equal(t, []string{"profiles.yaml", "org/common.yaml", "org/defaults.yaml"}, names)
+	hash, err := c.GetHash(false)
+	require.NoError(t, err)
+
+	files["org/defaults.yaml"] += "    initialize: true\n"
+	other, err := LoadFile(filepath.Join(writeRemoteProfileFiles(t, files), "profiles.yaml"), "")
+	require.NoError(t, err)
+	otherHash, err := other.GetHash(false)
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, otherHash)
+
+	_, err = c.GetHash(true)
+	assert.NoError(t, err)
+}
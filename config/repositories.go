@@ -0,0 +1,115 @@
+package config
+
+import (
+	"sort"
+
+	"github.com/creativeprojects/resticprofile/constants"
+	"github.com/creativeprojects/resticprofile/shell"
+	"golang.org/x/exp/maps"
+)
+
+const (
+	RepositoryModeBackup = "backup"
+	RepositoryModeCopy   = "copy"
+)
+
+// repositoryFlags are the flags selecting the repository and its password
+var repositoryFlags = []string{
+	constants.ParameterRepository,
+	constants.ParameterRepositoryFile,
+	constants.ParameterPasswordFile,
+	constants.ParameterPasswordCommand,
+	constants.ParameterKeyHint,
+}
+
+// RepositorySection is an additional repository of the profile, receiving each backup after the main repository
+type RepositorySection struct {
+	Repository      ConfidentialValue `mapstructure:"repository" description:"Location of the additional repository"`
+	RepositoryFile  string            `mapstructure:"repository-file" description:"File from which to read the location of the additional repository"`
+	PasswordFile    string            `mapstructure:"password-file" description:"File to read the password of the additional repository from"`
+	PasswordCommand string            `mapstructure:"password-command" description:"Shell command to obtain the password of the additional repository from"`
+	KeyHint         string            `mapstructure:"key-hint" description:"Key ID of key to try decrypting the additional repository first"`
+	Mode            string            `mapstructure:"mode" default:"backup" enum:"backup;copy" description:"Run the backup again to this repository, or copy the snapshots of the main repository to it"`
+}
+
+func (s *RepositorySection) setRootPath(rootPath string) {
+	s.PasswordFile = fixPath(s.PasswordFile, expandEnv, absolutePrefix(rootPath))
+	s.RepositoryFile = fixPath(s.RepositoryFile, expandEnv, absolutePrefix(rootPath))
+}
+
+// IsCopy returns true when the snapshots of the main repository are copied to this repository
+func (s *RepositorySection) IsCopy() bool {
+	return s.Mode == RepositoryModeCopy
+}
+
+// replaceFlags replaces the repository and password flags with the ones of this repository
+func (s *RepositorySection) replaceFlags(flags *shell.Args) {
+	for _, name := range repositoryFlags {
+		flags.Remove(name)
+	}
+	values := map[string]string{
+		constants.ParameterRepository:      s.Repository.Value(),
+		constants.ParameterRepositoryFile:  s.RepositoryFile,
+		constants.ParameterPasswordFile:    s.PasswordFile,
+		constants.ParameterPasswordCommand: s.PasswordCommand,
+		constants.ParameterKeyHint:         s.KeyHint,
+	}
+	for _, name := range repositoryFlags {
+		if value := values[name]; value != "" {
+			flags.AddFlag(name, value, shell.ArgConfigEscape)
+		}
+	}
+}
+
+// copySection returns the copy section with this repository as destination
+func (s *RepositorySection) copySection() *CopySection {
+	return &CopySection{
+		Repository:      s.Repository,
+		RepositoryFile:  s.RepositoryFile,
+		PasswordFile:    s.PasswordFile,
+		PasswordCommand: s.PasswordCommand,
+		KeyHint:         s.KeyHint,
+	}
+}
+
+// GetRepositoryNames returns the names of the additional repositories of the profile (sorted)
+func (p *Profile) GetRepositoryNames() []string {
+	names := maps.Keys(p.Repositories)
+	sort.Strings(names)
+	return names
+}
+
+// GetRepository returns the additional repository of the profile, or nil when not found
+func (p *Profile) GetRepository(name string) *RepositorySection {
+	return p.Repositories[name]
+}
+
+// SetRepositoryFlags replaces the repository and password flags with the ones of an additional repository
+func (p *Profile) SetRepositoryFlags(name string, flags *shell.Args) {
+	if repository := p.GetRepository(name); repository != nil && flags != nil {
+		repository.replaceFlags(flags)
+	}
+}
+
+// GetRepositoryCopyFlags returns the flags of the copy of the snapshots of the main repository to an additional repository
+func (p *Profile) GetRepositoryCopyFlags(name string) *shell.Args {
+	if repository := p.GetRepository(name); repository != nil {
+		return repository.copySection().getCommandFlags(p)
+	}
+	return nil
+}
+
+// GetRepositoryInitFlags returns the flags initializing an additional repository. A repository receiving a copy of the
+// snapshots is initialized with the chunker parameters of the main repository, to keep the deduplication of the copy.
+func (p *Profile) GetRepositoryInitFlags(name string) *shell.Args {
+	repository := p.GetRepository(name)
+	if repository == nil {
+		return nil
+	}
+	if repository.IsCopy() {
+		return repository.copySection().getInitFlags(p)
+	}
+	flags := p.GetCommandFlags(constants.CommandInit)
+	repository.replaceFlags(flags)
+	return flags
+}
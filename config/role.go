@@ -0,0 +1,141 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/creativeprojects/resticprofile/constants"
+	"github.com/creativeprojects/resticprofile/shell"
+	"golang.org/x/exp/slices"
+)
+
+// Roles of the credentials of a profile with an append-only repository
+const (
+	RoleWriter      = "writer"
+	RoleMaintenance = "maintenance"
+)
+
+// maintenanceCommands are the restic commands removing data from the repository.
+// An append-only repository (rest-server --append-only, or S3 with object lock) refuses them with the writer credentials.
+var maintenanceCommands = []string{
+	constants.CommandForget,
+	constants.CommandPrune,
+}
+
+// IsMaintenanceCommand returns true when the restic command removes data from the repository
+func IsMaintenanceCommand(command string) bool {
+	return slices.Contains(maintenanceCommands, command)
+}
+
+// MaintainerSection is the credentials of the maintainer of an append-only repository, used by forget and prune
+type MaintainerSection struct {
+	Repository      ConfidentialValue            `mapstructure:"repository" description:"Location of the repository with the maintainer credentials (like a rest-server URL with the maintainer user)"`
+	RepositoryFile  string                       `mapstructure:"repository-file" description:"File from which to read the location of the repository with the maintainer credentials"`
+	PasswordFile    string                       `mapstructure:"password-file" description:"File to read the password of the repository from, for the maintainer"`
+	PasswordCommand string                       `mapstructure:"password-command" description:"Shell command to obtain the password of the repository from, for the maintainer"`
+	Environment     map[string]ConfidentialValue `mapstructure:"env" description:"Additional environment variables of the maintainer (like the AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY allowed to delete objects)"`
+}
+
+func (m *MaintainerSection) setRootPath(rootPath string) {
+	m.PasswordFile = fixPath(m.PasswordFile, expandEnv, absolutePrefix(rootPath))
+	m.RepositoryFile = fixPath(m.RepositoryFile, expandEnv, absolutePrefix(rootPath))
+}
+
+// replaceFlags replaces the repository and password flags with the ones of the maintainer. The location and the password
+// are replaced separately: the maintainer may only have other credentials for one of them.
+func (m *MaintainerSection) replaceFlags(flags *shell.Args) {
+	groups := [][]string{
+		{constants.ParameterRepository, m.Repository.Value(), constants.ParameterRepositoryFile, m.RepositoryFile},
+		{constants.ParameterPasswordFile, m.PasswordFile, constants.ParameterPasswordCommand, m.PasswordCommand},
+	}
+	for _, group := range groups {
+		if group[1] == "" && group[3] == "" {
+			continue
+		}
+		for i := 0; i < len(group); i += 2 {
+			flags.Remove(group[i])
+			if group[i+1] != "" {
+				flags.AddFlag(group[i], group[i+1], shell.ArgConfigEscape)
+			}
+		}
+	}
+}
+
+// IsWriter returns true when the credentials of the profile can only add data to the repository
+func (p *Profile) IsWriter() bool { return p.Role == RoleWriter }
+
+// IsMaintenance returns true when the profile maintains the repository written by other profiles
+func (p *Profile) IsMaintenance() bool { return p.Role == RoleMaintenance }
+
+// SetMaintainerFlags replaces the repository and password flags with the maintainer credentials for forget and prune
+func (p *Profile) SetMaintainerFlags(command string, flags *shell.Args) {
+	if p.Maintainer != nil && flags != nil && IsMaintenanceCommand(command) {
+		p.Maintainer.replaceFlags(flags)
+	}
+}
+
+// GetMaintainerEnvironment returns the environment variables of the maintainer for forget and prune (sorted)
+func (p *Profile) GetMaintainerEnvironment(command string) (env []string) {
+	if p.Maintainer == nil || !IsMaintenanceCommand(command) {
+		return
+	}
+	for name, value := range p.Maintainer.Environment {
+		env = append(env, strings.ToUpper(name)+"="+value.Value())
+	}
+	sort.Strings(env)
+	return
+}
+
+// CheckRoleCommand returns an error when the role of the profile doesn't allow the command
+func (p *Profile) CheckRoleCommand(command string) error {
+	if p.IsWriter() && p.Maintainer == nil && IsMaintenanceCommand(command) {
+		return fmt.Errorf("profile '%s': command '%s' is not allowed with the writer credentials of an append-only repository (add the maintainer credentials, or run it from a maintenance profile)", p.Name, command)
+	}
+	if p.IsMaintenance() && command == constants.CommandBackup {
+		return fmt.Errorf("profile '%s': command '%s' is not allowed in a maintenance profile", p.Name, command)
+	}
+	return nil
+}
+
+// ValidateRole returns an error when the settings of the profile don't match its role
+func (p *Profile) ValidateRole() error {
+	switch p.Role {
+	case "":
+		return nil
+
+	case RoleWriter:
+		if p.Maintainer != nil {
+			return nil
+		}
+		if p.Retention != nil && (p.Retention.BeforeBackup || p.Retention.AfterBackup) {
+			return fmt.Errorf("profile '%s': the writer credentials cannot apply the retention with the backup: add the maintainer credentials, or move the retention to a maintenance profile", p.Name)
+		}
+		for _, command := range maintenanceCommands {
+			if p.isScheduled(command) {
+				return fmt.Errorf("profile '%s': the writer credentials cannot run the schedule of %s: add the maintainer credentials, or move the schedule to a maintenance profile", p.Name, command)
+			}
+		}
+		return nil
+
+	case RoleMaintenance:
+		if p.Maintainer != nil {
+			return fmt.Errorf("profile '%s': a maintenance profile uses its own credentials, the maintainer section is not allowed", p.Name)
+		}
+		if p.isScheduled(constants.CommandBackup) {
+			return fmt.Errorf("profile '%s': a maintenance profile cannot schedule backups", p.Name)
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("profile '%s': invalid role %q (expected %s or %s)", p.Name, p.Role, RoleWriter, RoleMaintenance)
+	}
+}
+
+// isScheduled returns true when the section of the command has a schedule
+func (p *Profile) isScheduled(command string) bool {
+	if section, found := GetSectionsWith[Scheduling](p)[command]; found {
+		return len(section.GetSchedule().Schedule) > 0
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/creativeprojects/resticprofile/util/templates"
+)
+
+// runTimeTemplatePattern matches the run-time templates like {{run .Date}} or {{ run (.Now.Format "2006") }}
+var runTimeTemplatePattern = regexp.MustCompile(`\{\{-?\s*run\s[^{}]*\}\}`)
+
+// RunTimeData contains the variables of the run-time templates ({{run .Date}}, {{run .Hostname}}, etc.).
+// Unlike the configuration templates, they're resolved when the command runs.
+type RunTimeData struct {
+	Now          time.Time
+	Date         string // 2006-01-02
+	Time         string // 15-04-05
+	Hostname     string
+	ProfileName  string
+	CommandName  string
+	ScheduleName string // "profile/command" when started by a scheduled job
+	Tags         string // tags from the "tag-command" of the backup, separated by commas
+}
+
+// NewRunTimeData returns the variables of the run-time templates for the current time
+func NewRunTimeData(profileName, commandName, scheduleName string) RunTimeData {
+	defaults := templates.NewDefaultData(nil)
+	return RunTimeData{
+		Now:          defaults.Now,
+		Date:         defaults.Now.Format("2006-01-02"),
+		Time:         defaults.Now.Format("15-04-05"),
+		Hostname:     defaults.Hostname,
+		ProfileName:  profileName,
+		CommandName:  commandName,
+		ScheduleName: scheduleName,
+	}
+}
+
+// escapeRunTimeTemplates keeps the run-time templates as they are when the configuration templates are executed
+func escapeRunTimeTemplates(source string) string {
+	return runTimeTemplatePattern.ReplaceAllStringFunc(source, func(match string) string {
+		return "{{" + strconv.Quote(match) + "}}"
+	})
+}
+
+// HasRunTimeTemplate returns true when the value contains a run-time template
+func HasRunTimeTemplate(value string) bool {
+	return strings.Contains(value, "{{") && runTimeTemplatePattern.MatchString(value)
+}
+
+// ResolveRunTime replaces the run-time templates of the value with the run-time data
+func ResolveRunTime(value string, data RunTimeData) (string, error) {
+	if !HasRunTimeTemplate(value) {
+		return value, nil
+	}
+	tpl := templates.New("run", map[string]any{
+		"run": func(value any) string { return fmt.Sprint(value) },
+	})
+	if _, err := tpl.Parse(value); err != nil {
+		return value, fmt.Errorf("invalid run-time template %q: %w", value, err)
+	}
+	result := &strings.Builder{}
+	if err := tpl.Execute(result, data); err != nil {
+		return value, fmt.Errorf("cannot resolve run-time template %q: %w", value, err)
+	}
+	return result.String(), nil
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunTimeTemplatesAreKeptInConfiguration(t *testing.T) {
+	testConfig := `
+version: "1"
+profile:
+  backup:
+    source: /home
+    tag:
+      - "{{ .Profile.Name }}"
+      - "{{run .Date}}-{{run .ScheduleName}}"
+      - '{{ run (.Now.Format "2006") }}'
+`
+	c, err := Load(bytes.NewBufferString(testConfig), "yaml")
+	require.NoError(t, err)
+	profile, err := c.GetProfile("profile")
+	require.NoError(t, err)
+
+	tags, found := profile.GetCommandFlags("backup").Get("tag")
+	require.True(t, found)
+	values := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		values = append(values, tag.Value())
+	}
+	assert.Equal(t, []string{"profile", "{{run .Date}}-{{run .ScheduleName}}", `{{ run (.Now.Format "2006") }}`}, values)
+}
+
+func TestResolveRunTime(t *testing.T) {
+	data := NewRunTimeData("profile", "backup", "profile/backup")
+	data.Now = time.Date(2024, 5, 17, 8, 30, 15, 0, time.UTC)
+	data.Date, data.Time = "2024-05-17", "08-30-15"
+	data.Hostname = "host"
+
+	testCases := []struct{ value, expected string }{
+		{"no template", "no template"},
+		{"{{ .Date }}", "{{ .Date }}"}, // not a run-time template
+		{"/var/log/{{run .ProfileName}}-{{run .CommandName}}-{{run .Date}}.log", "/var/log/profile-backup-2024-05-17.log"},
+		{"{{run .Hostname}}_{{run .Time}}", "host_08-30-15"},
+		{"{{run .ScheduleName}}", "profile/backup"},
+		{`{{ run (.Now.Format "2006/01") }}`, "2024/05"},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.value, func(t *testing.T) {
+			value, err := ResolveRunTime(testCase.value, data)
+			require.NoError(t, err)
+			assert.Equal(t, testCase.expected, value)
+		})
+	}
+
+	value, err := ResolveRunTime("{{run .Unknown}}", data)
+	assert.Error(t, err)
+	assert.Equal(t, "{{run .Unknown}}", value)
+}
+
+func TestNewRunTimeData(t *testing.T) {
+	data := NewRunTimeData("profile", "check", "")
+	assert.Equal(t, data.Now.Format("2006-01-02"), data.Date)
+	assert.Equal(t, data.Now.Format("15-04-05"), data.Time)
+	assert.NotEmpty(t, data.Hostname)
+	assert.Equal(t, "profile", data.ProfileName)
+	assert.Equal(t, "check", data.CommandName)
+	assert.Empty(t, data.ScheduleName)
+}
@@ -12,4 +12,5 @@ type Schedule struct {
 	Priority   string        `mapstructure:"priority"`
 	LockMode   string        `mapstructure:"lock-mode"`
 	LockWait   time.Duration `mapstructure:"lock-wait"`
+	Timezone   string        `mapstructure:"timezone"`
 }
@@ -1,8 +1,10 @@
 package config
 
 import (
+	"fmt"
 	"strings"
 	"time"
+	_ "time/tzdata" // time zones of schedules must load on systems without time zone database (windows)
 
 	"github.com/creativeprojects/resticprofile/constants"
 )
@@ -35,6 +37,7 @@ type ScheduleConfig struct {
 	Log              string
 	LockMode         string
 	LockWait         time.Duration
+	Timezone         string
 	ConfigFile       string
 	Flags            map[string]string
 	RemoveOnly       bool
@@ -83,6 +86,30 @@ func (s *ScheduleConfig) GetLockWait() time.Duration {
 	return s.LockWait
 }
 
+// GetTimezone returns the time zone of the schedules (local time zone when not set)
+func (s *ScheduleConfig) GetTimezone() (*time.Location, error) {
+	if s.Timezone == "" {
+		return time.Local, nil
+	}
+	location, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schedule timezone %q: %w", s.Timezone, err)
+	}
+	return location, nil
+}
+
+// GetSchedulesWithTimezone returns the schedules with the time zone appended (as expected by systemd timers)
+func (s *ScheduleConfig) GetSchedulesWithTimezone() []string {
+	if s.Timezone == "" {
+		return s.Schedules
+	}
+	schedules := make([]string, len(s.Schedules))
+	for i, schedule := range s.Schedules {
+		schedules[i] = schedule + " " + s.Timezone
+	}
+	return schedules
+}
+
 func (s *ScheduleConfig) GetFlag(name string) (string, bool) {
 	if len(s.Flags) == 0 {
 		return "", false
@@ -108,6 +135,7 @@ func (s *ScheduleConfig) Export() Schedule {
 		Priority:   s.Priority,
 		LockMode:   s.LockMode,
 		LockWait:   s.LockWait,
+		Timezone:   s.Timezone,
 		Schedule:   s.Schedules,
 	}
 }
@@ -98,3 +98,21 @@ func TestScheduleFlags(t *testing.T) {
 	assert.Equal(t, "test", flag)
 	assert.True(t, found)
 }
+
+func TestScheduleTimezone(t *testing.T) {
+	schedule := ScheduleConfig{Schedules: []string{"daily", "*-*-* 02:30"}}
+	location, err := schedule.GetTimezone()
+	assert.NoError(t, err)
+	assert.Equal(t, time.Local, location)
+	assert.Equal(t, []string{"daily", "*-*-* 02:30"}, schedule.GetSchedulesWithTimezone())
+
+	schedule.Timezone = "Europe/Paris"
+	location, err = schedule.GetTimezone()
+	assert.NoError(t, err)
+	assert.Equal(t, "Europe/Paris", location.String())
+	assert.Equal(t, []string{"daily Europe/Paris", "*-*-* 02:30 Europe/Paris"}, schedule.GetSchedulesWithTimezone())
+
+	schedule.Timezone = "Mars/Olympus_Mons"
+	_, err = schedule.GetTimezone()
+	assert.ErrorContains(t, err, "invalid schedule timezone")
+}
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"golang.org/x/exp/maps"
+)
+
+// ScriptPrefix starts a shell command referencing a script of the profile: "script:name arg1 arg2"
+const ScriptPrefix = "script:"
+
+// ScriptSection defines a named script of a profile, that can be referenced from any run-* hook
+type ScriptSection struct {
+	Description string   `mapstructure:"description" description:"Describes the script"`
+	Run         string   `mapstructure:"run" description:"Content of the script (can be multi-line)"`
+	File        string   `mapstructure:"file" description:"Path to the file containing the script (relative to the configuration file)"`
+	Parameters  []string `mapstructure:"parameters" examples:"database;output" description:"Names of the arguments expected by the script (available as $1, $2, etc.)"`
+
+	WorkingDirectory string                       `mapstructure:"working-directory" description:"Directory where the script runs (defaults to the current directory)"`
+	Environment      map[string]ConfidentialValue `mapstructure:"env" description:"Additional environment variables to set when running the script"`
+}
+
+func (s *ScriptSection) IsEmpty() bool { return s == nil || (s.Run == "" && s.File == "") }
+
+func (s *ScriptSection) setRootPath(_ *Profile, rootPath string) {
+	s.File = fixPath(s.File, expandEnv, absolutePrefix(rootPath))
+	s.WorkingDirectory = fixPath(s.WorkingDirectory, expandEnv, absolutePrefix(rootPath))
+}
+
+// GetEnvironment returns the environment variables of the script, as "KEY=value" sorted by key
+func (s *ScriptSection) GetEnvironment() (env []string) {
+	if s == nil || len(s.Environment) == 0 {
+		return
+	}
+	keys := maps.Keys(s.Environment)
+	sort.Strings(keys)
+	for _, key := range keys {
+		// env variables are always uppercase
+		env = append(env, fmt.Sprintf("%s=%s", strings.ToUpper(key), s.Environment[key].Value()))
+	}
+	return
+}
+
+// Content returns the content of the script, loading it from File when set
+func (s *ScriptSection) Content() (string, error) {
+	if s.IsEmpty() {
+		return "", errors.New("script has no content")
+	}
+	if s.Run != "" && s.File != "" {
+		return "", errors.New(`"run" and "file" cannot be used at the same time in a script`)
+	}
+	if s.File != "" {
+		content, err := os.ReadFile(s.File)
+		if err != nil {
+			return "", fmt.Errorf("cannot read script: %w", err)
+		}
+		return string(content), nil
+	}
+	return s.Run, nil
+}
+
+// ParseScriptReference returns the name of the script and its arguments when the shell command references a script,
+// e.g. "script:dump-db mydb /tmp/dump.sql". Arguments are returned unchanged (as a single string) to keep their quotes.
+func ParseScriptReference(shellCommand string) (name, arguments string, found bool) {
+	shellCommand = strings.TrimSpace(shellCommand)
+	if !strings.HasPrefix(shellCommand, ScriptPrefix) {
+		return
+	}
+	reference := strings.TrimSpace(strings.TrimPrefix(shellCommand, ScriptPrefix))
+	name, arguments, _ = strings.Cut(reference, " ")
+	return name, strings.TrimSpace(arguments), name != ""
+}
+
+// GetScript returns the script of this name (or nil when not defined)
+func (p *Profile) GetScript(name string) *ScriptSection {
+	if script, found := p.Scripts[name]; found && !script.IsEmpty() {
+		return script
+	}
+	return nil
+}
+
+// scriptDecoder allows to declare a script with its content only: "scripts.hello = 'echo hello'"
+func scriptDecoder() func(from reflect.Type, to reflect.Type, data any) (any, error) {
+	scriptType := reflect.TypeOf(ScriptSection{})
+
+	return func(from reflect.Type, to reflect.Type, data any) (any, error) {
+		if to == scriptType && from.Kind() == reflect.String {
+			return map[string]any{"run": data}, nil
+		}
+		return data, nil
+	}
+}
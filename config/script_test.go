@@ -0,0 +1,93 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseScriptReference(t *testing.T) {
+	fixtures := []struct {
+		command, name, arguments string
+		found                    bool
+	}{
+		{command: "echo hello"},
+		{command: "script:"},
+		{command: "script:hello", name: "hello", found: true},
+		{command: "  script:hello  ", name: "hello", found: true},
+		{command: "script: dump-db mydb '/tmp/my dump.sql' ", name: "dump-db", arguments: "mydb '/tmp/my dump.sql'", found: true},
+	}
+	for _, fixture := range fixtures {
+		t.Run(fixture.command, func(t *testing.T) {
+			name, arguments, found := ParseScriptReference(fixture.command)
+			assert.Equal(t, fixture.found, found)
+			assert.Equal(t, fixture.name, name)
+			assert.Equal(t, fixture.arguments, arguments)
+		})
+	}
+}
+
+func TestScriptContent(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "script.sh")
+	require.NoError(t, os.WriteFile(file, []byte("echo from file\n"), 0o600))
+
+	content, err := (&ScriptSection{Run: "echo inline"}).Content()
+	assert.NoError(t, err)
+	assert.Equal(t, "echo inline", content)
+
+	content, err = (&ScriptSection{File: file}).Content()
+	assert.NoError(t, err)
+	assert.Equal(t, "echo from file\n", content)
+
+	_, err = (&ScriptSection{Run: "echo inline", File: file}).Content()
+	assert.Error(t, err)
+	_, err = (&ScriptSection{File: file + ".missing"}).Content()
+	assert.Error(t, err)
+	_, err = (*ScriptSection)(nil).Content()
+	assert.Error(t, err)
+}
+
+func TestLoadScripts(t *testing.T) {
+	content := `
+[profile]
+repository = "test"
+run-before = ["script:hello world"]
+
+[profile.scripts]
+hello = "echo hello $1"
+
+[profile.scripts.dump]
+file = "dump.sh"
+parameters = ["database", "output"]
+working-directory = "dumps"
+
+[profile.scripts.dump.env]
+pguser = "backup"
+pgport = 5432
+
+[profile.scripts.empty]
+description = "nothing to run"
+`
+	c, err := Load(bytes.NewBufferString(content), FormatTOML)
+	require.NoError(t, err)
+	profile, err := c.GetProfile("profile")
+	require.NoError(t, err)
+	profile.SetRootPath("/root/path")
+
+	require.NotNil(t, profile.GetScript("hello"))
+	assert.Equal(t, "echo hello $1", profile.GetScript("hello").Run)
+	require.NotNil(t, profile.GetScript("dump"))
+	assert.Equal(t, filepath.FromSlash("/root/path/dump.sh"), profile.GetScript("dump").File)
+	assert.Equal(t, []string{"database", "output"}, profile.GetScript("dump").Parameters)
+	assert.Equal(t, filepath.FromSlash("/root/path/dumps"), profile.GetScript("dump").WorkingDirectory)
+	assert.Equal(t, []string{"PGPORT=5432", "PGUSER=backup"}, profile.GetScript("dump").GetEnvironment())
+	assert.Empty(t, profile.GetScript("hello").WorkingDirectory)
+	assert.Empty(t, profile.GetScript("hello").GetEnvironment())
+
+	assert.Nil(t, profile.GetScript("empty"))
+	assert.Nil(t, profile.GetScript("unknown"))
+}
@@ -0,0 +1,122 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/creativeprojects/resticprofile/secret"
+	"github.com/creativeprojects/resticprofile/util"
+)
+
+// PasswordVaultSection is the password of the repository kept in HashiCorp Vault
+type PasswordVaultSection struct {
+	Address   string `mapstructure:"address" examples:"https://vault.example.com:8200" description:"Address of the vault (VAULT_ADDR when not set)"`
+	Path      string `mapstructure:"path" examples:"secret/data/backup;kv/backup" description:"Path of the secret in the vault (\"secret/data/name\" for a KV version 2 engine mounted on \"secret\")"`
+	Field     string `mapstructure:"field" default:"password" description:"Field of the secret holding the password"`
+	Auth      string `mapstructure:"auth" default:"token" enum:"token;approle" description:"Authentication to the vault: \"token\" (VAULT_TOKEN or the token of \"vault login\") or \"approle\" (VAULT_ROLE_ID and VAULT_SECRET_ID)"`
+	Namespace string `mapstructure:"namespace" description:"Namespace of the secret (Vault Enterprise, VAULT_NAMESPACE when not set)"`
+}
+
+func (v *PasswordVaultSection) provider() secret.Vault {
+	return secret.Vault{
+		Address:   v.Address,
+		Path:      v.Path,
+		Field:     v.Field,
+		Auth:      v.Auth,
+		Namespace: v.Namespace,
+	}
+}
+
+// ResolveSecrets replaces the references to a secret (op://, bw:// and pass://) found in the environment variables
+// of the profile and of the maintainer by the value of the secret. The public representation of the value is the reference
+// (unless already hidden). The password of the repository kept in HashiCorp Vault (password-vault) is then given to restic
+// in a temporary password-file.
+func (p *Profile) ResolveSecrets(resolver *secret.Resolver) error {
+	if p == nil {
+		return nil
+	}
+	// the command line tools of the password managers get the other variables of the profile (e.g. BW_SESSION)
+	env, err := resolveEnvironment(resolver, p.Environment, os.Environ())
+	if err != nil {
+		return fmt.Errorf("profile '%s': env %w", p.Name, err)
+	}
+	if p.Maintainer != nil {
+		if _, err = resolveEnvironment(resolver, p.Maintainer.Environment, env); err != nil {
+			return fmt.Errorf("profile '%s': maintainer env %w", p.Name, err)
+		}
+	}
+
+	if p.PasswordVault != nil {
+		// the token of the vault can also be read from a password manager
+		password, err := resolver.Read(p.PasswordVault.provider(), env)
+		if err != nil {
+			return fmt.Errorf("profile '%s': password-vault: %w", p.Name, err)
+		}
+		if p.PasswordFile, err = writePasswordFile(password); err != nil {
+			return fmt.Errorf("profile '%s': password-vault: %w", p.Name, err)
+		}
+		p.PasswordCommand = ""
+	}
+	return nil
+}
+
+// resolveEnvironment replaces the references to a secret found in the environment variables. The variables are added to env,
+// which is given to the command line tools reading the secrets.
+func resolveEnvironment(resolver *secret.Resolver, environment map[string]ConfidentialValue, env []string) ([]string, error) {
+	references := make([]string, 0)
+	for name, value := range environment {
+		if secret.IsReference(value.Value()) {
+			references = append(references, name)
+			continue
+		}
+		env = append(env, strings.ToUpper(name)+"="+value.Value())
+	}
+	sort.Strings(references)
+
+	for _, name := range references {
+		current := environment[name]
+		value, err := resolver.Resolve(current.Value(), env)
+		if err != nil {
+			return env, fmt.Errorf("%s: %w", strings.ToUpper(name), err)
+		}
+		environment[name] = ConfidentialValue{public: current.String(), confidential: value}
+		env = append(env, strings.ToUpper(name)+"="+value)
+	}
+	return env, nil
+}
+
+// writePasswordFile writes the password in a new file of the temporary directory, only readable by the user.
+// The file is removed with the temporary directory at the end of the run.
+func writePasswordFile(password string) (string, error) {
+	file, err := util.CreateTempFile("password-*")
+	if err != nil {
+		return "", fmt.Errorf("cannot create the password file: %w", err)
+	}
+	defer file.Close()
+	if err = file.Chmod(0o600); err != nil {
+		return "", fmt.Errorf("cannot protect the password file: %w", err)
+	}
+	if _, err = file.WriteString(password); err != nil {
+		return "", fmt.Errorf("cannot write the password file: %w", err)
+	}
+	return file.Name(), nil
+}
+
+// GetPasswordVaultIssues returns the issues with the password of the repository kept in HashiCorp Vault
+func (p *Profile) GetPasswordVaultIssues() (issues []string) {
+	if p.PasswordVault == nil {
+		return
+	}
+	if strings.Trim(p.PasswordVault.Path, "/") == "" {
+		issues = append(issues, "password-vault: path of the secret is missing")
+	}
+	if p.PasswordFile != "" {
+		issues = append(issues, "password-vault: password-file is replaced by the password from the vault")
+	}
+	if p.PasswordCommand != "" {
+		issues = append(issues, "password-vault: password-command is replaced by the password from the vault")
+	}
+	return
+}
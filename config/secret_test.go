@@ -0,0 +1,154 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/creativeprojects/resticprofile/constants"
+	"github.com/creativeprojects/resticprofile/platform"
+	"github.com/creativeprojects/resticprofile/secret"
+	"github.com/creativeprojects/resticprofile/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/exp/slices"
+)
+
+func TestResolveSecrets(t *testing.T) {
+	testConfig := `
+[profile]
+[profile.env]
+RESTIC_PASSWORD = "op://vault/restic/password"
+AWS_ACCESS_KEY_ID = "pass://aws/access-key"
+BW_SESSION = "session"
+OTHER = "value"
+`
+	profile, err := getProfile("toml", testConfig, "profile", "")
+	require.NoError(t, err)
+
+	var sessions []string
+	resolver := secret.NewResolver(func(name string, args []string, env []string) ([]byte, error) {
+		for _, variable := range env {
+			if variable == "BW_SESSION=session" {
+				sessions = append(sessions, name)
+			}
+		}
+		switch name {
+		case "op":
+			return []byte("password"), nil
+		case "pass":
+			return []byte("access-key\n"), nil
+		}
+		return nil, errors.New("unexpected command")
+	})
+	require.NoError(t, profile.ResolveSecrets(resolver))
+
+	password := profile.Environment["restic_password"]
+	assert.Equal(t, "password", password.Value())
+	assert.Equal(t, ConfidentialReplacement, password.String())
+
+	accessKey := profile.Environment["aws_access_key_id"]
+	assert.Equal(t, "access-key", accessKey.Value())
+	assert.Equal(t, "pass://aws/access-key", accessKey.String())
+	assert.Equal(t, []string{"access-key"}, accessKey.confidentialParts())
+
+	assert.Equal(t, "value", profile.Environment["other"].Value())
+	// the other variables of the profile are given to the command line tools
+	assert.Equal(t, []string{"pass", "op"}, sessions)
+}
+
+func TestResolveSecretsError(t *testing.T) {
+	profile := NewProfile(nil, "profile")
+	profile.Environment = map[string]ConfidentialValue{"restic_password": NewConfidentialValue("pass://restic")}
+	resolver := secret.NewResolver(func(string, []string, []string) ([]byte, error) {
+		return nil, errors.New("not found")
+	})
+	err := profile.ResolveSecrets(resolver)
+	assert.EqualError(t, err, `profile 'profile': env RESTIC_PASSWORD: cannot read secret "pass://restic": not found`)
+}
+
+func TestResolveMaintainerSecrets(t *testing.T) {
+	testConfig := `
+[profile]
+[profile.env]
+BW_SESSION = "session"
+[profile.maintainer.env]
+AWS_SECRET_ACCESS_KEY = "op://vault/maintainer/secret-key"
+AWS_ACCESS_KEY_ID = "maintainer"
+`
+	profile, err := getProfile("toml", testConfig, "profile", "")
+	require.NoError(t, err)
+	require.NotNil(t, profile.Maintainer)
+
+	resolver := secret.NewResolver(func(name string, args []string, env []string) ([]byte, error) {
+		if name == "op" && slices.Contains(env, "BW_SESSION=session") {
+			return []byte("secret-key"), nil
+		}
+		return nil, errors.New("unexpected command")
+	})
+	require.NoError(t, profile.ResolveSecrets(resolver))
+
+	secretKey := profile.Maintainer.Environment["aws_secret_access_key"]
+	assert.Equal(t, "secret-key", secretKey.Value())
+	assert.Equal(t, ConfidentialReplacement, secretKey.String())
+	assert.Equal(t, []string{"AWS_ACCESS_KEY_ID=maintainer", "AWS_SECRET_ACCESS_KEY=secret-key"}, profile.GetMaintainerEnvironment(constants.CommandForget))
+
+	profile.Maintainer.Environment["aws_secret_access_key"] = NewConfidentialValue("pass://missing")
+	err = profile.ResolveSecrets(secret.NewResolver(func(string, []string, []string) ([]byte, error) {
+		return nil, errors.New("not found")
+	}))
+	assert.EqualError(t, err, `profile 'profile': maintainer env AWS_SECRET_ACCESS_KEY: cannot read secret "pass://missing": not found`)
+}
+
+func TestResolvePasswordVault(t *testing.T) {
+	defer util.ClearTempDir()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/secret/data/backup" || r.Header.Get("X-Vault-Token") != "token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"data":{"restic":"vault-password"},"metadata":{}}}`))
+	}))
+	defer server.Close()
+
+	testConfig := fmt.Sprintf(`
+[profile]
+password-command = "echo other"
+[profile.password-vault]
+address = %q
+path = "secret/data/backup"
+field = "restic"
+[profile.env]
+VAULT_TOKEN = "pass://vault/token"
+`, server.URL)
+	profile, err := getProfile("toml", testConfig, "profile", "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"password-vault: password-command is replaced by the password from the vault"}, profile.GetPasswordVaultIssues())
+
+	// the token of the vault is read from the password manager
+	resolver := secret.NewResolver(func(name string, args []string, env []string) ([]byte, error) {
+		return []byte("token\n"), nil
+	})
+	require.NoError(t, profile.ResolveSecrets(resolver))
+
+	assert.Empty(t, profile.PasswordCommand)
+	require.NotEmpty(t, profile.PasswordFile)
+	content, err := os.ReadFile(profile.PasswordFile)
+	require.NoError(t, err)
+	assert.Equal(t, "vault-password", string(content))
+	if !platform.IsWindows() {
+		info, err := os.Stat(profile.PasswordFile)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	}
+	assert.Equal(t, []string{profile.PasswordFile}, flagValues(profile.GetCommandFlags(constants.CommandBackup), "password-file"))
+
+	other := NewProfile(nil, "other")
+	other.PasswordVault = &PasswordVaultSection{Address: server.URL, Path: "secret/data/other"}
+	other.Environment = map[string]ConfidentialValue{"vault_token": NewConfidentialValue("token")}
+	err = other.ResolveSecrets(secret.NewResolver(nil))
+	assert.EqualError(t, err, fmt.Sprintf(`profile 'other': password-vault: cannot read secret "%s/v1/secret/data/other#password": 403 Forbidden`, server.URL))
+}
@@ -13,7 +13,17 @@ import (
 
 // ShowStruct write out to w a human-readable text representation of the orig parameter
 func ShowStruct(w io.Writer, orig any, name string) error {
+	return showStruct(newDisplay(name, w), orig)
+}
+
+// ShowStructWithOrigins is like ShowStruct, adding where each value comes from
+func ShowStructWithOrigins(w io.Writer, orig any, name string, origins ValueOrigins) error {
 	display := newDisplay(name, w)
+	display.origins = origins
+	return showStruct(display, orig)
+}
+
+func showStruct(display *Display, orig any) error {
 	err := showSubStruct([]string{}, display, orig)
 	if err != nil {
 		return err
@@ -187,3 +187,20 @@ func TestUnsupportedMapShowStruct(t *testing.T) {
 	err := ShowStruct(b, input, "invalid")
 	assert.Error(t, err)
 }
+
+func TestShowStructWithOrigins(t *testing.T) {
+	input := testObject{Id: 11, Name: "test", Person: testPerson{Name: "bob"}}
+	origins := ValueOrigins{
+		"id":     "profiles.yaml",
+		"person": "profile 'parent' (profiles.yaml)",
+	}
+	b := &strings.Builder{}
+	assert.NoError(t, ShowStructWithOrigins(b, input, "test", origins))
+	assert.Equal(t, `test:
+    id:    11    # profiles.yaml
+    name:  test  
+
+    person:
+        name:  bob  # profile 'parent' (profiles.yaml)
+`, b.String())
+}
@@ -0,0 +1,55 @@
+package config
+
+import "strings"
+
+const (
+	// defaultStorageCurrency is the currency of the prices when none is specified
+	defaultStorageCurrency = "USD"
+	// BackendLocal is the backend of the repositories on the local filesystem
+	BackendLocal = "local"
+)
+
+// StorageCostSection contains the prices of the storage of a repository, to estimate the cost of the backups
+type StorageCostSection struct {
+	PricePerGB         float64 `mapstructure:"price-per-gb" description:"Price of 1 GB (1024³ bytes) stored for a month"`
+	PricePerOperations float64 `mapstructure:"price-per-1000-operations" description:"Price of 1000 write operations (PUT requests) on the backend"`
+	Currency           string  `mapstructure:"currency" default:"USD" examples:"USD;EUR;GBP" description:"Currency of the prices, displayed in the reports"`
+}
+
+func (s *StorageCostSection) IsEmpty() bool {
+	return s == nil || (s.PricePerGB == 0 && s.PricePerOperations == 0)
+}
+
+// GetCurrency returns the currency of the prices ("USD" when not specified)
+func (s *StorageCostSection) GetCurrency() string {
+	if s == nil || s.Currency == "" {
+		return defaultStorageCurrency
+	}
+	return s.Currency
+}
+
+// GetRepositoryBackend returns the type of backend of a repository ("s3", "b2", "sftp", "rest", etc.), or "local"
+func GetRepositoryBackend(repository string) string {
+	if strings.HasPrefix(repository, localPrefix) {
+		return BackendLocal
+	}
+	if scheme := remoteRepository.FindString(repository); scheme != "" {
+		return strings.ToLower(strings.TrimSuffix(scheme, ":"))
+	}
+	return BackendLocal
+}
+
+// GetStorageCost returns the prices of the storage of the repository of the profile: the "storage-cost" section of the
+// profile, or the "storage-costs" of the global section for the backend of the repository. It returns nil without price.
+func (p *Profile) GetStorageCost(global *Global) *StorageCostSection {
+	if !p.StorageCost.IsEmpty() {
+		return p.StorageCost
+	}
+	if global == nil {
+		return nil
+	}
+	if cost := global.StorageCosts[GetRepositoryBackend(p.Repository.Value())]; !cost.IsEmpty() {
+		return cost
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetRepositoryBackend(t *testing.T) {
+	testData := []struct {
+		repository, backend string
+	}{
+		{"", BackendLocal},
+		{"/backup", BackendLocal},
+		{"local:/backup", BackendLocal},
+		{"s3:s3.amazonaws.com/bucket", "s3"},
+		{"b2:bucket:path", "b2"},
+		{"sftp:user@host:/backup", "sftp"},
+		{"rest:https://host:8000/", "rest"},
+		{"rclone:remote:path", "rclone"},
+	}
+	for _, testItem := range testData {
+		t.Run(testItem.repository, func(t *testing.T) {
+			assert.Equal(t, testItem.backend, GetRepositoryBackend(testItem.repository))
+		})
+	}
+}
+
+func TestGetStorageCost(t *testing.T) {
+	global := &Global{StorageCosts: map[string]*StorageCostSection{
+		"s3":  {PricePerGB: 0.023, PricePerOperations: 0.005},
+		"b2":  {PricePerGB: 0.006, Currency: "EUR"},
+		"gs":  {},
+		"foo": nil,
+	}}
+
+	profile := NewProfile(nil, "name")
+	profile.Repository = NewConfidentialValue("s3:s3.amazonaws.com/bucket")
+	assert.Equal(t, 0.023, profile.GetStorageCost(global).PricePerGB)
+	assert.Equal(t, "USD", profile.GetStorageCost(global).GetCurrency())
+
+	profile.Repository = NewConfidentialValue("b2:bucket:path")
+	assert.Equal(t, "EUR", profile.GetStorageCost(global).GetCurrency())
+
+	profile.Repository = NewConfidentialValue("gs:bucket:/")
+	assert.Nil(t, profile.GetStorageCost(global))
+
+	profile.Repository = NewConfidentialValue("/backup")
+	assert.Nil(t, profile.GetStorageCost(global))
+	assert.Nil(t, profile.GetStorageCost(nil))
+
+	profile.StorageCost = &StorageCostSection{PricePerGB: 0.01}
+	assert.Equal(t, 0.01, profile.GetStorageCost(global).PricePerGB)
+	assert.Equal(t, 0.01, profile.GetStorageCost(nil).PricePerGB)
+}
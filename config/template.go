@@ -17,6 +17,7 @@ type TemplateData struct {
 	Profile   ProfileTemplateData
 	Schedule  ScheduleTemplateData
 	ConfigDir string
+	Vars      map[string]string // RESTICPROFILE_VAR_* environment variables
 }
 
 // ProfileTemplateData contains profile data
@@ -47,6 +48,7 @@ func newTemplateData(configFile, profileName, scheduleName string) TemplateData
 			Name: scheduleName,
 		},
 		ConfigDir: configDir,
+		Vars:      templateVariables(),
 	}
 }
 
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/creativeprojects/resticprofile/util/collect"
+)
+
+// configFileFuncs returns template functions reading files from the directory of the configuration file
+//
+// Available functions:
+//   - {{ includeFile "excludes/common.txt" }} => content of the file
+//   - {{ glob "sources/*.txt" }} => ["/path/to/config/sources/a.txt", "/path/to/config/sources/b.txt"]
+//
+// Relative paths are relative to the configuration directory and no file outside of it can be read.
+func configFileFuncs(configFile string) map[string]any {
+	sandbox := newConfigSandbox(configFile)
+	return map[string]any{
+		"includeFile": sandbox.includeFile,
+		"glob":        sandbox.glob,
+	}
+}
+
+type configSandbox struct {
+	root string
+}
+
+func newConfigSandbox(configFile string) *configSandbox {
+	root, err := filepath.Abs(filepath.Dir(configFile))
+	if err != nil {
+		root = filepath.Dir(configFile)
+	}
+	return &configSandbox{root: root}
+}
+
+// resolve returns the absolute path of name, or an error when name is outside of the sandbox
+func (s *configSandbox) resolve(name string) (string, error) {
+	filename := filepath.FromSlash(name)
+	if !filepath.IsAbs(filename) {
+		filename = filepath.Join(s.root, filename)
+	}
+	filename = filepath.Clean(filename)
+
+	// symlinks are resolved (when the file exists) to find where the file really is
+	root, target := s.root, filename
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolved
+	}
+	if resolved, err := filepath.EvalSymlinks(target); err == nil {
+		target = resolved
+	}
+	if !isInDirectory(root, target) {
+		return "", fmt.Errorf("%q is outside of the configuration directory %q", name, filepath.ToSlash(s.root))
+	}
+	return filename, nil
+}
+
+func (s *configSandbox) includeFile(name string) (string, error) {
+	filename, err := s.resolve(name)
+	if err != nil {
+		return "", err
+	}
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+func (s *configSandbox) glob(pattern string) ([]any, error) {
+	absolutePattern, err := s.resolve(pattern)
+	if err != nil {
+		return nil, err
+	}
+	matches, err := filepath.Glob(absolutePattern)
+	if err != nil {
+		return nil, err
+	}
+	// a pattern inside the sandbox can still match a symlink pointing outside
+	matches = collect.All(matches, func(match string) bool {
+		_, err := s.resolve(match)
+		return err == nil
+	})
+	return collect.From(matches, func(match string) any { return filepath.ToSlash(match) }), nil
+}
+
+// isInDirectory returns true when filename is dir or is located somewhere below dir
+func isInDirectory(dir, filename string) bool {
+	relative, err := filepath.Rel(dir, filename)
+	if err != nil {
+		return false
+	}
+	return relative != ".." && !strings.HasPrefix(relative, ".."+string(filepath.Separator)) && !filepath.IsAbs(relative)
+}
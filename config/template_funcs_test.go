@@ -0,0 +1,105 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigSandbox(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "config", "sources"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config", "excludes.txt"), []byte("*.tmp\n*.bak\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config", "sources", "b.src"), []byte("b"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config", "sources", "a.src"), []byte("a"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "secret.txt"), []byte("secret"), 0o644))
+
+	root := filepath.Join(dir, "config")
+	sandbox := newConfigSandbox(filepath.Join(root, "profiles.yaml"))
+
+	t.Run("includeFile", func(t *testing.T) {
+		content, err := sandbox.includeFile("excludes.txt")
+		require.NoError(t, err)
+		assert.Equal(t, "*.tmp\n*.bak\n", content)
+
+		content, err = sandbox.includeFile(filepath.ToSlash(filepath.Join(root, "sources", "a.src")))
+		require.NoError(t, err)
+		assert.Equal(t, "a", content)
+
+		_, err = sandbox.includeFile("missing.txt")
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("includeFile outside", func(t *testing.T) {
+		for _, name := range []string{"../secret.txt", "sources/../../secret.txt", filepath.ToSlash(filepath.Join(dir, "secret.txt"))} {
+			_, err := sandbox.includeFile(name)
+			assert.ErrorContains(t, err, "outside of the configuration directory")
+		}
+	})
+
+	t.Run("glob", func(t *testing.T) {
+		matches, err := sandbox.glob("sources/*.src")
+		require.NoError(t, err)
+		assert.Equal(t, []any{
+			filepath.ToSlash(filepath.Join(root, "sources", "a.src")),
+			filepath.ToSlash(filepath.Join(root, "sources", "b.src")),
+		}, matches)
+
+		matches, err = sandbox.glob("*.none")
+		require.NoError(t, err)
+		assert.Empty(t, matches)
+
+		_, err = sandbox.glob("../*.txt")
+		assert.ErrorContains(t, err, "outside of the configuration directory")
+	})
+
+	t.Run("symlink outside", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("symlinks need extra privileges on Windows")
+		}
+		require.NoError(t, os.Symlink(filepath.Join(dir, "secret.txt"), filepath.Join(root, "sources", "link.src")))
+		defer os.Remove(filepath.Join(root, "sources", "link.src"))
+
+		_, err := sandbox.includeFile("sources/link.src")
+		assert.ErrorContains(t, err, "outside of the configuration directory")
+
+		matches, err := sandbox.glob("sources/*.src")
+		require.NoError(t, err)
+		assert.Len(t, matches, 2)
+	})
+}
+
+func TestIncludeFileAndGlobInConfiguration(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "backup.d"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "backup.d", "1.src"), []byte("/home\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "backup.d", "2.src"), []byte("/etc\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "excludes.txt"), []byte("*.tmp\n*.bak\n"), 0o644))
+
+	configFile := filepath.Join(dir, "profiles.yaml")
+	content := `version: "1"
+profile:
+  backup:
+    source:
+    {{- range glob "backup.d/*.src" }}
+      - "{{ includeFile . | trim }}"
+    {{- end }}
+    exclude:
+    {{- range includeFile "excludes.txt" | trim | split "\n" }}
+      - "{{ . }}"
+    {{- end }}
+`
+	require.NoError(t, os.WriteFile(configFile, []byte(content), 0o644))
+
+	config, err := LoadFile(configFile, "")
+	require.NoError(t, err)
+	profile, err := config.GetProfile("profile")
+	require.NoError(t, err)
+	require.NotNil(t, profile.Backup)
+	assert.Equal(t, []string{"/home", "/etc"}, profile.Backup.Source)
+	assert.Equal(t, []string{"*.tmp", "*.bak"}, profile.Backup.Exclude)
+}
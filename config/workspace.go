@@ -0,0 +1,83 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/creativeprojects/resticprofile/filesearch"
+	"golang.org/x/exp/maps"
+)
+
+// Workspace is a directory of independent configuration files.
+// Each file has its own global section, profiles, groups and schedules: a profile (or group) name
+// can only be defined in one file of the workspace.
+type Workspace struct {
+	dir     string
+	configs []*Config
+	owners  map[string]*Config // file defining each profile and group
+}
+
+// LoadWorkspace loads all the configuration files of the directory.
+// Leave format blank for auto-detection from the file extensions
+func LoadWorkspace(dir, format string) (*Workspace, error) {
+	files, err := filesearch.FindConfigurationDirFiles(dir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read configuration directory: %w", err)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no configuration file found in %s", dir)
+	}
+
+	workspace := &Workspace{
+		dir:    dir,
+		owners: make(map[string]*Config),
+	}
+	for _, file := range files {
+		config, err := LoadFile(file, format)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		if err = workspace.add(config); err != nil {
+			return nil, err
+		}
+	}
+	return workspace, nil
+}
+
+// add registers the profiles and groups of the configuration, failing when another file already defines them
+func (w *Workspace) add(config *Config) error {
+	names := make([]string, 0)
+	for _, name := range config.GetProfileNames() {
+		// abstract profiles are only inherited within their own file
+		if !config.IsAbstractProfile(name) {
+			names = append(names, name)
+		}
+	}
+	names = append(names, maps.Keys(config.GetProfileGroups())...)
+	sort.Strings(names)
+
+	for _, name := range names {
+		if owner, found := w.owners[name]; found && owner != config {
+			return fmt.Errorf("profile or group %q is defined in both %s and %s", name, owner.GetConfigFile(), config.GetConfigFile())
+		}
+		w.owners[name] = config
+	}
+	w.configs = append(w.configs, config)
+	return nil
+}
+
+// GetDir returns the directory of the workspace
+func (w *Workspace) GetDir() string {
+	return w.dir
+}
+
+// GetConfigs returns the configuration of each file of the workspace (sorted by file name)
+func (w *Workspace) GetConfigs() []*Config {
+	return w.configs
+}
+
+// FindConfig returns the configuration defining the profile or group
+func (w *Workspace) FindConfig(name string) (config *Config, found bool) {
+	config, found = w.owners[name]
+	return
+}
@@ -0,0 +1,112 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeWorkspaceFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+	}
+	return dir
+}
+
+func TestLoadWorkspace(t *testing.T) {
+	dir := writeWorkspaceFiles(t, map[string]string{
+		"home.toml": `
+version = "1"
+[global]
+scheduler = "crond"
+[base]
+abstract = true
+repository = "local:/backup/home"
+[home]
+inherit = "base"
+`,
+		"server.yaml": `
+version: "2"
+profiles:
+  base:
+    abstract: true
+    repository: "local:/backup/server"
+  web:
+    inherit: base
+  db:
+    inherit: base
+groups:
+  server:
+    profiles: [web, db]
+`,
+		"README.md": "not a configuration file",
+	})
+
+	workspace, err := LoadWorkspace(dir, "")
+	require.NoError(t, err)
+	assert.Equal(t, dir, workspace.GetDir())
+	require.Len(t, workspace.GetConfigs(), 2)
+	assert.Equal(t, filepath.Join(dir, "home.toml"), workspace.GetConfigs()[0].GetConfigFile())
+	assert.Equal(t, filepath.Join(dir, "server.yaml"), workspace.GetConfigs()[1].GetConfigFile())
+
+	for name, file := range map[string]string{"home": "home.toml", "web": "server.yaml", "server": "server.yaml"} {
+		c, found := workspace.FindConfig(name)
+		require.True(t, found, name)
+		assert.Equal(t, filepath.Join(dir, file), c.GetConfigFile())
+	}
+	_, found := workspace.FindConfig("other")
+	assert.False(t, found)
+
+	// each file keeps its own global section and profiles
+	c, _ := workspace.FindConfig("home")
+	global, err := c.GetGlobalSection()
+	require.NoError(t, err)
+	assert.Equal(t, "crond", global.Scheduler)
+	profile, err := c.GetProfile("home")
+	require.NoError(t, err)
+	assert.Equal(t, "local:/backup/home", profile.Repository.String())
+	assert.False(t, c.HasProfile("web"))
+}
+
+func TestLoadWorkspaceCollision(t *testing.T) {
+	t.Run("profile", func(t *testing.T) {
+		dir := writeWorkspaceFiles(t, map[string]string{
+			"a.toml": "[home]\nrepository = \"local:/a\"\n",
+			"b.toml": "[home]\nrepository = \"local:/b\"\n",
+		})
+		_, err := LoadWorkspace(dir, "")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `"home" is defined in both`)
+	})
+
+	t.Run("group and profile", func(t *testing.T) {
+		dir := writeWorkspaceFiles(t, map[string]string{
+			"a.toml": "[home]\nrepository = \"local:/a\"\n",
+			"b.yaml": "version: \"2\"\nprofiles:\n  web: {}\ngroups:\n  home:\n    profiles: [web]\n",
+		})
+		_, err := LoadWorkspace(dir, "")
+		require.Error(t, err)
+	})
+
+	t.Run("abstract profiles", func(t *testing.T) {
+		dir := writeWorkspaceFiles(t, map[string]string{
+			"a.yaml": "version: \"2\"\nprofiles:\n  base:\n    abstract: true\n  a:\n    inherit: base\n",
+			"b.yaml": "version: \"2\"\nprofiles:\n  base:\n    abstract: true\n  b:\n    inherit: base\n",
+		})
+		_, err := LoadWorkspace(dir, "")
+		assert.NoError(t, err)
+	})
+}
+
+func TestLoadEmptyWorkspace(t *testing.T) {
+	_, err := LoadWorkspace(t.TempDir(), "")
+	assert.ErrorContains(t, err, "no configuration file found")
+
+	_, err = LoadWorkspace(filepath.Join(t.TempDir(), "missing"), "")
+	assert.Error(t, err)
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+
+	"github.com/creativeprojects/resticprofile/config"
+)
+
+// configFromStdin is the value of the --config flag reading the configuration from stdin
+const configFromStdin = "-"
+
+// tomlSectionPattern matches a TOML section header line like "[profile]" or "[profile.backup]"
+var tomlSectionPattern = regexp.MustCompile(`(?m)^\s*\[{1,2}[\w.\-"]+\]{1,2}\s*$`)
+
+// readConfigContent reads the whole configuration from stdin
+func readConfigContent(stdin io.Reader) ([]byte, error) {
+	content, err := io.ReadAll(stdin)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read configuration from stdin: %w", err)
+	}
+	if len(bytes.TrimSpace(content)) == 0 {
+		return nil, errors.New("no configuration received on stdin")
+	}
+	return content, nil
+}
+
+// guessConfigFormat returns the format of a configuration without file name
+func guessConfigFormat(content []byte) string {
+	trimmed := bytes.TrimSpace(content)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		return config.FormatJSON
+	case tomlSectionPattern.Match(trimmed):
+		return config.FormatTOML
+	default:
+		return config.FormatYAML
+	}
+}
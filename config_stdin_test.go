@@ -0,0 +1,35 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadConfigContent(t *testing.T) {
+	content, err := readConfigContent(strings.NewReader("version: \"2\"\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "version: \"2\"\n", string(content))
+
+	_, err = readConfigContent(strings.NewReader(" \n"))
+	assert.Error(t, err)
+}
+
+func TestGuessConfigFormat(t *testing.T) {
+	testCases := []struct {
+		content string
+		format  string
+	}{
+		{`{"version": "2"}`, config.FormatJSON},
+		{"version = \"1\"\n[profile]\nrepository = \"local:/backup\"\n", config.FormatTOML},
+		{"[profile.backup]\nsource = \"/\"\n", config.FormatTOML},
+		{"version: \"2\"\nprofiles:\n  profile:\n    repository: local:/backup\n", config.FormatYAML},
+		{"profile:\n  source: [ \"/home\" ]\n", config.FormatYAML},
+	}
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.format, guessConfigFormat([]byte(testCase.content)), testCase.content)
+	}
+}
@@ -3,6 +3,7 @@ package constants
 // Restic commands
 const (
 	CommandBackup    = "backup"
+	CommandCat       = "cat"
 	CommandCheck     = "check"
 	CommandForget    = "forget"
 	CommandInit      = "init"
@@ -11,10 +12,12 @@ const (
 	CommandUnlock    = "unlock"
 	CommandMount     = "mount"
 	CommandCopy      = "copy"
+	CommandDiff      = "diff"
 	CommandDump      = "dump"
 	CommandFind      = "find"
 	CommandLs        = "ls"
 	CommandRestore   = "restore"
 	CommandStats     = "stats"
 	CommandTag       = "tag"
+	CommandList      = "list"
 )
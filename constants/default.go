@@ -5,6 +5,7 @@ import "time"
 // Configuration defaults
 const (
 	DefaultConfigurationFile    = "profiles"
+	DefaultsFileName            = "defaults" // machine-wide defaults file (without extension)
 	DefaultProfileName          = "default"
 	DefaultCommand              = "snapshots"
 	DefaultFilterResticFlags    = true
@@ -19,4 +20,7 @@ const (
 	DefaultQuietFlag            = false
 	DefaultMinMemory            = 100
 	DefaultSenderTimeout        = 30 * time.Second
+	DefaultCapturedOutputLimit  = 64
+	DefaultAttachOutputSize     = 16
+	DefaultAttachOutputField    = "output"
 )
@@ -5,7 +5,36 @@ const (
 	EnvProfileCommand   = "PROFILE_COMMAND"
 	EnvError            = "ERROR"
 	EnvErrorMessage     = "ERROR_MESSAGE"
+	EnvErrorCode        = "ERROR_CODE"
 	EnvErrorCommandLine = "ERROR_COMMANDLINE"
 	EnvErrorExitCode    = "ERROR_EXIT_CODE"
 	EnvErrorStderr      = "ERROR_STDERR"
+	EnvConfigIssues     = "CONFIG_ISSUES"
+	EnvErrorOutput      = "ERROR_OUTPUT"
+	EnvQuotaStatus      = "QUOTA_STATUS"
+	EnvQuotaUsed        = "QUOTA_USED"
+	EnvQuotaLimit       = "QUOTA_LIMIT"
+	EnvQuotaPercent     = "QUOTA_PERCENT"
+
+	EnvAnomaly             = "ANOMALY"
+	EnvAnomalyFilesAverage = "ANOMALY_FILES_AVERAGE"
+	EnvAnomalySizeAverage  = "ANOMALY_SIZE_AVERAGE"
+
+	EnvSummaryDuration         = "SUMMARY_DURATION"
+	EnvSummarySnapshotID       = "SUMMARY_SNAPSHOT_ID"
+	EnvSummaryFilesNew         = "SUMMARY_FILES_NEW"
+	EnvSummaryFilesChanged     = "SUMMARY_FILES_CHANGED"
+	EnvSummaryFilesUnmodified  = "SUMMARY_FILES_UNMODIFIED"
+	EnvSummaryDirsNew          = "SUMMARY_DIRS_NEW"
+	EnvSummaryDirsChanged      = "SUMMARY_DIRS_CHANGED"
+	EnvSummaryDirsUnmodified   = "SUMMARY_DIRS_UNMODIFIED"
+	EnvSummaryFilesTotal       = "SUMMARY_FILES_TOTAL"
+	EnvSummaryBytesAdded       = "SUMMARY_BYTES_ADDED"
+	EnvSummaryBytesTotal       = "SUMMARY_BYTES_TOTAL"
+	EnvSummarySnapshotsKept    = "SUMMARY_SNAPSHOTS_KEPT"
+	EnvSummarySnapshotsRemoved = "SUMMARY_SNAPSHOTS_REMOVED"
+	EnvSummaryCheckErrors      = "SUMMARY_CHECK_ERRORS"
+
+	EnvResticRestUsername = "RESTIC_REST_USERNAME"
+	EnvResticRestPassword = "RESTIC_REST_PASSWORD"
 )
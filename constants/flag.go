@@ -2,6 +2,7 @@ package constants
 
 // resticprofile flag
 const (
-	FlagAsChild = "as-child"
-	FlagPort    = "parent-port"
+	FlagAsChild      = "as-child"
+	FlagPort         = "parent-port"
+	FlagScheduleName = "schedule-name"
 )
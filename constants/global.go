@@ -12,6 +12,7 @@ const (
 	SchedulerWindows = "taskscheduler"
 	SchedulerSystemd = "systemd"
 	SchedulerCrond   = "crond"
+	SchedulerCrontab = "crontab"
 )
 
 var (
@@ -39,8 +40,24 @@ const (
 	ScheduleLockModeOptionIgnore = "ignore"
 )
 
+// Interrupt policy config options
 const (
-	ExitCodeSuccess = 0
-	ExitCodeError   = 1
-	ExitCodeWarning = 3
+	InterruptPolicyOptionForward = "forward"
+	InterruptPolicyOptionWait    = "wait"
+	InterruptPolicyOptionExit    = "exit"
+)
+
+// Exit codes of resticprofile (ExitCodeWarning is also the exit code of restic when some source files could not be read)
+const (
+	ExitCodeSuccess       = 0
+	ExitCodeError         = 1
+	ExitCodeCommandLine   = 2
+	ExitCodeWarning       = 3
+	ExitCodeConfiguration = 4
+	ExitCodeLocked        = 5
+	ExitCodeHook          = 6
+	// ExitCodeUpdateAvailable is returned by "self-update --check-only" when a newer version is available
+	ExitCodeUpdateAvailable = 7
+	// ExitCodeMemoryLimit is returned when restic was interrupted for using more memory than max-memory
+	ExitCodeMemoryLimit = 8
 )
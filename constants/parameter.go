@@ -22,4 +22,5 @@ const (
 	ParameterPasswordFile    = "password-file"
 	ParameterPasswordCommand = "password-command"
 	ParameterKeyHint         = "key-hint"
+	ParameterNoLock          = "no-lock"
 )
@@ -2,17 +2,22 @@ package constants
 
 // Section
 const (
+	SectionConfigurationAbstract    = "abstract"
 	SectionConfigurationDescription = "description"
 	SectionConfigurationGlobal      = "global"
 	SectionConfigurationRetention   = "retention"
+	SectionConfigurationVerify      = "verify-restore"
 	SectionConfigurationEnvironment = "env"
 	SectionConfigurationGroups      = "groups"
 	SectionConfigurationIncludes    = "includes"
 	SectionConfigurationInherit     = "inherit"
+	SectionConfigurationForeach     = "foreach"
+	SectionConfigurationForeachFile = "foreach-file"
 	SectionConfigurationProfiles    = "profiles"
 	SectionConfigurationSchedules   = "schedules"
 	SectionConfigurationMixins      = "mixins"
 	SectionConfigurationMixinUse    = "use"
+	SectionConfigurationAllProfiles = "all-profiles" // only in the machine-wide defaults file
 
 	SectionDefinitionCommon = "common"
 	SectionDefinitionForget = "forget"
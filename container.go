@@ -0,0 +1,519 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/calendar"
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/constants"
+	"github.com/creativeprojects/resticprofile/filesearch"
+	"github.com/creativeprojects/resticprofile/monitor/status"
+	"github.com/creativeprojects/resticprofile/term"
+)
+
+const (
+	// containerConfigEnv is the environment variable containing the whole configuration in container mode
+	containerConfigEnv = "RESTICPROFILE_CONFIG"
+	// defaultHealthListen is the address of the health endpoints in container mode
+	defaultHealthListen = ":8080"
+	// containerStopTimeout is the time given to the running job to stop before it's killed
+	containerStopTimeout = 5 * time.Minute
+)
+
+// containerConfigContent returns the configuration given in the environment, or on stdin when there is no configuration file.
+// It returns nil when the configuration file should be loaded as usual.
+func containerConfigContent(flags commandLineFlags, stdin io.Reader, stdinIsTerminal bool) ([]byte, error) {
+	if flags.config != constants.DefaultConfigurationFile {
+		return nil, nil // explicit configuration file
+	}
+	if content := os.Getenv(containerConfigEnv); strings.TrimSpace(content) != "" {
+		clog.Debugf("loading configuration from environment variable %s", containerConfigEnv)
+		return []byte(content), nil
+	}
+	if _, err := filesearch.FindConfigurationFile(flags.config); err == nil || stdinIsTerminal {
+		return nil, nil
+	}
+	content, err := io.ReadAll(stdin)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read configuration from stdin: %w", err)
+	}
+	if len(bytes.TrimSpace(content)) == 0 {
+		return nil, nil
+	}
+	clog.Debug("loading configuration from stdin")
+	return content, nil
+}
+
+// jsonLogHandler writes one JSON object per log entry (for log collectors reading the output of a container)
+type jsonLogHandler struct {
+	mutex  sync.Mutex
+	output io.Writer
+}
+
+func newJSONLogHandler(output io.Writer) *jsonLogHandler {
+	return &jsonLogHandler{output: output}
+}
+
+func (h *jsonLogHandler) LogEntry(entry clog.LogEntry) error {
+	record := struct {
+		Time    string `json:"time"`
+		Level   string `json:"level"`
+		Message string `json:"message"`
+	}{
+		Time:    time.Now().Format(time.RFC3339Nano),
+		Level:   strings.ToLower(strings.TrimSpace(entry.Level.String())),
+		Message: entry.GetMessage(),
+	}
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	_, err = h.output.Write(append(line, '\n'))
+	return err
+}
+
+func setupJSONLogger(flags commandLineFlags) {
+	clog.SetDefaultLogger(newFilteredLogger(flags, newJSONLogHandler(os.Stdout)))
+}
+
+// containerJob is a scheduled command of a profile run by the container scheduler
+type containerJob struct {
+	schedule   *config.ScheduleConfig
+	events     []*calendar.Event
+	location   *time.Location
+	statusFile string
+	Profile    string     `json:"profile"`
+	Command    string     `json:"command"`
+	Next       time.Time  `json:"next"`
+	LastStart  *time.Time `json:"last_start,omitempty"`
+	LastEnd    *time.Time `json:"last_end,omitempty"`
+	LastError  string     `json:"last_error,omitempty"`
+	Runs       int        `json:"runs"`
+	Failures   int        `json:"failures"`
+}
+
+// nextRun returns the first trigger of the job after the minute of the time
+func (j *containerJob) nextRun(after time.Time) time.Time {
+	after = after.In(j.location).Truncate(time.Minute).Add(time.Minute)
+	next := time.Time{}
+	for _, event := range j.events {
+		if run := event.Next(after); !run.IsZero() && (next.IsZero() || run.Before(next)) {
+			next = run
+		}
+	}
+	return next
+}
+
+// failed returns true when the last run of the job failed
+func (j *containerJob) failed() bool {
+	return j.LastError != ""
+}
+
+// containerScheduler runs the scheduled commands of all the profiles, one at a time
+type containerScheduler struct {
+	mutex   sync.Mutex
+	jobs    []*containerJob
+	started time.Time
+	running *containerJob
+	// start runs the job in a child process: the process is stopped by cancelling the context
+	start func(ctx context.Context, job *containerJob) error
+	// load returns the configuration reloaded when a signal is received from reload (daemon mode)
+	load   func() (*config.Config, error)
+	reload <-chan os.Signal
+	// saveStatus writes the state of the jobs in the status file of their profile (daemon mode)
+	saveStatus bool
+}
+
+// newContainerScheduler prepares the jobs of the schedules of all the profiles
+func newContainerScheduler(c *config.Config) (*containerScheduler, error) {
+	jobs, err := newContainerJobs(c)
+	if err != nil {
+		return nil, err
+	}
+	return &containerScheduler{started: time.Now(), jobs: jobs}, nil
+}
+
+// newContainerJobs returns the jobs of the schedules of all the profiles
+func newContainerJobs(c *config.Config) ([]*containerJob, error) {
+	var jobs []*containerJob
+	names := c.GetProfileNames()
+	sort.Strings(names)
+	for _, name := range names {
+		if c.IsAbstractProfile(name) {
+			continue
+		}
+		profile, err := c.GetProfile(name)
+		if err != nil {
+			return nil, fmt.Errorf("cannot load profile '%s': %w", name, err)
+		}
+		for _, schedule := range profile.Schedules() {
+			job := &containerJob{
+				schedule:   schedule,
+				statusFile: profile.StatusFile,
+				Profile:    schedule.Title,
+				Command:    schedule.SubTitle,
+			}
+			if job.location, err = schedule.GetTimezone(); err != nil {
+				return nil, fmt.Errorf("profile '%s', command %s: %w", name, schedule.SubTitle, err)
+			}
+			for _, definition := range schedule.Schedules {
+				event := calendar.NewEvent()
+				if err = event.Parse(definition); err != nil {
+					return nil, fmt.Errorf("profile '%s', command %s: %w", name, schedule.SubTitle, err)
+				}
+				job.events = append(job.events, event)
+			}
+			jobs = append(jobs, job)
+		}
+	}
+	return jobs, nil
+}
+
+// nextJob returns the job to run next (nil when no job will ever run again).
+// A job that became due while another job was running is started right after it.
+func (s *containerScheduler) nextJob(now time.Time) *containerJob {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	var next *containerJob
+	for _, job := range s.jobs {
+		if job.Next.IsZero() {
+			job.Next = job.nextRun(now)
+		}
+		if !job.Next.IsZero() && (next == nil || job.Next.Before(next.Next)) {
+			next = job
+		}
+	}
+	return next
+}
+
+// run triggers the jobs until the context is cancelled
+func (s *containerScheduler) run(ctx context.Context) error {
+	if len(s.jobs) == 0 {
+		return errors.New("no schedule found in any profile")
+	}
+	s.logJobs()
+	defer s.writeStatus(true)
+	for {
+		job := s.nextJob(time.Now())
+		s.writeStatus(false)
+		var timer *time.Timer
+		var trigger <-chan time.Time
+		if job != nil {
+			clog.Debugf("next job %s/%s at %s", job.Profile, job.Command, job.Next.Format(time.RFC3339))
+			timer = time.NewTimer(time.Until(job.Next))
+			trigger = timer.C
+		} else if s.reload == nil {
+			return errors.New("none of the schedules will trigger again")
+		} else {
+			clog.Warning("none of the schedules will trigger again: waiting for the configuration to be reloaded")
+		}
+		select {
+		case <-ctx.Done():
+			stopTimer(timer)
+			return nil
+		case <-s.reload:
+			stopTimer(timer)
+			s.reloadConfig()
+			continue
+		case <-trigger:
+		}
+		s.runJob(ctx, job)
+		if ctx.Err() != nil {
+			return nil
+		}
+	}
+}
+
+// stopTimer stops the timer when there's one
+func stopTimer(timer *time.Timer) {
+	if timer != nil {
+		timer.Stop()
+	}
+}
+
+// logJobs displays the schedules of the jobs
+func (s *containerScheduler) logJobs() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, job := range s.jobs {
+		clog.Infof("scheduled job %s/%s: %s", job.Profile, job.Command, strings.Join(job.schedule.Schedules, ", "))
+	}
+}
+
+// reloadConfig replaces the jobs with the schedules of the reloaded configuration.
+// The jobs still scheduled keep their state (last run, failures); the configuration in use is kept when it cannot be reloaded.
+func (s *containerScheduler) reloadConfig() {
+	if s.load == nil {
+		return
+	}
+	clog.Info("reloading the configuration")
+	c, err := s.load()
+	if err == nil {
+		var jobs []*containerJob
+		if jobs, err = newContainerJobs(c); err == nil {
+			s.writeStatus(true) // some profiles may not be scheduled anymore
+			s.replaceJobs(jobs)
+			s.logJobs()
+			return
+		}
+	}
+	clog.Errorf("cannot reload the configuration, the schedules are not changed: %v", err)
+}
+
+// replaceJobs replaces the jobs of the scheduler, keeping the state of the jobs of the same profile and command
+func (s *containerScheduler) replaceJobs(jobs []*containerJob) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	previous := make(map[string]*containerJob, len(s.jobs))
+	for _, job := range s.jobs {
+		previous[job.Profile+"/"+job.Command] = job
+	}
+	for i, job := range jobs {
+		if existing, found := previous[job.Profile+"/"+job.Command]; found {
+			existing.schedule, existing.events, existing.location, existing.statusFile = job.schedule, job.events, job.location, job.statusFile
+			existing.Next = time.Time{} // schedules may have changed
+			jobs[i] = existing
+		}
+	}
+	s.jobs = jobs
+}
+
+// writeStatus saves the state of the jobs in the status file of their profile, or removes it when the scheduler is stopped
+func (s *containerScheduler) writeStatus(stopped bool) {
+	if !s.saveStatus {
+		return
+	}
+	s.mutex.Lock()
+	files := make(map[string]map[string]*status.DaemonStatus)
+	for _, job := range s.jobs {
+		if job.statusFile == "" {
+			continue
+		}
+		if files[job.statusFile] == nil {
+			files[job.statusFile] = make(map[string]*status.DaemonStatus)
+		}
+		daemon := files[job.statusFile][job.Profile]
+		if daemon == nil {
+			daemon = &status.DaemonStatus{PID: os.Getpid(), Started: s.started, Schedules: make(map[string]*status.DaemonSchedule)}
+			files[job.statusFile][job.Profile] = daemon
+		}
+		daemon.Schedules[job.Command] = &status.DaemonSchedule{
+			Next:      job.Next,
+			Running:   s.running == job,
+			LastStart: job.LastStart,
+		}
+	}
+	s.mutex.Unlock()
+
+	for filename, profiles := range files {
+		err := status.NewStatus(filename).Update(func(state *status.Status) {
+			for name, daemon := range profiles {
+				if stopped {
+					state.Profile(name).DaemonStopped()
+				} else {
+					state.Profile(name).DaemonUpdated(daemon)
+				}
+			}
+		})
+		if err != nil {
+			clog.Warningf("saving status file '%s': %v", filename, err)
+		}
+	}
+}
+
+// runJob runs the job and records the result
+func (s *containerScheduler) runJob(ctx context.Context, job *containerJob) {
+	s.mutex.Lock()
+	s.running = job
+	start := time.Now()
+	job.LastStart = &start
+	s.mutex.Unlock()
+	s.writeStatus(false)
+
+	clog.Infof("starting job %s/%s", job.Profile, job.Command)
+	err := s.start(ctx, job)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.running = nil
+	end := time.Now()
+	job.LastEnd = &end
+	job.Runs++
+	job.LastError = ""
+	job.Next = job.nextRun(end)
+	if err != nil {
+		job.Failures++
+		job.LastError = err.Error()
+		clog.Errorf("job %s/%s failed: %v", job.Profile, job.Command, err)
+		return
+	}
+	clog.Infof("job %s/%s finished successfully", job.Profile, job.Command)
+}
+
+// containerStatus is the state of the scheduler returned by the /status endpoint
+type containerStatus struct {
+	Healthy bool           `json:"healthy"`
+	Started time.Time      `json:"started"`
+	Running string         `json:"running,omitempty"`
+	Jobs    []containerJob `json:"jobs"`
+}
+
+// status returns a copy of the state of the scheduler: it's not healthy when the last run of a job failed
+func (s *containerScheduler) status() containerStatus {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	status := containerStatus{
+		Healthy: true,
+		Started: s.started,
+		Jobs:    make([]containerJob, 0, len(s.jobs)),
+	}
+	if s.running != nil {
+		status.Running = s.running.Profile + "/" + s.running.Command
+	}
+	for _, job := range s.jobs {
+		if job.failed() {
+			status.Healthy = false
+		}
+		status.Jobs = append(status.Jobs, *job)
+	}
+	return status
+}
+
+// handler returns the health endpoints: "/healthz" is always OK while the scheduler is running,
+// "/status" returns the state of the jobs with a 503 status code when the last run of a job failed
+func (s *containerScheduler) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(resp http.ResponseWriter, req *http.Request) {
+		resp.Header().Set("Content-Type", "text/plain")
+		_, _ = resp.Write([]byte("ok\n"))
+	})
+	mux.HandleFunc("/status", func(resp http.ResponseWriter, req *http.Request) {
+		status := s.status()
+		data, err := json.Marshal(status)
+		if err != nil {
+			http.Error(resp, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		resp.Header().Set("Content-Type", "application/json")
+		if !status.Healthy {
+			resp.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_, _ = resp.Write(data)
+	})
+	return mux
+}
+
+// containerJobCommand returns a function starting the jobs as child processes: the command line is the one of a scheduled job,
+// in container mode (JSON logs) when enabled and with the configuration passed in the environment when it didn't come from a file
+func containerJobCommand(flags commandLineFlags, content []byte, format string, reaper *orphanReaper) func(ctx context.Context, job *containerJob) error {
+	return func(ctx context.Context, job *containerJob) error {
+		binary, err := os.Executable()
+		if err != nil {
+			return err
+		}
+		var args []string
+		if flags.container {
+			args = append(args, "--container")
+		}
+		if len(content) > 0 {
+			args = append(args, "--format", format)
+		}
+		jobArgs := scheduledJobArgs(job.schedule)
+		for i := 0; i < len(jobArgs); i++ {
+			if jobArgs[i] == "--config" && len(content) > 0 {
+				i++ // the configuration is in the environment
+				continue
+			}
+			args = append(args, jobArgs[i])
+		}
+		if flags.verbose {
+			args = append([]string{"--verbose"}, args...)
+		}
+
+		cmd := exec.Command(binary, args...)
+		cmd.Env = os.Environ()
+		if len(content) > 0 {
+			cmd.Env = append(cmd.Env, containerConfigEnv+"="+string(content))
+		}
+		cmd.Stdout = term.GetOutput()
+		cmd.Stderr = term.GetErrorOutput()
+
+		reaper.childStarting()
+		defer reaper.childEnded()
+		if err = cmd.Start(); err != nil {
+			return err
+		}
+		done := make(chan error, 1)
+		go func() { done <- cmd.Wait() }()
+		select {
+		case err = <-done:
+			return err
+		case <-ctx.Done():
+		}
+		clog.Infof("stopping job %s/%s", job.Profile, job.Command)
+		if err = cmd.Process.Signal(syscall.SIGTERM); err != nil {
+			_ = cmd.Process.Kill() // no SIGTERM on Windows
+		}
+		select {
+		case err = <-done:
+		case <-time.After(containerStopTimeout):
+			_ = cmd.Process.Kill()
+			err = <-done
+		}
+		return err
+	}
+}
+
+// runContainer runs the scheduler of container mode until the process receives a termination signal
+func runContainer(c *config.Config, flags commandLineFlags, content []byte, format string) int {
+	reaper := newOrphanReaper()
+	if os.Getpid() == 1 {
+		clog.Debug("running as PID 1: orphaned processes will be reaped")
+		reaper.start()
+	}
+
+	scheduler, err := newContainerScheduler(c)
+	if err != nil {
+		clog.Error(err)
+		return constants.ExitCodeConfiguration
+	}
+	scheduler.start = containerJobCommand(flags, content, format, reaper)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if flags.healthListen != "" {
+		server := &http.Server{Addr: flags.healthListen, Handler: scheduler.handler(), ReadHeaderTimeout: 10 * time.Second}
+		go func() {
+			clog.Infof("health endpoints listening on %s", flags.healthListen)
+			if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				clog.Errorf("health endpoints: %v", err)
+			}
+		}()
+		defer server.Close()
+	}
+
+	if err = scheduler.run(ctx); err != nil {
+		clog.Error(err)
+		return constants.ExitCodeConfiguration
+	}
+	clog.Info("container scheduler stopped")
+	return 0
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"sync"
+)
+
+// orphanReaper collects the exit status of the orphaned processes re-parented to resticprofile when it's running as PID 1.
+// The orphans are only reaped when no job is running: the exit status of the jobs belongs to the scheduler.
+type orphanReaper struct {
+	mutex    sync.Mutex
+	children int
+}
+
+func newOrphanReaper() *orphanReaper {
+	return &orphanReaper{}
+}
+
+// childStarting must be called before starting a child process
+func (r *orphanReaper) childStarting() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.children++
+}
+
+// childEnded must be called after the exit status of the child process has been collected
+func (r *orphanReaper) childEnded() {
+	r.mutex.Lock()
+	r.children--
+	r.mutex.Unlock()
+	r.reap()
+}
+
+// reap collects the zombie processes when no child process is running
+func (r *orphanReaper) reap() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.children > 0 {
+		return
+	}
+	reapOrphans()
+}
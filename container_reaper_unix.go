@@ -0,0 +1,33 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/creativeprojects/clog"
+)
+
+// start reaps the orphans each time a child process exits
+func (r *orphanReaper) start() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGCHLD)
+	go func() {
+		for range signals {
+			r.reap()
+		}
+	}()
+}
+
+func reapOrphans() {
+	for {
+		var status syscall.WaitStatus
+		pid, err := syscall.Wait4(-1, &status, syscall.WNOHANG, nil)
+		if err != nil || pid <= 0 {
+			return
+		}
+		clog.Debugf("reaped orphaned process %d", pid)
+	}
+}
@@ -0,0 +1,8 @@
+//go:build windows
+
+package main
+
+// start does nothing: there's no zombie process on Windows
+func (r *orphanReaper) start() {}
+
+func reapOrphans() {}
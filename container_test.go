@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContainerConfigContent(t *testing.T) {
+	// no configuration file in the current directory
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(t.TempDir()))
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+	flags := commandLineFlags{config: constants.DefaultConfigurationFile}
+
+	t.Run("environment", func(t *testing.T) {
+		t.Setenv(containerConfigEnv, "version: \"2\"")
+		content, err := containerConfigContent(flags, strings.NewReader("from stdin"), false)
+		require.NoError(t, err)
+		assert.Equal(t, "version: \"2\"", string(content))
+	})
+
+	t.Run("stdin", func(t *testing.T) {
+		content, err := containerConfigContent(flags, strings.NewReader("from stdin"), false)
+		require.NoError(t, err)
+		assert.Equal(t, "from stdin", string(content))
+
+		content, err = containerConfigContent(flags, strings.NewReader("from stdin"), true)
+		require.NoError(t, err)
+		assert.Nil(t, content, "stdin is a terminal")
+
+		content, err = containerConfigContent(flags, strings.NewReader("\n"), false)
+		require.NoError(t, err)
+		assert.Nil(t, content, "empty stdin")
+	})
+
+	t.Run("configuration file", func(t *testing.T) {
+		t.Setenv(containerConfigEnv, "version: \"2\"")
+		content, err := containerConfigContent(commandLineFlags{config: "other.yaml"}, strings.NewReader("from stdin"), false)
+		require.NoError(t, err)
+		assert.Nil(t, content)
+	})
+}
+
+func TestJSONLogHandler(t *testing.T) {
+	output := &bytes.Buffer{}
+	logger := clog.NewLogger(newJSONLogHandler(output))
+	logger.Warningf("message %d", 1)
+
+	record := map[string]string{}
+	require.NoError(t, json.Unmarshal(output.Bytes(), &record))
+	assert.Equal(t, "warn", record["level"])
+	assert.Equal(t, "message 1", record["message"])
+	assert.NotEmpty(t, record["time"])
+}
+
+func newTestContainerScheduler(t *testing.T) *containerScheduler {
+	t.Helper()
+	c, err := config.Load(bytes.NewBufferString(`
+version: "2"
+profiles:
+  base:
+    abstract: true
+    backup:
+      schedule: daily
+  home:
+    backup:
+      schedule: "*-*-* 02:00"
+    check:
+      schedule: ["Mon 03:00", "Thu 03:00"]
+  web:
+    backup:
+      source: /var/www
+`), config.FormatYAML)
+	require.NoError(t, err)
+	scheduler, err := newContainerScheduler(c)
+	require.NoError(t, err)
+	return scheduler
+}
+
+func TestContainerSchedulerJobs(t *testing.T) {
+	scheduler := newTestContainerScheduler(t)
+	require.Len(t, scheduler.jobs, 2)
+
+	// Monday 2 March 2026
+	now := time.Date(2026, time.March, 2, 1, 0, 0, 0, time.Local)
+	job := scheduler.nextJob(now)
+	require.NotNil(t, job)
+	assert.Equal(t, "home/backup", job.Profile+"/"+job.Command)
+	assert.Equal(t, time.Date(2026, time.March, 2, 2, 0, 0, 0, time.Local), job.Next)
+
+	scheduler.start = func(ctx context.Context, job *containerJob) error {
+		return errors.New("exit status 1")
+	}
+	scheduler.runJob(context.Background(), job)
+	assert.Equal(t, 1, job.Runs)
+	assert.Equal(t, 1, job.Failures)
+	assert.Equal(t, "exit status 1", job.LastError)
+	assert.True(t, job.Next.After(now.Add(23*time.Hour)))
+
+	// the check was due while the backup was running
+	job = scheduler.nextJob(now.Add(3 * time.Hour))
+	assert.Equal(t, "home/check", job.Profile+"/"+job.Command)
+	assert.Equal(t, time.Date(2026, time.March, 2, 3, 0, 0, 0, time.Local), job.Next)
+}
+
+func TestContainerSchedulerHandler(t *testing.T) {
+	scheduler := newTestContainerScheduler(t)
+	server := httptest.NewServer(scheduler.handler())
+	defer server.Close()
+
+	get := func(path string) (int, string) {
+		t.Helper()
+		resp, err := http.Get(server.URL + path)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body := &bytes.Buffer{}
+		_, _ = body.ReadFrom(resp.Body)
+		return resp.StatusCode, body.String()
+	}
+
+	code, body := get("/healthz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ok\n", body)
+
+	code, body = get("/status")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Contains(t, body, `"healthy":true`)
+	assert.Contains(t, body, `"profile":"home","command":"check"`)
+
+	scheduler.jobs[0].LastError = "exit status 1"
+	code, body = get("/status")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Contains(t, body, `"healthy":false`)
+	assert.Contains(t, body, `"last_error":"exit status 1"`)
+}
+
+func TestContainerSchedulerRun(t *testing.T) {
+	scheduler := newTestContainerScheduler(t)
+	assert.NoError(t, scheduler.run(canceledContext()))
+
+	scheduler.jobs = nil
+	assert.ErrorContains(t, scheduler.run(context.Background()), "no schedule found")
+}
+
+func canceledContext() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	return ctx
+}
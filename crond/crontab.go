@@ -4,8 +4,10 @@ package crond
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"os/exec"
 	"regexp"
@@ -19,6 +21,8 @@ const (
 
 type Crontab struct {
 	entries []Entry
+	binary  string
+	file    string
 }
 
 var (
@@ -28,9 +32,25 @@ var (
 func NewCrontab(entries []Entry) *Crontab {
 	return &Crontab{
 		entries: entries,
+		binary:  crontabBinary,
 	}
 }
 
+// SetBinary sets the crontab command used to load and save the crontab (when not empty)
+func (c *Crontab) SetBinary(binary string) *Crontab {
+	if binary != "" {
+		c.binary = binary
+	}
+	return c
+}
+
+// SetFile sets the crontab file (like /etc/cron.d/resticprofile) edited directly instead of
+// loading and saving the crontab of the user with the crontab command (when not empty)
+func (c *Crontab) SetFile(file string) *Crontab {
+	c.file = file
+	return c
+}
+
 // Update crontab entries:
 //
 // If addEntries is set to true, it will delete and add all new entries
@@ -117,8 +137,18 @@ func (c *Crontab) Generate(w io.StringWriter) error {
 }
 
 func (c *Crontab) LoadCurrent() (string, error) {
+	if c.file != "" {
+		content, err := os.ReadFile(c.file)
+		if errors.Is(err, fs.ErrNotExist) {
+			// it's ok to be empty
+			return "", nil
+		} else if err != nil {
+			return "", err
+		}
+		return string(content), nil
+	}
 	buffer := &strings.Builder{}
-	cmd := exec.Command(crontabBinary, "-l")
+	cmd := exec.Command(c.binary, "-l")
 	cmd.Stdout = buffer
 	cmd.Stderr = buffer
 	err := cmd.Run()
@@ -141,15 +171,7 @@ func (c *Crontab) Rewrite() error {
 	if err != nil {
 		return err
 	}
-
-	cmd := exec.Command(crontabBinary, "-")
-	cmd.Stdin = input
-	cmd.Stderr = os.Stderr
-	err = cmd.Run()
-	if err != nil {
-		return err
-	}
-	return nil
+	return c.save(input)
 }
 
 func (c *Crontab) Remove() (int, error) {
@@ -162,15 +184,19 @@ func (c *Crontab) Remove() (int, error) {
 	if err != nil {
 		return num, err
 	}
+	return num, c.save(buffer)
+}
 
-	cmd := exec.Command(crontabBinary, "-")
-	cmd.Stdin = buffer
-	cmd.Stderr = os.Stderr
-	err = cmd.Run()
-	if err != nil {
-		return num, err
+// save writes the crontab file, or loads the content as the crontab of the user
+func (c *Crontab) save(content *bytes.Buffer) error {
+	if c.file != "" {
+		// cron ignores the files writable by group or others
+		return os.WriteFile(c.file, content.Bytes(), 0o644)
 	}
-	return num, nil
+	cmd := exec.Command(c.binary, "-")
+	cmd.Stdin = content
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
 }
 
 func cleanupCrontab(crontab string) string {
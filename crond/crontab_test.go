@@ -5,6 +5,7 @@ package crond
 import (
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -168,3 +169,32 @@ func TestLoadCurrent(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "", result)
 }
+
+func TestCrontabFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "resticprofile")
+	entry := NewEntry(calendar.NewEvent(func(event *calendar.Event) {
+		event.Minute.MustAddValue(1)
+		event.Hour.MustAddValue(1)
+	}), "config.yaml", "profile", "backup", "/opt/resticprofile --no-ansi --config config.yaml --name profile backup", "").WithUser("root")
+
+	crontab := NewCrontab([]Entry{entry}).SetFile(file)
+	current, err := crontab.LoadCurrent()
+	require.NoError(t, err)
+	assert.Equal(t, "", current)
+
+	require.NoError(t, crontab.Rewrite())
+	content, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, "\n"+startMarker+"01 01 * * *\troot\t/opt/resticprofile --no-ansi --config config.yaml --name profile backup\n"+endMarker, string(content))
+
+	info, err := os.Stat(file)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o644), info.Mode().Perm())
+
+	deleted, err := crontab.Remove()
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+	content, err = os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, "\n"+startMarker+endMarker, string(content))
+}
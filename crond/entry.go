@@ -18,6 +18,7 @@ type Entry struct {
 	commandName string
 	commandLine string
 	workDir     string
+	user        string
 }
 
 // NewEntry creates a new crontab entry
@@ -32,6 +33,12 @@ func NewEntry(event *calendar.Event, configFile, profileName, commandName, comma
 	}
 }
 
+// WithUser returns a copy of the entry running the command as the user (system crontab format, like in /etc/cron.d)
+func (e Entry) WithUser(user string) Entry {
+	e.user = user
+	return e
+}
+
 // String returns the crontab line representation of the entry (end of line included)
 func (e Entry) String() string {
 	minute, hour, dayOfMonth, month, dayOfWeek := "*", "*", "*", "*", "*"
@@ -55,6 +62,9 @@ func (e Entry) String() string {
 		// don't make ranges for days of the week as it can fail with high sunday (7)
 		dayOfWeek = formatList(e.event.WeekDay.GetRangeValues(), formatWeekDay)
 	}
+	if e.user != "" {
+		return fmt.Sprintf("%s %s %s %s %s\t%s\t%s%s\n", minute, hour, dayOfMonth, month, dayOfWeek, e.user, wd, e.commandLine)
+	}
 	return fmt.Sprintf("%s %s %s %s %s\t%s%s\n", minute, hour, dayOfMonth, month, dayOfWeek, wd, e.commandLine)
 }
 
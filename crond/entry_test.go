@@ -19,6 +19,11 @@ func TestEmptyUserEvent(t *testing.T) {
 	assert.Equal(t, "* * * * *\tcommand line\n", buffer.String())
 }
 
+func TestEntryWithUser(t *testing.T) {
+	entry := NewEntry(calendar.NewEvent(), "", "", "", "command line", "workdir").WithUser("backup")
+	assert.Equal(t, "* * * * *\tbackup\tcd workdir && command line\n", entry.String())
+}
+
 func TestEvents(t *testing.T) {
 	testData := []struct {
 		event    string
@@ -1,12 +1,38 @@
 package main
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/creativeprojects/clog"
 	"github.com/creativeprojects/resticprofile/config"
 )
 
+// getDeprecationNotices returns a message for each deprecated option used in the global section or in the profile
+func getDeprecationNotices(global *config.Global, profile *config.Profile) (notices []string) {
+	if global != nil && global.LegacyArguments {
+		notices = append(notices, `The "legacy-arguments" option in the "global" section is deprecated and will be removed in a future version.`)
+	}
+	if profile != nil && profile.HasDeprecatedRetentionSchedule() {
+		notices = append(notices, `Using a schedule on a "retention" section is deprecated. Please move the schedule parameters to a "forget" section instead.`)
+	}
+	return
+}
+
 func displayProfileDeprecationNotices(profile *config.Profile) {
-	if profile.HasDeprecatedRetentionSchedule() {
-		clog.Warning(`Using a schedule on a "retention" section is deprecated. Please move the schedule parameters to a "forget" section instead.`)
+	displayDeprecationNotices(getDeprecationNotices(nil, profile))
+}
+
+func displayDeprecationNotices(notices []string) {
+	for _, notice := range notices {
+		clog.Warning(notice)
+	}
+}
+
+// checkDeprecationNotices returns an error when the configuration must not use deprecated options (global option "fail-on-deprecation")
+func checkDeprecationNotices(global *config.Global, notices []string) error {
+	if global == nil || !global.FailOnDeprecation || len(notices) == 0 {
+		return nil
 	}
+	return fmt.Errorf("the configuration uses deprecated options (fail-on-deprecation is enabled): %s", strings.Join(notices, " "))
 }
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetDeprecationNotices(t *testing.T) {
+	global := config.NewGlobal()
+	profile := config.NewProfile(nil, "name")
+	assert.Empty(t, getDeprecationNotices(global, profile))
+	assert.Empty(t, getDeprecationNotices(nil, nil))
+
+	global.LegacyArguments = true
+	profile.Retention = &config.RetentionSection{}
+	profile.Retention.Schedule = []string{"daily"}
+	notices := getDeprecationNotices(global, profile)
+	assert.Len(t, notices, 2)
+	assert.Contains(t, notices[0], "legacy-arguments")
+	assert.Contains(t, notices[1], "retention")
+}
+
+func TestCheckDeprecationNotices(t *testing.T) {
+	global := config.NewGlobal()
+	notices := []string{"deprecated option"}
+	assert.NoError(t, checkDeprecationNotices(nil, notices))
+	assert.NoError(t, checkDeprecationNotices(global, notices))
+
+	global.FailOnDeprecation = true
+	assert.NoError(t, checkDeprecationNotices(global, nil))
+	assert.ErrorContains(t, checkDeprecationNotices(global, notices), "deprecated option")
+}
@@ -0,0 +1,149 @@
+// Package exclude converts the exclusion rules of other tools (.gitignore files and borg patterns) into restic exclude patterns
+package exclude
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// GitignoreFile is the name of the file of the git exclusion rules
+const GitignoreFile = ".gitignore"
+
+// Styles of the borg patterns
+const (
+	borgShell      = "sh"
+	borgFnmatch    = "fm"
+	borgRegex      = "re"
+	borgPathPrefix = "pp"
+	borgPathFull   = "pf"
+)
+
+// ReadGitignore converts the rules of the .gitignore file of the directory. There's no pattern when the directory has no .gitignore file.
+func ReadGitignore(dir string) ([]string, error) {
+	file, err := os.Open(filepath.Join(dir, GitignoreFile))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+	return Gitignore(dir, file)
+}
+
+// Gitignore converts the rules of a .gitignore file found in the directory: a rule containing a "/" is anchored to the directory,
+// other rules match at any depth below the directory. Rules matching only directories (ending with "/") also match the files of the same name.
+func Gitignore(dir string, reader io.Reader) (patterns []string, err error) {
+	base := strings.TrimSuffix(filepath.ToSlash(dir), "/")
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = trimTrailingSpaces(line)
+		negate := strings.HasPrefix(line, "!")
+		if negate {
+			line = line[1:]
+		} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+			line = line[1:]
+		}
+		line = strings.TrimSuffix(line, "/")
+		if line == "" {
+			continue
+		}
+		var pattern string
+		if strings.Contains(line, "/") {
+			pattern = path.Join(base, strings.TrimPrefix(line, "/"))
+		} else {
+			pattern = base + "/**/" + line
+		}
+		if negate {
+			pattern = "!" + pattern
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, scanner.Err()
+}
+
+// trimTrailingSpaces removes the spaces at the end of the rule, unless they're escaped with a backslash
+func trimTrailingSpaces(line string) string {
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, `\ `) {
+		line = line[:len(line)-1]
+	}
+	return strings.ReplaceAll(line, `\ `, " ")
+}
+
+// ReadBorg converts the borg patterns of the file
+func ReadBorg(filename string) (patterns, warnings []string, err error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+	return Borg(file)
+}
+
+// Borg converts the patterns of a borg "--exclude-from" or "--patterns-from" file. The rules that cannot be converted
+// (regular expressions and include rules) are skipped with a warning.
+func Borg(reader io.Reader) (patterns, warnings []string, err error) {
+	style := borgShell
+	scanner := bufio.NewScanner(reader)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		// rules of the "--patterns-from" files
+		if len(line) > 2 && line[1] == ' ' && strings.ContainsRune("RPp+-!", rune(line[0])) {
+			value := strings.TrimSpace(line[2:])
+			switch line[0] {
+			case 'R', 'p':
+				// root directories (sources of the backup)
+				continue
+			case 'P':
+				style = value
+				continue
+			case '+':
+				warnings = append(warnings, fmt.Sprintf("line %d: include rule %q is not supported", lineNumber, value))
+				continue
+			}
+			line = value
+		}
+		pattern, convertErr := borgPattern(line, style)
+		if convertErr != nil {
+			warnings = append(warnings, fmt.Sprintf("line %d: %s", lineNumber, convertErr.Error()))
+			continue
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, warnings, scanner.Err()
+}
+
+// borgPattern converts a borg pattern: borg matches the patterns from the root of the filesystem
+func borgPattern(line, style string) (string, error) {
+	if len(line) > 3 && line[2] == ':' {
+		style, line = line[:2], line[3:]
+	}
+	switch style {
+	case borgShell, borgFnmatch, borgPathPrefix, borgPathFull:
+	case borgRegex:
+		return "", fmt.Errorf("regular expression %q is not supported", line)
+	default:
+		return "", fmt.Errorf("unknown style %q of pattern %q", style, line)
+	}
+	line = strings.TrimSuffix(line, "/")
+	if line == "" {
+		return "", errors.New("empty pattern")
+	}
+	if !strings.HasPrefix(line, "/") && !strings.HasPrefix(line, "*") {
+		line = "/" + line
+	}
+	return line, nil
+}
@@ -0,0 +1,91 @@
+package exclude
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitignore(t *testing.T) {
+	content := `# build output
+*.o
+build/
+/vendor
+docs/*.pdf
+!keep.o
+\#notes
+\!important
+` + "trailing   \nescaped\\ \n\n"
+	patterns, err := Gitignore("/home/user/src/", strings.NewReader(content))
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"/home/user/src/**/*.o",
+		"/home/user/src/**/build",
+		"/home/user/src/vendor",
+		"/home/user/src/docs/*.pdf",
+		"!/home/user/src/**/keep.o",
+		"/home/user/src/**/#notes",
+		"/home/user/src/**/!important",
+		"/home/user/src/**/trailing",
+		"/home/user/src/**/escaped ",
+	}, patterns)
+}
+
+func TestReadGitignore(t *testing.T) {
+	dir := t.TempDir()
+	patterns, err := ReadGitignore(dir)
+	require.NoError(t, err)
+	assert.Empty(t, patterns)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, GitignoreFile), []byte("node_modules/\r\n"), 0o600))
+	patterns, err = ReadGitignore(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.ToSlash(dir) + "/**/node_modules"}, patterns)
+}
+
+func TestBorg(t *testing.T) {
+	content := `# exclude-from file
+*.pyc
+home/*/junk
+sh:/home/*/.cache/**
+fm:/tmp/
+pp:root/.ssh
+re:^/home/[^/]+/\.thumbnails/
+xx:unknown
+`
+	patterns, warnings, err := Borg(strings.NewReader(content))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"*.pyc", "/home/*/junk", "/home/*/.cache/**", "/tmp", "/root/.ssh"}, patterns)
+	assert.Equal(t, []string{
+		`line 7: regular expression "^/home/[^/]+/\\.thumbnails/" is not supported`,
+		`line 8: unknown style "xx" of pattern "unknown"`,
+	}, warnings)
+}
+
+func TestBorgPatternsFrom(t *testing.T) {
+	content := `R /home
+P pp
+- /home/user/.cache
++ /home/user/Documents
+! /home/*/Downloads
+P sh
+- home/*/tmp
+- re:\.bak$
+`
+	patterns, warnings, err := Borg(strings.NewReader(content))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/home/user/.cache", "/home/*/Downloads", "/home/*/tmp"}, patterns)
+	assert.Equal(t, []string{
+		`line 4: include rule "/home/user/Documents" is not supported`,
+		`line 8: regular expression "\\.bak$" is not supported`,
+	}, warnings)
+}
+
+func TestReadBorgMissingFile(t *testing.T) {
+	_, _, err := ReadBorg(filepath.Join(t.TempDir(), "patterns"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
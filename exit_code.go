@@ -0,0 +1,139 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/creativeprojects/resticprofile/constants"
+	"github.com/creativeprojects/resticprofile/monitor"
+	"golang.org/x/exp/slices"
+)
+
+// Values of the --exit-code-from flag
+const (
+	exitCodeFromResticprofile = "resticprofile"
+	exitCodeFromRestic        = "restic"
+	exitCodeFromHook          = "hook"
+)
+
+var exitCodeFromValues = []string{exitCodeFromResticprofile, exitCodeFromRestic, exitCodeFromHook}
+
+// checkExitCodeFrom returns an error when the value of the --exit-code-from flag is unknown
+func checkExitCodeFrom(from string) error {
+	if !slices.Contains(exitCodeFromValues, from) {
+		return fmt.Errorf("invalid value %q for flag --exit-code-from (expected one of %v)", from, exitCodeFromValues)
+	}
+	return nil
+}
+
+// exitCodeError is an error with the exit code returned by resticprofile
+type exitCodeError struct {
+	code int
+	err  error
+}
+
+// newExitCodeError returns the error with the exit code of the process, or nil when err is nil
+func newExitCodeError(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &exitCodeError{code: code, err: err}
+}
+
+func (e *exitCodeError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitCodeError) Unwrap() error {
+	return e.err
+}
+
+// getExitCode returns the exit code of the process for the error
+func getExitCode(err error) int {
+	if err == nil {
+		return constants.ExitCodeSuccess
+	}
+	exitErr := &exitCodeError{}
+	if errors.As(err, &exitErr) {
+		return exitErr.code
+	}
+	return constants.ExitCodeError
+}
+
+// commandExitCode returns the exit code of a command from the error it returned (ExitCodeError when not available)
+func commandExitCode(err error) int {
+	if err == nil {
+		return constants.ExitCodeSuccess
+	}
+	if exitErr, ok := asExitError(err); ok && exitErr.ExitCode() > 0 {
+		return exitErr.ExitCode()
+	}
+	return constants.ExitCodeError
+}
+
+// setResticExitCode keeps the exit code of the last restic command
+func (r *resticWrapper) setResticExitCode(err error, output monitor.OutputAnalysis) {
+	r.resticExitCode = commandExitCode(err)
+	r.remoteLocked = err != nil && output != nil && output.ContainsRemoteLockFailure()
+	r.networkFailure = err != nil && output != nil && output.ContainsNetworkFailure()
+}
+
+// errorCode returns the code of the cause of the error (see monitor.ErrorCode), empty when err is nil
+func (r *resticWrapper) errorCode(err error) string {
+	if err == nil {
+		return ""
+	}
+	fail := &commandError{}
+	memoryErr := &memoryLimitError{}
+	switch {
+	case r.configError != nil && errors.Is(err, r.configError):
+		return monitor.ErrorCodeConfig
+	case errors.Is(err, errInterrupted):
+		return monitor.ErrorCodeInterrupted
+	case errors.As(err, &memoryErr):
+		return monitor.ErrorCodeMemoryLimit
+	case errors.As(err, &fail) && fail.hook:
+		return monitor.ErrorCodeHook
+	case errors.As(err, &fail) && r.remoteLocked:
+		return monitor.ErrorCodeLock
+	case errors.As(err, &fail) && r.networkFailure:
+		return monitor.ErrorCodeNetwork
+	case errors.As(err, &fail) && r.resticExitCode > 0:
+		return monitor.ResticErrorCode(r.resticExitCode)
+	}
+	return monitor.ErrorCode(err)
+}
+
+// withExitCode returns the error of the run with the exit code selected by exitCodeFrom
+func (r *resticWrapper) withExitCode(err error) error {
+	if err == nil {
+		return nil
+	}
+	code := constants.ExitCodeError
+	exitErr := &exitCodeError{}
+	fail := &commandError{}
+	memoryErr := &memoryLimitError{}
+	switch {
+	case errors.As(err, &exitErr):
+		code = exitErr.code
+	case errors.As(err, &memoryErr):
+		code = constants.ExitCodeMemoryLimit
+	case r.configError != nil && errors.Is(err, r.configError):
+		code = constants.ExitCodeConfiguration
+	case errors.As(err, &fail) && fail.hook:
+		code = constants.ExitCodeHook
+		if r.exitCodeFrom == exitCodeFromHook {
+			code = commandExitCode(fail.err)
+		}
+	case errors.As(err, &fail):
+		if r.remoteLocked {
+			code = constants.ExitCodeLocked
+		} else if commandExitCode(fail.err) == constants.ExitCodeWarning {
+			code = constants.ExitCodeWarning
+		}
+	}
+	if r.exitCodeFrom == exitCodeFromRestic && r.resticExitCode >= 0 {
+		code = r.resticExitCode
+	}
+	return newExitCodeError(code, monitor.NewCodedError(r.errorCode(err), err))
+}
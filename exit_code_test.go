@@ -0,0 +1,137 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/constants"
+	"github.com/creativeprojects/resticprofile/lock"
+	"github.com/creativeprojects/resticprofile/monitor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckExitCodeFrom(t *testing.T) {
+	for _, from := range []string{"resticprofile", "restic", "hook"} {
+		assert.NoError(t, checkExitCodeFrom(from))
+	}
+	assert.ErrorContains(t, checkExitCodeFrom("other"), `invalid value "other" for flag --exit-code-from`)
+
+	_, _, err := loadFlags([]string{"--exit-code-from", "invalid", "backup"})
+	assert.Error(t, err)
+	_, flags, err := loadFlags([]string{"backup"})
+	require.NoError(t, err)
+	assert.Equal(t, "resticprofile", flags.exitFrom)
+}
+
+func TestGetExitCode(t *testing.T) {
+	assert.Equal(t, 0, getExitCode(nil))
+	assert.Equal(t, 1, getExitCode(errors.New("error")))
+	assert.Equal(t, 4, getExitCode(newExitCodeError(constants.ExitCodeConfiguration, errors.New("error"))))
+	assert.Equal(t, 5, getExitCode(fmt.Errorf("wrapped: %w", newExitCodeError(constants.ExitCodeLocked, errors.New("error")))))
+	assert.Nil(t, newExitCodeError(constants.ExitCodeHook, nil))
+}
+
+func TestWrapperExitCodes(t *testing.T) {
+	fixtures := []struct {
+		name      string
+		args      []string
+		runBefore []string
+		runAfter  []string
+		expected  map[string]int
+		code      string
+	}{
+		{
+			name:     "success",
+			expected: map[string]int{"resticprofile": 0, "restic": 0, "hook": 0},
+		},
+		{
+			name:     "restic failure",
+			args:     []string{"--exit", "12"},
+			expected: map[string]int{"resticprofile": 1, "restic": 12, "hook": 1},
+			code:     "restic-exit-12",
+		},
+		{
+			name:     "restic warning",
+			args:     []string{"--exit", "3"},
+			expected: map[string]int{"resticprofile": 3, "restic": 3, "hook": 3},
+			code:     "restic-exit-3",
+		},
+		{
+			name:      "run-before failure",
+			runBefore: []string{"exit 7"},
+			expected:  map[string]int{"resticprofile": 6, "restic": 6, "hook": 7},
+			code:      monitor.ErrorCodeHook,
+		},
+		{
+			name:     "run-after failure",
+			runAfter: []string{"exit 7"},
+			expected: map[string]int{"resticprofile": 6, "restic": 0, "hook": 7},
+			code:     monitor.ErrorCodeHook,
+		},
+	}
+
+	for _, fixture := range fixtures {
+		for from, expected := range fixture.expected {
+			t.Run(fixture.name+" from "+from, func(t *testing.T) {
+				profile := config.NewProfile(nil, "name")
+				profile.RunBefore = fixture.runBefore
+				profile.RunAfter = fixture.runAfter
+				wrapper := newResticWrapper(nil, mockBinary, false, profile, "snapshots", fixture.args, nil)
+				wrapper.setExitCodeFrom(from)
+				err := wrapper.runProfile()
+				assert.Equal(t, expected, getExitCode(err))
+				assert.Equal(t, fixture.code, monitor.ErrorCode(err))
+			})
+		}
+	}
+}
+
+func TestWrapperConfigurationExitCode(t *testing.T) {
+	profile := config.NewProfile(nil, "name")
+	wrapper := newResticWrapper(nil, mockBinary, false, profile, "snapshots", nil, nil)
+	wrapper.setConfigIssues(nil, errors.New("invalid configuration"))
+	err := wrapper.runProfile()
+	assert.EqualError(t, err, "invalid configuration")
+	assert.Equal(t, constants.ExitCodeConfiguration, getExitCode(err))
+	assert.Equal(t, monitor.ErrorCodeConfig, monitor.ErrorCode(err))
+}
+
+func TestWrapperRemoteLockExitCode(t *testing.T) {
+	profile := config.NewProfile(nil, "name")
+	wrapper := newResticWrapper(nil, mockBinary, false, profile, "snapshots", nil, nil)
+	wrapper.remoteLocked = true
+	err := wrapper.withExitCode(newCommandError(shellCommandDefinition{}, "", errors.New("locked")))
+	assert.Equal(t, constants.ExitCodeLocked, getExitCode(err))
+	assert.Equal(t, monitor.ErrorCodeLock, monitor.ErrorCode(err))
+}
+
+func TestWrapperNetworkErrorCode(t *testing.T) {
+	profile := config.NewProfile(nil, "name")
+	wrapper := newResticWrapper(nil, mockBinary, false, profile, "snapshots", nil, nil)
+	fail := newCommandError(shellCommandDefinition{}, "", errors.New("exit status 1"))
+	assert.Equal(t, monitor.ErrorCodeUnknown, wrapper.errorCode(fail))
+	assert.Empty(t, wrapper.errorCode(nil))
+
+	wrapper.setResticExitCode(fail, &mockOutputAnalysis{network: true})
+	assert.Equal(t, monitor.ErrorCodeNetwork, wrapper.errorCode(fail))
+	assert.Equal(t, monitor.ErrorCodeInterrupted, wrapper.errorCode(errInterrupted))
+}
+
+func TestWrapperLockBusyExitCode(t *testing.T) {
+	lockFile := filepath.Join(t.TempDir(), "profile.lock")
+	runLock := lock.NewLock(lockFile)
+	require.True(t, runLock.TryAcquire())
+	defer runLock.Release()
+
+	profile := config.NewProfile(nil, "name")
+	profile.Lock = lockFile
+	wrapper := newResticWrapper(nil, mockBinary, false, profile, "snapshots", nil, nil)
+	err := wrapper.runProfile()
+	assert.ErrorContains(t, err, "another process is already running this profile")
+	assert.Equal(t, constants.ExitCodeLocked, getExitCode(err))
+	assert.Equal(t, monitor.ErrorCodeLock, monitor.ErrorCode(err))
+}
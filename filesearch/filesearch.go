@@ -12,6 +12,7 @@ import (
 
 	"github.com/adrg/xdg"
 	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/constants"
 	"github.com/creativeprojects/resticprofile/platform"
 )
 
@@ -44,6 +45,11 @@ var (
 		"c:\\resticprofile\\",
 	}
 
+	// defaultsFileLocations are the folders of the machine-wide defaults file
+	defaultsFileLocationsUnix    = []string{"/etc/resticprofile/"}
+	defaultsFileLocationsDarwin  = []string{"/Library/Preferences/resticprofile/", "/etc/resticprofile/"}
+	defaultsFileLocationsWindows = []string{"c:\\ProgramData\\resticprofile\\"}
+
 	resticBinaryUnix    = "restic"
 	resticBinaryWindows = "restic.exe"
 
@@ -158,6 +164,51 @@ func FindConfigurationIncludes(configFile string, includes []string) ([]string,
 	return files, nil
 }
 
+// FindDefaultsFile returns the path of the machine-wide defaults file ("defaults" with any of the configuration
+// extensions), or an empty string when there's none
+func FindDefaultsFile() string {
+	locations := defaultsFileLocationsUnix
+	if platform.IsWindows() {
+		locations = defaultsFileLocationsWindows
+	} else if platform.IsDarwin() {
+		locations = defaultsFileLocationsDarwin
+	}
+	for _, location := range locations {
+		for _, ext := range configurationExtensions {
+			filename := filepath.Join(location, constants.DefaultsFileName+"."+ext)
+			if fileExists(filename) {
+				return filename
+			}
+		}
+	}
+	return ""
+}
+
+// FindConfigurationDirFiles returns the configuration files (by extension) of a directory, sorted by name.
+// Hidden files and sub-directories are ignored.
+func FindConfigurationDirFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") {
+			continue
+		}
+		extension := strings.TrimPrefix(filepath.Ext(name), ".")
+		for _, ext := range configurationExtensions {
+			if extension == ext {
+				clog.Tracef("configuration: %s", name)
+				files = append(files, filepath.Join(dir, name))
+				break
+			}
+		}
+	}
+	return files, nil
+}
+
 // FindResticBinary returns the path of restic executable
 func FindResticBinary(configLocation string) (string, error) {
 	if configLocation != "" {
@@ -169,6 +220,12 @@ func FindResticBinary(configLocation string) (string, error) {
 		if filename != "" && fileExists(filename) {
 			return filename, nil
 		}
+		// a simple name (without any directory) is searched from the PATH
+		if isBinaryName(filename) {
+			if found, err := exec.LookPath(filename); err == nil {
+				return found, nil
+			}
+		}
 		clog.Warningf("cannot find or read the restic binary specified in the configuration: %q", configLocation)
 	}
 	paths := getSearchBinaryLocations()
@@ -188,6 +245,30 @@ func FindResticBinary(configLocation string) (string, error) {
 	return filename, nil
 }
 
+// FindResticBinaryIn searches the restic binary in the directories only. The binary name
+// is taken from configLocation when it's a simple name, otherwise the default name is used.
+// It returns false when configLocation is a path to a file.
+func FindResticBinaryIn(dirs []string, configLocation string) (string, bool) {
+	binaryFile := getResticBinaryName()
+	if configLocation != "" {
+		if !isBinaryName(configLocation) {
+			return "", false
+		}
+		binaryFile = configLocation
+	}
+	for _, dir := range dirs {
+		if filename := filepath.Join(dir, binaryFile); fileExists(filename) {
+			return filename, true
+		}
+	}
+	return "", false
+}
+
+// isBinaryName returns true when filename is a simple name without any directory
+func isBinaryName(filename string) bool {
+	return filename != "" && filepath.Base(filename) == filename && !strings.ContainsAny(filename, `/\`)
+}
+
 // ShellExpand uses the shell to expand variables and ~ from a filename.
 // On Windows the function simply returns the filename unchanged
 func ShellExpand(filename string) (string, error) {
@@ -344,3 +344,80 @@ func TestFindConfigurationIncludes(t *testing.T) {
 		})
 	}
 }
+
+func TestFindConfigurationDirFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"b.yaml", "a.toml", "c.conf", "notes.txt", ".hidden.toml", "d.json", "e.hcl"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte{}, 0o600))
+	}
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "sub.toml"), 0o700))
+
+	files, err := FindConfigurationDirFiles(dir)
+	require.NoError(t, err)
+	expected := []string{"a.toml", "b.yaml", "c.conf", "d.json", "e.hcl"}
+	for i := range expected {
+		expected[i] = filepath.Join(dir, expected[i])
+	}
+	assert.Equal(t, expected, files)
+
+	_, err = FindConfigurationDirFiles(filepath.Join(dir, "not-found"))
+	assert.Error(t, err)
+}
+
+func TestFindDefaultsFile(t *testing.T) {
+	dir := t.TempDir()
+	defer func(unix, darwin, windows []string) {
+		defaultsFileLocationsUnix, defaultsFileLocationsDarwin, defaultsFileLocationsWindows = unix, darwin, windows
+	}(defaultsFileLocationsUnix, defaultsFileLocationsDarwin, defaultsFileLocationsWindows)
+	defaultsFileLocationsUnix = []string{dir}
+	defaultsFileLocationsDarwin = []string{dir}
+	defaultsFileLocationsWindows = []string{dir}
+
+	assert.Empty(t, FindDefaultsFile())
+
+	filename := filepath.Join(dir, "defaults.yaml")
+	require.NoError(t, os.WriteFile(filename, []byte{}, 0o600))
+	assert.Equal(t, filename, FindDefaultsFile())
+}
+
+func TestFindResticBinaryFromPath(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("not supported on Windows")
+		return
+	}
+	dir := t.TempDir()
+	name := "restic-test-binary"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"), 0o755))
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	binary, err := FindResticBinary(name)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, name), binary)
+}
+
+func TestFindResticBinaryIn(t *testing.T) {
+	dir := t.TempDir()
+	binaryFile := getResticBinaryName()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, binaryFile), []byte{}, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "restic-custom"), []byte{}, 0o755))
+
+	fixtures := []struct {
+		dirs           []string
+		configLocation string
+		expected       string
+	}{
+		{dirs: []string{dir}, expected: filepath.Join(dir, binaryFile)},
+		{dirs: []string{t.TempDir(), dir}, expected: filepath.Join(dir, binaryFile)},
+		{dirs: []string{dir}, configLocation: "restic-custom", expected: filepath.Join(dir, "restic-custom")},
+		{dirs: []string{dir}, configLocation: filepath.Join(dir, binaryFile)},
+		{dirs: []string{dir}, configLocation: "not-found"},
+		{dirs: nil},
+	}
+	for _, fixture := range fixtures {
+		t.Run(strings.Join(fixture.dirs, ",")+":"+fixture.configLocation, func(t *testing.T) {
+			binary, found := FindResticBinaryIn(fixture.dirs, fixture.configLocation)
+			assert.Equal(t, fixture.expected != "", found)
+			assert.Equal(t, fixture.expected, binary)
+		})
+	}
+}
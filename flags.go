@@ -13,27 +13,39 @@ import (
 )
 
 type commandLineFlags struct {
-	help        bool
-	quiet       bool
-	verbose     bool
-	veryVerbose bool
-	config      string
-	format      string
-	name        string
-	log         string // file path or log url
-	dryRun      bool
-	noLock      bool
-	lockWait    time.Duration
-	noAnsi      bool
-	theme       string
-	resticArgs  []string
-	selfUpdate  bool
-	wait        bool
-	isChild     bool
-	parentPort  int
-	noPriority  bool
-	run         string
-	usagesHelp  string
+	help         bool
+	quiet        bool
+	verbose      bool
+	veryVerbose  bool
+	config       string
+	configDir    string // directory of independent configuration files
+	format       string
+	name         string
+	defaultName  bool   // no profile name was given on the command line
+	log          string // file path or log url
+	dryRun       bool
+	noLock       bool
+	readOnly     bool // no command or hook can modify the repositories or the system
+	lockWait     time.Duration
+	noAnsi       bool
+	theme        string
+	resticArgs   []string
+	selfUpdate   bool
+	wait         bool
+	isChild      bool
+	parentPort   int
+	noPriority   bool
+	configHash   string // expected hash of the configuration files
+	exitFrom     string // step giving the exit code of a failed run
+	json         bool   // summary of a group run in JSON format
+	profiles     string // run the profiles matching these labels
+	parallel     bool   // run the profiles of a group at the same time
+	continueOn   bool   // continue with the next profiles of a group after a failure
+	run          string
+	usagesHelp   string
+	scheduleName string // "profile/command" of the scheduled job running this command
+	container    bool   // container mode: JSON logs, configuration from the environment or stdin, and internal scheduler
+	healthListen string // address of the health endpoints in container mode
 }
 
 // loadFlags loads command line flags (before any command)
@@ -46,13 +58,16 @@ func loadFlags(args []string) (*pflag.FlagSet, commandLineFlags, error) {
 	flagset.BoolVarP(&flags.quiet, "quiet", "q", constants.DefaultQuietFlag, "display only warnings and errors")
 	flagset.BoolVarP(&flags.verbose, "verbose", "v", constants.DefaultVerboseFlag, "display some debugging information")
 	flagset.BoolVar(&flags.veryVerbose, "trace", constants.DefaultVerboseFlag, "display even more debugging information")
-	flagset.StringVarP(&flags.config, "config", "c", constants.DefaultConfigurationFile, "configuration file")
+	flagset.StringVarP(&flags.config, "config", "c", constants.DefaultConfigurationFile, "configuration file (\"-\" to read it from stdin)")
+	flagset.StringVar(&flags.configDir, "config-dir", "", "directory of independent configuration files (instead of a single configuration file)")
 	flagset.StringVarP(&flags.format, "format", "f", "", "file format of the configuration (default is to use the file extension)")
 	flagset.StringVarP(&flags.name, "name", "n", constants.DefaultProfileName, "profile name")
 	flagset.StringVarP(&flags.log, "log", "l", "", "logs to a target instead of the console")
 
 	flagset.BoolVar(&flags.dryRun, "dry-run", false, "display the restic commands instead of running them")
 
+	flagset.BoolVar(&flags.readOnly, "read-only", false, "only allow the commands that don't modify the repository, and don't run any hook")
+
 	flagset.BoolVar(&flags.noLock, "no-lock", false, "skip profile lock file")
 	flagset.DurationVar(&flags.lockWait, "lock-wait", 0, "wait up to duration to acquire a lock (syntax \"1h5m30s\")")
 
@@ -60,8 +75,24 @@ func loadFlags(args []string) (*pflag.FlagSet, commandLineFlags, error) {
 	flagset.StringVar(&flags.theme, "theme", constants.DefaultTheme, "console colouring theme (dark, light, none)")
 	flagset.BoolVar(&flags.noPriority, "no-prio", false, "don't set any priority on load: used when started from a service that has already set the priority")
 
+	flagset.BoolVar(&flags.json, "json", false, "display the summary of a group run in JSON format")
+	flagset.StringVar(&flags.profiles, "profiles", "", "run all the profiles matching the labels (syntax \"key=value[,key=value...]\")")
+	flagset.BoolVar(&flags.parallel, "parallel", false, "run the profiles of a group (or matching the labels) at the same time")
+	flagset.BoolVar(&flags.continueOn, "continue-on-error", false, "continue with the next profiles of a group (or matching the labels) when a profile fails")
+
 	flagset.BoolVarP(&flags.wait, "wait", "w", false, "wait at the end until the user presses the enter key")
 
+	flagset.StringVar(&flags.exitFrom, "exit-code-from", exitCodeFromResticprofile, "exit code of a failed run: from resticprofile, from the last restic command or from the failed hook (resticprofile, restic, hook)")
+
+	flagset.BoolVar(&flags.container, "container", false, "container mode: logs in JSON, configuration from $RESTICPROFILE_CONFIG or stdin, and schedules run by resticprofile when no command is given")
+	flagset.StringVar(&flags.healthListen, "health-listen", defaultHealthListen, "address of the health endpoints in container mode (empty to disable)")
+
+	flagset.StringVar(&flags.configHash, "expect-config-hash", "", "refuse to run when the hash of the configuration files is different (see \"config hash\" command)")
+
+	// flag for internal use only
+	flagset.StringVar(&flags.scheduleName, constants.FlagScheduleName, "", "name of the scheduled job running the command")
+	_ = flagset.MarkHidden(constants.FlagScheduleName)
+
 	if platform.IsWindows() {
 		// flag for internal use only
 		flagset.BoolVar(&flags.isChild, constants.FlagAsChild, false, "run as an elevated user child process")
@@ -81,9 +112,13 @@ func loadFlags(args []string) (*pflag.FlagSet, commandLineFlags, error) {
 	if err != nil {
 		return flagset, flags, err
 	}
+	if err = checkExitCodeFrom(flags.exitFrom); err != nil {
+		return flagset, flags, err
+	}
 
 	// remaining flags
 	flags.resticArgs = flagset.Args()
+	flags.defaultName = !flagset.Changed("name")
 
 	// if there are no further arguments, no further parsing is needed
 	if len(flags.resticArgs) == 0 {
@@ -116,6 +151,8 @@ func loadFlags(args []string) (*pflag.FlagSet, commandLineFlags, error) {
 		// set profile
 		if len(profile) == 0 {
 			profile = constants.DefaultProfileName
+		} else {
+			flags.defaultName = false
 		}
 		flags.name = profile
 	}
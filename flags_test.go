@@ -119,3 +119,22 @@ func TestProfileCommandTwoPartProfileMissingCommandMissing(t *testing.T) {
 	assert.Equal(t, flags.name, constants.DefaultProfileName)
 	assert.Equal(t, flags.resticArgs, []string{})
 }
+
+func TestDefaultProfileName(t *testing.T) {
+	testData := []struct {
+		args        []string
+		defaultName bool
+	}{
+		{[]string{}, true},
+		{[]string{"backup"}, true},
+		{[]string{".backup"}, true},
+		{[]string{"-n", constants.DefaultProfileName, "backup"}, false},
+		{[]string{"--name", "profile", "backup"}, false},
+		{[]string{"profile.backup"}, false},
+	}
+	for _, testItem := range testData {
+		_, flags, err := loadFlags(testItem.args)
+		require.NoError(t, err)
+		assert.Equalf(t, testItem.defaultName, flags.defaultName, "%v", testItem.args)
+	}
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/monitor"
+	"github.com/creativeprojects/resticprofile/term"
+	"github.com/creativeprojects/resticprofile/util"
+	"github.com/creativeprojects/resticprofile/util/redact"
+)
+
+// Status of a profile in the summary of a group run
+const (
+	groupStatusSuccess = "success"
+	groupStatusWarning = "warning"
+	groupStatusFailed  = "failed"
+	groupStatusSkipped = "skipped"
+)
+
+// groupSummary collects the results of the profiles run by a group
+type groupSummary struct {
+	Group    string                `json:"group"`
+	Command  string                `json:"command"`
+	Start    time.Time             `json:"start"`
+	Duration float64               `json:"duration"`
+	Success  bool                  `json:"success"`
+	Profiles []*groupProfileResult `json:"profiles"`
+}
+
+// groupProfileResult is the result of one profile of the group. It receives the summary of each command run by the profile.
+type groupProfileResult struct {
+	Profile   string               `json:"profile"`
+	Outcome   string               `json:"status"`
+	ExitCode  int                  `json:"exit_code"`
+	Duration  float64              `json:"duration"`
+	Error     string               `json:"error,omitempty"`
+	ErrorCode string               `json:"error_code,omitempty"`
+	Commands  []groupCommandResult `json:"commands,omitempty"`
+	start     time.Time
+}
+
+// groupCommandResult is the result of one command run by a profile
+type groupCommandResult struct {
+	Command   string  `json:"command"`
+	Success   bool    `json:"success"`
+	Warning   bool    `json:"warning,omitempty"`
+	Duration  float64 `json:"duration"`
+	Error     string  `json:"error,omitempty"`
+	ErrorCode string  `json:"error_code,omitempty"`
+}
+
+func newGroupSummary(group, command string, profiles []string) *groupSummary {
+	summary := &groupSummary{
+		Group:    group,
+		Command:  command,
+		Start:    time.Now(),
+		Profiles: make([]*groupProfileResult, len(profiles)),
+	}
+	for i, profile := range profiles {
+		summary.Profiles[i] = &groupProfileResult{Profile: profile, Outcome: groupStatusSkipped}
+	}
+	return summary
+}
+
+// start returns the result receiving the commands of the profile at index
+func (s *groupSummary) start(index int) *groupProfileResult {
+	result := s.Profiles[index]
+	result.start = time.Now()
+	return result
+}
+
+// done sets the outcome of the group
+func (s *groupSummary) done() {
+	s.Duration = time.Since(s.Start).Seconds()
+	s.Success = true
+	for _, result := range s.Profiles {
+		if result.Outcome == groupStatusFailed || result.Outcome == groupStatusSkipped {
+			s.Success = false
+		}
+	}
+}
+
+// count returns the number of profiles having the status
+func (s *groupSummary) count(status string) (count int) {
+	for _, result := range s.Profiles {
+		if result.Outcome == status {
+			count++
+		}
+	}
+	return
+}
+
+// String returns the one line summary of the group run
+func (s *groupSummary) String() string {
+	text := fmt.Sprintf("group '%s': %d profile(s) succeeded", s.Group, s.count(groupStatusSuccess))
+	for _, status := range []string{groupStatusWarning, groupStatusFailed, groupStatusSkipped} {
+		if count := s.count(status); count > 0 {
+			text += fmt.Sprintf(", %d %s", count, status)
+			if status == groupStatusFailed {
+				text += fmt.Sprintf(" (%s)", strings.Join(s.names(status), ", "))
+			}
+		}
+	}
+	return text
+}
+
+// names returns the names of the profiles having the status
+func (s *groupSummary) names(status string) (names []string) {
+	for _, result := range s.Profiles {
+		if result.Outcome == status {
+			names = append(names, result.Profile)
+		}
+	}
+	return
+}
+
+// display writes the table of the results
+func (s *groupSummary) display(output io.Writer) error {
+	_, _ = fmt.Fprintf(output, "\nSummary of group '%s' (%s):\n\n", s.Group, s.Command)
+	w := tabwriter.NewWriter(output, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "  Profile\tCommands\tDuration\tResult")
+	for _, result := range s.Profiles {
+		commands := make([]string, len(result.Commands))
+		for i, command := range result.Commands {
+			commands[i] = command.Command
+		}
+		duration := ""
+		if result.Outcome != groupStatusSkipped {
+			duration = util.FormatDuration((time.Duration(result.Duration * float64(time.Second))).Round(time.Second))
+		}
+		status := result.Outcome
+		if result.Outcome == groupStatusFailed {
+			status = fmt.Sprintf("%s (exit code %d)", status, result.ExitCode)
+		}
+		_, _ = fmt.Fprintf(w, "  %s\t%s\t%s\t%s\n", result.Profile, strings.Join(commands, ", "), duration, status)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(output, "")
+	return err
+}
+
+// done sets the outcome of the profile
+func (r *groupProfileResult) done(err error) {
+	r.Duration = time.Since(r.start).Seconds()
+	r.ExitCode = getExitCode(err)
+	switch {
+	case err != nil:
+		r.Outcome = groupStatusFailed
+		r.Error = redact.String(err.Error())
+		r.ErrorCode = monitor.ErrorCode(err)
+	case r.hasWarning():
+		r.Outcome = groupStatusWarning
+	default:
+		r.Outcome = groupStatusSuccess
+	}
+}
+
+func (r *groupProfileResult) hasWarning() bool {
+	for _, command := range r.Commands {
+		if command.Warning {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *groupProfileResult) Start(string) {}
+
+func (r *groupProfileResult) Status(monitor.Status) {}
+
+func (r *groupProfileResult) Summary(command string, summary monitor.Summary, _ string, result error) {
+	commandResult := groupCommandResult{
+		Command:  command,
+		Success:  monitor.IsSuccess(result),
+		Warning:  monitor.IsWarning(result),
+		Duration: summary.Duration.Seconds(),
+	}
+	if result != nil {
+		commandResult.Error = redact.String(result.Error())
+		commandResult.ErrorCode = summary.ErrorCode
+	}
+	r.Commands = append(r.Commands, commandResult)
+}
+
+var _ monitor.Receiver = &groupProfileResult{}
+
+// displayGroupSummary logs the outcome of the group run, and displays the result of each profile (in JSON with --json)
+func displayGroupSummary(summary *groupSummary, flags commandLineFlags) {
+	if summary.Success {
+		clog.Info(summary.String())
+	} else {
+		clog.Warning(summary.String())
+	}
+	var err error
+	if flags.json {
+		err = writeJSON(term.GetOutput(), summary)
+	} else {
+		err = summary.display(term.GetOutput())
+	}
+	if err != nil {
+		clog.Errorf("cannot display the summary of group '%s': %s", summary.Group, err)
+	}
+}
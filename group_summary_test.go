@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/creativeprojects/resticprofile/constants"
+	"github.com/creativeprojects/resticprofile/monitor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func warningError(t *testing.T) error {
+	t.Helper()
+	err := exec.Command(mockBinary, "test", "--exit", "3").Run()
+	require.Error(t, err)
+	return err
+}
+
+func TestGroupSummary(t *testing.T) {
+	summary := newGroupSummary("full", "backup", []string{"first", "second", "third", "fourth"})
+
+	result := summary.start(0)
+	result.Summary("backup", monitor.Summary{Duration: 2 * time.Second}, "", nil)
+	result.Summary("check", monitor.Summary{Duration: time.Second}, "", nil)
+	result.done(nil)
+
+	result = summary.start(1)
+	result.Summary("backup", monitor.Summary{Duration: time.Second}, "", warningError(t))
+	result.done(nil)
+
+	result = summary.start(2)
+	result.done(newExitCodeError(constants.ExitCodeHook, errors.New("run-before failed")))
+	summary.done()
+
+	assert.False(t, summary.Success)
+	assert.Equal(t, "group 'full': 1 profile(s) succeeded, 1 warning, 1 failed (third), 1 skipped", summary.String())
+	assert.Equal(t, []string{"success", "warning", "failed", "skipped"}, []string{
+		summary.Profiles[0].Outcome, summary.Profiles[1].Outcome, summary.Profiles[2].Outcome, summary.Profiles[3].Outcome,
+	})
+	assert.Equal(t, constants.ExitCodeHook, summary.Profiles[2].ExitCode)
+	assert.Equal(t, "run-before failed", summary.Profiles[2].Error)
+	assert.Equal(t, monitor.ErrorCodeUnknown, summary.Profiles[2].ErrorCode)
+
+	t.Run("table", func(t *testing.T) {
+		buffer := &bytes.Buffer{}
+		require.NoError(t, summary.display(buffer))
+		assert.Equal(t, `
+Summary of group 'full' (backup):
+
+  Profile  Commands       Duration  Result
+  first    backup, check  0s        success
+  second   backup         0s        warning
+  third                   0s        failed (exit code 6)
+  fourth                            skipped
+
+`, buffer.String())
+	})
+
+	t.Run("json", func(t *testing.T) {
+		buffer := &bytes.Buffer{}
+		require.NoError(t, writeJSON(buffer, summary))
+		decoded := map[string]any{}
+		require.NoError(t, json.Unmarshal(buffer.Bytes(), &decoded))
+		assert.Equal(t, "full", decoded["group"])
+		assert.Equal(t, false, decoded["success"])
+		profiles := decoded["profiles"].([]any)
+		require.Len(t, profiles, 4)
+		first := profiles[0].(map[string]any)
+		assert.Equal(t, "success", first["status"])
+		assert.Len(t, first["commands"], 2)
+		assert.Equal(t, float64(6), profiles[2].(map[string]any)["exit_code"])
+	})
+}
+
+func TestGroupSummarySuccess(t *testing.T) {
+	summary := newGroupSummary("full", "check", []string{"first"})
+	summary.start(0).done(nil)
+	summary.done()
+	assert.True(t, summary.Success)
+	assert.Equal(t, "group 'full': 1 profile(s) succeeded", summary.String())
+}
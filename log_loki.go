@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/constants"
+)
+
+const lokiSendTimeout = 10 * time.Second
+
+// lokiSender pushes log records to the HTTP push API of Grafana Loki (e.g. "http://localhost:3100/loki/api/v1/push")
+type lokiSender struct {
+	url    string
+	host   string
+	client *http.Client
+}
+
+func newLokiSender(url string) *lokiSender {
+	host, _ := os.Hostname()
+	return &lokiSender{
+		url:    url,
+		host:   host,
+		client: &http.Client{Timeout: lokiSendTimeout},
+	}
+}
+
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+type lokiPush struct {
+	Streams []*lokiStream `json:"streams"`
+}
+
+// Send pushes the records in one request, with one stream per log level
+func (l *lokiSender) Send(records []logRecord) error {
+	push := lokiPush{}
+	streams := make(map[clog.LogLevel]*lokiStream)
+	for _, record := range records {
+		stream, found := streams[record.Level]
+		if !found {
+			stream = &lokiStream{Stream: map[string]string{
+				"job":   constants.ApplicationName,
+				"host":  l.host,
+				"level": lokiLevel(record.Level),
+			}}
+			streams[record.Level] = stream
+			push.Streams = append(push.Streams, stream)
+		}
+		stream.Values = append(stream.Values, [2]string{strconv.FormatInt(record.Time.UnixNano(), 10), record.Message})
+	}
+
+	body, err := json.Marshal(push)
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequest(http.MethodPost, l.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "resticprofile/"+version)
+
+	response, err := l.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	_, _ = io.Copy(io.Discard, response.Body)
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("HTTP %s", response.Status)
+	}
+	return nil
+}
+
+func (l *lokiSender) Close() error {
+	l.client.CloseIdleConnections()
+	return nil
+}
+
+func lokiLevel(level clog.LogLevel) string {
+	switch level {
+	case clog.LevelTrace, clog.LevelDebug:
+		return "debug"
+	case clog.LevelWarning:
+		return "warning"
+	case clog.LevelError:
+		return "error"
+	default:
+		return "info"
+	}
+}
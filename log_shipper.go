@@ -0,0 +1,295 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/constants"
+)
+
+const (
+	logShipperQueueSize    = 10000
+	logShipperBatchSize    = 500
+	logShipperInterval     = time.Second
+	logShipperMaxRetryWait = 30 * time.Second
+	logShipperFlushTimeout = 5 * time.Second
+)
+
+// logRecord is a log entry waiting to be shipped to a remote endpoint
+type logRecord struct {
+	Time    time.Time     `json:"time"`
+	Level   clog.LogLevel `json:"level"`
+	Message string        `json:"message"`
+}
+
+// logSender delivers log records to a remote endpoint
+type logSender interface {
+	Send(records []logRecord) error
+	Close() error
+}
+
+// logShipper is a log handler that never blocks: log entries are queued in memory and sent in the background.
+// When the queue is full, the oldest entries are dropped. Entries that could not be delivered when closing
+// are saved in a spool file, and sent with the logs of the next run.
+type logShipper struct {
+	sender       logSender
+	target       string
+	spoolFile    string
+	queueSize    int
+	flushTimeout time.Duration
+
+	mutex    sync.Mutex
+	queue    []logRecord
+	dropped  int
+	inFlight []logRecord // batch being sent
+	sending  int         // number of dropped records reported by the batch being sent
+	spooled  bool        // the spool file was written: nothing is sent after that
+	failures int
+	wake     chan struct{}
+	done     chan struct{}
+	stopped  chan struct{}
+}
+
+func newLogShipper(sender logSender, target string) *logShipper {
+	shipper := &logShipper{
+		sender:       sender,
+		target:       target,
+		spoolFile:    getLogSpoolFile(target),
+		queueSize:    logShipperQueueSize,
+		flushTimeout: logShipperFlushTimeout,
+		wake:         make(chan struct{}, 1),
+		done:         make(chan struct{}),
+		stopped:      make(chan struct{}),
+	}
+	shipper.loadSpool()
+	go shipper.run()
+	return shipper
+}
+
+// getLogSpoolFile returns the file keeping the undelivered log entries of the target between runs
+func getLogSpoolFile(target string) string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	hash := sha256.Sum256([]byte(target))
+	return filepath.Join(dir, constants.ApplicationName, "log-spool-"+hex.EncodeToString(hash[:8])+".jsonl")
+}
+
+// LogEntry queues the entry and returns immediately
+func (s *logShipper) LogEntry(entry clog.LogEntry) error {
+	s.push(logRecord{Time: time.Now(), Level: entry.Level, Message: entry.GetMessage()})
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func (s *logShipper) push(records ...logRecord) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.queue = append(s.queue, records...)
+	if excess := len(s.queue) - s.queueSize; excess > 0 {
+		s.queue = s.queue[excess:]
+		s.dropped += excess
+	}
+}
+
+// takeBatch removes the next records to send from the queue, and returns the number of records dropped until now.
+// The batch stays in flight until it's either delivered or put back.
+func (s *logShipper) takeBatch() (batch []logRecord, dropped int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.spooled {
+		return
+	}
+	size := len(s.queue)
+	if size > logShipperBatchSize {
+		size = logShipperBatchSize
+	}
+	batch = make([]logRecord, size)
+	copy(batch, s.queue)
+	s.queue = s.queue[size:]
+	dropped, s.dropped = s.dropped, 0
+	s.inFlight, s.sending = batch, dropped
+	return
+}
+
+// delivered releases the batch in flight
+func (s *logShipper) delivered() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.inFlight, s.sending = nil, 0
+}
+
+// putBack returns the batch in flight that could not be sent at the front of the queue
+func (s *logShipper) putBack() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	batch, dropped := s.inFlight, s.sending
+	s.inFlight, s.sending = nil, 0
+	if s.spooled {
+		// the batch was already saved in the spool file
+		return
+	}
+	s.dropped += dropped
+	s.queue = append(batch, s.queue...)
+	if excess := len(s.queue) - s.queueSize; excess > 0 {
+		s.queue = s.queue[excess:]
+		s.dropped += excess
+	}
+}
+
+// droppedRecord reports the records dropped because the queue was full
+func droppedRecord(dropped int) logRecord {
+	return logRecord{
+		Time:    time.Now(),
+		Level:   clog.LevelWarning,
+		Message: fmt.Sprintf("log shipping: %d log entries were dropped (queue full)", dropped),
+	}
+}
+
+// sendAll sends the queued records, and returns the first error
+func (s *logShipper) sendAll() error {
+	for {
+		batch, dropped := s.takeBatch()
+		if len(batch) == 0 && dropped == 0 {
+			return nil
+		}
+		records := batch
+		if dropped > 0 {
+			records = append([]logRecord{droppedRecord(dropped)}, batch...)
+		}
+		if err := s.sender.Send(records); err != nil {
+			s.putBack()
+			s.failures++
+			if s.failures == 1 {
+				// the logger cannot be used to report its own failure
+				_, _ = fmt.Fprintf(os.Stderr, "log shipping to %s failed (will retry): %s\n", s.target, err)
+			}
+			return err
+		}
+		s.delivered()
+		s.failures = 0
+	}
+}
+
+func (s *logShipper) run() {
+	defer close(s.stopped)
+	retryWait := logShipperInterval
+	timer := time.NewTimer(logShipperInterval)
+	defer timer.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-s.wake:
+			if s.failures > 0 {
+				continue // wait for the retry timer
+			}
+		case <-timer.C:
+		}
+		if err := s.sendAll(); err != nil {
+			retryWait *= 2
+			if retryWait > logShipperMaxRetryWait {
+				retryWait = logShipperMaxRetryWait
+			}
+		} else {
+			retryWait = logShipperInterval
+		}
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(retryWait)
+	}
+}
+
+// Close sends the remaining entries (waiting up to flushTimeout), and saves the undelivered entries in the spool file
+func (s *logShipper) Close() error {
+	close(s.done)
+	flushed := make(chan error, 1)
+	go func() {
+		<-s.stopped
+		flushed <- s.sendAll()
+	}()
+	select {
+	case err := <-flushed:
+		if err != nil {
+			s.saveSpool()
+		}
+		return s.sender.Close()
+	case <-time.After(s.flushTimeout):
+		// the sender is still busy: leave it to the end of the process. The batch in flight is saved too,
+		// as it would be lost if the send fails: it may be delivered twice, but never lost.
+		s.saveSpool()
+		return nil
+	}
+}
+
+// loadSpool queues the entries left undelivered by a previous run
+func (s *logShipper) loadSpool() {
+	if s.spoolFile == "" {
+		return
+	}
+	file, err := os.Open(s.spoolFile)
+	if err != nil {
+		return
+	}
+	var records []logRecord
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		record := logRecord{}
+		if json.Unmarshal(scanner.Bytes(), &record) == nil {
+			records = append(records, record)
+		}
+	}
+	_ = file.Close()
+	_ = os.Remove(s.spoolFile)
+	s.push(records...)
+}
+
+// saveSpool appends the batch in flight and the entries still in the queue to the spool file.
+// Nothing is sent once the spool file is saved.
+func (s *logShipper) saveSpool() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.spooled = true
+	s.queue = append(s.inFlight, s.queue...)
+	s.dropped += s.sending
+	s.inFlight, s.sending = nil, 0
+	if s.spoolFile == "" || (len(s.queue) == 0 && s.dropped == 0) {
+		return
+	}
+	if s.dropped > 0 {
+		s.queue = append([]logRecord{droppedRecord(s.dropped)}, s.queue...)
+		s.dropped = 0
+	}
+	if err := os.MkdirAll(filepath.Dir(s.spoolFile), 0o700); err != nil {
+		return
+	}
+	file, err := os.OpenFile(s.spoolFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "log shipping: cannot save %d undelivered log entries: %s\n", len(s.queue), err)
+		return
+	}
+	defer file.Close()
+	encoder := json.NewEncoder(file)
+	for _, record := range s.queue {
+		_ = encoder.Encode(record)
+	}
+	s.queue = nil
+}
+
+var _ LogCloser = &logShipper{}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/creativeprojects/clog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockLogSender struct {
+	mutex   sync.Mutex
+	fail    bool
+	block   chan struct{}
+	records []logRecord
+}
+
+func (m *mockLogSender) Send(records []logRecord) error {
+	if m.block != nil {
+		<-m.block
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.fail {
+		return errors.New("endpoint unavailable")
+	}
+	m.records = append(m.records, records...)
+	return nil
+}
+
+func (m *mockLogSender) Close() error { return nil }
+
+func (m *mockLogSender) messages() (messages []string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for _, record := range m.records {
+		messages = append(messages, record.Message)
+	}
+	return
+}
+
+func setLogSpoolDir(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", dir)
+	t.Setenv("HOME", dir)
+	t.Setenv("LocalAppData", dir)
+}
+
+func logMessage(message string) clog.LogEntry {
+	return clog.LogEntry{Level: clog.LevelInfo, Format: message}
+}
+
+func TestLogShipperSendsInBackground(t *testing.T) {
+	setLogSpoolDir(t)
+	sender := &mockLogSender{}
+	shipper := newLogShipper(sender, "test://background")
+
+	assert.NoError(t, shipper.LogEntry(logMessage("first")))
+	assert.NoError(t, shipper.LogEntry(logMessage("second")))
+	assert.Eventually(t, func() bool { return len(sender.messages()) == 2 }, 3*time.Second, 10*time.Millisecond)
+
+	assert.NoError(t, shipper.LogEntry(logMessage("last")))
+	assert.NoError(t, shipper.Close())
+	assert.Equal(t, []string{"first", "second", "last"}, sender.messages())
+}
+
+func TestLogShipperNeverBlocks(t *testing.T) {
+	setLogSpoolDir(t)
+	sender := &mockLogSender{block: make(chan struct{})}
+	shipper := newLogShipper(sender, "test://blocked")
+	shipper.queueSize = 10
+	shipper.flushTimeout = 100 * time.Millisecond
+
+	start := time.Now()
+	for i := 0; i < 100; i++ {
+		assert.NoError(t, shipper.LogEntry(logMessage("message")))
+	}
+	assert.Less(t, time.Since(start), time.Second)
+	assert.NoError(t, shipper.Close())
+	close(sender.block)
+}
+
+func TestLogShipperSpoolsUndeliveredEntries(t *testing.T) {
+	setLogSpoolDir(t)
+	sender := &mockLogSender{fail: true}
+	shipper := newLogShipper(sender, "test://spool")
+	shipper.queueSize = 3
+
+	for _, message := range []string{"one", "two", "three", "four"} {
+		assert.NoError(t, shipper.LogEntry(logMessage(message)))
+	}
+	assert.NoError(t, shipper.Close())
+	assert.Empty(t, sender.messages())
+	require.FileExists(t, shipper.spoolFile)
+
+	// next run: the endpoint is back online
+	sender = &mockLogSender{}
+	shipper = newLogShipper(sender, "test://spool")
+	assert.NoFileExists(t, shipper.spoolFile)
+	assert.NoError(t, shipper.LogEntry(logMessage("five")))
+	assert.NoError(t, shipper.Close())
+
+	messages := sender.messages()
+	require.Len(t, messages, 5)
+	assert.Contains(t, messages[0], "1 log entries were dropped")
+	assert.Equal(t, []string{"two", "three", "four", "five"}, messages[1:])
+}
+
+func TestLogShipperSpoolsBatchInFlight(t *testing.T) {
+	setLogSpoolDir(t)
+	sender := &mockLogSender{block: make(chan struct{}), fail: true}
+	shipper := newLogShipper(sender, "test://in-flight")
+	shipper.flushTimeout = 100 * time.Millisecond
+
+	assert.NoError(t, shipper.LogEntry(logMessage("in flight")))
+	assert.Eventually(t, func() bool {
+		shipper.mutex.Lock()
+		defer shipper.mutex.Unlock()
+		return len(shipper.inFlight) == 1
+	}, 3*time.Second, 10*time.Millisecond)
+	assert.NoError(t, shipper.LogEntry(logMessage("queued")))
+	assert.NoError(t, shipper.Close())
+	require.FileExists(t, shipper.spoolFile)
+
+	// the send fails after the spool file was saved
+	close(sender.block)
+	assert.Eventually(t, func() bool {
+		shipper.mutex.Lock()
+		defer shipper.mutex.Unlock()
+		return shipper.inFlight == nil
+	}, 3*time.Second, 10*time.Millisecond)
+	shipper.mutex.Lock()
+	assert.Empty(t, shipper.queue)
+	shipper.mutex.Unlock()
+
+	// next run
+	sender = &mockLogSender{}
+	shipper = newLogShipper(sender, "test://in-flight")
+	assert.NoError(t, shipper.Close())
+	assert.Equal(t, []string{"in flight", "queued"}, sender.messages())
+}
+
+func TestLokiSender(t *testing.T) {
+	var received lokiPush
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/loki/api/v1/push", r.URL.Path)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	sender := newLokiSender(server.URL + "/loki/api/v1/push")
+	defer sender.Close()
+	now := time.Unix(1700000000, 5)
+	err := sender.Send([]logRecord{
+		{Time: now, Level: clog.LevelInfo, Message: "info 1"},
+		{Time: now, Level: clog.LevelError, Message: "error"},
+		{Time: now, Level: clog.LevelInfo, Message: "info 2"},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, received.Streams, 2)
+	assert.Equal(t, "info", received.Streams[0].Stream["level"])
+	assert.Equal(t, "resticprofile", received.Streams[0].Stream["job"])
+	assert.Equal(t, [][2]string{{"1700000000000000005", "info 1"}, {"1700000000000000005", "info 2"}}, received.Streams[0].Values)
+	assert.Equal(t, "error", received.Streams[1].Stream["level"])
+}
+
+func TestLokiSenderError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	sender := newLokiSender(server.URL)
+	err := sender.Send([]logRecord{{Time: time.Now(), Message: "message"}})
+	assert.ErrorContains(t, err, "429")
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/creativeprojects/resticprofile/remote"
 	"github.com/creativeprojects/resticprofile/term"
 	"github.com/creativeprojects/resticprofile/util"
+	"github.com/creativeprojects/resticprofile/util/redact"
 )
 
 type LogCloser interface {
@@ -35,7 +36,7 @@ func setupConsoleLogger(flags commandLineFlags) {
 }
 
 func setupRemoteLogger(client *remote.Client) {
-	logger := clog.NewLogger(client)
+	logger := clog.NewLogger(redactHandler{client})
 	client.SetPrefix("elevated user: ")
 	clog.SetDefaultLogger(logger)
 }
@@ -48,7 +49,11 @@ func setupTargetLogger(flags commandLineFlags) (io.Closer, error) {
 	)
 	scheme, hostPort, isURL := dial.GetAddr(flags.log)
 	if isURL {
-		handler, err = getSyslogHandler(flags, scheme, hostPort)
+		if scheme == "http" || scheme == "https" {
+			handler = newLogShipper(newLokiSender(flags.log), flags.log)
+		} else {
+			handler, err = getSyslogHandler(flags, scheme, hostPort)
+		}
 	} else {
 		handler, file, err = getFileHandler(flags)
 	}
@@ -130,7 +135,19 @@ func newFilteredLogger(flags commandLineFlags, handler clog.Handler) *clog.Logge
 		minLevel = clog.LevelDebug
 	}
 	// now create and return the logger
-	return clog.NewLogger(clog.NewLevelFilter(minLevel, handler))
+	return clog.NewLogger(clog.NewLevelFilter(minLevel, redactHandler{handler}))
+}
+
+// redactHandler masks the confidential values of the profile in all log messages (including --trace output)
+type redactHandler struct {
+	clog.Handler
+}
+
+func (h redactHandler) LogEntry(entry clog.LogEntry) error {
+	if redact.Enabled() {
+		entry = clog.NewLogEntryf(entry.Calldepth, entry.Level, "%s", redact.String(entry.GetMessage()))
+	}
+	return h.Handler.LogEntry(entry)
 }
 
 func changeLevelFilter(level clog.LogLevel) {
@@ -12,6 +12,7 @@ import (
 	"github.com/creativeprojects/resticprofile/constants"
 	"github.com/creativeprojects/resticprofile/term"
 	"github.com/creativeprojects/resticprofile/util"
+	"github.com/creativeprojects/resticprofile/util/redact"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -97,3 +98,16 @@ func TestFileHandler(t *testing.T) {
 		}
 	}
 }
+
+func TestRedactLogHandler(t *testing.T) {
+	defer redact.Clear()
+	memory := clog.NewMemoryHandler()
+	logger := newFilteredLogger(commandLineFlags{veryVerbose: true}, memory)
+
+	logger.Tracef("password=%s", "my-password")
+	redact.Add("my-password", "×××")
+	logger.Tracef("password=%s", "my-password")
+	logger.Info("my-password")
+
+	assert.Equal(t, []string{"password=my-password", "password=×××", "×××"}, memory.Logs())
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"fmt"
 	"math/rand"
 	"os"
@@ -14,15 +15,26 @@ import (
 	"github.com/creativeprojects/resticprofile/config"
 	"github.com/creativeprojects/resticprofile/constants"
 	"github.com/creativeprojects/resticprofile/filesearch"
+	"github.com/creativeprojects/resticprofile/monitor"
+	"github.com/creativeprojects/resticprofile/monitor/audit"
+	"github.com/creativeprojects/resticprofile/monitor/collector"
+	"github.com/creativeprojects/resticprofile/monitor/history"
+	"github.com/creativeprojects/resticprofile/monitor/mqtt"
+	"github.com/creativeprojects/resticprofile/monitor/otel"
 	"github.com/creativeprojects/resticprofile/monitor/prom"
 	"github.com/creativeprojects/resticprofile/monitor/status"
 	"github.com/creativeprojects/resticprofile/preventsleep"
 	"github.com/creativeprojects/resticprofile/priority"
 	"github.com/creativeprojects/resticprofile/remote"
 	"github.com/creativeprojects/resticprofile/restic"
+	"github.com/creativeprojects/resticprofile/secret"
+	"github.com/creativeprojects/resticprofile/shell"
 	"github.com/creativeprojects/resticprofile/term"
+	"github.com/creativeprojects/resticprofile/util"
 	"github.com/creativeprojects/resticprofile/util/bools"
+	"github.com/creativeprojects/resticprofile/util/crypt"
 	"github.com/creativeprojects/resticprofile/util/shutdown"
+	"github.com/creativeprojects/resticprofile/volume"
 	"github.com/mackerelio/go-osstat/memory"
 	"github.com/spf13/pflag"
 )
@@ -54,11 +66,11 @@ func main() {
 	defer shutdown.RunHooks()
 
 	args := os.Args[1:]
-	_, flags, flagErr := loadFlags(args)
+	flagset, flags, flagErr := loadFlags(args)
 	if flagErr != nil && flagErr != pflag.ErrHelp {
 		fmt.Println(flagErr)
 		_ = displayHelpCommand(os.Stdout, commandRequest{ownCommands: ownCommands, flags: flags, args: args})
-		exitCode = 2
+		exitCode = constants.ExitCodeCommandLine
 		return
 	}
 
@@ -84,6 +96,16 @@ func main() {
 		return
 	}
 
+	// the log target can contain run-time templates (e.g. "schedule-log" of a scheduled job)
+	var logTemplateErr error
+	if config.HasRunTimeTemplate(flags.log) {
+		command := ""
+		if len(flags.resticArgs) > 0 {
+			command = flags.resticArgs[0]
+		}
+		flags.log, logTemplateErr = config.ResolveRunTime(flags.log, config.NewRunTimeData(flags.name, command, flags.scheduleName))
+	}
+
 	// setting up the logger - we can start logging right after
 	if flags.isChild {
 		// use a remote logger
@@ -112,10 +134,17 @@ func main() {
 			defer handle.Close()
 		}
 
+	} else if flags.container {
+		// JSON logs on stdout
+		setupJSONLogger(flags)
+
 	} else {
 		// Use the console logger
 		setupConsoleLogger(flags)
 	}
+	if logTemplateErr != nil {
+		clog.Warning(logTemplateErr)
+	}
 
 	// keep this one last if possible (so it will be first at the end)
 	defer showPanicData()
@@ -128,37 +157,104 @@ func main() {
 			err = ownCommands.Run(nil, flags.resticArgs[0], flags, flags.resticArgs[1:])
 			if err != nil {
 				clog.Error(err)
-				exitCode = 1
+				exitCode = getExitCode(err)
 				return
 			}
 			return
 		}
 	}
 
-	configFile, err := filesearch.FindConfigurationFile(flags.config)
+	var c *config.Config
+	var configContent []byte // configuration from stdin (or from the environment in container mode)
+	configFormat := flags.format
+	if flags.config == configFromStdin {
+		configContent, err = readConfigContent(os.Stdin)
+	} else if flags.container && flags.configDir == "" {
+		configContent, err = containerConfigContent(flags, os.Stdin, term.OsStdinIsTerminal())
+	}
 	if err != nil {
 		clog.Error(err)
-		exitCode = 1
+		exitCode = constants.ExitCodeConfiguration
 		return
 	}
-	if configFile != flags.config {
-		clog.Infof("using configuration file: %s", configFile)
+	if configContent != nil && configFormat == "" {
+		configFormat = guessConfigFormat(configContent)
 	}
 
-	c, err := config.LoadFile(configFile, flags.format)
-	if err != nil {
-		clog.Errorf("cannot load configuration file: %v", err)
-		exitCode = 1
-		return
+	if configContent != nil {
+		c, err = config.LoadReader(bytes.NewReader(configContent), configFormat)
+		if err != nil {
+			clog.Errorf("cannot load configuration: %v", err)
+			exitCode = constants.ExitCodeConfiguration
+			return
+		}
+
+	} else if flags.configDir != "" {
+		// independent configuration files: use the one defining the profile or group
+		var workspace *config.Workspace
+		workspace, c, err = loadWorkspaceConfig(flags)
+		if err != nil {
+			clog.Error(err)
+			exitCode = constants.ExitCodeConfiguration
+			return
+		}
+		if c == nil {
+			if len(flags.resticArgs) > 0 && ownCommands.Exists(flags.resticArgs[0], true) {
+				exitCode = runOwnCommandInWorkspace(workspace, flags)
+				return
+			}
+			clog.Errorf("profile or group %q not found in any configuration file of %s", flags.name, flags.configDir)
+			exitCode = constants.ExitCodeConfiguration
+			return
+		}
+		flags.config = c.GetConfigFile()
+		clog.Infof("using configuration file: %s", flags.config)
+
+	} else {
+		configFile, err := filesearch.FindConfigurationFile(flags.config)
+		if err != nil {
+			clog.Error(err)
+			exitCode = constants.ExitCodeConfiguration
+			return
+		}
+		if configFile != flags.config {
+			clog.Infof("using configuration file: %s", configFile)
+		}
+
+		c, err = config.LoadFile(configFile, flags.format)
+		if err != nil {
+			clog.Errorf("cannot load configuration file: %v", err)
+			exitCode = constants.ExitCodeConfiguration
+			return
+		}
+	}
+	if flags.configHash != "" {
+		if err = c.CheckHash(flags.configHash); err != nil {
+			clog.Error(err)
+			exitCode = constants.ExitCodeConfiguration
+			return
+		}
 	}
 
 	global, err := c.GetGlobalSection()
 	if err != nil {
 		clog.Errorf("cannot load global configuration: %v", err)
-		exitCode = 1
+		exitCode = constants.ExitCodeConfiguration
 		return
 	}
 
+	// select the default profile of this host
+	if flags.defaultName {
+		hostname, _ := os.Hostname()
+		flags.name = selectDefaultProfile(c, global, hostname, flags.name)
+	}
+
+	// directories to search for restic, shells and any other commands
+	if len(global.PathPrepend) > 0 {
+		prependPath(global.PathPrepend)
+		clog.Debugf("PATH=%s", os.Getenv(pathEnv))
+	}
+
 	// prevent computer from sleeping
 	var caffeinate *preventsleep.Caffeinate
 	if global.PreventSleep {
@@ -179,6 +275,41 @@ func main() {
 		}
 	}()
 
+	// Limit the output of commands kept in memory
+	shell.CapturedOutputLimit = global.CapturedOutputLimit * 1024
+
+	// Formatting of sizes and durations in human output
+	if err = util.SetSizeUnits(global.SizeUnits); err != nil {
+		clog.Warning(err)
+	}
+	if err = util.SetDurationStyle(global.DurationStyle); err != nil {
+		clog.Warning(err)
+	}
+
+	// encryption of the status and history files
+	if global.StatusKeyFile != "" {
+		key, err := crypt.LoadKeyFile(global.StatusKeyFile)
+		if err != nil {
+			clog.Errorf("cannot load the encryption key of the status and history files: %v", err)
+			exitCode = constants.ExitCodeConfiguration
+			return
+		}
+		crypt.SetDefaultKey(key)
+	}
+
+	// audit log of the commands
+	if global.AuditLog != "" {
+		key, err := loadAuditKey(global.AuditLogKeyFile)
+		if err != nil {
+			clog.Errorf("cannot load the key of the audit log: %v", err)
+			exitCode = constants.ExitCodeConfiguration
+			return
+		}
+		auditLog = audit.NewLog(global.AuditLog, key)
+		volume.AuditCommand = auditExternalCommand
+		secret.AuditCommand = auditExternalCommand
+	}
+
 	// Check memory pressure
 	if global.MinMemory > 0 {
 		avail := free()
@@ -203,6 +334,22 @@ func main() {
 		}
 	}
 
+	// container mode without command: run the schedules of all the profiles
+	if flags.container && len(flags.resticArgs) == 0 {
+		exitCode = runContainer(c, flags, configContent, configFormat)
+		return
+	}
+
+	// simulation of the retention policy (doesn't need restic)
+	if len(flags.resticArgs) > 0 && isRetentionSimulation(flags.resticArgs[0], flags.resticArgs[1:]) {
+		request := commandRequest{ownCommands: ownCommands, config: c, flags: flags, args: flags.resticArgs[2:]}
+		if err = simulateRetention(os.Stdout, request); err != nil {
+			clog.Error(err)
+			exitCode = 1
+		}
+		return
+	}
+
 	resticBinary, err := filesearch.FindResticBinary(global.ResticBinary)
 	if err != nil {
 		clog.Error("cannot find restic: ", err)
@@ -224,7 +371,7 @@ func main() {
 		err = ownCommands.Run(c, resticCommand, flags, resticArguments)
 		if err != nil {
 			clog.Error(err)
-			exitCode = 1
+			exitCode = getExitCode(err)
 			return
 		}
 		return
@@ -239,7 +386,41 @@ func main() {
 	}
 	clog.Debugf("restic %s", global.ResticVersion)
 
-	if c.HasProfile(flags.name) {
+	// temporary files of runs that were killed before they could remove them
+	if !flags.readOnly {
+		removeStaleTempDirs()
+	}
+
+	run := profilesRun{
+		config:          c,
+		global:          global,
+		flags:           flags,
+		flagset:         flagset,
+		resticBinary:    resticBinary,
+		resticArguments: resticArguments,
+		resticCommand:   resticCommand,
+	}
+
+	if flags.profiles != "" {
+		// Run of the profiles selected by labels
+		profiles, err := c.SelectProfilesByLabels(flags.profiles)
+		if err != nil {
+			clog.Error(err)
+			exitCode = constants.ExitCodeConfiguration
+			return
+		}
+		if len(profiles) == 0 {
+			clog.Errorf("no profile matching labels '%s'", flags.profiles)
+			exitCode = constants.ExitCodeConfiguration
+			return
+		}
+		// if running as a systemd timer
+		notifyStart()
+		defer notifyStop()
+
+		exitCode = run.run(flags.profiles, profiles, flags.continueOn || global.GroupContinueOnError)
+
+	} else if c.HasProfile(flags.name) {
 		// if running as a systemd timer
 		notifyStart()
 		defer notifyStop()
@@ -248,7 +429,7 @@ func main() {
 		err = runProfile(c, global, flags, flags.name, resticBinary, resticArguments, resticCommand, "")
 		if err != nil {
 			clog.Error(err)
-			exitCode = 1
+			exitCode = getExitCode(err)
 			return
 		}
 
@@ -257,33 +438,25 @@ func main() {
 		group, err := c.GetProfileGroup(flags.name)
 		if err != nil {
 			clog.Errorf("cannot load group '%s': %v", flags.name, err)
+			exitCode = constants.ExitCodeConfiguration
+			return
 		}
 		if group != nil && len(group.Profiles) > 0 {
 			// if running as a systemd timer
 			notifyStart()
 			defer notifyStop()
 
-			for i, profileName := range group.Profiles {
-				clog.Debugf("[%d/%d] starting profile '%s' from group '%s'", i+1, len(group.Profiles), profileName, flags.name)
-				err = runProfile(c, global, flags, profileName, resticBinary, resticArguments, resticCommand, flags.name)
-				if err != nil {
-					clog.Error(err)
-					if global.GroupContinueOnError && bools.IsTrueOrUndefined(group.ContinueOnError) ||
-						bools.IsTrue(group.ContinueOnError) {
-						// keep going to the next profile
-						continue
-					}
-					exitCode = 1
-					return
-				}
-			}
+			continueOnError := flags.continueOn ||
+				global.GroupContinueOnError && bools.IsTrueOrUndefined(group.ContinueOnError) ||
+				bools.IsTrue(group.ContinueOnError)
+			exitCode = run.run(flags.name, group.Profiles, continueOnError)
 		}
 
 	} else {
 		clog.Errorf("profile or group not found '%s'", flags.name)
-		displayProfiles(os.Stdout, c, flags)
+		displayProfiles(os.Stdout, c, flags, false)
 		displayGroups(os.Stdout, c, flags)
-		exitCode = 1
+		exitCode = constants.ExitCodeConfiguration
 		return
 	}
 }
@@ -315,6 +488,21 @@ func setPriority(nice int, class string) error {
 	return nil
 }
 
+// selectDefaultProfile returns the profile (or group) declared in "default-profile-by-host" for the host,
+// falling back to the default profile when no pattern matches or when the profile doesn't exist
+func selectDefaultProfile(c *config.Config, global *config.Global, hostname, defaultProfile string) string {
+	name, pattern, found := global.GetDefaultProfileForHost(hostname)
+	if !found {
+		return defaultProfile
+	}
+	if !c.HasProfile(name) && !c.HasProfileGroup(name) {
+		clog.Warningf("profile or group '%s' selected for host %q (pattern %q) not found: using profile '%s'", name, hostname, pattern, defaultProfile)
+		return defaultProfile
+	}
+	clog.Debugf("using profile '%s' for host %q (pattern %q)", name, hostname, pattern)
+	return name
+}
+
 func runProfile(
 	c *config.Config,
 	global *config.Global,
@@ -324,6 +512,7 @@ func runProfile(
 	resticArguments []string,
 	resticCommand string,
 	group string,
+	progress ...monitor.Receiver,
 ) error {
 	var err error
 
@@ -332,12 +521,39 @@ func runProfile(
 		clog.Warning(err)
 	}
 	if profile == nil {
-		return fmt.Errorf("cannot load profile '%s'", profileName)
+		return newExitCodeError(constants.ExitCodeConfiguration, fmt.Errorf("cannot load profile '%s'", profileName))
 	}
+	if err = checkAbstractProfile(profile, "run"); err != nil {
+		return newExitCodeError(constants.ExitCodeConfiguration, err)
+	}
+	if err = checkProfileUser(profile); err != nil {
+		return err
+	}
+	// secrets of the profile are masked in all output from now on
+	config.RedactConfidentialValues(profile)
 
-	displayProfileDeprecationNotices(profile)
+	// issues are kept to be reported in the summary of the run
+	deprecations := getDeprecationNotices(global, profile)
+	configIssues := append(deprecations[:len(deprecations):len(deprecations)], c.GetConfigurationIssues()...)
+	displayDeprecationNotices(deprecations)
 	c.DisplayConfigurationIssues()
 
+	// directories of the profile are searched first, for restic and for any other commands
+	if len(profile.Path) > 0 {
+		defer prependPath(profile.Path)()
+		clog.Debugf("profile '%s': PATH=%s", profile.Name, os.Getenv(pathEnv))
+		if binary, found := filesearch.FindResticBinaryIn(profile.Path, global.ResticBinary); found {
+			clog.Debugf("profile '%s': using restic binary %q", profile.Name, binary)
+			resticBinary = binary
+		}
+	}
+	// references to a password manager are read with its command line tool (found in the PATH of the profile)
+	if err = profile.ResolveSecrets(secret.DefaultResolver()); err != nil {
+		return newExitCodeError(constants.ExitCodeConfiguration, err)
+	}
+	config.RedactConfidentialValues(profile)
+	checkShellBinary(global.ShellBinary)
+
 	// Send the quiet/verbose down to restic as well (override profile configuration)
 	if flags.quiet {
 		profile.Quiet = true
@@ -368,6 +584,15 @@ func runProfile(
 	if e := profile.SetResticVersion(global.ResticVersion); e != nil {
 		clog.Warningf("restic version %q is no valid semver: %s", global.ResticVersion, e.Error())
 	}
+	// restic reads the credentials of a REST server from the environment: they no longer need to be in the repository URL
+	if profile.SplitRepositoryCredentials() {
+		clog.Debugf("profile '%s': repository credentials passed in %s and %s", profile.Name, constants.EnvResticRestUsername, constants.EnvResticRestPassword)
+	}
+
+	// flags not supported by this version of restic (and invalid repository options) are reported with the configuration issues
+	compatibilityNotices := getCompatibilityNotices(profile, global.ResticVersion)
+	displayDeprecationNotices(compatibilityNotices)
+	configIssues = append(configIssues, compatibilityNotices...)
 
 	// Specific case for the "host" flag where an empty value should be replaced by the hostname
 	hostname := "none"
@@ -393,6 +618,18 @@ func runProfile(
 		sigChan,
 	)
 
+	wrapper.setConfigIssues(configIssues, checkDeprecationNotices(global, deprecations))
+	if hash, err := c.GetHash(false); err == nil {
+		clog.Debugf("configuration hash: %s", hash)
+		wrapper.setConfigHash(hash)
+	}
+
+	if flags.readOnly {
+		wrapper.setReadOnly()
+	}
+	wrapper.setExitCodeFrom(flags.exitFrom)
+	wrapper.setScheduleName(flags.scheduleName)
+
 	if flags.noLock {
 		wrapper.ignoreLock()
 	} else if flags.lockWait > 0 {
@@ -403,9 +640,24 @@ func runProfile(
 	if profile.StatusFile != "" {
 		wrapper.addProgress(status.NewProgress(profile, status.NewStatus(profile.StatusFile)))
 	}
-	if profile.PrometheusPush != "" || profile.PrometheusSaveToFile != "" {
+	if profile.PrometheusPush.Value() != "" || profile.PrometheusSaveToFile != "" {
 		wrapper.addProgress(prom.NewProgress(profile, prom.NewMetrics(group, version, profile.PrometheusLabels)))
 	}
+	if profile.HistoryFile != "" {
+		wrapper.addProgress(history.NewProgress(profile, history.NewHistory(profile.HistoryFile)))
+	}
+	if profile.CollectorURL.Value() != "" {
+		wrapper.addProgress(collector.NewSender(profile, version))
+	}
+	if !profile.MQTT.IsEmpty() {
+		wrapper.addProgress(mqtt.NewSender(profile, version, global.CACertificates, flags.dryRun))
+	}
+	if profile.OtelEndpoint.Value() != "" {
+		wrapper.setTracer(otel.NewTracer(profile, version))
+	}
+	for _, receiver := range progress {
+		wrapper.addProgress(receiver)
+	}
 
 	err = wrapper.runProfile()
 	if err != nil {
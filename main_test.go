@@ -0,0 +1,34 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectDefaultProfile(t *testing.T) {
+	testConfig := `
+version = "2"
+[global.default-profile-by-host]
+"web-*" = "web"
+"db-*" = "databases"
+"test-*" = "missing"
+[profiles.default]
+[profiles.web]
+[groups.databases]
+profiles = ["default"]
+`
+	c, err := config.Load(bytes.NewBufferString(testConfig), "toml")
+	require.NoError(t, err)
+	global, err := c.GetGlobalSection()
+	require.NoError(t, err)
+
+	assert.Equal(t, "web", selectDefaultProfile(c, global, "web-01", constants.DefaultProfileName))
+	assert.Equal(t, "databases", selectDefaultProfile(c, global, "db-01", constants.DefaultProfileName))
+	assert.Equal(t, constants.DefaultProfileName, selectDefaultProfile(c, global, "test-01", constants.DefaultProfileName))
+	assert.Equal(t, constants.DefaultProfileName, selectDefaultProfile(c, global, "laptop", constants.DefaultProfileName))
+}
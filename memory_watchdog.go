@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/shell"
+	"github.com/creativeprojects/resticprofile/util"
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// memoryWatchInterval is the time between two readings of the memory used by the restic process
+var memoryWatchInterval = time.Second
+
+// memoryLimitError is returned when the restic process was interrupted for using more memory than max-memory
+type memoryLimitError struct {
+	limit uint64
+	used  uint64
+	err   error
+}
+
+func (e *memoryLimitError) Error() string {
+	return fmt.Sprintf("memory limit exceeded: restic used %s, more than max-memory %s (%s)",
+		util.FormatBytes(e.used), util.FormatBytes(e.limit), e.err)
+}
+
+func (e *memoryLimitError) Unwrap() error {
+	return e.err
+}
+
+// memoryWatchdog interrupts a process (and its children) when the memory they use goes over the limit
+type memoryWatchdog struct {
+	limit    uint64
+	pid      int
+	used     atomic.Uint64 // memory used when the limit was exceeded
+	exceeded atomic.Bool
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// startMemoryWatchdog starts watching the memory of the process
+func startMemoryWatchdog(pid int, limit uint64) *memoryWatchdog {
+	w := &memoryWatchdog{
+		limit: limit,
+		pid:   pid,
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+func (w *memoryWatchdog) run() {
+	defer close(w.done)
+	ticker := time.NewTicker(memoryWatchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+		}
+		used, pids := processTreeMemory(int32(w.pid))
+		if used <= w.limit {
+			continue
+		}
+		w.used.Store(used)
+		w.exceeded.Store(true)
+		clog.Errorf("restic uses %s of memory, more than max-memory %s: interrupting restic", util.FormatBytes(used), util.FormatBytes(w.limit))
+		interruptProcesses(pids)
+
+		// terminate the processes still running after the grace period
+		select {
+		case <-w.stop:
+		case <-time.After(shell.DefaultInterruptGracePeriod):
+			clog.Warningf("restic still running %s after the interrupt signal, terminating it", shell.DefaultInterruptGracePeriod)
+			for _, pid := range pids {
+				if p, err := os.FindProcess(pid); err == nil {
+					_ = p.Kill()
+				}
+			}
+		}
+		return
+	}
+}
+
+// Stop stops watching the process, and returns the error of the process: a memoryLimitError when the limit was exceeded
+func (w *memoryWatchdog) Stop(err error) error {
+	w.stopOnce.Do(func() { close(w.stop) })
+	<-w.done
+	if w.exceeded.Load() {
+		return &memoryLimitError{limit: w.limit, used: w.used.Load(), err: err}
+	}
+	return err
+}
+
+// processTreeMemory returns the resident memory of the process and all its children, with the pids of the processes
+func processTreeMemory(pid int32) (used uint64, pids []int) {
+	p, err := process.NewProcess(pid)
+	if err != nil {
+		return 0, nil
+	}
+	pids = append(pids, int(pid))
+	if info, err := p.MemoryInfo(); err == nil {
+		used = info.RSS
+	}
+	children, _ := p.Children()
+	for _, child := range children {
+		childUsed, childPids := processTreeMemory(child.Pid)
+		used += childUsed
+		pids = append(pids, childPids...)
+	}
+	return
+}
+
+// interruptProcesses sends the interrupt signal, or kills the processes when the signal is not supported (Windows)
+func interruptProcesses(pids []int) {
+	for _, pid := range pids {
+		p, err := os.FindProcess(pid)
+		if err != nil {
+			continue
+		}
+		if err = p.Signal(os.Interrupt); err != nil {
+			_ = p.Kill()
+		}
+	}
+}
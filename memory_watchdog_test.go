@@ -0,0 +1,49 @@
+//go:build !windows
+
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryLimitError(t *testing.T) {
+	cause := errors.New("signal: interrupt")
+	err := &memoryLimitError{limit: 1024, used: 2048, err: cause}
+	assert.Equal(t, "memory limit exceeded: restic used 2.0 KiB, more than max-memory 1.0 KiB (signal: interrupt)", err.Error())
+	assert.ErrorIs(t, err, cause)
+
+	wrapper := newResticWrapper(nil, "echo", false, config.NewProfile(nil, "name"), "backup", nil, nil)
+	assert.Equal(t, constants.ExitCodeMemoryLimit, getExitCode(wrapper.withExitCode(err)))
+}
+
+func TestMemoryWatchdog(t *testing.T) {
+	defer func(interval time.Duration) { memoryWatchInterval = interval }(memoryWatchInterval)
+	memoryWatchInterval = 10 * time.Millisecond
+
+	t.Run("under the limit", func(t *testing.T) {
+		command := newShellCommand("sleep", []string{"0.2"}, nil, nil, false, nil, nil)
+		command.process = config.ProcessSection{MaxMemory: "1T"}
+		_, _, err := runShellCommand(command)
+		assert.NoError(t, err)
+	})
+
+	t.Run("over the limit", func(t *testing.T) {
+		start := time.Now()
+		command := newShellCommand("sleep", []string{"5"}, nil, nil, false, nil, nil)
+		command.process = config.ProcessSection{MaxMemory: "1"}
+		_, _, err := runShellCommand(command)
+		require.Error(t, err)
+		memoryErr := &memoryLimitError{}
+		require.ErrorAs(t, err, &memoryErr)
+		assert.Equal(t, uint64(1), memoryErr.limit)
+		assert.Greater(t, memoryErr.used, uint64(1))
+		assert.Less(t, time.Since(start), 4*time.Second)
+	})
+}
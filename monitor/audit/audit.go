@@ -0,0 +1,214 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"time"
+
+	"github.com/creativeprojects/resticprofile/util/filelock"
+)
+
+// lockTimeout is the maximum time to wait for another process writing to the audit log
+const lockTimeout = 30 * time.Second
+
+// tailSize is the size of the first block read at the end of the file to find the last entry
+const tailSize = 4096
+
+// Entry is a line of the audit log
+type Entry struct {
+	Time       time.Time `json:"time"`
+	User       string    `json:"user"`
+	Host       string    `json:"host"`
+	PID        int       `json:"pid"`
+	Profile    string    `json:"profile,omitempty"`
+	Command    string    `json:"command,omitempty"`
+	Executable string    `json:"executable"`
+	Args       []string  `json:"args,omitempty"`
+	Dir        string    `json:"dir,omitempty"`
+	ExitCode   int       `json:"exit_code"`
+	Duration   float64   `json:"duration"`
+	Error      string    `json:"error,omitempty"`
+	HMAC       string    `json:"hmac,omitempty"`
+}
+
+// Log is an append-only file of JSON lines. When a key is set, each entry is signed with an HMAC chained to
+// the previous entry: removing, reordering or modifying entries can be detected with Verify.
+type Log struct {
+	filename string
+	key      []byte
+}
+
+// NewLog returns an audit log writing to filename. The key is optional.
+func NewLog(filename string, key []byte) *Log {
+	return &Log{
+		filename: filename,
+		key:      key,
+	}
+}
+
+// Filename of the audit log
+func (l *Log) Filename() string {
+	return l.filename
+}
+
+// Add appends the entry to the log
+func (l *Log) Add(entry Entry) error {
+	unlock, err := filelock.Lock(l.filename, lockTimeout)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	file, err := os.OpenFile(l.filename, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	previous := ""
+	if len(l.key) > 0 {
+		last, err := lastLine(file)
+		if err != nil {
+			return fmt.Errorf("cannot read the last entry: %w", err)
+		}
+		if len(last) > 0 {
+			lastEntry := Entry{}
+			if err = json.Unmarshal(last, &lastEntry); err != nil {
+				return fmt.Errorf("cannot read the last entry: %w", err)
+			}
+			previous = lastEntry.HMAC
+		}
+	}
+
+	entry.HMAC = ""
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if len(l.key) > 0 {
+		line = appendHMAC(line, sign(l.key, previous, line))
+	}
+	line = append(line, '\n')
+	if _, err = file.Write(line); err != nil {
+		return err
+	}
+	return file.Sync()
+}
+
+// Verify checks the HMAC chain of the audit log and returns the number of entries
+func Verify(filename string, key []byte) (int, error) {
+	if len(key) == 0 {
+		return 0, errors.New("no key to verify the audit log")
+	}
+	file, err := os.Open(filename)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	count := 0
+	previous := ""
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		line = bytes.TrimSpace(line)
+		if len(line) > 0 {
+			count++
+			entry := Entry{}
+			if jsonErr := json.Unmarshal(line, &entry); jsonErr != nil {
+				return count - 1, fmt.Errorf("line %d: %w", count, jsonErr)
+			}
+			if entry.HMAC == "" {
+				return count - 1, fmt.Errorf("line %d: entry is not signed", count)
+			}
+			payload, found := removeHMAC(line, entry.HMAC)
+			if !found || !hmac.Equal([]byte(entry.HMAC), []byte(sign(key, previous, payload))) {
+				return count - 1, fmt.Errorf("line %d: invalid signature: the entry or a previous entry was modified or removed", count)
+			}
+			previous = entry.HMAC
+		}
+		if errors.Is(err, io.EOF) {
+			return count, nil
+		}
+		if err != nil {
+			return count, err
+		}
+	}
+}
+
+// sign returns the HMAC of the previous HMAC followed by the entry
+func sign(key []byte, previous string, payload []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(previous))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// appendHMAC adds the "hmac" field as the last field of the JSON object
+func appendHMAC(payload []byte, signature string) []byte {
+	line := make([]byte, 0, len(payload)+len(signature)+11)
+	line = append(line, payload[:len(payload)-1]...)
+	line = append(line, `,"hmac":"`...)
+	line = append(line, signature...)
+	return append(line, `"}`...)
+}
+
+// removeHMAC returns the JSON object as it was signed, without the "hmac" field
+func removeHMAC(line []byte, signature string) ([]byte, bool) {
+	suffix := []byte(`,"hmac":"` + signature + `"}`)
+	if !bytes.HasSuffix(line, suffix) {
+		return nil, false
+	}
+	payload := make([]byte, 0, len(line)-len(suffix)+1)
+	payload = append(payload, line[:len(line)-len(suffix)]...)
+	return append(payload, '}'), true
+}
+
+// lastLine returns the last non-empty line of the file, reading larger blocks from the end until the line is complete
+func lastLine(file *os.File) ([]byte, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := info.Size()
+	for blockSize := int64(tailSize); ; blockSize *= 2 {
+		if blockSize > size {
+			blockSize = size
+		}
+		block := make([]byte, blockSize)
+		if _, err = file.ReadAt(block, size-blockSize); err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		block = bytes.TrimRight(block, "\r\n ")
+		if index := bytes.LastIndexByte(block, '\n'); index >= 0 {
+			return block[index+1:], nil
+		}
+		if blockSize == size {
+			return block, nil
+		}
+	}
+}
+
+// NewEntry returns an entry with the time, the user, the host and the PID of the current process
+func NewEntry() Entry {
+	entry := Entry{
+		Time: time.Now(),
+		PID:  os.Getpid(),
+	}
+	if current, err := user.Current(); err == nil {
+		entry.User = current.Username
+	}
+	if host, err := os.Hostname(); err == nil {
+		entry.Host = host
+	}
+	return entry
+}
@@ -0,0 +1,92 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func addEntries(t *testing.T, log *Log, count int) {
+	t.Helper()
+	for i := 0; i < count; i++ {
+		entry := NewEntry()
+		entry.Profile = "profile"
+		entry.Executable = "restic"
+		entry.Args = []string{"backup", strings.Repeat("x", i*1000)}
+		require.NoError(t, log.Add(entry))
+	}
+}
+
+func TestAddWithoutKey(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "audit.log")
+	addEntries(t, NewLog(filename, nil), 2)
+
+	content, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	require.Len(t, lines, 2)
+
+	entry := Entry{}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &entry))
+	assert.Equal(t, "profile", entry.Profile)
+	assert.Equal(t, "restic", entry.Executable)
+	assert.Equal(t, os.Getpid(), entry.PID)
+	assert.Empty(t, entry.HMAC)
+
+	_, err = Verify(filename, []byte("key"))
+	assert.ErrorContains(t, err, "line 1: entry is not signed")
+}
+
+func TestVerify(t *testing.T) {
+	key := []byte("secret key")
+	filename := filepath.Join(t.TempDir(), "audit.log")
+	// the entries get larger than the block read at the end of the file
+	addEntries(t, NewLog(filename, key), 10)
+
+	count, err := Verify(filename, key)
+	assert.NoError(t, err)
+	assert.Equal(t, 10, count)
+
+	_, err = Verify(filename, []byte("another key"))
+	assert.ErrorContains(t, err, "line 1: invalid signature")
+
+	content, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	lines := bytes.SplitAfter(content, []byte("\n"))
+
+	t.Run("modified", func(t *testing.T) {
+		modified := bytes.Join(lines, nil)
+		modified = bytes.Replace(modified, []byte(`"profile":"profile"`), []byte(`"profile":"another"`), 1)
+		require.NoError(t, os.WriteFile(filename, modified, 0600))
+		count, err := Verify(filename, key)
+		assert.ErrorContains(t, err, "line 1: invalid signature")
+		assert.Equal(t, 0, count)
+	})
+
+	t.Run("removed", func(t *testing.T) {
+		removed := bytes.Join(append(lines[:3:3], lines[4:]...), nil)
+		require.NoError(t, os.WriteFile(filename, removed, 0600))
+		count, err := Verify(filename, key)
+		assert.ErrorContains(t, err, "line 4: invalid signature")
+		assert.Equal(t, 3, count)
+	})
+
+	t.Run("truncated and continued", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filename, bytes.Join(lines[:5], nil), 0600))
+		addEntries(t, NewLog(filename, key), 2)
+		count, err := Verify(filename, key)
+		assert.NoError(t, err)
+		assert.Equal(t, 7, count)
+	})
+}
+
+func TestVerifyWithoutKey(t *testing.T) {
+	_, err := Verify(filepath.Join(t.TempDir(), "audit.log"), nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,66 @@
+package collector
+
+import (
+	"time"
+
+	"github.com/creativeprojects/resticprofile/monitor"
+	"github.com/creativeprojects/resticprofile/util/redact"
+)
+
+// APIPath is the endpoint of the collector receiving the runs (POST) and serving the fleet status (GET)
+const APIPath = "/api/v1/runs"
+
+// Run is the summary of a command sent by a host to the collector
+type Run struct {
+	Host         string    `json:"host"`
+	Profile      string    `json:"profile"`
+	Command      string    `json:"command"`
+	Time         time.Time `json:"time"`
+	Success      bool      `json:"success"`
+	Warning      bool      `json:"warning,omitempty"`
+	Error        string    `json:"error,omitempty"`
+	ErrorCode    string    `json:"error_code,omitempty"`
+	ConfigIssues []string  `json:"config_issues,omitempty"`
+	Duration     int64     `json:"duration"`
+	FilesNew     int       `json:"files_new,omitempty"`
+	FilesChanged int       `json:"files_changed,omitempty"`
+	FilesTotal   int       `json:"files_total,omitempty"`
+	BytesAdded   uint64    `json:"bytes_added,omitempty"`
+	BytesTotal   uint64    `json:"bytes_total,omitempty"`
+	Version      string    `json:"version,omitempty"`
+}
+
+// NewRun creates the summary of a command
+func NewRun(host, profile, command, version string, summary monitor.Summary, result error) Run {
+	run := Run{
+		Host:         host,
+		Profile:      profile,
+		Command:      command,
+		Time:         time.Now(),
+		Success:      monitor.IsSuccess(result),
+		Warning:      monitor.IsWarning(result),
+		Duration:     int64(summary.Duration.Round(time.Second).Seconds()),
+		FilesNew:     summary.FilesNew,
+		FilesChanged: summary.FilesChanged,
+		FilesTotal:   summary.FilesTotal,
+		BytesAdded:   summary.BytesAdded,
+		BytesTotal:   summary.BytesTotal,
+		ConfigIssues: summary.ConfigIssues,
+		Version:      version,
+	}
+	if result != nil {
+		run.Error = redact.String(result.Error())
+		run.ErrorCode = summary.ErrorCode
+	}
+	return run
+}
+
+// key identifies the latest run of a command of a profile on a host
+func (r Run) key() string {
+	return r.Host + "/" + r.Profile + "/" + r.Command
+}
+
+// valid returns true when the run identifies a host, profile and command
+func (r Run) valid() bool {
+	return r.Host != "" && r.Profile != "" && r.Command != "" && !r.Time.IsZero()
+}
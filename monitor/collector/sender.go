@@ -0,0 +1,88 @@
+package collector
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/monitor"
+)
+
+const sendTimeout = 30 * time.Second
+
+// Sender sends the summary of each command to the collector
+type Sender struct {
+	profile *config.Profile
+	version string
+	host    string
+	client  *http.Client
+}
+
+func NewSender(profile *config.Profile, version string) *Sender {
+	host, err := os.Hostname()
+	if err != nil {
+		clog.Debugf("cannot get hostname: %s", err)
+		host = "unknown"
+	}
+	return &Sender{
+		profile: profile,
+		version: version,
+		host:    host,
+		client:  &http.Client{Timeout: sendTimeout},
+	}
+}
+
+func (s *Sender) Start(command string) {
+	// nothing to do here
+}
+
+func (s *Sender) Status(status monitor.Status) {
+	// we don't report any progress here
+}
+
+func (s *Sender) Summary(command string, summary monitor.Summary, stderr string, result error) {
+	if s.profile.CollectorURL.Value() == "" {
+		return
+	}
+	run := NewRun(s.host, summary.ReportName(s.profile.Name), command, s.version, summary, result)
+	if err := s.send(run); err != nil {
+		// not important enough to throw an error here
+		clog.Warningf("sending summary to collector %q: %v", s.profile.CollectorURL.String(), err)
+	}
+}
+
+func (s *Sender) send(run Run) error {
+	endpoint, err := url.JoinPath(s.profile.CollectorURL.Value(), APIPath)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(run)
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "resticprofile/"+s.version)
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode >= 300 {
+		return fmt.Errorf("unexpected response: %s", response.Status)
+	}
+	return nil
+}
+
+// Verify interface
+var _ monitor.Receiver = &Sender{}
@@ -0,0 +1,44 @@
+package collector
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/monitor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSenderPostsSummary(t *testing.T) {
+	store := NewStore("")
+	server := httptest.NewServer(NewServer(store, "secret"))
+	defer server.Close()
+
+	profile := config.NewProfile(nil, "profile")
+	profile.CollectorURL = config.NewConfidentialValue(strings.Replace(server.URL, "://", "://user:secret@", 1))
+	sender := NewSender(profile, "1.0")
+
+	sender.Summary("backup", monitor.Summary{Duration: 2 * time.Second, FilesNew: 3}, "", nil)
+	sender.Summary("check", monitor.Summary{}, "", errors.New("failed"))
+
+	runs := store.Runs()
+	require.Len(t, runs, 2)
+	assert.Equal(t, "profile", runs[0].Profile)
+	assert.Equal(t, "backup", runs[0].Command)
+	assert.True(t, runs[0].Success)
+	assert.Equal(t, int64(2), runs[0].Duration)
+	assert.Equal(t, 3, runs[0].FilesNew)
+	assert.Equal(t, "1.0", runs[0].Version)
+	assert.False(t, runs[1].Success)
+	assert.Equal(t, "failed", runs[1].Error)
+}
+
+func TestSenderWarning(t *testing.T) {
+	run := NewRun("host", "profile", "backup", "", monitor.Summary{}, &monitor.InternalWarning{})
+	assert.False(t, run.Success)
+	assert.True(t, run.Warning)
+}
@@ -0,0 +1,105 @@
+package collector
+
+import (
+	"crypto/subtle"
+	_ "embed"
+	"encoding/json"
+	"html/template"
+	"io"
+	"net/http"
+
+	"github.com/creativeprojects/clog"
+)
+
+const maxRunSize = 64 * 1024
+
+//go:embed status.gohtml
+var statusPage string
+
+var statusTemplate = template.Must(template.New("status").Funcs(template.FuncMap{
+	"status": func(run Run) string {
+		switch {
+		case run.Success:
+			return "success"
+		case run.Warning:
+			return "warning"
+		default:
+			return "error"
+		}
+	},
+}).Parse(statusPage))
+
+// Server receives the runs from the hosts and serves the status of the fleet:
+//   - POST /api/v1/runs records a run
+//   - GET /api/v1/runs returns the latest runs in JSON format
+//   - GET / displays the latest runs as an HTML page
+type Server struct {
+	store    *Store
+	password string
+	mux      *http.ServeMux
+}
+
+// NewServer creates the collector handler. When password is not empty, requests must authenticate with HTTP basic authentication.
+func NewServer(store *Store, password string) *Server {
+	server := &Server{
+		store:    store,
+		password: password,
+		mux:      http.NewServeMux(),
+	}
+	server.mux.HandleFunc(APIPath, server.handleRuns)
+	server.mux.HandleFunc("/", server.handlePage)
+	return server
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.password != "" {
+		_, password, ok := r.BasicAuth()
+		if !ok || subtle.ConstantTimeCompare([]byte(password), []byte(s.password)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Basic realm="resticprofile"`)
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) handleRuns(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(s.store.Runs())
+
+	case http.MethodPost:
+		run := Run{}
+		if err := json.NewDecoder(io.LimitReader(r.Body, maxRunSize)).Decode(&run); err != nil {
+			http.Error(w, "invalid run: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if !run.valid() {
+			http.Error(w, "invalid run: host, profile, command and time are required", http.StatusBadRequest)
+			return
+		}
+		if err := s.store.Add(run); err != nil {
+			clog.Errorf("collector: cannot save run from %s: %s", run.Host, err)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		clog.Debugf("collector: received %s/%s from %s", run.Profile, run.Command, run.Host)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handlePage(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := statusTemplate.Execute(w, s.store.Runs()); err != nil {
+		clog.Errorf("collector: %s", err)
+	}
+}
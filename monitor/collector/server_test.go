@@ -0,0 +1,70 @@
+package collector
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer(t *testing.T) {
+	server := httptest.NewServer(NewServer(NewStore(""), ""))
+	defer server.Close()
+
+	post := func(body string) int {
+		response, err := http.Post(server.URL+APIPath, "application/json", strings.NewReader(body))
+		require.NoError(t, err)
+		response.Body.Close()
+		return response.StatusCode
+	}
+	assert.Equal(t, http.StatusNoContent, post(`{"host":"h","profile":"p","command":"backup","time":"2023-05-10T10:00:00Z","success":true}`))
+	assert.Equal(t, http.StatusNoContent, post(`{"host":"h","profile":"p","command":"check","time":"2023-05-10T11:00:00Z","error":"<failed>"}`))
+	assert.Equal(t, http.StatusBadRequest, post(`{"host":"h"}`))
+	assert.Equal(t, http.StatusBadRequest, post(`not json`))
+
+	response, err := http.Get(server.URL + APIPath)
+	require.NoError(t, err)
+	defer response.Body.Close()
+	runs := make([]Run, 0)
+	require.NoError(t, json.NewDecoder(response.Body).Decode(&runs))
+	require.Len(t, runs, 2)
+	assert.Equal(t, "backup", runs[0].Command)
+	assert.Equal(t, "<failed>", runs[1].Error)
+
+	page, err := http.Get(server.URL + "/")
+	require.NoError(t, err)
+	defer page.Body.Close()
+	content := &strings.Builder{}
+	_, err = io.Copy(content, page.Body)
+	require.NoError(t, err)
+	assert.Contains(t, content.String(), `<tr class="error">`)
+	assert.Contains(t, content.String(), "&lt;failed&gt;")
+
+	notFound, err := http.Get(server.URL + "/other")
+	require.NoError(t, err)
+	notFound.Body.Close()
+	assert.Equal(t, http.StatusNotFound, notFound.StatusCode)
+}
+
+func TestServerAuthentication(t *testing.T) {
+	server := httptest.NewServer(NewServer(NewStore(""), "secret"))
+	defer server.Close()
+
+	response, err := http.Get(server.URL + APIPath)
+	require.NoError(t, err)
+	response.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+
+	request, err := http.NewRequest(http.MethodGet, server.URL+APIPath, nil)
+	require.NoError(t, err)
+	request.SetBasicAuth("any", "secret")
+	response, err = http.DefaultClient.Do(request)
+	require.NoError(t, err)
+	response.Body.Close()
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+}
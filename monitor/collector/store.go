@@ -0,0 +1,121 @@
+package collector
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const storeOpenTimeout = 5 * time.Second
+
+var runsBucket = []byte("runs")
+
+// Store keeps the latest run of each command of each profile of each host.
+// The store is persisted in a bbolt database when a filename is specified.
+type Store struct {
+	filename string
+	db       *bolt.DB
+	runs     map[string]Run
+	mutex    sync.RWMutex
+}
+
+// NewStore creates a store persisted to filename (in memory only when filename is empty)
+func NewStore(filename string) *Store {
+	return &Store{
+		filename: filename,
+		runs:     make(map[string]Run),
+	}
+}
+
+// Load opens the database and reads the runs previously saved; a missing database is created
+func (s *Store) Load() error {
+	if s.filename == "" {
+		return nil
+	}
+	db, err := bolt.Open(s.filename, 0o600, &bolt.Options{Timeout: storeOpenTimeout})
+	if err != nil {
+		return fmt.Errorf("cannot open collector store %q: %w", s.filename, err)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	err = db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(runsBucket)
+		if err != nil {
+			return err
+		}
+		return bucket.ForEach(func(key, value []byte) error {
+			run := Run{}
+			if err := json.Unmarshal(value, &run); err != nil {
+				return fmt.Errorf("run %q: %w", key, err)
+			}
+			s.runs[string(key)] = run
+			return nil
+		})
+	})
+	if err != nil {
+		_ = db.Close()
+		return fmt.Errorf("cannot read collector store %q: %w", s.filename, err)
+	}
+	s.db = db
+	return nil
+}
+
+// Close closes the database
+func (s *Store) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.db == nil {
+		return nil
+	}
+	err := s.db.Close()
+	s.db = nil
+	return err
+}
+
+// Add records the run, replacing the previous run of the same command
+func (s *Store) Add(run Run) error {
+	if !run.valid() {
+		return errors.New("host, profile, command and time are required")
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := s.save(run); err != nil {
+		return err
+	}
+	s.runs[run.key()] = run
+	return nil
+}
+
+// Runs returns the latest runs sorted by host, profile and command
+func (s *Store) Runs() []Run {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	runs := make([]Run, 0, len(s.runs))
+	for _, run := range s.runs {
+		runs = append(runs, run)
+	}
+	sort.Slice(runs, func(i, j int) bool { return runs[i].key() < runs[j].key() })
+	return runs
+}
+
+// save writes the run in the database, replacing the previous run of the same command
+func (s *Store) save(run Run) error {
+	if s.db == nil {
+		return nil
+	}
+	data, err := json.Marshal(run)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(runsBucket).Put([]byte(run.key()), data)
+	})
+}
@@ -0,0 +1,43 @@
+package collector
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreKeepsLatestRun(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "collector.db")
+	store := NewStore(filename)
+	require.NoError(t, store.Load())
+
+	now := time.Now().Round(time.Second)
+	require.NoError(t, store.Add(Run{Host: "host2", Profile: "profile", Command: "backup", Time: now}))
+	require.NoError(t, store.Add(Run{Host: "host1", Profile: "profile", Command: "backup", Time: now, Error: "first"}))
+	require.NoError(t, store.Add(Run{Host: "host1", Profile: "profile", Command: "backup", Time: now, Success: true}))
+	assert.Error(t, store.Add(Run{Host: "host1", Profile: "profile"}))
+
+	runs := store.Runs()
+	require.Len(t, runs, 2)
+	assert.Equal(t, "host1", runs[0].Host)
+	assert.True(t, runs[0].Success)
+	assert.Equal(t, "host2", runs[1].Host)
+	require.NoError(t, store.Close())
+
+	reloaded := NewStore(filename)
+	require.NoError(t, reloaded.Load())
+	defer reloaded.Close()
+	assert.Len(t, reloaded.Runs(), 2)
+	assert.True(t, reloaded.Runs()[0].Time.Equal(now))
+}
+
+func TestStoreInMemory(t *testing.T) {
+	store := NewStore("")
+	require.NoError(t, store.Load())
+	require.NoError(t, store.Add(Run{Host: "host", Profile: "profile", Command: "backup", Time: time.Now()}))
+	assert.Len(t, store.Runs(), 1)
+	assert.NoError(t, store.Close())
+}
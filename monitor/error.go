@@ -1,12 +1,84 @@
 package monitor
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"net"
+	"os"
 	"os/exec"
 
 	"github.com/creativeprojects/resticprofile/constants"
 )
 
+// Codes of the cause of a failure: they're part of the JSON output, the status file and the monitoring payloads,
+// and must stay the same across versions. A failure of restic is "restic-exit-<exit code>" (see ResticErrorCode).
+const (
+	ErrorCodeConfig      = "config"
+	ErrorCodeLock        = "lock"
+	ErrorCodeHook        = "hook"
+	ErrorCodeNetwork     = "network"
+	ErrorCodeTimeout     = "timeout"
+	ErrorCodeInterrupted = "interrupted"
+	ErrorCodeMemoryLimit = "memory-limit"
+	ErrorCodeUnknown     = "error"
+)
+
+// ResticErrorCode returns the code of a failure of restic with the exit code
+func ResticErrorCode(exitCode int) string {
+	return fmt.Sprintf("restic-exit-%d", exitCode)
+}
+
+// CodedError is an error knowing the code of its cause
+type CodedError interface {
+	error
+	ErrorCode() string
+}
+
+type codedError struct {
+	code string
+	err  error
+}
+
+// NewCodedError returns the error with the code of its cause, or nil when err is nil
+func NewCodedError(code string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &codedError{code: code, err: err}
+}
+
+func (e *codedError) Error() string     { return e.err.Error() }
+func (e *codedError) Unwrap() error     { return e.err }
+func (e *codedError) ErrorCode() string { return e.code }
+
+// ErrorCode returns the code of the cause of the error (empty when there's no error).
+// The code of a CodedError takes precedence, then timeouts, network errors and exit codes are recognized.
+func ErrorCode(err error) string {
+	if err == nil {
+		return ""
+	}
+	var coded CodedError
+	if errors.As(err, &coded) && coded.ErrorCode() != "" {
+		return coded.ErrorCode()
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, os.ErrDeadlineExceeded) {
+		return ErrorCodeTimeout
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return ErrorCodeTimeout
+		}
+		return ErrorCodeNetwork
+	}
+	exitErr := &exec.ExitError{}
+	if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 {
+		return ResticErrorCode(exitErr.ExitCode())
+	}
+	return ErrorCodeUnknown
+}
+
 func IsSuccess(err error) bool {
 	return err == nil
 }
@@ -0,0 +1,39 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorCode(t *testing.T) {
+	exitErr := exec.Command("go", "tool", "-unknown-flag").Run()
+	require.Error(t, exitErr)
+	var exitError *exec.ExitError
+	require.ErrorAs(t, exitErr, &exitError)
+
+	fixtures := []struct {
+		err  error
+		code string
+	}{
+		{nil, ""},
+		{errors.New("error"), ErrorCodeUnknown},
+		{NewCodedError(ErrorCodeLock, errors.New("locked")), ErrorCodeLock},
+		{fmt.Errorf("wrapped: %w", NewCodedError(ErrorCodeHook, errors.New("hook"))), ErrorCodeHook},
+		{fmt.Errorf("wrapped: %w", context.DeadlineExceeded), ErrorCodeTimeout},
+		{&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, ErrorCodeNetwork},
+		{&net.DNSError{Err: "timeout", IsTimeout: true}, ErrorCodeTimeout},
+		{exitErr, ResticErrorCode(exitError.ExitCode())},
+	}
+	for _, fixture := range fixtures {
+		assert.Equal(t, fixture.code, ErrorCode(fixture.err), fixture.err)
+	}
+	assert.Nil(t, NewCodedError(ErrorCodeConfig, nil))
+	assert.Equal(t, "restic-exit-12", ResticErrorCode(12))
+}
@@ -0,0 +1,234 @@
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/creativeprojects/resticprofile/monitor"
+	"github.com/creativeprojects/resticprofile/util/crypt"
+	"github.com/creativeprojects/resticprofile/util/filelock"
+)
+
+// lockTimeout is the maximum time to wait for another process writing to the history file
+const lockTimeout = 30 * time.Second
+
+// maxStderrSize is the maximum size of the error output kept in each entry
+const maxStderrSize = 4 * 1024
+
+// Entry is the record of a command run by resticprofile
+type Entry struct {
+	Time           time.Time `json:"time"`
+	Profile        string    `json:"profile"`
+	Command        string    `json:"command"`
+	Success        bool      `json:"success"`
+	Warning        bool      `json:"warning,omitempty"`
+	Error          string    `json:"error,omitempty"`
+	ErrorCode      string    `json:"error_code,omitempty"`
+	Stderr         string    `json:"stderr,omitempty"`
+	ConfigIssues   []string  `json:"config_issues,omitempty"`
+	ConfigHash     string    `json:"config_hash,omitempty"`
+	Duration       float64   `json:"duration"`
+	FilesNew       int       `json:"files_new,omitempty"`
+	FilesChanged   int       `json:"files_changed,omitempty"`
+	FilesTotal     int       `json:"files_total,omitempty"`
+	BytesAdded     uint64    `json:"bytes_added,omitempty"`
+	BytesTotal     uint64    `json:"bytes_total,omitempty"`
+	RepositorySize uint64    `json:"repository_size,omitempty"` // size of the repository after a backup (when known)
+}
+
+// NewEntry creates the record of a command
+func NewEntry(profile, command string, summary monitor.Summary, stderr string, result error) Entry {
+	entry := Entry{
+		Time:         time.Now(),
+		Profile:      profile,
+		Command:      command,
+		Success:      monitor.IsSuccess(result),
+		Warning:      monitor.IsWarning(result),
+		Duration:     summary.Duration.Seconds(),
+		FilesNew:     summary.FilesNew,
+		FilesChanged: summary.FilesChanged,
+		FilesTotal:   summary.FilesTotal,
+		BytesAdded:   summary.BytesAdded,
+		BytesTotal:   summary.BytesTotal,
+		ConfigIssues: summary.ConfigIssues,
+		ConfigHash:   summary.ConfigHash,
+	}
+	if summary.Storage != nil {
+		entry.RepositorySize = summary.Storage.RepositorySize
+	}
+	if result != nil {
+		entry.Error = result.Error()
+		entry.ErrorCode = summary.ErrorCode
+		if len(stderr) > maxStderrSize {
+			stderr = stderr[len(stderr)-maxStderrSize:]
+		}
+		entry.Stderr = stderr
+	}
+	return entry
+}
+
+// History is a file recording one entry per line (JSON lines format), oldest first.
+// Each line is encrypted when an encryption key is configured.
+type History struct {
+	filename string
+	key      *crypt.Key
+}
+
+func NewHistory(filename string) *History {
+	return &History{filename: filename, key: crypt.DefaultKey()}
+}
+
+// encode returns the line of the entry (without line feed)
+func (h *History) encode(entry Entry) ([]byte, error) {
+	data, err := json.Marshal(entry)
+	if err != nil || h.key == nil {
+		return data, err
+	}
+	return h.key.Encrypt(data)
+}
+
+// decode returns the entry of a line (plain or encrypted)
+func (h *History) decode(data []byte) (entry Entry, err error) {
+	if crypt.IsEncrypted(data) {
+		if h.key == nil {
+			return entry, crypt.ErrNoKey
+		}
+		if data, err = h.key.Decrypt(data); err != nil {
+			return
+		}
+	}
+	err = json.Unmarshal(data, &entry)
+	return
+}
+
+// Add appends the entry to the history file
+func (h *History) Add(entry Entry) error {
+	data, err := h.encode(entry)
+	if err != nil {
+		return err
+	}
+	unlock, err := filelock.Lock(h.filename, lockTimeout)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	file, err := os.OpenFile(h.filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = file.Write(append(data, '\n'))
+	return err
+}
+
+// Filter selects entries from the history
+type Filter struct {
+	Profile string
+	Command string
+	Since   time.Time
+}
+
+func (f Filter) match(entry Entry) bool {
+	return (f.Profile == "" || f.Profile == entry.Profile) &&
+		(f.Command == "" || f.Command == entry.Command) &&
+		(f.Since.IsZero() || !entry.Time.Before(f.Since))
+}
+
+// List returns the entries matching the filter, oldest first. A missing history file has no entry.
+func (h *History) List(filter Filter) ([]Entry, error) {
+	entries, err := h.readAll()
+	if err != nil {
+		return nil, err
+	}
+	matching := make([]Entry, 0, len(entries))
+	for _, entry := range entries {
+		if filter.match(entry) {
+			matching = append(matching, entry)
+		}
+	}
+	return matching, nil
+}
+
+// Prune removes the entries older than the specified time, and returns the number of entries removed.
+// The history file is locked during the rewrite: an entry added at the same time is not lost.
+func (h *History) Prune(before time.Time) (int, error) {
+	unlock, err := filelock.Lock(h.filename, lockTimeout)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	entries, err := h.readAll()
+	if err != nil || len(entries) == 0 {
+		return 0, err
+	}
+	buffer := &bytes.Buffer{}
+	removed := 0
+	for _, entry := range entries {
+		if entry.Time.Before(before) {
+			removed++
+			continue
+		}
+		data, err := h.encode(entry)
+		if err != nil {
+			return 0, err
+		}
+		buffer.Write(append(data, '\n'))
+	}
+	if removed == 0 {
+		return 0, nil
+	}
+	return removed, h.replace(buffer.Bytes())
+}
+
+func (h *History) readAll() ([]Entry, error) {
+	file, err := os.Open(h.filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	entries := make([]Entry, 0)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		entry, err := h.decode(data)
+		if err != nil {
+			return nil, fmt.Errorf("history file %q line %d: %w", h.filename, line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// replace writes the content to a temporary file before replacing the history file
+func (h *History) replace(content []byte) error {
+	temp, err := os.CreateTemp(filepath.Dir(h.filename), filepath.Base(h.filename)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+	if _, err = temp.Write(content); err != nil {
+		temp.Close()
+		return err
+	}
+	if err = temp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(temp.Name(), h.filename)
+}
@@ -0,0 +1,164 @@
+package history
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/monitor"
+	"github.com/creativeprojects/resticprofile/util/crypt"
+	"github.com/creativeprojects/resticprofile/util/filelock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewEntry(t *testing.T) {
+	entry := NewEntry("profile", "backup", monitor.Summary{Duration: 1500 * time.Millisecond, FilesNew: 2}, "ignored", nil)
+	assert.True(t, entry.Success)
+	assert.Equal(t, 1.5, entry.Duration)
+	assert.Equal(t, 2, entry.FilesNew)
+	assert.Empty(t, entry.Stderr)
+
+	stderr := strings.Repeat("a", maxStderrSize) + "end"
+	entry = NewEntry("profile", "check", monitor.Summary{}, stderr, errors.New("failed"))
+	assert.False(t, entry.Success)
+	assert.Equal(t, "failed", entry.Error)
+	assert.Len(t, entry.Stderr, maxStderrSize)
+	assert.True(t, strings.HasSuffix(entry.Stderr, "end"))
+
+	entry = NewEntry("profile", "backup", monitor.Summary{ConfigIssues: []string{"deprecated"}}, "", nil)
+	assert.Equal(t, []string{"deprecated"}, entry.ConfigIssues)
+
+	entry = NewEntry("profile", "backup", monitor.Summary{Storage: &monitor.StorageSummary{RepositorySize: 1024}}, "", nil)
+	assert.Equal(t, uint64(1024), entry.RepositorySize)
+}
+
+func TestHistory(t *testing.T) {
+	history := NewHistory(filepath.Join(t.TempDir(), "history.jsonl"))
+
+	entries, err := history.List(Filter{})
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	now := time.Now()
+	require.NoError(t, history.Add(Entry{Time: now.Add(-48 * time.Hour), Profile: "one", Command: "backup"}))
+	require.NoError(t, history.Add(Entry{Time: now.Add(-time.Hour), Profile: "one", Command: "check"}))
+	require.NoError(t, history.Add(Entry{Time: now, Profile: "two", Command: "backup"}))
+
+	entries, err = history.List(Filter{Profile: "one"})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "backup", entries[0].Command)
+
+	entries, err = history.List(Filter{Command: "backup", Since: now.Add(-2 * time.Hour)})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "two", entries[0].Profile)
+
+	removed, err := history.Prune(now.Add(-24 * time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+	entries, err = history.List(Filter{})
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+}
+
+func TestPruneWaitsForTheLock(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "history.jsonl")
+	history := NewHistory(filename)
+	now := time.Now()
+	require.NoError(t, history.Add(Entry{Time: now.Add(-48 * time.Hour), Profile: "one", Command: "backup"}))
+
+	unlock, err := filelock.Lock(filename, time.Second)
+	require.NoError(t, err)
+
+	done := make(chan int)
+	go func() {
+		removed, _ := history.Prune(now.Add(-24 * time.Hour))
+		done <- removed
+	}()
+	select {
+	case <-done:
+		t.Fatal("prune didn't wait for the lock")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// an entry added by another process while the lock is held
+	file, err := os.OpenFile(filename, os.O_WRONLY|os.O_APPEND, 0644)
+	require.NoError(t, err)
+	data, err := history.encode(Entry{Time: now, Profile: "two", Command: "backup"})
+	require.NoError(t, err)
+	_, err = file.Write(append(data, '\n'))
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+	unlock()
+
+	assert.Equal(t, 1, <-done)
+	entries, err := history.List(Filter{})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "two", entries[0].Profile)
+}
+
+func TestInvalidHistory(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "history.jsonl")
+	require.NoError(t, os.WriteFile(filename, []byte("{}\nnot json\n"), 0o600))
+	_, err := NewHistory(filename).List(Filter{})
+	assert.ErrorContains(t, err, "line 2")
+}
+
+func TestEncryptedHistory(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "history.jsonl")
+	now := time.Now()
+	// an existing plain entry
+	require.NoError(t, NewHistory(filename).Add(Entry{Time: now.Add(-48 * time.Hour), Profile: "plain", Command: "backup"}))
+
+	key, err := crypt.NewKey([]byte("0123456789abcdef"))
+	require.NoError(t, err)
+	history := &History{filename: filename, key: key}
+	require.NoError(t, history.Add(Entry{Time: now, Profile: "secret", Command: "backup"}))
+
+	content, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[0], "plain")
+	assert.True(t, crypt.IsEncrypted([]byte(lines[1])))
+	assert.NotContains(t, lines[1], "secret")
+
+	entries, err := history.List(Filter{})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "secret", entries[1].Profile)
+
+	// pruning encrypts the remaining entries
+	removed, err := history.Prune(now.Add(-24 * time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+	content, err = os.ReadFile(filename)
+	require.NoError(t, err)
+	assert.True(t, crypt.IsEncrypted(content))
+
+	_, err = NewHistory(filename).List(Filter{})
+	assert.ErrorIs(t, err, crypt.ErrNoKey)
+}
+
+func TestProgress(t *testing.T) {
+	profile := config.NewProfile(nil, "profile")
+	profile.HistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	profile.HistoryRetention = time.Hour
+	history := NewHistory(profile.HistoryFile)
+	require.NoError(t, history.Add(Entry{Time: time.Now().Add(-2 * time.Hour), Profile: "profile", Command: "old"}))
+
+	progress := NewProgress(profile, history)
+	progress.Summary("backup", monitor.Summary{}, "", nil)
+
+	entries, err := history.List(Filter{})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "backup", entries[0].Command)
+}
@@ -0,0 +1,50 @@
+package history
+
+import (
+	"time"
+
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/monitor"
+)
+
+// Progress records every command of the profile in the history file
+type Progress struct {
+	profile *config.Profile
+	history *History
+}
+
+func NewProgress(profile *config.Profile, history *History) *Progress {
+	return &Progress{
+		profile: profile,
+		history: history,
+	}
+}
+
+func (p *Progress) Start(command string) {
+	// nothing to do here
+}
+
+func (p *Progress) Status(status monitor.Status) {
+	// we don't report any progress here
+}
+
+func (p *Progress) Summary(command string, summary monitor.Summary, stderr string, result error) {
+	if p.profile.HistoryFile == "" {
+		return
+	}
+	err := p.history.Add(NewEntry(summary.ReportName(p.profile.Name), command, summary, stderr, result))
+	if err != nil {
+		// not important enough to throw an error here
+		clog.Warningf("saving history file '%s': %v", p.profile.HistoryFile, err)
+		return
+	}
+	if p.profile.HistoryRetention > 0 {
+		if _, err = p.history.Prune(time.Now().Add(-p.profile.HistoryRetention)); err != nil {
+			clog.Warningf("pruning history file '%s': %v", p.profile.HistoryFile, err)
+		}
+	}
+}
+
+// Verify interface
+var _ monitor.Receiver = &Progress{}
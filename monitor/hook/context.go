@@ -1,6 +1,11 @@
 package hook
 
-import "github.com/creativeprojects/resticprofile/util/templates"
+import (
+	"strings"
+
+	"github.com/creativeprojects/resticprofile/monitor"
+	"github.com/creativeprojects/resticprofile/util/templates"
+)
 
 type Context struct {
 	templates.DefaultData
@@ -8,10 +13,21 @@ type Context struct {
 	ProfileCommand string
 	Error          ErrorContext
 	Stdout         string
+	Output         string // end of the output of a failed run, when the sender has "attach-output"
+	Diff           *monitor.DiffSummary
+	Summary        monitor.Summary // summary of the command of the profile, once it ran
+	ConfigIssues   []string
+	Anomalies      []string // anomalies detected in the statistics of the backup
+}
+
+// ConfigIssuesText returns the issues found in the configuration, one per line
+func (c Context) ConfigIssuesText() string {
+	return strings.Join(c.ConfigIssues, "\n")
 }
 
 type ErrorContext struct {
 	Message     string
+	Code        string // code of the cause of the failure (see monitor.ErrorCode)
 	CommandLine string
 	ExitCode    string
 	Stderr      string
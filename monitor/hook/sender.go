@@ -7,7 +7,9 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
+	"net/textproto"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -18,9 +20,19 @@ import (
 	"github.com/creativeprojects/clog"
 	"github.com/creativeprojects/resticprofile/config"
 	"github.com/creativeprojects/resticprofile/constants"
+	"github.com/creativeprojects/resticprofile/util/redact"
 	"github.com/creativeprojects/resticprofile/util/templates"
 )
 
+// Values of "attach-output"
+const (
+	attachInline    = "inline"
+	attachMultipart = "multipart"
+
+	// multipartBodyField is the name of the body in a multipart request
+	multipartBodyField = "body"
+)
+
 type Sender struct {
 	client         *http.Client
 	insecureClient *http.Client
@@ -81,24 +93,47 @@ func (s *Sender) Send(cfg config.SendMonitoringSection, ctx Context) error {
 		if err != nil {
 			return err
 		}
-		body = bodyTemplate
+		body = redact.String(bodyTemplate)
 		bodyReader = bytes.NewBufferString(body)
 	}
 	if cfg.Body != "" {
-		body = resolve(cfg.Body, ctx)
+		body = redact.String(resolve(cfg.Body, ctx))
 		bodyReader = bytes.NewBufferString(body)
 	}
 
+	// the output of a failed run is sent as the body, or next to it in a multipart request
+	attachFile := cfg.AttachOutput == attachMultipart && ctx.Output != ""
+	bodyContentType := ""
+	if cfg.AttachOutput == attachInline && ctx.Output != "" && cfg.Body == "" && cfg.BodyTemplate == "" {
+		body = ctx.Output
+		bodyReader = bytes.NewBufferString(body)
+		bodyContentType = "text/plain; charset=utf-8"
+	}
+	if attachFile {
+		for _, header := range cfg.Headers {
+			if http.CanonicalHeaderKey(header.Name) == "Content-Type" {
+				bodyContentType = header.Value.Value()
+			}
+		}
+		var err error
+		if bodyReader, bodyContentType, err = multipartBody(body, bodyContentType, cfg.AttachOutputField, ctx); err != nil {
+			return err
+		}
+	}
+
 	req, err := http.NewRequest(method, url, bodyReader)
 	if err != nil {
 		return err
 	}
 	for _, header := range cfg.Headers {
-		if header.Name == "" {
+		if header.Name == "" || (attachFile && http.CanonicalHeaderKey(header.Name) == "Content-Type") {
 			continue
 		}
 		req.Header.Add(header.Name, header.Value.Value())
 	}
+	if bodyContentType != "" && (attachFile || req.Header.Get("Content-Type") == "") {
+		req.Header.Set("Content-Type", bodyContentType)
+	}
 	s.setUserAgent(req)
 
 	client := s.client
@@ -111,6 +146,9 @@ func (s *Sender) Send(cfg config.SendMonitoringSection, ctx Context) error {
 		if len(body) > 0 {
 			clog.Infof("dry-run: webhook request body:\n%s", body)
 		}
+		if attachFile {
+			clog.Infof("dry-run: webhook request output attached as multipart field %q (%d bytes)", outputField(cfg.AttachOutputField), len(ctx.Output))
+		}
 		return nil
 	}
 
@@ -200,6 +238,9 @@ func resolve(body string, ctx Context) string {
 		case constants.EnvError:
 			return ctx.Error.Message
 
+		case constants.EnvErrorCode:
+			return ctx.Error.Code
+
 		case constants.EnvErrorCommandLine:
 			return ctx.Error.CommandLine
 
@@ -209,13 +250,64 @@ func resolve(body string, ctx Context) string {
 		case constants.EnvErrorStderr:
 			return ctx.Error.Stderr
 
+		case constants.EnvConfigIssues:
+			return ctx.ConfigIssuesText()
+
+		case constants.EnvErrorOutput:
+			return ctx.Output
+
+		case constants.EnvAnomaly:
+			return strings.Join(ctx.Anomalies, "\n")
+
 		default:
+			if value, found := ctx.Summary.Environment()[s]; found {
+				return value
+			}
 			return os.Getenv(s)
 		}
 	})
 	return body
 }
 
+// multipartBody returns a multipart/form-data body with the body (if any) and the output of the run attached as a file
+func multipartBody(body, bodyContentType, field string, ctx Context) (io.Reader, string, error) {
+	buffer := &bytes.Buffer{}
+	writer := multipart.NewWriter(buffer)
+	if body != "" {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Disposition", `form-data; name="`+multipartBodyField+`"`)
+		if bodyContentType != "" {
+			header.Set("Content-Type", bodyContentType)
+		}
+		part, err := writer.CreatePart(header)
+		if err != nil {
+			return nil, "", err
+		}
+		if _, err = io.WriteString(part, body); err != nil {
+			return nil, "", err
+		}
+	}
+	filename := fmt.Sprintf("%s-%s.log", ctx.ProfileName, ctx.ProfileCommand)
+	part, err := writer.CreateFormFile(outputField(field), filename)
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err = io.WriteString(part, ctx.Output); err != nil {
+		return nil, "", err
+	}
+	if err = writer.Close(); err != nil {
+		return nil, "", err
+	}
+	return buffer, writer.FormDataContentType(), nil
+}
+
+func outputField(field string) string {
+	if field == "" {
+		return constants.DefaultAttachOutputField
+	}
+	return field
+}
+
 func loadBodyTemplate(filename string, ctx Context) (string, error) {
 	tmpl, err := templates.New(filepath.Base(filename)).ParseFiles(filename)
 	if err != nil {
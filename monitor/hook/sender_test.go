@@ -15,6 +15,7 @@ import (
 
 	"github.com/creativeprojects/clog"
 	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/monitor"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -96,6 +97,70 @@ func TestSend(t *testing.T) {
 	}
 }
 
+func TestSendInlineOutput(t *testing.T) {
+	var contentType, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		data, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		body = string(data)
+	}))
+	defer server.Close()
+
+	sender := NewSender(nil, "resticprofile_test", time.Second, false)
+	ctx := Context{ProfileName: "profile", ProfileCommand: "backup", Output: "last lines\n"}
+
+	// output as the body
+	cfg := config.SendMonitoringSection{Method: http.MethodPost, URL: config.NewConfidentialValue(server.URL), AttachOutput: "inline"}
+	require.NoError(t, sender.Send(cfg, ctx))
+	assert.Equal(t, "text/plain; charset=utf-8", contentType)
+	assert.Equal(t, "last lines\n", body)
+
+	// output in the body
+	cfg.Body = "$PROFILE_NAME failed:\n$ERROR_OUTPUT"
+	cfg.Headers = []config.SendMonitoringHeader{{Name: "Content-Type", Value: config.NewConfidentialValue("text/markdown")}}
+	require.NoError(t, sender.Send(cfg, ctx))
+	assert.Equal(t, "text/markdown", contentType)
+	assert.Equal(t, "profile failed:\nlast lines\n", body)
+}
+
+func TestSendMultipartOutput(t *testing.T) {
+	var fields map[string]string
+	var filename string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fields = map[string]string{}
+		require.NoError(t, r.ParseMultipartForm(1024))
+		for name, values := range r.MultipartForm.Value {
+			fields[name] = values[0]
+		}
+		for name, files := range r.MultipartForm.File {
+			filename = files[0].Filename
+			file, err := files[0].Open()
+			require.NoError(t, err)
+			data, err := io.ReadAll(file)
+			require.NoError(t, err)
+			fields[name] = string(data)
+		}
+	}))
+	defer server.Close()
+
+	sender := NewSender(nil, "resticprofile_test", time.Second, false)
+	cfg := config.SendMonitoringSection{
+		Method:       http.MethodPost,
+		URL:          config.NewConfidentialValue(server.URL),
+		Body:         `{"text":"$PROFILE_NAME failed"}`,
+		Headers:      []config.SendMonitoringHeader{{Name: "Content-Type", Value: config.NewConfidentialValue("application/json")}},
+		AttachOutput: "multipart",
+	}
+	require.NoError(t, sender.Send(cfg, Context{ProfileName: "profile", ProfileCommand: "backup", Output: "last lines\n"}))
+	assert.Equal(t, map[string]string{"body": `{"text":"profile failed"}`, "output": "last lines\n"}, fields)
+	assert.Equal(t, "profile-backup.log", filename)
+
+	cfg.AttachOutputField = "file"
+	require.NoError(t, sender.Send(cfg, Context{ProfileName: "profile", ProfileCommand: "backup", Output: "output"}))
+	assert.Equal(t, "output", fields["file"])
+}
+
 func TestDryRun(t *testing.T) {
 	var calls uint32
 	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -281,3 +346,42 @@ func TestResponseSanitizer(t *testing.T) {
 		assert.Equal(t, test[1], responseContentSanitizer.ReplaceAllString(test[0], " "), "test #%d", i)
 	}
 }
+
+func TestResolveConfigIssues(t *testing.T) {
+	ctx := Context{
+		ProfileName:  "test_profile",
+		ConfigIssues: []string{"first issue", "second issue"},
+	}
+	assert.Equal(t, "test_profile: first issue\nsecond issue", resolve("$PROFILE_NAME: $CONFIG_ISSUES", ctx))
+
+	template := `{{ range .ConfigIssues }}- {{ . }};{{ end }}`
+	filename := filepath.Join(t.TempDir(), "body.txt")
+	require.NoError(t, os.WriteFile(filename, []byte(template), 0o600))
+
+	result, err := loadBodyTemplate(filename, ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "- first issue;- second issue;", result)
+}
+
+func TestResolveSummary(t *testing.T) {
+	ctx := Context{
+		ProfileName: "test_profile",
+		Summary: monitor.Summary{
+			Duration:   90 * time.Second,
+			FilesNew:   3,
+			FilesTotal: 10,
+			BytesAdded: 2048,
+			SnapshotID: "6daa8ef6",
+		},
+	}
+	assert.Equal(t, "snapshot 6daa8ef6: 3 new files, 2048 bytes added in 90s",
+		resolve("snapshot $SUMMARY_SNAPSHOT_ID: $SUMMARY_FILES_NEW new files, $SUMMARY_BYTES_ADDED bytes added in ${SUMMARY_DURATION}s", ctx))
+
+	template := `{{ .Summary.SnapshotID }} {{ .Summary.FilesTotal }}`
+	filename := filepath.Join(t.TempDir(), "body.txt")
+	require.NoError(t, os.WriteFile(filename, []byte(template), 0o600))
+
+	result, err := loadBodyTemplate(filename, ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "6daa8ef6 10", result)
+}
@@ -0,0 +1,239 @@
+package mqtt
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"time"
+)
+
+// Types of the MQTT 3.1.1 control packets used by the client
+const (
+	packetConnect    = 1
+	packetConnAck    = 2
+	packetPublish    = 3
+	packetPubAck     = 4
+	packetPubRec     = 5
+	packetPubRel     = 6
+	packetPubComp    = 7
+	packetDisconnect = 14
+)
+
+const (
+	protocolLevel   = 4 // MQTT 3.1.1
+	keepAlive       = 30
+	maxPacketLength = 268435455
+	defaultPort     = "1883"
+	defaultTLSPort  = "8883"
+)
+
+var connectErrors = map[byte]string{
+	1: "unacceptable protocol version",
+	2: "identifier rejected",
+	3: "server unavailable",
+	4: "bad user name or password",
+	5: "not authorized",
+}
+
+// Message is published to a topic
+type Message struct {
+	Topic   string
+	Payload []byte
+	QoS     byte
+	Retain  bool
+}
+
+// Client publishes messages with a new connection to the broker each time: the messages of a run are too few to keep a connection open.
+type Client struct {
+	Broker    *url.URL
+	ClientID  string
+	Timeout   time.Duration
+	TLSConfig *tls.Config // used when the scheme of the broker is "mqtts", "ssl" or "tls"
+	Secure    bool
+}
+
+// Publish connects to the broker, publishes the messages and disconnects
+func (c *Client) Publish(messages ...Message) error {
+	conn, err := c.dial()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(c.Timeout))
+
+	reader := bufio.NewReader(conn)
+	if err = c.connect(conn, reader); err != nil {
+		return err
+	}
+	for index, message := range messages {
+		if err = publish(conn, reader, message, uint16(index+1)); err != nil {
+			return fmt.Errorf("cannot publish to %q: %w", message.Topic, err)
+		}
+	}
+	_, err = conn.Write([]byte{packetDisconnect << 4, 0})
+	return err
+}
+
+func (c *Client) dial() (net.Conn, error) {
+	host := c.Broker.Host
+	if c.Broker.Port() == "" {
+		port := defaultPort
+		if c.Secure {
+			port = defaultTLSPort
+		}
+		host = net.JoinHostPort(c.Broker.Hostname(), port)
+	}
+	dialer := &net.Dialer{Timeout: c.Timeout}
+	if c.Secure {
+		config := c.TLSConfig
+		if config == nil {
+			config = &tls.Config{}
+		}
+		if config.ServerName == "" {
+			config = config.Clone()
+			config.ServerName = c.Broker.Hostname()
+		}
+		return tls.DialWithDialer(dialer, "tcp", host, config)
+	}
+	return dialer.Dial("tcp", host)
+}
+
+// connect sends the CONNECT packet and waits for the CONNACK
+func (c *Client) connect(conn io.Writer, reader *bufio.Reader) error {
+	flags := byte(0x02) // clean session
+	payload := appendString(nil, c.ClientID)
+	if user := c.Broker.User; user != nil {
+		flags |= 0x80
+		payload = appendString(payload, user.Username())
+		if password, ok := user.Password(); ok {
+			flags |= 0x40
+			payload = appendString(payload, password)
+		}
+	}
+	header := appendString(nil, "MQTT")
+	header = append(header, protocolLevel, flags, keepAlive>>8, keepAlive&0xff)
+	if err := writePacket(conn, packetConnect<<4, append(header, payload...)); err != nil {
+		return err
+	}
+
+	packetType, body, err := readPacket(reader)
+	if err != nil {
+		return fmt.Errorf("no answer from the broker: %w", err)
+	}
+	if packetType != packetConnAck || len(body) != 2 {
+		return fmt.Errorf("unexpected answer from the broker (packet type %d)", packetType)
+	}
+	if code := body[1]; code != 0 {
+		if message, found := connectErrors[code]; found {
+			return fmt.Errorf("connection refused: %s", message)
+		}
+		return fmt.Errorf("connection refused (code %d)", code)
+	}
+	return nil
+}
+
+// publish sends a PUBLISH packet and completes the acknowledgement of QoS 1 or 2
+func publish(conn io.Writer, reader *bufio.Reader, message Message, packetID uint16) error {
+	if message.QoS > 2 {
+		return fmt.Errorf("invalid qos %d", message.QoS)
+	}
+	flags := byte(packetPublish<<4) | message.QoS<<1
+	if message.Retain {
+		flags |= 0x01
+	}
+	body := appendString(nil, message.Topic)
+	if message.QoS > 0 {
+		body = append(body, byte(packetID>>8), byte(packetID))
+	}
+	if err := writePacket(conn, flags, append(body, message.Payload...)); err != nil {
+		return err
+	}
+
+	switch message.QoS {
+	case 1:
+		return expectAck(reader, packetPubAck, packetID)
+	case 2:
+		if err := expectAck(reader, packetPubRec, packetID); err != nil {
+			return err
+		}
+		if err := writePacket(conn, packetPubRel<<4|0x02, []byte{byte(packetID >> 8), byte(packetID)}); err != nil {
+			return err
+		}
+		return expectAck(reader, packetPubComp, packetID)
+	}
+	return nil
+}
+
+// expectAck reads the acknowledgement of the packet
+func expectAck(reader *bufio.Reader, expectedType byte, packetID uint16) error {
+	packetType, body, err := readPacket(reader)
+	if err != nil {
+		return err
+	}
+	if packetType != expectedType || len(body) != 2 || uint16(body[0])<<8|uint16(body[1]) != packetID {
+		return fmt.Errorf("unexpected acknowledgement (packet type %d)", packetType)
+	}
+	return nil
+}
+
+func writePacket(conn io.Writer, header byte, body []byte) error {
+	if len(body) > maxPacketLength {
+		return errors.New("message too large")
+	}
+	packet := append([]byte{header}, encodeLength(len(body))...)
+	_, err := conn.Write(append(packet, body...))
+	return err
+}
+
+// readPacket returns the type and the body of the next packet
+func readPacket(reader *bufio.Reader) (packetType byte, body []byte, err error) {
+	header, err := reader.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	length, multiplier := 0, 1
+	for i := 0; ; i++ {
+		if i == 4 {
+			return 0, nil, errors.New("malformed packet length")
+		}
+		var digit byte
+		if digit, err = reader.ReadByte(); err != nil {
+			return 0, nil, err
+		}
+		length += int(digit&0x7f) * multiplier
+		multiplier *= 128
+		if digit&0x80 == 0 {
+			break
+		}
+	}
+	body = make([]byte, length)
+	if _, err = io.ReadFull(reader, body); err != nil {
+		return 0, nil, err
+	}
+	return header >> 4, body, nil
+}
+
+// encodeLength encodes the remaining length of a packet (7 bits per byte)
+func encodeLength(length int) (encoded []byte) {
+	for {
+		digit := byte(length % 128)
+		length /= 128
+		if length > 0 {
+			digit |= 0x80
+		}
+		encoded = append(encoded, digit)
+		if length == 0 {
+			return
+		}
+	}
+}
+
+// appendString appends a string prefixed with its length
+func appendString(buffer []byte, value string) []byte {
+	buffer = append(buffer, byte(len(value)>>8), byte(len(value)))
+	return append(buffer, value...)
+}
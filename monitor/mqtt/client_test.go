@@ -0,0 +1,112 @@
+package mqtt
+
+import (
+	"bufio"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type receivedPacket struct {
+	packetType byte
+	flags      byte
+	body       []byte
+}
+
+// startBroker accepts one connection and answers the packets like a broker would
+func startBroker(t *testing.T, connectCode byte) (address string, packets chan receivedPacket) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	packets = make(chan receivedPacket, 10)
+	go func() {
+		defer close(packets)
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		for {
+			header, err := reader.Peek(1)
+			if err != nil {
+				return
+			}
+			flags := header[0] & 0x0f
+			packetType, body, err := readPacket(reader)
+			if err != nil {
+				return
+			}
+			packets <- receivedPacket{packetType: packetType, flags: flags, body: body}
+			switch packetType {
+			case packetConnect:
+				_ = writePacket(conn, packetConnAck<<4, []byte{0, connectCode})
+			case packetPublish:
+				qos := (flags >> 1) & 0x03
+				topicLength := int(body[0])<<8 | int(body[1])
+				if qos == 1 {
+					_ = writePacket(conn, packetPubAck<<4, body[2+topicLength:4+topicLength])
+				} else if qos == 2 {
+					_ = writePacket(conn, packetPubRec<<4, body[2+topicLength:4+topicLength])
+				}
+			case packetPubRel:
+				_ = writePacket(conn, packetPubComp<<4, body)
+			case packetDisconnect:
+				return
+			}
+		}
+	}()
+	return listener.Addr().String(), packets
+}
+
+func TestEncodeLength(t *testing.T) {
+	assert.Equal(t, []byte{0}, encodeLength(0))
+	assert.Equal(t, []byte{127}, encodeLength(127))
+	assert.Equal(t, []byte{0x80, 0x01}, encodeLength(128))
+	assert.Equal(t, []byte{0xff, 0x7f}, encodeLength(16383))
+	assert.Equal(t, []byte{0x80, 0x80, 0x01}, encodeLength(16384))
+}
+
+func TestPublish(t *testing.T) {
+	for _, qos := range []byte{0, 1, 2} {
+		t.Run(string('0'+qos), func(t *testing.T) {
+			address, packets := startBroker(t, 0)
+			client := &Client{
+				Broker:   &url.URL{Scheme: "mqtt", Host: address, User: url.UserPassword("user", "secret")},
+				ClientID: "client",
+				Timeout:  5 * time.Second,
+			}
+			require.NoError(t, client.Publish(Message{Topic: "backup/status", Payload: []byte(`{"ok":true}`), QoS: qos, Retain: true}))
+
+			connect := <-packets
+			assert.Equal(t, byte(packetConnect), connect.packetType)
+			assert.Equal(t, byte(0xc2), connect.body[7]) // user, password and clean session
+			assert.Contains(t, string(connect.body), "client")
+			assert.Contains(t, string(connect.body), "secret")
+
+			published := <-packets
+			assert.Equal(t, byte(packetPublish), published.packetType)
+			assert.Equal(t, qos<<1|0x01, published.flags)
+			assert.Equal(t, "backup/status", string(published.body[2:15]))
+			assert.Contains(t, string(published.body), `{"ok":true}`)
+
+			if qos == 2 {
+				assert.Equal(t, byte(packetPubRel), (<-packets).packetType)
+			}
+			assert.Equal(t, byte(packetDisconnect), (<-packets).packetType)
+		})
+	}
+}
+
+func TestPublishRefused(t *testing.T) {
+	address, _ := startBroker(t, 5)
+	client := &Client{Broker: &url.URL{Scheme: "mqtt", Host: address}, ClientID: "client", Timeout: 5 * time.Second}
+	err := client.Publish(Message{Topic: "topic"})
+	assert.EqualError(t, err, "connection refused: not authorized")
+}
@@ -0,0 +1,92 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/creativeprojects/clog"
+)
+
+// invalidObjectIDChars matches the characters not allowed in the node and object IDs of the discovery topics
+var invalidObjectIDChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// discoveryDevice groups the sensors of all the profiles of a host in Home Assistant
+type discoveryDevice struct {
+	Identifiers  []string `json:"identifiers"`
+	Name         string   `json:"name"`
+	Manufacturer string   `json:"manufacturer"`
+	Model        string   `json:"model"`
+	SWVersion    string   `json:"sw_version,omitempty"`
+}
+
+// discoveryConfig is the payload of a discovery message creating a sensor in Home Assistant
+type discoveryConfig struct {
+	Name              string          `json:"name"`
+	UniqueID          string          `json:"unique_id"`
+	ObjectID          string          `json:"object_id"`
+	StateTopic        string          `json:"state_topic"`
+	ValueTemplate     string          `json:"value_template"`
+	DeviceClass       string          `json:"device_class,omitempty"`
+	UnitOfMeasurement string          `json:"unit_of_measurement,omitempty"`
+	StateClass        string          `json:"state_class,omitempty"`
+	Icon              string          `json:"icon,omitempty"`
+	Device            discoveryDevice `json:"device"`
+}
+
+// discoverySensor describes a sensor created for each command of the profile
+type discoverySensor struct {
+	key, name, template, deviceClass, unit, stateClass, icon string
+}
+
+var discoverySensors = []discoverySensor{
+	{key: "status", name: "status", template: "{{ value_json.status }}", icon: "mdi:backup-restore"},
+	{key: "last_run", name: "last run", template: "{{ value_json.time }}", deviceClass: "timestamp"},
+	{key: "duration", name: "duration", template: "{{ value_json.duration | default(0) }}", deviceClass: "duration", unit: "s", stateClass: "measurement"},
+}
+
+// discoveryMessages returns the retained messages creating the sensors of the command in Home Assistant
+func (s *Sender) discoveryMessages(command, stateTopic string) []Message {
+	nodeID := objectID("resticprofile_" + s.host)
+	device := discoveryDevice{
+		Identifiers:  []string{nodeID},
+		Name:         "resticprofile " + s.host,
+		Manufacturer: "resticprofile",
+		Model:        "resticprofile",
+		SWVersion:    s.version,
+	}
+	prefix := s.profile.MQTT.GetDiscoveryPrefix()
+
+	messages := make([]Message, 0, len(discoverySensors))
+	for _, sensor := range discoverySensors {
+		id := objectID(s.profile.Name + "_" + command + "_" + sensor.key)
+		payload, err := json.Marshal(discoveryConfig{
+			Name:              s.profile.Name + " " + command + " " + sensor.name,
+			UniqueID:          nodeID + "_" + id,
+			ObjectID:          "resticprofile_" + id,
+			StateTopic:        stateTopic,
+			ValueTemplate:     sensor.template,
+			DeviceClass:       sensor.deviceClass,
+			UnitOfMeasurement: sensor.unit,
+			StateClass:        sensor.stateClass,
+			Icon:              sensor.icon,
+			Device:            device,
+		})
+		if err != nil {
+			clog.Warningf("mqtt: %s", err)
+			continue
+		}
+		messages = append(messages, Message{
+			Topic:   strings.Join([]string{prefix, "sensor", nodeID, id, "config"}, "/"),
+			Payload: payload,
+			QoS:     byte(s.profile.MQTT.QoS),
+			Retain:  true, // Home Assistant creates the sensors again after a restart
+		})
+	}
+	return messages
+}
+
+// objectID returns the value with only the characters allowed in a discovery topic
+func objectID(value string) string {
+	return strings.Trim(invalidObjectIDChars.ReplaceAllString(strings.ToLower(value), "_"), "_")
+}
@@ -0,0 +1,72 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/monitor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestObjectID(t *testing.T) {
+	assert.Equal(t, "resticprofile_my_nas", objectID("resticprofile_My NAS"))
+	assert.Equal(t, "db_app_backup", objectID("db.app_backup"))
+	assert.Equal(t, "host-1", objectID("__host-1.."))
+}
+
+func TestDiscoveryMessages(t *testing.T) {
+	profile := config.NewProfile(nil, "documents")
+	profile.MQTT = &config.MQTTSection{Broker: config.NewConfidentialValue("mqtt://localhost"), HomeAssistant: true, QoS: 1}
+	sender := NewSender(profile, "1.0", nil, false)
+	sender.host = "nas"
+
+	messages := sender.discoveryMessages("backup", "resticprofile/nas/documents/backup")
+	require.Len(t, messages, 3)
+
+	topics := make([]string, len(messages))
+	for i, message := range messages {
+		topics[i] = message.Topic
+		assert.True(t, message.Retain)
+		assert.Equal(t, byte(1), message.QoS)
+	}
+	assert.Equal(t, []string{
+		"homeassistant/sensor/resticprofile_nas/documents_backup_status/config",
+		"homeassistant/sensor/resticprofile_nas/documents_backup_last_run/config",
+		"homeassistant/sensor/resticprofile_nas/documents_backup_duration/config",
+	}, topics)
+
+	decoded := discoveryConfig{}
+	require.NoError(t, json.Unmarshal(messages[2].Payload, &decoded))
+	assert.Equal(t, "documents backup duration", decoded.Name)
+	assert.Equal(t, "resticprofile_nas_documents_backup_duration", decoded.UniqueID)
+	assert.Equal(t, "resticprofile/nas/documents/backup", decoded.StateTopic)
+	assert.Equal(t, "duration", decoded.DeviceClass)
+	assert.Equal(t, "s", decoded.UnitOfMeasurement)
+	assert.Equal(t, []string{"resticprofile_nas"}, decoded.Device.Identifiers)
+	assert.Equal(t, "1.0", decoded.Device.SWVersion)
+
+	profile.MQTT.DiscoveryPrefix = "ha/"
+	assert.Equal(t, "ha/sensor/resticprofile_nas/documents_backup_status/config", sender.discoveryMessages("backup", "state")[0].Topic)
+}
+
+func TestSenderPublishesDiscovery(t *testing.T) {
+	address, packets := startBroker(t, 0)
+	profile := config.NewProfile(nil, "documents")
+	profile.MQTT = &config.MQTTSection{Broker: config.NewConfidentialValue("mqtt://" + address), HomeAssistant: true}
+	sender := NewSender(profile, "1.0", nil, false)
+
+	sender.Summary("check", monitor.Summary{}, "", nil)
+
+	topics := make([]string, 0, 4)
+	for packet := range packets {
+		if packet.packetType == packetPublish {
+			topicLength := int(packet.body[0])<<8 | int(packet.body[1])
+			topics = append(topics, string(packet.body[2:2+topicLength]))
+		}
+	}
+	require.Len(t, topics, 4)
+	assert.Contains(t, topics[0], "homeassistant/sensor/")
+	assert.Equal(t, "resticprofile/"+sender.host+"/documents/check", topics[3])
+}
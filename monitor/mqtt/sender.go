@@ -0,0 +1,218 @@
+package mqtt
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/constants"
+	"github.com/creativeprojects/resticprofile/monitor"
+	"github.com/creativeprojects/resticprofile/util/redact"
+)
+
+// Events published for each command
+const (
+	EventStart  = "start"
+	EventFinish = "finish"
+)
+
+// Status of the command in the messages
+const (
+	StatusRunning = "running"
+	StatusSuccess = "success"
+	StatusWarning = "warning"
+	StatusFailure = "failure"
+)
+
+// Event is the JSON payload of the messages
+type Event struct {
+	Event        string    `json:"event"`
+	Status       string    `json:"status"`
+	Host         string    `json:"host"`
+	Profile      string    `json:"profile"`
+	Command      string    `json:"command"`
+	Repository   string    `json:"repository,omitempty"` // additional repository of the profile
+	Time         time.Time `json:"time"`
+	Duration     int64     `json:"duration,omitempty"`
+	Error        string    `json:"error,omitempty"`
+	ErrorCode    string    `json:"error_code,omitempty"`
+	FilesNew     int       `json:"files_new,omitempty"`
+	FilesChanged int       `json:"files_changed,omitempty"`
+	FilesTotal   int       `json:"files_total,omitempty"`
+	BytesAdded   uint64    `json:"bytes_added,omitempty"`
+	BytesTotal   uint64    `json:"bytes_total,omitempty"`
+	Version      string    `json:"version,omitempty"`
+}
+
+// Variables replaced in the topic (in addition to the environment variables)
+const (
+	TopicHostname = "HOSTNAME"
+	TopicEvent    = "MQTT_EVENT"
+)
+
+// Sender publishes the start and the summary of each command to the MQTT broker of the profile
+type Sender struct {
+	profile *config.Profile
+	version string
+	host    string
+	client  *Client
+	dryRun  bool
+}
+
+func NewSender(profile *config.Profile, version string, certificates []string, dryRun bool) *Sender {
+	host, err := os.Hostname()
+	if err != nil {
+		clog.Debugf("cannot get hostname: %s", err)
+		host = "unknown"
+	}
+	sender := &Sender{
+		profile: profile,
+		version: version,
+		host:    host,
+		dryRun:  dryRun,
+	}
+	if profile.MQTT.IsEmpty() {
+		return sender
+	}
+	broker, err := url.Parse(profile.MQTT.Broker.Value())
+	if err != nil || !config.IsMQTTScheme(broker.Scheme) {
+		clog.Warningf("mqtt: invalid broker %q", profile.MQTT.Broker.String())
+		return sender
+	}
+	clientID := profile.MQTT.ClientID
+	if clientID == "" {
+		clientID = "resticprofile-" + host + "-" + profile.Name
+	}
+	sender.client = &Client{
+		Broker:   broker,
+		ClientID: clientID,
+		Timeout:  profile.MQTT.GetTimeout(),
+		Secure:   config.IsMQTTSecureScheme(broker.Scheme),
+		TLSConfig: &tls.Config{
+			RootCAs:            getRootCAs(certificates),
+			InsecureSkipVerify: profile.MQTT.SkipTLS, //nolint:gosec
+		},
+	}
+	return sender
+}
+
+func (s *Sender) Start(command string) {
+	s.publish(command, Event{
+		Event:   EventStart,
+		Status:  StatusRunning,
+		Command: command,
+	})
+}
+
+func (s *Sender) Status(status monitor.Status) {
+	// we don't report any progress here
+}
+
+func (s *Sender) Summary(command string, summary monitor.Summary, stderr string, result error) {
+	event := Event{
+		Event:        EventFinish,
+		Status:       StatusSuccess,
+		Command:      command,
+		Duration:     int64(summary.Duration.Round(time.Second).Seconds()),
+		FilesNew:     summary.FilesNew,
+		FilesChanged: summary.FilesChanged,
+		FilesTotal:   summary.FilesTotal,
+		BytesAdded:   summary.BytesAdded,
+		BytesTotal:   summary.BytesTotal,
+		Repository:   summary.Repository,
+	}
+	switch {
+	case monitor.IsWarning(result):
+		event.Status = StatusWarning
+	case !monitor.IsSuccess(result):
+		event.Status = StatusFailure
+	}
+	if result != nil {
+		event.Error = redact.String(result.Error())
+		event.ErrorCode = summary.ErrorCode
+	}
+	s.publish(command, event)
+}
+
+func (s *Sender) publish(command string, event Event) {
+	if s.client == nil {
+		return
+	}
+	event.Host = s.host
+	event.Profile = s.profile.Name
+	event.Time = time.Now()
+	event.Version = s.version
+
+	topic := s.topic(command, event.Event)
+	payload, err := json.Marshal(event)
+	if err != nil {
+		clog.Warningf("mqtt: %s", err)
+		return
+	}
+	messages := make([]Message, 0, 4)
+	if event.Event == EventFinish && s.profile.MQTT.HomeAssistant {
+		// the sensors read the state from the topic of the finish event
+		messages = append(messages, s.discoveryMessages(command, topic)...)
+	}
+	messages = append(messages, Message{Topic: topic, Payload: payload, QoS: byte(s.profile.MQTT.QoS), Retain: s.profile.MQTT.Retain})
+
+	if s.dryRun {
+		for _, message := range messages {
+			clog.Infof("dry-run: mqtt publish to %q: %s", message.Topic, message.Payload)
+		}
+		return
+	}
+	if err = s.client.Publish(messages...); err != nil {
+		// not important enough to throw an error here
+		clog.Warningf("mqtt: publishing to %q: %v", s.profile.MQTT.Broker.String(), err)
+		return
+	}
+	clog.Debugf("mqtt: published %s event to %q", event.Event, topic)
+}
+
+// topic returns the topic of the message, with the variables replaced
+func (s *Sender) topic(command, event string) string {
+	return os.Expand(s.profile.MQTT.GetTopic(), func(name string) string {
+		switch name {
+		case TopicHostname:
+			return s.host
+		case constants.EnvProfileName:
+			return s.profile.Name
+		case constants.EnvProfileCommand:
+			return command
+		case TopicEvent:
+			return event
+		default:
+			return os.Getenv(name)
+		}
+	})
+}
+
+func getRootCAs(certificates []string) *x509.CertPool {
+	if len(certificates) == 0 {
+		return nil // system certificates
+	}
+	caCertPool, err := x509.SystemCertPool()
+	if err != nil {
+		caCertPool = x509.NewCertPool()
+	}
+	for _, filename := range certificates {
+		caCert, err := os.ReadFile(filename)
+		if err != nil {
+			clog.Warningf("cannot load CA certificate: %s", err)
+			continue
+		}
+		if !caCertPool.AppendCertsFromPEM(caCert) {
+			clog.Warningf("invalid certificate: %q", filename)
+		}
+	}
+	return caCertPool
+}
+
+// Verify interface
+var _ monitor.Receiver = &Sender{}
@@ -0,0 +1,59 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/monitor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSenderTopic(t *testing.T) {
+	t.Setenv("SITE", "home")
+	profile := config.NewProfile(nil, "documents")
+	profile.MQTT = &config.MQTTSection{Broker: config.NewConfidentialValue("mqtt://localhost")}
+	sender := NewSender(profile, "1.0", nil, false)
+	sender.host = "nas"
+
+	assert.Equal(t, "resticprofile/nas/documents/backup", sender.topic("backup", EventStart))
+
+	profile.MQTT.Topic = "$SITE/${PROFILE_NAME}/$MQTT_EVENT"
+	assert.Equal(t, "home/documents/finish", sender.topic("backup", EventFinish))
+}
+
+func TestSenderWithoutBroker(t *testing.T) {
+	profile := config.NewProfile(nil, "documents")
+	assert.Nil(t, NewSender(profile, "1.0", nil, false).client)
+
+	profile.MQTT = &config.MQTTSection{Broker: config.NewConfidentialValue("http://localhost")}
+	assert.Nil(t, NewSender(profile, "1.0", nil, false).client)
+}
+
+func TestSenderPublishesEvents(t *testing.T) {
+	address, packets := startBroker(t, 0)
+	profile := config.NewProfile(nil, "documents")
+	profile.MQTT = &config.MQTTSection{Broker: config.NewConfidentialValue("mqtt://" + address), Topic: "test/${MQTT_EVENT}"}
+	sender := NewSender(profile, "1.0", nil, false)
+	assert.False(t, sender.client.Secure)
+
+	sender.Summary("backup", monitor.Summary{Duration: 3 * time.Second, FilesNew: 2}, "", errors.New("failed"))
+
+	<-packets // connect
+	published := <-packets
+	topicLength := int(published.body[0])<<8 | int(published.body[1])
+	assert.Equal(t, "test/finish", string(published.body[2:2+topicLength]))
+
+	event := Event{}
+	require.NoError(t, json.Unmarshal(published.body[2+topicLength:], &event))
+	assert.Equal(t, EventFinish, event.Event)
+	assert.Equal(t, StatusFailure, event.Status)
+	assert.Equal(t, "documents", event.Profile)
+	assert.Equal(t, "backup", event.Command)
+	assert.Equal(t, int64(3), event.Duration)
+	assert.Equal(t, 2, event.FilesNew)
+	assert.Equal(t, "failed", event.Error)
+}
@@ -0,0 +1,192 @@
+package otel
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// TracesPath is the path of the OTLP/HTTP endpoint receiving the traces
+const TracesPath = "/v1/traces"
+
+// Values of the OTLP protocol
+const (
+	spanKindInternal = 1
+	statusCodeOk     = 1
+	statusCodeError  = 2
+)
+
+// OTLP/HTTP with JSON encoding, see https://opentelemetry.io/docs/specs/otlp/#json-protobuf-encoding
+type exportRequest struct {
+	ResourceSpans []resourceSpans `json:"resourceSpans"`
+}
+
+type resourceSpans struct {
+	Resource   resource     `json:"resource"`
+	ScopeSpans []scopeSpans `json:"scopeSpans"`
+}
+
+type resource struct {
+	Attributes []keyValue `json:"attributes"`
+}
+
+type scopeSpans struct {
+	Scope scope      `json:"scope"`
+	Spans []spanData `json:"spans"`
+}
+
+type scope struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type spanData struct {
+	TraceID           string     `json:"traceId"`
+	SpanID            string     `json:"spanId"`
+	ParentSpanID      string     `json:"parentSpanId,omitempty"`
+	Name              string     `json:"name"`
+	Kind              int        `json:"kind"`
+	StartTimeUnixNano string     `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string     `json:"endTimeUnixNano"`
+	Attributes        []keyValue `json:"attributes,omitempty"`
+	Status            status     `json:"status"`
+}
+
+type status struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type keyValue struct {
+	Key   string   `json:"key"`
+	Value anyValue `json:"value"`
+}
+
+type anyValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+}
+
+// newExportRequest converts the spans into an OTLP request
+func (t *Tracer) newExportRequest(spans []*Span) exportRequest {
+	end := spans[0].Finish
+	data := make([]spanData, 0, len(spans))
+	for _, span := range spans {
+		if span.Finish.IsZero() {
+			// still running at the end of the run (e.g. interrupted)
+			span.Finish = end
+		}
+		item := spanData{
+			TraceID:           hex.EncodeToString(span.TraceID[:]),
+			SpanID:            hex.EncodeToString(span.SpanID[:]),
+			Name:              span.Name,
+			Kind:              spanKindInternal,
+			StartTimeUnixNano: strconv.FormatInt(span.Start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(span.Finish.UnixNano(), 10),
+			Attributes:        toKeyValues(span.Attributes),
+			Status:            status{Code: statusCodeOk},
+		}
+		if span.ParentID != [8]byte{} {
+			item.ParentSpanID = hex.EncodeToString(span.ParentID[:])
+		}
+		if span.Failed {
+			item.Status = status{Code: statusCodeError, Message: span.Error}
+		}
+		data = append(data, item)
+	}
+	return exportRequest{
+		ResourceSpans: []resourceSpans{{
+			Resource: resource{Attributes: toKeyValues(map[string]any{
+				"service.name":    "resticprofile",
+				"service.version": t.version,
+				"host.name":       t.host,
+				AttributeProfile:  t.profile.Name,
+			})},
+			ScopeSpans: []scopeSpans{{
+				Scope: scope{Name: "resticprofile", Version: t.version},
+				Spans: data,
+			}},
+		}},
+	}
+}
+
+// export sends the spans to the OTLP/HTTP endpoint
+func (t *Tracer) export(spans []*Span) error {
+	endpoint, err := tracesEndpoint(t.profile.OtelEndpoint.Value())
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(t.newExportRequest(spans))
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "resticprofile/"+t.version)
+	for _, header := range t.profile.OtelHeaders {
+		request.Header.Set(header.Name, header.Value.Value())
+	}
+
+	response, err := t.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode >= 300 {
+		return fmt.Errorf("unexpected response: %s", response.Status)
+	}
+	return nil
+}
+
+// tracesEndpoint adds the path of the traces to the URL of the collector (unless already there)
+func tracesEndpoint(endpoint string) (string, error) {
+	if strings.HasSuffix(strings.TrimSuffix(endpoint, "/"), TracesPath) {
+		return endpoint, nil
+	}
+	return url.JoinPath(endpoint, TracesPath)
+}
+
+func toKeyValues(attributes map[string]any) []keyValue {
+	keys := make([]string, 0, len(attributes))
+	for key := range attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	values := make([]keyValue, 0, len(keys))
+	for _, key := range keys {
+		values = append(values, keyValue{Key: key, Value: toAnyValue(attributes[key])})
+	}
+	return values
+}
+
+func toAnyValue(value any) anyValue {
+	switch v := value.(type) {
+	case bool:
+		return anyValue{BoolValue: &v}
+	case int:
+		s := strconv.FormatInt(int64(v), 10)
+		return anyValue{IntValue: &s}
+	case int64:
+		s := strconv.FormatInt(v, 10)
+		return anyValue{IntValue: &s}
+	case uint64:
+		s := strconv.FormatUint(v, 10)
+		return anyValue{IntValue: &s}
+	case float64:
+		return anyValue{DoubleValue: &v}
+	default:
+		s := fmt.Sprint(v)
+		return anyValue{StringValue: &s}
+	}
+}
@@ -0,0 +1,50 @@
+package otel
+
+import (
+	"sync"
+	"time"
+
+	"github.com/creativeprojects/resticprofile/util/redact"
+)
+
+// Attributes of the spans
+const (
+	AttributeProfile       = "resticprofile.profile"
+	AttributeCommand       = "resticprofile.command"
+	AttributeHook          = "resticprofile.hook"
+	AttributeResticCommand = "restic.command"
+	AttributeExitCode      = "process.exit_code"
+	AttributeFilesNew      = "restic.files.new"
+	AttributeFilesChanged  = "restic.files.changed"
+	AttributeFilesTotal    = "restic.files.total"
+	AttributeBytesAdded    = "restic.bytes.added"
+	AttributeBytesTotal    = "restic.bytes.total"
+)
+
+// Span is a step of the run
+type Span struct {
+	TraceID    [16]byte
+	SpanID     [8]byte
+	ParentID   [8]byte
+	Name       string
+	Start      time.Time
+	Finish     time.Time
+	Attributes map[string]any
+	Error      string
+	Failed     bool
+	once       sync.Once
+}
+
+// End the span: a non-nil error marks the span as failed
+func (s *Span) End(err error) {
+	if s == nil {
+		return
+	}
+	s.once.Do(func() {
+		s.Finish = time.Now()
+		if err != nil {
+			s.Failed = true
+			s.Error = redact.String(err.Error())
+		}
+	})
+}
@@ -0,0 +1,158 @@
+package otel
+
+import (
+	"crypto/rand"
+	"errors"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/monitor"
+)
+
+const exportTimeout = 10 * time.Second
+
+// Tracer records a run of a profile as a trace: the run is the root span, and each hook and restic command is a child span.
+// The trace is sent to the OpenTelemetry collector at the end of the run.
+type Tracer struct {
+	profile  *config.Profile
+	version  string
+	host     string
+	client   *http.Client
+	mutex    sync.Mutex
+	traceID  [16]byte
+	root     *Span
+	spans    []*Span
+	commands map[string]*Span // spans of the restic commands in progress
+}
+
+func NewTracer(profile *config.Profile, version string) *Tracer {
+	host, err := os.Hostname()
+	if err != nil {
+		clog.Debugf("cannot get hostname: %s", err)
+		host = "unknown"
+	}
+	return &Tracer{
+		profile:  profile,
+		version:  version,
+		host:     host,
+		client:   &http.Client{Timeout: exportTimeout},
+		commands: make(map[string]*Span),
+	}
+}
+
+// StartRun starts the trace of the run of a command of the profile
+func (t *Tracer) StartRun(command string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	_, _ = rand.Read(t.traceID[:])
+	t.root = t.newSpan(t.profile.Name+"."+command, nil)
+	t.root.Attributes[AttributeProfile] = t.profile.Name
+	t.root.Attributes[AttributeCommand] = command
+}
+
+// StartSpan starts a step of the run. The span is ended with its End method.
+func (t *Tracer) StartSpan(name string, attributes map[string]any) *Span {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	span := t.newSpan(name, t.root)
+	for key, value := range attributes {
+		span.Attributes[key] = value
+	}
+	return span
+}
+
+// EndRun ends the trace and sends it to the collector
+func (t *Tracer) EndRun(err error) {
+	t.mutex.Lock()
+	if t.root == nil {
+		t.mutex.Unlock()
+		return
+	}
+	t.root.End(err)
+	if code, ok := exitCode(err); ok {
+		t.root.Attributes[AttributeExitCode] = code
+	}
+	spans := append([]*Span{t.root}, t.spans...)
+	t.root, t.spans = nil, nil
+	t.mutex.Unlock()
+
+	if err = t.export(spans); err != nil {
+		// not important enough to throw an error here
+		clog.Warningf("sending trace to %q: %v", t.profile.OtelEndpoint.String(), err)
+	}
+}
+
+func (t *Tracer) newSpan(name string, parent *Span) *Span {
+	span := &Span{
+		TraceID:    t.traceID,
+		Name:       name,
+		Start:      time.Now(),
+		Attributes: make(map[string]any),
+	}
+	_, _ = rand.Read(span.SpanID[:])
+	if parent != nil {
+		span.ParentID = parent.SpanID
+		t.spans = append(t.spans, span)
+	}
+	return span
+}
+
+// Start of a restic command
+func (t *Tracer) Start(command string) {
+	span := t.StartSpan("restic "+command, map[string]any{AttributeResticCommand: command})
+	t.mutex.Lock()
+	t.commands[command] = span
+	t.mutex.Unlock()
+}
+
+func (t *Tracer) Status(status monitor.Status) {
+	// we don't report any progress here
+}
+
+// Summary ends the span of a restic command
+func (t *Tracer) Summary(command string, summary monitor.Summary, stderr string, result error) {
+	t.mutex.Lock()
+	span, found := t.commands[command]
+	delete(t.commands, command)
+	t.mutex.Unlock()
+	if !found {
+		// some commands are reported without a start
+		span = t.StartSpan("restic "+command, map[string]any{AttributeResticCommand: command})
+		span.Start = time.Now().Add(-summary.Duration)
+	}
+	if code, ok := exitCode(result); ok {
+		span.Attributes[AttributeExitCode] = code
+	}
+	if summary.FilesTotal > 0 || summary.BytesTotal > 0 {
+		span.Attributes[AttributeFilesNew] = summary.FilesNew
+		span.Attributes[AttributeFilesChanged] = summary.FilesChanged
+		span.Attributes[AttributeFilesTotal] = summary.FilesTotal
+		span.Attributes[AttributeBytesAdded] = summary.BytesAdded
+		span.Attributes[AttributeBytesTotal] = summary.BytesTotal
+	}
+	if monitor.IsWarning(result) {
+		// a warning is not an error of the run
+		span.End(nil)
+		return
+	}
+	span.End(result)
+}
+
+// exitCode returns the exit code of a failed command
+func exitCode(err error) (int, bool) {
+	if err == nil {
+		return 0, true
+	}
+	var exitErr interface{ ExitCode() int }
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode(), true
+	}
+	return 0, false
+}
+
+// Verify interface
+var _ monitor.Receiver = &Tracer{}
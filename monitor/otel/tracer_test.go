@@ -0,0 +1,93 @@
+package otel
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/monitor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTracesEndpoint(t *testing.T) {
+	testCases := []struct{ endpoint, expected string }{
+		{"http://localhost:4318", "http://localhost:4318/v1/traces"},
+		{"http://localhost:4318/", "http://localhost:4318/v1/traces"},
+		{"https://otlp.example.com/otlp", "https://otlp.example.com/otlp/v1/traces"},
+		{"http://localhost:4318/v1/traces", "http://localhost:4318/v1/traces"},
+	}
+	for _, testCase := range testCases {
+		endpoint, err := tracesEndpoint(testCase.endpoint)
+		require.NoError(t, err)
+		assert.Equal(t, testCase.expected, endpoint)
+	}
+}
+
+func TestToAnyValue(t *testing.T) {
+	data, err := json.Marshal(toKeyValues(map[string]any{"b": 12, "a": "text", "c": uint64(3), "d": true, "e": 1.5}))
+	require.NoError(t, err)
+	assert.JSONEq(t, `[
+		{"key":"a","value":{"stringValue":"text"}},
+		{"key":"b","value":{"intValue":"12"}},
+		{"key":"c","value":{"intValue":"3"}},
+		{"key":"d","value":{"boolValue":true}},
+		{"key":"e","value":{"doubleValue":1.5}}
+	]`, string(data))
+}
+
+func TestExportTrace(t *testing.T) {
+	requests := make(chan exportRequest, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, TracesPath, r.URL.Path)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, "secret", r.Header.Get("X-Api-Key"))
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		request := exportRequest{}
+		assert.NoError(t, json.Unmarshal(body, &request))
+		requests <- request
+	}))
+	defer server.Close()
+
+	profile := config.NewProfile(nil, "name")
+	profile.OtelEndpoint = config.NewConfidentialValue(server.URL)
+	profile.OtelHeaders = []config.SendMonitoringHeader{{Name: "X-Api-Key", Value: config.NewConfidentialValue("secret")}}
+
+	tracer := NewTracer(profile, "1.0")
+	tracer.StartRun("backup")
+	tracer.StartSpan("run-before backup", map[string]any{AttributeHook: "run-before backup"}).End(nil)
+	tracer.Start("backup")
+	tracer.Summary("backup", monitor.Summary{Duration: time.Second, FilesTotal: 10, BytesAdded: 100, BytesTotal: 1000}, "", nil)
+	tracer.Summary("forget", monitor.Summary{Duration: time.Second}, "", errors.New("failed"))
+	tracer.EndRun(errors.New("failed"))
+
+	request := <-requests
+	require.Len(t, request.ResourceSpans, 1)
+	assert.Contains(t, request.ResourceSpans[0].Resource.Attributes, keyValue{Key: "service.name", Value: toAnyValue("resticprofile")})
+	spans := request.ResourceSpans[0].ScopeSpans[0].Spans
+	require.Len(t, spans, 4)
+
+	root := spans[0]
+	assert.Equal(t, "name.backup", root.Name)
+	assert.Empty(t, root.ParentSpanID)
+	assert.Equal(t, status{Code: statusCodeError, Message: "failed"}, root.Status)
+	assert.Len(t, root.TraceID, 32)
+
+	names := []string{}
+	for _, span := range spans[1:] {
+		names = append(names, span.Name)
+		assert.Equal(t, root.TraceID, span.TraceID)
+		assert.Equal(t, root.SpanID, span.ParentSpanID)
+	}
+	assert.Equal(t, []string{"run-before backup", "restic backup", "restic forget"}, names)
+	assert.Equal(t, statusCodeOk, spans[2].Status.Code)
+	assert.Contains(t, spans[2].Attributes, keyValue{Key: AttributeBytesAdded, Value: toAnyValue(uint64(100))})
+	assert.Contains(t, spans[2].Attributes, keyValue{Key: AttributeExitCode, Value: toAnyValue(0)})
+	assert.Equal(t, statusCodeError, spans[3].Status.Code)
+}
@@ -25,6 +25,11 @@ type BackupMetrics struct {
 	bytesTotal      *prometheus.GaugeVec
 	status          *prometheus.GaugeVec
 	time            *prometheus.GaugeVec
+	repositorySize  *prometheus.GaugeVec
+	writeOperations *prometheus.GaugeVec
+	storageCost     *prometheus.GaugeVec
+	operationsCost  *prometheus.GaugeVec
+	snapshot        *prometheus.GaugeVec
 }
 
 func newBackupMetrics(group string, configLabels map[string]string) BackupMetrics {
@@ -35,6 +40,8 @@ func newBackupMetrics(group string, configLabels map[string]string) BackupMetric
 		labels = []string{profileLabel}
 	}
 	labels = mergeKeys(labels, configLabels)
+	costLabels := append(append([]string{}, labels...), currencyLabel)
+	snapshotLabels := append(append([]string{}, labels...), snapshotIDLabel)
 
 	backupMetrics := BackupMetrics{
 		duration: prometheus.NewGaugeVec(prometheus.GaugeOpts{
@@ -109,6 +116,36 @@ func newBackupMetrics(group string, configLabels map[string]string) BackupMetric
 			Name:      "time_seconds",
 			Help:      "Last backup run (unixtime).",
 		}, labels),
+		repositorySize: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: backup,
+			Name:      "repository_size_bytes",
+			Help:      "Size of the data stored in the repository after the backup.",
+		}, labels),
+		writeOperations: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: backup,
+			Name:      "write_operations",
+			Help:      "Estimated number of write operations (PUT requests) sent to the backend by the backup.",
+		}, labels),
+		storageCost: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: backup,
+			Name:      "storage_monthly_cost",
+			Help:      "Estimated monthly cost of the storage of the repository.",
+		}, costLabels),
+		operationsCost: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: backup,
+			Name:      "write_operations_cost",
+			Help:      "Estimated cost of the write operations of the backup.",
+		}, costLabels),
+		snapshot: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: backup,
+			Name:      "snapshot_info",
+			Help:      "ID of the snapshot saved by the last backup (always 1).",
+		}, snapshotLabels),
 	}
 	return backupMetrics
 }
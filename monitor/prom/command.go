@@ -0,0 +1,50 @@
+package prom
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type CommandMetrics struct {
+	duration *prometheus.GaugeVec
+	status   *prometheus.GaugeVec
+	exitCode *prometheus.GaugeVec
+	time     *prometheus.GaugeVec
+}
+
+func newCommandMetrics(group string, configLabels map[string]string) CommandMetrics {
+	var labels []string
+	if group != "" {
+		labels = []string{groupLabel, profileLabel, commandLabel}
+	} else {
+		labels = []string{profileLabel, commandLabel}
+	}
+	labels = mergeKeys(labels, configLabels)
+
+	commandMetrics := CommandMetrics{
+		duration: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: command,
+			Name:      "duration_seconds",
+			Help:      "The command duration (in seconds).",
+		}, labels),
+		status: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: command,
+			Name:      "status",
+			Help:      "Command status: 0=fail, 1=warning, 2=success.",
+		}, labels),
+		exitCode: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: command,
+			Name:      "exit_code",
+			Help:      "Exit code of the command (0 when successful).",
+		}, labels),
+		time: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: command,
+			Name:      "time_seconds",
+			Help:      "Last command run (unixtime).",
+		}, labels),
+	}
+	return commandMetrics
+}
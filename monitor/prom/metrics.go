@@ -1,6 +1,8 @@
 package prom
 
 import (
+	"errors"
+	"net/url"
 	"runtime"
 	"time"
 
@@ -11,10 +13,15 @@ import (
 
 const namespace = "resticprofile"
 const backup = "backup"
+const command = "command"
 const groupLabel = "group"
 const profileLabel = "profile"
+const commandLabel = "command"
+const currencyLabel = "currency"
+const snapshotIDLabel = "snapshot_id"
 const goVersionLabel = "goversion"
 const versionLabel = "version"
+const instanceLabel = "instance"
 
 type Metrics struct {
 	group        string
@@ -22,6 +29,7 @@ type Metrics struct {
 	registry     *prometheus.Registry
 	info         *prometheus.GaugeVec
 	backup       BackupMetrics
+	command      CommandMetrics
 }
 
 func NewMetrics(group, version string, configLabels map[string]string) *Metrics {
@@ -39,6 +47,7 @@ func NewMetrics(group, version string, configLabels map[string]string) *Metrics
 	p.info.With(mergeLabels(prometheus.Labels{goVersionLabel: runtime.Version(), versionLabel: version}, configLabels)).Set(1)
 
 	p.backup = newBackupMetrics(group, configLabels)
+	p.command = newCommandMetrics(group, configLabels)
 
 	registry.MustRegister(
 		p.info,
@@ -54,16 +63,31 @@ func NewMetrics(group, version string, configLabels map[string]string) *Metrics
 		p.backup.bytesTotal,
 		p.backup.status,
 		p.backup.time,
+		p.backup.repositorySize,
+		p.backup.writeOperations,
+		p.backup.storageCost,
+		p.backup.operationsCost,
+		p.backup.snapshot,
+		p.command.duration,
+		p.command.status,
+		p.command.exitCode,
+		p.command.time,
 	)
 	return p
 }
 
+// CommandResults records the result of any command, including backup
+func (p *Metrics) CommandResults(profile, command string, status Status, exitCode int, summary monitor.Summary) {
+	labels := p.labels(profile)
+	labels[commandLabel] = command
+	p.command.duration.With(labels).Set(summary.Duration.Seconds())
+	p.command.status.With(labels).Set(float64(status))
+	p.command.exitCode.With(labels).Set(float64(exitCode))
+	p.command.time.With(labels).Set(float64(time.Now().Unix()))
+}
+
 func (p *Metrics) BackupResults(profile string, status Status, summary monitor.Summary) {
-	labels := prometheus.Labels{profileLabel: profile}
-	if p.group != "" {
-		labels[groupLabel] = p.group
-	}
-	labels = mergeLabels(labels, p.configLabels)
+	labels := p.labels(profile)
 	p.backup.duration.With(labels).Set(summary.Duration.Seconds())
 
 	p.backup.filesNew.With(labels).Set(float64(summary.FilesNew))
@@ -79,16 +103,46 @@ func (p *Metrics) BackupResults(profile string, status Status, summary monitor.S
 	p.backup.bytesTotal.With(labels).Set(float64(summary.BytesTotal))
 	p.backup.status.With(labels).Set(float64(status))
 	p.backup.time.With(labels).Set(float64(time.Now().Unix()))
+
+	if storage := summary.Storage; storage != nil {
+		p.backup.repositorySize.With(labels).Set(float64(storage.RepositorySize))
+		p.backup.writeOperations.With(labels).Set(float64(storage.WriteOperations))
+		costLabels := mergeLabels(prometheus.Labels{currencyLabel: storage.Currency}, labels)
+		p.backup.storageCost.With(costLabels).Set(storage.MonthlyCost)
+		p.backup.operationsCost.With(costLabels).Set(storage.OperationsCost)
+	}
+	if summary.SnapshotID != "" {
+		p.backup.snapshot.With(mergeLabels(prometheus.Labels{snapshotIDLabel: summary.SnapshotID}, labels)).Set(1)
+	}
 }
 
-func (p *Metrics) SaveTo(filename string) error {
-	return prometheus.WriteToTextfile(filename, p.registry)
+// labels returns the labels identifying the profile
+func (p *Metrics) labels(profile string) prometheus.Labels {
+	labels := prometheus.Labels{profileLabel: profile}
+	if p.group != "" {
+		labels[groupLabel] = p.group
+	}
+	return mergeLabels(labels, p.configLabels)
 }
 
-func (p *Metrics) Push(url, jobName string) error {
-	return push.New(url, jobName).
-		Gatherer(p.registry).
-		Add()
+// Push sends the metrics to the push gateway, grouped by job and instance. The metrics of the group
+// having the same names are replaced. The user and password of the URL are sent with basic authentication.
+func (p *Metrics) Push(gatewayURL, jobName, instance string) error {
+	gateway, err := url.Parse(gatewayURL)
+	if err != nil {
+		return errors.New("invalid URL") // the error would display the password
+	}
+	credentials := gateway.User
+	gateway.User = nil
+	pusher := push.New(gateway.String(), jobName)
+	if credentials != nil {
+		password, _ := credentials.Password()
+		pusher = pusher.BasicAuth(credentials.Username(), password)
+	}
+	if instance != "" {
+		pusher = pusher.Grouping(instanceLabel, instance)
+	}
+	return pusher.Gatherer(p.registry).Add()
 }
 
 func mergeKeys(keys []string, add map[string]string) []string {
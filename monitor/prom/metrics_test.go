@@ -1,10 +1,19 @@
 package prom
 
 import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/creativeprojects/resticprofile/config"
 	"github.com/creativeprojects/resticprofile/monitor"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -45,3 +54,128 @@ func TestSaveBackupsInGroup(t *testing.T) {
 	err := p.SaveTo("test_group.prom")
 	require.NoError(t, err)
 }
+
+func TestSaveBackupWithStorageCost(t *testing.T) {
+	p := NewMetrics("", "", nil)
+	p.BackupResults("test", StatusSuccess, monitor.Summary{
+		Duration:   time.Duration(11 * time.Second),
+		BytesAdded: 100,
+		BytesTotal: 1000,
+		Storage: &monitor.StorageSummary{
+			Backend:         "s3",
+			RepositorySize:  2048,
+			MonthlyCost:     1.5,
+			WriteOperations: 3,
+			OperationsCost:  0.25,
+			Currency:        "EUR",
+		},
+	})
+	filename := filepath.Join(t.TempDir(), "storage.prom")
+	err := p.SaveTo(filename)
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), `resticprofile_backup_repository_size_bytes{profile="test"} 2048`)
+	assert.Contains(t, string(content), `resticprofile_backup_write_operations{profile="test"} 3`)
+	assert.Contains(t, string(content), `resticprofile_backup_storage_monthly_cost{currency="EUR",profile="test"} 1.5`)
+	assert.Contains(t, string(content), `resticprofile_backup_write_operations_cost{currency="EUR",profile="test"} 0.25`)
+}
+
+func TestSaveCommandResults(t *testing.T) {
+	p := NewMetrics("", "", nil)
+	p.CommandResults("test", "check", StatusFailed, 12, monitor.Summary{Duration: 3 * time.Second})
+	p.BackupResults("test", StatusSuccess, monitor.Summary{SnapshotID: "07ab30a5"})
+	filename := filepath.Join(t.TempDir(), "command.prom")
+	require.NoError(t, p.SaveTo(filename))
+
+	content, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), `resticprofile_command_duration_seconds{command="check",profile="test"} 3`)
+	assert.Contains(t, string(content), `resticprofile_command_status{command="check",profile="test"} 0`)
+	assert.Contains(t, string(content), `resticprofile_command_exit_code{command="check",profile="test"} 12`)
+	assert.Contains(t, string(content), `resticprofile_backup_snapshot_info{profile="test",snapshot_id="07ab30a5"} 1`)
+}
+
+func TestSaveKeepsOtherMetrics(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "merge.prom")
+
+	p := NewMetrics("", "", nil)
+	p.CommandResults("first", "backup", StatusSuccess, 0, monitor.Summary{Duration: time.Second})
+	p.BackupResults("first", StatusSuccess, monitor.Summary{SnapshotID: "11111111"})
+	p.CommandResults("second", "backup", StatusSuccess, 0, monitor.Summary{Duration: time.Second})
+	require.NoError(t, p.SaveTo(filename))
+
+	// a later run of the first profile
+	p = NewMetrics("", "", nil)
+	p.CommandResults("first", "backup", StatusWarning, 3, monitor.Summary{Duration: 2 * time.Second})
+	p.BackupResults("first", StatusWarning, monitor.Summary{SnapshotID: "22222222"})
+	p.CommandResults("first", "check", StatusSuccess, 0, monitor.Summary{Duration: 5 * time.Second})
+	require.NoError(t, p.SaveTo(filename))
+
+	content, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	text := string(content)
+	assert.Contains(t, text, `resticprofile_command_duration_seconds{command="backup",profile="first"} 2`)
+	assert.Contains(t, text, `resticprofile_command_duration_seconds{command="check",profile="first"} 5`)
+	assert.Contains(t, text, `resticprofile_command_duration_seconds{command="backup",profile="second"} 1`)
+	assert.Contains(t, text, `resticprofile_backup_snapshot_info{profile="first",snapshot_id="22222222"} 1`)
+	assert.NotContains(t, text, "11111111")
+	assert.Equal(t, 1, strings.Count(text, "# TYPE resticprofile_command_duration_seconds gauge"))
+	assert.Equal(t, 1, strings.Count(text, "resticprofile_build_info{"))
+}
+
+func TestSaveReplacesInvalidFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "invalid.prom")
+	require.NoError(t, os.WriteFile(filename, []byte("not { a metric\n"), 0o644))
+
+	p := NewMetrics("", "", nil)
+	p.CommandResults("test", "check", StatusSuccess, 0, monitor.Summary{})
+	require.NoError(t, p.SaveTo(filename))
+
+	content, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	assert.NotContains(t, string(content), "not { a metric")
+	assert.Contains(t, string(content), `resticprofile_command_status{command="check",profile="test"} 2`)
+}
+
+func TestExitCode(t *testing.T) {
+	assert.Equal(t, 0, exitCode(nil))
+	assert.Equal(t, 1, exitCode(errors.New("error")))
+}
+
+func TestPushToGateway(t *testing.T) {
+	var requests []string
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, _ := r.BasicAuth()
+		requests = append(requests, r.Method+" "+r.URL.Path+" "+username+":"+password)
+		content, _ := io.ReadAll(r.Body)
+		body = string(content)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	profile := config.NewProfile(nil, "home")
+	profile.PrometheusPush = config.NewConfidentialValue(strings.Replace(server.URL, "http://", "http://user:secret@", 1))
+	progress := NewProgress(profile, NewMetrics("", "", nil))
+	progress.Summary("check", monitor.Summary{Duration: time.Second}, "", nil)
+
+	host, _ := os.Hostname()
+	require.Len(t, requests, 1)
+	assert.Equal(t, "POST /metrics/job/home/instance/"+host+" user:secret", requests[0])
+	assert.Contains(t, body, "resticprofile_command_status")
+	assert.NotContains(t, body, "resticprofile_backup_status")
+
+	profile.PrometheusPushJob = "backups"
+	progress.Summary("backup", monitor.Summary{Duration: time.Second}, "", nil)
+	require.Len(t, requests, 2)
+	assert.Equal(t, "POST /metrics/job/backups/instance/"+host+" user:secret", requests[1])
+	assert.Contains(t, body, "resticprofile_backup_status")
+}
+
+func TestPushInvalidURL(t *testing.T) {
+	err := NewMetrics("", "", nil).Push("http://user:secret@[invalid", "job", "")
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "secret")
+}
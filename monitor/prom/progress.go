@@ -1,6 +1,10 @@
 package prom
 
 import (
+	"errors"
+	"os"
+	"os/exec"
+
 	"github.com/creativeprojects/clog"
 	"github.com/creativeprojects/resticprofile/config"
 	"github.com/creativeprojects/resticprofile/constants"
@@ -28,7 +32,7 @@ func (p *Progress) Status(status monitor.Status) {
 }
 
 func (p *Progress) Summary(command string, summary monitor.Summary, stderr string, result error) {
-	if p.profile.PrometheusPush == "" && p.profile.PrometheusSaveToFile == "" {
+	if p.profile.PrometheusPush.Value() == "" && p.profile.PrometheusSaveToFile == "" {
 		return
 	}
 	var status Status
@@ -42,10 +46,11 @@ func (p *Progress) Summary(command string, summary monitor.Summary, stderr strin
 	case monitor.IsError(result):
 		status = StatusFailed
 	}
-	if command != constants.CommandBackup {
-		return
+	name := summary.ReportName(p.profile.Name)
+	p.metrics.CommandResults(name, command, status, exitCode(result), summary)
+	if command == constants.CommandBackup {
+		p.metrics.BackupResults(name, status, summary)
 	}
-	p.metrics.BackupResults(p.profile.Name, status, summary)
 
 	if p.profile.PrometheusSaveToFile != "" {
 		err := p.metrics.SaveTo(p.profile.PrometheusSaveToFile)
@@ -54,14 +59,39 @@ func (p *Progress) Summary(command string, summary monitor.Summary, stderr strin
 			clog.Warningf("saving prometheus file %q: %v", p.profile.PrometheusSaveToFile, err)
 		}
 	}
-	if p.profile.PrometheusPush != "" {
-		err := p.metrics.Push(p.profile.PrometheusPush, command)
+	if p.profile.PrometheusPush.Value() != "" {
+		err := p.metrics.Push(p.profile.PrometheusPush.Value(), p.pushJob(), hostname())
 		if err != nil {
 			// not important enough to throw an error here
-			clog.Warningf("pushing prometheus metrics to %q: %v", p.profile.PrometheusPush, err)
+			clog.Warningf("pushing prometheus metrics to %q: %v", p.profile.PrometheusPush.String(), err)
 		}
 	}
 }
 
+// pushJob returns the job label of the metrics sent to the push gateway
+func (p *Progress) pushJob() string {
+	if p.profile.PrometheusPushJob != "" {
+		return p.profile.PrometheusPushJob
+	}
+	return p.profile.Name
+}
+
+func hostname() string {
+	name, _ := os.Hostname()
+	return name
+}
+
+// exitCode returns the exit code of the command from its result
+func exitCode(result error) int {
+	if result == nil {
+		return 0
+	}
+	exitErr := &exec.ExitError{}
+	if errors.As(result, &exitErr) && exitErr.ExitCode() > 0 {
+		return exitErr.ExitCode()
+	}
+	return 1
+}
+
 // Verify interface
 var _ monitor.Receiver = &Progress{}
@@ -0,0 +1,106 @@
+package prom
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/creativeprojects/clog"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+// identityLabels are the labels identifying the source of a metric: the metrics of the other sources found in the file are kept
+var identityLabels = []string{groupLabel, profileLabel, commandLabel}
+
+// SaveTo writes the metrics to the file in the text format (for the textfile collector of node_exporter).
+// The metrics of the other profiles and commands already in the file are kept.
+func (p *Metrics) SaveTo(filename string) error {
+	families, err := p.registry.Gather()
+	if err != nil {
+		return err
+	}
+	families = mergeFamilies(loadTextfile(filename), families)
+
+	// the textfile collector must never read a partial file
+	tmp, err := os.CreateTemp(filepath.Dir(filename), filepath.Base(filename))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	for _, family := range families {
+		if _, err = expfmt.MetricFamilyToText(tmp, family); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if err = os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filename)
+}
+
+// loadTextfile returns the metrics of the file, or nil when the file cannot be read
+func loadTextfile(filename string) map[string]*dto.MetricFamily {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	parser := expfmt.TextParser{}
+	families, err := parser.TextToMetricFamilies(file)
+	if err != nil {
+		clog.Debugf("prometheus file %q will be replaced: %s", filename, err)
+		return nil
+	}
+	return families
+}
+
+// mergeFamilies returns the current metrics, with the previous metrics of the other sources
+func mergeFamilies(previous map[string]*dto.MetricFamily, current []*dto.MetricFamily) []*dto.MetricFamily {
+	for _, family := range current {
+		old, found := previous[family.GetName()]
+		if !found {
+			continue
+		}
+		delete(previous, family.GetName())
+		if old.GetType() != family.GetType() {
+			continue
+		}
+		replaced := make(map[string]bool, len(family.GetMetric()))
+		for _, metric := range family.GetMetric() {
+			replaced[identity(metric)] = true
+		}
+		kept := make([]*dto.Metric, 0, len(old.GetMetric()))
+		for _, metric := range old.GetMetric() {
+			if !replaced[identity(metric)] {
+				kept = append(kept, metric)
+			}
+		}
+		family.Metric = append(kept, family.GetMetric()...)
+	}
+	for _, family := range previous {
+		current = append(current, family)
+	}
+	sort.Slice(current, func(i, j int) bool { return current[i].GetName() < current[j].GetName() })
+	return current
+}
+
+// identity returns the values of the identity labels of the metric
+func identity(metric *dto.Metric) string {
+	values := make([]string, len(identityLabels))
+	for _, label := range metric.GetLabel() {
+		for i, name := range identityLabels {
+			if label.GetName() == name {
+				values[i] = label.GetValue()
+			}
+		}
+	}
+	return strings.Join(values, "\xff")
+}
@@ -12,6 +12,9 @@ type Profile struct {
 	Backup    *BackupStatus  `json:"backup,omitempty"`
 	Retention *CommandStatus `json:"retention,omitempty"`
 	Check     *CommandStatus `json:"check,omitempty"`
+	Verify    *CommandStatus `json:"verify-restore,omitempty"`
+	Mount     *MountStatus   `json:"mount,omitempty"`
+	Daemon    *DaemonStatus  `json:"daemon,omitempty"`
 }
 
 func newProfile() *Profile {
@@ -20,25 +23,139 @@ func newProfile() *Profile {
 
 // CommandStatus is the last command status
 type CommandStatus struct {
-	Success  bool      `json:"success"`
-	Time     time.Time `json:"time"`
-	Error    string    `json:"error"`
-	Stderr   string    `json:"stderr"`
-	Duration int64     `json:"duration"`
+	Success   bool          `json:"success"`
+	Time      time.Time     `json:"time"`
+	Error     string        `json:"error"`
+	ErrorCode string        `json:"error_code,omitempty"`
+	Stderr    string        `json:"stderr"`
+	Duration  int64         `json:"duration"`
+	Forget    *ForgetStatus `json:"forget,omitempty"`
+	Check     *CheckStatus  `json:"check,omitempty"`
+}
+
+// ForgetStatus contains the snapshots kept and removed by the last retention (read from the JSON output of restic)
+type ForgetStatus struct {
+	SnapshotsKept    int `json:"snapshots_kept"`
+	SnapshotsRemoved int `json:"snapshots_removed"`
+}
+
+func newForgetStatus(forget *monitor.ForgetSummary) *ForgetStatus {
+	if forget == nil {
+		return nil
+	}
+	return &ForgetStatus{
+		SnapshotsKept:    forget.SnapshotsKept,
+		SnapshotsRemoved: forget.SnapshotsRemoved,
+	}
+}
+
+// CheckStatus contains the result of the last check (read from the JSON output of restic)
+type CheckStatus struct {
+	Errors             int  `json:"errors"`
+	SuggestRepairIndex bool `json:"suggest_repair_index"`
+	SuggestPrune       bool `json:"suggest_prune"`
+}
+
+func newCheckStatus(check *monitor.CheckSummary) *CheckStatus {
+	if check == nil {
+		return nil
+	}
+	return &CheckStatus{
+		Errors:             check.Errors,
+		SuggestRepairIndex: check.SuggestRepairIndex,
+		SuggestPrune:       check.SuggestPrune,
+	}
 }
 
 // BackupStatus contains the last backup status
 type BackupStatus struct {
 	CommandStatus
-	FilesNew        int    `json:"files_new"`
-	FilesChanged    int    `json:"files_changed"`
-	FilesUnmodified int    `json:"files_unmodified"`
-	DirsNew         int    `json:"dirs_new"`
-	DirsChanged     int    `json:"dirs_changed"`
-	DirsUnmodified  int    `json:"dirs_unmodified"`
-	FilesTotal      int    `json:"files_total"`
-	BytesAdded      uint64 `json:"bytes_added"`
-	BytesTotal      uint64 `json:"bytes_total"`
+	FilesNew        int            `json:"files_new"`
+	FilesChanged    int            `json:"files_changed"`
+	FilesUnmodified int            `json:"files_unmodified"`
+	DirsNew         int            `json:"dirs_new"`
+	DirsChanged     int            `json:"dirs_changed"`
+	DirsUnmodified  int            `json:"dirs_unmodified"`
+	FilesTotal      int            `json:"files_total"`
+	BytesAdded      uint64         `json:"bytes_added"`
+	BytesTotal      uint64         `json:"bytes_total"`
+	SnapshotID      string         `json:"snapshot_id,omitempty"`
+	Diff            *DiffStatus    `json:"diff,omitempty"`
+	Storage         *StorageStatus `json:"storage,omitempty"`
+}
+
+// StorageStatus contains the estimated cost of the repository after the last backup
+type StorageStatus struct {
+	Backend         string  `json:"backend"`
+	RepositorySize  uint64  `json:"repository_size"`
+	MonthlyCost     float64 `json:"monthly_cost"`
+	WriteOperations uint64  `json:"write_operations"`
+	OperationsCost  float64 `json:"operations_cost"`
+	Currency        string  `json:"currency"`
+}
+
+func newStorageStatus(storage *monitor.StorageSummary) *StorageStatus {
+	if storage == nil {
+		return nil
+	}
+	return &StorageStatus{
+		Backend:         storage.Backend,
+		RepositorySize:  storage.RepositorySize,
+		MonthlyCost:     storage.MonthlyCost,
+		WriteOperations: storage.WriteOperations,
+		OperationsCost:  storage.OperationsCost,
+		Currency:        storage.Currency,
+	}
+}
+
+// DiffStatus contains the changes between the last backup and the previous snapshot
+type DiffStatus struct {
+	PreviousSnapshot string `json:"previous_snapshot"`
+	Snapshot         string `json:"snapshot"`
+	FilesAdded       int    `json:"files_added"`
+	FilesRemoved     int    `json:"files_removed"`
+	FilesChanged     int    `json:"files_changed"`
+	BytesAdded       uint64 `json:"bytes_added"`
+	BytesRemoved     uint64 `json:"bytes_removed"`
+	SizeDelta        int64  `json:"size_delta"`
+}
+
+func newDiffStatus(diff *monitor.DiffSummary) *DiffStatus {
+	if diff == nil {
+		return nil
+	}
+	return &DiffStatus{
+		PreviousSnapshot: diff.PreviousSnapshot,
+		Snapshot:         diff.Snapshot,
+		FilesAdded:       diff.FilesAdded,
+		FilesRemoved:     diff.FilesRemoved,
+		FilesChanged:     diff.FilesChanged,
+		BytesAdded:       diff.BytesAdded,
+		BytesRemoved:     diff.BytesRemoved,
+		SizeDelta:        diff.SizeDelta(),
+	}
+}
+
+// MountStatus contains the state of a restic mount running in the background
+type MountStatus struct {
+	PID        int       `json:"pid"`
+	Mountpoint string    `json:"mountpoint"`
+	LogFile    string    `json:"log_file,omitempty"`
+	Time       time.Time `json:"time"`
+}
+
+// DaemonStatus contains the state of the schedules of the profile run by "resticprofile daemon"
+type DaemonStatus struct {
+	PID       int                        `json:"pid"`
+	Started   time.Time                  `json:"started"`
+	Schedules map[string]*DaemonSchedule `json:"schedules"`
+}
+
+// DaemonSchedule contains the state of the schedule of a command, per command name
+type DaemonSchedule struct {
+	Next      time.Time  `json:"next"`
+	Running   bool       `json:"running"`
+	LastStart *time.Time `json:"last_start,omitempty"`
 }
 
 // BackupSuccess indicates the last backup was successful
@@ -59,6 +176,9 @@ func (p *Profile) BackupSuccess(summary monitor.Summary, stderr string) *Profile
 		FilesTotal:      summary.FilesTotal,
 		BytesAdded:      summary.BytesAdded,
 		BytesTotal:      summary.BytesTotal,
+		SnapshotID:      summary.SnapshotID,
+		Diff:            newDiffStatus(summary.Diff),
+		Storage:         newStorageStatus(summary.Storage),
 	}
 	return p
 }
@@ -67,11 +187,12 @@ func (p *Profile) BackupSuccess(summary monitor.Summary, stderr string) *Profile
 func (p *Profile) BackupError(err error, summary monitor.Summary, stderr string) *Profile {
 	p.Backup = &BackupStatus{
 		CommandStatus: CommandStatus{
-			Success:  false,
-			Time:     time.Now(),
-			Error:    err.Error(),
-			Duration: int64(math.Ceil(summary.Duration.Seconds())),
-			Stderr:   stderr,
+			Success:   false,
+			Time:      time.Now(),
+			Error:     err.Error(),
+			ErrorCode: summary.ErrorCode,
+			Duration:  int64(math.Ceil(summary.Duration.Seconds())),
+			Stderr:    stderr,
 		},
 		FilesNew:        0,
 		FilesChanged:    0,
@@ -89,24 +210,40 @@ func (p *Profile) BackupError(err error, summary monitor.Summary, stderr string)
 // RetentionSuccess indicates the last retention was successful
 func (p *Profile) RetentionSuccess(summary monitor.Summary, stderr string) *Profile {
 	p.Retention = newSuccess(summary.Duration, stderr)
+	p.Retention.Forget = newForgetStatus(summary.Forget)
 	return p
 }
 
 // RetentionError sets the error of the last retention
 func (p *Profile) RetentionError(err error, summary monitor.Summary, stderr string) *Profile {
-	p.Retention = newError(err, summary.Duration, stderr)
+	p.Retention = newError(err, summary, stderr)
+	p.Retention.Forget = newForgetStatus(summary.Forget)
 	return p
 }
 
 // CheckSuccess indicates the last check was successful
 func (p *Profile) CheckSuccess(summary monitor.Summary, stderr string) *Profile {
 	p.Check = newSuccess(summary.Duration, stderr)
+	p.Check.Check = newCheckStatus(summary.Check)
 	return p
 }
 
 // CheckError sets the error of the last check
 func (p *Profile) CheckError(err error, summary monitor.Summary, stderr string) *Profile {
-	p.Check = newError(err, summary.Duration, stderr)
+	p.Check = newError(err, summary, stderr)
+	p.Check.Check = newCheckStatus(summary.Check)
+	return p
+}
+
+// VerifySuccess indicates the last restore verification was successful
+func (p *Profile) VerifySuccess(summary monitor.Summary, stderr string) *Profile {
+	p.Verify = newSuccess(summary.Duration, stderr)
+	return p
+}
+
+// VerifyError sets the error of the last restore verification
+func (p *Profile) VerifyError(err error, summary monitor.Summary, stderr string) *Profile {
+	p.Verify = newError(err, summary, stderr)
 	return p
 }
 
@@ -119,12 +256,42 @@ func newSuccess(duration time.Duration, stderr string) *CommandStatus {
 	}
 }
 
-func newError(err error, duration time.Duration, stderr string) *CommandStatus {
+func newError(err error, summary monitor.Summary, stderr string) *CommandStatus {
 	return &CommandStatus{
-		Success:  false,
-		Time:     time.Now(),
-		Error:    err.Error(),
-		Duration: int64(math.Ceil(duration.Seconds())),
-		Stderr:   stderr,
+		Success:   false,
+		Time:      time.Now(),
+		Error:     err.Error(),
+		ErrorCode: summary.ErrorCode,
+		Duration:  int64(math.Ceil(summary.Duration.Seconds())),
+		Stderr:    stderr,
 	}
 }
+
+// MountStarted records a restic mount running in the background
+func (p *Profile) MountStarted(pid int, mountpoint, logFile string) *Profile {
+	p.Mount = &MountStatus{
+		PID:        pid,
+		Mountpoint: mountpoint,
+		LogFile:    logFile,
+		Time:       time.Now(),
+	}
+	return p
+}
+
+// MountStopped removes the background mount information
+func (p *Profile) MountStopped() *Profile {
+	p.Mount = nil
+	return p
+}
+
+// DaemonUpdated records the state of the schedules run by the daemon
+func (p *Profile) DaemonUpdated(daemon *DaemonStatus) *Profile {
+	p.Daemon = daemon
+	return p
+}
+
+// DaemonStopped removes the state of the daemon
+func (p *Profile) DaemonStopped() *Profile {
+	p.Daemon = nil
+	return p
+}
@@ -19,13 +19,12 @@ func NewProgress(profile *config.Profile, statusGenerator *Status) *Progress {
 	}
 }
 
-// getGenerator returns the default status file generator.
-// Please note the status file is loaded every time you call this function.
+// getGenerator returns the default status file generator
 func (p *Progress) getGenerator() *Status {
 	if p.generator == nil {
 		p.generator = NewStatus(p.profile.StatusFile)
 	}
-	return p.generator.Load()
+	return p.generator
 }
 
 func (p *Progress) Start(command string) {
@@ -57,43 +56,38 @@ func (p *Progress) Summary(command string, summary monitor.Summary, stderr strin
 }
 
 func (p *Progress) success(command string, summary monitor.Summary, stderr string) {
-	var err error
+	name := summary.ReportName(p.profile.Name)
 	switch command {
 	case constants.CommandBackup:
-		status := p.getGenerator()
-		status.Profile(p.profile.Name).BackupSuccess(summary, stderr)
-		err = status.Save()
+		p.update(name, func(profile *Profile) { profile.BackupSuccess(summary, stderr) })
 	case constants.CommandCheck:
-		status := p.getGenerator()
-		status.Profile(p.profile.Name).CheckSuccess(summary, stderr)
-		err = status.Save()
+		p.update(name, func(profile *Profile) { profile.CheckSuccess(summary, stderr) })
 	case constants.SectionConfigurationRetention, constants.CommandForget:
-		status := p.getGenerator()
-		status.Profile(p.profile.Name).RetentionSuccess(summary, stderr)
-		err = status.Save()
-	}
-	if err != nil {
-		// not important enough to throw an error here
-		clog.Warningf("saving status file '%s': %v", p.profile.StatusFile, err)
+		p.update(name, func(profile *Profile) { profile.RetentionSuccess(summary, stderr) })
+	case constants.SectionConfigurationVerify:
+		p.update(name, func(profile *Profile) { profile.VerifySuccess(summary, stderr) })
 	}
 }
 
 func (p *Progress) error(command string, summary monitor.Summary, stderr string, fail error) {
-	var err error
+	name := summary.ReportName(p.profile.Name)
 	switch command {
 	case constants.CommandBackup:
-		status := p.getGenerator()
-		status.Profile(p.profile.Name).BackupError(fail, summary, stderr)
-		err = status.Save()
+		p.update(name, func(profile *Profile) { profile.BackupError(fail, summary, stderr) })
 	case constants.CommandCheck:
-		status := p.getGenerator()
-		status.Profile(p.profile.Name).CheckError(fail, summary, stderr)
-		err = status.Save()
+		p.update(name, func(profile *Profile) { profile.CheckError(fail, summary, stderr) })
 	case constants.SectionConfigurationRetention, constants.CommandForget:
-		status := p.getGenerator()
-		status.Profile(p.profile.Name).RetentionError(fail, summary, stderr)
-		err = status.Save()
+		p.update(name, func(profile *Profile) { profile.RetentionError(fail, summary, stderr) })
+	case constants.SectionConfigurationVerify:
+		p.update(name, func(profile *Profile) { profile.VerifyError(fail, summary, stderr) })
 	}
+}
+
+// update changes the status of the profile (or of its additional repository) in the status file
+func (p *Progress) update(name string, update func(profile *Profile)) {
+	err := p.getGenerator().Update(func(status *Status) {
+		update(status.Profile(name))
+	})
 	if err != nil {
 		// not important enough to throw an error here
 		clog.Warningf("saving status file '%s': %v", p.profile.StatusFile, err)
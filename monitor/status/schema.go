@@ -0,0 +1,126 @@
+package status
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+)
+
+// SchemaVersion is the version of the status file format written by resticprofile.
+// New optional fields can be added within a version: the fields unknown to an older resticprofile are ignored,
+// so the file is still loaded (and not replaced) by the older versions. Removing a field or changing its meaning
+// must increase the version and come with a migration from the previous version.
+const SchemaVersion = 1
+
+//go:embed schema/status-v1.json
+var schemaV1 []byte
+
+// Schema returns the JSON schema describing the status file
+func Schema() []byte {
+	return schemaV1
+}
+
+// migration upgrades the raw content of a status file to the next schema version
+type migration func(raw map[string]any) error
+
+// migrations contains the migration from each schema version to the next one
+var migrations = map[int]migration{
+	0: migrateFromV0,
+}
+
+// migrateFromV0 upgrades a status file written before the schema was versioned:
+// the content is the same as version 1 without the version field
+func migrateFromV0(raw map[string]any) error {
+	if _, found := raw["profiles"]; !found {
+		raw["profiles"] = map[string]any{}
+	}
+	return nil
+}
+
+// schemaVersion returns the schema version of a raw status file (0 when the version field is missing)
+func schemaVersion(raw map[string]any) (int, error) {
+	value, found := raw["version"]
+	if !found {
+		return 0, nil
+	}
+	version, ok := value.(float64)
+	if !ok || version < 1 || version != float64(int(version)) {
+		return 0, fmt.Errorf("invalid schema version: %v", value)
+	}
+	return int(version), nil
+}
+
+// decodeStatus migrates the content of a status file to the current schema version and validates it
+func decodeStatus(data []byte) (*Status, error) {
+	raw := make(map[string]any)
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	version, err := schemaVersion(raw)
+	if err != nil {
+		return nil, err
+	}
+	if version > SchemaVersion {
+		return nil, &newerVersionError{version: version}
+	}
+	for ; version < SchemaVersion; version++ {
+		if err = migrations[version](raw); err != nil {
+			return nil, fmt.Errorf("cannot migrate from schema version %d: %w", version, err)
+		}
+	}
+	raw["version"] = SchemaVersion
+
+	data, err = json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	status := &Status{}
+	if err = json.Unmarshal(data, status); err != nil {
+		return nil, fmt.Errorf("content doesn't match schema version %d: %w", SchemaVersion, err)
+	}
+	if err = status.validate(); err != nil {
+		return nil, fmt.Errorf("content doesn't match schema version %d: %w", SchemaVersion, err)
+	}
+	return status, nil
+}
+
+// validate checks the constraints of the schema that cannot be verified by the JSON decoder
+func (s *Status) validate() error {
+	if s.Profiles == nil {
+		return fmt.Errorf("missing profiles")
+	}
+	for name, profile := range s.Profiles {
+		if profile == nil {
+			return fmt.Errorf("profile %q: no status", name)
+		}
+		commands := map[string]*CommandStatus{
+			"retention":      profile.Retention,
+			"check":          profile.Check,
+			"verify-restore": profile.Verify,
+		}
+		if profile.Backup != nil {
+			commands["backup"] = &profile.Backup.CommandStatus
+		}
+		for command, status := range commands {
+			if status != nil && status.Time.IsZero() {
+				return fmt.Errorf("profile %q: %s: missing time", name, command)
+			}
+		}
+		if profile.Mount != nil && (profile.Mount.PID <= 0 || profile.Mount.Mountpoint == "") {
+			return fmt.Errorf("profile %q: mount: missing pid or mountpoint", name)
+		}
+		if profile.Daemon != nil && (profile.Daemon.PID <= 0 || profile.Daemon.Started.IsZero()) {
+			return fmt.Errorf("profile %q: daemon: missing pid or start time", name)
+		}
+	}
+	return nil
+}
+
+// newerVersionError is returned when the status file was written by a more recent version of resticprofile
+type newerVersionError struct {
+	version int
+}
+
+func (e *newerVersionError) Error() string {
+	return fmt.Sprintf("schema version %d is not supported (maximum supported is %d), please upgrade resticprofile", e.version, SchemaVersion)
+}
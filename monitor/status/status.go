@@ -1,26 +1,39 @@
 package status
 
 import (
+	"bytes"
 	"encoding/json"
-	"os"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"time"
 
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/util/crypt"
+	"github.com/creativeprojects/resticprofile/util/filelock"
 	"github.com/spf13/afero"
 )
 
+// lockTimeout is the maximum time to wait for another process updating the status file
+const lockTimeout = 30 * time.Second
+
 // Status of last schedule profile
 type Status struct {
 	fs       afero.Fs
 	filename string
+	key      *crypt.Key
+	readOnly error
+	locking  bool
+	Version  int                 `json:"version"`
 	Profiles map[string]*Profile `json:"profiles"`
 }
 
 // NewStatus returns a new blank status
 func NewStatus(fileName string) *Status {
-	return &Status{
-		fs:       afero.NewOsFs(),
-		filename: fileName,
-		Profiles: make(map[string]*Profile),
-	}
+	status := newAferoStatus(afero.NewOsFs(), fileName)
+	status.locking = true
+	return status
 }
 
 // newAferoStatus returns a new blank status for unit test
@@ -28,11 +41,15 @@ func newAferoStatus(fs afero.Fs, fileName string) *Status {
 	return &Status{
 		fs:       fs,
 		filename: fileName,
+		key:      crypt.DefaultKey(),
+		Version:  SchemaVersion,
 		Profiles: make(map[string]*Profile),
 	}
 }
 
-// Load existing status; does not complain if the file does not exists, or is not readable
+// Load existing status; does not complain if the file does not exists, or is not readable.
+// A status file written with a previous schema version is migrated to the current version.
+// A status file that doesn't match the schema is logged and replaced on the next Save.
 func (s *Status) Load() *Status {
 	// we're not bothered if the status cannot be loaded
 	file, err := s.fs.Open(s.filename)
@@ -40,8 +57,35 @@ func (s *Status) Load() *Status {
 		return s
 	}
 	defer file.Close()
-	decoder := json.NewDecoder(file)
-	_ = decoder.Decode(s)
+	data, err := io.ReadAll(file)
+	if err != nil || len(bytes.TrimSpace(data)) == 0 {
+		return s
+	}
+	if crypt.IsEncrypted(data) {
+		if s.key == nil {
+			err = crypt.ErrNoKey
+		} else {
+			data, err = s.key.Decrypt(data)
+		}
+		if err != nil {
+			// don't replace a status file we cannot read
+			clog.Warningf("status file '%s': %s", s.filename, err)
+			s.readOnly = err
+			return s
+		}
+	}
+	loaded, err := decodeStatus(data)
+	if err != nil {
+		clog.Warningf("status file '%s': %s", s.filename, err)
+		var newerVersion *newerVersionError
+		if errors.As(err, &newerVersion) {
+			// don't lose information from a more recent version of resticprofile
+			s.readOnly = err
+		}
+		return s
+	}
+	s.Version = loaded.Version
+	s.Profiles = loaded.Profiles
 	return s
 }
 
@@ -55,17 +99,60 @@ func (s *Status) Profile(name string) *Profile {
 	return profile
 }
 
-// Save current status to the file
+// Update loads the status file, applies the changes and saves the file, while holding a lock on the file:
+// the processes sharing the same status file (like the profiles of a group running in parallel) update it in turn
+func (s *Status) Update(update func(status *Status)) error {
+	if s.locking && s.filename != "" {
+		unlock, err := filelock.Lock(s.filename, lockTimeout)
+		if err != nil {
+			// better saving the status than losing it
+			clog.Warningf("status file '%s': %s", s.filename, err)
+		} else {
+			defer unlock()
+		}
+	}
+	s.Load()
+	update(s)
+	return s.Save()
+}
+
+// Save current status to the file. The file is replaced atomically: a reader never sees a partially written file.
 func (s *Status) Save() error {
-	file, err := s.fs.OpenFile(s.filename, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if s.readOnly != nil {
+		return fmt.Errorf("cannot overwrite status file: %w", s.readOnly)
+	}
+	s.Version = SchemaVersion
+	data, err := json.Marshal(s)
 	if err != nil {
 		return err
 	}
-	defer file.Close()
-	encoder := json.NewEncoder(file)
-	err = encoder.Encode(s)
+	if s.key != nil {
+		if data, err = s.key.Encrypt(data); err != nil {
+			return err
+		}
+	}
+	file, err := afero.TempFile(s.fs, filepath.Dir(s.filename), filepath.Base(s.filename)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = s.fs.Remove(file.Name())
+		}
+	}()
+	_, err = file.Write(append(data, '\n'))
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
 	if err != nil {
 		return err
 	}
-	return nil
+	if err = s.fs.Chmod(file.Name(), 0644); err != nil {
+		return err
+	}
+	err = s.fs.Rename(file.Name(), s.filename)
+	return err
 }
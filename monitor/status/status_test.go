@@ -1,13 +1,16 @@
 package status
 
 import (
+	"encoding/json"
 	"errors"
 	"testing"
 	"time"
 
 	"github.com/creativeprojects/resticprofile/monitor"
+	"github.com/creativeprojects/resticprofile/util/crypt"
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestLoadNoFile(t *testing.T) {
@@ -38,6 +41,18 @@ func TestBackupError(t *testing.T) {
 	assert.Equal(t, int64(45), status.Profile(profileName).Backup.Duration)
 }
 
+func TestBackupSuccessWithDiff(t *testing.T) {
+	profileName := "test profile"
+	status := NewStatus("")
+	status.Profile(profileName).BackupSuccess(monitor.Summary{Diff: &monitor.DiffSummary{FilesAdded: 2, BytesAdded: 10, BytesRemoved: 30}}, "")
+	require.NotNil(t, status.Profile(profileName).Backup.Diff)
+	assert.Equal(t, 2, status.Profile(profileName).Backup.Diff.FilesAdded)
+	assert.Equal(t, int64(-20), status.Profile(profileName).Backup.Diff.SizeDelta)
+
+	status.Profile(profileName).BackupSuccess(monitor.Summary{}, "")
+	assert.Nil(t, status.Profile(profileName).Backup.Diff)
+}
+
 func TestRetentionSuccess(t *testing.T) {
 	profileName := "test profile"
 	status := NewStatus("")
@@ -59,6 +74,20 @@ func TestRetentionError(t *testing.T) {
 	assert.Equal(t, int64(45), status.Profile(profileName).Retention.Duration)
 }
 
+func TestVerifySuccessAndError(t *testing.T) {
+	profileName := "test profile"
+	status := NewStatus("")
+	assert.Nil(t, status.Profile(profileName).Verify)
+	status.Profile(profileName).VerifySuccess(monitor.Summary{Duration: parseDuration("45s")}, "")
+	assert.True(t, status.Profile(profileName).Verify.Success)
+	assert.Equal(t, int64(45), status.Profile(profileName).Verify.Duration)
+
+	status.Profile(profileName).VerifyError(errors.New("failed"), monitor.Summary{}, "stderr")
+	assert.False(t, status.Profile(profileName).Verify.Success)
+	assert.Equal(t, "failed", status.Profile(profileName).Verify.Error)
+	assert.Equal(t, "stderr", status.Profile(profileName).Verify.Stderr)
+}
+
 func TestCheckSuccess(t *testing.T) {
 	profileName := "test profile"
 	status := NewStatus("")
@@ -217,3 +246,198 @@ func parseDuration(input string) time.Duration {
 	}
 	return duration
 }
+
+func TestMountStartedAndStopped(t *testing.T) {
+	profileName := "test profile"
+	status := NewStatus("")
+	assert.Nil(t, status.Profile(profileName).Mount)
+	status.Profile(profileName).MountStarted(1234, "/mnt/restic", "/tmp/mount.log")
+	require.NotNil(t, status.Profile(profileName).Mount)
+	assert.Equal(t, 1234, status.Profile(profileName).Mount.PID)
+	assert.Equal(t, "/mnt/restic", status.Profile(profileName).Mount.Mountpoint)
+	assert.Equal(t, "/tmp/mount.log", status.Profile(profileName).Mount.LogFile)
+	assert.False(t, status.Profile(profileName).Mount.Time.IsZero())
+
+	status.Profile(profileName).MountStopped()
+	assert.Nil(t, status.Profile(profileName).Mount)
+}
+
+func TestLoadStatusWithoutVersion(t *testing.T) {
+	filename := "status.json"
+	fs := afero.NewMemMapFs()
+	content := `{"profiles":{"test":{"backup":{"success":true,"time":"2021-03-24T16:36:56.831077Z","error":"","stderr":"","duration":16,"files_new":215}}}}`
+	require.NoError(t, afero.WriteFile(fs, filename, []byte(content), 0o600))
+
+	status := newAferoStatus(fs, filename).Load()
+	assert.Equal(t, SchemaVersion, status.Version)
+	require.NotNil(t, status.Profile("test").Backup)
+	assert.Equal(t, 215, status.Profile("test").Backup.FilesNew)
+
+	require.NoError(t, status.Save())
+	saved, err := afero.ReadFile(fs, filename)
+	require.NoError(t, err)
+	assert.Contains(t, string(saved), `"version":1`)
+}
+
+func TestLoadInvalidStatus(t *testing.T) {
+	filename := "status.json"
+	fixtures := []string{
+		`not json`,
+		`{"version":"one","profiles":{}}`,
+		`{"version":1,"profiles":{"test":{"backup":{"success":true}}}}`,
+		`{"version":1}`,
+	}
+	for _, fixture := range fixtures {
+		t.Run(fixture, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			require.NoError(t, afero.WriteFile(fs, filename, []byte(fixture), 0o600))
+
+			status := newAferoStatus(fs, filename).Load()
+			assert.Empty(t, status.Profiles)
+			// an invalid file is replaced
+			status.Profile("test").CheckSuccess(monitor.Summary{}, "")
+			assert.NoError(t, status.Save())
+		})
+	}
+}
+
+func TestLoadStatusWithUnknownFields(t *testing.T) {
+	// a status file written by a more recent resticprofile with the same schema version
+	filename := "status.json"
+	fs := afero.NewMemMapFs()
+	content := `{"version":1,"profiles":{"test":{"unknown":{},"backup":{"success":true,"time":"2026-10-17T10:00:00Z","other":1}}}}`
+	require.NoError(t, afero.WriteFile(fs, filename, []byte(content), 0o600))
+
+	status := newAferoStatus(fs, filename).Load()
+	require.NotNil(t, status.Profile("test").Backup)
+	assert.True(t, status.Profile("test").Backup.Success)
+
+	status.Profile("test").CheckSuccess(monitor.Summary{}, "")
+	require.NoError(t, status.Save())
+	status = newAferoStatus(fs, filename).Load()
+	assert.NotNil(t, status.Profile("test").Backup)
+	assert.NotNil(t, status.Profile("test").Check)
+}
+
+func TestLoadStatusFromNewerVersion(t *testing.T) {
+	filename := "status.json"
+	fs := afero.NewMemMapFs()
+	content := `{"version":99,"profiles":{}}`
+	require.NoError(t, afero.WriteFile(fs, filename, []byte(content), 0o600))
+
+	status := newAferoStatus(fs, filename).Load()
+	status.Profile("test").CheckSuccess(monitor.Summary{}, "")
+	assert.ErrorContains(t, status.Save(), "schema version 99 is not supported")
+
+	saved, err := afero.ReadFile(fs, filename)
+	require.NoError(t, err)
+	assert.Equal(t, content, string(saved))
+}
+
+func TestSchemaMatchesStatus(t *testing.T) {
+	schema := make(map[string]any)
+	require.NoError(t, json.Unmarshal(Schema(), &schema))
+	definitions := schema["$defs"].(map[string]any)
+
+	properties := func(definition string) []string {
+		names := make([]string, 0)
+		for name := range definitions[definition].(map[string]any)["properties"].(map[string]any) {
+			names = append(names, name)
+		}
+		return names
+	}
+	fields := func(value any) []string {
+		data, err := json.Marshal(value)
+		require.NoError(t, err)
+		content := make(map[string]any)
+		require.NoError(t, json.Unmarshal(data, &content))
+		names := make([]string, 0)
+		for name := range content {
+			names = append(names, name)
+		}
+		return names
+	}
+
+	profile := newProfile().
+		BackupSuccess(monitor.Summary{SnapshotID: "abcd", Diff: &monitor.DiffSummary{}, Storage: &monitor.StorageSummary{}}, "").
+		CheckError(errors.New("error"), monitor.Summary{ErrorCode: monitor.ErrorCodeUnknown, Check: &monitor.CheckSummary{Errors: 1}}, "").
+		RetentionSuccess(monitor.Summary{Forget: &monitor.ForgetSummary{}}, "").
+		VerifySuccess(monitor.Summary{}, "").
+		MountStarted(10, "/mnt", "log").
+		DaemonUpdated(&DaemonStatus{PID: 10, Started: time.Now(), Schedules: map[string]*DaemonSchedule{
+			"backup": {Next: time.Now(), LastStart: new(time.Time)},
+		}})
+	profile.Backup.ErrorCode = monitor.ErrorCodeUnknown // only set on failure
+	assert.ElementsMatch(t, properties("profile"), fields(profile))
+	assert.ElementsMatch(t, properties("backup"), fields(profile.Backup))
+	profile.Check.Forget = profile.Retention.Forget // both are only set on their own command
+	assert.ElementsMatch(t, properties("command"), fields(profile.Check))
+	assert.ElementsMatch(t, properties("forget"), fields(profile.Retention.Forget))
+	assert.ElementsMatch(t, properties("check"), fields(profile.Check.Check))
+	assert.ElementsMatch(t, properties("diff"), fields(profile.Backup.Diff))
+	assert.ElementsMatch(t, properties("storage"), fields(profile.Backup.Storage))
+	assert.ElementsMatch(t, properties("mount"), fields(profile.Mount))
+	assert.ElementsMatch(t, properties("daemon"), fields(profile.Daemon))
+	assert.ElementsMatch(t, properties("daemonSchedule"), fields(profile.Daemon.Schedules["backup"]))
+	assert.ElementsMatch(t, []any{"version", "profiles"}, schema["required"])
+	assert.ElementsMatch(t, []string{"version", "profiles"}, fields(NewStatus("")))
+}
+
+func TestSaveAndLoadEncryptedStatus(t *testing.T) {
+	filename := "TestSaveAndLoadEncryptedStatus.json"
+	profileName := "test profile"
+	key, err := crypt.NewKey([]byte("0123456789abcdef"))
+	require.NoError(t, err)
+
+	fs := afero.NewMemMapFs()
+	status := newAferoStatus(fs, filename)
+	status.key = key
+	status.Profile(profileName).BackupSuccess(monitor.Summary{Duration: parseDuration("1m")}, "")
+	require.NoError(t, status.Save())
+
+	content, err := afero.ReadFile(fs, filename)
+	require.NoError(t, err)
+	assert.True(t, crypt.IsEncrypted(content))
+	assert.NotContains(t, string(content), profileName)
+
+	status = newAferoStatus(fs, filename)
+	status.key = key
+	status.Load()
+	require.NotNil(t, status.Profile(profileName).Backup)
+	assert.True(t, status.Profile(profileName).Backup.Success)
+
+	// without the key, the status file cannot be read nor replaced
+	status = newAferoStatus(fs, filename).Load()
+	assert.Empty(t, status.Profiles)
+	assert.ErrorIs(t, status.Save(), crypt.ErrNoKey)
+
+	// with the wrong key either
+	other, err := crypt.NewKey([]byte("fedcba9876543210"))
+	require.NoError(t, err)
+	status = newAferoStatus(fs, filename)
+	status.key = other
+	status.Load()
+	assert.Empty(t, status.Profiles)
+	assert.ErrorIs(t, status.Save(), crypt.ErrInvalidKey)
+}
+
+func TestEncryptPlainStatus(t *testing.T) {
+	filename := "TestEncryptPlainStatus.json"
+	profileName := "test profile"
+	fs := afero.NewMemMapFs()
+	status := newAferoStatus(fs, filename)
+	status.Profile(profileName).BackupSuccess(monitor.Summary{}, "")
+	require.NoError(t, status.Save())
+
+	key, err := crypt.NewKey([]byte("0123456789abcdef"))
+	require.NoError(t, err)
+	status = newAferoStatus(fs, filename)
+	status.key = key
+	status.Load()
+	require.NotNil(t, status.Profile(profileName).Backup)
+	require.NoError(t, status.Save())
+
+	content, err := afero.ReadFile(fs, filename)
+	require.NoError(t, err)
+	assert.True(t, crypt.IsEncrypted(content))
+}
@@ -0,0 +1,42 @@
+package status
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/creativeprojects/resticprofile/monitor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConcurrentUpdates(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "status.json")
+	const count = 20
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err := NewStatus(filename).Update(func(status *Status) {
+				status.Profile(fmt.Sprintf("profile%d", i)).BackupSuccess(monitor.Summary{}, "")
+			})
+			assert.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+
+	status := NewStatus(filename).Load()
+	assert.Len(t, status.Profiles, count)
+
+	entries, err := os.ReadDir(filepath.Dir(filename))
+	require.NoError(t, err)
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	assert.ElementsMatch(t, []string{"status.json", "status.json.lock"}, names, "no temporary file left behind")
+}
@@ -1,6 +1,11 @@
 package monitor
 
-import "time"
+import (
+	"strconv"
+	"time"
+
+	"github.com/creativeprojects/resticprofile/constants"
+)
 
 // Summary of the profile run
 type Summary struct {
@@ -14,7 +19,93 @@ type Summary struct {
 	FilesTotal      int
 	BytesAdded      uint64
 	BytesTotal      uint64
+	SnapshotID      string // ID of the snapshot saved by the backup
+	Diff            *DiffSummary
+	Storage         *StorageSummary
+	Forget          *ForgetSummary // snapshots kept and removed, read from the JSON output of forget
+	Check           *CheckSummary  // errors found, read from the JSON output of check
+	Repository      string         // name of the additional repository of the profile (empty for the main repository)
 	OutputAnalysis  OutputAnalysis
+	ConfigIssues    []string // deprecations and other issues found in the configuration
+	ConfigHash      string   // hash of the configuration files
+	ErrorCode       string   // code of the cause of the failure (see ErrorCode), empty when the command succeeded
+}
+
+// DiffSummary of the changes between the new snapshot and the previous one
+type DiffSummary struct {
+	PreviousSnapshot string
+	Snapshot         string
+	FilesAdded       int
+	FilesRemoved     int
+	FilesChanged     int
+	BytesAdded       uint64
+	BytesRemoved     uint64
+}
+
+// StorageSummary is the estimated cost of the repository after a backup
+type StorageSummary struct {
+	Backend         string  // type of backend of the repository (s3, b2, sftp, local, etc.)
+	RepositorySize  uint64  // size of the data stored in the repository
+	MonthlyCost     float64 // estimated cost of the storage for a month
+	WriteOperations uint64  // estimated number of write operations (PUT requests) of the backup
+	OperationsCost  float64 // estimated cost of the write operations of the backup
+	Currency        string
+}
+
+// ForgetSummary of the snapshots kept and removed by the retention policy
+type ForgetSummary struct {
+	SnapshotsKept    int
+	SnapshotsRemoved int
+}
+
+// CheckSummary of the verification of the repository
+type CheckSummary struct {
+	Errors             int
+	SuggestRepairIndex bool
+	SuggestPrune       bool
+}
+
+// Environment returns the values of the summary exposed to the hooks as environment variables.
+// The statistics of the backup are only available when they were read from the output of restic.
+func (s Summary) Environment() map[string]string {
+	env := make(map[string]string)
+	if s.Duration > 0 {
+		env[constants.EnvSummaryDuration] = strconv.FormatInt(int64(s.Duration.Seconds()), 10)
+	}
+	if s.SnapshotID != "" || s.FilesTotal > 0 {
+		env[constants.EnvSummarySnapshotID] = s.SnapshotID
+		env[constants.EnvSummaryFilesNew] = strconv.Itoa(s.FilesNew)
+		env[constants.EnvSummaryFilesChanged] = strconv.Itoa(s.FilesChanged)
+		env[constants.EnvSummaryFilesUnmodified] = strconv.Itoa(s.FilesUnmodified)
+		env[constants.EnvSummaryDirsNew] = strconv.Itoa(s.DirsNew)
+		env[constants.EnvSummaryDirsChanged] = strconv.Itoa(s.DirsChanged)
+		env[constants.EnvSummaryDirsUnmodified] = strconv.Itoa(s.DirsUnmodified)
+		env[constants.EnvSummaryFilesTotal] = strconv.Itoa(s.FilesTotal)
+		env[constants.EnvSummaryBytesAdded] = strconv.FormatUint(s.BytesAdded, 10)
+		env[constants.EnvSummaryBytesTotal] = strconv.FormatUint(s.BytesTotal, 10)
+	}
+	if s.Forget != nil {
+		env[constants.EnvSummarySnapshotsKept] = strconv.Itoa(s.Forget.SnapshotsKept)
+		env[constants.EnvSummarySnapshotsRemoved] = strconv.Itoa(s.Forget.SnapshotsRemoved)
+	}
+	if s.Check != nil {
+		env[constants.EnvSummaryCheckErrors] = strconv.Itoa(s.Check.Errors)
+	}
+	return env
+}
+
+// ReportName returns the name under which the results of the profile are reported: the results of an
+// additional repository are reported separately from the main repository, as "profile@repository"
+func (s Summary) ReportName(profile string) string {
+	if s.Repository == "" {
+		return profile
+	}
+	return profile + "@" + s.Repository
+}
+
+// SizeDelta returns the difference in size between the two snapshots
+func (d DiffSummary) SizeDelta() int64 {
+	return int64(d.BytesAdded) - int64(d.BytesRemoved)
 }
 
 // OutputAnalysis of the profile run
@@ -28,4 +119,10 @@ type OutputAnalysis interface {
 
 	// GetRemoteLockedBy returns who locked the remote lock, if available.
 	GetRemoteLockedBy() (string, bool)
+
+	// ContainsDiskFull returns true if the output indicates that a disk ran out of space (ENOSPC).
+	ContainsDiskFull() bool
+
+	// ContainsNetworkFailure returns true if the output indicates that the repository could not be reached.
+	ContainsNetworkFailure() bool
 }
@@ -23,6 +23,7 @@ type ownCommand struct {
 	needConfiguration bool              // true if the action needs a configuration file loaded
 	hide              bool              // don't display the command in help and completion
 	hideInCompletion  bool              // don't display the command in completion
+	readOnly          bool              // true if the action never modifies anything (allowed with --read-only)
 	flags             map[string]string // own command flags should be simple enough to be handled manually for now
 }
 
@@ -58,6 +59,9 @@ func (o *OwnCommands) All() []ownCommand {
 func (o *OwnCommands) Run(configuration *config.Config, commandName string, flags commandLineFlags, args []string) error {
 	for _, command := range o.commands {
 		if command.name == commandName {
+			if flags.readOnly && !command.readOnly {
+				return fmt.Errorf("command %q is not allowed in read-only mode", commandName)
+			}
 			return command.action(os.Stdout, commandRequest{
 				ownCommands: o,
 				config:      configuration,
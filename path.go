@@ -0,0 +1,78 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/shell"
+	"github.com/creativeprojects/resticprofile/util/collect"
+	"golang.org/x/exp/slices"
+)
+
+const pathEnv = "PATH"
+
+// prependPathList adds the directories at the beginning of the path list, moving them to the front when already present
+func prependPathList(pathList string, dirs []string) string {
+	var prepend []string
+	for _, dir := range dirs {
+		if dir = strings.TrimSpace(dir); dir != "" && !slices.Contains(prepend, filepath.Clean(dir)) {
+			prepend = append(prepend, filepath.Clean(dir))
+		}
+	}
+	if len(prepend) == 0 {
+		return pathList
+	}
+	result := prepend
+	for _, dir := range filepath.SplitList(pathList) {
+		if dir != "" && !slices.Contains(prepend, filepath.Clean(dir)) {
+			result = append(result, dir)
+		}
+	}
+	return strings.Join(result, string(os.PathListSeparator))
+}
+
+// prependPath adds the directories at the beginning of the PATH of the current process (and of all the processes it starts).
+// It returns a function restoring the previous PATH.
+func prependPath(dirs []string) (restore func()) {
+	previous, found := os.LookupEnv(pathEnv)
+	_ = os.Setenv(pathEnv, prependPathList(previous, dirs))
+	return func() {
+		if found {
+			_ = os.Setenv(pathEnv, previous)
+		} else {
+			_ = os.Unsetenv(pathEnv)
+		}
+	}
+}
+
+// environmentWithPath returns a copy of the environment with the directories added at the beginning of its PATH
+func environmentWithPath(env []string, dirs []string) []string {
+	result := make([]string, 0, len(env)+1)
+	pathList := ""
+	for _, entry := range env {
+		if name, value, _ := strings.Cut(entry, "="); strings.EqualFold(name, pathEnv) {
+			pathList = value
+			continue
+		}
+		result = append(result, entry)
+	}
+	if pathList = prependPathList(pathList, dirs); pathList != "" {
+		result = append(result, pathEnv+"="+pathList)
+	}
+	return result
+}
+
+// checkShellBinary resolves the shell configured in the global section, so that a missing shell is reported before running any command
+func checkShellBinary(shells []string) {
+	shells = collect.All(shells, collect.Not(collect.In("auto")))
+	if len(shells) == 0 {
+		return
+	}
+	if binary, err := shell.FindShell(shells); err != nil {
+		clog.Warningf("%s: commands like run-before or run-after are going to fail (PATH=%s)", err, os.Getenv(pathEnv))
+	} else {
+		clog.Debugf("using shell %q", binary)
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrependPathList(t *testing.T) {
+	sep := string(os.PathListSeparator)
+	a, b, c := filepath.FromSlash("/a"), filepath.FromSlash("/b"), filepath.FromSlash("/c")
+
+	fixtures := []struct {
+		pathList string
+		dirs     []string
+		expected string
+	}{
+		{pathList: "", dirs: nil, expected: ""},
+		{pathList: a, dirs: nil, expected: a},
+		{pathList: "", dirs: []string{a}, expected: a},
+		{pathList: strings.Join([]string{b, c}, sep), dirs: []string{a}, expected: strings.Join([]string{a, b, c}, sep)},
+		{pathList: strings.Join([]string{a, b, c}, sep), dirs: []string{c, " ", a + string(filepath.Separator)}, expected: strings.Join([]string{c, a, b}, sep)},
+		{pathList: strings.Join([]string{a, "", b}, sep), dirs: []string{b, b}, expected: strings.Join([]string{b, a}, sep)},
+	}
+	for _, fixture := range fixtures {
+		t.Run(fixture.pathList+":"+strings.Join(fixture.dirs, ","), func(t *testing.T) {
+			assert.Equal(t, fixture.expected, prependPathList(fixture.pathList, fixture.dirs))
+		})
+	}
+}
+
+func TestPrependPath(t *testing.T) {
+	dir := filepath.FromSlash("/resticprofile/test")
+	t.Setenv(pathEnv, "")
+
+	restore := prependPath([]string{dir})
+	assert.Equal(t, dir, os.Getenv(pathEnv))
+	restore()
+	assert.Equal(t, "", os.Getenv(pathEnv))
+}
+
+func TestEnvironmentWithPath(t *testing.T) {
+	sep := string(os.PathListSeparator)
+	a, b := filepath.FromSlash("/a"), filepath.FromSlash("/b")
+
+	env := environmentWithPath([]string{"HOME=/home", "PATH=" + b}, []string{a})
+	assert.Equal(t, []string{"HOME=/home", "PATH=" + a + sep + b}, env)
+
+	env = environmentWithPath([]string{"HOME=/home"}, []string{a})
+	assert.Equal(t, []string{"HOME=/home", "PATH=" + a}, env)
+
+	env = environmentWithPath([]string{"HOME=/home"}, nil)
+	assert.Equal(t, []string{"HOME=/home"}, env)
+}
@@ -0,0 +1,17 @@
+package priority
+
+import "errors"
+
+// Scheduling classes of a child process
+const (
+	SchedulingNormal = "normal"
+	SchedulingBatch  = "batch"
+	SchedulingIdle   = "idle"
+)
+
+var (
+	// ErrNotSupported is returned when the setting of the child process is not available on this OS
+	ErrNotSupported = errors.New("not supported on this operating system")
+	// ErrSchedulingClass is returned when the scheduling class cannot be set (the child process was not started)
+	ErrSchedulingClass = errors.New("cannot set scheduling class")
+)
@@ -0,0 +1,70 @@
+//go:build linux
+
+package priority
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// Linux scheduling policies (see sched(7))
+const (
+	schedOther = 0
+	schedBatch = 3
+	schedIdle  = 5
+)
+
+var schedulingPolicies = map[string]int{
+	SchedulingNormal: schedOther,
+	SchedulingBatch:  schedBatch,
+	SchedulingIdle:   schedIdle,
+}
+
+// StartWithSchedulingClass calls start from a thread using the scheduling class: the child process started
+// by start inherits the class, while resticprofile keeps its own.
+func StartWithSchedulingClass(class string, start func() error) error {
+	if class == "" {
+		return start()
+	}
+	policy, found := schedulingPolicies[class]
+	if !found {
+		return fmt.Errorf("unknown scheduling class %q", class)
+	}
+	done := make(chan error, 1)
+	go func() {
+		// the thread is never unlocked: it is terminated with the goroutine, instead of running other goroutines
+		// with the scheduling class of the child
+		runtime.LockOSThread()
+		if err := setThreadSchedulingPolicy(policy); err != nil {
+			done <- fmt.Errorf("%w %q: %s", ErrSchedulingClass, class, err)
+			return
+		}
+		done <- start()
+	}()
+	return <-done
+}
+
+func setThreadSchedulingPolicy(policy int) error {
+	// the static priority must be 0 for the normal, batch and idle policies
+	param := struct{ priority int32 }{0}
+	_, _, errno := unix.Syscall(unix.SYS_SCHED_SETSCHEDULER, 0, uintptr(policy), uintptr(unsafe.Pointer(&param)))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// SetOOMScoreAdjust sets the adjustment of the score used by the kernel to pick the process to kill when
+// running out of memory (from -1000 to 1000: a higher value makes the process more likely to be killed)
+func SetOOMScoreAdjust(pid, score int) error {
+	if score < -1000 || score > 1000 {
+		return fmt.Errorf("unexpected OOM score adjustment %d", score)
+	}
+	filename := fmt.Sprintf("/proc/%d/oom_score_adj", pid)
+	return os.WriteFile(filename, []byte(strconv.Itoa(score)), 0)
+}
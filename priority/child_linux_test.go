@@ -0,0 +1,73 @@
+//go:build linux
+
+package priority
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// schedulingPolicy returns the policy field of the output of /proc/<pid>/stat
+func schedulingPolicy(t *testing.T, stat string) int {
+	t.Helper()
+	// the command name (2nd field) is between parentheses and may contain spaces
+	fields := strings.Fields(stat[strings.LastIndex(stat, ")")+2:])
+	require.Greater(t, len(fields), 38)
+	policy, err := strconv.Atoi(fields[38])
+	require.NoError(t, err)
+	return policy
+}
+
+func TestStartWithSchedulingClass(t *testing.T) {
+	for class, policy := range schedulingPolicies {
+		t.Run(class, func(t *testing.T) {
+			output := &bytes.Buffer{}
+			cmd := exec.Command("cat", "/proc/self/stat")
+			cmd.Stdout = output
+			require.NoError(t, StartWithSchedulingClass(class, cmd.Start))
+			require.NoError(t, cmd.Wait())
+			assert.Equal(t, policy, schedulingPolicy(t, output.String()))
+		})
+	}
+
+	t.Run("unknown", func(t *testing.T) {
+		cmd := exec.Command("true")
+		assert.ErrorContains(t, StartWithSchedulingClass("realtime", cmd.Start), `unknown scheduling class "realtime"`)
+	})
+
+	t.Run("current thread is unchanged", func(t *testing.T) {
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+
+		cmd := exec.Command("true")
+		require.NoError(t, StartWithSchedulingClass(SchedulingIdle, cmd.Start))
+		require.NoError(t, cmd.Wait())
+		stat, err := os.ReadFile("/proc/thread-self/stat")
+		require.NoError(t, err)
+		assert.Equal(t, schedOther, schedulingPolicy(t, string(stat)))
+	})
+}
+
+func TestSetOOMScoreAdjust(t *testing.T) {
+	cmd := exec.Command("sleep", "5")
+	require.NoError(t, cmd.Start())
+	defer func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	}()
+
+	require.NoError(t, SetOOMScoreAdjust(cmd.Process.Pid, 900))
+	content, err := os.ReadFile("/proc/" + strconv.Itoa(cmd.Process.Pid) + "/oom_score_adj")
+	require.NoError(t, err)
+	assert.Equal(t, "900", strings.TrimSpace(string(content)))
+
+	assert.Error(t, SetOOMScoreAdjust(cmd.Process.Pid, 1001))
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package priority
+
+// StartWithSchedulingClass calls start: scheduling classes are only available on Linux
+func StartWithSchedulingClass(_ string, start func() error) error {
+	return start()
+}
+
+// SetOOMScoreAdjust is only available on Linux
+func SetOOMScoreAdjust(_, _ int) error {
+	return ErrNotSupported
+}
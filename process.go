@@ -0,0 +1,12 @@
+package main
+
+import "github.com/shirou/gopsutil/v3/process"
+
+// processExists returns true when a process with this pid is running
+func processExists(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	running, err := process.PidExists(int32(pid))
+	return err == nil && running
+}
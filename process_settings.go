@@ -0,0 +1,61 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/priority"
+)
+
+// processStarter starts a restic process with the settings of the profile, and watches its memory during the run
+type processStarter struct {
+	settings  config.ProcessSection
+	maxMemory uint64
+	watchdog  *memoryWatchdog
+}
+
+// newProcessStarter returns nil when there's no setting to apply to the process
+func newProcessStarter(settings config.ProcessSection) (*processStarter, error) {
+	maxMemory, err := settings.GetMaxMemory()
+	if err != nil {
+		return nil, fmt.Errorf("max-memory: %w", err)
+	}
+	if settings.OOMScoreAdjust == nil && settings.SchedulingClass == "" && maxMemory == 0 {
+		return nil, nil
+	}
+	return &processStarter{settings: settings, maxMemory: maxMemory}, nil
+}
+
+// start is the shell.StartProcess callback
+func (s *processStarter) start(cmd *exec.Cmd) error {
+	err := priority.StartWithSchedulingClass(s.settings.SchedulingClass, cmd.Start)
+	if errors.Is(err, priority.ErrSchedulingClass) {
+		// the process wasn't started
+		clog.Warningf("%s, starting the process with the default scheduling class", err)
+		err = cmd.Start()
+	}
+	if err != nil {
+		return err
+	}
+	if s.settings.OOMScoreAdjust != nil {
+		if err := priority.SetOOMScoreAdjust(cmd.Process.Pid, *s.settings.OOMScoreAdjust); err != nil {
+			clog.Warningf("cannot adjust the OOM score of the process: %s", err)
+		}
+	}
+	if s.maxMemory > 0 {
+		s.watchdog = startMemoryWatchdog(cmd.Process.Pid, s.maxMemory)
+	}
+	return nil
+}
+
+// done stops watching the process once it has finished, and returns the error of the process
+func (s *processStarter) done(err error) error {
+	if s.watchdog != nil {
+		err = s.watchdog.Stop(err)
+		s.watchdog = nil
+	}
+	return err
+}
@@ -0,0 +1,41 @@
+//go:build linux
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessStarter(t *testing.T) {
+	starter, err := newProcessStarter(config.ProcessSection{})
+	assert.NoError(t, err)
+	assert.Nil(t, starter)
+
+	_, err = newProcessStarter(config.ProcessSection{MaxMemory: "2X"})
+	assert.ErrorContains(t, err, "max-memory")
+
+	score := 800
+	starter, err = newProcessStarter(config.ProcessSection{OOMScoreAdjust: &score, SchedulingClass: "batch"})
+	require.NoError(t, err)
+	require.NotNil(t, starter)
+
+	cmd := exec.Command("sleep", "5")
+	require.NoError(t, starter.start(cmd))
+	defer func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	}()
+
+	content, err := os.ReadFile("/proc/" + strconv.Itoa(cmd.Process.Pid) + "/oom_score_adj")
+	require.NoError(t, err)
+	assert.Equal(t, "800", strings.TrimSpace(string(content)))
+	assert.Nil(t, starter.watchdog)
+}
@@ -0,0 +1,23 @@
+//go:build !windows
+
+package main
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// startDetached configures the command to run in its own session so it survives the end of resticprofile
+func startDetached(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+}
+
+// interruptProcessGroup sends SIGINT to the whole process group led by pid
+func interruptProcessGroup(pid int) error {
+	return syscall.Kill(-pid, syscall.SIGINT)
+}
+
+// killProcessGroup sends SIGKILL to the whole process group led by pid
+func killProcessGroup(pid int) error {
+	return syscall.Kill(-pid, syscall.SIGKILL)
+}
@@ -0,0 +1,146 @@
+package restic
+
+import (
+	"fmt"
+	"sort"
+
+	"golang.org/x/exp/maps"
+)
+
+// renamedOptions lists the flags that were renamed in restic, by command: new name => legacy name
+var renamedOptions = map[string]map[string]string{
+	"init": {
+		"from-repo":             "repo2",
+		"from-repository-file":  "repository-file2",
+		"from-password-file":    "password-file2",
+		"from-password-command": "password-command2",
+		"from-key-hint":         "key-hint2",
+	},
+}
+
+// UnsupportedOptionError is returned when a flag is not supported by a restic command, or not by the requested restic version
+type UnsupportedOptionError struct {
+	Command, Option, Version string
+	FromVersion              string // version where the flag was introduced (set when the requested version is too old)
+	RemovedInVersion         string // version where the flag was removed (set when the requested version is too recent)
+}
+
+func (e *UnsupportedOptionError) Error() string {
+	switch {
+	case e.FromVersion != "":
+		return fmt.Sprintf("flag --%s of command %q requires restic %s or newer (restic version is %s)", e.Option, e.Command, e.FromVersion, e.Version)
+	case e.RemovedInVersion != "":
+		return fmt.Sprintf("flag --%s of command %q was removed in restic %s (restic version is %s)", e.Option, e.Command, e.RemovedInVersion, e.Version)
+	default:
+		return fmt.Sprintf("unknown flag --%s for command %q", e.Option, e.Command)
+	}
+}
+
+// UnsupportedCommandError is returned when a command is not available in the requested restic version
+type UnsupportedCommandError struct {
+	Command, Version              string
+	FromVersion, RemovedInVersion string
+}
+
+func (e *UnsupportedCommandError) Error() string {
+	switch {
+	case e.FromVersion != "":
+		return fmt.Sprintf("command %q requires restic %s or newer (restic version is %s)", e.Command, e.FromVersion, e.Version)
+	case e.RemovedInVersion != "":
+		return fmt.Sprintf("command %q was removed in restic %s (restic version is %s)", e.Command, e.RemovedInVersion, e.Version)
+	default:
+		return fmt.Sprintf("unknown restic command %q", e.Command)
+	}
+}
+
+// CheckCommand returns an UnsupportedCommandError when the command is unknown or not available in the specified restic version
+func CheckCommand(commandName, version string) error {
+	cmd, found := commands[commandName]
+	if !found {
+		return &UnsupportedCommandError{Command: commandName, Version: version}
+	}
+	if version == VersionLatest {
+		version = latestKnownVersion()
+	}
+	if version == AnyVersion {
+		return nil
+	}
+	actual := tryParseVersion(version)
+	if actual == nil || includedInVersion(cmd, false, actual) {
+		return nil
+	}
+	err := &UnsupportedCommandError{Command: commandName, Version: version}
+	if !includedInVersion(cmd, true, actual) {
+		err.FromVersion = cmd.FromVersion
+	} else {
+		err.RemovedInVersion = cmd.RemovedInVersion
+	}
+	return err
+}
+
+// CheckOption returns an UnsupportedOptionError when the flag name (or alias) is not known for the restic command,
+// or when the flag is not available in the specified restic version. Only the existence of the flag is checked for AnyVersion.
+func CheckOption(commandName, name, version string) error {
+	cmd, found := commands[commandName]
+	if !found {
+		return &UnsupportedCommandError{Command: commandName, Version: version}
+	}
+	option, found := cmd.Lookup(name)
+	if !found {
+		return &UnsupportedOptionError{Command: commandName, Option: name, Version: version}
+	}
+	if version == VersionLatest {
+		version = latestKnownVersion()
+	}
+	if version == AnyVersion {
+		return nil
+	}
+	actual := tryParseVersion(version)
+	if actual == nil || includedInVersion(&option, false, actual) {
+		return nil
+	}
+	err := &UnsupportedOptionError{Command: commandName, Option: option.Name, Version: version}
+	if !includedInVersion(&option, true, actual) {
+		err.FromVersion = option.FromVersion
+	} else {
+		err.RemovedInVersion = option.RemovedInVersion
+	}
+	return err
+}
+
+// RenamedOptions returns the current names of the flags that were renamed in the restic command
+func RenamedOptions(commandName string) (names []string) {
+	names = maps.Keys(renamedOptions[commandName])
+	sort.Strings(names)
+	return
+}
+
+// LegacyOptionName returns the name of the flag to use with the restic version when the flag was renamed
+// after this version. The legacy name is returned for AnyVersion (the version of restic is unknown).
+func LegacyOptionName(commandName, name, version string) (legacyName string, found bool) {
+	legacyName, found = renamedOptions[commandName][name]
+	if !found || version == AnyVersion {
+		return
+	}
+	if cmd, exists := GetCommandForVersion(commandName, version, false); exists {
+		if _, available := cmd.Lookup(name); available {
+			return "", false
+		}
+		_, found = cmd.Lookup(legacyName)
+	}
+	if !found {
+		legacyName = ""
+	}
+	return
+}
+
+// KnowsVersion returns true when the flags of the restic version are known: the version is not more recent
+// than the latest version in the commands dataset (patch versions are ignored)
+func KnowsVersion(version string) bool {
+	actual := tryParseVersion(version)
+	latest := tryParseVersion(latestKnownVersion())
+	if actual == nil || latest == nil {
+		return false
+	}
+	return actual.Major() < latest.Major() || (actual.Major() == latest.Major() && actual.Minor() <= latest.Minor())
+}
@@ -0,0 +1,80 @@
+package restic
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckCommand(t *testing.T) {
+	assert.NoError(t, CheckCommand("backup", AnyVersion))
+	assert.NoError(t, CheckCommand("copy", "0.10"))
+	assert.NoError(t, CheckCommand("copy", VersionLatest))
+	assert.EqualError(t, CheckCommand("copy", "0.9"), `command "copy" requires restic 0.10.0 or newer (restic version is 0.9)`)
+	assert.EqualError(t, CheckCommand("unknown", AnyVersion), `unknown restic command "unknown"`)
+}
+
+func TestCheckOption(t *testing.T) {
+	tests := []struct {
+		command, option, version, expected string
+	}{
+		{command: "backup", option: "exclude", version: AnyVersion},
+		{command: "backup", option: "e", version: "0.9"},
+		{command: "backup", option: "repo", version: "0.9"},
+		{command: "backup", option: "read-concurrency", version: "0.15"},
+		{command: "backup", option: "read-concurrency", version: VersionLatest},
+		{command: "backup", option: "read-concurrency", version: "0.14",
+			expected: `flag --read-concurrency of command "backup" requires restic 0.15.0 or newer (restic version is 0.14)`},
+		{command: "init", option: "repo2", version: "0.13"},
+		{command: "init", option: "repo2", version: AnyVersion},
+		{command: "init", option: "repo2", version: "0.14",
+			expected: `flag --repo2 of command "init" was removed in restic 0.14.0 (restic version is 0.14)`},
+		{command: "backup", option: "unknown", version: AnyVersion,
+			expected: `unknown flag --unknown for command "backup"`},
+		{command: "unknown", option: "exclude", version: AnyVersion,
+			expected: `unknown restic command "unknown"`},
+	}
+	for _, test := range tests {
+		t.Run(test.command+"/"+test.option+"@"+test.version, func(t *testing.T) {
+			err := CheckOption(test.command, test.option, test.version)
+			if test.expected == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, test.expected)
+			}
+		})
+	}
+}
+
+func TestLegacyOptionName(t *testing.T) {
+	tests := []struct {
+		command, option, version, legacy string
+		found                            bool
+	}{
+		{command: "init", option: "from-repo", version: AnyVersion, legacy: "repo2", found: true},
+		{command: "init", option: "from-repo", version: "0.13", legacy: "repo2", found: true},
+		{command: "init", option: "from-repository-file", version: "0.13.1", legacy: "repository-file2", found: true},
+		{command: "init", option: "from-repository-file", version: "0.12", found: false}, // not available before 0.13
+		{command: "init", option: "from-repo", version: "0.14"},
+		{command: "init", option: "from-repo", version: VersionLatest},
+		{command: "init", option: "copy-chunker-params", version: "0.13"},
+		{command: "backup", option: "exclude", version: AnyVersion},
+	}
+	for _, test := range tests {
+		t.Run(test.command+"/"+test.option+"@"+test.version, func(t *testing.T) {
+			legacy, found := LegacyOptionName(test.command, test.option, test.version)
+			assert.Equal(t, test.found, found)
+			assert.Equal(t, test.legacy, legacy)
+		})
+	}
+	assert.Contains(t, RenamedOptions("init"), "from-repo")
+	assert.Empty(t, RenamedOptions("backup"))
+}
+
+func TestKnowsVersion(t *testing.T) {
+	assert.True(t, KnowsVersion("0.9"))
+	assert.True(t, KnowsVersion(latestKnownVersion()))
+	assert.False(t, KnowsVersion("99.0"))
+	assert.False(t, KnowsVersion(AnyVersion))
+	assert.False(t, KnowsVersion("invalid"))
+}
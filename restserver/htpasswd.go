@@ -0,0 +1,96 @@
+package restserver
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// User is an account of the rest-server
+type User struct {
+	Username string
+	Password string
+}
+
+// Repository is a REST repository of a profile
+type Repository struct {
+	Username string
+	Password string
+	Path     string
+}
+
+// ParseRepository returns the credentials and the path of a "rest:" repository.
+// It returns false when the repository is not served by a rest-server.
+func ParseRepository(repository string) (Repository, bool) {
+	if !strings.HasPrefix(repository, "rest:") {
+		return Repository{}, false
+	}
+	location, err := url.Parse(strings.TrimPrefix(repository, "rest:"))
+	if err != nil || location.Host == "" {
+		return Repository{}, false
+	}
+	result := Repository{Path: location.Path}
+	if location.User != nil {
+		result.Username = location.User.Username()
+		result.Password, _ = location.User.Password()
+	}
+	return result, true
+}
+
+// HashUser returns the htpasswd line of the user, with a bcrypt hash of the password
+func HashUser(user User) (string, error) {
+	if user.Username == "" || strings.ContainsAny(user.Username, ":\r\n") {
+		return "", fmt.Errorf("invalid username %q", user.Username)
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("cannot hash the password of %q: %w", user.Username, err)
+	}
+	return user.Username + ":" + string(hash), nil
+}
+
+// MergeHtpasswd adds the users to the content of an htpasswd file. The line of a user already in the file
+// is kept when the password didn't change, and replaced otherwise. Other lines are left untouched.
+func MergeHtpasswd(content []byte, users []User) ([]byte, error) {
+	pending := make(map[string]User, len(users))
+	for _, user := range users {
+		pending[user.Username] = user
+	}
+	output := &bytes.Buffer{}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		username, hash, found := strings.Cut(line, ":")
+		user, update := pending[username]
+		if found && update {
+			delete(pending, username)
+			if bcrypt.CompareHashAndPassword([]byte(hash), []byte(user.Password)) != nil {
+				var err error
+				if line, err = HashUser(user); err != nil {
+					return nil, err
+				}
+			}
+		}
+		output.WriteString(line + "\n")
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	// new users are added in order
+	for _, user := range users {
+		if _, found := pending[user.Username]; !found {
+			continue
+		}
+		delete(pending, user.Username)
+		line, err := HashUser(user)
+		if err != nil {
+			return nil, err
+		}
+		output.WriteString(line + "\n")
+	}
+	return output.Bytes(), nil
+}
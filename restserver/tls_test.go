@@ -0,0 +1,33 @@
+package restserver
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateCertificate(t *testing.T) {
+	certificate, key, err := GenerateCertificate([]string{"backup.lan", "192.168.1.10"}, 24*time.Hour)
+	require.NoError(t, err)
+
+	_, err = tls.X509KeyPair(certificate, key)
+	require.NoError(t, err)
+
+	block, _ := pem.Decode(certificate)
+	require.NotNil(t, block)
+	parsed, err := x509.ParseCertificate(block.Bytes)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"backup.lan"}, parsed.DNSNames)
+	assert.True(t, parsed.IPAddresses[0].Equal(net.ParseIP("192.168.1.10")))
+	assert.NoError(t, parsed.VerifyHostname("backup.lan"))
+	assert.WithinDuration(t, time.Now().Add(24*time.Hour), parsed.NotAfter, time.Minute)
+
+	_, _, err = GenerateCertificate(nil, time.Hour)
+	assert.Error(t, err)
+}
@@ -0,0 +1,84 @@
+package restserver
+
+import (
+	"io"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// UnitOptions are the settings of the rest-server systemd service
+type UnitOptions struct {
+	Binary       string
+	Path         string
+	Listen       string
+	User         string
+	Htpasswd     string
+	TLSDir       string
+	AppendOnly   bool
+	PrivateRepos bool
+}
+
+var unitTemplate = template.Must(template.New("unit").Parse(`[Unit]
+Description=rest-server for restic repositories (generated by resticprofile)
+After=network-online.target
+Wants=network-online.target
+
+[Service]
+Type=simple
+{{- if .User }}
+User={{ .User }}
+Group={{ .User }}
+{{- end }}
+ExecStart={{ .ExecStart }}
+Restart=on-failure
+RestartSec=5s
+NoNewPrivileges=true
+PrivateTmp=true
+ProtectSystem=strict
+ProtectHome=read-only
+ReadWritePaths={{ .Path }}
+
+[Install]
+WantedBy=multi-user.target
+`))
+
+// Arguments returns the command line arguments of rest-server
+func (o UnitOptions) Arguments() []string {
+	args := []string{"--path", o.Path}
+	if o.Listen != "" {
+		args = append(args, "--listen", o.Listen)
+	}
+	if o.Htpasswd != "" {
+		args = append(args, "--htpasswd-file", o.Htpasswd)
+	}
+	if o.TLSDir != "" {
+		args = append(args,
+			"--tls",
+			"--tls-cert", filepath.Join(o.TLSDir, CertificateFile),
+			"--tls-key", filepath.Join(o.TLSDir, PrivateKeyFile),
+		)
+	}
+	if o.AppendOnly {
+		args = append(args, "--append-only")
+	}
+	if o.PrivateRepos {
+		args = append(args, "--private-repos")
+	}
+	return args
+}
+
+// WriteUnit writes the systemd service running rest-server
+func WriteUnit(output io.Writer, options UnitOptions) error {
+	args := options.Arguments()
+	for index, arg := range args {
+		if strings.ContainsAny(arg, " \t\"") {
+			args[index] = `"` + strings.ReplaceAll(arg, `"`, `\"`) + `"`
+		}
+	}
+	return unitTemplate.Execute(output, map[string]string{
+		"User":      options.User,
+		"Path":      options.Path,
+		"ExecStart": strings.Join(append([]string{options.Binary}, args...), " "),
+	})
+}
@@ -0,0 +1,37 @@
+package restserver
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteUnit(t *testing.T) {
+	output := &bytes.Buffer{}
+	err := WriteUnit(output, UnitOptions{
+		Binary:       "/usr/bin/rest-server",
+		Path:         "/srv/restic repos",
+		Listen:       ":8000",
+		User:         "restic",
+		TLSDir:       "/etc/rest-server",
+		PrivateRepos: true,
+	})
+	require.NoError(t, err)
+
+	unit := output.String()
+	assert.Contains(t, unit, "User=restic\nGroup=restic\n")
+	assert.Contains(t, unit, `ExecStart=/usr/bin/rest-server --path "/srv/restic repos" --listen :8000 --tls --tls-cert `+
+		filepath.Join("/etc/rest-server", CertificateFile)+" --tls-key "+filepath.Join("/etc/rest-server", PrivateKeyFile)+" --private-repos\n")
+	assert.Contains(t, unit, "ReadWritePaths=/srv/restic repos\n")
+	assert.Contains(t, unit, "WantedBy=multi-user.target\n")
+}
+
+func TestUnitWithoutUser(t *testing.T) {
+	output := &bytes.Buffer{}
+	require.NoError(t, WriteUnit(output, UnitOptions{Binary: "rest-server", Path: "/srv", AppendOnly: true, Htpasswd: "/etc/htpasswd"}))
+	assert.NotContains(t, output.String(), "User=")
+	assert.Contains(t, output.String(), "ExecStart=rest-server --path /srv --htpasswd-file /etc/htpasswd --append-only\n")
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/constants"
+	"github.com/creativeprojects/resticprofile/term"
+	"github.com/spf13/pflag"
+	"golang.org/x/exp/slices"
+)
+
+// profilesRun runs a list of profiles (a group or the profiles selected by labels):
+// one after the other, or all at the same time with --parallel
+type profilesRun struct {
+	config          *config.Config
+	global          *config.Global
+	flags           commandLineFlags
+	flagset         *pflag.FlagSet
+	resticBinary    string
+	resticArguments []string
+	resticCommand   string
+}
+
+// run runs the profiles, displays the summary and returns the exit code of the first profile that failed
+func (r profilesRun) run(name string, profiles []string, continueOnError bool) (exitCode int) {
+	summary := newGroupSummary(name, r.resticCommand, profiles)
+	if r.flags.parallel {
+		exitCode = r.runParallel(summary, profiles)
+	} else {
+		exitCode = r.runSequential(summary, name, profiles, continueOnError)
+	}
+	summary.done()
+	displayGroupSummary(summary, r.flags)
+	return
+}
+
+func (r profilesRun) runSequential(summary *groupSummary, name string, profiles []string, continueOnError bool) int {
+	for i, profileName := range profiles {
+		clog.Debugf("[%d/%d] starting profile '%s' from group '%s'", i+1, len(profiles), profileName, name)
+		result := summary.start(i)
+		err := runProfile(r.config, r.global, r.flags, profileName, r.resticBinary, r.resticArguments, r.resticCommand, name, result)
+		result.done(err)
+		if err != nil {
+			clog.Error(err)
+			if continueOnError {
+				// keep going to the next profile
+				continue
+			}
+			return getExitCode(err)
+		}
+	}
+	// exit code of the first profile that failed when the group continued after the failure
+	return firstExitCode(summary)
+}
+
+// runParallel runs each profile in a new resticprofile process, all at the same time
+func (r profilesRun) runParallel(summary *groupSummary, profiles []string) int {
+	binary, err := os.Executable()
+	if err != nil {
+		clog.Errorf("cannot run the profiles in parallel: %s", err)
+		return constants.ExitCodeError
+	}
+	wg := sync.WaitGroup{}
+	for i, profileName := range profiles {
+		args := r.childArgs(profileName)
+		clog.Debugf("starting profile '%s': %s %s", profileName, binary, strings.Join(args, " "))
+		result := summary.start(i)
+		cmd := exec.Command(binary, args...)
+		cmd.Stdout = term.GetOutput()
+		cmd.Stderr = term.GetErrorOutput()
+		if err = cmd.Start(); err != nil {
+			result.done(fmt.Errorf("cannot start profile '%s': %w", profileName, err))
+			continue
+		}
+		wg.Add(1)
+		go func(profileName string, result *groupProfileResult) {
+			defer wg.Done()
+			err := cmd.Wait()
+			if exitErr, ok := asExitError(err); ok {
+				err = newExitCodeError(exitErr.ExitCode(), fmt.Errorf("profile '%s' failed: %w", profileName, err))
+			}
+			result.done(err)
+		}(profileName, result)
+	}
+	wg.Wait()
+	return firstExitCode(summary)
+}
+
+// childArgs returns the command line running the profile in a new process, with the same flags
+func (r profilesRun) childArgs(profileName string) []string {
+	excluded := []string{"name", "profiles", "parallel", "continue-on-error", "json", "wait"}
+	args := make([]string, 0, 10)
+	if r.flagset != nil {
+		r.flagset.Visit(func(flag *pflag.Flag) {
+			if !slices.Contains(excluded, flag.Name) {
+				args = append(args, fmt.Sprintf("--%s=%s", flag.Name, flag.Value.String()))
+			}
+		})
+	}
+	args = append(args, "--name", profileName, r.resticCommand)
+	return append(args, r.resticArguments...)
+}
+
+// firstExitCode returns the exit code of the first profile that failed
+func firstExitCode(summary *groupSummary) int {
+	for _, result := range summary.Profiles {
+		if result.ExitCode != 0 {
+			return result.ExitCode
+		}
+	}
+	return constants.ExitCodeSuccess
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChildArgs(t *testing.T) {
+	flagset, flags, err := loadFlags([]string{"-c", "profiles.yaml", "--profiles", "env=prod", "--parallel", "--continue-on-error", "--json", "-q", "--lock-wait", "1h", "backup", "--tag", "daily"})
+	require.NoError(t, err)
+	assert.Equal(t, "env=prod", flags.profiles)
+	assert.True(t, flags.parallel)
+	assert.True(t, flags.continueOn)
+
+	run := profilesRun{flags: flags, flagset: flagset, resticCommand: "backup", resticArguments: []string{"--tag", "daily"}}
+	assert.Equal(t, []string{
+		"--config=profiles.yaml", "--lock-wait=1h0m0s", "--quiet=true", "--name", "first", "backup", "--tag", "daily",
+	}, run.childArgs("first"))
+
+	_, childFlags, err := loadFlags(run.childArgs("first"))
+	require.NoError(t, err)
+	assert.Equal(t, "profiles.yaml", childFlags.config)
+	assert.Equal(t, "first", childFlags.name)
+	assert.True(t, childFlags.quiet)
+	assert.Equal(t, []string{"backup", "--tag", "daily"}, childFlags.resticArgs)
+}
+
+func TestFirstExitCode(t *testing.T) {
+	summary := newGroupSummary("group", "backup", []string{"first", "second", "third"})
+	assert.Equal(t, 0, firstExitCode(summary))
+	summary.Profiles[1].ExitCode = 6
+	summary.Profiles[2].ExitCode = 1
+	assert.Equal(t, 6, firstExitCode(summary))
+}
+
+func TestRunSequentialContinueOnError(t *testing.T) {
+	content := `
+version = "1"
+
+[first]
+run-before = "exit 1"
+
+[second]
+
+[third]
+run-before = "exit 1"
+`
+	cfg, err := config.Load(bytes.NewBufferString(content), config.FormatTOML)
+	require.NoError(t, err)
+	global := config.NewGlobal()
+	profiles := []string{"first", "second", "third"}
+
+	run := profilesRun{config: cfg, global: global, flags: commandLineFlags{noLock: true}, resticBinary: "echo", resticCommand: "snapshots"}
+
+	summary := newGroupSummary("group", "snapshots", profiles)
+	exitCode := run.runSequential(summary, "group", profiles, false)
+	summary.done()
+	assert.Equal(t, constants.ExitCodeHook, exitCode)
+	assert.Equal(t, "group 'group': 0 profile(s) succeeded, 1 failed (first), 2 skipped", summary.String())
+
+	summary = newGroupSummary("group", "snapshots", profiles)
+	exitCode = run.runSequential(summary, "group", profiles, true)
+	summary.done()
+	assert.Equal(t, constants.ExitCodeHook, exitCode)
+	assert.Equal(t, "group 'group': 1 profile(s) succeeded, 2 failed (first, third)", summary.String())
+}
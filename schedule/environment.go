@@ -0,0 +1,71 @@
+package schedule
+
+import (
+	"os"
+	"sort"
+
+	"github.com/creativeprojects/resticprofile/constants"
+)
+
+const (
+	// systemdDefaultPath is the PATH set by systemd for the services it starts
+	systemdDefaultPath = "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin"
+	// crondDefaultPath is the PATH set by most cron daemons
+	crondDefaultPath = "/usr/bin:/bin"
+)
+
+// SchedulerType returns the type of scheduler used by the configuration, resolving the OS default
+func SchedulerType(scheduler SchedulerConfig) string {
+	if scheduler.Type() != "" {
+		return scheduler.Type()
+	}
+	return defaultSchedulerName
+}
+
+// JobEnvironment returns (an approximation of) the environment variables the scheduler
+// starts a job with. The environment variables of the profile are not included: they are
+// set by resticprofile when running the profile.
+func JobEnvironment(scheduler SchedulerConfig) []string {
+	env := make(map[string]string)
+	copyEnv := func(names ...string) {
+		for _, name := range names {
+			if value, found := os.LookupEnv(name); found {
+				env[name] = value
+			}
+		}
+	}
+	home, _ := os.UserHomeDir()
+
+	switch SchedulerType(scheduler) {
+	case constants.SchedulerWindows:
+		// task scheduler starts the job with the environment of the user
+		return os.Environ()
+
+	case constants.SchedulerLaunchd:
+		// PATH is saved in the plist file, launchd sets the user variables
+		copyEnv("PATH", "USER", "LOGNAME", "SHELL", "TMPDIR")
+		env["HOME"] = home
+
+	case constants.SchedulerCrond, constants.SchedulerCrontab:
+		copyEnv("LOGNAME")
+		env["HOME"] = home
+		env["SHELL"] = "/bin/sh"
+		env["PATH"] = crondDefaultPath
+
+	default:
+		// HOME and SUDO_USER are saved in the unit file
+		copyEnv("SUDO_USER")
+		env["HOME"] = home
+		env["PATH"] = systemdDefaultPath
+	}
+
+	result := make([]string, 0, len(env))
+	for name, value := range env {
+		if name == "HOME" && value == "" {
+			continue
+		}
+		result = append(result, name+"="+value)
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,60 @@
+package schedule
+
+import (
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/creativeprojects/resticprofile/constants"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchedulerType(t *testing.T) {
+	assert.Equal(t, constants.SchedulerCrond, SchedulerType(SchedulerCrond{}))
+	assert.Equal(t, constants.SchedulerCrontab, SchedulerType(SchedulerCrontab{}))
+	assert.Equal(t, constants.SchedulerSystemd, SchedulerType(SchedulerSystemd{}))
+
+	expected := constants.SchedulerSystemd
+	if runtime.GOOS == "darwin" {
+		expected = constants.SchedulerLaunchd
+	} else if runtime.GOOS == "windows" {
+		expected = constants.SchedulerWindows
+	}
+	assert.Equal(t, expected, SchedulerType(SchedulerDefaultOS{}))
+}
+
+func TestJobEnvironment(t *testing.T) {
+	t.Setenv("RESTICPROFILE_TEST_VARIABLE", "value")
+
+	lookup := func(env []string, name string) (string, bool) {
+		for _, entry := range env {
+			if strings.HasPrefix(entry, name+"=") {
+				return entry[len(name)+1:], true
+			}
+		}
+		return "", false
+	}
+
+	env := JobEnvironment(SchedulerCrond{})
+	path, _ := lookup(env, "PATH")
+	assert.Equal(t, crondDefaultPath, path)
+	_, found := lookup(env, "RESTICPROFILE_TEST_VARIABLE")
+	assert.False(t, found)
+
+	env = JobEnvironment(SchedulerCrontab{})
+	path, _ = lookup(env, "PATH")
+	assert.Equal(t, crondDefaultPath, path)
+
+	env = JobEnvironment(SchedulerSystemd{})
+	path, _ = lookup(env, "PATH")
+	assert.Equal(t, systemdDefaultPath, path)
+	if home, err := os.UserHomeDir(); err == nil {
+		value, _ := lookup(env, "HOME")
+		assert.Equal(t, home, value)
+	}
+
+	env = JobEnvironment(SchedulerWindows{})
+	value, _ := lookup(env, "RESTICPROFILE_TEST_VARIABLE")
+	assert.Equal(t, "value", value)
+}
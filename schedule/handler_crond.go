@@ -3,10 +3,15 @@
 package schedule
 
 import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
 	"strings"
 
 	"github.com/creativeprojects/resticprofile/calendar"
 	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/constants"
 	"github.com/creativeprojects/resticprofile/crond"
 )
 
@@ -28,7 +33,56 @@ func NewHandlerCrond(config SchedulerConfig) *HandlerCrond {
 
 // Init verifies crond is available on this system
 func (h *HandlerCrond) Init() error {
-	return lookupBinary("crond", crontabBinary)
+	if file := h.settings().CrontabFile; file != "" {
+		if info, err := os.Stat(filepath.Dir(file)); err != nil || !info.IsDir() {
+			return fmt.Errorf("cannot write crontab file %q: directory %q not found", file, filepath.Dir(file))
+		}
+		return nil
+	}
+	return lookupBinary("crond", h.crontabBinary())
+}
+
+// settings returns the crond settings of the scheduler configuration
+func (h *HandlerCrond) settings() SchedulerCrond {
+	switch cfg := h.config.(type) {
+	case SchedulerCrond:
+		return cfg
+	case SchedulerCrontab:
+		return cfg.SchedulerCrond
+	}
+	return SchedulerCrond{}
+}
+
+// crontabBinary returns the crontab command set in the scheduler parameters, or the default one
+func (h *HandlerCrond) crontabBinary() string {
+	if binary := h.settings().CrontabBinary; binary != "" {
+		return binary
+	}
+	return crontabBinary
+}
+
+// newCrontab returns the crontab of the user, or the crontab file set in the scheduler parameters
+func (h *HandlerCrond) newCrontab(entries []crond.Entry) *crond.Crontab {
+	return crond.NewCrontab(entries).SetBinary(h.crontabBinary()).SetFile(h.settings().CrontabFile)
+}
+
+// crontabUser returns the user running the job in a crontab file (the user crontab has no user field)
+func (h *HandlerCrond) crontabUser(permission string) (string, error) {
+	if h.settings().CrontabFile == "" {
+		return "", nil
+	}
+	if permission == constants.SchedulePermissionSystem {
+		return "root", nil
+	}
+	// the crontab file is written as root: the job belongs to the user behind sudo
+	if sudoUser := os.Getenv("SUDO_USER"); sudoUser != "" {
+		return sudoUser, nil
+	}
+	current, err := user.Current()
+	if err != nil {
+		return "", fmt.Errorf("cannot find the user of the job: %w", err)
+	}
+	return current.Username, nil
 }
 
 // Close does nothing with crond
@@ -54,6 +108,13 @@ func (h *HandlerCrond) DisplayStatus(profileName string) error {
 
 // CreateJob is creating the crontab
 func (h *HandlerCrond) CreateJob(job *config.ScheduleConfig, schedules []*calendar.Event, permission string) error {
+	if err := checkLocalTimezone(job, "crond"); err != nil {
+		return err
+	}
+	jobUser, err := h.crontabUser(permission)
+	if err != nil {
+		return err
+	}
 	entries := make([]crond.Entry, len(schedules))
 	for i, event := range schedules {
 		entries[i] = crond.NewEntry(
@@ -63,10 +124,10 @@ func (h *HandlerCrond) CreateJob(job *config.ScheduleConfig, schedules []*calend
 			job.SubTitle,
 			job.Command+" "+strings.Join(job.Arguments, " "),
 			job.WorkingDirectory,
-		)
+		).WithUser(jobUser)
 	}
-	crontab := crond.NewCrontab(entries)
-	err := crontab.Rewrite()
+	crontab := h.newCrontab(entries)
+	err = crontab.Rewrite()
 	if err != nil {
 		return err
 	}
@@ -84,7 +145,7 @@ func (h *HandlerCrond) RemoveJob(job *config.ScheduleConfig, permission string)
 			job.WorkingDirectory,
 		),
 	}
-	crontab := crond.NewCrontab(entries)
+	crontab := h.newCrontab(entries)
 	num, err := crontab.Remove()
 	if err != nil {
 		return err
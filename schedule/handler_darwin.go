@@ -67,12 +67,22 @@ var priorityValues = map[string]string{
 	constants.SchedulePriorityStandard:   "Standard",
 }
 
+// defaultSchedulerName is launchd, the only scheduler available on macOS
+const defaultSchedulerName = constants.SchedulerLaunchd
+
+func init() {
+	RegisterScheduler(constants.SchedulerLaunchd, SchedulerFuncs{
+		Config:  func(*config.Global, string) SchedulerConfig { return SchedulerLaunchd{} },
+		Handler: func(config SchedulerConfig) Handler { return NewHandlerLaunchd(config) },
+	})
+}
+
 type HandlerLaunchd struct {
 	config SchedulerConfig
 	fs     afero.Fs
 }
 
-func NewHandler(config SchedulerConfig) *HandlerLaunchd {
+func NewHandlerLaunchd(config SchedulerConfig) *HandlerLaunchd {
 	return &HandlerLaunchd{
 		config: config,
 		fs:     afero.NewOsFs(),
@@ -106,6 +116,9 @@ func (h *HandlerLaunchd) DisplayStatus(profileName string) error {
 
 // CreateJob creates a plist file and registers it with launchd
 func (h *HandlerLaunchd) CreateJob(job *config.ScheduleConfig, schedules []*calendar.Event, permission string) error {
+	if err := checkLocalTimezone(job, "launchd"); err != nil {
+		return err
+	}
 	filename, err := h.createPlistFile(h.getLaunchdJob(job, schedules), permission)
 	if err != nil {
 		if filename != "" {
@@ -124,7 +124,7 @@ func TestHandlerInstanceDefault(t *testing.T) {
 }
 
 func TestHandlerInstanceLaunchd(t *testing.T) {
-	handler := NewHandler(SchedulerLaunchd{})
+	handler := NewHandlerLaunchd(SchedulerLaunchd{})
 	assert.NotNil(t, handler)
 }
 
@@ -143,7 +143,7 @@ func TestLaunchdJobLog(t *testing.T) {
 
 	for _, fixture := range fixtures {
 		t.Run(fixture.log, func(t *testing.T) {
-			handler := NewHandler(SchedulerLaunchd{})
+			handler := NewHandlerLaunchd(SchedulerLaunchd{})
 			args := []string{"--log", fixture.log}
 			cfg := &config.ScheduleConfig{
 				Title:     "profile",
@@ -163,7 +163,7 @@ func TestLaunchdJobLog(t *testing.T) {
 }
 
 func TestCreateUserPlist(t *testing.T) {
-	handler := NewHandler(SchedulerLaunchd{})
+	handler := NewHandlerLaunchd(SchedulerLaunchd{})
 	handler.fs = afero.NewMemMapFs()
 
 	launchdJob := &LaunchdJob{
@@ -177,7 +177,7 @@ func TestCreateUserPlist(t *testing.T) {
 }
 
 func TestCreateSystemPlist(t *testing.T) {
-	handler := NewHandler(SchedulerLaunchd{})
+	handler := NewHandlerLaunchd(SchedulerLaunchd{})
 	handler.fs = afero.NewMemMapFs()
 
 	launchdJob := &LaunchdJob{
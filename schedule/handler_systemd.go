@@ -110,7 +110,7 @@ func (h *HandlerSystemd) CreateJob(job *config.ScheduleConfig, schedules []*cale
 		SubTitle:         job.SubTitle,
 		JobDescription:   job.JobDescription,
 		TimerDescription: job.TimerDescription,
-		Schedules:        job.Schedules,
+		Schedules:        job.GetSchedulesWithTimezone(),
 		UnitType:         unitType,
 		Priority:         job.GetPriority(),
 		UnitFile:         h.config.UnitTemplate,
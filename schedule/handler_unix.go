@@ -2,12 +2,34 @@
 
 package schedule
 
-import "github.com/creativeprojects/resticprofile/constants"
-
-// NewHandler creates a crond or systemd handler depending on the configuration
-func NewHandler(config SchedulerConfig) Handler {
-	if config.Type() == constants.SchedulerCrond {
-		return NewHandlerCrond(config)
-	}
-	return NewHandlerSystemd(config)
+import (
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/constants"
+)
+
+// defaultSchedulerName is systemd, crond can be selected in the configuration
+const defaultSchedulerName = constants.SchedulerSystemd
+
+func init() {
+	RegisterScheduler(constants.SchedulerSystemd, SchedulerFuncs{
+		Config: func(global *config.Global, _ string) SchedulerConfig {
+			return SchedulerSystemd{
+				UnitTemplate:  global.SystemdUnitTemplate,
+				TimerTemplate: global.SystemdTimerTemplate,
+			}
+		},
+		Handler: func(config SchedulerConfig) Handler { return NewHandlerSystemd(config) },
+	})
+	RegisterScheduler(constants.SchedulerCrond, SchedulerFuncs{
+		Config: func(_ *config.Global, params string) SchedulerConfig {
+			return SchedulerCrond{CrontabBinary: params}
+		},
+		Handler: func(config SchedulerConfig) Handler { return NewHandlerCrond(config) },
+	})
+	RegisterScheduler(constants.SchedulerCrontab, SchedulerFuncs{
+		Config: func(_ *config.Global, params string) SchedulerConfig {
+			return SchedulerCrontab{SchedulerCrond{CrontabFile: params}}
+		},
+		Handler: func(config SchedulerConfig) Handler { return NewHandlerCrond(config) },
+	})
 }
@@ -3,9 +3,14 @@
 package schedule
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/constants"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestHandlerCrond(t *testing.T) {
@@ -13,6 +18,47 @@ func TestHandlerCrond(t *testing.T) {
 	assert.IsType(t, &HandlerCrond{}, handler)
 }
 
+func TestHandlerCrontab(t *testing.T) {
+	cfg := NewSchedulerConfig(&config.Global{Scheduler: "crontab:/etc/cron.d/resticprofile"})
+	require.IsType(t, SchedulerCrontab{}, cfg)
+	assert.Equal(t, "/etc/cron.d/resticprofile", cfg.(SchedulerCrontab).CrontabFile)
+
+	handler := NewHandler(cfg)
+	require.IsType(t, &HandlerCrond{}, handler)
+	assert.Equal(t, "/etc/cron.d/resticprofile", handler.(*HandlerCrond).settings().CrontabFile)
+	assert.Equal(t, crontabBinary, handler.(*HandlerCrond).crontabBinary())
+}
+
+func TestHandlerCrontabFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "resticprofile")
+	handler := NewHandler(SchedulerCrontab{SchedulerCrond{CrontabFile: file}})
+	require.NoError(t, handler.Init())
+
+	job := &config.ScheduleConfig{
+		Title:      "profile",
+		SubTitle:   "backup",
+		ConfigFile: "config.yaml",
+		Command:    "/opt/resticprofile",
+		Arguments:  []string{"--no-ansi", "--config", "config.yaml", "--name", "profile", "backup"},
+	}
+	schedules, err := handler.ParseSchedules([]string{"*-*-* 03:15"})
+	require.NoError(t, err)
+
+	require.NoError(t, handler.CreateJob(job, schedules, constants.SchedulePermissionSystem))
+	content, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "15 03 * * *\troot\t/opt/resticprofile --no-ansi --config config.yaml --name profile backup\n")
+
+	require.NoError(t, handler.RemoveJob(job, constants.SchedulePermissionSystem))
+	content, err = os.ReadFile(file)
+	require.NoError(t, err)
+	assert.NotContains(t, string(content), "/opt/resticprofile")
+	assert.ErrorIs(t, handler.RemoveJob(job, constants.SchedulePermissionSystem), ErrorServiceNotFound)
+
+	handler = NewHandler(SchedulerCrontab{SchedulerCrond{CrontabFile: filepath.Join(file, "missing", "resticprofile")}})
+	assert.Error(t, handler.Init())
+}
+
 func TestHandlerSystemd(t *testing.T) {
 	handler := NewHandler(SchedulerSystemd{})
 	assert.IsType(t, &HandlerSystemd{}, handler)
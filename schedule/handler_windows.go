@@ -11,13 +11,23 @@ import (
 	"github.com/creativeprojects/resticprofile/schtasks"
 )
 
+// defaultSchedulerName is the task scheduler, the only scheduler available on Windows
+const defaultSchedulerName = constants.SchedulerWindows
+
+func init() {
+	RegisterScheduler(constants.SchedulerWindows, SchedulerFuncs{
+		Config:  func(*config.Global, string) SchedulerConfig { return SchedulerWindows{} },
+		Handler: func(config SchedulerConfig) Handler { return NewHandlerWindows(config) },
+	})
+}
+
 // HandlerWindows is using windows task manager
 type HandlerWindows struct {
 	config SchedulerConfig
 }
 
-// NewHandler creates a new handler for windows task manager
-func NewHandler(config SchedulerConfig) *HandlerWindows {
+// NewHandlerWindows creates a new handler for windows task manager
+func NewHandlerWindows(config SchedulerConfig) *HandlerWindows {
 	return &HandlerWindows{
 		config: config,
 	}
@@ -55,6 +65,9 @@ func (h *HandlerWindows) DisplayStatus(profileName string) error {
 
 // CreateJob is creating the task scheduler job.
 func (h *HandlerWindows) CreateJob(job *config.ScheduleConfig, schedules []*calendar.Event, permission string) error {
+	if err := checkLocalTimezone(job, "Task Scheduler"); err != nil {
+		return err
+	}
 	// default permission will be system
 	perm := schtasks.SystemAccount
 	if permission == constants.SchedulePermissionUser {
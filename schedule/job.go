@@ -3,6 +3,7 @@ package schedule
 import (
 	"errors"
 
+	"github.com/creativeprojects/resticprofile/calendar"
 	"github.com/creativeprojects/resticprofile/config"
 )
 
@@ -45,20 +46,11 @@ func (j *Job) Create() error {
 		return permissionError("create")
 	}
 
-	schedules, err := j.handler.ParseSchedules(j.config.Schedules)
+	schedules, err := j.parseSchedules()
 	if err != nil {
 		return err
 	}
 
-	if len(schedules) > 0 {
-		j.handler.DisplayParsedSchedules(j.config.SubTitle, schedules)
-	} else {
-		err := j.handler.DisplaySchedules(j.config.SubTitle, j.config.Schedules)
-		if err != nil {
-			return err
-		}
-	}
-
 	err = j.handler.CreateJob(j.config, schedules, permission)
 	if err != nil {
 		return err
@@ -94,25 +86,38 @@ func (j *Job) Status() error {
 		return ErrorJobCanBeRemovedOnly
 	}
 
-	schedules, err := j.handler.ParseSchedules(j.config.Schedules)
+	_, err := j.parseSchedules()
+	if err != nil {
+		return err
+	}
+
+	err = j.handler.DisplayJobStatus(j.config)
 	if err != nil {
 		return err
 	}
+	return nil
+}
+
+// parseSchedules parses and displays the schedules of the job
+func (j *Job) parseSchedules() ([]*calendar.Event, error) {
+	if _, err := j.config.GetTimezone(); err != nil {
+		return nil, err
+	}
+
+	schedules, err := j.handler.ParseSchedules(j.config.Schedules)
+	if err != nil {
+		return nil, err
+	}
 
 	if len(schedules) > 0 {
 		j.handler.DisplayParsedSchedules(j.config.SubTitle, schedules)
 	} else {
-		err := j.handler.DisplaySchedules(j.config.SubTitle, j.config.Schedules)
+		err := j.handler.DisplaySchedules(j.config.SubTitle, j.config.GetSchedulesWithTimezone())
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
-
-	err = j.handler.DisplayJobStatus(j.config)
-	if err != nil {
-		return err
-	}
-	return nil
+	return schedules, nil
 }
 
 // Verify interface
@@ -0,0 +1,97 @@
+package schedule
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/config"
+	"golang.org/x/exp/maps"
+)
+
+// SchedulerFactory creates the configuration and the handler of a scheduler.
+//
+// Schedulers are registered at build time (from an init function) with RegisterScheduler,
+// and selected with the "scheduler" option of the global section: "name" or "name:parameters"
+type SchedulerFactory interface {
+	// NewConfig creates the scheduler configuration from the global section and the parameters following the scheduler name
+	NewConfig(global *config.Global, params string) SchedulerConfig
+	// NewHandler creates the handler scheduling the jobs with the configuration
+	NewHandler(config SchedulerConfig) Handler
+}
+
+// SchedulerFuncs is a SchedulerFactory made of two functions
+type SchedulerFuncs struct {
+	Config  func(global *config.Global, params string) SchedulerConfig
+	Handler func(config SchedulerConfig) Handler
+}
+
+func (f SchedulerFuncs) NewConfig(global *config.Global, params string) SchedulerConfig {
+	return f.Config(global, params)
+}
+
+func (f SchedulerFuncs) NewHandler(config SchedulerConfig) Handler {
+	return f.Handler(config)
+}
+
+var schedulers = make(map[string]SchedulerFactory)
+
+// RegisterScheduler makes a scheduler available under its name. The name must be the type of the configurations created
+// by the factory. It panics when the name is already registered.
+func RegisterScheduler(name string, factory SchedulerFactory) {
+	if name == "" || strings.Contains(name, ":") {
+		panic(fmt.Errorf("invalid scheduler name %q", name))
+	}
+	if _, found := schedulers[name]; found {
+		panic(fmt.Errorf("scheduler %q is already registered", name))
+	}
+	schedulers[name] = factory
+}
+
+// RegisteredSchedulers returns the sorted names of the schedulers available in this build
+func RegisteredSchedulers() []string {
+	names := maps.Keys(schedulers)
+	sort.Strings(names)
+	return names
+}
+
+// DefaultScheduler returns the name of the scheduler used when none is configured
+func DefaultScheduler() string {
+	return defaultSchedulerName
+}
+
+// ParseScheduler splits the "scheduler" option of the global section into the name and the parameters of the scheduler
+func ParseScheduler(scheduler string) (name, params string) {
+	name, params, _ = strings.Cut(scheduler, ":")
+	return strings.TrimSpace(name), strings.TrimSpace(params)
+}
+
+// lookupScheduler returns the factory registered with the name, or the factory of the default scheduler
+func lookupScheduler(name string) (string, SchedulerFactory) {
+	if name == "" {
+		name = defaultSchedulerName
+	}
+	if factory, found := schedulers[name]; found {
+		return name, factory
+	}
+	clog.Warningf("scheduler %q is not available on this system (available: %s): using %s instead",
+		name, strings.Join(RegisteredSchedulers(), ", "), defaultSchedulerName)
+	return defaultSchedulerName, schedulers[defaultSchedulerName]
+}
+
+// NewSchedulerConfig creates the configuration of the scheduler selected in the global section
+func NewSchedulerConfig(global *config.Global) SchedulerConfig {
+	name, params := ParseScheduler(global.Scheduler)
+	resolved, factory := lookupScheduler(name)
+	if name != "" && resolved != name {
+		params = "" // parameters were meant for another scheduler
+	}
+	return factory.NewConfig(global, params)
+}
+
+// NewHandler creates the handler of the scheduler configuration
+func NewHandler(config SchedulerConfig) Handler {
+	_, factory := lookupScheduler(config.Type())
+	return factory.NewHandler(config)
+}
@@ -0,0 +1,69 @@
+package schedule
+
+import (
+	"testing"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testSchedulerConfig struct {
+	params string
+}
+
+func (c testSchedulerConfig) Type() string {
+	return "test-scheduler"
+}
+
+func TestParseScheduler(t *testing.T) {
+	fixtures := []struct {
+		scheduler, name, params string
+	}{
+		{scheduler: "", name: "", params: ""},
+		{scheduler: "crond", name: "crond", params: ""},
+		{scheduler: " crond : /usr/bin/crontab ", name: "crond", params: "/usr/bin/crontab"},
+		{scheduler: "custom:a:b", name: "custom", params: "a:b"},
+	}
+	for _, fixture := range fixtures {
+		t.Run(fixture.scheduler, func(t *testing.T) {
+			name, params := ParseScheduler(fixture.scheduler)
+			assert.Equal(t, fixture.name, name)
+			assert.Equal(t, fixture.params, params)
+		})
+	}
+}
+
+func TestRegisterScheduler(t *testing.T) {
+	defer delete(schedulers, "test-scheduler")
+
+	handler := &mockHandler{t: t}
+	RegisterScheduler("test-scheduler", SchedulerFuncs{
+		Config: func(_ *config.Global, params string) SchedulerConfig {
+			return testSchedulerConfig{params: params}
+		},
+		Handler: func(config SchedulerConfig) Handler {
+			return handler
+		},
+	})
+	assert.Contains(t, RegisteredSchedulers(), "test-scheduler")
+	assert.Contains(t, RegisteredSchedulers(), DefaultScheduler())
+
+	cfg := NewSchedulerConfig(&config.Global{Scheduler: "test-scheduler:some params"})
+	require.IsType(t, testSchedulerConfig{}, cfg)
+	assert.Equal(t, "some params", cfg.(testSchedulerConfig).params)
+	assert.Same(t, handler, NewHandler(cfg))
+
+	assert.Panics(t, func() { RegisterScheduler("test-scheduler", SchedulerFuncs{}) })
+	assert.Panics(t, func() { RegisterScheduler("", SchedulerFuncs{}) })
+	assert.Panics(t, func() { RegisterScheduler("name:params", SchedulerFuncs{}) })
+}
+
+func TestUnknownSchedulerUsesDefault(t *testing.T) {
+	cfg := NewSchedulerConfig(&config.Global{Scheduler: "unknown:params"})
+	assert.Equal(t, DefaultScheduler(), SchedulerType(cfg))
+	assert.NotNil(t, NewHandler(cfg))
+
+	cfg = NewSchedulerConfig(&config.Global{})
+	assert.Equal(t, DefaultScheduler(), SchedulerType(cfg))
+}
@@ -1,15 +1,12 @@
 package schedule
 
 import (
-	"runtime"
-
-	"github.com/creativeprojects/resticprofile/config"
 	"github.com/creativeprojects/resticprofile/constants"
 	"github.com/spf13/afero"
 )
 
 type SchedulerConfig interface {
-	// Type of scheduler config ("windows", "launchd", "crond", "systemd" or "" for OS default)
+	// Type of scheduler config ("windows", "launchd", "crond", "crontab", "systemd" or "" for OS default)
 	Type() string
 }
 
@@ -34,13 +31,24 @@ func (s SchedulerLaunchd) Type() string {
 }
 
 type SchedulerCrond struct {
-	Fs afero.Fs
+	Fs            afero.Fs
+	CrontabBinary string
+	CrontabFile   string
 }
 
 func (s SchedulerCrond) Type() string {
 	return constants.SchedulerCrond
 }
 
+// SchedulerCrontab is crond scheduling, writing the user crontab or a system crontab file (like /etc/cron.d/resticprofile)
+type SchedulerCrontab struct {
+	SchedulerCrond
+}
+
+func (s SchedulerCrontab) Type() string {
+	return constants.SchedulerCrontab
+}
+
 type SchedulerSystemd struct {
 	Fs            afero.Fs
 	UnitTemplate  string
@@ -51,33 +59,10 @@ func (s SchedulerSystemd) Type() string {
 	return constants.SchedulerSystemd
 }
 
-func NewSchedulerConfig(global *config.Global) SchedulerConfig {
-	switch global.Scheduler {
-	case constants.SchedulerCrond:
-		return SchedulerCrond{}
-	case constants.SchedulerLaunchd:
-		return SchedulerLaunchd{}
-	case constants.SchedulerSystemd:
-		return SchedulerSystemd{
-			UnitTemplate:  global.SystemdUnitTemplate,
-			TimerTemplate: global.SystemdTimerTemplate,
-		}
-	case constants.SchedulerWindows:
-		return SchedulerWindows{}
-	default:
-		if runtime.GOOS != "darwin" && runtime.GOOS != "windows" {
-			return SchedulerSystemd{
-				UnitTemplate:  global.SystemdUnitTemplate,
-				TimerTemplate: global.SystemdTimerTemplate,
-			}
-		}
-		return SchedulerDefaultOS{}
-	}
-}
-
 var (
 	_ SchedulerConfig = SchedulerDefaultOS{}
 	_ SchedulerConfig = SchedulerCrond{}
+	_ SchedulerConfig = SchedulerCrontab{}
 	_ SchedulerConfig = SchedulerLaunchd{}
 	_ SchedulerConfig = SchedulerSystemd{}
 	_ SchedulerConfig = SchedulerWindows{}
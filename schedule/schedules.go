@@ -2,10 +2,12 @@ package schedule
 
 import (
 	"errors"
+	"fmt"
 	"os/exec"
 	"time"
 
 	"github.com/creativeprojects/resticprofile/calendar"
+	"github.com/creativeprojects/resticprofile/config"
 	"github.com/creativeprojects/resticprofile/term"
 )
 
@@ -61,3 +63,29 @@ func displaySystemdSchedules(command string, schedules []string) error {
 	term.Print("\n")
 	return nil
 }
+
+// checkLocalTimezone returns an error when the job is scheduled in a time zone different from the local time zone:
+// only systemd timers can run in a specific time zone, the other schedulers always use the local time of the system
+func checkLocalTimezone(job *config.ScheduleConfig, scheduler string) error {
+	location, err := job.GetTimezone()
+	if err != nil {
+		return err
+	}
+	if !sameTimezone(location, time.Local, time.Now()) {
+		return fmt.Errorf("%s cannot run a schedule in time zone %q which is different from the local time zone", scheduler, job.Timezone)
+	}
+	return nil
+}
+
+// sameTimezone returns true when both locations have the same offset from UTC every day of the year (daylight saving time included)
+func sameTimezone(location, other *time.Location, from time.Time) bool {
+	for day := 0; day <= 366; day++ {
+		current := from.AddDate(0, 0, day)
+		_, offset := current.In(location).Zone()
+		_, otherOffset := current.In(other).Zone()
+		if offset != otherOffset {
+			return false
+		}
+	}
+	return true
+}
@@ -6,9 +6,12 @@ import (
 	"os/exec"
 	"runtime"
 	"testing"
+	"time"
 
+	"github.com/creativeprojects/resticprofile/config"
 	"github.com/creativeprojects/resticprofile/term"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestParseEmptySchedules(t *testing.T) {
@@ -93,3 +96,34 @@ func TestDisplaySystemdSchedulesError(t *testing.T) {
 	err := displaySystemdSchedules("command", []string{"daily"})
 	assert.Error(t, err)
 }
+
+func TestSameTimezone(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	require.NoError(t, err)
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	london, err := time.LoadLocation("Europe/London")
+	require.NoError(t, err)
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	// same winter offset as New York, but no daylight saving time
+	panama, err := time.LoadLocation("America/Panama")
+	require.NoError(t, err)
+
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	assert.True(t, sameTimezone(paris, berlin, from))
+	assert.False(t, sameTimezone(paris, london, from))
+	assert.False(t, sameTimezone(newYork, panama, from))
+}
+
+func TestCheckLocalTimezone(t *testing.T) {
+	assert.NoError(t, checkLocalTimezone(&config.ScheduleConfig{}, "crond"))
+	assert.NoError(t, checkLocalTimezone(&config.ScheduleConfig{Timezone: "Local"}, "crond"))
+	assert.Error(t, checkLocalTimezone(&config.ScheduleConfig{Timezone: "Invalid/Timezone"}, "crond"))
+
+	timezone := "Pacific/Kiritimati" // UTC+14
+	if _, offset := time.Now().Zone(); offset == 14*60*60 {
+		timezone = "UTC"
+	}
+	assert.ErrorContains(t, checkLocalTimezone(&config.ScheduleConfig{Timezone: timezone}, "crond"), "crond cannot run a schedule")
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/schedule"
+	"golang.org/x/exp/slices"
+)
+
+const scheduleEnvDiff = "env-diff"
+
+// importantEnvKeys matches the environment variables that commonly break scheduled jobs when missing
+var importantEnvKeys = regexp.MustCompile(`^(HOME|PATH|USER|LOGNAME|LANG|LC_.+|TMPDIR|TEMP|TMP|SSH_AUTH_SOCK|` +
+	`RESTIC_.+|AWS_.+|AZURE_.+|B2_.+|GOOGLE_.+|OS_.+|ST_.+|RCLONE_.+|XDG_CONFIG_HOME|XDG_CACHE_HOME)$`)
+
+// envDiff is the difference between the current environment and the environment of the scheduled jobs
+type envDiff struct {
+	missingImportant []string
+	missingOther     []string
+	different        []string
+	fromProfile      []string
+	missingPath      []string
+}
+
+// diffEnvironment compares the current environment with the scheduled environment (both in "key=value" format).
+// profileEnv contains the variable names set by the profile.
+func diffEnvironment(current, scheduled []string, profileEnv []string) (diff envDiff) {
+	currentEnv, scheduledEnv := envMap(current), envMap(scheduled)
+	for _, name := range sortedKeys(currentEnv) {
+		if slices.Contains(profileEnv, name) {
+			continue
+		}
+		scheduledValue, found := scheduledEnv[name]
+		switch {
+		case !found && importantEnvKeys.MatchString(name):
+			diff.missingImportant = append(diff.missingImportant, name)
+		case !found:
+			diff.missingOther = append(diff.missingOther, name)
+		case scheduledValue != currentEnv[name] && name != "PATH": // PATH differences are listed by directory
+			diff.different = append(diff.different, name)
+		}
+	}
+	diff.fromProfile = append(diff.fromProfile, profileEnv...)
+	sort.Strings(diff.fromProfile)
+
+	if currentPath, found := currentEnv["PATH"]; found && !slices.Contains(profileEnv, "PATH") {
+		scheduledPath := filepath.SplitList(scheduledEnv["PATH"])
+		for _, dir := range filepath.SplitList(currentPath) {
+			if dir != "" && !slices.Contains(scheduledPath, dir) && !slices.Contains(diff.missingPath, dir) {
+				diff.missingPath = append(diff.missingPath, dir)
+			}
+		}
+	}
+	return
+}
+
+func envMap(env []string) map[string]string {
+	result := make(map[string]string, len(env))
+	for _, entry := range env {
+		if name, value, found := strings.Cut(entry, "="); found && name != "" {
+			result[name] = value
+		}
+	}
+	return result
+}
+
+func sortedKeys(env map[string]string) []string {
+	keys := make([]string, 0, len(env))
+	for key := range env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// displayScheduleEnvDiff displays the environment variables of the current session missing in the scheduled jobs of the profile
+func displayScheduleEnvDiff(output io.Writer, scheduler schedule.SchedulerConfig, profile *config.Profile, pathPrepend []string) error {
+	profileEnv := make([]string, 0, len(profile.Environment))
+	for name := range profile.Environment {
+		profileEnv = append(profileEnv, strings.ToUpper(name))
+	}
+	// the directories of the profile are added to the PATH when running the profile
+	current := environmentWithPath(os.Environ(), profile.Path)
+	scheduled := environmentWithPath(schedule.JobEnvironment(scheduler), append(slices.Clone(profile.Path), pathPrepend...))
+	diff := diffEnvironment(current, scheduled, profileEnv)
+
+	out := func(format string, args ...any) { _, _ = fmt.Fprintf(output, format, args...) }
+	out("Environment of the jobs scheduled with %s for profile '%s', compared with the current environment:\n\n",
+		schedule.SchedulerType(scheduler), profile.Name)
+
+	if len(diff.missingImportant) == 0 && len(diff.different) == 0 && len(diff.missingPath) == 0 {
+		out("No important difference found.\n")
+	}
+	if len(diff.missingImportant) > 0 {
+		out("Missing in the scheduled jobs (add them to the \"env\" section of the profile if needed):\n")
+		for _, name := range diff.missingImportant {
+			out("  - %s\n", name)
+		}
+		out("\n")
+	}
+	if len(diff.different) > 0 {
+		out("Different in the scheduled jobs:\n")
+		for _, name := range diff.different {
+			out("  - %s\n", name)
+		}
+		out("\n")
+	}
+	if len(diff.missingPath) > 0 {
+		out("Directories of the current PATH not in the PATH of the scheduled jobs:\n")
+		for _, dir := range diff.missingPath {
+			out("  - %s\n", dir)
+		}
+		out("\n")
+	}
+	if len(diff.fromProfile) > 0 {
+		out("Set by the profile in both cases: %s\n\n", strings.Join(diff.fromProfile, ", "))
+	}
+	if len(diff.missingOther) > 0 {
+		out("Other variables of the current session, missing in the scheduled jobs: %s\n\n", strings.Join(diff.missingOther, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffEnvironment(t *testing.T) {
+	path := func(dirs ...string) string { return "PATH=" + strings.Join(dirs, string(filepath.ListSeparator)) }
+	current := []string{
+		"HOME=/home/user",
+		path("/home/user/bin", "/usr/bin", "/bin"),
+		"AWS_ACCESS_KEY_ID=key",
+		"RESTIC_PASSWORD=secret",
+		"LANG=en_GB.UTF-8",
+		"TERM=xterm",
+		"B2_ACCOUNT_ID=id",
+	}
+	scheduled := []string{
+		"HOME=/root",
+		path("/usr/bin", "/bin"),
+		"LANG=en_GB.UTF-8",
+	}
+
+	diff := diffEnvironment(current, scheduled, []string{"B2_ACCOUNT_ID"})
+	assert.Equal(t, []string{"AWS_ACCESS_KEY_ID", "RESTIC_PASSWORD"}, diff.missingImportant)
+	assert.Equal(t, []string{"TERM"}, diff.missingOther)
+	assert.Equal(t, []string{"HOME"}, diff.different)
+	assert.Equal(t, []string{"B2_ACCOUNT_ID"}, diff.fromProfile)
+	assert.Equal(t, []string{"/home/user/bin"}, diff.missingPath)
+
+	// PATH set by the profile
+	diff = diffEnvironment(current, scheduled, []string{"PATH"})
+	assert.Empty(t, diff.missingPath)
+}
@@ -4,11 +4,14 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"os/exec"
+	"strings"
 
 	"github.com/creativeprojects/clog"
 	"github.com/creativeprojects/resticprofile/config"
 	"github.com/creativeprojects/resticprofile/constants"
 	"github.com/creativeprojects/resticprofile/schedule"
+	"github.com/creativeprojects/resticprofile/term"
 )
 
 func scheduleJobs(handler schedule.Handler, profileName string, configs []*config.ScheduleConfig) error {
@@ -29,27 +32,7 @@ func scheduleJobs(handler schedule.Handler, profileName string, configs []*confi
 	defer scheduler.Close()
 
 	for _, scheduleConfig := range configs {
-		args := []string{
-			"--no-ansi",
-			"--config",
-			scheduleConfig.ConfigFile,
-			"--name",
-			scheduleConfig.Title,
-		}
-
-		if scheduleConfig.Log != "" {
-			args = append(args, "--log", scheduleConfig.Log)
-		}
-
-		if scheduleConfig.GetLockMode() == config.ScheduleLockModeDefault {
-			if scheduleConfig.GetLockWait() > 0 {
-				args = append(args, "--lock-wait", scheduleConfig.GetLockWait().String())
-			}
-		} else if scheduleConfig.GetLockMode() == config.ScheduleLockModeIgnore {
-			args = append(args, "--no-lock")
-		}
-
-		args = append(args, getResticCommand(scheduleConfig.SubTitle))
+		args := scheduledJobArgs(scheduleConfig)
 
 		scheduleConfig.SetCommand(wd, binary, args)
 		scheduleConfig.JobDescription =
@@ -70,6 +53,62 @@ func scheduleJobs(handler schedule.Handler, profileName string, configs []*confi
 	return nil
 }
 
+// scheduledJobArgs returns the resticprofile command line arguments of a scheduled job
+func scheduledJobArgs(scheduleConfig *config.ScheduleConfig) []string {
+	args := []string{
+		"--no-ansi",
+		"--config",
+		scheduleConfig.ConfigFile,
+		"--name",
+		scheduleConfig.Title,
+	}
+
+	if scheduleConfig.Log != "" {
+		args = append(args, "--log", scheduleConfig.Log)
+	}
+
+	if scheduleConfig.GetLockMode() == config.ScheduleLockModeDefault {
+		if scheduleConfig.GetLockWait() > 0 {
+			args = append(args, "--lock-wait", scheduleConfig.GetLockWait().String())
+		}
+	} else if scheduleConfig.GetLockMode() == config.ScheduleLockModeIgnore {
+		args = append(args, "--no-lock")
+	}
+
+	args = append(args, "--"+constants.FlagScheduleName, scheduleConfig.Title+"/"+scheduleConfig.SubTitle)
+
+	return append(args, getResticCommand(scheduleConfig.SubTitle))
+}
+
+// runScheduledJob runs the job immediately, with the same command line and (an approximation of) the same environment as the scheduler
+func runScheduledJob(scheduler schedule.SchedulerConfig, scheduleConfig *config.ScheduleConfig) error {
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	binary, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	args := scheduledJobArgs(scheduleConfig)
+	env := schedule.JobEnvironment(scheduler)
+
+	clog.Infof("running job %s/%s as %s would: %s %s", scheduleConfig.Title, scheduleConfig.SubTitle,
+		schedule.SchedulerType(scheduler), binary, strings.Join(args, " "))
+	clog.Debugf("environment of the job: %s", strings.Join(env, ", "))
+
+	cmd := exec.Command(binary, args...)
+	cmd.Dir = wd
+	cmd.Env = env
+	cmd.Stdout = term.GetOutput()
+	cmd.Stderr = term.GetErrorOutput()
+	if err = cmd.Run(); err != nil {
+		return fmt.Errorf("job %s/%s failed: %w", scheduleConfig.Title, scheduleConfig.SubTitle, err)
+	}
+	clog.Infof("job %s/%s finished successfully", scheduleConfig.Title, scheduleConfig.SubTitle)
+	return nil
+}
+
 func removeJobs(handler schedule.Handler, profileName string, configs []*config.ScheduleConfig) error {
 	scheduler := schedule.NewScheduler(handler, profileName)
 	err := scheduler.Init()
@@ -27,7 +27,7 @@ func TestArgumentsOnScheduleJobNoLog(t *testing.T) {
 		mock.AnythingOfType("[]*calendar.Event"),
 		mock.AnythingOfType("string")).
 		Return(func(scheduleConfig *config.ScheduleConfig, events []*calendar.Event, permission string) error {
-			assert.Equal(t, []string{"--no-ansi", "--config", "", "--name", "profile", "backup"}, scheduleConfig.Arguments)
+			assert.Equal(t, []string{"--no-ansi", "--config", "", "--name", "profile", "--schedule-name", "profile/backup", "backup"}, scheduleConfig.Arguments)
 			return nil
 		})
 
@@ -46,7 +46,7 @@ func TestArgumentsOnScheduleJobLogFile(t *testing.T) {
 		mock.AnythingOfType("[]*calendar.Event"),
 		mock.AnythingOfType("string")).
 		Run(func(scheduleConfig *config.ScheduleConfig, events []*calendar.Event, permission string) {
-			assert.Equal(t, []string{"--no-ansi", "--config", "", "--name", "profile", "--log", "/path/to/file", "backup"}, scheduleConfig.Arguments)
+			assert.Equal(t, []string{"--no-ansi", "--config", "", "--name", "profile", "--log", "/path/to/file", "--schedule-name", "profile/backup", "backup"}, scheduleConfig.Arguments)
 		}).
 		Return(nil)
 
@@ -69,7 +69,7 @@ func TestArgumentsOnScheduleJobLogSyslog(t *testing.T) {
 		mock.AnythingOfType("[]*calendar.Event"),
 		mock.AnythingOfType("string")).
 		Return(func(scheduleConfig *config.ScheduleConfig, events []*calendar.Event, permission string) error {
-			assert.Equal(t, []string{"--no-ansi", "--config", "", "--name", "profile", "--log", "tcp://localhost:123", "backup"}, scheduleConfig.Arguments)
+			assert.Equal(t, []string{"--no-ansi", "--config", "", "--name", "profile", "--log", "tcp://localhost:123", "--schedule-name", "profile/backup", "backup"}, scheduleConfig.Arguments)
 			return nil
 		})
 
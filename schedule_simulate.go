@@ -0,0 +1,253 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/calendar"
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/monitor/history"
+	"github.com/creativeprojects/resticprofile/util"
+)
+
+const (
+	scheduleSimulate        = "simulate"
+	simulateDateFormat      = "2006-01-02"
+	defaultSimulateDuration = 30 * time.Minute
+)
+
+// simulateOptions are the options of "schedule simulate"
+type simulateOptions struct {
+	from     time.Time
+	to       time.Time
+	duration time.Duration
+}
+
+// parseSimulateArgs returns the options from the arguments following "schedule simulate"
+func parseSimulateArgs(args []string, now time.Time) (options simulateOptions, err error) {
+	options.duration = defaultSimulateDuration
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--all" {
+			continue // simulate always covers all profiles
+		}
+		if arg != "--from" && arg != "--to" && arg != "--duration" {
+			return options, fmt.Errorf("unknown flag %s for schedule %s", arg, scheduleSimulate)
+		}
+		if i+1 >= len(args) {
+			return options, fmt.Errorf("missing value for %s", arg)
+		}
+		i++
+		switch arg {
+		case "--from", "--to":
+			date, err := time.ParseInLocation(simulateDateFormat, args[i], time.Local)
+			if err != nil {
+				return options, fmt.Errorf("invalid date %q for %s: expected format YYYY-MM-DD", args[i], arg)
+			}
+			if arg == "--from" {
+				options.from = date
+			} else {
+				options.to = date
+			}
+		case "--duration":
+			if options.duration, err = time.ParseDuration(args[i]); err != nil || options.duration <= 0 {
+				return options, fmt.Errorf("invalid duration %q", args[i])
+			}
+		}
+	}
+	if options.from.IsZero() {
+		year, month, day := now.Date()
+		options.from = time.Date(year, month, day, 0, 0, 0, 0, time.Local)
+	}
+	if options.to.IsZero() {
+		options.to = options.from.AddDate(0, 0, 7)
+	}
+	if !options.to.After(options.from) {
+		return options, errors.New("the end of the simulation (--to) must be after its start (--from)")
+	}
+	return
+}
+
+// maintenanceWindow is a time range when the repositories are not available
+type maintenanceWindow struct {
+	definition string
+	event      *calendar.Event
+	duration   time.Duration
+}
+
+// parseMaintenanceWindow parses a maintenance window in the form "<calendar event> for <duration>"
+func parseMaintenanceWindow(definition string) (window maintenanceWindow, err error) {
+	index := strings.LastIndex(definition, " for ")
+	if index < 0 {
+		return window, fmt.Errorf("invalid maintenance window %q: expected \"<calendar event> for <duration>\"", definition)
+	}
+	window.definition = definition
+	window.event = calendar.NewEvent()
+	if err = window.event.Parse(strings.TrimSpace(definition[:index])); err != nil {
+		return window, fmt.Errorf("invalid maintenance window %q: %w", definition, err)
+	}
+	if window.duration, err = time.ParseDuration(strings.TrimSpace(definition[index+len(" for "):])); err != nil || window.duration <= 0 {
+		return window, fmt.Errorf("invalid duration in maintenance window %q", definition)
+	}
+	return window, nil
+}
+
+// simulatedRun is a run a schedule would trigger
+type simulatedRun struct {
+	start      time.Time
+	duration   time.Duration
+	profile    string
+	command    string
+	repository string // masked for display
+	repoKey    string
+	notes      []string
+}
+
+func (r *simulatedRun) end() time.Time {
+	return r.start.Add(r.duration)
+}
+
+func (r *simulatedRun) overlaps(start, end time.Time) bool {
+	return r.start.Before(end) && start.Before(r.end())
+}
+
+// simulateProfileRuns returns the runs the schedules of the profile would trigger in between from and to
+func simulateProfileRuns(profile *config.Profile, from, to time.Time, defaultDuration time.Duration) ([]*simulatedRun, error) {
+	var runs []*simulatedRun
+	for _, schedule := range profile.Schedules() {
+		location, err := schedule.GetTimezone()
+		if err != nil {
+			return nil, err
+		}
+		duration := expectedRunDuration(profile, schedule.SubTitle, defaultDuration)
+		for _, definition := range schedule.Schedules {
+			event := calendar.NewEvent()
+			if err = event.Parse(definition); err != nil {
+				return nil, fmt.Errorf("profile '%s', command %s: %w", profile.Name, schedule.SubTitle, err)
+			}
+			for _, start := range event.GetAllInBetween(from.In(location), to.In(location)) {
+				runs = append(runs, &simulatedRun{
+					start:      start.In(time.Local),
+					duration:   duration,
+					profile:    profile.Name,
+					command:    schedule.SubTitle,
+					repository: profile.Repository.String(),
+					repoKey:    profile.Repository.Value(),
+				})
+			}
+		}
+	}
+	return runs, nil
+}
+
+// expectedRunDuration returns the average duration of the successful runs in the history of the profile, or the default duration
+func expectedRunDuration(profile *config.Profile, command string, defaultDuration time.Duration) time.Duration {
+	if profile.HistoryFile == "" {
+		return defaultDuration
+	}
+	entries, err := history.NewHistory(profile.HistoryFile).List(history.Filter{Profile: profile.Name, Command: command})
+	if err != nil {
+		clog.Debugf("cannot read history of profile '%s': %s", profile.Name, err)
+		return defaultDuration
+	}
+	total, count := 0.0, 0
+	for _, entry := range entries {
+		if entry.Success && entry.Duration > 0 {
+			total += entry.Duration
+			count++
+		}
+	}
+	if count == 0 {
+		return defaultDuration
+	}
+	return time.Duration(total / float64(count) * float64(time.Second)).Round(time.Second)
+}
+
+// flagConflicts adds notes to the runs happening during a maintenance window or overlapping another run on the same repository
+func flagConflicts(runs []*simulatedRun, windows []maintenanceWindow, from, to time.Time) {
+	for _, window := range windows {
+		// a window starting before the simulation can still cover its first runs
+		for _, start := range window.event.GetAllInBetween(from.Add(-window.duration), to) {
+			end := start.Add(window.duration)
+			for _, run := range runs {
+				if run.overlaps(start, end) {
+					run.notes = append(run.notes, fmt.Sprintf("maintenance window %q", window.definition))
+				}
+			}
+		}
+	}
+	for i, run := range runs {
+		for j, other := range runs {
+			if i == j || run.repoKey == "" || run.repoKey != other.repoKey || !run.overlaps(other.start, other.end()) {
+				continue
+			}
+			run.notes = append(run.notes, fmt.Sprintf("overlaps %s.%s on the same repository", other.profile, other.command))
+		}
+	}
+}
+
+// simulateSchedules lists the runs of the schedules of all profiles in between the dates of the options
+func simulateSchedules(output io.Writer, c *config.Config, options simulateOptions) error {
+	global, err := c.GetGlobalSection()
+	if err != nil {
+		return fmt.Errorf("cannot load global section: %w", err)
+	}
+	windows := make([]maintenanceWindow, 0, len(global.MaintenanceWindows))
+	for _, definition := range global.MaintenanceWindows {
+		window, err := parseMaintenanceWindow(definition)
+		if err != nil {
+			return err
+		}
+		windows = append(windows, window)
+	}
+
+	var runs []*simulatedRun
+	for _, profileName := range c.GetProfileNames() {
+		if c.IsAbstractProfile(profileName) {
+			continue
+		}
+		profile, err := c.GetProfile(profileName)
+		if err != nil {
+			return fmt.Errorf("cannot load profile '%s': %w", profileName, err)
+		}
+		profileRuns, err := simulateProfileRuns(profile, options.from, options.to, options.duration)
+		if err != nil {
+			return err
+		}
+		runs = append(runs, profileRuns...)
+	}
+	sort.SliceStable(runs, func(i, j int) bool {
+		if runs[i].start.Equal(runs[j].start) {
+			return runs[i].profile+"."+runs[i].command < runs[j].profile+"."+runs[j].command
+		}
+		return runs[i].start.Before(runs[j].start)
+	})
+	flagConflicts(runs, windows, options.from, options.to)
+
+	fmt.Fprintf(output, "Simulation of the schedules from %s to %s\n\n", options.from.Format(simulateDateFormat), options.to.Format(simulateDateFormat))
+	if len(runs) == 0 {
+		fmt.Fprintln(output, "no scheduled run in this time range")
+		return nil
+	}
+	conflicts := 0
+	w := tabwriter.NewWriter(output, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "TIME\tPROFILE\tCOMMAND\tDURATION\tREPOSITORY\tCONFLICTS")
+	for _, run := range runs {
+		if len(run.notes) > 0 {
+			conflicts++
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			run.start.Format("2006-01-02 15:04 MST"), run.profile, run.command, util.FormatDuration(run.duration), run.repository, strings.Join(run.notes, ", "))
+	}
+	if err = w.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(output, "\n%d run(s), %d with conflict(s)\n", len(runs), conflicts)
+	return nil
+}
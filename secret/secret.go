@@ -0,0 +1,269 @@
+// Package secret resolves the references to a secret kept by a password manager (1Password, Bitwarden, pass)
+// through the command line tool of the password manager, and reads the secrets of a Provider (HashiCorp Vault)
+// through its API.
+package secret
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/util/shutdown"
+)
+
+// Prefixes of the references to a secret
+const (
+	PrefixOnePassword = "op://"
+	PrefixBitwarden   = "bw://"
+	PrefixPass        = "pass://"
+)
+
+// Environment variables of the sessions of the password managers
+const (
+	EnvBitwardenSession  = "BW_SESSION"
+	EnvBitwardenPassword = "BW_PASSWORD"
+)
+
+// Runner runs an external command with the environment and returns its standard output
+type Runner func(name string, args []string, env []string) (output []byte, err error)
+
+// IsReference returns true when the value is a reference to a secret
+func IsReference(value string) bool {
+	return strings.HasPrefix(value, PrefixOnePassword) ||
+		strings.HasPrefix(value, PrefixBitwarden) ||
+		strings.HasPrefix(value, PrefixPass)
+}
+
+// Resolver reads the secrets, keeping them in memory so each secret is only read once per run
+type Resolver struct {
+	run      Runner
+	mutex    sync.Mutex
+	secrets  map[string]string
+	items    map[string]*bitwardenItem
+	bwUnlock string   // session of bitwarden opened by the resolver
+	bwEnv    []string // environment of the command that opened the session
+}
+
+var (
+	defaultResolver     *Resolver
+	defaultResolverOnce sync.Once
+
+	// AuditCommand is called after each command line tool of the password managers has run, to record it in the audit log (optional)
+	AuditCommand func(name string, args []string, start time.Time, err error)
+)
+
+// DefaultResolver returns the resolver shared by all the profiles of the run.
+// The vault of bitwarden unlocked by the resolver is locked again when shutdown.RunHooks is invoked.
+func DefaultResolver() *Resolver {
+	defaultResolverOnce.Do(func() {
+		defaultResolver = NewResolver(nil)
+		shutdown.AddHook(func() {
+			if err := defaultResolver.Lock(); err != nil {
+				clog.Warning(err)
+			}
+		})
+	})
+	return defaultResolver
+}
+
+// NewResolver creates a resolver running the command line tools of the password managers with run (or with os/exec when nil)
+func NewResolver(run Runner) *Resolver {
+	if run == nil {
+		run = execCommand
+	}
+	return &Resolver{
+		run:     run,
+		secrets: make(map[string]string),
+		items:   make(map[string]*bitwardenItem),
+	}
+}
+
+// Resolve returns the secret of the reference. The environment is given to the command line tool
+// of the password manager (e.g. with BW_SESSION or OP_SERVICE_ACCOUNT_TOKEN).
+func (r *Resolver) Resolve(reference string, env []string) (string, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if value, found := r.secrets[reference]; found {
+		return value, nil
+	}
+	var value string
+	var err error
+	switch {
+	case strings.HasPrefix(reference, PrefixOnePassword):
+		value, err = r.readOnePassword(reference, env)
+	case strings.HasPrefix(reference, PrefixBitwarden):
+		value, err = r.readBitwarden(reference, env)
+	case strings.HasPrefix(reference, PrefixPass):
+		value, err = r.readPass(reference, env)
+	default:
+		return "", fmt.Errorf("%q is not a reference to a secret", reference)
+	}
+	if err != nil {
+		return "", fmt.Errorf("cannot read secret %q: %w", reference, err)
+	}
+	r.secrets[reference] = value
+	return value, nil
+}
+
+// readOnePassword reads "op://vault/item/[section/]field" with "op read"
+func (r *Resolver) readOnePassword(reference string, env []string) (string, error) {
+	if strings.Count(strings.TrimPrefix(reference, PrefixOnePassword), "/") < 2 {
+		return "", errors.New("expected op://vault/item/field")
+	}
+	output, err := r.run("op", []string{"read", "--no-newline", reference}, env)
+	if err != nil {
+		return "", err
+	}
+	return string(output), nil
+}
+
+// readPass reads the first line of "pass://path/of/entry" with "pass show"
+func (r *Resolver) readPass(reference string, env []string) (string, error) {
+	name := strings.TrimPrefix(reference, PrefixPass)
+	if name == "" {
+		return "", errors.New("expected pass://path/of/entry")
+	}
+	output, err := r.run("pass", []string{"show", name}, env)
+	if err != nil {
+		return "", err
+	}
+	value, _, _ := strings.Cut(string(output), "\n")
+	return strings.TrimSuffix(value, "\r"), nil
+}
+
+// bitwardenItem is the part of the JSON output of "bw get item" holding the secrets
+type bitwardenItem struct {
+	Notes string `json:"notes"`
+	Login *struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	} `json:"login"`
+	Fields []struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	} `json:"fields"`
+}
+
+// field returns the value of a field of the item: username, password, notes or the name of a custom field
+func (i *bitwardenItem) field(name string) (string, bool) {
+	switch {
+	case name == "password" && i.Login != nil:
+		return i.Login.Password, true
+	case name == "username" && i.Login != nil:
+		return i.Login.Username, true
+	case name == "notes":
+		return i.Notes, true
+	}
+	for _, field := range i.Fields {
+		if field.Name == name {
+			return field.Value, true
+		}
+	}
+	return "", false
+}
+
+// readBitwarden reads "bw://item/field" with "bw get item" (the field is "password" when omitted)
+func (r *Resolver) readBitwarden(reference string, env []string) (string, error) {
+	name, field, _ := strings.Cut(strings.TrimPrefix(reference, PrefixBitwarden), "/")
+	if name == "" {
+		return "", errors.New("expected bw://item/field")
+	}
+	if field == "" {
+		field = "password"
+	}
+	item, found := r.items[name]
+	if !found {
+		env, err := r.bitwardenSession(env)
+		if err != nil {
+			return "", err
+		}
+		output, err := r.run("bw", []string{"get", "item", name, "--nointeraction"}, env)
+		if err != nil {
+			return "", err
+		}
+		item = new(bitwardenItem)
+		if err = json.Unmarshal(output, item); err != nil {
+			return "", fmt.Errorf("cannot read the item of bw: %w", err)
+		}
+		r.items[name] = item
+	}
+	value, found := item.field(field)
+	if !found {
+		return "", fmt.Errorf("no field %q in item %q", field, name)
+	}
+	return value, nil
+}
+
+// bitwardenSession returns the environment with the session of bitwarden. When no session is opened,
+// the vault is unlocked once with the master password found in BW_PASSWORD.
+func (r *Resolver) bitwardenSession(env []string) ([]string, error) {
+	if lookupEnv(env, EnvBitwardenSession) != "" {
+		return env, nil
+	}
+	if r.bwUnlock == "" {
+		if lookupEnv(env, EnvBitwardenPassword) == "" {
+			return nil, fmt.Errorf("the vault of bw is locked: set %s with the output of \"bw unlock --raw\", or set %s", EnvBitwardenSession, EnvBitwardenPassword)
+		}
+		clog.Debug("unlocking the vault of bw")
+		output, err := r.run("bw", []string{"unlock", "--passwordenv", EnvBitwardenPassword, "--raw", "--nointeraction"}, env)
+		if err != nil {
+			return nil, fmt.Errorf("cannot unlock the vault of bw: %w", err)
+		}
+		r.bwUnlock = strings.TrimSpace(string(output))
+		r.bwEnv = env
+	}
+	return append(env, EnvBitwardenSession+"="+r.bwUnlock), nil
+}
+
+// Lock locks the vault of bitwarden when it was unlocked by the resolver. A session given in BW_SESSION is left open.
+func (r *Resolver) Lock() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.bwUnlock == "" {
+		return nil
+	}
+	env := append(r.bwEnv, EnvBitwardenSession+"="+r.bwUnlock)
+	r.bwUnlock, r.bwEnv = "", nil
+	if _, err := r.run("bw", []string{"lock", "--nointeraction"}, env); err != nil {
+		return fmt.Errorf("cannot lock the vault of bw: %w", err)
+	}
+	return nil
+}
+
+// lookupEnv returns the last value of the variable in the environment
+func lookupEnv(env []string, name string) (value string) {
+	for _, variable := range env {
+		if key, current, found := strings.Cut(variable, "="); found && key == name {
+			value = current
+		}
+	}
+	return
+}
+
+func execCommand(name string, args []string, env []string) ([]byte, error) {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	cmd := exec.Command(name, args...)
+	cmd.Env = env
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	start := time.Now()
+	err := cmd.Run()
+	if AuditCommand != nil {
+		AuditCommand(name, args, start, err)
+	}
+	if err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return nil, fmt.Errorf("%s: %w: %s", name, err, message)
+		}
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return stdout.Bytes(), nil
+}
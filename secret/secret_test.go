@@ -0,0 +1,148 @@
+package secret
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeCommands struct {
+	calls   []string
+	outputs map[string]string
+}
+
+func (f *fakeCommands) run(name string, args []string, env []string) ([]byte, error) {
+	call := strings.Join(append([]string{name}, args...), " ")
+	f.calls = append(f.calls, call)
+	if name == "bw" && args[0] == "get" && lookupEnv(env, EnvBitwardenSession) == "" {
+		return nil, errors.New("vault is locked")
+	}
+	output, found := f.outputs[call]
+	if !found {
+		return nil, errors.New("unknown command " + call)
+	}
+	return []byte(output), nil
+}
+
+func TestIsReference(t *testing.T) {
+	for _, value := range []string{"op://vault/item/password", "bw://item", "pass://backup/restic"} {
+		assert.True(t, IsReference(value), value)
+	}
+	for _, value := range []string{"", "password", "https://host/", "op:/vault", "passphrase", "pass:word", "pass:/entry"} {
+		assert.False(t, IsReference(value), value)
+	}
+}
+
+func TestResolveOnePassword(t *testing.T) {
+	fake := &fakeCommands{outputs: map[string]string{
+		"op read --no-newline op://vault/restic/password": "secret",
+	}}
+	resolver := NewResolver(fake.run)
+	for i := 0; i < 2; i++ {
+		value, err := resolver.Resolve("op://vault/restic/password", nil)
+		require.NoError(t, err)
+		assert.Equal(t, "secret", value)
+	}
+	// the secret is read once
+	assert.Len(t, fake.calls, 1)
+
+	_, err := resolver.Resolve("op://vault/restic", nil)
+	assert.ErrorContains(t, err, "expected op://vault/item/field")
+}
+
+func TestResolvePass(t *testing.T) {
+	fake := &fakeCommands{outputs: map[string]string{
+		"pass show backup/restic": "secret\nusername: backup\n",
+	}}
+	value, err := NewResolver(fake.run).Resolve("pass://backup/restic", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", value)
+
+	_, err = NewResolver(fake.run).Resolve("pass://other", nil)
+	assert.ErrorContains(t, err, `cannot read secret "pass://other": unknown command pass show other`)
+}
+
+func TestResolveBitwarden(t *testing.T) {
+	item := `{"notes":"note","login":{"username":"user","password":"secret"},"fields":[{"name":"api-key","value":"key"}]}`
+	fake := &fakeCommands{outputs: map[string]string{
+		"bw get item restic --nointeraction":                        item,
+		"bw unlock --passwordenv BW_PASSWORD --raw --nointeraction": "session-key\n",
+		"bw get item missing --nointeraction":                       `{}`,
+	}}
+
+	t.Run("locked", func(t *testing.T) {
+		_, err := NewResolver(fake.run).Resolve("bw://restic", nil)
+		assert.ErrorContains(t, err, "the vault of bw is locked")
+	})
+
+	t.Run("session", func(t *testing.T) {
+		fake.calls = nil
+		resolver := NewResolver(fake.run)
+		env := []string{"BW_SESSION=key"}
+		fixtures := map[string]string{
+			"bw://restic":          "secret",
+			"bw://restic/password": "secret",
+			"bw://restic/username": "user",
+			"bw://restic/notes":    "note",
+			"bw://restic/api-key":  "key",
+		}
+		for reference, expected := range fixtures {
+			value, err := resolver.Resolve(reference, env)
+			require.NoError(t, err)
+			assert.Equal(t, expected, value)
+		}
+		// the item is read once
+		assert.Equal(t, []string{"bw get item restic --nointeraction"}, fake.calls)
+
+		_, err := resolver.Resolve("bw://restic/other", env)
+		assert.ErrorContains(t, err, `no field "other" in item "restic"`)
+		_, err = resolver.Resolve("bw://missing", env)
+		assert.ErrorContains(t, err, `no field "password" in item "missing"`)
+	})
+
+	t.Run("unlock", func(t *testing.T) {
+		fake.calls = nil
+		resolver := NewResolver(fake.run)
+		env := []string{"BW_PASSWORD=master"}
+		value, err := resolver.Resolve("bw://restic/username", env)
+		require.NoError(t, err)
+		assert.Equal(t, "user", value)
+		_, err = resolver.Resolve("bw://missing", env)
+		assert.Error(t, err)
+		// the vault is unlocked once
+		assert.Equal(t, []string{
+			"bw unlock --passwordenv BW_PASSWORD --raw --nointeraction",
+			"bw get item restic --nointeraction",
+			"bw get item missing --nointeraction",
+		}, fake.calls)
+	})
+
+	t.Run("lock", func(t *testing.T) {
+		fake.calls = nil
+		fake.outputs["bw lock --nointeraction"] = "Your vault is locked."
+		resolver := NewResolver(fake.run)
+
+		// nothing to lock
+		require.NoError(t, resolver.Lock())
+		_, err := resolver.Resolve("bw://restic", []string{"BW_SESSION=key"})
+		require.NoError(t, err)
+		require.NoError(t, resolver.Lock())
+		assert.Equal(t, []string{"bw get item restic --nointeraction"}, fake.calls)
+
+		// the vault unlocked by the resolver is locked once
+		fake.calls = nil
+		resolver = NewResolver(fake.run)
+		_, err = resolver.Resolve("bw://restic", []string{"BW_PASSWORD=master"})
+		require.NoError(t, err)
+		require.NoError(t, resolver.Lock())
+		require.NoError(t, resolver.Lock())
+		assert.Equal(t, []string{
+			"bw unlock --passwordenv BW_PASSWORD --raw --nointeraction",
+			"bw get item restic --nointeraction",
+			"bw lock --nointeraction",
+		}, fake.calls)
+	})
+}
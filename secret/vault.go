@@ -0,0 +1,216 @@
+package secret
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Environment variables read by the HashiCorp Vault provider (the same as the vault command line tool)
+const (
+	EnvVaultAddress   = "VAULT_ADDR"
+	EnvVaultToken     = "VAULT_TOKEN"
+	EnvVaultNamespace = "VAULT_NAMESPACE"
+	EnvVaultRoleID    = "VAULT_ROLE_ID"
+	EnvVaultSecretID  = "VAULT_SECRET_ID"
+)
+
+// Authentication methods of the HashiCorp Vault provider
+const (
+	VaultAuthToken   = "token"
+	VaultAuthAppRole = "approle"
+)
+
+// Provider is a secrets manager read through its API
+type Provider interface {
+	// Key identifies the secret in the resolver, so each secret is only read once per run.
+	// The environment holds the settings of the secrets manager that are not in the provider.
+	Key(env []string) string
+	// Read returns the value of the secret. The environment holds the credentials of the secrets manager.
+	Read(env []string) (string, error)
+}
+
+// Read returns the secret of the provider
+func (r *Resolver) Read(provider Provider, env []string) (string, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	key := provider.Key(env)
+	if value, found := r.secrets[key]; found {
+		return value, nil
+	}
+	value, err := provider.Read(env)
+	if err != nil {
+		return "", fmt.Errorf("cannot read secret %q: %w", key, err)
+	}
+	r.secrets[key] = value
+	return value, nil
+}
+
+var vaultClient = &http.Client{Timeout: 30 * time.Second}
+
+// Vault reads a field of a secret kept in a KV secrets engine (version 1 or 2) of HashiCorp Vault
+type Vault struct {
+	Address   string // VAULT_ADDR when empty
+	Path      string // path of the secret, like "secret/data/backup" for a KV version 2 engine mounted on "secret"
+	Field     string // "password" when empty
+	Auth      string // "token" (VAULT_TOKEN or ~/.vault-token) or "approle" (VAULT_ROLE_ID and VAULT_SECRET_ID)
+	Namespace string // VAULT_NAMESPACE when empty (Vault Enterprise)
+}
+
+// Key returns the URL of the secret followed by the namespace and the field. The address and the namespace
+// found in the environment are part of the key: two profiles can read the same path from different vaults.
+func (v Vault) Key(env []string) string {
+	v = v.withEnvironment(env)
+	key := fmt.Sprintf("%s/v1/%s", strings.TrimSuffix(v.Address, "/"), strings.Trim(v.Path, "/"))
+	if v.Namespace != "" {
+		key += "?namespace=" + v.Namespace
+	}
+	return key + "#" + v.field()
+}
+
+// withEnvironment returns the settings completed with VAULT_ADDR and VAULT_NAMESPACE
+func (v Vault) withEnvironment(env []string) Vault {
+	if v.Address == "" {
+		v.Address = lookupEnv(env, EnvVaultAddress)
+	}
+	if v.Namespace == "" {
+		v.Namespace = lookupEnv(env, EnvVaultNamespace)
+	}
+	return v
+}
+
+func (v Vault) field() string {
+	if v.Field == "" {
+		return "password"
+	}
+	return v.Field
+}
+
+// Read logs in when needed, and returns the field of the secret
+func (v Vault) Read(env []string) (string, error) {
+	v = v.withEnvironment(env)
+	if v.Address == "" {
+		return "", fmt.Errorf("no address of the vault: set address or %s", EnvVaultAddress)
+	}
+	if strings.Trim(v.Path, "/") == "" {
+		return "", errors.New("no path of the secret in the vault")
+	}
+	token, err := v.token(env)
+	if err != nil {
+		return "", err
+	}
+	response := struct {
+		Data map[string]any `json:"data"`
+	}{}
+	if err = v.request(http.MethodGet, strings.Trim(v.Path, "/"), token, nil, &response); err != nil {
+		return "", err
+	}
+	data := response.Data
+	// a KV version 2 engine wraps the secret with its metadata
+	if inner, ok := data["data"].(map[string]any); ok {
+		if _, found := data["metadata"]; found {
+			data = inner
+		}
+	}
+	value, found := data[v.field()]
+	if !found {
+		return "", fmt.Errorf("no field %q in secret %q", v.field(), v.Path)
+	}
+	text, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("field %q in secret %q is not a string", v.field(), v.Path)
+	}
+	return text, nil
+}
+
+// token returns the token of the authentication method
+func (v Vault) token(env []string) (string, error) {
+	switch v.Auth {
+	case "", VaultAuthToken:
+		if token := lookupEnv(env, EnvVaultToken); token != "" {
+			return token, nil
+		}
+		// the vault command line tool keeps the token of "vault login" in this file
+		if home, err := os.UserHomeDir(); err == nil {
+			if content, err := os.ReadFile(filepath.Join(home, ".vault-token")); err == nil && len(bytes.TrimSpace(content)) > 0 {
+				return string(bytes.TrimSpace(content)), nil
+			}
+		}
+		return "", fmt.Errorf("no token of the vault: set %s or run \"vault login\"", EnvVaultToken)
+
+	case VaultAuthAppRole:
+		roleID, secretID := lookupEnv(env, EnvVaultRoleID), lookupEnv(env, EnvVaultSecretID)
+		if roleID == "" || secretID == "" {
+			return "", fmt.Errorf("approle authentication needs %s and %s", EnvVaultRoleID, EnvVaultSecretID)
+		}
+		login := map[string]string{"role_id": roleID, "secret_id": secretID}
+		response := struct {
+			Auth *struct {
+				ClientToken string `json:"client_token"`
+			} `json:"auth"`
+		}{}
+		if err := v.request(http.MethodPost, "auth/approle/login", "", login, &response); err != nil {
+			return "", fmt.Errorf("cannot log in to the vault: %w", err)
+		}
+		if response.Auth == nil || response.Auth.ClientToken == "" {
+			return "", errors.New("cannot log in to the vault: no token in the response")
+		}
+		return response.Auth.ClientToken, nil
+
+	default:
+		return "", fmt.Errorf("unsupported authentication method %q (expected %s or %s)", v.Auth, VaultAuthToken, VaultAuthAppRole)
+	}
+}
+
+// request calls the HTTP API of the vault and decodes the JSON response into result
+func (v Vault) request(method, path, token string, body any, result any) error {
+	var reader io.Reader
+	if body != nil {
+		content, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(content)
+	}
+	request, err := http.NewRequest(method, strings.TrimSuffix(v.Address, "/")+"/v1/"+path, reader)
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		request.Header.Set("X-Vault-Token", token)
+	}
+	if v.Namespace != "" {
+		request.Header.Set("X-Vault-Namespace", v.Namespace)
+	}
+	response, err := vaultClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	content, err := io.ReadAll(io.LimitReader(response.Body, 1024*1024))
+	if err != nil {
+		return err
+	}
+	if response.StatusCode != http.StatusOK {
+		errorResponse := struct {
+			Errors []string `json:"errors"`
+		}{}
+		if json.Unmarshal(content, &errorResponse) == nil && len(errorResponse.Errors) > 0 {
+			return fmt.Errorf("%s: %s", response.Status, strings.Join(errorResponse.Errors, ", "))
+		}
+		return errors.New(response.Status)
+	}
+	if err = json.Unmarshal(content, result); err != nil {
+		return fmt.Errorf("cannot read the response of the vault: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package secret
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newFakeVault(t *testing.T) (*httptest.Server, *int) {
+	t.Helper()
+	reads := new(int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/auth/approle/login":
+			login := map[string]string{}
+			_ = json.NewDecoder(r.Body).Decode(&login)
+			if login["role_id"] != "role" || login["secret_id"] != "secret" {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"errors":["invalid role or secret ID"]}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"auth":{"client_token":"approle-token"}}`))
+			return
+		}
+		if token := r.Header.Get("X-Vault-Token"); token != "token" && token != "approle-token" {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		*reads++
+		switch r.URL.Path {
+		case "/v1/secret/data/backup":
+			_, _ = w.Write([]byte(`{"data":{"data":{"password":"kv2-secret","other":"value","number":1},"metadata":{"version":3}}}`))
+		case "/v1/kv/backup":
+			_, _ = w.Write([]byte(`{"data":{"password":"kv1-secret"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errors":[]}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, reads
+}
+
+func TestVaultToken(t *testing.T) {
+	server, reads := newFakeVault(t)
+	env := []string{"VAULT_TOKEN=token"}
+	resolver := NewResolver(nil)
+
+	for i := 0; i < 2; i++ {
+		value, err := resolver.Read(Vault{Address: server.URL, Path: "secret/data/backup"}, env)
+		require.NoError(t, err)
+		assert.Equal(t, "kv2-secret", value)
+	}
+	// the secret is read once
+	assert.Equal(t, 1, *reads)
+
+	value, err := resolver.Read(Vault{Path: "/kv/backup/"}, append(env, "VAULT_ADDR="+server.URL))
+	require.NoError(t, err)
+	assert.Equal(t, "kv1-secret", value)
+
+	value, err = resolver.Read(Vault{Address: server.URL, Path: "secret/data/backup", Field: "other"}, env)
+	require.NoError(t, err)
+	assert.Equal(t, "value", value)
+}
+
+func TestVaultAppRole(t *testing.T) {
+	server, _ := newFakeVault(t)
+	vault := Vault{Address: server.URL, Path: "secret/data/backup", Auth: VaultAuthAppRole}
+
+	value, err := vault.Read([]string{"VAULT_ROLE_ID=role", "VAULT_SECRET_ID=secret"})
+	require.NoError(t, err)
+	assert.Equal(t, "kv2-secret", value)
+
+	_, err = vault.Read([]string{"VAULT_ROLE_ID=role", "VAULT_SECRET_ID=other"})
+	assert.EqualError(t, err, "cannot log in to the vault: 400 Bad Request: invalid role or secret ID")
+
+	_, err = vault.Read([]string{"VAULT_ROLE_ID=role"})
+	assert.EqualError(t, err, "approle authentication needs VAULT_ROLE_ID and VAULT_SECRET_ID")
+}
+
+func TestVaultErrors(t *testing.T) {
+	server, _ := newFakeVault(t)
+	env := []string{"VAULT_TOKEN=token"}
+
+	for _, fixture := range []struct {
+		vault Vault
+		env   []string
+		err   string
+	}{
+		{Vault{Path: "kv/backup"}, env, "no address of the vault: set address or VAULT_ADDR"},
+		{Vault{Address: server.URL}, env, "no path of the secret in the vault"},
+		{Vault{Address: server.URL, Path: "kv/backup", Auth: "ldap"}, env, `unsupported authentication method "ldap" (expected token or approle)`},
+		{Vault{Address: server.URL, Path: "kv/backup"}, []string{"VAULT_TOKEN=other"}, "403 Forbidden: permission denied"},
+		{Vault{Address: server.URL, Path: "kv/missing"}, env, "404 Not Found"},
+		{Vault{Address: server.URL, Path: "kv/backup", Field: "username"}, env, `no field "username" in secret "kv/backup"`},
+		{Vault{Address: server.URL, Path: "secret/data/backup", Field: "number"}, env, `field "number" in secret "secret/data/backup" is not a string`},
+	} {
+		t.Run(fixture.err, func(t *testing.T) {
+			_, err := NewResolver(nil).Read(fixture.vault, fixture.env)
+			assert.ErrorContains(t, err, fixture.err)
+		})
+	}
+}
+
+func TestVaultKey(t *testing.T) {
+	vault := Vault{Path: "/kv/backup/"}
+	assert.Equal(t, "https://one:8200/v1/kv/backup#password", vault.Key([]string{"VAULT_ADDR=https://one:8200/"}))
+	assert.Equal(t, "https://one:8200/v1/kv/backup?namespace=team#password", vault.Key([]string{"VAULT_ADDR=https://one:8200", "VAULT_NAMESPACE=team"}))
+
+	vault = Vault{Address: "https://one:8200", Path: "kv/backup", Namespace: "team", Field: "key"}
+	assert.Equal(t, "https://one:8200/v1/kv/backup?namespace=team#key", vault.Key([]string{"VAULT_ADDR=https://two:8200", "VAULT_NAMESPACE=other"}))
+}
+
+func TestVaultSamePathInTwoVaults(t *testing.T) {
+	first, firstReads := newFakeVault(t)
+	second, secondReads := newFakeVault(t)
+	resolver := NewResolver(nil)
+	vault := Vault{Path: "kv/backup"}
+
+	for _, server := range []string{first.URL, second.URL, first.URL} {
+		_, err := resolver.Read(vault, []string{"VAULT_TOKEN=token", "VAULT_ADDR=" + server})
+		require.NoError(t, err)
+	}
+	// each vault is read once
+	assert.Equal(t, 1, *firstReads)
+	assert.Equal(t, 1, *secondReads)
+}
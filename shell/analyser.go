@@ -39,6 +39,8 @@ var outputAnalyserPatterns = map[string]*regexp.Regexp{
 	"lock-failure,who":   regexp.MustCompile("unable to create lock.+already locked.+?by (.+)$"),
 	"lock-failure,age":   regexp.MustCompile("lock was created at.+\\(([^()]+)\\s+ago\\)"),
 	"lock-failure,stale": regexp.MustCompile("the\\W+unlock\\W+command can be used to remove stale locks"),
+	"disk-full":          regexp.MustCompile("(?i)no space left on device|not enough space on the disk"),
+	"network-failure":    regexp.MustCompile("(?i)dial tcp|connection refused|connection reset by peer|no such host|network is unreachable|i/o timeout|tls handshake timeout|ssh: handshake failed"),
 }
 
 func NewOutputAnalyser() *OutputAnalyser {
@@ -134,6 +136,20 @@ func (a OutputAnalyser) ContainsRemoteLockFailure() bool {
 	return false
 }
 
+func (a OutputAnalyser) ContainsDiskFull() bool {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	return a.counts["disk-full"] > 0
+}
+
+func (a OutputAnalyser) ContainsNetworkFailure() bool {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	return a.counts["network-failure"] > 0
+}
+
 func (a OutputAnalyser) GetRemoteLockedSince() (time.Duration, bool) {
 	a.lock.Lock()
 	defer a.lock.Unlock()
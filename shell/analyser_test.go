@@ -35,6 +35,29 @@ func TestRemoteLockFailure(t *testing.T) {
 	})
 }
 
+func TestDiskFull(t *testing.T) {
+	analysis := NewOutputAnalyser()
+	require.NoError(t, analysis.AnalyseStringLines("Fatal: unable to save snapshot: open /backup/data/tmp-123: no space left on device\n"))
+	assert.True(t, analysis.ContainsDiskFull())
+	assert.False(t, analysis.ContainsRemoteLockFailure())
+
+	analysis.Reset()
+	assert.False(t, analysis.ContainsDiskFull())
+	require.NoError(t, analysis.AnalyseStringLines(ResticLockFailureOutput))
+	assert.False(t, analysis.ContainsDiskFull())
+}
+
+func TestNetworkFailure(t *testing.T) {
+	analysis := NewOutputAnalyser()
+	require.NoError(t, analysis.AnalyseStringLines("Fatal: unable to open repository at rest:http://backup:8000/: Get \"http://backup:8000/config\": dial tcp 10.0.0.2:8000: connect: connection refused\n"))
+	assert.True(t, analysis.ContainsNetworkFailure())
+	assert.False(t, analysis.ContainsDiskFull())
+
+	analysis.Reset()
+	require.NoError(t, analysis.AnalyseStringLines(ResticLockFailureOutput))
+	assert.False(t, analysis.ContainsNetworkFailure())
+}
+
 func TestCustomErrorCallback(t *testing.T) {
 	var analyser *OutputAnalyser
 	invoked := 0
@@ -7,6 +7,9 @@ import (
 	"github.com/creativeprojects/resticprofile/util/collect"
 )
 
+// Args is the list of flags and arguments of a command line.
+// Flags are always emitted first, sorted by name (values of the same flag keep the order they were added in),
+// followed by the arguments with no flag in the order they were added.
 type Args struct {
 	args   map[string][]Arg
 	more   []Arg
@@ -33,6 +36,7 @@ func (a *Args) Clone() *Args {
 	return clone
 }
 
+// Walk calls the callback for each flag value and argument, in the same order as GetAll
 func (a *Args) Walk(callback func(name string, arg *Arg) *Arg) {
 	processArgs := func(name string, args []Arg) {
 		for i, arg := range args {
@@ -41,8 +45,8 @@ func (a *Args) Walk(callback func(name string, arg *Arg) *Arg) {
 			}
 		}
 	}
-	for name, args := range a.args {
-		processArgs(name, args)
+	for _, name := range a.sortedNames() {
+		processArgs(name, a.args[name])
 	}
 	processArgs("", a.more)
 }
@@ -74,6 +78,15 @@ func (a *Args) AddFlags(key string, values []string, argType ArgType) {
 	a.args[key] = args
 }
 
+// SetArgs replaces the values of a flag, keeping their type. The flag is removed when there's no value left
+func (a *Args) SetArgs(key string, args []Arg) {
+	if len(args) == 0 {
+		delete(a.args, key)
+		return
+	}
+	a.args[key] = args
+}
+
 // AddArg adds a single argument with no flag
 func (a *Args) AddArg(arg string, argType ArgType) {
 	a.more = append(a.more, NewArg(arg, a.addLegacy(argType)))
@@ -133,7 +146,8 @@ func (a *Args) Rename(oldName, newName string) bool {
 	return ok
 }
 
-// GetAll return a clean list of arguments to send on the command line
+// GetAll return a clean list of arguments to send on the command line.
+// The order is stable: flags sorted by name first, then the arguments with no flag.
 func (a *Args) GetAll() []string {
 	args := make([]string, 0, len(a.args)+len(a.more)+10)
 
@@ -141,16 +155,8 @@ func (a *Args) GetAll() []string {
 		return args
 	}
 
-	// we make a list of keys first, so we can loop on the map from an ordered list of keys
-	keys := make([]string, 0, len(a.args))
-	for key := range a.args {
-		keys = append(keys, key)
-	}
-	// sort the keys in order
-	sort.Strings(keys)
-
-	// now we loop from the ordered list of keys
-	for _, key := range keys {
+	// we loop on the map from an ordered list of keys
+	for _, key := range a.sortedNames() {
 		values := a.args[key]
 		if values == nil {
 			continue
@@ -174,3 +180,13 @@ func (a *Args) GetAll() []string {
 	}
 	return args
 }
+
+// sortedNames returns the names of all flags in alphabetical order
+func (a *Args) sortedNames() []string {
+	names := make([]string, 0, len(a.args))
+	for name := range a.args {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
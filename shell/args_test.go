@@ -62,6 +62,28 @@ func TestWalk(t *testing.T) {
 	assert.Equal(t, []string{"--x=newY", "more"}, args.GetAll())
 }
 
+func TestStableOrder(t *testing.T) {
+	args := NewArgs()
+	args.AddArg("second", ArgConfigEscape)
+	args.AddFlags("zzz", []string{"2", "1"}, ArgConfigEscape)
+	args.AddArg("first", ArgConfigEscape)
+	args.AddFlag("aaa", "a", ArgConfigEscape)
+	args.AddFlags("mmm", nil, ArgConfigEscape)
+
+	expected := []string{"--aaa=a", "--mmm", "--zzz=2", "--zzz=1", "second", "first"}
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, expected, args.GetAll())
+		assert.Equal(t, expected, args.Clone().GetAll())
+	}
+
+	var walked []string
+	args.Walk(func(name string, arg *Arg) *Arg {
+		walked = append(walked, name+":"+arg.Value())
+		return arg
+	})
+	assert.Equal(t, []string{"aaa:a", "zzz:2", "zzz:1", ":second", ":first"}, walked)
+}
+
 func TestRenameAndRemove(t *testing.T) {
 	args := NewArgs()
 	args.AddFlag("x", "y", ArgConfigEscape)
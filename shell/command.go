@@ -1,7 +1,6 @@
 package shell
 
 import (
-	"bytes"
 	"errors"
 	"fmt"
 	"io"
@@ -14,6 +13,7 @@ import (
 
 	"github.com/creativeprojects/clog"
 	"github.com/creativeprojects/resticprofile/monitor"
+	"github.com/creativeprojects/resticprofile/util/redact"
 )
 
 const (
@@ -31,6 +31,9 @@ type SetPID func(pid int)
 // The implementation is expected to send everything read from the reader back to the writer
 type ScanOutput func(r io.Reader, summary *monitor.Summary, w io.Writer) error
 
+// StartProcess is a callback starting the child process, e.g. to change the settings of the child after it started
+type StartProcess func(cmd *exec.Cmd) error
+
 // Command holds the configuration to run a shell command
 type Command struct {
 	Command    string
@@ -41,13 +44,24 @@ type Command struct {
 	Stdin      io.Reader
 	Stdout     io.Writer
 	Stderr     io.Writer
+	RawStdout  bool // Stdout receives data (not messages): confidential values are not masked
 	SetPID     SetPID
 	ScanStdout ScanOutput
-	sigChan    chan os.Signal
-	done       chan interface{}
-	analyser   *OutputAnalyser
+	Start      StartProcess // starts the child process instead of exec.Cmd.Start (optional)
+
+	// InterruptGracePeriod is the time given to the command to stop after the interrupt signal was forwarded,
+	// before all processes of the command are terminated
+	InterruptGracePeriod time.Duration
+
+	sigChan  chan os.Signal
+	done     chan interface{}
+	analyser *OutputAnalyser
 }
 
+// DefaultInterruptGracePeriod is the time given to a signalled command to stop after the interrupt signal was forwarded,
+// before all processes of the command are terminated
+const DefaultInterruptGracePeriod = 30 * time.Second
+
 // NewCommand instantiate a default Command without receiving OS signals (SIGTERM, etc.)
 func NewCommand(command string, args []string) *Command {
 	return &Command{
@@ -55,6 +69,8 @@ func NewCommand(command string, args []string) *Command {
 		Arguments: args,
 		Environ:   []string{},
 		analyser:  NewOutputAnalyser(),
+
+		InterruptGracePeriod: DefaultInterruptGracePeriod,
 	}
 }
 
@@ -67,6 +83,8 @@ func NewSignalledCommand(command string, args []string, c chan os.Signal) *Comma
 		sigChan:   c,
 		done:      make(chan interface{}),
 		analyser:  NewOutputAnalyser(),
+
+		InterruptGracePeriod: DefaultInterruptGracePeriod,
 	}
 }
 
@@ -89,6 +107,16 @@ func (c *Command) Run() (monitor.Summary, string, error) {
 
 	// clog.Tracef("command: %s %q", command, args)
 	cmd := exec.Command(command, args...)
+	if c.sigChan != nil {
+		setupProcessGroup(cmd)
+	}
+
+	// confidential values are masked in the output of the command
+	output := c.Stdout
+	if !c.RawStdout {
+		output = redact.NewWriter(c.Stdout)
+		defer func() { _ = redact.Flush(output) }()
+	}
 
 	if c.ScanStdout != nil {
 		// install a pipe for scanning the output
@@ -97,15 +125,16 @@ func (c *Command) Run() (monitor.Summary, string, error) {
 			return summary, "", err
 		}
 	} else {
-		cmd.Stdout = c.Stdout
+		cmd.Stdout = output
 	}
 	if c.Stderr != nil {
 		stderr, err = cmd.StderrPipe()
 		if err != nil {
-			cmd.Stderr = c.Stderr
+			cmd.Stderr = redact.NewWriter(c.Stderr)
 		}
 	}
 	cmd.Stdin = c.Stdin
+	cmd.Dir = c.Dir
 
 	cmd.Env = os.Environ()
 	if c.Environ != nil && len(c.Environ) > 0 {
@@ -115,38 +144,57 @@ func (c *Command) Run() (monitor.Summary, string, error) {
 	start := time.Now()
 
 	// spawn the child process
-	if err = cmd.Start(); err != nil {
+	if c.Start != nil {
+		err = c.Start(cmd)
+	} else {
+		err = cmd.Start()
+	}
+	if err != nil {
 		return summary, "", err
 	}
 	if c.SetPID != nil {
 		// send the PID back (to write down in a lockfile)
 		c.SetPID(cmd.Process.Pid)
 	}
-	// setup the OS signalling if we need it (typically used for unixes but not windows)
+	// setup the OS signalling if we need it
 	if c.sigChan != nil {
+		group := newProcessGroup(cmd.Process)
+		gracePeriod := c.InterruptGracePeriod
 		defer func() {
 			close(c.done)
+			group.release()
 		}()
 		go func() {
 			// send INT signal
-			c.propagateSignal(cmd.Process)
+			if !c.propagateSignal(group) {
+				return
+			}
 			// close stdin (if possible) to unblock Wait on cmd.Process
 			if in, canClose := cmd.Stdin.(io.Closer); canClose && in != nil {
 				in.Close()
 			}
+			// terminate all processes of the command if still running after the grace period
+			select {
+			case <-c.done:
+			case <-time.After(gracePeriod):
+				clog.Warningf("command still running %s after the interrupt signal, terminating it", gracePeriod)
+				if err := group.terminate(); err != nil {
+					clog.Errorf("cannot terminate command: %s", err)
+				}
+			}
 		}()
 	}
 
 	// output scanner
 	if stdout != nil {
-		err = c.ScanStdout(stdout, &summary, c.Stdout)
+		err = c.ScanStdout(stdout, &summary, output)
 		if err != nil {
 			return summary, "", err
 		}
 	}
 
-	// handle command errors
-	errors := &bytes.Buffer{}
+	// handle command errors (keeping the beginning and the end of a large output only)
+	errors := NewOutputBuffer(CapturedOutputLimit)
 
 	// send error output to buffer & stderr
 	if stderr != nil {
@@ -155,7 +203,9 @@ func (c *Command) Run() (monitor.Summary, string, error) {
 			stderrOutput = os.Stderr
 		}
 
-		err = c.analyser.AnalyseLines(io.TeeReader(stderr, io.MultiWriter(stderrOutput, errors)))
+		errorOutput := redact.NewWriter(io.MultiWriter(stderrOutput, errors))
+		err = c.analyser.AnalyseLines(io.TeeReader(stderr, errorOutput))
+		_ = redact.Flush(errorOutput)
 		if err != nil {
 			clog.Errorf("failed reading stderr from command: %s ; Cause: %s", command, err.Error())
 		}
@@ -173,31 +223,51 @@ func (c *Command) Run() (monitor.Summary, string, error) {
 	return summary, errorText, err
 }
 
+// propagateSignal forwards the interrupt signal received by resticprofile to the processes of the command.
+// It returns false when the command finished without any signal received.
+func (c *Command) propagateSignal(group *processGroup) bool {
+	select {
+	case <-c.sigChan:
+		if err := group.interrupt(); err != nil {
+			clog.Warningf("cannot send interrupt signal to the command, terminating it: %s", err)
+			_ = group.terminate()
+		}
+		return true
+	case <-c.done:
+		return false
+	}
+}
+
 // GetShellCommand transforms the command line and arguments to be launched via a shell (sh or cmd.exe)
 func (c *Command) GetShellCommand() (shell string, arguments []string, err error) {
+	shell, err = FindShell(c.Shell)
+	if err != nil {
+		return
+	}
+
+	composer := getArgumentsComposer(shell)
+	arguments = composer(c)
+	return
+}
+
+// FindShell returns the full path of the first shell found from the list (or from the OS default list when empty)
+func FindShell(shells []string) (shell string, err error) {
 	var searchList []string
-	for _, sh := range c.Shell {
+	for _, sh := range shells {
 		if sh = strings.TrimSpace(sh); sh != "" {
 			searchList = append(searchList, sh)
 		}
 	}
 	if len(searchList) == 0 {
-		searchList = c.getShellSearchList()
+		searchList = (&Command{}).getShellSearchList()
 	}
 
 	for _, search := range searchList {
 		if shell, err = exec.LookPath(search); err == nil {
-			break
+			return
 		}
 	}
-
-	if err != nil {
-		err = fmt.Errorf("cannot find shell: %w (tried %s)", err, strings.Join(searchList, ", "))
-		return
-	}
-
-	composer := getArgumentsComposer(shell)
-	arguments = composer(c)
+	err = fmt.Errorf("cannot find shell: %w (tried %s)", err, strings.Join(searchList, ", "))
 	return
 }
 
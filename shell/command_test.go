@@ -2,11 +2,13 @@ package shell
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"os/exec"
 	"os/signal"
+	"path/filepath"
 	"regexp"
 	"runtime"
 	"strings"
@@ -14,8 +16,10 @@ import (
 	"testing"
 	"time"
 
+	"github.com/creativeprojects/resticprofile/util/redact"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh/terminal"
 )
 
 var (
@@ -273,6 +277,22 @@ func TestRunShellEcho(t *testing.T) {
 	assert.Contains(t, string(output), "TestRunShellEcho")
 }
 
+func TestRunShellInWorkingDirectory(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("pwd is not available on Windows")
+	}
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+
+	buffer := &bytes.Buffer{}
+	cmd := NewCommand("pwd", nil)
+	cmd.Dir = dir
+	cmd.Stdout = buffer
+	_, _, err = cmd.Run()
+	require.NoError(t, err)
+	assert.Equal(t, dir, strings.TrimSpace(buffer.String()))
+}
+
 func TestRunShellEchoWithSignalling(t *testing.T) {
 	buffer := &bytes.Buffer{}
 
@@ -327,6 +347,51 @@ func TestInterruptShellCommand(t *testing.T) {
 	assert.Less(t, duration.Milliseconds(), int64(300))
 }
 
+func TestInterruptShellPipeline(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Test not running on this platform")
+	}
+	if terminal.IsTerminal(int(os.Stdin.Fd())) {
+		t.Skip("the command doesn't run in its own process group from a terminal")
+	}
+	sigChan := make(chan os.Signal, 1)
+
+	// the output pipe stays open until all processes of the pipeline are stopped
+	cmd := NewSignalledCommand("sleep 5 | cat", nil, sigChan)
+	cmd.Stdout = &bytes.Buffer{}
+
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		sigChan <- syscall.SIGINT
+	}()
+	start := time.Now()
+	_, _, err := cmd.Run()
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), 3*time.Second)
+}
+
+func TestTerminateAfterGracePeriod(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Test not running on this platform")
+	}
+	if terminal.IsTerminal(int(os.Stdin.Fd())) {
+		t.Skip("the command doesn't run in its own process group from a terminal")
+	}
+	sigChan := make(chan os.Signal, 1)
+	cmd := NewSignalledCommand(`trap "" INT; sleep 5`, nil, sigChan)
+	cmd.Stdout = &bytes.Buffer{}
+	cmd.InterruptGracePeriod = 300 * time.Millisecond
+
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		sigChan <- syscall.SIGINT
+	}()
+	start := time.Now()
+	_, _, err := cmd.Run()
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), 3*time.Second)
+}
+
 func TestSetPIDCallback(t *testing.T) {
 	called := 0
 	buffer := &bytes.Buffer{}
@@ -343,6 +408,26 @@ func TestSetPIDCallback(t *testing.T) {
 	assert.Equal(t, 1, called)
 }
 
+func TestStartProcessCallback(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	cmd := NewCommand("echo", []string{"TestStartProcessCallback"})
+	cmd.Stdout = buffer
+	started := false
+	cmd.Start = func(child *exec.Cmd) error {
+		started = true
+		return child.Start()
+	}
+	_, _, err := cmd.Run()
+	require.NoError(t, err)
+	assert.True(t, started)
+	assert.Equal(t, "TestStartProcessCallback\n", strings.ReplaceAll(buffer.String(), "\r\n", "\n"))
+
+	cmd = NewCommand("echo", []string{"TestStartProcessCallback"})
+	cmd.Start = func(*exec.Cmd) error { return errors.New("cannot start") }
+	_, _, err = cmd.Run()
+	assert.EqualError(t, err, "cannot start")
+}
+
 func TestSetPIDCallbackWithSignalling(t *testing.T) {
 	called := 0
 	buffer := &bytes.Buffer{}
@@ -425,6 +510,22 @@ func TestStderr(t *testing.T) {
 	assert.Equal(t, expected, stderr)
 }
 
+func TestConfidentialValuesAreMaskedInOutput(t *testing.T) {
+	defer redact.Clear()
+	redact.Add("top-secret", "×××")
+
+	cmd := NewCommand("echo", []string{"out:top-secret", "&&", "echo", "err:top-secret", ">&2"})
+	bufferStdout, bufferStderr := &bytes.Buffer{}, &bytes.Buffer{}
+	cmd.Stdout = bufferStdout
+	cmd.Stderr = bufferStderr
+	_, stderr, err := cmd.Run()
+	require.NoError(t, err)
+	assert.Contains(t, bufferStdout.String(), "out:×××")
+	assert.Contains(t, bufferStderr.String(), "err:×××")
+	assert.Contains(t, stderr, "err:×××")
+	assert.NotContains(t, bufferStdout.String()+bufferStderr.String()+stderr, "top-secret")
+}
+
 func TestStderrSignalledCommand(t *testing.T) {
 	expected := "error message\n"
 	if runtime.GOOS == "windows" {
@@ -4,32 +4,46 @@ package shell
 
 import (
 	"os"
+	"os/exec"
 	"syscall"
+
+	"golang.org/x/crypto/ssh/terminal"
 )
 
-func (c *Command) propagateSignal(process *os.Process) {
-	select {
-	case <-c.sigChan:
-		// We resend the signal to the child process
-		process.Signal(syscall.SIGINT)
-		return
-	case <-c.done:
+// setupProcessGroup starts the process in its own process group, so that all the processes started by the command
+// (e.g. a pipeline in a shell) receive the signals forwarded by resticprofile.
+// When resticprofile runs from a terminal, the process stays in the foreground group as it may need to read from
+// the terminal (e.g. a password prompt): the terminal sends Ctrl+C to all processes of the group anyway.
+func setupProcessGroup(cmd *exec.Cmd) {
+	if terminal.IsTerminal(int(os.Stdin.Fd())) {
 		return
 	}
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
 }
 
-func (c *Command) propagateGroupSignal(process *os.Process) {
-	select {
-	case <-c.sigChan:
-		// We resend the signal to the child group
-		group, _ := os.FindProcess(-process.Pid)
-		group.Signal(syscall.SIGINT)
-		return
-	case <-c.done:
-		return
+// processGroup sends signals to the child process, or to all the processes of its group when the child leads its own group
+type processGroup struct {
+	pid int
+}
+
+func newProcessGroup(process *os.Process) *processGroup {
+	pid := process.Pid
+	if pgid, err := syscall.Getpgid(pid); err == nil && pgid == pid {
+		pid = -pid
 	}
+	return &processGroup{pid: pid}
 }
 
+func (g *processGroup) interrupt() error {
+	return syscall.Kill(g.pid, syscall.SIGINT)
+}
+
+func (g *processGroup) terminate() error {
+	return syscall.Kill(g.pid, syscall.SIGKILL)
+}
+
+func (g *processGroup) release() {}
+
 // getShellSearchList returns a priority sorted list of default shells to pick when none was specified
 func (c *Command) getShellSearchList() []string {
 	return []string{
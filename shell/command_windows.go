@@ -4,16 +4,69 @@ package shell
 
 import (
 	"os"
+	"os/exec"
+	"syscall"
+
+	"github.com/creativeprojects/clog"
+	"golang.org/x/sys/windows"
 )
 
-// In Windows, all hierarchy will receive the signal (which is good because we cannot send it anyway)
-// In fact, there's nothing for us to do here but method must block on channels
-func (c *Command) propagateSignal(*os.Process) {
-	select {
-	case <-c.sigChan:
-		return
-	case <-c.done:
-		return
+// setupProcessGroup starts the process in its own process group: console events (Ctrl+C, Ctrl+Break)
+// are no longer received directly from the console, but forwarded by propagateSignal
+func setupProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.CreationFlags |= windows.CREATE_NEW_PROCESS_GROUP
+}
+
+// processGroup sends console events to the process group of the child process, and terminates all the processes
+// started by the command with a job object
+type processGroup struct {
+	process *os.Process
+	job     windows.Handle
+}
+
+func newProcessGroup(process *os.Process) *processGroup {
+	group := &processGroup{process: process}
+	job, err := windows.CreateJobObject(nil, nil)
+	if err != nil {
+		clog.Debugf("cannot create job object: %s", err)
+		return group
+	}
+	handle, err := windows.OpenProcess(windows.PROCESS_SET_QUOTA|windows.PROCESS_TERMINATE, false, uint32(process.Pid))
+	if err == nil {
+		err = windows.AssignProcessToJobObject(job, handle)
+		_ = windows.CloseHandle(handle)
+	}
+	if err != nil {
+		clog.Debugf("cannot assign process %d to job object: %s", process.Pid, err)
+		_ = windows.CloseHandle(job)
+		return group
+	}
+	group.job = job
+	return group
+}
+
+// interrupt sends a Ctrl+Break event to the process group (Ctrl+C cannot be sent to a process group).
+// Go and restic handle Ctrl+Break like an interrupt signal.
+func (g *processGroup) interrupt() error {
+	return windows.GenerateConsoleCtrlEvent(windows.CTRL_BREAK_EVENT, uint32(g.process.Pid))
+}
+
+func (g *processGroup) terminate() error {
+	if g.job != 0 {
+		if err := windows.TerminateJobObject(g.job, 1); err == nil {
+			return nil
+		}
+	}
+	return g.process.Kill()
+}
+
+func (g *processGroup) release() {
+	if g.job != 0 {
+		_ = windows.CloseHandle(g.job)
+		g.job = 0
 	}
 }
 
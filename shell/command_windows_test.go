@@ -0,0 +1,30 @@
+//go:build windows
+
+package shell
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterruptSignalledCommandOnWindows(t *testing.T) {
+	sigChan := make(chan os.Signal, 1)
+	cmd := NewSignalledCommand(mockBinary, []string{"test", "--sleep", "5000"}, sigChan)
+	cmd.Stdout = &bytes.Buffer{}
+	cmd.InterruptGracePeriod = 500 * time.Millisecond
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		sigChan <- os.Interrupt
+	}()
+	start := time.Now()
+	_, _, err := cmd.Run()
+	assert.Error(t, err)
+
+	// either stopped by the Ctrl+Break event or terminated after the grace period
+	assert.Less(t, time.Since(start), 3*time.Second)
+}
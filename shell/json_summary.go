@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"io"
 	"runtime"
 
@@ -57,6 +58,7 @@ var ScanBackupJson ScanOutput = func(r io.Reader, summary *monitor.Summary, w io
 				summary.FilesTotal = jsonSummary.TotalFilesProcessed
 				summary.BytesAdded = jsonSummary.DataAdded
 				summary.BytesTotal = jsonSummary.TotalBytesProcessed
+				summary.SnapshotID = jsonSummary.SnapshotID
 			}
 			continue
 		}
@@ -70,3 +72,86 @@ var ScanBackupJson ScanOutput = func(r io.Reader, summary *monitor.Summary, w io
 	}
 	return nil
 }
+
+// resticJsonForgetGroup is a group of snapshots in the output of "forget --json"
+type resticJsonForgetGroup struct {
+	Keep   []json.RawMessage `json:"keep"`
+	Remove []json.RawMessage `json:"remove"`
+}
+
+// ScanForgetJson counts the snapshots kept and removed in the output of "forget --json",
+// and writes the count instead of the JSON document
+var ScanForgetJson ScanOutput = func(r io.Reader, summary *monitor.Summary, w io.Writer) error {
+	eol := "\n"
+	if runtime.GOOS == "windows" {
+		eol = "\r\n"
+	}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), 64*1024*1024) // one line lists all the snapshots
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if bytes.HasPrefix(line, []byte("[")) && bytes.HasSuffix(line, []byte("]")) {
+			groups := make([]resticJsonForgetGroup, 0)
+			if err := json.Unmarshal(line, &groups); err == nil {
+				forget := &monitor.ForgetSummary{}
+				for _, group := range groups {
+					forget.SnapshotsKept += len(group.Keep)
+					forget.SnapshotsRemoved += len(group.Remove)
+				}
+				summary.Forget = forget
+				_, _ = fmt.Fprintf(w, "%d snapshot(s) kept, %d snapshot(s) removed%s", forget.SnapshotsKept, forget.SnapshotsRemoved, eol)
+				continue
+			}
+		}
+		_, _ = w.Write(scanner.Bytes())
+		_, _ = w.Write([]byte(eol))
+	}
+	return scanner.Err()
+}
+
+// resticJsonCheckMessage is a message in the output of "check --json" (restic >= 0.17)
+type resticJsonCheckMessage struct {
+	MessageType        string `json:"message_type"`
+	Message            string `json:"message"`
+	NumErrors          int    `json:"num_errors"`
+	SuggestRepairIndex bool   `json:"suggest_repair_index"`
+	SuggestPrune       bool   `json:"suggest_prune"`
+}
+
+// ScanCheckJson reads the summary of "check --json": the error messages are written as text,
+// the other JSON messages are not written to the output
+var ScanCheckJson ScanOutput = func(r io.Reader, summary *monitor.Summary, w io.Writer) error {
+	jsonPrefix := []byte(`{"message_type":"`)
+	eol := "\n"
+	if runtime.GOOS == "windows" {
+		eol = "\r\n"
+	}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if bytes.HasPrefix(line, jsonPrefix) && bytes.HasSuffix(line, []byte("}")) {
+			message := resticJsonCheckMessage{}
+			if err := json.Unmarshal(line, &message); err == nil {
+				switch message.MessageType {
+				case "summary":
+					summary.Check = &monitor.CheckSummary{
+						Errors:             message.NumErrors,
+						SuggestRepairIndex: message.SuggestRepairIndex,
+						SuggestPrune:       message.SuggestPrune,
+					}
+					if message.NumErrors == 0 {
+						_, _ = fmt.Fprintf(w, "no errors were found%s", eol)
+					} else {
+						_, _ = fmt.Fprintf(w, "%d error(s) found%s", message.NumErrors, eol)
+					}
+				case "error":
+					_, _ = fmt.Fprintf(w, "error: %s%s", message.Message, eol)
+				}
+				continue
+			}
+		}
+		_, _ = w.Write(line)
+		_, _ = w.Write([]byte(eol))
+	}
+	return scanner.Err()
+}
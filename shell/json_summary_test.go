@@ -65,6 +65,7 @@ func TestScanJsonSummary(t *testing.T) {
 	assert.Equal(t, uint64(296530781), summary.BytesAdded)
 	assert.Equal(t, uint64(362948126), summary.BytesTotal)
 	assert.Equal(t, 236, summary.FilesTotal)
+	assert.Equal(t, "6daa8ef6", summary.SnapshotID)
 }
 
 func TestScanJsonError(t *testing.T) {
@@ -110,3 +111,41 @@ Is there a repository at the following location?
 	assert.Equal(t, uint64(0), summary.BytesTotal)
 	assert.Equal(t, 0, summary.FilesTotal)
 }
+
+func TestScanJsonForget(t *testing.T) {
+	resticOutput := `[{"tags":null,"host":"h","paths":["/home"],"keep":[{"id":"a"},{"id":"b"}],"remove":[{"id":"c"}],"reasons":[]},{"tags":null,"host":"h","paths":["/etc"],"keep":[{"id":"d"}],"remove":null,"reasons":[]}]
+loading indexes...
+`
+	summary := &monitor.Summary{}
+	output := &strings.Builder{}
+	require.NoError(t, ScanForgetJson(strings.NewReader(resticOutput), summary, output))
+
+	assert.Equal(t, "3 snapshot(s) kept, 1 snapshot(s) removed"+eol+"loading indexes..."+eol, output.String())
+	require.NotNil(t, summary.Forget)
+	assert.Equal(t, monitor.ForgetSummary{SnapshotsKept: 3, SnapshotsRemoved: 1}, *summary.Forget)
+}
+
+func TestScanJsonForgetInvalid(t *testing.T) {
+	summary := &monitor.Summary{}
+	output := &strings.Builder{}
+	require.NoError(t, ScanForgetJson(strings.NewReader("[not json]\n"), summary, output))
+
+	assert.Equal(t, "[not json]"+eol, output.String())
+	assert.Nil(t, summary.Forget)
+}
+
+func TestScanJsonCheck(t *testing.T) {
+	resticOutput := `using temporary cache in /tmp/restic-check-cache-1
+{"message_type":"error","message":"pack 1234 contained in several indexes"}
+{"message_type":"summary","num_errors":1,"broken_packs":null,"suggest_repair_index":true,"suggest_prune":false}
+`
+	summary := &monitor.Summary{}
+	output := &strings.Builder{}
+	require.NoError(t, ScanCheckJson(strings.NewReader(resticOutput), summary, output))
+
+	assert.Equal(t, "using temporary cache in /tmp/restic-check-cache-1"+eol+
+		"error: pack 1234 contained in several indexes"+eol+
+		"1 error(s) found"+eol, output.String())
+	require.NotNil(t, summary.Check)
+	assert.Equal(t, monitor.CheckSummary{Errors: 1, SuggestRepairIndex: true}, *summary.Check)
+}
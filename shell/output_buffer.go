@@ -0,0 +1,90 @@
+package shell
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// CapturedOutputLimit is the maximum size (in bytes) of the error output of a command kept in memory.
+// Only the beginning and the end of a larger output are kept. There's no limit when set to zero.
+var CapturedOutputLimit = 64 * 1024
+
+// OutputBuffer is a writer keeping the beginning and the end of the output up to a maximum size:
+// the middle of a larger output is dropped, and replaced by a truncation note in String()
+type OutputBuffer struct {
+	headSize, tailSize int
+	head, tail         []byte
+	dropped            int64
+}
+
+// NewOutputBuffer creates a buffer keeping up to limit bytes (no limit when limit is zero or negative)
+func NewOutputBuffer(limit int) *OutputBuffer {
+	if limit <= 0 {
+		return &OutputBuffer{headSize: -1}
+	}
+	headSize := limit / 2
+	return &OutputBuffer{headSize: headSize, tailSize: limit - headSize}
+}
+
+// NewTailBuffer creates a buffer keeping only the end of the output, up to limit bytes (no limit when limit is zero or negative)
+func NewTailBuffer(limit int) *OutputBuffer {
+	if limit <= 0 {
+		return &OutputBuffer{headSize: -1}
+	}
+	return &OutputBuffer{headSize: 0, tailSize: limit}
+}
+
+// Write always succeeds: the output exceeding the limit is dropped
+func (b *OutputBuffer) Write(p []byte) (int, error) {
+	written := len(p)
+	if b.headSize < 0 {
+		b.head = append(b.head, p...)
+		return written, nil
+	}
+	if free := b.headSize - len(b.head); free > 0 {
+		if free > len(p) {
+			free = len(p)
+		}
+		b.head = append(b.head, p[:free]...)
+		p = p[free:]
+	}
+	if len(p) > 0 {
+		b.tail = append(b.tail, p...)
+		// compact the tail once it has grown to twice its size
+		if excess := len(b.tail) - b.tailSize; excess > b.tailSize {
+			b.dropped += int64(excess)
+			b.tail = append(b.tail[:0], b.tail[excess:]...)
+		}
+	}
+	return written, nil
+}
+
+// Truncated returns true when some output was dropped
+func (b *OutputBuffer) Truncated() bool {
+	return b.dropped > 0 || len(b.tail) > b.tailSize
+}
+
+// String returns the output kept in the buffer. When truncated, the output is cut at line boundaries where possible
+// and a note tells how much was dropped.
+func (b *OutputBuffer) String() string {
+	if !b.Truncated() {
+		return string(b.head) + string(b.tail)
+	}
+	head, tail, dropped := b.head, b.tail, b.dropped
+	if excess := len(tail) - b.tailSize; excess > 0 {
+		tail, dropped = tail[excess:], dropped+int64(excess)
+	}
+	if index := bytes.LastIndexByte(head, '\n'); index >= 0 {
+		dropped += int64(len(head) - index - 1)
+		head = head[:index+1]
+	}
+	if index := bytes.IndexByte(tail, '\n'); index >= 0 && index < len(tail)-1 {
+		dropped += int64(index + 1)
+		tail = tail[index+1:]
+	}
+	note := fmt.Sprintf("[... %d bytes truncated ...]", dropped)
+	if len(head) > 0 && head[len(head)-1] != '\n' {
+		note = "\n" + note
+	}
+	return string(head) + note + "\n" + string(tail)
+}
@@ -0,0 +1,100 @@
+package shell
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutputBufferWithinLimit(t *testing.T) {
+	buffer := NewOutputBuffer(20)
+	_, _ = buffer.Write([]byte("line 1\n"))
+	_, _ = buffer.Write([]byte("line 2\n"))
+	assert.False(t, buffer.Truncated())
+	assert.Equal(t, "line 1\nline 2\n", buffer.String())
+}
+
+func TestOutputBufferWithoutLimit(t *testing.T) {
+	buffer := NewOutputBuffer(0)
+	content := strings.Repeat("0123456789\n", 1000)
+	n, err := buffer.Write([]byte(content))
+	assert.NoError(t, err)
+	assert.Equal(t, len(content), n)
+	assert.False(t, buffer.Truncated())
+	assert.Equal(t, content, buffer.String())
+}
+
+func TestOutputBufferKeepsHeadAndTail(t *testing.T) {
+	buffer := NewOutputBuffer(40)
+	for i := 1; i <= 1000; i++ {
+		line := fmt.Sprintf("line %d\n", i)
+		n, err := buffer.Write([]byte(line))
+		assert.NoError(t, err)
+		assert.Equal(t, len(line), n)
+	}
+	assert.True(t, buffer.Truncated())
+
+	output := buffer.String()
+	assert.True(t, strings.HasPrefix(output, "line 1\nline 2\n"), output)
+	assert.True(t, strings.HasSuffix(output, "line 999\nline 1000\n"), output)
+	assert.Contains(t, output, " bytes truncated ...]\n")
+	assert.NotContains(t, output, "line 500\n")
+	assert.Less(t, len(output), 80)
+
+	// the note counts all the bytes that were not kept
+	total := 0
+	for i := 1; i <= 1000; i++ {
+		total += len(fmt.Sprintf("line %d\n", i))
+	}
+	lines := strings.SplitAfter(output, "\n")
+	kept := 0
+	var dropped int
+	for _, line := range lines {
+		if strings.HasPrefix(line, "[... ") {
+			_, _ = fmt.Sscanf(line, "[... %d bytes truncated ...]", &dropped)
+			continue
+		}
+		kept += len(line)
+	}
+	assert.Equal(t, total, kept+dropped)
+}
+
+func TestOutputBufferWithoutNewLine(t *testing.T) {
+	buffer := NewOutputBuffer(10)
+	_, _ = buffer.Write([]byte(strings.Repeat("a", 10) + strings.Repeat("b", 10) + strings.Repeat("c", 10)))
+	assert.Equal(t, "aaaaa\n[... 20 bytes truncated ...]\nccccc", buffer.String())
+}
+
+func TestTailBuffer(t *testing.T) {
+	buffer := NewTailBuffer(10)
+	_, _ = buffer.Write([]byte("line 1\nline 2\n"))
+	_, _ = buffer.Write([]byte("line 3\n"))
+	assert.True(t, buffer.Truncated())
+	assert.Equal(t, "[... 14 bytes truncated ...]\nline 3\n", buffer.String())
+
+	buffer = NewTailBuffer(0)
+	_, _ = buffer.Write([]byte("line 1\nline 2\n"))
+	assert.False(t, buffer.Truncated())
+	assert.Equal(t, "line 1\nline 2\n", buffer.String())
+}
+
+func TestCapturedOutputLimit(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Test not running on this platform")
+	}
+	defer func(limit int) { CapturedOutputLimit = limit }(CapturedOutputLimit)
+	CapturedOutputLimit = 100
+
+	cmd := NewCommand("for i in $(seq 1 1000); do echo line $i >&2; done", nil)
+	cmd.Stderr = &strings.Builder{}
+	_, stderr, err := cmd.Run()
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(stderr, "line 1\n"))
+	assert.True(t, strings.HasSuffix(stderr, "line 1000\n"))
+	assert.Contains(t, stderr, "bytes truncated")
+	assert.Less(t, len(stderr), 150)
+	assert.Contains(t, cmd.Stderr.(*strings.Builder).String(), "line 500\n")
+}
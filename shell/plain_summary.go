@@ -17,7 +17,7 @@ var ScanBackupPlain ScanOutput = func(r io.Reader, summary *monitor.Summary, w i
 	if runtime.GOOS == "windows" {
 		eol = "\r\n"
 	}
-	rawBytes, unit, duration := 0.0, "", ""
+	rawBytes, unit, duration, snapshotID := 0.0, "", "", ""
 	scanner := bufio.NewScanner(r)
 	for scanner.Scan() {
 		w.Write([]byte(scanner.Text() + eol))
@@ -34,6 +34,11 @@ var ScanBackupPlain ScanOutput = func(r io.Reader, summary *monitor.Summary, w i
 		if n == 4 && err == nil {
 			summary.BytesTotal = unformatBytes(rawBytes, unit)
 		}
+
+		n, err = fmt.Sscanf(scanner.Text(), "snapshot %s saved", &snapshotID)
+		if n == 1 && err == nil {
+			summary.SnapshotID = snapshotID
+		}
 	}
 
 	if err := scanner.Err(); err != nil {
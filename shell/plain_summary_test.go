@@ -60,4 +60,5 @@ snapshot 07ab30a5 saved
 	assert.Equal(t, uint64(296503738), summary.BytesAdded)
 	assert.Equal(t, uint64(362919494), summary.BytesTotal)
 	assert.Equal(t, 223, summary.FilesTotal)
+	assert.Equal(t, "07ab30a5", summary.SnapshotID)
 }
@@ -5,27 +5,39 @@ import (
 	"io"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/creativeprojects/clog"
 	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/constants"
 	"github.com/creativeprojects/resticprofile/monitor"
+	"github.com/creativeprojects/resticprofile/monitor/audit"
 	"github.com/creativeprojects/resticprofile/shell"
+	"github.com/creativeprojects/resticprofile/util/redact"
 )
 
+// auditLog records every command started by resticprofile (when configured)
+var auditLog *audit.Log
+
 type shellCommandDefinition struct {
 	command     string
 	args        []string
 	publicArgs  []string
 	env         []string
+	dir         string
 	shell       []string
 	stdin       io.ReadCloser
 	stdout      io.Writer
 	stderr      io.Writer
+	rawStdout   bool // stdout receives data: confidential values must not be masked
 	dryRun      bool
 	sigChan     chan os.Signal
 	setPID      shell.SetPID
 	scanOutput  shell.ScanOutput
 	streamError []config.StreamErrorSection
+	cleanup     func()    // removes the temporary files used by the command (if any) once it has finished
+	capture     io.Writer // also receives the output of the command (if set)
+	process     config.ProcessSection
 }
 
 // newShellCommand creates a new shell command definition
@@ -50,6 +62,9 @@ func newShellCommand(command string, args, env, shell []string, dryRun bool, sig
 
 // runShellCommand instantiates a shell.Command and sends the information to run the shell command
 func runShellCommand(command shellCommandDefinition) (summary monitor.Summary, stderr string, err error) {
+	if command.cleanup != nil {
+		defer command.cleanup()
+	}
 	if command.dryRun {
 		clog.Infof("dry-run: %s %s", command.command, strings.Join(command.publicArgs, " "))
 	}
@@ -57,8 +72,18 @@ func runShellCommand(command shellCommandDefinition) (summary monitor.Summary, s
 	shellCmd := shell.NewSignalledCommand(command.command, command.args, command.sigChan)
 
 	shellCmd.Shell = command.shell
+	shellCmd.Dir = command.dir
 	shellCmd.Stdout = command.stdout
 	shellCmd.Stderr = command.stderr
+	shellCmd.RawStdout = command.rawStdout
+	if command.capture != nil {
+		if command.stdout != nil && !command.rawStdout {
+			shellCmd.Stdout = io.MultiWriter(command.stdout, command.capture)
+		}
+		if command.stderr != nil {
+			shellCmd.Stderr = io.MultiWriter(command.stderr, command.capture)
+		}
+	}
 
 	if command.dryRun {
 		shellBinary, args, commandErr := shellCmd.GetShellCommand()
@@ -85,6 +110,15 @@ func runShellCommand(command shellCommandDefinition) (summary monitor.Summary, s
 		shellCmd.Environ = append(shellCmd.Environ, command.env...)
 	}
 
+	// settings of the process
+	starter, err := newProcessStarter(command.process)
+	if err != nil {
+		return
+	}
+	if starter != nil {
+		shellCmd.Start = starter.start
+	}
+
 	// scan output
 	if command.scanOutput != nil {
 		shellCmd.ScanStdout = command.scanOutput
@@ -95,7 +129,12 @@ func runShellCommand(command shellCommandDefinition) (summary monitor.Summary, s
 		return
 	}
 
+	start := time.Now()
 	summary, stderr, err = shellCmd.Run()
+	if starter != nil {
+		err = starter.done(err)
+	}
+	auditCommand(command, start, err)
 	return
 }
 
@@ -113,7 +152,9 @@ func setupStreamErrorHandlers(command *shellCommandDefinition, shellCmd *shell.C
 			clog.Debugf(`starting stream error callback "%s" for line "%s"`, errorCmd.Command, line)
 			errorCmd.Stdin = strings.NewReader(line)
 
+			start := time.Now()
 			_, stderr, err := errorCmd.Run()
+			auditCommand(shellCommandDefinition{command: commandLine, env: errorCmd.Environ, dir: command.dir}, start, err)
 			if err != nil {
 				err = newCommandError(shellCommandDefinition{publicArgs: []string{commandLine}}, stderr, err)
 			}
@@ -128,3 +169,43 @@ func setupStreamErrorHandlers(command *shellCommandDefinition, shellCmd *shell.C
 	}
 	return nil
 }
+
+// auditCommand adds the command to the audit log. Confidential values are masked.
+func auditCommand(command shellCommandDefinition, start time.Time, err error) {
+	if auditLog == nil {
+		return
+	}
+	entry := audit.NewEntry()
+	entry.Time = start
+	entry.Duration = time.Since(start).Seconds()
+	entry.Executable = redact.String(command.command)
+	entry.Args = redact.Strings(command.publicArgs)
+	entry.Dir = command.dir
+	entry.Profile = lastEnvironmentValue(command.env, constants.EnvProfileName)
+	entry.Command = lastEnvironmentValue(command.env, constants.EnvProfileCommand)
+	if err != nil {
+		entry.ExitCode = -1
+		if exitErr, ok := asExitError(err); ok {
+			entry.ExitCode = exitErr.ExitCode()
+		}
+		entry.Error = redact.String(err.Error())
+	}
+	if auditErr := auditLog.Add(entry); auditErr != nil {
+		clog.Errorf("cannot write to audit log %q: %v", auditLog.Filename(), auditErr)
+	}
+}
+
+// auditExternalCommand adds a command started outside of the shell (volumes and password managers) to the audit log
+func auditExternalCommand(name string, args []string, start time.Time, err error) {
+	auditCommand(shellCommandDefinition{command: name, publicArgs: args}, start, err)
+}
+
+// lastEnvironmentValue returns the value of the last definition of the variable (the one the command receives)
+func lastEnvironmentValue(env []string, name string) string {
+	for i := len(env) - 1; i >= 0; i-- {
+		if strings.HasPrefix(env[i], name+"=") {
+			return strings.TrimPrefix(env[i], name+"=")
+		}
+	}
+	return ""
+}
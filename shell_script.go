@@ -0,0 +1,202 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/shell"
+	"github.com/creativeprojects/resticprofile/util"
+)
+
+var scriptNameReplacer = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// hookCommand is an entry of a run-* hook, ready to run
+type hookCommand struct {
+	commandLine string
+	dir         string   // working directory (current directory when empty)
+	env         []string // additional environment variables
+	cleanup     func()   // removes the temporary script file (if any) once the command has finished
+}
+
+// prepareShellCommand returns the command to run for an entry of a run-* hook.
+// An entry referencing a script of the profile ("script:name arguments") or spanning multiple lines is written
+// to a temporary file, which is removed by calling cleanup once the command has finished.
+func (r *resticWrapper) prepareShellCommand(entry string) (hook hookCommand, err error) {
+	hook.cleanup = func() {}
+	name, arguments, found := config.ParseScriptReference(entry)
+	content := ""
+	if found {
+		var script *config.ScriptSection
+		script, content, err = r.getScript(name, arguments)
+		if err != nil {
+			return
+		}
+		hook.dir = script.WorkingDirectory
+		hook.env = script.GetEnvironment()
+	} else if isMultiLine(entry) {
+		name, content = "inline", entry
+	} else {
+		hook.commandLine = entry
+		return
+	}
+
+	shellBinary, err := shell.FindShell(r.getShell())
+	if err != nil {
+		return
+	}
+	filename, err := writeScriptFile(name, scriptFileContent(content, shellBinary), shellBinary)
+	if err != nil {
+		err = fmt.Errorf("script %q: %w", name, err)
+		return
+	}
+	hook.cleanup = func() {
+		if err := os.Remove(filename); err != nil {
+			clog.Debugf("cannot remove script file: %s", err)
+		}
+	}
+
+	hook.commandLine = scriptCommandLine(filename, shellBinary)
+	if arguments != "" {
+		hook.commandLine += " " + arguments
+	}
+	clog.Debugf("running script %q from %q", name, filename)
+	return
+}
+
+// getScript returns the script of the profile and its content, after checking it receives enough arguments
+func (r *resticWrapper) getScript(name, arguments string) (*config.ScriptSection, string, error) {
+	script := r.profile.GetScript(name)
+	if script == nil {
+		return nil, "", fmt.Errorf("script %q is not defined in profile '%s'", name, r.profile.Name)
+	}
+	if count := len(splitArguments(arguments)); count < len(script.Parameters) {
+		return nil, "", fmt.Errorf("script %q expects %d argument(s) (%s) but received %d",
+			name, len(script.Parameters), strings.Join(script.Parameters, ", "), count)
+	}
+	content, err := script.Content()
+	if err != nil {
+		return nil, "", fmt.Errorf("script %q: %w", name, err)
+	}
+	return script, content, nil
+}
+
+// isMultiLine returns true when the shell command spans more than one line (ignoring leading and trailing blank lines)
+func isMultiLine(shellCommand string) bool {
+	return strings.ContainsAny(strings.TrimSpace(shellCommand), "\r\n")
+}
+
+// scriptFileContent prepares the content of the script file for the shell:
+//   - on unix shells, a script without shebang gets one to run with the configured shell
+//   - a windows batch file doesn't echo its commands, and cannot start with a shebang
+func scriptFileContent(content, shellBinary string) string {
+	hasShebang := strings.HasPrefix(content, "#!")
+	switch {
+	case isWindowsCmd(shellBinary):
+		if hasShebang {
+			_, content, _ = strings.Cut(content, "\n")
+		}
+		if !strings.HasPrefix(strings.ToLower(content), "@echo") {
+			content = "@echo off\n" + content
+		}
+	case isPowershell(shellBinary):
+		// a shebang is a comment for powershell
+	case !hasShebang:
+		content = "#!" + shellBinary + "\n" + content
+	}
+	return content
+}
+
+// writeScriptFile writes the content of the script in a new file of the temporary directory, only accessible by the current user
+func writeScriptFile(name, content, shellBinary string) (filename string, err error) {
+	pattern := "script-" + scriptNameReplacer.ReplaceAllString(name, "_") + "-*" + scriptFileExtension(shellBinary)
+	file, err := util.CreateTempFile(pattern)
+	if err != nil {
+		return
+	}
+	filename = file.Name()
+	if isWindowsCmd(shellBinary) {
+		content = strings.ReplaceAll(strings.ReplaceAll(content, "\r\n", "\n"), "\n", "\r\n")
+	}
+	_, err = file.WriteString(content)
+	if e := file.Close(); err == nil {
+		err = e
+	}
+	if err == nil {
+		err = os.Chmod(filename, 0o700)
+	}
+	if err != nil {
+		_ = os.Remove(filename)
+		filename = ""
+	}
+	return
+}
+
+// shellName returns the name of the shell binary without path nor extension (e.g. "bash", "powershell", "cmd")
+func shellName(shellBinary string) string {
+	name := strings.ToLower(path.Base(strings.ReplaceAll(shellBinary, `\`, "/")))
+	return strings.TrimSuffix(name, path.Ext(name))
+}
+
+func isWindowsCmd(shellBinary string) bool { return shellName(shellBinary) == "cmd" }
+
+func isPowershell(shellBinary string) bool {
+	name := shellName(shellBinary)
+	return name == "powershell" || name == "pwsh"
+}
+
+// scriptFileExtension returns the extension of a script file that the shell can run
+func scriptFileExtension(shellBinary string) string {
+	switch {
+	case isWindowsCmd(shellBinary):
+		return ".bat"
+	case isPowershell(shellBinary):
+		return ".ps1"
+	default:
+		return ".sh"
+	}
+}
+
+// scriptCommandLine returns the command line running the script file with the shell
+func scriptCommandLine(filename, shellBinary string) string {
+	if isPowershell(shellBinary) {
+		return fmt.Sprintf(`& "%s"`, filename)
+	}
+	return fmt.Sprintf(`"%s"`, filename)
+}
+
+// splitArguments splits a command line into arguments, keeping quoted arguments together
+func splitArguments(commandLine string) (arguments []string) {
+	var (
+		current strings.Builder
+		quote   rune
+		inArg   bool
+	)
+	for _, c := range commandLine {
+		switch {
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote != 0:
+			current.WriteRune(c)
+		case c == '"' || c == '\'':
+			quote, inArg = c, true
+		case c == ' ' || c == '\t':
+			if inArg {
+				arguments = append(arguments, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(c)
+			inArg = true
+		}
+	}
+	if inArg {
+		arguments = append(arguments, current.String())
+	}
+	return
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/term"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitArguments(t *testing.T) {
+	fixtures := []struct {
+		commandLine string
+		expected    []string
+	}{
+		{commandLine: "", expected: nil},
+		{commandLine: "  one  two\tthree ", expected: []string{"one", "two", "three"}},
+		{commandLine: `one "two three" 'four "five"'`, expected: []string{"one", "two three", `four "five"`}},
+		{commandLine: `empty "" end`, expected: []string{"empty", "", "end"}},
+	}
+	for _, fixture := range fixtures {
+		t.Run(fixture.commandLine, func(t *testing.T) {
+			assert.Equal(t, fixture.expected, splitArguments(fixture.commandLine))
+		})
+	}
+}
+
+func TestScriptFileForShell(t *testing.T) {
+	fixtures := []struct {
+		shell, extension, commandLine string
+	}{
+		{shell: "/bin/sh", extension: ".sh", commandLine: `"file"`},
+		{shell: "/usr/bin/bash", extension: ".sh", commandLine: `"file"`},
+		{shell: `C:\Windows\System32\cmd.exe`, extension: ".bat", commandLine: `"file"`},
+		{shell: "powershell.exe", extension: ".ps1", commandLine: `& "file"`},
+		{shell: "pwsh", extension: ".ps1", commandLine: `& "file"`},
+	}
+	for _, fixture := range fixtures {
+		t.Run(fixture.shell, func(t *testing.T) {
+			assert.Equal(t, fixture.extension, scriptFileExtension(fixture.shell))
+			assert.Equal(t, fixture.commandLine, scriptCommandLine("file", fixture.shell))
+		})
+	}
+}
+
+func TestIsMultiLine(t *testing.T) {
+	assert.False(t, isMultiLine("echo hello"))
+	assert.False(t, isMultiLine("\n echo hello\n\n"))
+	assert.True(t, isMultiLine("echo hello\necho world"))
+	assert.True(t, isMultiLine("echo hello\r\necho world\r\n"))
+}
+
+func TestScriptFileContent(t *testing.T) {
+	fixtures := []struct {
+		shell, content, expected string
+	}{
+		{shell: "/bin/sh", content: "echo hello\n", expected: "#!/bin/sh\necho hello\n"},
+		{shell: "/bin/bash", content: "#!/usr/bin/env python3\nprint(1)\n", expected: "#!/usr/bin/env python3\nprint(1)\n"},
+		{shell: "cmd.exe", content: "echo hello\n", expected: "@echo off\necho hello\n"},
+		{shell: "cmd.exe", content: "#!/bin/sh\n@ECHO ON\necho hello\n", expected: "@ECHO ON\necho hello\n"},
+		{shell: "powershell.exe", content: "#!/usr/bin/env pwsh\nWrite-Host hello\n", expected: "#!/usr/bin/env pwsh\nWrite-Host hello\n"},
+	}
+	for _, fixture := range fixtures {
+		t.Run(fixture.shell, func(t *testing.T) {
+			assert.Equal(t, fixture.expected, scriptFileContent(fixture.content, fixture.shell))
+		})
+	}
+}
+
+func TestPrepareShellCommand(t *testing.T) {
+	profile := config.NewProfile(nil, "TestPrepareShellCommand")
+	profile.Scripts = map[string]*config.ScriptSection{
+		"hello": {Run: "echo hello $1"},
+		"dump":  {Run: "echo dump", Parameters: []string{"database", "output"}},
+		"env": {
+			Run:              "echo $FIRST $SECOND",
+			WorkingDirectory: "/tmp",
+			Environment: map[string]config.ConfidentialValue{
+				"second": config.NewConfidentialValue("2"),
+				"first":  config.NewConfidentialValue("1"),
+			},
+		},
+	}
+	wrapper := newResticWrapper(nil, "echo", false, profile, "test", nil, nil)
+
+	t.Run("plain command", func(t *testing.T) {
+		hook, err := wrapper.prepareShellCommand("echo hello")
+		require.NoError(t, err)
+		hook.cleanup()
+		assert.Equal(t, "echo hello", hook.commandLine)
+	})
+
+	t.Run("script", func(t *testing.T) {
+		hook, err := wrapper.prepareShellCommand("script:hello 'big world'")
+		require.NoError(t, err)
+		require.True(t, strings.HasSuffix(hook.commandLine, " 'big world'"))
+
+		filename := strings.Trim(strings.TrimSuffix(hook.commandLine, " 'big world'"), `"`)
+		content, err := os.ReadFile(filename)
+		require.NoError(t, err)
+		assert.True(t, strings.HasSuffix(string(content), "echo hello $1"))
+		if runtime.GOOS != "windows" {
+			info, err := os.Stat(filename)
+			require.NoError(t, err)
+			assert.Equal(t, os.FileMode(0o700), info.Mode().Perm())
+		}
+
+		hook.cleanup()
+		assert.NoFileExists(t, filename)
+	})
+
+	t.Run("multi-line command", func(t *testing.T) {
+		hook, err := wrapper.prepareShellCommand("echo one\necho two\n")
+		require.NoError(t, err)
+		defer hook.cleanup()
+
+		content, err := os.ReadFile(strings.Trim(hook.commandLine, `"`))
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "echo one\necho two\n")
+	})
+
+	t.Run("working directory and environment", func(t *testing.T) {
+		hook, err := wrapper.prepareShellCommand("script:env")
+		require.NoError(t, err)
+		defer hook.cleanup()
+		assert.Equal(t, "/tmp", hook.dir)
+		assert.Equal(t, []string{"FIRST=1", "SECOND=2"}, hook.env)
+	})
+
+	t.Run("undefined script", func(t *testing.T) {
+		_, err := wrapper.prepareShellCommand("script:unknown")
+		assert.ErrorContains(t, err, `script "unknown" is not defined`)
+	})
+
+	t.Run("missing arguments", func(t *testing.T) {
+		_, err := wrapper.prepareShellCommand("script:dump mydb")
+		assert.ErrorContains(t, err, `expects 2 argument(s) (database, output) but received 1`)
+	})
+}
+
+func TestRunBeforeScript(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("script uses unix shell syntax")
+	}
+	buffer := &bytes.Buffer{}
+	term.SetOutput(buffer)
+	defer term.SetOutput(os.Stdout)
+
+	profile := config.NewProfile(nil, "TestRunBeforeScript")
+	profile.Scripts = map[string]*config.ScriptSection{
+		"hello": {Run: "echo hello $1\necho profile $PROFILE_NAME\n"},
+	}
+	profile.RunBefore = []string{"script:hello 'big world'"}
+
+	wrapper := newResticWrapper(nil, "echo", false, profile, "test", nil, nil)
+	err := wrapper.runProfile()
+	assert.NoError(t, err)
+	assert.Equal(t, "hello big world\nprofile TestRunBeforeScript\ntest\n", buffer.String())
+}
+
+func TestRunBeforeMultiLine(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("script uses unix shell syntax")
+	}
+	buffer := &bytes.Buffer{}
+	term.SetOutput(buffer)
+	defer term.SetOutput(os.Stdout)
+
+	profile := config.NewProfile(nil, "TestRunBeforeMultiLine")
+	profile.RunBefore = []string{"for i in 1 2; do\n  echo \"line $i\"\ndone\n"}
+
+	wrapper := newResticWrapper(nil, "echo", false, profile, "test", nil, nil)
+	err := wrapper.runProfile()
+	assert.NoError(t, err)
+	assert.Equal(t, "line 1\nline 2\ntest\n", buffer.String())
+}
+
+func TestRunBeforeScriptWithWorkingDirectory(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("script uses unix shell syntax")
+	}
+	buffer := &bytes.Buffer{}
+	term.SetOutput(buffer)
+	defer term.SetOutput(os.Stdout)
+
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+
+	profile := config.NewProfile(nil, "TestRunBeforeScriptWithWorkingDirectory")
+	profile.Scripts = map[string]*config.ScriptSection{
+		"where": {
+			Run:              `echo "$(pwd) $NAME"`,
+			WorkingDirectory: dir,
+			Environment:      map[string]config.ConfidentialValue{"name": config.NewConfidentialValue("value")},
+		},
+	}
+	profile.RunBefore = []string{"script:where", "echo $NAME"}
+
+	wrapper := newResticWrapper(nil, "echo", false, profile, "test", nil, nil)
+	err = wrapper.runProfile()
+	assert.NoError(t, err)
+	assert.Equal(t, dir+" value\n\ntest\n", buffer.String())
+}
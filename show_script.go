@@ -0,0 +1,243 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/constants"
+	"github.com/creativeprojects/resticprofile/filesearch"
+	"github.com/creativeprojects/resticprofile/platform"
+	"github.com/creativeprojects/resticprofile/shell"
+)
+
+const (
+	showFormatScript      = "script"
+	scriptShellSh         = "sh"
+	scriptShellPowershell = "powershell"
+)
+
+// showScriptOptions are the options of "show --format script"
+type showScriptOptions struct {
+	format  string
+	shell   string
+	command string
+	secrets bool
+	origin  bool
+}
+
+// parseShowArgs returns the options from the arguments of the show command
+func parseShowArgs(args []string) (options showScriptOptions, err error) {
+	options.shell = scriptShellSh
+	if platform.IsWindows() {
+		options.shell = scriptShellPowershell
+	}
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; arg {
+		case "--origin":
+			options.origin = true
+		case "--secrets":
+			options.secrets = true
+		case "--format", "--shell", "--command":
+			if i+1 >= len(args) {
+				return options, fmt.Errorf("missing value for %s", arg)
+			}
+			i++
+			switch arg {
+			case "--format":
+				options.format = args[i]
+			case "--shell":
+				options.shell = args[i]
+			case "--command":
+				options.command = args[i]
+			}
+		default:
+			return options, fmt.Errorf("unknown flag %s for show", arg)
+		}
+	}
+	if options.format != "" && options.format != showFormatScript {
+		return options, fmt.Errorf("invalid format %q: expected %s", options.format, showFormatScript)
+	}
+	if options.shell != scriptShellSh && options.shell != scriptShellPowershell {
+		return options, fmt.Errorf("invalid shell %q: expected %s or %s", options.shell, scriptShellSh, scriptShellPowershell)
+	}
+	if options.format != showFormatScript && (options.secrets || options.command != "") {
+		return options, errors.New("--secrets and --command can only be used with --format script")
+	}
+	return
+}
+
+// scriptCommand is a command of the script: a restic command or a hook
+type scriptCommand struct {
+	comment     string
+	commandLine string
+	ignoreError bool
+}
+
+// showScript writes the run of the profile command as a shell script: environment variables, hooks and restic commands
+func showScript(output io.Writer, global *config.Global, profile *config.Profile, options showScriptOptions) error {
+	command := options.command
+	if command == "" {
+		command = global.DefaultCommand
+	}
+	if profile.GetCommandAlias(command) != nil {
+		return fmt.Errorf("custom command %q cannot be shown in script form", command)
+	}
+
+	// the script may run on another host: keep the restic binary of the configuration even when it doesn't exist here
+	resticBinary := global.ResticBinary
+	if resticBinary == "" {
+		var err error
+		if resticBinary, err = filesearch.FindResticBinary(""); err != nil {
+			resticBinary = "restic"
+		}
+	}
+	wrapper := newResticWrapper(global, resticBinary, true, profile, command, nil, nil)
+
+	resticCommandLine := func(command string, args *shell.Args) string {
+		if !options.secrets {
+			args = config.GetNonConfidentialArgs(profile, args)
+		}
+		arguments := args.GetAll()
+		if filter := wrapper.getCommandArgumentsFilter(command); filter != nil {
+			arguments = filter(arguments, true)
+		}
+		return strings.Join(append([]string{scriptQuote(options.shell, resticBinary), command}, arguments...), " ")
+	}
+	hooks := func(comment string, commandLines []string) (commands []scriptCommand) {
+		for _, commandLine := range commandLines {
+			commands = append(commands, scriptCommand{comment: comment, commandLine: commandLine})
+		}
+		return
+	}
+
+	profileHooks, sectionHooks := profile.GetRunShellCommandsSections(command)
+	var main []scriptCommand
+	main = append(main, hooks("run-before (profile)", profileHooks.RunBefore)...)
+	if (global.Initialize || profile.Initialize) && command != constants.CommandInit {
+		main = append(main, scriptCommand{
+			comment:     "initialize the repository (if missing)",
+			commandLine: resticCommandLine(constants.CommandInit, profile.GetCommandFlags(constants.CommandInit)),
+			ignoreError: true,
+		})
+	}
+	main = append(main, hooks("run-before ("+command+")", sectionHooks.RunBefore)...)
+	args := profile.GetCommandFlags(command)
+	if command == constants.CommandBackup {
+		args.AddArgs(profile.GetBackupSource(), shell.ArgConfigBackupSource)
+	}
+	main = append(main, scriptCommand{comment: "restic " + command, commandLine: resticCommandLine(command, args)})
+	main = append(main, hooks("run-after ("+command+")", sectionHooks.RunAfter)...)
+	main = append(main, hooks("run-after (profile)", profileHooks.RunAfter)...)
+
+	var fail, finally []scriptCommand
+	fail = append(fail, hooks("run-after-fail ("+command+")", sectionHooks.RunAfterFail)...)
+	fail = append(fail, hooks("run-after-fail (profile)", profileHooks.RunAfterFail)...)
+	finally = append(finally, hooks("run-finally ("+command+")", sectionHooks.RunFinally)...)
+	finally = append(finally, hooks("run-finally (profile)", profileHooks.RunFinally)...)
+
+	env := scriptEnvironment(profile, command, options.secrets)
+	title := fmt.Sprintf("profile %q, command %q (generated by \"resticprofile show --format script\")", profile.Name, command)
+	if options.shell == scriptShellPowershell {
+		return writePowershellScript(output, title, env, main, fail, finally)
+	}
+	return writeShScript(output, title, env, main, fail, finally)
+}
+
+// scriptEnvironment returns the sorted environment variables (name, value) set by resticprofile for the profile
+func scriptEnvironment(profile *config.Profile, command string, secrets bool) (env [][2]string) {
+	for name, value := range profile.Environment {
+		if secrets {
+			env = append(env, [2]string{strings.ToUpper(name), value.Value()})
+		} else {
+			env = append(env, [2]string{strings.ToUpper(name), value.String()})
+		}
+	}
+	sort.Slice(env, func(i, j int) bool { return env[i][0] < env[j][0] })
+	env = append(env,
+		[2]string{constants.EnvProfileName, profile.Name},
+		[2]string{constants.EnvProfileCommand, command},
+	)
+	return
+}
+
+// scriptQuote quotes the value for the shell
+func scriptQuote(shell, value string) string {
+	if shell == scriptShellPowershell {
+		return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+	}
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+func writeShScript(output io.Writer, title string, env [][2]string, main, fail, finally []scriptCommand) error {
+	w := &strings.Builder{}
+	w.WriteString("#!/bin/sh\n# " + title + "\nset -e\n\n")
+	for _, variable := range env {
+		fmt.Fprintf(w, "export %s=%s\n", variable[0], scriptQuote(scriptShellSh, variable[1]))
+	}
+
+	if len(fail) > 0 || len(finally) > 0 {
+		w.WriteString("\non_exit() {\n\tstatus=$?\n\ttrap - EXIT\n")
+		if len(fail) > 0 {
+			w.WriteString("\tif [ \"$status\" -ne 0 ]; then\n")
+			writeScriptCommands(w, "\t\t", fail, " || true")
+			w.WriteString("\tfi\n")
+		}
+		writeScriptCommands(w, "\t", finally, " || true")
+		w.WriteString("\texit \"$status\"\n}\ntrap on_exit EXIT\n")
+	}
+
+	w.WriteString("\n")
+	for _, command := range main {
+		suffix := ""
+		if command.ignoreError {
+			suffix = " || true"
+		}
+		writeScriptCommands(w, "", []scriptCommand{command}, suffix)
+	}
+	_, err := io.WriteString(output, w.String())
+	return err
+}
+
+func writePowershellScript(output io.Writer, title string, env [][2]string, main, fail, finally []scriptCommand) error {
+	w := &strings.Builder{}
+	w.WriteString("# " + title + "\n$ErrorActionPreference = 'Stop'\n\n")
+	for _, variable := range env {
+		fmt.Fprintf(w, "$env:%s = %s\n", variable[0], scriptQuote(scriptShellPowershell, variable[1]))
+	}
+
+	w.WriteString("\ntry {\n")
+	for _, command := range main {
+		writeScriptCommands(w, "\t", []scriptCommand{command}, "")
+		if strings.HasPrefix(command.comment, "restic ") || command.ignoreError {
+			if command.ignoreError {
+				w.WriteString("\t$global:LASTEXITCODE = 0\n")
+			} else {
+				w.WriteString("\tif ($LASTEXITCODE -ne 0) { throw \"restic failed with exit code $LASTEXITCODE\" }\n")
+			}
+		}
+	}
+	w.WriteString("} catch {\n")
+	writeScriptCommands(w, "\t", fail, "")
+	w.WriteString("\tthrow\n} finally {\n")
+	writeScriptCommands(w, "\t", finally, "")
+	w.WriteString("}\n")
+	_, err := io.WriteString(output, w.String())
+	return err
+}
+
+func writeScriptCommands(w *strings.Builder, indent string, commands []scriptCommand, suffix string) {
+	for _, command := range commands {
+		fmt.Fprintf(w, "%s# %s\n", indent, command.comment)
+		lines := strings.Split(strings.TrimRight(command.commandLine, "\n"), "\n")
+		for i, line := range lines {
+			if i == len(lines)-1 {
+				line += suffix
+			}
+			w.WriteString(indent + line + "\n")
+		}
+	}
+}
@@ -48,11 +48,40 @@ func (l *Syslog) Close() error {
 
 var _ LogCloser = &Syslog{}
 
-func getSyslogHandler(flags commandLineFlags, scheme, hostPort string) (*Syslog, error) {
-	writer, err := syslog.Dial(scheme, hostPort, syslog.LOG_USER|syslog.LOG_NOTICE, constants.ApplicationName)
-	if err != nil {
-		return nil, fmt.Errorf("cannot open syslog logger: %w", err)
+// syslogSender sends log records to a syslog server. The connection is only opened when sending,
+// so that an unavailable server doesn't delay the start of resticprofile.
+type syslogSender struct {
+	scheme, hostPort string
+	handler          *Syslog
+}
+
+func (s *syslogSender) Send(records []logRecord) error {
+	if s.handler == nil {
+		writer, err := syslog.Dial(s.scheme, s.hostPort, syslog.LOG_USER|syslog.LOG_NOTICE, constants.ApplicationName)
+		if err != nil {
+			return fmt.Errorf("cannot open syslog logger: %w", err)
+		}
+		s.handler = NewSyslogHandler(writer)
+	}
+	for _, record := range records {
+		if err := s.handler.LogEntry(clog.LogEntry{Level: record.Level, Format: "%s", Values: []any{record.Message}}); err != nil {
+			_ = s.Close()
+			return err
+		}
 	}
-	handler := NewSyslogHandler(writer)
-	return handler, nil
+	return nil
+}
+
+func (s *syslogSender) Close() (err error) {
+	if s.handler != nil {
+		err = s.handler.Close()
+		s.handler = nil
+	}
+	return
+}
+
+var _ logSender = &syslogSender{}
+
+func getSyslogHandler(flags commandLineFlags, scheme, hostPort string) (LogCloser, error) {
+	return newLogShipper(&syslogSender{scheme: scheme, hostPort: hostPort}, flags.log), nil
 }
@@ -86,6 +86,12 @@ func OsStdoutIsTerminal() bool {
 	return terminal.IsTerminal(fd)
 }
 
+// OsStdinIsTerminal returns true as os.Stdin is a terminal session
+func OsStdinIsTerminal() bool {
+	fd := int(os.Stdin.Fd())
+	return terminal.IsTerminal(fd)
+}
+
 // OsStdoutIsTerminal returns true as os.Stdout is a terminal session
 func OsStdoutTerminalSize() (width, height int) {
 	fd := int(os.Stdout.Fd())
@@ -3,27 +3,48 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"io"
+	"net/http"
+	"net/url"
 	"os"
+	"path/filepath"
 	"runtime"
+	"strings"
 
 	"github.com/creativeprojects/clog"
 	"github.com/creativeprojects/go-selfupdate"
+	"github.com/creativeprojects/go-selfupdate/update"
 	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/constants"
 	"github.com/creativeprojects/resticprofile/term"
 )
 
+const (
+	updateChannelStable = "stable"
+	updateChannelBeta   = "beta"
+	checksumsFilename   = "checksums.txt"
+)
+
 func init() {
 	def := ownCommand{
 		name:              "self-update",
 		description:       "update to latest resticprofile",
-		longDescription:   "The \"self-update\" command checks for the latest resticprofile release and updates the current application binary if a newer version is available",
+		longDescription:   "The \"self-update\" command checks for the latest resticprofile release and updates the current application binary if a newer version is available.\n\nThe archive of the release is verified with the checksums file of the release (and its signature when a public key is given). With \"--from-file\", the update is installed from a release archive downloaded beforehand (air-gapped hosts).\n\nWith \"--check-only\", the exit code is 7 when a newer version is available.",
 		action:            selfUpdate,
 		needConfiguration: false,
-		flags:             map[string]string{"-q, --quiet": "update without confirmation prompt"},
+		flags: map[string]string{
+			"-q, --quiet":             "update without confirmation prompt",
+			"--check-only":            "only check if a newer version is available (exit code 7 when there is one)",
+			"--channel <stable|beta>": "update to the latest stable release (default) or to the latest pre-release",
+			"--proxy <url>":           "HTTP proxy to use (instead of HTTPS_PROXY from the environment)",
+			"--public-key <file>":     "armored PGP public key verifying the signature of the checksums file",
+			"--from-file <archive>":   "install the release archive instead of downloading it",
+			"--checksums <file>":      "checksums file of the release archive (required with --from-file)",
+		},
 	}
 	ownCommands.Register([]ownCommand{
 		def,
@@ -32,26 +53,138 @@ func init() {
 	config.ExcludeProfileSection(def.name)
 }
 
+// selfUpdateOptions are the options of the self-update command
+type selfUpdateOptions struct {
+	quiet, debug, checkOnly bool
+	channel                 string
+	proxy                   string
+	publicKey               string
+	fromFile                string
+	checksums               string
+}
+
 func selfUpdate(_ io.Writer, request commandRequest) error {
-	quiet := request.flags.quiet
-	if !quiet && len(request.args) > 0 && (request.args[0] == "-q" || request.args[0] == "--quiet") {
-		quiet = true
+	options, err := parseSelfUpdateArgs(request.args)
+	if err != nil {
+		return newExitCodeError(constants.ExitCodeCommandLine, err)
 	}
-	err := confirmAndSelfUpdate(quiet, request.flags.verbose, version, true)
+	options.quiet = options.quiet || request.flags.quiet
+	options.debug = request.flags.verbose
+
+	exe, err := os.Executable()
 	if err != nil {
-		return err
+		return errors.New("could not locate executable path")
+	}
+	if manager, command := packageManagerOf(exe); manager != "" && !options.checkOnly {
+		return fmt.Errorf("resticprofile was installed with %s: please use %q to update it", manager, command)
+	}
+	if options.fromFile != "" {
+		return updateFromFile(options, exe)
+	}
+	return confirmAndSelfUpdate(options, version, exe)
+}
+
+// parseSelfUpdateArgs returns the options from the arguments of the self-update command
+func parseSelfUpdateArgs(args []string) (options selfUpdateOptions, err error) {
+	options.channel = updateChannelStable
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; arg {
+		case "-q", "--quiet":
+			options.quiet = true
+		case "--check-only":
+			options.checkOnly = true
+		case "--channel", "--proxy", "--public-key", "--from-file", "--checksums":
+			if i+1 >= len(args) {
+				return options, fmt.Errorf("missing value for %s", arg)
+			}
+			i++
+			value := args[i]
+			switch arg {
+			case "--channel":
+				options.channel = value
+			case "--proxy":
+				options.proxy = value
+			case "--public-key":
+				options.publicKey = value
+			case "--from-file":
+				options.fromFile = value
+			case "--checksums":
+				options.checksums = value
+			}
+		default:
+			return options, fmt.Errorf("unknown flag %s for self-update", arg)
+		}
+	}
+	if options.channel != updateChannelStable && options.channel != updateChannelBeta {
+		return options, fmt.Errorf("invalid channel %q: expected %s or %s", options.channel, updateChannelStable, updateChannelBeta)
 	}
+	if options.fromFile != "" && options.checksums == "" {
+		return options, errors.New("the checksums file of the release archive is required with --from-file")
+	}
+	if options.fromFile != "" && options.checkOnly {
+		return options, errors.New("--check-only cannot be used with --from-file")
+	}
+	return
+}
+
+// packageManagerOf returns the package manager that installed the executable (and the command updating it) if any
+func packageManagerOf(exe string) (manager, command string) {
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+	exe = strings.ToLower(filepath.ToSlash(exe))
+	switch {
+	case strings.Contains(exe, "/cellar/") || strings.Contains(exe, "/homebrew/") || strings.Contains(exe, "/linuxbrew/"):
+		return "Homebrew", "brew upgrade resticprofile"
+	case strings.Contains(exe, "/scoop/apps/"):
+		return "Scoop", "scoop update resticprofile"
+	}
+	return
+}
+
+// setUpdateProxy sets the proxy of the HTTP client downloading the releases
+func setUpdateProxy(proxy string) error {
+	proxyURL, err := url.Parse(proxy)
+	if err != nil || proxyURL.Host == "" {
+		return fmt.Errorf("invalid proxy URL %q", proxy)
+	}
+	transport, ok := http.DefaultTransport.(*http.Transport)
+	if !ok {
+		return errors.New("cannot set the proxy of the HTTP client")
+	}
+	transport.Proxy = http.ProxyURL(proxyURL)
 	return nil
 }
 
-func confirmAndSelfUpdate(quiet, debug bool, version string, prerelease bool) error {
-	if debug {
+// newUpdateValidator returns the validator of the release archive: the checksums file, signed when a public key is given
+func newUpdateValidator(publicKey string) (selfupdate.Validator, error) {
+	if publicKey == "" {
+		return &selfupdate.ChecksumValidator{UniqueFilename: checksumsFilename}, nil
+	}
+	key, err := os.ReadFile(publicKey)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read public key: %w", err)
+	}
+	return selfupdate.NewChecksumWithPGPValidator(checksumsFilename, key), nil
+}
+
+func confirmAndSelfUpdate(options selfUpdateOptions, version, exe string) error {
+	if options.debug {
 		selfupdate.SetLogger(clog.NewStandardLogger(clog.LevelDebug, clog.GetDefaultLogger()))
 	}
+	if options.proxy != "" {
+		if err := setUpdateProxy(options.proxy); err != nil {
+			return newExitCodeError(constants.ExitCodeCommandLine, err)
+		}
+	}
+	validator, err := newUpdateValidator(options.publicKey)
+	if err != nil {
+		return err
+	}
 	updater, _ := selfupdate.NewUpdater(
 		selfupdate.Config{
-			Validator:  &selfupdate.ChecksumValidator{UniqueFilename: "checksums.txt"},
-			Prerelease: prerelease,
+			Validator:  validator,
+			Prerelease: options.channel == updateChannelBeta,
 		})
 	latest, found, err := updater.DetectLatest(context.Background(), selfupdate.NewRepositorySlug("creativeprojects", "resticprofile"))
 	if err != nil {
@@ -66,19 +199,65 @@ func confirmAndSelfUpdate(quiet, debug bool, version string, prerelease bool) er
 		return nil
 	}
 
+	if options.checkOnly {
+		return newExitCodeError(constants.ExitCodeUpdateAvailable, fmt.Errorf("version %s is available (current version is %s)", latest.Version(), version))
+	}
+
 	// don't ask in quiet mode
-	if !quiet && !term.AskYesNo(os.Stdin, fmt.Sprintf("Do you want to update to version %s", latest.Version()), true) {
+	if !options.quiet && !term.AskYesNo(os.Stdin, fmt.Sprintf("Do you want to update to version %s", latest.Version()), true) {
 		fmt.Println("Never mind")
 		return nil
 	}
 
-	exe, err := os.Executable()
-	if err != nil {
-		return errors.New("could not locate executable path")
-	}
 	if err := updater.UpdateTo(context.Background(), latest, exe); err != nil {
 		return fmt.Errorf("unable to update binary: %w", err)
 	}
 	clog.Infof("Successfully updated to version %s", latest.Version())
 	return nil
 }
+
+// updateFromFile replaces the executable with the one from the release archive, after verifying the archive with
+// the checksums file (and the signature of the checksums file when a public key is given)
+func updateFromFile(options selfUpdateOptions, exe string) error {
+	archive, err := os.ReadFile(options.fromFile)
+	if err != nil {
+		return fmt.Errorf("cannot read release archive: %w", err)
+	}
+	checksums, err := os.ReadFile(options.checksums)
+	if err != nil {
+		return fmt.Errorf("cannot read checksums file: %w", err)
+	}
+	if options.publicKey != "" {
+		key, err := os.ReadFile(options.publicKey)
+		if err != nil {
+			return fmt.Errorf("cannot read public key: %w", err)
+		}
+		signature, err := os.ReadFile(options.checksums + ".asc")
+		if err != nil {
+			return fmt.Errorf("cannot read signature of the checksums file: %w", err)
+		}
+		validator := new(selfupdate.PGPValidator).WithArmoredKeyRing(key)
+		if err = validator.Validate(filepath.Base(options.checksums), checksums, signature); err != nil {
+			return fmt.Errorf("invalid signature of the checksums file: %w", err)
+		}
+	}
+	validator := &selfupdate.ChecksumValidator{UniqueFilename: filepath.Base(options.checksums)}
+	if err = validator.Validate(filepath.Base(options.fromFile), archive, checksums); err != nil {
+		return fmt.Errorf("invalid release archive: %w", err)
+	}
+
+	if !options.quiet && !term.AskYesNo(os.Stdin, fmt.Sprintf("Do you want to update from %s", filepath.Base(options.fromFile)), true) {
+		fmt.Println("Never mind")
+		return nil
+	}
+
+	asset, err := selfupdate.DecompressCommand(bytes.NewReader(archive), options.fromFile, filepath.Base(exe), runtime.GOOS, runtime.GOARCH)
+	if err != nil {
+		return err
+	}
+	if err = update.Apply(asset, update.Options{TargetPath: exe}); err != nil {
+		return fmt.Errorf("unable to update binary: %w", err)
+	}
+	clog.Infof("Successfully updated from %s", filepath.Base(options.fromFile))
+	return nil
+}
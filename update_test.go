@@ -3,12 +3,20 @@
 package main
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/creativeprojects/clog"
 	"github.com/creativeprojects/go-selfupdate"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestUpdate(t *testing.T) {
@@ -18,8 +26,102 @@ func TestUpdate(t *testing.T) {
 	clog.SetTestLog(t)
 	defer clog.CloseTestLog()
 
-	err := confirmAndSelfUpdate(true, true, "0.0.1", false)
+	exe, err := os.Executable()
+	require.NoError(t, err)
+	err = confirmAndSelfUpdate(selfUpdateOptions{quiet: true, debug: true, channel: updateChannelStable}, "0.0.1", exe)
 	assert.Error(t, err)
 	assert.Truef(t, errors.Is(err, selfupdate.ErrExecutableNotFoundInArchive), "error returned isn't wrapping %q but is instead: %q", selfupdate.ErrExecutableNotFoundInArchive, err)
 	assert.Contains(t, err.Error(), "resticprofile.test")
 }
+
+func TestParseSelfUpdateArgs(t *testing.T) {
+	options, err := parseSelfUpdateArgs(nil)
+	require.NoError(t, err)
+	assert.Equal(t, selfUpdateOptions{channel: updateChannelStable}, options)
+
+	options, err = parseSelfUpdateArgs([]string{"-q", "--check-only", "--channel", "beta", "--proxy", "http://proxy:3128", "--public-key", "key.asc"})
+	require.NoError(t, err)
+	assert.Equal(t, selfUpdateOptions{quiet: true, checkOnly: true, channel: updateChannelBeta, proxy: "http://proxy:3128", publicKey: "key.asc"}, options)
+
+	options, err = parseSelfUpdateArgs([]string{"--from-file", "archive.tar.gz", "--checksums", "checksums.txt"})
+	require.NoError(t, err)
+	assert.Equal(t, "archive.tar.gz", options.fromFile)
+	assert.Equal(t, "checksums.txt", options.checksums)
+
+	for _, args := range [][]string{
+		{"--channel", "nightly"},
+		{"--channel"},
+		{"--unknown"},
+		{"--from-file", "archive.tar.gz"},
+		{"--from-file", "archive.tar.gz", "--checksums", "checksums.txt", "--check-only"},
+	} {
+		_, err = parseSelfUpdateArgs(args)
+		assert.Error(t, err, args)
+	}
+}
+
+func TestPackageManagerOf(t *testing.T) {
+	manager, command := packageManagerOf("/opt/homebrew/Cellar/resticprofile/0.20.0/bin/resticprofile")
+	assert.Equal(t, "Homebrew", manager)
+	assert.Equal(t, "brew upgrade resticprofile", command)
+
+	manager, _ = packageManagerOf(`C:\Users\user\scoop\apps\resticprofile\current\resticprofile.exe`)
+	if filepath.Separator == '\\' {
+		assert.Equal(t, "Scoop", manager)
+	}
+
+	manager, _ = packageManagerOf("/usr/local/bin/resticprofile")
+	assert.Empty(t, manager)
+}
+
+func TestSetUpdateProxy(t *testing.T) {
+	assert.Error(t, setUpdateProxy("not a url"))
+}
+
+func TestUpdateFromFile(t *testing.T) {
+	clog.SetTestLog(t)
+	defer clog.CloseTestLog()
+
+	dir := t.TempDir()
+	exe := filepath.Join(dir, "resticprofile")
+	require.NoError(t, os.WriteFile(exe, []byte("old version"), 0o755))
+
+	// release archive containing the new executable
+	buffer := &bytes.Buffer{}
+	gz := gzip.NewWriter(buffer)
+	archive := tar.NewWriter(gz)
+	content := []byte("new version")
+	require.NoError(t, archive.WriteHeader(&tar.Header{Name: "resticprofile", Mode: 0o755, Size: int64(len(content))}))
+	_, err := archive.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, archive.Close())
+	require.NoError(t, gz.Close())
+	archiveFile := filepath.Join(dir, "resticprofile_0.99.0_linux_amd64.tar.gz")
+	require.NoError(t, os.WriteFile(archiveFile, buffer.Bytes(), 0o644))
+
+	options := selfUpdateOptions{quiet: true, fromFile: archiveFile, checksums: filepath.Join(dir, "checksums.txt")}
+
+	t.Run("invalid checksum", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(options.checksums, []byte("0000  resticprofile_0.99.0_linux_amd64.tar.gz\n"), 0o644))
+		assert.ErrorContains(t, updateFromFile(options, exe), "invalid release archive")
+		current, err := os.ReadFile(exe)
+		require.NoError(t, err)
+		assert.Equal(t, "old version", string(current))
+	})
+
+	t.Run("valid checksum", func(t *testing.T) {
+		sum := sha256.Sum256(buffer.Bytes())
+		require.NoError(t, os.WriteFile(options.checksums, []byte(hex.EncodeToString(sum[:])+"  resticprofile_0.99.0_linux_amd64.tar.gz\n"), 0o644))
+		require.NoError(t, updateFromFile(options, exe))
+		current, err := os.ReadFile(exe)
+		require.NoError(t, err)
+		assert.Equal(t, "new version", string(current))
+	})
+
+	t.Run("missing signature", func(t *testing.T) {
+		options := options
+		options.publicKey = filepath.Join(dir, "key.asc")
+		require.NoError(t, os.WriteFile(options.publicKey, []byte("key"), 0o644))
+		assert.ErrorContains(t, updateFromFile(options, exe), "cannot read signature of the checksums file")
+	})
+}
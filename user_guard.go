@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"strings"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/platform"
+	"github.com/creativeprojects/resticprofile/win"
+)
+
+// checkProfileUser returns an error when the current user is not allowed to run the profile ("require-user" and "forbid-root")
+func checkProfileUser(profile *config.Profile) error {
+	if profile.RequireUser == "" && !profile.ForbidRoot {
+		return nil
+	}
+	current, err := user.Current()
+	if err != nil {
+		return fmt.Errorf("profile '%s': cannot check the user running the profile: %w", profile.Name, err)
+	}
+	return checkUser(profile, current, isRootUser())
+}
+
+func checkUser(profile *config.Profile, current *user.User, root bool) error {
+	if profile.ForbidRoot && root {
+		return fmt.Errorf("profile '%s' cannot run as %s (forbid-root): please start resticprofile as another user", profile.Name, rootName())
+	}
+	if profile.RequireUser != "" && !isUser(current, profile.RequireUser) {
+		return fmt.Errorf("profile '%s' must run as user '%s' (require-user), not as '%s'", profile.Name, profile.RequireUser, current.Username)
+	}
+	return nil
+}
+
+// isUser returns true when the name is the name or the ID of the user. On Windows, the name can omit the domain.
+func isUser(current *user.User, name string) bool {
+	if name == current.Username || name == current.Uid {
+		return true
+	}
+	if platform.IsWindows() {
+		username := current.Username
+		if _, shortName, found := strings.Cut(username, `\`); found && !strings.Contains(name, `\`) {
+			username = shortName
+		}
+		return strings.EqualFold(name, username)
+	}
+	return false
+}
+
+func isRootUser() bool {
+	if platform.IsWindows() {
+		return win.IsElevated()
+	}
+	return os.Geteuid() == 0
+}
+
+func rootName() string {
+	if platform.IsWindows() {
+		return "an elevated administrator"
+	}
+	return "root"
+}
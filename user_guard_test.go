@@ -0,0 +1,47 @@
+package main
+
+import (
+	"os/user"
+	"testing"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckUser(t *testing.T) {
+	backup := &user.User{Username: "backup", Uid: "1001"}
+	root := &user.User{Username: "root", Uid: "0"}
+
+	testData := []struct {
+		name        string
+		requireUser string
+		forbidRoot  bool
+		current     *user.User
+		root        bool
+		message     string
+	}{
+		{name: "no restriction", current: root, root: true},
+		{name: "required user", requireUser: "backup", current: backup},
+		{name: "required user ID", requireUser: "1001", current: backup},
+		{name: "required root", requireUser: "root", current: root, root: true},
+		{name: "not root", forbidRoot: true, current: backup},
+		{name: "wrong user", requireUser: "backup", current: root, root: true, message: "profile 'test' must run as user 'backup' (require-user), not as 'root'"},
+		{name: "wrong user ID", requireUser: "1002", current: backup, message: "profile 'test' must run as user '1002' (require-user), not as 'backup'"},
+		{name: "forbidden root", forbidRoot: true, current: root, root: true, message: "profile 'test' cannot run as " + rootName() + " (forbid-root)"},
+	}
+	for _, testItem := range testData {
+		t.Run(testItem.name, func(t *testing.T) {
+			profile := &config.Profile{Name: "test", RequireUser: testItem.requireUser, ForbidRoot: testItem.forbidRoot}
+			err := checkUser(profile, testItem.current, testItem.root)
+			if testItem.message == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, testItem.message)
+			}
+		})
+	}
+}
+
+func TestCheckProfileUserWithoutRestriction(t *testing.T) {
+	assert.NoError(t, checkProfileUser(&config.Profile{Name: "test"}))
+}
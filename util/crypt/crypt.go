@@ -0,0 +1,113 @@
+// Package crypt encrypts the files written by resticprofile about its runs (status and history files) with AES-256-GCM
+package crypt
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Prefix starts all the encrypted data
+const Prefix = "aes256gcm:"
+
+// MinKeySize is the minimum size of a key: the key must be random, it is not derived from a password
+const MinKeySize = 16
+
+var (
+	ErrNoKey      = errors.New("the content is encrypted but no encryption key is configured")
+	ErrInvalidKey = errors.New("cannot decrypt the content: wrong encryption key or corrupted data")
+)
+
+// Key encrypts and decrypts data
+type Key struct {
+	aead cipher.AEAD
+}
+
+// NewKey creates a key from a random secret of at least MinKeySize bytes (leading and trailing spaces are ignored)
+func NewKey(secret []byte) (*Key, error) {
+	secret = bytes.TrimSpace(secret)
+	if len(secret) < MinKeySize {
+		return nil, fmt.Errorf("encryption key is too short: %d bytes, expected at least %d", len(secret), MinKeySize)
+	}
+	sum := sha256.Sum256(secret)
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Key{aead: aead}, nil
+}
+
+// LoadKeyFile creates a key from the content of the file
+func LoadKeyFile(filename string) (*Key, error) {
+	secret, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	key, err := NewKey(secret)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return key, nil
+}
+
+// Encrypt returns the encrypted data as a single line of text (without line feed)
+func (k *Key) Encrypt(plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, k.aead.NonceSize(), k.aead.NonceSize()+len(plaintext)+k.aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	sealed := k.aead.Seal(nonce, nonce, plaintext, nil)
+	encrypted := make([]byte, len(Prefix)+base64.StdEncoding.EncodedLen(len(sealed)))
+	copy(encrypted, Prefix)
+	base64.StdEncoding.Encode(encrypted[len(Prefix):], sealed)
+	return encrypted, nil
+}
+
+// Decrypt returns the plain content of data encrypted with Encrypt
+func (k *Key) Decrypt(data []byte) ([]byte, error) {
+	data = bytes.TrimSpace(data)
+	if !IsEncrypted(data) {
+		return nil, errors.New("the content is not encrypted")
+	}
+	sealed := make([]byte, base64.StdEncoding.DecodedLen(len(data)-len(Prefix)))
+	n, err := base64.StdEncoding.Decode(sealed, data[len(Prefix):])
+	if err != nil {
+		return nil, ErrInvalidKey
+	}
+	sealed = sealed[:n]
+	if len(sealed) < k.aead.NonceSize() {
+		return nil, ErrInvalidKey
+	}
+	plaintext, err := k.aead.Open(nil, sealed[:k.aead.NonceSize()], sealed[k.aead.NonceSize():], nil)
+	if err != nil {
+		return nil, ErrInvalidKey
+	}
+	return plaintext, nil
+}
+
+// IsEncrypted returns true when the data was encrypted with Encrypt
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(data), []byte(Prefix))
+}
+
+var defaultKey *Key
+
+// SetDefaultKey sets the key used for the status and history files (nil for no encryption)
+func SetDefaultKey(key *Key) {
+	defaultKey = key
+}
+
+// DefaultKey returns the key used for the status and history files, or nil when they're not encrypted
+func DefaultKey() *Key {
+	return defaultKey
+}
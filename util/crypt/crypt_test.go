@@ -0,0 +1,70 @@
+package crypt
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptAndDecrypt(t *testing.T) {
+	key, err := NewKey([]byte("0123456789abcdef\n"))
+	require.NoError(t, err)
+
+	plaintext := []byte(`{"profile":"test"}`)
+	encrypted, err := key.Encrypt(plaintext)
+	require.NoError(t, err)
+	assert.True(t, IsEncrypted(encrypted))
+	assert.False(t, IsEncrypted(plaintext))
+	assert.NotContains(t, string(encrypted), "profile")
+	assert.False(t, bytes.ContainsAny(encrypted, "\r\n"))
+
+	// a new nonce is used each time
+	again, err := key.Encrypt(plaintext)
+	require.NoError(t, err)
+	assert.NotEqual(t, encrypted, again)
+
+	decrypted, err := key.Decrypt(append(encrypted, '\n'))
+	require.NoError(t, err)
+	assert.Equal(t, plaintext, decrypted)
+
+	_, err = key.Decrypt(plaintext)
+	assert.Error(t, err)
+}
+
+func TestDecryptWithWrongKey(t *testing.T) {
+	key, err := NewKey([]byte("0123456789abcdef"))
+	require.NoError(t, err)
+	other, err := NewKey([]byte("fedcba9876543210"))
+	require.NoError(t, err)
+
+	encrypted, err := key.Encrypt([]byte("content"))
+	require.NoError(t, err)
+	_, err = other.Decrypt(encrypted)
+	assert.ErrorIs(t, err, ErrInvalidKey)
+
+	_, err = key.Decrypt([]byte(Prefix + "not base64!"))
+	assert.ErrorIs(t, err, ErrInvalidKey)
+	_, err = key.Decrypt([]byte(Prefix + "YWJj"))
+	assert.ErrorIs(t, err, ErrInvalidKey)
+}
+
+func TestLoadKeyFile(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "key")
+	require.NoError(t, os.WriteFile(filename, []byte("0123456789abcdef0123456789abcdef\n"), 0o600))
+	key, err := LoadKeyFile(filename)
+	require.NoError(t, err)
+	assert.NotNil(t, key)
+
+	short := filepath.Join(dir, "short")
+	require.NoError(t, os.WriteFile(short, []byte("  short  \n"), 0o600))
+	_, err = LoadKeyFile(short)
+	assert.ErrorContains(t, err, "too short")
+
+	_, err = LoadKeyFile(filepath.Join(dir, "missing"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -0,0 +1,38 @@
+package filelock
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// retryDelay is the delay between two attempts to lock the file
+const retryDelay = 20 * time.Millisecond
+
+// ErrLocked is returned when another process holds the lock
+var ErrLocked = errors.New("file is locked by another process")
+
+// Lock waits for an exclusive advisory lock on "<filename>.lock". The lock is held until unlock is called.
+// The lock file is left behind on purpose: deleting it would allow two processes to lock two different files.
+func Lock(filename string, timeout time.Duration) (unlock func(), err error) {
+	file, err := os.OpenFile(filename+".lock", os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open lock file: %w", err)
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		err = tryLockFile(file)
+		if err == nil {
+			return func() {
+				_ = unlockFile(file)
+				_ = file.Close()
+			}, nil
+		}
+		if !errors.Is(err, ErrLocked) || time.Now().After(deadline) {
+			_ = file.Close()
+			return nil, fmt.Errorf("cannot lock %s: %w", file.Name(), err)
+		}
+		time.Sleep(retryDelay)
+	}
+}
@@ -0,0 +1,26 @@
+package filelock
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLock(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "status.json")
+
+	unlock, err := Lock(filename, time.Second)
+	require.NoError(t, err)
+	assert.FileExists(t, filename+".lock")
+
+	_, err = Lock(filename, 50*time.Millisecond)
+	assert.ErrorIs(t, err, ErrLocked)
+
+	unlock()
+	unlock, err = Lock(filename, 50*time.Millisecond)
+	require.NoError(t, err)
+	unlock()
+}
@@ -0,0 +1,160 @@
+package util
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// SizeUnitsIEC formats sizes with binary units (1 KiB = 1024 bytes)
+	SizeUnitsIEC = "iec"
+	// SizeUnitsSI formats sizes with decimal units (1 kB = 1000 bytes)
+	SizeUnitsSI = "si"
+
+	// DurationStyleCompact formats durations like "1h2m3s"
+	DurationStyleCompact = "compact"
+	// DurationStyleLong formats durations like "1 hour 2 minutes 3 seconds"
+	DurationStyleLong = "long"
+	// DurationStyleClock formats durations like "1:02:03"
+	DurationStyleClock = "clock"
+)
+
+var (
+	byteUnits     = []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+	siByteUnits   = []string{"B", "kB", "MB", "GB", "TB", "PB", "EB"}
+	sizeUnits     = SizeUnitsIEC
+	durationStyle = DurationStyleCompact
+)
+
+// SetSizeUnits selects the units used by FormatBytes: SizeUnitsIEC (default) or SizeUnitsSI
+func SetSizeUnits(units string) error {
+	switch units {
+	case "", SizeUnitsIEC:
+		sizeUnits = SizeUnitsIEC
+	case SizeUnitsSI:
+		sizeUnits = SizeUnitsSI
+	default:
+		return fmt.Errorf("unknown size units %q, expected %q or %q", units, SizeUnitsIEC, SizeUnitsSI)
+	}
+	return nil
+}
+
+// SetDurationStyle selects the style used by FormatDuration: DurationStyleCompact (default), DurationStyleLong or DurationStyleClock
+func SetDurationStyle(style string) error {
+	switch style {
+	case "", DurationStyleCompact:
+		durationStyle = DurationStyleCompact
+	case DurationStyleLong, DurationStyleClock:
+		durationStyle = style
+	default:
+		return fmt.Errorf("unknown duration style %q, expected %q, %q or %q", style, DurationStyleCompact, DurationStyleLong, DurationStyleClock)
+	}
+	return nil
+}
+
+// FormatBytes returns a human readable representation of a size in bytes (using binary units unless SI units were selected)
+func FormatBytes(size uint64) string {
+	units, base := byteUnits, float64(1024)
+	if sizeUnits == SizeUnitsSI {
+		units, base = siByteUnits, 1000
+	}
+	value := float64(size)
+	unit := 0
+	for value >= base && unit < len(units)-1 {
+		value /= base
+		unit++
+	}
+	if unit == 0 {
+		return fmt.Sprintf("%d %s", size, units[unit])
+	}
+	return fmt.Sprintf("%.1f %s", value, units[unit])
+}
+
+// ParseBytes reads a size like "512M", "2G" or "1.5GiB". The units are powers of 1024, and a size without unit is in bytes
+func ParseBytes(size string) (uint64, error) {
+	value := strings.TrimSpace(size)
+	upper := strings.ToUpper(value)
+	multiplier := uint64(1)
+	for index, unit := range []string{"K", "M", "G", "T", "P"} {
+		for _, suffix := range []string{unit + "IB", unit + "B", unit} {
+			if strings.HasSuffix(upper, suffix) {
+				multiplier = 1 << (10 * (index + 1))
+				value = strings.TrimSpace(value[:len(value)-len(suffix)])
+				break
+			}
+		}
+		if multiplier > 1 {
+			break
+		}
+	}
+	if multiplier == 1 && strings.HasSuffix(upper, "B") {
+		value = strings.TrimSpace(value[:len(value)-1])
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil || number < 0 {
+		return 0, fmt.Errorf("invalid size %q", size)
+	}
+	return uint64(number * float64(multiplier)), nil
+}
+
+// FormatBytesDelta returns a human readable representation of a size difference, always signed
+func FormatBytesDelta(delta int64) string {
+	if delta < 0 {
+		return "-" + FormatBytes(uint64(-delta))
+	}
+	return "+" + FormatBytes(uint64(delta))
+}
+
+// FormatDuration returns a human readable representation of a duration in the selected style
+func FormatDuration(duration time.Duration) string {
+	switch durationStyle {
+	case DurationStyleLong:
+		return formatLongDuration(duration)
+	case DurationStyleClock:
+		return formatClockDuration(duration)
+	default:
+		return duration.String()
+	}
+}
+
+func formatLongDuration(duration time.Duration) string {
+	sign := ""
+	if duration < 0 {
+		sign, duration = "-", -duration
+	}
+	parts := make([]string, 0, 4)
+	add := func(value int64, name string) {
+		if value == 1 {
+			parts = append(parts, "1 "+name)
+		} else if value > 0 {
+			parts = append(parts, strconv.FormatInt(value, 10)+" "+name+"s")
+		}
+	}
+	add(int64(duration/(24*time.Hour)), "day")
+	add(int64(duration%(24*time.Hour)/time.Hour), "hour")
+	add(int64(duration%time.Hour/time.Minute), "minute")
+	seconds := duration % time.Minute
+	if seconds%time.Second == 0 {
+		add(int64(seconds/time.Second), "second")
+	} else {
+		parts = append(parts, strconv.FormatFloat(seconds.Seconds(), 'f', -1, 64)+" seconds")
+	}
+	if len(parts) == 0 {
+		return "0 seconds"
+	}
+	return sign + strings.Join(parts, " ")
+}
+
+func formatClockDuration(duration time.Duration) string {
+	sign := ""
+	if duration < 0 {
+		sign, duration = "-", -duration
+	}
+	seconds := strconv.FormatFloat((duration % time.Minute).Seconds(), 'f', -1, 64)
+	if duration%time.Minute < 10*time.Second {
+		seconds = "0" + seconds
+	}
+	return fmt.Sprintf("%s%d:%02d:%s", sign, int64(duration/time.Hour), int64(duration%time.Hour/time.Minute), seconds)
+}
@@ -0,0 +1,105 @@
+package util
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatBytes(t *testing.T) {
+	testData := []struct {
+		size     uint64
+		expected string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KiB"},
+		{1536, "1.5 KiB"},
+		{5 * 1024 * 1024 * 1024, "5.0 GiB"},
+	}
+	for _, testItem := range testData {
+		assert.Equal(t, testItem.expected, FormatBytes(testItem.size))
+	}
+}
+
+func TestParseBytes(t *testing.T) {
+	testData := []struct {
+		size     string
+		expected uint64
+	}{
+		{"0", 0},
+		{"1024", 1024},
+		{"100B", 100},
+		{"2k", 2048},
+		{"512M", 512 * 1024 * 1024},
+		{"2G", 2 * 1024 * 1024 * 1024},
+		{"1.5 GiB", 1536 * 1024 * 1024},
+		{"1GB", 1024 * 1024 * 1024},
+		{" 3T ", 3 * 1024 * 1024 * 1024 * 1024},
+	}
+	for _, testItem := range testData {
+		t.Run(testItem.size, func(t *testing.T) {
+			size, err := ParseBytes(testItem.size)
+			require.NoError(t, err)
+			assert.Equal(t, testItem.expected, size)
+		})
+	}
+
+	for _, invalid := range []string{"", "G", "2X", "-1G", "two"} {
+		_, err := ParseBytes(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestFormatBytesDelta(t *testing.T) {
+	assert.Equal(t, "+0 B", FormatBytesDelta(0))
+	assert.Equal(t, "+2.0 KiB", FormatBytesDelta(2048))
+	assert.Equal(t, "-2.0 KiB", FormatBytesDelta(-2048))
+}
+
+func TestFormatBytesSI(t *testing.T) {
+	require.NoError(t, SetSizeUnits(SizeUnitsSI))
+	defer func() { _ = SetSizeUnits(SizeUnitsIEC) }()
+
+	assert.Equal(t, "999 B", FormatBytes(999))
+	assert.Equal(t, "1.0 kB", FormatBytes(1000))
+	assert.Equal(t, "1.5 MB", FormatBytes(1_500_000))
+	assert.Equal(t, "-2.0 kB", FormatBytesDelta(-2000))
+}
+
+func TestSetFormatErrors(t *testing.T) {
+	assert.Error(t, SetSizeUnits("metric"))
+	assert.Error(t, SetDurationStyle("short"))
+	assert.NoError(t, SetSizeUnits(""))
+	assert.NoError(t, SetDurationStyle(""))
+}
+
+func TestFormatDuration(t *testing.T) {
+	testData := []struct {
+		style    string
+		duration time.Duration
+		expected string
+	}{
+		{DurationStyleCompact, 0, "0s"},
+		{DurationStyleCompact, time.Hour + 2*time.Minute + 3*time.Second, "1h2m3s"},
+		{DurationStyleLong, 0, "0 seconds"},
+		{DurationStyleLong, time.Second, "1 second"},
+		{DurationStyleLong, 1500 * time.Millisecond, "1.5 seconds"},
+		{DurationStyleLong, time.Hour + 2*time.Minute + 3*time.Second, "1 hour 2 minutes 3 seconds"},
+		{DurationStyleLong, 50*time.Hour + time.Minute, "2 days 2 hours 1 minute"},
+		{DurationStyleLong, -time.Minute, "-1 minute"},
+		{DurationStyleClock, 0, "0:00:00"},
+		{DurationStyleClock, 1500 * time.Millisecond, "0:00:01.5"},
+		{DurationStyleClock, time.Hour + 2*time.Minute + 3*time.Second, "1:02:03"},
+		{DurationStyleClock, 26*time.Hour + 45*time.Second, "26:00:45"},
+	}
+	defer func() { _ = SetDurationStyle(DurationStyleCompact) }()
+	for _, testItem := range testData {
+		t.Run(testItem.style+"/"+testItem.expected, func(t *testing.T) {
+			require.NoError(t, SetDurationStyle(testItem.style))
+			assert.Equal(t, testItem.expected, FormatDuration(testItem.duration))
+		})
+	}
+}
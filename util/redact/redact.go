@@ -0,0 +1,174 @@
+// Package redact masks the secrets registered during a run (passwords, tokens, credentials in URLs)
+// in any text or output stream leaving resticprofile.
+package redact
+
+import (
+	"bytes"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// MinSecretLength is the minimum length of a secret: shorter values would mask too much of the output
+const MinSecretLength = 4
+
+var (
+	lock     sync.RWMutex
+	secrets  = make(map[string]string)
+	replacer *strings.Replacer
+)
+
+// Add registers a secret to mask with its replacement. Secrets shorter than MinSecretLength are ignored.
+func Add(secret, replacement string) {
+	if len(secret) < MinSecretLength || secret == replacement {
+		return
+	}
+	lock.Lock()
+	defer lock.Unlock()
+
+	if current, found := secrets[secret]; found && current == replacement {
+		return
+	}
+	secrets[secret] = replacement
+	replacer = newReplacer()
+}
+
+// Clear removes all the registered secrets
+func Clear() {
+	lock.Lock()
+	defer lock.Unlock()
+
+	secrets = make(map[string]string)
+	replacer = nil
+}
+
+// Enabled returns true when at least one secret is registered
+func Enabled() bool {
+	lock.RLock()
+	defer lock.RUnlock()
+
+	return replacer != nil
+}
+
+// newReplacer creates a replacer trying the longest secrets first (a secret may contain another one)
+func newReplacer() *strings.Replacer {
+	keys := make([]string, 0, len(secrets))
+	for secret := range secrets {
+		keys = append(keys, secret)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if len(keys[i]) != len(keys[j]) {
+			return len(keys[i]) > len(keys[j])
+		}
+		return keys[i] < keys[j]
+	})
+	pairs := make([]string, 0, len(keys)*2)
+	for _, secret := range keys {
+		pairs = append(pairs, secret, secrets[secret])
+	}
+	return strings.NewReplacer(pairs...)
+}
+
+// String returns the value with all the registered secrets masked
+func String(value string) string {
+	lock.RLock()
+	defer lock.RUnlock()
+
+	if replacer == nil || value == "" {
+		return value
+	}
+	return replacer.Replace(value)
+}
+
+// Strings returns a new list with all the registered secrets masked
+func Strings(values []string) []string {
+	if values == nil {
+		return nil
+	}
+	masked := make([]string, len(values))
+	for i, value := range values {
+		masked[i] = String(value)
+	}
+	return masked
+}
+
+// pendingLength returns the length of the longest end of data that could be the beginning of a secret
+func pendingLength(data []byte) (length int) {
+	lock.RLock()
+	defer lock.RUnlock()
+
+	for secret := range secrets {
+		limit := len(secret) - 1
+		if limit > len(data) {
+			limit = len(data)
+		}
+		for size := limit; size > length; size-- {
+			if bytes.HasSuffix(data, []byte(secret[:size])) {
+				length = size
+				break
+			}
+		}
+	}
+	return
+}
+
+// Writer masks the registered secrets in the data written to the underlying writer.
+// The end of a write that could be the beginning of a secret is kept until the next write or Flush,
+// so a secret split across multiple writes is still masked.
+type Writer struct {
+	writer  io.Writer
+	mutex   sync.Mutex
+	pending []byte
+}
+
+// NewWriter returns a writer masking the registered secrets. A nil writer stays nil.
+func NewWriter(w io.Writer) io.Writer {
+	if w == nil {
+		return nil
+	}
+	return &Writer{writer: w}
+}
+
+// Write masks the secrets in p and writes the result to the underlying writer
+func (w *Writer) Write(p []byte) (n int, err error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if !Enabled() && len(w.pending) == 0 {
+		return w.writer.Write(p)
+	}
+	data := append(w.pending, p...)
+	keep := pendingLength(data)
+	w.pending = append([]byte{}, data[len(data)-keep:]...)
+	if keep < len(data) {
+		_, err = io.WriteString(w.writer, String(string(data[:len(data)-keep])))
+	}
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Flush writes the data kept from the previous write, and flushes the underlying writer when supported
+func (w *Writer) Flush() (err error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if len(w.pending) > 0 {
+		_, err = io.WriteString(w.writer, String(string(w.pending)))
+		w.pending = nil
+	}
+	if flusher, ok := w.writer.(interface{ Flush() error }); ok && err == nil {
+		err = flusher.Flush()
+	}
+	return
+}
+
+// Flush flushes the writer when it was created by NewWriter
+func Flush(w io.Writer) error {
+	if writer, ok := w.(*Writer); ok {
+		return writer.Flush()
+	}
+	return nil
+}
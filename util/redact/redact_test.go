@@ -0,0 +1,80 @@
+package redact
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestString(t *testing.T) {
+	defer Clear()
+	assert.False(t, Enabled())
+	assert.Equal(t, "my password", String("my password"))
+
+	Add("password", "***")
+	Add("pass", "###")
+	Add("abc", "***") // too short
+	assert.True(t, Enabled())
+
+	assert.Equal(t, "my ***, my ### and abc", String("my password, my pass and abc"))
+	assert.Equal(t, []string{"***", "###"}, Strings([]string{"password", "pass"}))
+	assert.Nil(t, Strings(nil))
+
+	Clear()
+	assert.False(t, Enabled())
+	assert.Equal(t, "password", String("password"))
+}
+
+func TestWriter(t *testing.T) {
+	defer Clear()
+	Add("secret-value", "***")
+
+	tests := []struct {
+		name   string
+		writes []string
+	}{
+		{name: "single write", writes: []string{"a secret-value in the output\n"}},
+		{name: "split secret", writes: []string{"a secr", "et-val", "ue in the output\n"}},
+		{name: "one byte at a time", writes: []string{"a ", "s", "e", "c", "r", "e", "t", "-", "v", "a", "l", "u", "e", " in the output\n"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			buffer := &bytes.Buffer{}
+			writer := NewWriter(buffer)
+			for _, data := range test.writes {
+				n, err := writer.Write([]byte(data))
+				require.NoError(t, err)
+				assert.Equal(t, len(data), n)
+			}
+			require.NoError(t, Flush(writer))
+			assert.Equal(t, "a *** in the output\n", buffer.String())
+		})
+	}
+}
+
+func TestWriterKeepsPossibleSecretUntilFlush(t *testing.T) {
+	defer Clear()
+	Add("secret-value", "***")
+
+	buffer := &bytes.Buffer{}
+	writer := NewWriter(buffer)
+	_, err := writer.Write([]byte("output: secret-"))
+	require.NoError(t, err)
+	assert.Equal(t, "output: ", buffer.String())
+
+	require.NoError(t, Flush(writer))
+	assert.Equal(t, "output: secret-", buffer.String())
+}
+
+func TestWriterWithoutSecret(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	writer := NewWriter(buffer)
+	_, err := writer.Write([]byte("secret-value"))
+	require.NoError(t, err)
+	assert.Equal(t, "secret-value", buffer.String())
+
+	assert.Nil(t, NewWriter(nil))
+	assert.NoError(t, Flush(buffer))
+}
@@ -3,6 +3,9 @@ package util
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"sync"
 
 	"github.com/creativeprojects/clog"
@@ -18,6 +21,7 @@ var (
 const (
 	tempDirPattern = "resticprofile*"
 	tempDirHookTag = "tempDir-cleanup-hook"
+	tempDirPidFile = ".resticprofile.pid"
 )
 
 // TempDir returns the path to a temporary directory that is stable within the same process and cleaned when shutdown.RunHooks is invoked
@@ -46,6 +50,15 @@ func MustGetTempDir() string {
 	}
 }
 
+// CreateTempFile creates a new file in the directory from TempDir (the file is removed with the directory)
+func CreateTempFile(pattern string) (*os.File, error) {
+	dir, err := TempDir()
+	if err != nil {
+		return nil, err
+	}
+	return os.CreateTemp(dir, pattern)
+}
+
 // ClearTempDir removes the temporary directory (if present) and resets the state.
 // This is not safe for concurrent use and is meant for cleanup in unit tests only.
 func ClearTempDir() {
@@ -73,6 +86,59 @@ func createTempDir(path string) (tempDir string, tempDirErr error) {
 
 	if len(tempDir) > 0 {
 		clog.Tracef("temporary directory created: %s", tempDir)
+		// the PID allows to remove the directory when the process didn't clean it up (e.g. killed)
+		pid := []byte(strconv.Itoa(os.Getpid()))
+		if err := os.WriteFile(filepath.Join(tempDir, tempDirPidFile), pid, 0600); err != nil {
+			clog.Debugf("cannot write PID file in temp dir: %s", err)
+		}
+	}
+	return
+}
+
+// StaleTempDirs returns the temporary directories left behind by resticprofile processes that are no longer running
+func StaleTempDirs(isRunning func(pid int) bool) []string {
+	parents := []string{os.TempDir()}
+	if cacheDir, err := os.UserCacheDir(); err == nil {
+		parents = append(parents, cacheDir)
+	}
+	return staleTempDirsIn(parents, isRunning)
+}
+
+func staleTempDirsIn(parents []string, isRunning func(pid int) bool) (dirs []string) {
+	prefix := strings.TrimSuffix(tempDirPattern, "*")
+	for _, parent := range parents {
+		entries, err := os.ReadDir(parent)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() || !strings.HasPrefix(entry.Name(), prefix) {
+				continue
+			}
+			dir := filepath.Join(parent, entry.Name())
+			// only directories with a PID file were created by TempDir
+			content, err := os.ReadFile(filepath.Join(dir, tempDirPidFile))
+			if err != nil {
+				continue
+			}
+			pid, err := strconv.Atoi(strings.TrimSpace(string(content)))
+			if err != nil || pid == os.Getpid() || isRunning(pid) {
+				continue
+			}
+			dirs = append(dirs, dir)
+		}
+	}
+	return
+}
+
+// RemoveStaleTempDirs removes the directories from StaleTempDirs and returns the directories removed
+func RemoveStaleTempDirs(isRunning func(pid int) bool) (removed []string, err error) {
+	for _, dir := range StaleTempDirs(isRunning) {
+		if e := os.RemoveAll(dir); e != nil {
+			err = e
+			continue
+		}
+		removed = append(removed, dir)
 	}
 	return
 }
@@ -3,6 +3,7 @@ package util
 import (
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"testing"
 
@@ -99,3 +100,47 @@ func TestTempDir(t *testing.T) {
 		assert.NoDirExists(t, dir)
 	})
 }
+
+func TestStaleTempDirs(t *testing.T) {
+	parent := t.TempDir()
+	create := func(name string, pid string) string {
+		dir := filepath.Join(parent, name)
+		require.NoError(t, os.Mkdir(dir, 0700))
+		if pid != "" {
+			require.NoError(t, os.WriteFile(filepath.Join(dir, tempDirPidFile), []byte(pid), 0600))
+		}
+		return dir
+	}
+	stale := create("resticprofile123", "1001")
+	create("resticprofile456", "1002")                     // running
+	create("resticprofile789", "")                         // no PID file
+	create("other", "1001")                                // not a resticprofile directory
+	create("resticprofile-own", strconv.Itoa(os.Getpid())) // current process
+	require.NoError(t, os.WriteFile(filepath.Join(parent, "resticprofile-mount.log"), nil, 0600))
+
+	isRunning := func(pid int) bool { return pid == 1002 }
+	assert.Equal(t, []string{stale}, staleTempDirsIn([]string{parent, filepath.Join(parent, "missing")}, isRunning))
+}
+
+func TestCreateTempDirWritesPid(t *testing.T) {
+	dir, err := createTempDir("")
+	defer removeTempDir(dir, err)
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(filepath.Join(dir, tempDirPidFile))
+	require.NoError(t, err)
+	assert.Equal(t, strconv.Itoa(os.Getpid()), string(content))
+
+	assert.NotContains(t, staleTempDirsIn([]string{filepath.Dir(dir)}, func(int) bool { return false }), dir)
+}
+
+func TestCreateTempFile(t *testing.T) {
+	defer ClearTempDir()
+	ClearTempDir()
+
+	file, err := CreateTempFile("test-*.txt")
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+	assert.Equal(t, MustGetTempDir(), filepath.Dir(file.Name()))
+	assert.True(t, strings.HasPrefix(filepath.Base(file.Name()), "test-"))
+}
@@ -9,6 +9,7 @@ import (
 	"strings"
 	"sync"
 	"text/template"
+	"time"
 
 	"github.com/creativeprojects/resticprofile/util"
 	"github.com/creativeprojects/resticprofile/util/collect"
@@ -30,6 +31,8 @@ import (
 //   - {{ list "A" "B" "C" }} => ["A", "B", "C"]
 //   - {{ tempDir }} => "/path/to/unique-tempdir"
 //   - {{ tempFile "filename" }} => "/path/to/unique-tempdir/filename"
+//   - {{ 1536 | formatBytes }} => "1.5 KiB"
+//   - {{ 90 | formatDuration }} => "1m30s"
 func TemplateFuncs(funcs ...map[string]any) (templateFuncs map[string]any) {
 	toString := func(arg any) string { return fmt.Sprint(arg) }
 	toAny := func(arg string) any { return arg }
@@ -44,18 +47,20 @@ func TemplateFuncs(funcs ...map[string]any) (templateFuncs map[string]any) {
 	}
 
 	templateFuncs = map[string]any{
-		"replace":    func(old, new, src string) string { return strings.ReplaceAll(src, old, new) },
-		"regex":      func(ptn, repl, src string) string { return mustCompileRegex(ptn).ReplaceAllString(src, repl) },
-		"lower":      strings.ToLower,
-		"upper":      strings.ToUpper,
-		"trim":       strings.TrimSpace,
-		"trimPrefix": func(prefix, src string) string { return strings.TrimPrefix(src, prefix) },
-		"trimSuffix": func(suffix, src string) string { return strings.TrimSuffix(src, suffix) },
-		"split":      func(sep, src string) []any { return collect.From(strings.Split(src, sep), toAny) },
-		"join":       func(sep string, src []any) string { return strings.Join(collect.From(src, toString), sep) },
-		"list":       func(args ...any) []any { return args },
-		"tempDir":    TempDir,
-		"tempFile":   TempFile,
+		"replace":        func(old, new, src string) string { return strings.ReplaceAll(src, old, new) },
+		"regex":          func(ptn, repl, src string) string { return mustCompileRegex(ptn).ReplaceAllString(src, repl) },
+		"lower":          strings.ToLower,
+		"upper":          strings.ToUpper,
+		"trim":           strings.TrimSpace,
+		"trimPrefix":     func(prefix, src string) string { return strings.TrimPrefix(src, prefix) },
+		"trimSuffix":     func(suffix, src string) string { return strings.TrimSuffix(src, suffix) },
+		"split":          func(sep, src string) []any { return collect.From(strings.Split(src, sep), toAny) },
+		"join":           func(sep string, src []any) string { return strings.Join(collect.From(src, toString), sep) },
+		"list":           func(args ...any) []any { return args },
+		"tempDir":        TempDir,
+		"tempFile":       TempFile,
+		"formatBytes":    formatBytes,
+		"formatDuration": formatDuration,
 	}
 
 	for _, funcsMap := range funcs {
@@ -64,6 +69,47 @@ func TemplateFuncs(funcs ...map[string]any) (templateFuncs map[string]any) {
 	return
 }
 
+// formatBytes formats a size in bytes with the units selected in util.SetSizeUnits
+func formatBytes(size any) string {
+	switch value := size.(type) {
+	case uint64:
+		return util.FormatBytes(value)
+	case uint:
+		return util.FormatBytes(uint64(value))
+	case int64:
+		return signedBytes(value)
+	case int:
+		return signedBytes(int64(value))
+	case float64:
+		return signedBytes(int64(value))
+	default:
+		return fmt.Sprint(size)
+	}
+}
+
+func signedBytes(size int64) string {
+	if size < 0 {
+		return "-" + util.FormatBytes(uint64(-size))
+	}
+	return util.FormatBytes(uint64(size))
+}
+
+// formatDuration formats a duration (or a number of seconds) with the style selected in util.SetDurationStyle
+func formatDuration(duration any) string {
+	switch value := duration.(type) {
+	case time.Duration:
+		return util.FormatDuration(value)
+	case int:
+		return util.FormatDuration(time.Duration(value) * time.Second)
+	case int64:
+		return util.FormatDuration(time.Duration(value) * time.Second)
+	case float64:
+		return util.FormatDuration(time.Duration(value * float64(time.Second)))
+	default:
+		return fmt.Sprint(duration)
+	}
+}
+
 // New returns a new Template instance with configured funcs (including TemplateFuncs)
 func New(name string, funcs ...map[string]any) (tpl *template.Template) {
 	tpl = template.New(name)
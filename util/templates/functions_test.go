@@ -33,6 +33,11 @@ func TestTemplateFuncs(t *testing.T) {
 		{template: `{{ tempDir }}`, expected: dir}, // constant results when repeated
 		{template: `{{ tempFile "test.txt" }}`, expected: file},
 		{template: `{{ tempFile "test.txt" }}`, expected: file}, // constant results when repeated
+		{template: `{{ 1536 | formatBytes }}`, expected: `1.5 KiB`},
+		{template: `{{ -2048 | formatBytes }}`, expected: `-2.0 KiB`},
+		{template: `{{ 90 | formatDuration }}`, expected: `1m30s`},
+		{template: `{{ 1.5 | formatDuration }}`, expected: `1.5s`},
+		{template: `{{ "text" | formatDuration }}`, expected: `text`},
 		{template: `{{ hello }}`, expected: `Hello World`},
 	}
 
@@ -0,0 +1,10 @@
+//go:build windows
+
+package volume
+
+import "errors"
+
+// mountPoint is not supported on Windows
+func mountPoint(path string) (bool, error) {
+	return false, errors.New("mounting volumes is not supported on Windows")
+}
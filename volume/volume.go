@@ -0,0 +1,369 @@
+package volume
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/creativeprojects/clog"
+)
+
+// Types of volume
+const (
+	TypeCIFS       = "cifs"
+	TypeNFS        = "nfs"
+	TypeSSHFS      = "sshfs"
+	TypeCryptsetup = "cryptsetup"
+)
+
+const (
+	defaultRetryDelay = 5 * time.Second
+	mapperDirectory   = "/dev/mapper"
+	mapperPrefix      = "resticprofile-"
+)
+
+// Volume describes an encrypted or network volume to mount
+type Volume struct {
+	Type            string
+	Source          string
+	Target          string
+	Options         []string
+	Username        string
+	Password        string
+	CredentialsFile string
+	IdentityFile    string
+	KeyFile         string
+	Name            string
+	Retries         int
+	RetryDelay      time.Duration
+}
+
+// command is an external command run to mount or unmount a volume
+type command struct {
+	name  string
+	args  []string
+	stdin string // secret given on the standard input of the command
+}
+
+func (c command) String() string {
+	return strings.Join(append([]string{c.name}, c.args...), " ")
+}
+
+// Runner runs an external command and returns its combined output
+type Runner func(name string, args []string, stdin string) (output string, err error)
+
+// Mounted is a volume mounted by Mount
+type Mounted struct {
+	volume  *Volume
+	run     Runner
+	skipped bool // the volume was mounted before, it is not unmounted
+}
+
+var (
+	// runCommand runs the external commands (can be replaced in tests)
+	runCommand Runner = execCommand
+	// AuditCommand is called after each external command has run, to record it in the audit log (optional)
+	AuditCommand func(name string, args []string, start time.Time, err error)
+	// isMountPoint returns true when the directory is a mount point (can be replaced in tests)
+	isMountPoint = mountPoint
+	// fileExists is used to find an opened encrypted device (can be replaced in tests)
+	fileExists = func(name string) bool {
+		_, err := os.Stat(name)
+		return err == nil
+	}
+)
+
+// Validate returns an error when the volume cannot be mounted
+func (v *Volume) Validate() error {
+	switch v.Type {
+	case TypeCIFS, TypeNFS, TypeSSHFS, TypeCryptsetup:
+	case "":
+		return errors.New("missing type of volume")
+	default:
+		return fmt.Errorf("unknown type of volume %q", v.Type)
+	}
+	if v.Source == "" {
+		return fmt.Errorf("missing source of %s volume", v.Type)
+	}
+	if v.Target == "" && v.Type != TypeCryptsetup {
+		return fmt.Errorf("missing target of %s volume %q", v.Type, v.Source)
+	}
+	switch v.Type {
+	case TypeNFS:
+		if v.Username != "" || v.Password != "" || v.CredentialsFile != "" {
+			return fmt.Errorf("nfs volume %q doesn't accept credentials", v.Source)
+		}
+	case TypeCIFS:
+		if v.CredentialsFile != "" && (v.Username != "" || v.Password != "") {
+			return fmt.Errorf("cifs volume %q: use either a credentials file or a username and password", v.Source)
+		}
+	case TypeCryptsetup:
+		if v.KeyFile == "" && v.Password == "" {
+			return fmt.Errorf("cryptsetup volume %q needs a key file or a password", v.Source)
+		}
+	}
+	return nil
+}
+
+// String returns a short description of the volume
+func (v *Volume) String() string {
+	if v.Target == "" {
+		return fmt.Sprintf("%s %s", v.Type, v.Source)
+	}
+	return fmt.Sprintf("%s %s on %s", v.Type, v.Source, v.Target)
+}
+
+// Mount mounts the volume and verifies the mount point, retrying when it fails.
+// A volume already mounted is left as is, and won't be unmounted.
+func (v *Volume) Mount() (*Mounted, error) {
+	if err := v.Validate(); err != nil {
+		return nil, err
+	}
+	mounted := &Mounted{volume: v, run: runCommand}
+	if v.isMounted() {
+		clog.Infof("volume %s is already mounted", v)
+		mounted.skipped = true
+		return mounted, nil
+	}
+
+	delay := v.RetryDelay
+	if delay <= 0 {
+		delay = defaultRetryDelay
+	}
+	var err error
+	for attempt := 0; attempt <= v.Retries; attempt++ {
+		if attempt > 0 {
+			clog.Warningf("mounting volume %s: %v, retrying in %s", v, err, delay)
+			time.Sleep(delay)
+		}
+		if err = mounted.mount(); err == nil {
+			return mounted, nil
+		}
+	}
+	return nil, fmt.Errorf("cannot mount volume %s: %w", v, err)
+}
+
+// mount runs the mount commands once, and leaves nothing mounted on error
+func (m *Mounted) mount() error {
+	v := m.volume
+	credentials, removeCredentials, err := v.cifsCredentials()
+	if err != nil {
+		return err
+	}
+	defer removeCredentials()
+
+	commands := v.mountCommands(credentials)
+	for index, cmd := range commands {
+		if err = m.runCommand(cmd); err != nil {
+			// undo the steps done so far (e.g. close the encrypted device)
+			m.runQuietly(v.undoCommands(index))
+			return err
+		}
+	}
+	if !v.isMounted() {
+		m.runQuietly(v.undoCommands(len(commands)))
+		return errors.New("the volume is not mounted after running the mount command")
+	}
+	return nil
+}
+
+// Unmount unmounts the volume, unless it was already mounted before Mount
+func (m *Mounted) Unmount() error {
+	if m == nil || m.skipped {
+		return nil
+	}
+	var firstErr error
+	for _, cmd := range m.volume.unmountCommands() {
+		// keep going: the next step can still succeed (e.g. close the device after a lazy unmount)
+		if err := m.runCommand(cmd); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if firstErr != nil {
+		return fmt.Errorf("cannot unmount volume %s: %w", m.volume, firstErr)
+	}
+	return nil
+}
+
+func (m *Mounted) runCommand(cmd command) error {
+	clog.Debugf("volume: running %s", cmd)
+	output, err := m.run(cmd.name, cmd.args, cmd.stdin)
+	if err != nil {
+		if output = strings.TrimSpace(output); output != "" {
+			return fmt.Errorf("%s: %w: %s", cmd.name, err, output)
+		}
+		return fmt.Errorf("%s: %w", cmd.name, err)
+	}
+	return nil
+}
+
+func (m *Mounted) runQuietly(commands []command) {
+	for _, cmd := range commands {
+		if err := m.runCommand(cmd); err != nil {
+			clog.Debug(err)
+		}
+	}
+}
+
+// isMounted returns true when the target is a mount point (or when the encrypted device is opened)
+func (v *Volume) isMounted() bool {
+	if v.Target == "" {
+		return fileExists(v.mapperDevice())
+	}
+	mounted, err := isMountPoint(v.Target)
+	if err != nil {
+		clog.Debugf("cannot verify mount point %q: %v", v.Target, err)
+	}
+	return mounted
+}
+
+// mapperName returns the name of the encrypted device opened by cryptsetup
+func (v *Volume) mapperName() string {
+	if v.Name != "" {
+		return v.Name
+	}
+	return mapperPrefix + filepath.Base(v.Source)
+}
+
+func (v *Volume) mapperDevice() string {
+	return mapperDirectory + "/" + v.mapperName()
+}
+
+// mountCommands returns the commands mounting the volume
+func (v *Volume) mountCommands(credentialsFile string) []command {
+	options := append([]string{}, v.Options...)
+
+	switch v.Type {
+	case TypeCIFS:
+		if credentialsFile != "" {
+			options = append([]string{"credentials=" + credentialsFile}, options...)
+		}
+		return []command{mountCommand(v.Type, options, v.Source, v.Target)}
+
+	case TypeNFS:
+		return []command{mountCommand(v.Type, options, v.Source, v.Target)}
+
+	case TypeSSHFS:
+		stdin := ""
+		if v.IdentityFile != "" {
+			options = append(options, "IdentityFile="+v.IdentityFile)
+		}
+		if v.Password != "" {
+			options = append(options, "password_stdin")
+			stdin = v.Password + "\n"
+		}
+		args := []string{v.Source, v.Target}
+		if len(options) > 0 {
+			args = append(args, "-o", strings.Join(options, ","))
+		}
+		return []command{{name: "sshfs", args: args, stdin: stdin}}
+
+	case TypeCryptsetup:
+		open := command{name: "cryptsetup", args: []string{"open"}}
+		if v.KeyFile != "" {
+			open.args = append(open.args, "--key-file", v.KeyFile)
+		} else {
+			open.args = append(open.args, "--key-file", "-")
+			open.stdin = v.Password
+		}
+		open.args = append(open.args, v.Source, v.mapperName())
+		commands := []command{open}
+		if v.Target != "" {
+			commands = append(commands, mountCommand("", options, v.mapperDevice(), v.Target))
+		}
+		return commands
+	}
+	return nil
+}
+
+// unmountCommands returns the commands unmounting the volume
+func (v *Volume) unmountCommands() []command {
+	switch v.Type {
+	case TypeSSHFS:
+		if _, err := exec.LookPath("fusermount"); err == nil {
+			return []command{{name: "fusermount", args: []string{"-u", v.Target}}}
+		}
+		return []command{{name: "umount", args: []string{v.Target}}}
+
+	case TypeCryptsetup:
+		commands := make([]command, 0, 2)
+		if v.Target != "" {
+			commands = append(commands, command{name: "umount", args: []string{v.Target}})
+		}
+		return append(commands, command{name: "cryptsetup", args: []string{"close", v.mapperName()}})
+	}
+	return []command{{name: "umount", args: []string{v.Target}}}
+}
+
+// undoCommands returns the commands reverting the first "done" mount commands
+func (v *Volume) undoCommands(done int) []command {
+	if done == 0 {
+		return nil
+	}
+	if v.Type == TypeCryptsetup && done == 1 {
+		// only the device was opened
+		return []command{{name: "cryptsetup", args: []string{"close", v.mapperName()}}}
+	}
+	return v.unmountCommands()
+}
+
+// cifsCredentials writes the username and password of a cifs volume to a temporary credentials file,
+// so they don't appear in the list of processes. The returned function removes the file.
+func (v *Volume) cifsCredentials() (string, func(), error) {
+	if v.Type != TypeCIFS {
+		return "", func() {}, nil
+	}
+	if v.CredentialsFile != "" || (v.Username == "" && v.Password == "") {
+		return v.CredentialsFile, func() {}, nil
+	}
+	file, err := os.CreateTemp("", "resticprofile-cifs-*")
+	if err != nil {
+		return "", nil, fmt.Errorf("cannot create credentials file: %w", err)
+	}
+	remove := func() { _ = os.Remove(file.Name()) }
+	content := fmt.Sprintf("username=%s\npassword=%s\n", v.Username, v.Password)
+	_, err = file.WriteString(content)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(file.Name(), 0600)
+	}
+	if err != nil {
+		remove()
+		return "", nil, fmt.Errorf("cannot write credentials file: %w", err)
+	}
+	return file.Name(), remove, nil
+}
+
+func mountCommand(fsType string, options []string, source, target string) command {
+	args := make([]string, 0, 6)
+	if fsType != "" {
+		args = append(args, "-t", fsType)
+	}
+	if len(options) > 0 {
+		args = append(args, "-o", strings.Join(options, ","))
+	}
+	return command{name: "mount", args: append(args, source, target)}
+}
+
+func execCommand(name string, args []string, stdin string) (string, error) {
+	cmd := exec.Command(name, args...)
+	if stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
+	output := &bytes.Buffer{}
+	cmd.Stdout = output
+	cmd.Stderr = output
+	start := time.Now()
+	err := cmd.Run()
+	if AuditCommand != nil {
+		AuditCommand(name, args, start, err)
+	}
+	return output.String(), err
+}
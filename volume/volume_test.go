@@ -0,0 +1,188 @@
+package volume
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSystem records the commands and mounts the target when the mount command succeeds
+type fakeSystem struct {
+	commands []string
+	stdin    []string
+	mounted  map[string]bool
+	failures int // number of failing mount commands
+	content  string
+}
+
+func (f *fakeSystem) run(name string, args []string, stdin string) (string, error) {
+	f.commands = append(f.commands, strings.Join(append([]string{name}, args...), " "))
+	f.stdin = append(f.stdin, stdin)
+	target := args[len(args)-1]
+	switch name {
+	case "mount", "sshfs":
+		if name == "sshfs" {
+			target = args[1]
+		}
+		for _, arg := range args {
+			if strings.HasPrefix(arg, "credentials=") {
+				content, _ := os.ReadFile(strings.TrimPrefix(arg, "credentials="))
+				f.content = string(content)
+			}
+		}
+		if f.failures > 0 {
+			f.failures--
+			return "mount error(113): could not connect", errors.New("exit status 32")
+		}
+		f.mounted[target] = true
+	case "umount":
+		delete(f.mounted, target)
+	case "fusermount":
+		delete(f.mounted, target)
+	}
+	return "", nil
+}
+
+func setupFakeSystem(t *testing.T) *fakeSystem {
+	t.Helper()
+	fake := &fakeSystem{mounted: make(map[string]bool)}
+	runCommand = fake.run
+	isMountPoint = func(path string) (bool, error) { return fake.mounted[path], nil }
+	t.Cleanup(func() {
+		runCommand = execCommand
+		isMountPoint = mountPoint
+	})
+	return fake
+}
+
+func TestValidate(t *testing.T) {
+	testCases := []struct {
+		volume Volume
+		err    string
+	}{
+		{Volume{Type: "cifs", Source: "//server/share", Target: "/mnt"}, ""},
+		{Volume{Type: "cryptsetup", Source: "/dev/sdb1", KeyFile: "/root/key"}, ""},
+		{Volume{Source: "//server/share", Target: "/mnt"}, "missing type of volume"},
+		{Volume{Type: "smb", Source: "//server/share", Target: "/mnt"}, `unknown type of volume "smb"`},
+		{Volume{Type: "nfs", Target: "/mnt"}, "missing source of nfs volume"},
+		{Volume{Type: "sshfs", Source: "host:/"}, `missing target of sshfs volume "host:/"`},
+		{Volume{Type: "nfs", Source: "host:/", Target: "/mnt", Password: "secret"}, `nfs volume "host:/" doesn't accept credentials`},
+		{Volume{Type: "cifs", Source: "//s/x", Target: "/mnt", Username: "u", CredentialsFile: "/c"}, `cifs volume "//s/x": use either a credentials file or a username and password`},
+		{Volume{Type: "cryptsetup", Source: "/dev/sdb1", Target: "/mnt"}, `cryptsetup volume "/dev/sdb1" needs a key file or a password`},
+	}
+	for _, testCase := range testCases {
+		err := testCase.volume.Validate()
+		if testCase.err == "" {
+			assert.NoError(t, err)
+		} else {
+			assert.EqualError(t, err, testCase.err)
+		}
+	}
+}
+
+func TestMountCommands(t *testing.T) {
+	testCases := []struct {
+		volume   Volume
+		commands []string
+		stdin    string
+	}{
+		{
+			volume:   Volume{Type: TypeNFS, Source: "server:/export", Target: "/mnt/backup", Options: []string{"vers=4", "ro"}},
+			commands: []string{"mount -t nfs -o vers=4,ro server:/export /mnt/backup"},
+		},
+		{
+			volume:   Volume{Type: TypeCIFS, Source: "//server/share", Target: "/mnt/share"},
+			commands: []string{"mount -t cifs //server/share /mnt/share"},
+		},
+		{
+			volume:   Volume{Type: TypeSSHFS, Source: "user@host:/data", Target: "/mnt/data", IdentityFile: "/root/.ssh/id", Password: "secret"},
+			commands: []string{"sshfs user@host:/data /mnt/data -o IdentityFile=/root/.ssh/id,password_stdin"},
+			stdin:    "secret\n",
+		},
+		{
+			volume: Volume{Type: TypeCryptsetup, Source: "/dev/sdb1", Target: "/mnt/crypt", Password: "secret"},
+			commands: []string{
+				"cryptsetup open --key-file - /dev/sdb1 resticprofile-sdb1",
+				"mount /dev/mapper/resticprofile-sdb1 /mnt/crypt",
+			},
+			stdin: "secret",
+		},
+		{
+			volume:   Volume{Type: TypeCryptsetup, Source: "/dev/sdb1", Name: "backup", KeyFile: "/root/key"},
+			commands: []string{"cryptsetup open --key-file /root/key /dev/sdb1 backup"},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.volume.String(), func(t *testing.T) {
+			commands := testCase.volume.mountCommands("")
+			lines := make([]string, len(commands))
+			for i, cmd := range commands {
+				lines[i] = cmd.String()
+			}
+			assert.Equal(t, testCase.commands, lines)
+			assert.Equal(t, testCase.stdin, commands[0].stdin)
+		})
+	}
+}
+
+func TestMountAndUnmount(t *testing.T) {
+	fake := setupFakeSystem(t)
+	volume := &Volume{Type: TypeCIFS, Source: "//server/share", Target: "/mnt/share", Username: "backup", Password: "secret"}
+
+	mounted, err := volume.Mount()
+	require.NoError(t, err)
+	assert.True(t, fake.mounted["/mnt/share"])
+	assert.Equal(t, "username=backup\npassword=secret\n", fake.content)
+	// the password is not on the command line, and the credentials file was removed
+	require.Len(t, fake.commands, 1)
+	assert.NotContains(t, fake.commands[0], "secret")
+	credentials := strings.TrimPrefix(strings.Fields(fake.commands[0])[4], "credentials=")
+	assert.NoFileExists(t, credentials)
+
+	require.NoError(t, mounted.Unmount())
+	assert.False(t, fake.mounted["/mnt/share"])
+	assert.Equal(t, "umount /mnt/share", fake.commands[1])
+}
+
+func TestMountAlreadyMounted(t *testing.T) {
+	fake := setupFakeSystem(t)
+	fake.mounted["/mnt/backup"] = true
+	volume := &Volume{Type: TypeNFS, Source: "server:/export", Target: "/mnt/backup"}
+
+	mounted, err := volume.Mount()
+	require.NoError(t, err)
+	require.NoError(t, mounted.Unmount())
+	assert.Empty(t, fake.commands)
+	assert.True(t, fake.mounted["/mnt/backup"])
+}
+
+func TestMountRetries(t *testing.T) {
+	fake := setupFakeSystem(t)
+	fake.failures = 2
+	volume := &Volume{Type: TypeNFS, Source: "server:/export", Target: "/mnt/backup", Retries: 1, RetryDelay: time.Millisecond}
+
+	_, err := volume.Mount()
+	assert.ErrorContains(t, err, "cannot mount volume nfs server:/export on /mnt/backup: mount: exit status 32: mount error(113): could not connect")
+
+	fake.failures = 1
+	mounted, err := volume.Mount()
+	require.NoError(t, err)
+	assert.NotNil(t, mounted)
+	assert.True(t, fake.mounted["/mnt/backup"])
+}
+
+func TestMountNotVerified(t *testing.T) {
+	fake := setupFakeSystem(t)
+	isMountPoint = func(string) (bool, error) { return false, nil }
+	volume := &Volume{Type: TypeCryptsetup, Source: "/dev/sdb1", Target: "/mnt/crypt", KeyFile: "/root/key"}
+
+	_, err := volume.Mount()
+	assert.ErrorContains(t, err, "the volume is not mounted after running the mount command")
+	// the device is closed after the failure
+	assert.Equal(t, "cryptsetup close resticprofile-sdb1", fake.commands[len(fake.commands)-1])
+}
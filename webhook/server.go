@@ -0,0 +1,241 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/creativeprojects/clog"
+)
+
+// RunPath is the endpoint triggering a command of a profile: POST /run/<profile>.<command>
+const RunPath = "/run/"
+
+const maxBodySize = 64 * 1024
+
+// Runner runs a command of a profile. It is called in its own goroutine.
+type Runner func(profile, command string) error
+
+// Options of the webhook server
+type Options struct {
+	Secret    []byte       // key of the HMAC signature of the requests (required)
+	Allow     []string     // "profile.command" patterns that can be triggered, like "db.backup" or "*.copy"
+	AllowFrom []*net.IPNet // networks of the clients allowed to send requests (any client when empty)
+	Exists    func(profile string) bool
+	Runner    Runner
+}
+
+// Response is the JSON body of the response to a valid request
+type Response struct {
+	Profile string `json:"profile"`
+	Command string `json:"command"`
+	Status  string `json:"status"`
+}
+
+// Server receives the webhooks triggering the profiles. A profile runs only once at a time:
+// a request for a profile still running is refused with 409 Conflict.
+type Server struct {
+	options Options
+	now     func() time.Time
+	running map[string]bool
+	seen    map[string]time.Time // signatures already used, with the time they expire
+	mu      sync.Mutex
+	wg      sync.WaitGroup
+}
+
+// NewServer creates the webhook handler
+func NewServer(options Options) (*Server, error) {
+	if len(options.Secret) == 0 {
+		return nil, errors.New("a secret is required to verify the requests")
+	}
+	if options.Runner == nil {
+		return nil, errors.New("no runner")
+	}
+	for _, pattern := range options.Allow {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+	return &Server{
+		options: options,
+		now:     time.Now,
+		running: make(map[string]bool),
+		seen:    make(map[string]time.Time),
+	}, nil
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.URL.Path, RunPath) {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.allowedFrom(r.RemoteAddr) {
+		clog.Warningf("webhook: refused request from %s", r.RemoteAddr)
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize))
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	timestamp, signature := r.Header.Get(TimestampHeader), r.Header.Get(SignatureHeader)
+	err = Verify(s.options.Secret, timestamp, signature, r.Method, r.URL.Path, body, s.now())
+	if err == nil && !s.firstUse(timestamp, signature) {
+		err = ErrReplayedSignature
+	}
+	if err != nil {
+		clog.Warningf("webhook: refused request from %s: %s", r.RemoteAddr, err)
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	profile, command, found := ParseTarget(strings.TrimPrefix(r.URL.Path, RunPath))
+	if !found || (s.options.Exists != nil && !s.options.Exists(profile)) {
+		http.NotFound(w, r)
+		return
+	}
+	if !s.allowed(profile, command) {
+		clog.Warningf("webhook: %s.%s is not in the allowed list (request from %s)", profile, command, r.RemoteAddr)
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+	if !s.start(profile, command) {
+		http.Error(w, fmt.Sprintf("profile '%s' is already running", profile), http.StatusConflict)
+		return
+	}
+	clog.Infof("webhook: starting %s.%s (request from %s)", profile, command, r.RemoteAddr)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	_ = json.NewEncoder(w).Encode(Response{Profile: profile, Command: command, Status: "started"})
+}
+
+// Wait waits until all the commands started by the server are finished
+func (s *Server) Wait() {
+	s.wg.Wait()
+}
+
+// start runs the command in the background, unless the profile is already running
+func (s *Server) start(profile, command string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running[profile] {
+		return false
+	}
+	s.running[profile] = true
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		err := s.options.Runner(profile, command)
+		if err != nil {
+			clog.Errorf("webhook: %s.%s failed: %s", profile, command, err)
+		} else {
+			clog.Infof("webhook: %s.%s finished", profile, command)
+		}
+		s.mu.Lock()
+		delete(s.running, profile)
+		s.mu.Unlock()
+	}()
+	return true
+}
+
+// firstUse records the signature of a verified request, and returns false when the same signature was already received.
+// The signature covers the timestamp: it is the only key of the cache. A signature is kept until its timestamp is out
+// of MaxClockSkew: the request would be refused as expired after that.
+func (s *Server) firstUse(timestamp, signature string) bool {
+	now := s.now()
+	key := normalizeSignature(signature)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for used, expires := range s.seen {
+		if now.After(expires) {
+			delete(s.seen, used)
+		}
+	}
+	if _, found := s.seen[key]; found {
+		return false
+	}
+	seconds, _ := parseTimestamp(timestamp)
+	s.seen[key] = time.Unix(seconds, 0).Add(MaxClockSkew)
+	return true
+}
+
+// allowed returns true when the command of the profile matches one of the allowed patterns
+func (s *Server) allowed(profile, command string) bool {
+	target := profile + "." + command
+	for _, pattern := range s.options.Allow {
+		if matched, _ := path.Match(pattern, target); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// allowedFrom returns true when the client address is in one of the allowed networks
+func (s *Server) allowedFrom(remoteAddr string) bool {
+	if len(s.options.AllowFrom) == 0 {
+		return true
+	}
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, network := range s.options.AllowFrom {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseTarget splits "<profile>.<command>" (the profile name can contain dots, the command cannot)
+func ParseTarget(target string) (profile, command string, found bool) {
+	index := strings.LastIndex(target, ".")
+	if index <= 0 || index == len(target)-1 || strings.Contains(target, "/") {
+		return "", "", false
+	}
+	return target[:index], target[index+1:], true
+}
+
+// ParseNetworks parses a list of IP addresses and networks in CIDR notation ("10.0.0.0/8", "192.168.1.10", "::1")
+func ParseNetworks(values []string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if !strings.Contains(value, "/") {
+			ip := net.ParseIP(value)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid IP address %q", value)
+			}
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid network %q: %w", value, err)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
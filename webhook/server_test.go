@@ -0,0 +1,189 @@
+package webhook
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTarget(t *testing.T) {
+	testData := []struct {
+		target, profile, command string
+		found                    bool
+	}{
+		{"db.backup", "db", "backup", true},
+		{"my.profile.copy", "my.profile", "copy", true},
+		{"backup", "", "", false},
+		{".backup", "", "", false},
+		{"db.", "", "", false},
+		{"db/other.backup", "", "", false},
+	}
+	for _, testItem := range testData {
+		t.Run(testItem.target, func(t *testing.T) {
+			profile, command, found := ParseTarget(testItem.target)
+			assert.Equal(t, testItem.found, found)
+			assert.Equal(t, testItem.profile, profile)
+			assert.Equal(t, testItem.command, command)
+		})
+	}
+}
+
+func TestParseNetworks(t *testing.T) {
+	networks, err := ParseNetworks([]string{"10.0.0.0/8", "192.168.1.10", "::1"})
+	require.NoError(t, err)
+	require.Len(t, networks, 3)
+	assert.Equal(t, "10.0.0.0/8", networks[0].String())
+	assert.Equal(t, "192.168.1.10/32", networks[1].String())
+	assert.Equal(t, "::1/128", networks[2].String())
+
+	_, err = ParseNetworks([]string{"host"})
+	assert.Error(t, err)
+	_, err = ParseNetworks([]string{"10.0.0.0/40"})
+	assert.Error(t, err)
+}
+
+func TestNewServer(t *testing.T) {
+	runner := func(profile, command string) error { return nil }
+	_, err := NewServer(Options{Runner: runner})
+	assert.Error(t, err)
+	_, err = NewServer(Options{Secret: []byte("secret")})
+	assert.Error(t, err)
+	_, err = NewServer(Options{Secret: []byte("secret"), Runner: runner, Allow: []string{"[db"}})
+	assert.Error(t, err)
+}
+
+func TestServer(t *testing.T) {
+	secret := []byte("secret")
+	release := make(chan struct{})
+	runs := make([]string, 0)
+	mu := sync.Mutex{}
+	networks, err := ParseNetworks([]string{"127.0.0.1"})
+	require.NoError(t, err)
+
+	server, err := NewServer(Options{
+		Secret:    secret,
+		Allow:     []string{"db.backup", "*.copy"},
+		AllowFrom: networks,
+		Exists:    func(profile string) bool { return profile != "unknown" },
+		Runner: func(profile, command string) error {
+			mu.Lock()
+			runs = append(runs, profile+"."+command)
+			mu.Unlock()
+			<-release
+			return nil
+		},
+	})
+	require.NoError(t, err)
+
+	count := 0
+	send := func(method, target string, sign bool) *httptest.ResponseRecorder {
+		// each request has its own body: the same signature cannot be used twice
+		count++
+		body := `{"source":"ci","request":` + strconv.Itoa(count) + `}`
+		request := httptest.NewRequest(method, RunPath+target, strings.NewReader(body))
+		request.RemoteAddr = "127.0.0.1:4567"
+		if sign {
+			now := time.Now().Unix()
+			request.Header.Set(TimestampHeader, strconv.FormatInt(now, 10))
+			request.Header.Set(SignatureHeader, Sign(secret, now, method, RunPath+target, []byte(body)))
+		}
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	assert.Equal(t, http.StatusMethodNotAllowed, send(http.MethodGet, "db.backup", true).Code)
+	assert.Equal(t, http.StatusUnauthorized, send(http.MethodPost, "db.backup", false).Code)
+	assert.Equal(t, http.StatusNotFound, send(http.MethodPost, "unknown.backup", true).Code)
+	assert.Equal(t, http.StatusNotFound, send(http.MethodPost, "backup", true).Code)
+	assert.Equal(t, http.StatusForbidden, send(http.MethodPost, "db.forget", true).Code)
+
+	response := send(http.MethodPost, "db.backup", true)
+	assert.Equal(t, http.StatusAccepted, response.Code)
+	decoded := Response{}
+	require.NoError(t, json.NewDecoder(response.Body).Decode(&decoded))
+	assert.Equal(t, Response{Profile: "db", Command: "backup", Status: "started"}, decoded)
+
+	// the profile is still running
+	assert.Equal(t, http.StatusConflict, send(http.MethodPost, "db.copy", true).Code)
+	assert.Equal(t, http.StatusAccepted, send(http.MethodPost, "files.copy", true).Code)
+
+	close(release)
+	server.Wait()
+	assert.ElementsMatch(t, []string{"db.backup", "files.copy"}, runs)
+	assert.Equal(t, http.StatusAccepted, send(http.MethodPost, "db.copy", true).Code)
+	server.Wait()
+}
+
+func TestServerRefusesSignatureOfAnotherRequest(t *testing.T) {
+	secret := []byte("secret")
+	server, err := NewServer(Options{
+		Secret: secret,
+		Allow:  []string{"*"},
+		Runner: func(profile, command string) error { return nil },
+	})
+	require.NoError(t, err)
+	now := time.Unix(1700000000, 0)
+	server.now = func() time.Time { return now }
+
+	signature := Sign(secret, now.Unix(), http.MethodPost, RunPath+"db.check", nil)
+	prefix := ""
+	send := func(target string) int {
+		request := httptest.NewRequest(http.MethodPost, RunPath+target, nil)
+		request.Header.Set(TimestampHeader, prefix+strconv.FormatInt(now.Unix(), 10))
+		request.Header.Set(SignatureHeader, signature)
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, request)
+		return recorder.Code
+	}
+
+	// the signature of a check cannot start a forget
+	assert.Equal(t, http.StatusUnauthorized, send("db.forget"))
+	assert.Equal(t, http.StatusAccepted, send("db.check"))
+	server.Wait()
+
+	// nor be replayed, even with another form of the same timestamp
+	assert.Equal(t, http.StatusUnauthorized, send("db.check"))
+	for _, prefix = range []string{"+", "0", "00"} {
+		assert.Equal(t, http.StatusUnauthorized, send("db.check"), prefix)
+	}
+	prefix = ""
+	assert.Len(t, server.seen, 1)
+
+	// the used signatures are forgotten once expired
+	now = now.Add(2 * MaxClockSkew)
+	signature = Sign(secret, now.Unix(), http.MethodPost, RunPath+"db.check", nil)
+	assert.Equal(t, http.StatusAccepted, send("db.check"))
+	server.Wait()
+	assert.Len(t, server.seen, 1)
+}
+
+func TestServerAllowFrom(t *testing.T) {
+	networks, err := ParseNetworks([]string{"10.0.0.0/8"})
+	require.NoError(t, err)
+	server, err := NewServer(Options{
+		Secret:    []byte("secret"),
+		Allow:     []string{"*"},
+		AllowFrom: networks,
+		Runner:    func(profile, command string) error { return nil },
+	})
+	require.NoError(t, err)
+
+	assert.True(t, server.allowedFrom("10.1.2.3:1234"))
+	assert.False(t, server.allowedFrom("192.168.1.1:1234"))
+	assert.False(t, server.allowedFrom("invalid"))
+
+	request := httptest.NewRequest(http.MethodPost, RunPath+"db.backup", nil)
+	request.RemoteAddr = "192.168.1.1:1234"
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+}
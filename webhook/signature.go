@@ -0,0 +1,72 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// SignatureHeader contains the HMAC-SHA256 of the timestamp, the method, the path and the body of the request, as "sha256=<hex>"
+	SignatureHeader = "X-Resticprofile-Signature"
+	// TimestampHeader contains the time the request was signed, in seconds since the epoch
+	TimestampHeader = "X-Resticprofile-Timestamp"
+	// MaxClockSkew is the maximum difference between the timestamp of a request and the time of the server
+	MaxClockSkew = 5 * time.Minute
+
+	signaturePrefix = "sha256="
+)
+
+var (
+	ErrMissingSignature  = errors.New("missing signature")
+	ErrInvalidSignature  = errors.New("invalid signature")
+	ErrExpiredSignature  = errors.New("expired signature")
+	ErrReplayedSignature = errors.New("signature already used")
+)
+
+// Sign returns the signature of a request sent at this time to this path with this body:
+// the HMAC-SHA256 of "<timestamp>.<METHOD>.<path>.<body>"
+func Sign(secret []byte, timestamp int64, method, path string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "." + strings.ToUpper(method) + "." + path + "."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature of a request. The timestamp must be within MaxClockSkew of now, so a request cannot be replayed later.
+func Verify(secret []byte, timestamp, signature, method, path string, body []byte, now time.Time) error {
+	if timestamp == "" || signature == "" {
+		return ErrMissingSignature
+	}
+	seconds, err := parseTimestamp(timestamp)
+	if err != nil {
+		return err
+	}
+	if skew := now.Sub(time.Unix(seconds, 0)); skew > MaxClockSkew || skew < -MaxClockSkew {
+		return ErrExpiredSignature
+	}
+	expected := Sign(secret, seconds, method, path, body)
+	if !hmac.Equal([]byte(expected), []byte(normalizeSignature(signature))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// parseTimestamp returns the seconds of a timestamp in canonical decimal form: a leading "+" or leading zeros
+// would give another header value with the same signature
+func parseTimestamp(timestamp string) (int64, error) {
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || strconv.FormatInt(seconds, 10) != timestamp {
+		return 0, fmt.Errorf("%w: invalid timestamp %q", ErrInvalidSignature, timestamp)
+	}
+	return seconds, nil
+}
+
+func normalizeSignature(signature string) string {
+	return strings.ToLower(strings.TrimSpace(signature))
+}
@@ -0,0 +1,44 @@
+package webhook
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignature(t *testing.T) {
+	secret := []byte("secret")
+	now := time.Unix(1700000000, 0)
+	body := []byte(`{"source":"ci"}`)
+	path := "/run/db.backup"
+	signature := Sign(secret, now.Unix(), "POST", path, body)
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+
+	assert.Regexp(t, "^sha256=[0-9a-f]{64}$", signature)
+	assert.NoError(t, Verify(secret, timestamp, signature, "POST", path, body, now))
+	assert.NoError(t, Verify(secret, timestamp, signature, "POST", path, body, now.Add(4*time.Minute)))
+
+	assert.ErrorIs(t, Verify(secret, "", signature, "POST", path, body, now), ErrMissingSignature)
+	assert.ErrorIs(t, Verify(secret, timestamp, "", "POST", path, body, now), ErrMissingSignature)
+	assert.ErrorIs(t, Verify(secret, "yesterday", signature, "POST", path, body, now), ErrInvalidSignature)
+	assert.ErrorIs(t, Verify(secret, "+"+timestamp, signature, "POST", path, body, now), ErrInvalidSignature)
+	assert.ErrorIs(t, Verify(secret, "0"+timestamp, signature, "POST", path, body, now), ErrInvalidSignature)
+	assert.ErrorIs(t, Verify(secret, " "+timestamp, signature, "POST", path, body, now), ErrInvalidSignature)
+	assert.ErrorIs(t, Verify([]byte("other"), timestamp, signature, "POST", path, body, now), ErrInvalidSignature)
+	assert.ErrorIs(t, Verify(secret, timestamp, signature, "POST", path, []byte("{}"), now), ErrInvalidSignature)
+	assert.ErrorIs(t, Verify(secret, timestamp, signature, "PUT", path, body, now), ErrInvalidSignature)
+	assert.ErrorIs(t, Verify(secret, timestamp, signature, "POST", path, body, now.Add(10*time.Minute)), ErrExpiredSignature)
+	assert.ErrorIs(t, Verify(secret, timestamp, signature, "POST", path, body, now.Add(-10*time.Minute)), ErrExpiredSignature)
+}
+
+func TestSignatureOfAnotherPath(t *testing.T) {
+	secret := []byte("secret")
+	now := time.Unix(1700000000, 0)
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	signature := Sign(secret, now.Unix(), "POST", "/run/db.check", nil)
+
+	assert.NoError(t, Verify(secret, timestamp, signature, "POST", "/run/db.check", nil, now))
+	assert.ErrorIs(t, Verify(secret, timestamp, signature, "POST", "/run/db.forget", nil, now), ErrInvalidSignature)
+}
@@ -8,3 +8,8 @@ import "errors"
 func RunElevated(port int) error {
 	return errors.New("only available on windows platform")
 }
+
+// IsElevated always returns false when not on windows platform
+func IsElevated() bool {
+	return false
+}
@@ -50,3 +50,8 @@ func GetConsoleWindow() windows.Handle {
 	ret, _, _ := getConsoleWindow.Call()
 	return windows.Handle(ret)
 }
+
+// IsElevated returns true when resticprofile runs in elevated mode (as administrator)
+func IsElevated() bool {
+	return windows.GetCurrentProcessToken().IsElevated()
+}
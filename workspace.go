@@ -0,0 +1,38 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/term"
+)
+
+// loadWorkspaceConfig loads the configuration directory (--config-dir) and returns the workspace,
+// and the configuration file defining the selected profile or group (nil when no file defines it)
+func loadWorkspaceConfig(flags commandLineFlags) (*config.Workspace, *config.Config, error) {
+	if flags.profiles != "" {
+		return nil, nil, errors.New("profiles cannot be selected by labels with a configuration directory")
+	}
+	workspace, err := config.LoadWorkspace(flags.configDir, flags.format)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot load configuration directory: %w", err)
+	}
+	c, _ := workspace.FindConfig(flags.name)
+	return workspace, c, nil
+}
+
+// runOwnCommandInWorkspace runs the own command with each configuration file of the workspace,
+// for the commands that are not about a single profile (like "profiles" or "schedule --all")
+func runOwnCommandInWorkspace(workspace *config.Workspace, flags commandLineFlags) (exitCode int) {
+	for _, c := range workspace.GetConfigs() {
+		term.Printf("\n%s:\n", c.GetConfigFile())
+		flags.config = c.GetConfigFile()
+		if err := ownCommands.Run(c, flags.resticArgs[0], flags, flags.resticArgs[1:]); err != nil {
+			clog.Error(err)
+			exitCode = getExitCode(err)
+		}
+	}
+	return
+}
@@ -11,6 +11,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/creativeprojects/clog"
@@ -19,10 +20,12 @@ import (
 	"github.com/creativeprojects/resticprofile/lock"
 	"github.com/creativeprojects/resticprofile/monitor"
 	"github.com/creativeprojects/resticprofile/monitor/hook"
+	"github.com/creativeprojects/resticprofile/monitor/otel"
 	"github.com/creativeprojects/resticprofile/restic"
 	"github.com/creativeprojects/resticprofile/shell"
 	"github.com/creativeprojects/resticprofile/term"
 	"github.com/creativeprojects/resticprofile/util/collect"
+	"github.com/creativeprojects/resticprofile/util/redact"
 	"golang.org/x/exp/slices"
 )
 
@@ -30,6 +33,7 @@ type resticWrapper struct {
 	resticBinary string
 	dryRun       bool
 	noLock       bool
+	readOnly     bool
 	lockWait     *time.Duration
 	profile      *config.Profile
 	global       *config.Global
@@ -40,11 +44,34 @@ type resticWrapper struct {
 	stdin        io.ReadCloser
 	progress     []monitor.Receiver
 	sender       *hook.Sender
+	configIssues []string
+	configError  error
+	configHash   string
+	exitCodeFrom string
+	scheduleName string
 
 	// States
-	startTime     time.Time
-	executionTime time.Duration
-	doneTryUnlock bool
+	startTime       time.Time
+	executionTime   time.Duration
+	doneTryUnlock   bool
+	doneDiskFull    bool // the run-on-disk-full commands already ran
+	diff            *monitor.DiffSummary
+	backupSet       string          // name of the backup set currently running (empty without sets)
+	repository      string          // name of the additional repository currently running (empty for the main repository)
+	backupSummary   monitor.Summary // summary of the last backup command
+	commandSummary  monitor.Summary // summary of the command of the profile
+	commandWarnings string          // stderr of the command of the profile when it ended with warnings
+	quota           *quotaState     // last check of the quota of the repository
+	anomaly         *anomalyResult  // anomalies detected in the last backup (nil when not checked)
+	repositorySize  *uint64         // size of the repository read after the backup (nil when unknown)
+	interrupted     atomic.Bool
+	resticExitCode  int  // exit code of the last restic command (-1 before running restic)
+	remoteLocked    bool // the last restic command failed on a repository lock
+	networkFailure  bool // the last restic command failed to reach the repository
+	runTimeData     *config.RunTimeData
+	commandTags     []string       // output of the "tag-command" of the backup (nil when not evaluated yet)
+	output          *outputCapture // end of the output of the run (only when attached to a sender)
+	tracer          *otel.Tracer   // trace of the run sent to OpenTelemetry (optional)
 }
 
 func newResticWrapper(
@@ -63,24 +90,47 @@ func newResticWrapper(
 	senderDryRun := dryRun || slices.ContainsFunc(moreArgs, collect.In("--dry-run", "-n"))
 
 	return &resticWrapper{
-		resticBinary:  resticBinary,
-		dryRun:        dryRun,
-		noLock:        false,
-		lockWait:      nil,
-		profile:       profile,
-		global:        global,
-		command:       command,
-		moreArgs:      moreArgs,
-		sigChan:       c,
-		stdin:         os.Stdin,
-		progress:      make([]monitor.Receiver, 0),
-		sender:        hook.NewSender(global.CACertificates, "resticprofile/"+version, global.SenderTimeout, senderDryRun),
-		startTime:     time.Unix(0, 0),
-		executionTime: 0,
-		doneTryUnlock: false,
+		resticBinary:   resticBinary,
+		dryRun:         dryRun,
+		noLock:         false,
+		lockWait:       nil,
+		profile:        profile,
+		global:         global,
+		command:        command,
+		moreArgs:       moreArgs,
+		sigChan:        c,
+		stdin:          os.Stdin,
+		progress:       make([]monitor.Receiver, 0),
+		sender:         hook.NewSender(global.CACertificates, "resticprofile/"+version, global.SenderTimeout, senderDryRun),
+		exitCodeFrom:   exitCodeFromResticprofile,
+		startTime:      time.Unix(0, 0),
+		executionTime:  0,
+		doneTryUnlock:  false,
+		resticExitCode: -1,
 	}
 }
 
+// setConfigIssues sets the issues found in the configuration, and the error failing the run because of them (if any)
+func (r *resticWrapper) setConfigIssues(issues []string, err error) {
+	r.configIssues = issues
+	r.configError = err
+}
+
+// setConfigHash sets the hash of the configuration files, recorded with the summary of each command
+func (r *resticWrapper) setConfigHash(hash string) {
+	r.configHash = hash
+}
+
+// setExitCodeFrom selects the step giving the exit code of a failed run (see --exit-code-from)
+func (r *resticWrapper) setExitCodeFrom(from string) {
+	r.exitCodeFrom = from
+}
+
+// setScheduleName sets the name of the scheduled job running the profile (available to the run-time templates)
+func (r *resticWrapper) setScheduleName(name string) {
+	r.scheduleName = name
+}
+
 // ignoreLock configures resticWrapper to ignore the lock defined in profile
 func (r *resticWrapper) ignoreLock() {
 	r.noLock = true
@@ -115,6 +165,11 @@ func (r *resticWrapper) summary(command string, summary monitor.Summary, stderr
 	if r.dryRun {
 		return
 	}
+	summary.Repository = r.repository
+	summary.ConfigIssues = r.configIssues
+	summary.ConfigHash = r.configHash
+	summary.ErrorCode = r.errorCode(result)
+	r.recordCommandResult(command, summary, stderr, result)
 	for _, p := range r.progress {
 		p.Summary(command, summary, stderr, result)
 	}
@@ -156,6 +211,9 @@ func (r *resticWrapper) getBackupAction() func() error {
 	backupAction := r.getCommandAction(constants.CommandBackup)
 
 	return func() (err error) {
+		// Backups blocked by an exceeded quota
+		err = r.checkQuotaBlock()
+
 		// Check before
 		if err == nil && r.profile.Backup != nil && r.profile.Backup.CheckBefore {
 			err = r.runCheck()
@@ -167,8 +225,22 @@ func (r *resticWrapper) getBackupAction() func() error {
 		}
 
 		// Backup command
+		runBackup := func() error {
+			if sets := r.profile.GetBackupSetNames(); len(sets) > 0 {
+				return r.runBackupSets(sets, backupAction)
+			}
+			r.checkSourceAccess()
+			return backupAction()
+		}
 		if err == nil {
-			err = backupAction()
+			err = runBackup()
+
+			// Additional repositories
+			if names := r.profile.GetRepositoryNames(); len(names) > 0 {
+				if repositoriesErr := r.runRepositories(names, err == nil, runBackup); err == nil {
+					err = repositoriesErr
+				}
+			}
 		}
 
 		// Retention after
@@ -181,10 +253,49 @@ func (r *resticWrapper) getBackupAction() func() error {
 			err = r.runCheck()
 		}
 
+		// Quota of the repository
+		if err == nil {
+			r.runQuotaCheck()
+		}
+
 		return
 	}
 }
 
+func (r *resticWrapper) getRestoreAction() func() error {
+	restoreAction := r.getCommandAction(constants.CommandRestore)
+
+	return func() error {
+		r.checkRestoreTarget()
+		return restoreAction()
+	}
+}
+
+// getRunner returns the action running a command
+func (r *resticWrapper) getRunner(command string) (runner func() error) {
+	switch command {
+	case constants.CommandCopy:
+		runner = r.getCopyAction()
+	case constants.CommandBackup:
+		runner = r.getBackupAction()
+	case constants.CommandRestore:
+		runner = r.getRestoreAction()
+	case constants.SectionConfigurationVerify:
+		runner = r.getVerifyRestoreAction()
+	case constants.CommandUnlock:
+		runner = r.getUnlockAction()
+	case constants.CommandMount:
+		if r.mountInBackground() {
+			runner = r.getMountBackgroundAction()
+		} else {
+			runner = r.getCommandAction(command)
+		}
+	default:
+		runner = r.getCommandAction(command)
+	}
+	return
+}
+
 func (r *resticWrapper) runProfile() error {
 	lockFile := r.profile.Lock
 	if r.noLock || r.dryRun {
@@ -192,32 +303,45 @@ func (r *resticWrapper) runProfile() error {
 	}
 
 	r.startTime = time.Now()
+	endTrace := r.startTrace()
+	stopInterruptPolicy := r.setupInterruptPolicy()
+	defer stopInterruptPolicy()
+
+	if err := r.checkReadOnly(r.command); err != nil {
+		endTrace(err)
+		return err
+	}
+	// the role of the credentials is checked before running any hook
+	if err := r.profile.CheckRoleCommand(r.command); err != nil {
+		endTrace(err)
+		return err
+	}
+
 	profileShellCommands, shellCommands := r.profile.GetRunShellCommandsSections(r.command)
 	sendMonitoring := r.profile.GetMonitoringSections(r.command)
+	r.startOutputCapture(sendMonitoring)
 
 	err := lockRun(lockFile, r.profile.ForceLock, r.lockWait, func(setPID lock.SetPID) error {
 		r.setPID = setPID
 		return runOnFailure(
-			r.runnerWithBeforeAndAfter(profileShellCommands, "", func() (err error) {
+			r.withConfigCheck(r.withPremount(r.runnerWithBeforeAndAfter(profileShellCommands, "", func() (err error) {
 				// breaking change from 0.7.0 and 0.7.1:
 				// run the initialization after the pre-profile commands
-				if (r.global.Initialize || r.profile.Initialize) && r.command != constants.CommandInit {
+				if (r.global.Initialize || r.profile.Initialize) && r.command != constants.CommandInit && !r.readOnly {
 					_ = r.runInitialize()
 					// it's ok for the initialize to error out when the repository exists
 				}
 
 				r.sendBefore(sendMonitoring, r.command)
+				r.pingHealthchecks(sendMonitoring, r.command, config.HealthchecksPingStart, nil)
 
 				// Main command
 				{
 					var runner func() error
-					switch r.command {
-					case constants.CommandCopy:
-						runner = r.getCopyAction()
-					case constants.CommandBackup:
-						runner = r.getBackupAction()
-					default:
-						runner = r.getCommandAction(r.command)
+					if alias := r.profile.GetCommandAlias(r.command); alias != nil {
+						runner = r.getCommandAliasAction(alias)
+					} else {
+						runner = r.getRunner(r.command)
 					}
 
 					// Wrap command action in "run-before" & "run-after" from section
@@ -229,12 +353,19 @@ func (r *resticWrapper) runProfile() error {
 
 				if err == nil {
 					r.sendAfter(sendMonitoring, r.command)
+					r.sendHeartbeat(sendMonitoring, r.command)
+					r.pingHealthchecks(sendMonitoring, r.command, config.HealthchecksPingSuccess, nil)
 				}
 				return
-			}),
+			}))),
 			// on failure
 			func(err error) {
 				r.sendAfterFail(sendMonitoring, r.command, err)
+				r.pingHealthchecks(sendMonitoring, r.command, config.HealthchecksPingFail, err)
+				if r.skipRunAfterFail() {
+					clog.Infof("profile '%s': interrupted, skipping run-after-fail", r.profile.Name)
+					return
+				}
 				// "run-after-fail" in section (returns nil when no-error or not defined)
 				if r.runAfterFailCommands(shellCommands, err, r.command) == nil {
 					// "run-after-fail" in profile
@@ -249,9 +380,23 @@ func (r *resticWrapper) runProfile() error {
 		)
 	})
 	if err != nil {
-		return err
+		err = r.withExitCode(err)
+	}
+	endTrace(err)
+	return err
+}
+
+// withConfigCheck fails the run before any premount, run-before hook or restic command when the configuration
+// has issues failing the run (e.g. fail-on-deprecation). The failure is still reported by the monitoring and the
+// run-after-fail hooks.
+func (r *resticWrapper) withConfigCheck(action func() error) func() error {
+	return func() error {
+		if r.configError != nil {
+			r.summary(r.command, monitor.Summary{}, "", r.configError)
+			return r.configError
+		}
+		return action()
 	}
-	return nil
 }
 
 func (r *resticWrapper) getResticVersion() string {
@@ -348,6 +493,8 @@ func (r *resticWrapper) getCommandArgumentsFilter(command string) argumentsFilte
 func (r *resticWrapper) prepareCommand(command string, args *shell.Args, allowExtraValues bool) shellCommandDefinition {
 	// Create local instance to allow modification
 	args = args.Clone()
+	// forget and prune run with the credentials of the maintainer of an append-only repository
+	r.profile.SetMaintainerFlags(command, args)
 
 	filter := r.getCommandArgumentsFilter(command)
 
@@ -358,10 +505,14 @@ func (r *resticWrapper) prepareCommand(command string, args *shell.Args, allowEx
 		moreArgs = filter(moreArgs, allowExtraValues)
 	}
 	args.AddArgs(moreArgs, shell.ArgCommandLineEscape)
+	r.resolveRunTimeArgs(args)
 
-	// Special case for backup command
+	// Special case for backup command: long lists are written to files when the command line is too long
+	var cleanup func()
 	if command == constants.CommandBackup {
-		args.AddArgs(r.profile.GetBackupSource(), shell.ArgConfigBackupSource)
+		var source []string
+		source, cleanup = r.spillLongArguments(args, r.resolveRunTimeValues(r.getBackupSource()))
+		args.AddArgs(source, shell.ArgConfigBackupSource)
 	}
 
 	// Build arguments and publicArguments (for logging)
@@ -377,6 +528,7 @@ func (r *resticWrapper) prepareCommand(command string, args *shell.Args, allowEx
 
 	env := append(os.Environ(), r.getEnvironment()...)
 	env = append(env, r.getProfileEnvironment()...)
+	env = append(env, r.profile.GetMaintainerEnvironment(command)...)
 
 	clog.Debugf("starting command: %s %s", r.resticBinary, strings.Join(publicArguments, " "))
 	rCommand := newShellCommand(r.resticBinary, arguments, env, r.getShell(), r.dryRun, r.sigChan, r.setPID)
@@ -385,6 +537,8 @@ func (r *resticWrapper) prepareCommand(command string, args *shell.Args, allowEx
 	rCommand.stdout = term.GetOutput()
 	rCommand.stderr = term.GetErrorOutput()
 	rCommand.streamError = r.profile.StreamError
+	rCommand.cleanup = cleanup
+	rCommand.process = r.profile.GetCommandProcessSettings(command)
 
 	return rCommand
 }
@@ -394,6 +548,7 @@ func (r *resticWrapper) runInitialize() error {
 	clog.Infof("profile '%s': initializing repository (if not existing)", r.profile.Name)
 	args := r.profile.GetCommandFlags(constants.CommandInit)
 	rCommand := r.prepareCommand(constants.CommandInit, args, false)
+	rCommand.capture = r.captureWriter()
 	// don't display any error
 	rCommand.stderr = nil
 	_, stderr, err := runShellCommand(rCommand)
@@ -411,6 +566,7 @@ func (r *resticWrapper) runInitializeCopy() error {
 		return nil // copy is not configured, do nothing
 	}
 	rCommand := r.prepareCommand(constants.CommandInit, args, false)
+	rCommand.capture = r.captureWriter()
 	// don't display any error
 	rCommand.stderr = nil
 	_, stderr, err := runShellCommand(rCommand)
@@ -424,10 +580,17 @@ func (r *resticWrapper) runCheck() error {
 	clog.Infof("profile '%s': checking repository consistency", r.profile.Name)
 	r.start(constants.CommandCheck)
 	args := r.profile.GetCommandFlags(constants.CommandCheck)
+	jsonScanner := r.jsonSummaryScanner(constants.CommandCheck, args)
 	for {
+		if err := r.checkInterrupted(); err != nil {
+			return err
+		}
 		rCommand := r.prepareCommand(constants.CommandCheck, args, false)
+		rCommand.scanOutput = jsonScanner
+		rCommand.capture = r.captureWriter()
 		summary, stderr, err := runShellCommand(rCommand)
 		r.executionTime += summary.Duration
+		r.setResticExitCode(err, summary.OutputAnalysis)
 		r.summary(constants.CommandCheck, summary, stderr, err)
 		if err != nil {
 			if r.canRetryAfterError(constants.CommandCheck, summary, err) {
@@ -440,13 +603,24 @@ func (r *resticWrapper) runCheck() error {
 }
 
 func (r *resticWrapper) runRetention() error {
+	if err := r.profile.CheckRoleCommand(constants.CommandForget); err != nil {
+		return err
+	}
 	clog.Infof("profile '%s': cleaning up repository using retention information", r.profile.Name)
 	r.start(constants.SectionConfigurationRetention)
+	r.repositorySize = nil // the size of the repository changes with prune
 	args := r.profile.GetRetentionFlags()
+	jsonScanner := r.jsonSummaryScanner(constants.CommandForget, args)
 	for {
+		if err := r.checkInterrupted(); err != nil {
+			return err
+		}
 		rCommand := r.prepareCommand(constants.CommandForget, args, false)
+		rCommand.scanOutput = jsonScanner
+		rCommand.capture = r.captureWriter()
 		summary, stderr, err := runShellCommand(rCommand)
 		r.executionTime += summary.Duration
+		r.setResticExitCode(err, summary.OutputAnalysis)
 		r.summary(constants.SectionConfigurationRetention, summary, stderr, err)
 		if err != nil {
 			if r.canRetryAfterError(constants.CommandForget, summary, err) {
@@ -459,25 +633,58 @@ func (r *resticWrapper) runRetention() error {
 }
 
 func (r *resticWrapper) runCommand(command string) error {
+	if r.readOnly && !isReadOnlyCommand(command) {
+		return fmt.Errorf("profile '%s': command '%s' is not allowed in read-only mode", r.profile.Name, command)
+	}
+	if err := r.profile.CheckRoleCommand(command); err != nil {
+		return err
+	}
 	clog.Infof("profile '%s': starting '%s'", r.profile.Name, command)
 	r.start(command)
 	args := r.profile.GetCommandFlags(command)
 
+	if command == constants.CommandBackup {
+		args = r.getBackupFlags()
+		if err := r.addCommandTags(args); err != nil {
+			return fmt.Errorf("%s on profile '%s': %w", r.command, r.profile.Name, err)
+		}
+		cleanup, err := r.generateFilesFrom(args)
+		if err != nil {
+			return fmt.Errorf("%s on profile '%s': %w", r.command, r.profile.Name, err)
+		}
+		defer cleanup()
+		cleanupExclude, err := r.generateExcludeFile(args)
+		if err != nil {
+			return fmt.Errorf("%s on profile '%s': %w", r.command, r.profile.Name, err)
+		}
+		defer cleanupExclude()
+	}
+	if r.repository != "" {
+		r.profile.SetRepositoryFlags(r.repository, args)
+	}
+	jsonScanner := r.jsonSummaryScanner(command, args)
+
 	streamSource := io.NopCloser(strings.NewReader(""))
 	defer func() { streamSource.Close() }()
 
 	for {
+		if err := r.checkInterrupted(); err != nil {
+			return err
+		}
 		if err := streamSource.Close(); err != nil {
 			return fmt.Errorf("%s on profile '%s'. Failed closing stream source: %w", r.command, r.profile.Name, err)
 		}
 
 		rCommand := r.prepareCommand(command, args, true)
+		rCommand.capture = r.captureWriter()
+		// the content of a file must be left untouched
+		rCommand.rawStdout = command == constants.CommandDump || command == constants.CommandCat
 
 		if command == constants.CommandBackup && r.profile.Backup != nil {
 			// Add output scanners
-			if len(r.progress) > 0 {
-				if r.profile.Backup.ExtendedStatus {
-					rCommand.scanOutput = shell.ScanBackupJson
+			if len(r.progress) > 0 || r.profile.JSONSummary || !r.profile.Anomaly.IsEmpty() {
+				if jsonScanner != nil {
+					rCommand.scanOutput = jsonScanner
 				} else if !term.OsStdoutIsTerminal() {
 					// restic detects its output is not a terminal and no longer displays the monitor.
 					// Scan plain output only if resticprofile is not run from a terminal (e.g. schedule)
@@ -494,10 +701,23 @@ func (r *resticWrapper) runCommand(command string) error {
 			} else {
 				return newCommandError(rCommand, "", fmt.Errorf("%s on profile '%s': %w", r.command, r.profile.Name, err))
 			}
+		} else if jsonScanner != nil {
+			rCommand.scanOutput = jsonScanner
 		}
 
 		summary, stderr, err := runShellCommand(rCommand)
 		r.executionTime += summary.Duration
+		r.setResticExitCode(err, summary.OutputAnalysis)
+		// the diff and the storage cost are only available for the main repository
+		if err == nil && command == constants.CommandBackup && r.repository == "" && r.profile.Backup != nil && r.profile.Backup.DiffAfter {
+			summary.Diff = r.runDiffAfterBackup()
+		}
+		if err == nil && command == constants.CommandBackup && r.repository == "" {
+			summary.Storage = r.getStorageSummary(summary)
+		}
+		if command == constants.CommandBackup {
+			r.backupSummary = summary
+		}
 		r.summary(r.command, summary, stderr, err)
 
 		if err != nil && !r.canSucceedAfterError(command, summary, err) {
@@ -506,16 +726,27 @@ func (r *resticWrapper) runCommand(command string) error {
 			}
 			return newCommandError(rCommand, stderr, fmt.Errorf("%s on profile '%s': %w", r.command, r.profile.Name, err))
 		}
+		if command == constants.CommandBackup {
+			r.runAnomalyCheck(summary)
+		}
 		clog.Infof("profile '%s': finished '%s'", r.profile.Name, command)
 		return nil
 	}
 }
 
 func (r *resticWrapper) runUnlock() error {
+	if r.readOnly {
+		return fmt.Errorf("profile '%s': unlock is not allowed in read-only mode", r.profile.Name)
+	}
+	// the age of the stale lock was already checked: only keep the locks of the running processes of this host
+	if err := r.checkUnlock(true, 0); err != nil {
+		return err
+	}
 	clog.Infof("profile '%s': unlock stale locks", r.profile.Name)
 	r.start(constants.CommandUnlock)
 	args := r.profile.GetCommandFlags(constants.CommandUnlock)
 	rCommand := r.prepareCommand(constants.CommandUnlock, args, false)
+	rCommand.capture = r.captureWriter()
 	summary, stderr, err := runShellCommand(rCommand)
 	r.executionTime += summary.Duration
 	r.summary(constants.CommandUnlock, summary, stderr, err)
@@ -547,22 +778,41 @@ func (r *resticWrapper) runShellCommands(commands []string, commandsType, comman
 	if len(command) > 0 {
 		commandsType = commandsType + " " + command
 	}
+	if r.skipHooks(commandsType, len(commands)) {
+		return nil
+	}
 
 	env := append(os.Environ(), r.getEnvironment()...)
 	env = append(env, r.getProfileEnvironment()...)
 	env = append(env, r.getFailEnvironment(failure)...)
 
-	for i, shellCommand := range commands {
+	for i, entry := range commands {
+		if failure == nil {
+			if err := r.checkInterrupted(); err != nil {
+				return fmt.Errorf("%s: %w", commandsType, err)
+			}
+		}
 		clog.Debugf("starting %s on profile %d/%d", commandsType, i+1, len(commands))
-		rCommand := newShellCommand(shellCommand, nil, env, r.getShell(), r.dryRun, r.sigChan, r.setPID)
+		hook, err := r.prepareShellCommand(entry)
+		if err != nil {
+			return newExitCodeError(constants.ExitCodeHook, fmt.Errorf("%s on profile '%s': %w", commandsType, r.profile.Name, err))
+		}
+		rCommand := newShellCommand(hook.commandLine, nil, append(slices.Clip(env), hook.env...), r.getShell(), r.dryRun, r.sigChan, r.setPID)
+		rCommand.dir = hook.dir
 		// stdout are stderr are coming from the default terminal (in case they're redirected)
 		rCommand.stdout = term.GetOutput()
 		rCommand.stderr = term.GetErrorOutput()
+		rCommand.capture = r.captureWriter()
 		term.FlushAllOutput()
+		endSpan := r.traceSpan(commandsType, map[string]any{otel.AttributeHook: commandsType})
 		_, stderr, err := runShellCommand(rCommand)
+		endSpan(err)
+		hook.cleanup()
 		if err != nil {
 			err = fmt.Errorf("%s on profile '%s': %w", commandsType, r.profile.Name, err)
-			return newCommandError(rCommand, stderr, err)
+			fail := newCommandError(rCommand, stderr, err)
+			fail.hook = true
+			return fail
 		}
 	}
 	return nil
@@ -575,6 +825,9 @@ func (r *resticWrapper) runFinalShellCommands(command string, fail error) {
 	profileCommands, sectionCommands := r.profile.GetRunShellCommandsSections(command)
 	commands = append(commands, sectionCommands.RunFinally...)
 	commands = append(commands, profileCommands.RunFinally...)
+	if r.skipHooks("run-finally", len(commands)) {
+		return
+	}
 
 	env := append(os.Environ(), r.getEnvironment()...)
 	env = append(env, r.getProfileEnvironment()...)
@@ -582,14 +835,22 @@ func (r *resticWrapper) runFinalShellCommands(command string, fail error) {
 
 	for i := len(commands) - 1; i >= 0; i-- {
 		// Using defer stack for "finally" to ensure every command is run even on panic
-		defer func(index int, cmd string) {
+		defer func(index int, entry string) {
 			clog.Debugf("starting final command %d/%d", index+1, len(commands))
-			rCommand := newShellCommand(cmd, nil, env, r.getShell(), r.dryRun, r.sigChan, r.setPID)
-			// stdout are stderr are coming from the default terminal (in case they're redirected)
-			rCommand.stdout = term.GetOutput()
-			rCommand.stderr = term.GetErrorOutput()
-			term.FlushAllOutput()
-			_, _, err := runShellCommand(rCommand)
+			hook, err := r.prepareShellCommand(entry)
+			defer hook.cleanup()
+			if err == nil {
+				rCommand := newShellCommand(hook.commandLine, nil, append(slices.Clip(env), hook.env...), r.getShell(), r.dryRun, r.sigChan, r.setPID)
+				rCommand.dir = hook.dir
+				// stdout are stderr are coming from the default terminal (in case they're redirected)
+				rCommand.stdout = term.GetOutput()
+				rCommand.stderr = term.GetErrorOutput()
+				rCommand.capture = r.captureWriter()
+				term.FlushAllOutput()
+				endSpan := r.traceSpan("run-finally", map[string]any{otel.AttributeHook: "run-finally"})
+				_, _, err = runShellCommand(rCommand)
+				endSpan(err)
+			}
 			if err != nil {
 				clog.Errorf("run-finally command %d/%d failed ('%s' on profile '%s'): %w",
 					index+1, len(commands), command, r.profile.Name, err)
@@ -608,6 +869,11 @@ func (r *resticWrapper) sendAfter(monitoring config.SendMonitoringSections, comm
 	r.sendMonitoring(monitoring.SendAfter, command, "send-after", nil)
 }
 
+// sendHeartbeat pings the dead man's switch after a successful command
+func (r *resticWrapper) sendHeartbeat(monitoring config.SendMonitoringSections, command string) {
+	r.sendMonitoring(monitoring.Heartbeat, command, "heartbeat", nil)
+}
+
 // sendAfterFail a command
 func (r *resticWrapper) sendAfterFail(monitoring config.SendMonitoringSections, command string, err error) {
 	r.sendMonitoring(monitoring.SendAfterFail, command, "send-after-fail", err)
@@ -619,10 +885,17 @@ func (r *resticWrapper) sendFinally(monitoring config.SendMonitoringSections, co
 }
 
 func (r *resticWrapper) sendMonitoring(sections []config.SendMonitoringSection, command, sendType string, err error) {
+	if r.skipHooks(sendType, len(sections)) {
+		return
+	}
 	for i, section := range sections {
 		clog.Debugf("starting %q from %s %d/%d", sendType, command, i+1, len(sections))
 		term.FlushAllOutput()
-		err := r.sender.Send(section, r.getContextWithError(err))
+		ctx := r.getContextWithError(err)
+		ctx.Output = r.attachedOutput(section, err)
+		endSpan := r.traceSpan(sendType, map[string]any{otel.AttributeHook: sendType, "url.full": section.URL.String()})
+		err := r.sender.Send(section, ctx)
+		endSpan(err)
 		if err != nil {
 			clog.Warningf("%q returned an error: %s", sendType, err.Error())
 		}
@@ -650,10 +923,17 @@ func (r *resticWrapper) getEnvironment() []string {
 // (name and command for now)
 func (r *resticWrapper) getProfileEnvironment() []string {
 	ctx := r.getContext()
-	return []string{
+	env := []string{
 		fmt.Sprintf("%s=%s", constants.EnvProfileName, ctx.ProfileName),
 		fmt.Sprintf("%s=%s", constants.EnvProfileCommand, ctx.ProfileCommand),
 	}
+	if len(ctx.ConfigIssues) > 0 {
+		env = append(env, fmt.Sprintf("%s=%s", constants.EnvConfigIssues, ctx.ConfigIssuesText()))
+	}
+	env = append(env, r.getQuotaEnvironment()...)
+	env = append(env, r.getAnomalyEnvironment()...)
+	env = append(env, r.getSummaryEnvironment()...)
+	return env
 }
 
 // getFailEnvironment returns additional environment variables describing the failure
@@ -663,6 +943,9 @@ func (r *resticWrapper) getFailEnvironment(err error) (env []string) {
 		env = append(env, fmt.Sprintf("%s=%s", constants.EnvError, ctx.Message)) // powershell already has $ERROR
 		env = append(env, fmt.Sprintf("%s=%s", constants.EnvErrorMessage, ctx.Message))
 	}
+	if ctx.Code != "" {
+		env = append(env, fmt.Sprintf("%s=%s", constants.EnvErrorCode, ctx.Code))
+	}
 	if ctx.CommandLine != "" {
 		env = append(env, fmt.Sprintf("%s=%s", constants.EnvErrorCommandLine, ctx.CommandLine))
 	}
@@ -681,6 +964,10 @@ func (r *resticWrapper) getContext() hook.Context {
 	return hook.Context{
 		ProfileName:    r.profile.Name,
 		ProfileCommand: r.command,
+		Diff:           r.diff,
+		ConfigIssues:   r.configIssues,
+		Summary:        r.commandSummary,
+		Anomalies:      r.getAnomalyReasons(),
 	}
 }
 
@@ -695,17 +982,19 @@ func (r *resticWrapper) getErrorContext(err error) hook.ErrorContext {
 	if err == nil {
 		return ctx
 	}
-	ctx.Message = err.Error()
+	// confidential values may be found in the error message or in the error output
+	ctx.Message = redact.String(err.Error())
+	ctx.Code = r.errorCode(err)
 
-	if fail, ok := err.(*commandError); ok {
+	if fail := (&commandError{}); errors.As(err, &fail) {
 		exitCode := -1
 		if code, err := fail.ExitCode(); err == nil {
 			exitCode = code
 		}
 
-		ctx.CommandLine = fail.Commandline()
+		ctx.CommandLine = redact.String(fail.Commandline())
 		ctx.ExitCode = strconv.Itoa(exitCode)
-		ctx.Stderr = fail.Stderr()
+		ctx.Stderr = redact.String(fail.Stderr())
 	}
 	return ctx
 }
@@ -740,6 +1029,8 @@ func (r *resticWrapper) canRetryAfterError(command string, summary monitor.Summa
 	if output != nil && output.ContainsRemoteLockFailure() {
 		clog.Debugf("repository lock failed when running '%s'", command)
 		retry, sleep = r.canRetryAfterRemoteLockFailure(output)
+	} else if output != nil && output.ContainsDiskFull() {
+		retry = r.canRetryAfterDiskFull(command, err)
 	}
 
 	if retry && sleep > 0 {
@@ -875,7 +1166,7 @@ func lockRun(lockFile string, force bool, lockWait *time.Duration, run func(setP
 		// Retry or return?
 		if !success {
 			if lockWait == nil {
-				return fmt.Errorf("another process is already running this profile: %s", locker)
+				return newExitCodeError(constants.ExitCodeLocked, monitor.NewCodedError(monitor.ErrorCodeLock, fmt.Errorf("another process is already running this profile: %s", locker)))
 			}
 			if time.Since(start) < *lockWait {
 				lockName := fmt.Sprintf("%s locked by %s", lockFile, locker)
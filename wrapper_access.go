@@ -0,0 +1,205 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/constants"
+)
+
+// maxUnreadableDisplayed is the number of unreadable paths listed in the warning of the full access check
+const maxUnreadableDisplayed = 5
+
+// errXattrNotSupported is returned when the filesystem doesn't support extended attributes
+var errXattrNotSupported = errors.New("extended attributes are not supported")
+
+// canReadAllFiles returns true when resticprofile runs with the privilege to read any file (root, or CAP_DAC_READ_SEARCH on Linux)
+var canReadAllFiles = func() bool {
+	return os.Geteuid() <= 0 || hasReadSearchCapability()
+}
+
+// checkSourceAccess warns when the sources of the backup cannot be read fully, instead of silently saving a partial snapshot
+func (r *resticWrapper) checkSourceAccess() {
+	backup := r.profile.Backup
+	mode := backup.GetSourceAccessCheck()
+	if mode == config.SourceAccessCheckOff || backup.UseStdin {
+		return
+	}
+	sources := append([]string{}, r.getBackupSource()...)
+	sources = append(append(sources, backup.SourceVerbatim...), backup.SourceRaw...)
+	if len(sources) == 0 {
+		return
+	}
+	for _, warning := range sourceAccessWarnings(sources, mode, r.getSourceFilter()) {
+		clog.Warningf("profile '%s': %s", r.profile.Name, warning)
+	}
+}
+
+// getSourceFilter returns the filter of the files excluded from the backup (nil when the exclusions cannot be loaded)
+func (r *resticWrapper) getSourceFilter() *estimateFilter {
+	filter, err := newEstimateFilter(r.getBackupFlags())
+	if err != nil {
+		clog.Debugf("cannot load the exclusions of the backup: %s", err)
+		return nil
+	}
+	patterns, err := convertedExcludes(r.profile.Name, r.profile.Backup, r.getBackupSource())
+	if err != nil {
+		clog.Debugf("cannot load the exclusions of the backup: %s", err)
+		return nil
+	}
+	filter.exclude = append(filter.exclude, patterns...)
+	return filter
+}
+
+// sourceAccessWarnings returns the problems found in the sources
+func sourceAccessWarnings(sources []string, mode string, filter *estimateFilter) (warnings []string) {
+	privileged := canReadAllFiles()
+	currentUser := currentUserName()
+
+	for _, source := range sources {
+		info, err := os.Lstat(source)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("cannot access source %q: %s", source, unwrapPathError(err)))
+			continue
+		}
+		if err = checkReadable(source, info); err != nil {
+			warnings = append(warnings, fmt.Sprintf("source %q cannot be read by user %s: %s", source, currentUser, unwrapPathError(err)))
+			continue
+		}
+		if !privileged {
+			if owned, known := isOwnedByCurrentUser(info); known && !owned {
+				warnings = append(warnings, fmt.Sprintf("source %q belongs to another user and resticprofile is not running as root (nor with CAP_DAC_READ_SEARCH): files not readable by user %s will be missing from the snapshot", source, currentUser))
+			}
+		}
+		if err = checkXattrSupport(source, false); errors.Is(err, errXattrNotSupported) {
+			warnings = append(warnings, fmt.Sprintf("the filesystem of source %q doesn't support extended attributes: they won't be saved in the snapshot", source))
+		}
+	}
+
+	if mode == config.SourceAccessCheckFull {
+		if unreadable := findUnreadable(sources, filter); len(unreadable) > 0 {
+			displayed := unreadable
+			if len(displayed) > maxUnreadableDisplayed {
+				displayed = displayed[:maxUnreadableDisplayed]
+			}
+			warnings = append(warnings, fmt.Sprintf("%d files or directories cannot be read by user %s and will be missing from the snapshot: %s",
+				len(unreadable), currentUser, strings.Join(displayed, ", ")))
+		}
+	}
+	return
+}
+
+// checkRestoreTarget warns when the extended attributes and ACLs cannot be restored in the target directory
+func (r *resticWrapper) checkRestoreTarget() {
+	target := restoreTarget(r.profile.GetCommandFlags(constants.CommandRestore).GetAll(), r.moreArgs)
+	if target == "" {
+		return
+	}
+	if err := checkXattrSupport(existingParent(target), true); errors.Is(err, errXattrNotSupported) {
+		clog.Warningf("profile '%s': the filesystem of the restore target %q doesn't support extended attributes: extended attributes and ACLs won't be restored", r.profile.Name, target)
+	}
+}
+
+// restoreTarget returns the value of the last --target flag
+func restoreTarget(argLists ...[]string) (target string) {
+	for _, args := range argLists {
+		for index, arg := range args {
+			if arg == "--target" || arg == "-t" {
+				if index+1 < len(args) {
+					target = args[index+1]
+				}
+			} else if strings.HasPrefix(arg, "--target=") {
+				target = strings.TrimPrefix(arg, "--target=")
+			}
+		}
+	}
+	return strings.Trim(target, `"'`)
+}
+
+// existingParent returns the path, or its first parent directory that exists
+func existingParent(path string) string {
+	path = filepath.Clean(path)
+	for {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return path
+		}
+		path = parent
+	}
+}
+
+// checkReadable opens the file (or lists the directory) to verify it can be read
+func checkReadable(path string, info fs.FileInfo) error {
+	if info.Mode()&fs.ModeSymlink != 0 {
+		// restic saves the link itself
+		return nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if info.IsDir() {
+		if _, err = file.Readdirnames(1); err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+	}
+	return nil
+}
+
+// findUnreadable walks the sources and returns the files and directories that cannot be read
+func findUnreadable(sources []string, filter *estimateFilter) (unreadable []string) {
+	for _, source := range sources {
+		_ = filepath.WalkDir(source, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				if errors.Is(err, fs.ErrPermission) {
+					unreadable = append(unreadable, path)
+				}
+				return nil
+			}
+			if filter != nil && filter.excludes(path, entry) {
+				if entry.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if entry.Type().IsRegular() {
+				if file, err := os.Open(path); err != nil {
+					if errors.Is(err, fs.ErrPermission) {
+						unreadable = append(unreadable, path)
+					}
+				} else {
+					file.Close()
+				}
+			}
+			return nil
+		})
+	}
+	return
+}
+
+func currentUserName() string {
+	if current, err := user.Current(); err == nil {
+		return current.Username
+	}
+	return "unknown"
+}
+
+func unwrapPathError(err error) error {
+	pathErr := &fs.PathError{}
+	if errors.As(err, &pathErr) {
+		return pathErr.Err
+	}
+	return err
+}
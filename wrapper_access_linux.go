@@ -0,0 +1,58 @@
+//go:build linux
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// capDacReadSearch is the capability to bypass the read permission checks of files and directories
+const capDacReadSearch = 2
+
+// hasReadSearchCapability returns true when the process has CAP_DAC_READ_SEARCH in its effective capabilities
+func hasReadSearchCapability() bool {
+	file, err := os.Open("/proc/self/status")
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := scanner.Text(); strings.HasPrefix(line, "CapEff:") {
+			capabilities, err := strconv.ParseUint(strings.TrimSpace(strings.TrimPrefix(line, "CapEff:")), 16, 64)
+			return err == nil && capabilities&(1<<capDacReadSearch) != 0
+		}
+	}
+	return false
+}
+
+// checkXattrSupport returns errXattrNotSupported when the filesystem of the path doesn't support extended attributes.
+// With write, it verifies that an extended attribute can be set on a new file of the directory.
+func checkXattrSupport(path string, write bool) error {
+	if !write {
+		_, err := unix.Llistxattr(path, nil)
+		if errors.Is(err, unix.ENOTSUP) {
+			return errXattrNotSupported
+		}
+		return err
+	}
+	file, err := os.CreateTemp(path, ".resticprofile-xattr-*")
+	if err != nil {
+		return err
+	}
+	file.Close()
+	defer os.Remove(file.Name())
+
+	err = unix.Setxattr(file.Name(), "user.resticprofile", []byte("test"), 0)
+	if errors.Is(err, unix.ENOTSUP) {
+		return errXattrNotSupported
+	}
+	return err
+}
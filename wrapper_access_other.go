@@ -0,0 +1,13 @@
+//go:build !linux
+
+package main
+
+// hasReadSearchCapability is only available on Linux
+func hasReadSearchCapability() bool {
+	return false
+}
+
+// checkXattrSupport is only available on Linux: the support of extended attributes is not verified
+func checkXattrSupport(path string, write bool) error {
+	return nil
+}
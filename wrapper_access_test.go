@@ -0,0 +1,65 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRestoreTarget(t *testing.T) {
+	assert.Equal(t, "", restoreTarget([]string{"--verify"}, nil))
+	assert.Equal(t, "/restore", restoreTarget([]string{"--target", `"/restore"`}, nil))
+	assert.Equal(t, "/other", restoreTarget([]string{"--target", "/restore"}, []string{"latest", "-t", "/other"}))
+	assert.Equal(t, "/other", restoreTarget(nil, []string{"--target=/other"}))
+}
+
+func TestExistingParent(t *testing.T) {
+	dir := t.TempDir()
+	assert.Equal(t, dir, existingParent(dir))
+	assert.Equal(t, dir, existingParent(filepath.Join(dir, "missing", "restore")))
+}
+
+func TestSourceAccessWarnings(t *testing.T) {
+	dir := t.TempDir()
+	missing := filepath.Join(dir, "missing")
+
+	warnings := sourceAccessWarnings([]string{dir, missing}, config.SourceAccessCheckQuick, nil)
+	require.Len(t, warnings, 1)
+	assert.Contains(t, warnings[0], `cannot access source "`+missing+`"`)
+
+	t.Run("not privileged", func(t *testing.T) {
+		if runtime.GOOS == "windows" || os.Geteuid() != 0 {
+			t.Skip("needs root to change the owner of a file")
+		}
+		other := filepath.Join(dir, "other")
+		require.NoError(t, os.Mkdir(other, 0o755))
+		require.NoError(t, os.Chown(other, 65534, 65534))
+
+		defer func(previous func() bool) { canReadAllFiles = previous }(canReadAllFiles)
+		canReadAllFiles = func() bool { return false }
+
+		warnings := sourceAccessWarnings([]string{dir, other}, config.SourceAccessCheckQuick, nil)
+		require.Len(t, warnings, 1)
+		assert.Contains(t, warnings[0], `source "`+other+`" belongs to another user`)
+	})
+
+	t.Run("unreadable files", func(t *testing.T) {
+		if runtime.GOOS == "windows" || os.Geteuid() == 0 {
+			t.Skip("permissions are not enforced")
+		}
+		locked := filepath.Join(dir, "locked")
+		require.NoError(t, os.Mkdir(locked, 0o000))
+		defer os.Chmod(locked, 0o755)
+
+		assert.Empty(t, sourceAccessWarnings([]string{dir}, config.SourceAccessCheckQuick, nil))
+		warnings := sourceAccessWarnings([]string{dir}, config.SourceAccessCheckFull, nil)
+		require.Len(t, warnings, 1)
+		assert.Contains(t, warnings[0], "1 files or directories cannot be read")
+		assert.Contains(t, warnings[0], locked)
+	})
+}
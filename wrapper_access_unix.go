@@ -0,0 +1,18 @@
+//go:build !windows
+
+package main
+
+import (
+	"io/fs"
+	"os"
+	"syscall"
+)
+
+// isOwnedByCurrentUser returns true when the file belongs to the effective user (known is false when the owner cannot be found)
+func isOwnedByCurrentUser(info fs.FileInfo) (owned, known bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return false, false
+	}
+	return int(stat.Uid) == os.Geteuid(), true
+}
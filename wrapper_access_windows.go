@@ -0,0 +1,10 @@
+//go:build windows
+
+package main
+
+import "io/fs"
+
+// isOwnedByCurrentUser cannot find the owner of a file on Windows
+func isOwnedByCurrentUser(info fs.FileInfo) (owned, known bool) {
+	return false, false
+}
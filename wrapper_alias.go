@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/config"
+	"golang.org/x/exp/slices"
+)
+
+// getCommandAliasAction returns the action running all the steps of a custom command
+func (r *resticWrapper) getCommandAliasAction(alias *config.CommandAliasSection) func() error {
+	return func() error {
+		aliasName, moreArgs := r.command, r.moreArgs
+		defer func() {
+			r.command, r.moreArgs = aliasName, moreArgs
+		}()
+
+		steps := alias.Steps()
+		for i, step := range steps {
+			command := step[0]
+			if r.profile.GetCommandAlias(command) != nil {
+				return fmt.Errorf("%s on profile '%s': cannot run custom command '%s' from a custom command", aliasName, r.profile.Name, command)
+			}
+			clog.Debugf("profile '%s': %s step %d/%d: %s", r.profile.Name, aliasName, i+1, len(steps), command)
+
+			// fixed flags of the step come first, then the flags from the command line
+			r.command = command
+			r.moreArgs = append(slices.Clone(step[1:]), moreArgs...)
+
+			_, shellCommands := r.profile.GetRunShellCommandsSections(command)
+			runner := r.runnerWithBeforeAndAfter(shellCommands, command, r.getRunner(command))
+			if err := runner(); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/term"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunCommandAlias(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	term.SetOutput(buffer)
+	profile := config.NewProfile(nil, "name")
+	profile.Commands = map[string]*config.CommandAliasSection{
+		"nightly": {Run: []string{"snapshots --compact", "check"}},
+	}
+	profile.Commands["nightly"].RunBefore = []string{"echo before"}
+
+	wrapper := newResticWrapper(nil, "echo", false, profile, "nightly", []string{"--extra"}, nil)
+	err := wrapper.runProfile()
+	assert.NoError(t, err)
+	assert.Equal(t, "before\nsnapshots --compact --extra\ncheck --extra\n", strings.ReplaceAll(buffer.String(), "\r\n", "\n"))
+	assert.Equal(t, "nightly", wrapper.command)
+}
+
+func TestRunNestedCommandAlias(t *testing.T) {
+	term.SetOutput(&bytes.Buffer{})
+	profile := config.NewProfile(nil, "name")
+	profile.Commands = map[string]*config.CommandAliasSection{
+		"nightly": {Run: []string{"snapshots", "weekly"}},
+		"weekly":  {Run: []string{"check"}},
+	}
+
+	wrapper := newResticWrapper(nil, "echo", false, profile, "nightly", nil, nil)
+	err := wrapper.runProfile()
+	assert.ErrorContains(t, err, "cannot run custom command 'weekly'")
+}
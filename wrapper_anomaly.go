@@ -0,0 +1,193 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/constants"
+	"github.com/creativeprojects/resticprofile/monitor"
+	"github.com/creativeprojects/resticprofile/util"
+)
+
+// anomalyRecord is the statistics of a backup, kept in the state file of the anomalies
+type anomalyRecord struct {
+	Time  time.Time `json:"time"`
+	Files int       `json:"files"`
+	Size  uint64    `json:"size"`
+}
+
+// anomalyState keeps the statistics of the previous backups between runs, per backup set (empty name without sets)
+type anomalyState struct {
+	Backups map[string][]anomalyRecord `json:"backups"`
+}
+
+// anomalyResult is the anomalies detected in the last backup
+type anomalyResult struct {
+	Reasons      []string
+	FilesAverage float64
+	SizeAverage  float64
+}
+
+// getAnomalyStateFile returns the file keeping the statistics of the previous backups of the profile
+func getAnomalyStateFile(profile *config.Profile) string {
+	if profile.Anomaly != nil && profile.Anomaly.StateFile != "" {
+		return profile.Anomaly.StateFile
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	hash := sha256.Sum256([]byte(profile.Name + "\n" + profile.Repository.Value()))
+	return filepath.Join(dir, constants.ApplicationName, "anomaly-"+hex.EncodeToString(hash[:8])+".json")
+}
+
+// loadAnomalyState reads the statistics of the previous backups, and returns an empty state when there's none yet
+func loadAnomalyState(filename string) (*anomalyState, error) {
+	state := &anomalyState{Backups: make(map[string][]anomalyRecord)}
+	if filename == "" {
+		return state, nil
+	}
+	content, err := os.ReadFile(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read the statistics of the previous backups: %w", err)
+	}
+	if err = json.Unmarshal(content, state); err != nil {
+		return nil, fmt.Errorf("cannot read the statistics of the previous backups from %q: %w", filename, err)
+	}
+	if state.Backups == nil {
+		state.Backups = make(map[string][]anomalyRecord)
+	}
+	return state, nil
+}
+
+// saveAnomalyState writes the statistics of the previous backups
+func saveAnomalyState(filename string, state *anomalyState) error {
+	if filename == "" {
+		return errors.New("cannot save the statistics of the backups: no cache directory")
+	}
+	content, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(filename), 0o700); err != nil {
+		return fmt.Errorf("cannot save the statistics of the backups: %w", err)
+	}
+	if err = os.WriteFile(filename, content, 0o600); err != nil {
+		return fmt.Errorf("cannot save the statistics of the backups: %w", err)
+	}
+	return nil
+}
+
+// detectAnomalies compares the statistics of the backup with the averages of the previous backups.
+// It returns nil when there are not enough previous backups to compare with.
+func detectAnomalies(section *config.AnomalySection, previous []anomalyRecord, current anomalyRecord, summary monitor.Summary) *anomalyResult {
+	if len(previous) == 0 || len(previous) < section.GetMinBackups() {
+		return nil
+	}
+	result := &anomalyResult{}
+	for _, record := range previous {
+		result.FilesAverage += float64(record.Files)
+		result.SizeAverage += float64(record.Size)
+	}
+	result.FilesAverage /= float64(len(previous))
+	result.SizeAverage /= float64(len(previous))
+
+	if limit, _ := section.GetFilesDeviation(); limit > 0 && result.FilesAverage > 0 {
+		if deviation := (float64(current.Files) - result.FilesAverage) * 100 / result.FilesAverage; math.Abs(deviation) > limit {
+			result.Reasons = append(result.Reasons, fmt.Sprintf("%d files, %+.0f%% from the average of %.0f files", current.Files, deviation, result.FilesAverage))
+		}
+	}
+	if limit, _ := section.GetSizeDeviation(); limit > 0 && result.SizeAverage > 0 {
+		if deviation := (float64(current.Size) - result.SizeAverage) * 100 / result.SizeAverage; math.Abs(deviation) > limit {
+			result.Reasons = append(result.Reasons, fmt.Sprintf("size of %s, %+.0f%% from the average of %s",
+				util.FormatBytes(current.Size), deviation, util.FormatBytes(uint64(result.SizeAverage))))
+		}
+	}
+	if limit, _ := section.GetMaxChanged(); limit > 0 && summary.FilesTotal > 0 {
+		if changed := float64(summary.FilesNew+summary.FilesChanged) * 100 / float64(summary.FilesTotal); changed > limit {
+			result.Reasons = append(result.Reasons, fmt.Sprintf("%.0f%% of the files are new or modified (%d new, %d modified)", changed, summary.FilesNew, summary.FilesChanged))
+		}
+	}
+	return result
+}
+
+// runAnomalyCheck compares the statistics of the backup with the previous backups, and reports the anomalies.
+// An anomaly never fails the backup: it's logged, and the "run-on-anomaly" and "send-on-anomaly" hooks are called.
+func (r *resticWrapper) runAnomalyCheck(summary monitor.Summary) {
+	section := r.profile.Anomaly
+	if r.dryRun || section.IsEmpty() || r.repository != "" {
+		return
+	}
+	if summary.FilesTotal == 0 && summary.BytesTotal == 0 {
+		clog.Debugf("profile '%s': no statistics of the backup to detect anomalies", r.profile.Name)
+		return
+	}
+	filename := getAnomalyStateFile(r.profile)
+	state, err := loadAnomalyState(filename)
+	if err != nil {
+		clog.Warningf("profile '%s': %s", r.profile.Name, err)
+		return
+	}
+	current := anomalyRecord{Time: time.Now(), Files: summary.FilesTotal, Size: summary.BytesTotal}
+	previous := state.Backups[r.backupSet]
+	r.anomaly = detectAnomalies(section, previous, current, summary)
+
+	// the backup takes part in the next averages, even when it's an anomaly
+	previous = append(previous, current)
+	if window := section.GetWindow(); len(previous) > window {
+		previous = previous[len(previous)-window:]
+	}
+	state.Backups[r.backupSet] = previous
+	if err = saveAnomalyState(filename, state); err != nil {
+		clog.Warningf("profile '%s': %s", r.profile.Name, err)
+	}
+
+	if r.anomaly == nil || len(r.anomaly.Reasons) == 0 {
+		return
+	}
+	if r.backupSet != "" {
+		clog.Warningf("profile '%s': anomaly detected in the backup set '%s': %s", r.profile.Name, r.backupSet, strings.Join(r.anomaly.Reasons, "; "))
+	} else {
+		clog.Warningf("profile '%s': anomaly detected in the backup: %s", r.profile.Name, strings.Join(r.anomaly.Reasons, "; "))
+	}
+	if len(section.RunOnAnomaly) > 0 {
+		if err = r.runShellCommands(section.RunOnAnomaly, "run-on-anomaly", "", nil); err != nil {
+			clog.Error(err)
+		}
+	}
+	r.sendMonitoring(section.SendOnAnomaly, constants.CommandBackup, "send-on-anomaly", nil)
+}
+
+// getAnomalyEnvironment returns the environment variables describing the anomalies detected in the last backup
+func (r *resticWrapper) getAnomalyEnvironment() []string {
+	if r.anomaly == nil || len(r.anomaly.Reasons) == 0 {
+		return nil
+	}
+	return []string{
+		fmt.Sprintf("%s=%s", constants.EnvAnomaly, strings.Join(r.anomaly.Reasons, "\n")),
+		fmt.Sprintf("%s=%.0f", constants.EnvAnomalyFilesAverage, r.anomaly.FilesAverage),
+		fmt.Sprintf("%s=%.0f", constants.EnvAnomalySizeAverage, r.anomaly.SizeAverage),
+	}
+}
+
+// getAnomalyReasons returns the anomalies detected in the last backup (nil when none)
+func (r *resticWrapper) getAnomalyReasons() []string {
+	if r.anomaly == nil {
+		return nil
+	}
+	return r.anomaly.Reasons
+}
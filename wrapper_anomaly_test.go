@@ -0,0 +1,120 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/monitor"
+	"github.com/creativeprojects/resticprofile/platform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectAnomalies(t *testing.T) {
+	previous := []anomalyRecord{
+		{Files: 1000, Size: 1000 * 1024},
+		{Files: 1100, Size: 1100 * 1024},
+		{Files: 900, Size: 900 * 1024},
+	}
+	section := &config.AnomalySection{}
+
+	for _, fixture := range []struct {
+		name     string
+		previous []anomalyRecord
+		current  anomalyRecord
+		summary  monitor.Summary
+		reasons  []string
+	}{
+		{
+			name:     "not enough backups",
+			previous: previous[:2],
+			current:  anomalyRecord{Files: 10, Size: 10},
+		},
+		{
+			name:     "usual backup",
+			previous: previous,
+			current:  anomalyRecord{Files: 1050, Size: 1050 * 1024},
+			summary:  monitor.Summary{FilesTotal: 1050, FilesNew: 50, FilesChanged: 10},
+			reasons:  []string{},
+		},
+		{
+			name:     "missing mount",
+			previous: previous,
+			current:  anomalyRecord{Files: 12, Size: 20 * 1024},
+			summary:  monitor.Summary{FilesTotal: 12},
+			reasons: []string{
+				"12 files, -99% from the average of 1000 files",
+				"size of 20.0 KiB, -98% from the average of 1000.0 KiB",
+			},
+		},
+		{
+			name:     "encrypted files",
+			previous: previous,
+			current:  anomalyRecord{Files: 1000, Size: 1000 * 1024},
+			summary:  monitor.Summary{FilesTotal: 1000, FilesNew: 100, FilesChanged: 800},
+			reasons:  []string{"90% of the files are new or modified (100 new, 800 modified)"},
+		},
+	} {
+		t.Run(fixture.name, func(t *testing.T) {
+			result := detectAnomalies(section, fixture.previous, fixture.current, fixture.summary)
+			if fixture.reasons == nil {
+				assert.Nil(t, result)
+				return
+			}
+			require.NotNil(t, result)
+			assert.ElementsMatch(t, fixture.reasons, result.Reasons)
+			assert.Equal(t, 1000.0, result.FilesAverage)
+		})
+	}
+}
+
+func TestRunAnomalyCheck(t *testing.T) {
+	if platform.IsWindows() {
+		t.Skip("shell script")
+	}
+	dir := t.TempDir()
+	result := filepath.Join(dir, "result")
+	profile := config.NewProfile(nil, "name")
+	profile.Anomaly = &config.AnomalySection{
+		Window:       3,
+		MinBackups:   2,
+		StateFile:    filepath.Join(dir, "state.json"),
+		RunOnAnomaly: []string{"echo \"$ANOMALY_FILES_AVERAGE\" > " + result},
+	}
+	wrapper := newResticWrapper(nil, "restic", false, profile, "backup", nil, nil)
+
+	for _, files := range []int{100, 110, 90, 100} {
+		wrapper.runAnomalyCheck(monitor.Summary{FilesTotal: files, BytesTotal: uint64(files) * 1024})
+	}
+	require.NotNil(t, wrapper.anomaly)
+	assert.Empty(t, wrapper.anomaly.Reasons)
+	assert.NoFileExists(t, result)
+
+	state, err := loadAnomalyState(profile.Anomaly.StateFile)
+	require.NoError(t, err)
+	assert.Len(t, state.Backups[""], 3)
+
+	wrapper.runAnomalyCheck(monitor.Summary{FilesTotal: 5, BytesTotal: 5 * 1024})
+	require.NotNil(t, wrapper.anomaly)
+	assert.Len(t, wrapper.anomaly.Reasons, 2)
+	content, err := os.ReadFile(result)
+	require.NoError(t, err)
+	assert.Equal(t, "100\n", string(content))
+	assert.Contains(t, wrapper.getContext().Anomalies, wrapper.anomaly.Reasons[0])
+
+	t.Run("no statistics", func(t *testing.T) {
+		wrapper := newResticWrapper(nil, "restic", false, profile, "backup", nil, nil)
+		wrapper.runAnomalyCheck(monitor.Summary{Duration: time.Second})
+		assert.Nil(t, wrapper.anomaly)
+	})
+
+	t.Run("additional repository", func(t *testing.T) {
+		wrapper := newResticWrapper(nil, "restic", false, profile, "backup", nil, nil)
+		wrapper.repository = "offsite"
+		wrapper.runAnomalyCheck(monitor.Summary{FilesTotal: 5})
+		assert.Nil(t, wrapper.anomaly)
+	})
+}
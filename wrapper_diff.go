@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/constants"
+	"github.com/creativeprojects/resticprofile/monitor"
+	"github.com/creativeprojects/resticprofile/shell"
+	"github.com/creativeprojects/resticprofile/util"
+)
+
+// snapshotInfo is an entry of the JSON output of "restic snapshots"
+type snapshotInfo struct {
+	ID      string    `json:"id"`
+	ShortID string    `json:"short_id"`
+	Time    time.Time `json:"time"`
+}
+
+// diffStatistics is the final message of the JSON output of "restic diff"
+type diffStatistics struct {
+	MessageType  string `json:"message_type"`
+	ChangedFiles int    `json:"changed_files"`
+	Added        struct {
+		Files int    `json:"files"`
+		Bytes uint64 `json:"bytes"`
+	} `json:"added"`
+	Removed struct {
+		Files int    `json:"files"`
+		Bytes uint64 `json:"bytes"`
+	} `json:"removed"`
+}
+
+// runDiffAfterBackup compares the last two snapshots of the backup and returns a summary of the changes.
+// Errors are not fatal to the backup: they're logged and nil is returned.
+func (r *resticWrapper) runDiffAfterBackup() *monitor.DiffSummary {
+	if r.dryRun {
+		return nil
+	}
+	clog.Infof("profile '%s': comparing snapshot with the previous one", r.profile.Name)
+
+	snapshots, err := r.listBackupSnapshots()
+	if err != nil {
+		clog.Warning(err)
+		return nil
+	}
+	if len(snapshots) < 2 {
+		clog.Infof("profile '%s': no previous snapshot to compare with", r.profile.Name)
+		return nil
+	}
+	previous, last := snapshots[len(snapshots)-2], snapshots[len(snapshots)-1]
+
+	args := r.profile.GetCommonFlags()
+	args.AddFlag("json", "", shell.ArgConfigEscape)
+	args.AddArgs([]string{previous.ID, last.ID}, shell.ArgConfigEscape)
+
+	output := &bytes.Buffer{}
+	rCommand := r.prepareCommand(constants.CommandDiff, args, false)
+	rCommand.stdout = output
+	_, _, err = runShellCommand(rCommand)
+	if err != nil {
+		clog.Warningf("profile '%s': diff with previous snapshot failed: %s", r.profile.Name, err)
+		return nil
+	}
+
+	diff, err := parseDiffStatistics(output)
+	if err != nil {
+		clog.Warningf("profile '%s': %s", r.profile.Name, err)
+		return nil
+	}
+	diff.PreviousSnapshot = previous.ShortID
+	diff.Snapshot = last.ShortID
+
+	clog.Infof("profile '%s': changes since snapshot %s: %d file(s) added, %d removed, %d modified, size %s",
+		r.profile.Name, diff.PreviousSnapshot, diff.FilesAdded, diff.FilesRemoved, diff.FilesChanged,
+		util.FormatBytesDelta(diff.SizeDelta()))
+
+	r.diff = diff
+	return diff
+}
+
+// listBackupSnapshots returns the last two snapshots of the same host, paths and tags as the backup, sorted by time
+func (r *resticWrapper) listBackupSnapshots() ([]snapshotInfo, error) {
+	args := r.profile.GetCommonFlags()
+	backupArgs := r.getBackupFlags()
+	if values, found := backupArgs.Get(constants.ParameterHost); found {
+		args.AddFlags(constants.ParameterHost, argValues(values), shell.ArgConfigEscape)
+	}
+	// restic selects the snapshots having any of the --tag flags: all the tags of the backup must be in a single flag
+	if values, found := backupArgs.Get(constants.ParameterTag); found && len(values) > 0 {
+		args.AddFlag(constants.ParameterTag, strings.Join(argValues(values), ","), shell.ArgConfigEscape)
+	}
+	if !r.profile.Backup.UseStdin {
+		if source := r.getBackupSource(); len(source) > 0 {
+			args.AddFlags(constants.ParameterPath, source, shell.ArgConfigEscape)
+		}
+	}
+	args.AddFlag("latest", "2", shell.ArgConfigEscape)
+	args.AddFlag("json", "", shell.ArgConfigEscape)
+
+	output := &bytes.Buffer{}
+	rCommand := r.prepareCommand(constants.CommandSnapshots, args, false)
+	rCommand.stdout = output
+	_, _, err := runShellCommand(rCommand)
+	if err != nil {
+		return nil, fmt.Errorf("profile '%s': cannot list snapshots: %w", r.profile.Name, err)
+	}
+
+	snapshots := make([]snapshotInfo, 0)
+	if err = json.Unmarshal(bytes.TrimSpace(output.Bytes()), &snapshots); err != nil {
+		return nil, fmt.Errorf("profile '%s': cannot parse snapshots: %w", r.profile.Name, err)
+	}
+	sort.SliceStable(snapshots, func(i, j int) bool { return snapshots[i].Time.Before(snapshots[j].Time) })
+	return snapshots, nil
+}
+
+// parseDiffStatistics reads the statistics message from the JSON output of "restic diff"
+func parseDiffStatistics(reader io.Reader) (*monitor.DiffSummary, error) {
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 || line[0] != '{' {
+			continue
+		}
+		stats := diffStatistics{}
+		if err := json.Unmarshal(line, &stats); err != nil || stats.MessageType != "statistics" {
+			continue
+		}
+		return &monitor.DiffSummary{
+			FilesAdded:   stats.Added.Files,
+			FilesRemoved: stats.Removed.Files,
+			FilesChanged: stats.ChangedFiles,
+			BytesAdded:   stats.Added.Bytes,
+			BytesRemoved: stats.Removed.Bytes,
+		}, nil
+	}
+	return nil, fmt.Errorf("no statistics found in the output of restic diff")
+}
+
+// argValues returns the values of arguments
+func argValues(args []shell.Arg) (values []string) {
+	for _, arg := range args {
+		values = append(values, arg.Value())
+	}
+	return
+}
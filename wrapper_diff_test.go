@@ -0,0 +1,72 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/constants"
+	"github.com/creativeprojects/resticprofile/platform"
+	"github.com/creativeprojects/resticprofile/shell"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDiffStatistics(t *testing.T) {
+	output := `{"message_type":"change","path":"/home/file","modifier":"+"}
+{"message_type":"change","path":"/home/other","modifier":"M"}
+{"message_type":"statistics","source_snapshot":"aaa","target_snapshot":"bbb","changed_files":1,"added":{"files":2,"dirs":0,"others":0,"data_blobs":3,"tree_blobs":1,"bytes":3000},"removed":{"files":1,"dirs":0,"others":0,"data_blobs":1,"tree_blobs":1,"bytes":1000}}
+`
+	diff, err := parseDiffStatistics(strings.NewReader(output))
+	require.NoError(t, err)
+	assert.Equal(t, 2, diff.FilesAdded)
+	assert.Equal(t, 1, diff.FilesRemoved)
+	assert.Equal(t, 1, diff.FilesChanged)
+	assert.Equal(t, int64(2000), diff.SizeDelta())
+}
+
+func TestParseDiffStatisticsMissing(t *testing.T) {
+	_, err := parseDiffStatistics(strings.NewReader(`{"message_type":"change","path":"/home/file","modifier":"+"}`))
+	assert.Error(t, err)
+}
+
+func TestArgValues(t *testing.T) {
+	args := shell.NewArgs()
+	args.AddFlags("tag", []string{"one", "two"}, shell.ArgConfigEscape)
+	values, found := args.Get("tag")
+	require.True(t, found)
+	assert.Equal(t, []string{"one", "two"}, argValues(values))
+}
+
+func TestListBackupSnapshotsArguments(t *testing.T) {
+	if platform.IsWindows() {
+		t.Skip("shell script")
+	}
+	dir := t.TempDir()
+	binary := filepath.Join(dir, "restic")
+	argsFile := filepath.Join(dir, "args")
+	script := "#!/bin/sh\nfor arg in \"$@\"; do echo \"$arg\" >> " + argsFile + "; done\n" +
+		"echo '[{\"short_id\":\"bbb\",\"time\":\"2026-10-17T10:00:00Z\"},{\"short_id\":\"aaa\",\"time\":\"2026-10-16T10:00:00Z\"}]'\n"
+	require.NoError(t, os.WriteFile(binary, []byte(script), 0o700))
+
+	profile := config.NewProfile(nil, "name")
+	profile.Backup = &config.BackupSection{Source: []string{"/home"}}
+	profile.Backup.OtherFlags = map[string]any{
+		constants.ParameterHost: "box",
+		constants.ParameterTag:  []string{"one", "two"},
+	}
+	wrapper := newResticWrapper(nil, binary, false, profile, constants.CommandBackup, nil, nil)
+
+	snapshots, err := wrapper.listBackupSnapshots()
+	require.NoError(t, err)
+	require.Len(t, snapshots, 2)
+	assert.Equal(t, "aaa", snapshots[0].ShortID)
+
+	content, err := os.ReadFile(argsFile)
+	require.NoError(t, err)
+	args := strings.Fields(string(content))
+	assert.Equal(t, "snapshots", args[0])
+	assert.Equal(t, []string{"--host=box", "--json", "--latest=2", "--path=/home", "--tag=one,two"}, args[1:])
+}
@@ -0,0 +1,29 @@
+package main
+
+import "github.com/creativeprojects/clog"
+
+// canRetryAfterDiskFull runs the run-on-disk-full commands after restic failed on a full disk, and returns true
+// when the command can run again. The recovery is only attempted once per run.
+func (r *resticWrapper) canRetryAfterDiskFull(command string, err error) bool {
+	clog.Errorf("profile '%s': '%s' failed: no space left on device", r.profile.Name, command)
+	if r.doneDiskFull {
+		clog.Infof("profile '%s': recovery from a full disk already attempted, will not try again", r.profile.Name)
+		return false
+	}
+	r.doneDiskFull = true
+
+	if len(r.profile.RunOnDiskFull) > 0 {
+		if hookErr := r.runShellCommands(r.profile.RunOnDiskFull, "run-on-disk-full", "", err); hookErr != nil {
+			clog.Errorf("%s", hookErr)
+			return false
+		}
+	}
+	if !r.profile.RetryOnDiskFull {
+		return false
+	}
+	if err := r.checkInterrupted(); err != nil {
+		return false
+	}
+	clog.Infof("profile '%s': running '%s' again after recovering from a full disk", r.profile.Name, command)
+	return true
+}
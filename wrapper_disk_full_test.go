@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/monitor"
+	"github.com/creativeprojects/resticprofile/term"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanRetryAfterDiskFull(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	term.SetOutput(buffer)
+	defer term.SetOutput(os.Stdout)
+
+	summary := monitor.Summary{OutputAnalysis: &mockOutputAnalysis{diskFull: true}}
+	failure := errors.New("exit status 1")
+
+	t.Run("recovery without retry", func(t *testing.T) {
+		buffer.Reset()
+		profile := config.NewProfile(nil, "name")
+		profile.RunOnDiskFull = []string{"echo cleanup"}
+		wrapper := newResticWrapper(nil, "echo", false, profile, "backup", nil, nil)
+
+		assert.False(t, wrapper.canRetryAfterError("backup", summary, failure))
+		assert.Equal(t, "cleanup\n", strings.ReplaceAll(buffer.String(), "\r\n", "\n"))
+	})
+
+	t.Run("retry once", func(t *testing.T) {
+		buffer.Reset()
+		profile := config.NewProfile(nil, "name")
+		profile.RunOnDiskFull = []string{"echo cleanup"}
+		profile.RetryOnDiskFull = true
+		wrapper := newResticWrapper(nil, "echo", false, profile, "backup", nil, nil)
+
+		assert.True(t, wrapper.canRetryAfterError("backup", summary, failure))
+		assert.False(t, wrapper.canRetryAfterError("backup", summary, failure))
+		assert.Equal(t, "cleanup\n", strings.ReplaceAll(buffer.String(), "\r\n", "\n"))
+	})
+
+	t.Run("no retry when the recovery failed", func(t *testing.T) {
+		profile := config.NewProfile(nil, "name")
+		profile.RunOnDiskFull = []string{"exit 1"}
+		profile.RetryOnDiskFull = true
+		wrapper := newResticWrapper(nil, "echo", false, profile, "backup", nil, nil)
+
+		assert.False(t, wrapper.canRetryAfterError("backup", summary, failure))
+	})
+
+	t.Run("other failures", func(t *testing.T) {
+		buffer.Reset()
+		profile := config.NewProfile(nil, "name")
+		profile.RunOnDiskFull = []string{"echo cleanup"}
+		profile.RetryOnDiskFull = true
+		wrapper := newResticWrapper(nil, "echo", false, profile, "backup", nil, nil)
+
+		assert.False(t, wrapper.canRetryAfterError("backup", monitor.Summary{OutputAnalysis: &mockOutputAnalysis{}}, failure))
+		assert.Empty(t, buffer.String())
+	})
+}
+
+func TestRunProfileOnDiskFull(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	term.SetOutput(buffer)
+	defer term.SetOutput(os.Stdout)
+	errorBuffer := &bytes.Buffer{}
+	term.SetErrorOutput(errorBuffer)
+	defer term.SetErrorOutput(os.Stderr)
+
+	profile := config.NewProfile(nil, "name")
+	profile.RunOnDiskFull = []string{"echo cleanup: $ERROR_MESSAGE"}
+	profile.RetryOnDiskFull = true
+
+	wrapper := newResticWrapper(nil, mockBinary, false, profile, "backup", []string{"--stderr", "no space left on device", "--exit", "1"}, nil)
+	err := wrapper.runProfile()
+	assert.Error(t, err)
+	assert.Equal(t, "cleanup: exit status 1\n", strings.ReplaceAll(buffer.String(), "\r\n", "\n"))
+	// restic ran twice
+	assert.Equal(t, 2, strings.Count(errorBuffer.String(), "no space left on device"))
+}
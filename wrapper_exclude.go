@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/exclude"
+	"github.com/creativeprojects/resticprofile/shell"
+)
+
+const excludeFile = "exclude-file"
+
+// convertedExcludes returns the restic patterns converted from the .gitignore files of the sources ("exclude-from-gitignore")
+// and from the borg pattern files ("exclude-borg-patterns") of the backup section
+func convertedExcludes(profileName string, backup *config.BackupSection, sources []string) (patterns []string, err error) {
+	if backup == nil {
+		return nil, nil
+	}
+	if backup.ExcludeFromGitignore {
+		for _, source := range sources {
+			if info, err := os.Stat(source); err != nil || !info.IsDir() {
+				continue
+			}
+			if dir, err := filepath.Abs(source); err == nil {
+				source = dir
+			}
+			rules, err := exclude.ReadGitignore(source)
+			if err != nil {
+				return nil, fmt.Errorf("cannot read the %s file of %q: %w", exclude.GitignoreFile, source, err)
+			}
+			patterns = append(patterns, rules...)
+		}
+	}
+	for _, filename := range backup.ExcludeBorgPatterns {
+		rules, warnings, err := exclude.ReadBorg(filename)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read the borg patterns: %w", err)
+		}
+		for _, warning := range warnings {
+			clog.Warningf("profile '%s': borg patterns %q %s: ignored", profileName, filename, warning)
+		}
+		patterns = append(patterns, rules...)
+	}
+	return patterns, nil
+}
+
+// generateExcludeFile writes the exclusions converted from the .gitignore files and the borg patterns
+// to a temporary file passed to restic with the --exclude-file flag.
+// It returns a function removing the temporary file.
+func (r *resticWrapper) generateExcludeFile(args *shell.Args) (func(), error) {
+	cleanup := func() {}
+	patterns, err := convertedExcludes(r.profile.Name, r.profile.Backup, r.getBackupSource())
+	if err != nil || len(patterns) == 0 {
+		return cleanup, err
+	}
+	filename, err := writeSeparatedListFile(excludeFile, patterns, "\n")
+	if err != nil {
+		return cleanup, fmt.Errorf("cannot write the converted exclusions to a temporary file: %w", err)
+	}
+	clog.Debugf("%d exclusion(s) converted from .gitignore and borg patterns", len(patterns))
+	addFlagValue(args, excludeFile, filename)
+	return func() {
+		if err := os.Remove(filename); err != nil {
+			clog.Debugf("cannot remove temporary file: %s", err)
+		}
+	}, nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/shell"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateExcludeFile(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "src")
+	require.NoError(t, os.Mkdir(source, 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(source, ".gitignore"), []byte("*.o\n/build/\n"), 0o600))
+	borg := filepath.Join(dir, "patterns")
+	require.NoError(t, os.WriteFile(borg, []byte("- home/*/.cache\n+ /home/user/keep\n"), 0o600))
+
+	profile := config.NewProfile(nil, "name")
+	profile.Backup = &config.BackupSection{
+		Source:               []string{source, filepath.Join(dir, "missing")},
+		ExcludeFromGitignore: true,
+		ExcludeBorgPatterns:  []string{borg},
+	}
+
+	t.Run("exclude file", func(t *testing.T) {
+		wrapper := newResticWrapper(nil, "restic", false, profile, "backup", nil, nil)
+		args := shell.NewArgs()
+		args.AddFlag("exclude-file", "/etc/excludes", shell.ArgConfigEscape)
+		cleanup, err := wrapper.generateExcludeFile(args)
+		require.NoError(t, err)
+
+		flags := args.ToMap()
+		require.Len(t, flags["exclude-file"], 2)
+		assert.Equal(t, "/etc/excludes", flags["exclude-file"][0])
+		content, err := os.ReadFile(flags["exclude-file"][1])
+		require.NoError(t, err)
+		base := filepath.ToSlash(source)
+		assert.Equal(t, base+"/**/*.o\n"+base+"/build\n/home/*/.cache\n", string(content))
+
+		cleanup()
+		assert.NoFileExists(t, flags["exclude-file"][1])
+	})
+
+	t.Run("source filter", func(t *testing.T) {
+		wrapper := newResticWrapper(nil, "restic", false, profile, "backup", nil, nil)
+		filter := wrapper.getSourceFilter()
+		require.NotNil(t, filter)
+		assert.Contains(t, filter.exclude, "/home/*/.cache")
+	})
+
+	t.Run("missing borg patterns", func(t *testing.T) {
+		missing := config.NewProfile(nil, "name")
+		missing.Backup = &config.BackupSection{ExcludeBorgPatterns: []string{filepath.Join(dir, "missing")}}
+		wrapper := newResticWrapper(nil, "restic", false, missing, "backup", nil, nil)
+		args := shell.NewArgs()
+		_, err := wrapper.generateExcludeFile(args)
+		assert.ErrorContains(t, err, "cannot read the borg patterns")
+		assert.Empty(t, args.ToMap())
+	})
+
+	t.Run("no conversion", func(t *testing.T) {
+		wrapper := newResticWrapper(nil, "restic", false, config.NewProfile(nil, "name"), "backup", nil, nil)
+		args := shell.NewArgs()
+		cleanup, err := wrapper.generateExcludeFile(args)
+		require.NoError(t, err)
+		cleanup()
+		assert.Empty(t, args.ToMap())
+	})
+}
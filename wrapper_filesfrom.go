@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/constants"
+	"github.com/creativeprojects/resticprofile/restic"
+	"github.com/creativeprojects/resticprofile/shell"
+)
+
+const filesFromRaw = "files-from-raw"
+
+// generateFilesFrom writes the "source-verbatim" and "source-raw" lists of the backup section to temporary files,
+// passed to restic with the --files-from-verbatim and --files-from-raw flags.
+// A path of "source-verbatim" containing a new line cannot be written one per line: it's written in the raw file instead.
+// It returns a function removing the temporary files.
+func (r *resticWrapper) generateFilesFrom(args *shell.Args) (func(), error) {
+	var files []string
+	cleanup := func() {
+		for _, file := range files {
+			if err := os.Remove(file); err != nil {
+				clog.Debugf("cannot remove temporary file: %s", err)
+			}
+		}
+	}
+	if r.profile.Backup == nil || (len(r.profile.Backup.SourceVerbatim) == 0 && len(r.profile.Backup.SourceRaw) == 0) {
+		return cleanup, nil
+	}
+
+	verbatim := make([]string, 0, len(r.profile.Backup.SourceVerbatim))
+	raw := append([]string{}, r.profile.Backup.SourceRaw...)
+	for _, path := range r.profile.Backup.SourceVerbatim {
+		if strings.ContainsAny(path, "\r\n") {
+			clog.Debugf("path %q contains a new line: writing it to the %s file", path, filesFromRaw)
+			raw = append(raw, path)
+			continue
+		}
+		verbatim = append(verbatim, path)
+	}
+
+	for _, list := range []struct {
+		flag      string
+		paths     []string
+		separator string
+	}{
+		{flag: filesFromVerbatim, paths: verbatim, separator: "\n"},
+		{flag: filesFromRaw, paths: raw, separator: "\x00"},
+	} {
+		if len(list.paths) == 0 {
+			continue
+		}
+		if err := restic.CheckOption(constants.CommandBackup, list.flag, r.getResticVersion()); err != nil {
+			cleanup()
+			return nil, fmt.Errorf("cannot use the %s list: %w", strings.Replace(list.flag, "files-from", "source", 1), err)
+		}
+		filename, err := writeSeparatedListFile(list.flag, list.paths, list.separator)
+		if err != nil {
+			cleanup()
+			return nil, fmt.Errorf("cannot write the %s list to a temporary file: %w", list.flag, err)
+		}
+		files = append(files, filename)
+		addFlagValue(args, list.flag, filename)
+	}
+	return cleanup, nil
+}
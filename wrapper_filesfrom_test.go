@@ -0,0 +1,61 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/shell"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateFilesFrom(t *testing.T) {
+	profile := config.NewProfile(nil, "name")
+	profile.Backup = &config.BackupSection{
+		SourceVerbatim: []string{"/home/user/$HOME", "/home/user/with spaces", "/home/user/new\nline"},
+		SourceRaw:      []string{"/home/user/*"},
+	}
+
+	t.Run("lists", func(t *testing.T) {
+		wrapper := newResticWrapper(nil, "restic", false, profile, "backup", nil, nil)
+		args := shell.NewArgs()
+		args.AddFlag("files-from-verbatim", "/etc/sources", shell.ArgConfigEscape)
+		cleanup, err := wrapper.generateFilesFrom(args)
+		require.NoError(t, err)
+
+		flags := args.ToMap()
+		require.Len(t, flags["files-from-verbatim"], 2)
+		assert.Equal(t, "/etc/sources", flags["files-from-verbatim"][0])
+		require.Len(t, flags["files-from-raw"], 1)
+
+		verbatim, err := os.ReadFile(flags["files-from-verbatim"][1])
+		require.NoError(t, err)
+		assert.Equal(t, "/home/user/$HOME\n/home/user/with spaces\n", string(verbatim))
+
+		raw, err := os.ReadFile(flags["files-from-raw"][0])
+		require.NoError(t, err)
+		assert.Equal(t, "/home/user/*\x00/home/user/new\nline\x00", string(raw))
+
+		cleanup()
+		assert.NoFileExists(t, flags["files-from-verbatim"][1])
+		assert.NoFileExists(t, flags["files-from-raw"][0])
+	})
+
+	t.Run("old restic", func(t *testing.T) {
+		wrapper := newResticWrapper(&config.Global{ResticVersion: "0.11"}, "restic", false, profile, "backup", nil, nil)
+		args := shell.NewArgs()
+		_, err := wrapper.generateFilesFrom(args)
+		assert.ErrorContains(t, err, "source-verbatim")
+		assert.Empty(t, args.ToMap())
+	})
+
+	t.Run("no list", func(t *testing.T) {
+		wrapper := newResticWrapper(nil, "restic", false, config.NewProfile(nil, "name"), "backup", nil, nil)
+		args := shell.NewArgs()
+		cleanup, err := wrapper.generateFilesFrom(args)
+		require.NoError(t, err)
+		cleanup()
+		assert.Empty(t, args.ToMap())
+	})
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/monitor"
+	"github.com/creativeprojects/resticprofile/util"
+	"github.com/creativeprojects/resticprofile/util/redact"
+)
+
+// recordCommandResult keeps the summary of the command of the profile, sent with the pings of healthchecks.io
+func (r *resticWrapper) recordCommandResult(command string, summary monitor.Summary, stderr string, result error) {
+	// the additional repositories of the profile are reported separately
+	if command != r.command || summary.Repository != "" {
+		return
+	}
+	r.commandSummary = summary
+	r.commandWarnings = ""
+	if monitor.IsWarning(result) {
+		r.commandWarnings = stderr
+	}
+}
+
+// pingHealthchecks sends the ping (start, success or fail) of the command to healthchecks.io. A successful
+// command with warnings also sends a "log" ping with the warnings.
+func (r *resticWrapper) pingHealthchecks(monitoring config.SendMonitoringSections, command, ping string, err error) {
+	check := monitoring.Healthchecks
+	if check.IsEmpty() || r.skipHooks("healthchecks", 1) {
+		return
+	}
+	limit := check.GetLogSize()
+	switch ping {
+	case config.HealthchecksPingStart:
+		r.sendHealthchecks(check, command, ping, "")
+	case config.HealthchecksPingSuccess:
+		if r.commandWarnings != "" {
+			r.sendHealthchecks(check, command, config.HealthchecksPingLog, lastBytes(redact.String(r.commandWarnings), limit))
+		}
+		r.sendHealthchecks(check, command, ping, firstBytes(healthchecksSummary(command, r.commandSummary), limit))
+	case config.HealthchecksPingFail:
+		body := r.getErrorContext(err).Message
+		if r.commandSummary.Duration > 0 {
+			body += "\n\n" + healthchecksSummary(command, r.commandSummary)
+		}
+		if r.output != nil {
+			output := redact.String(r.output.String())
+			if remaining := limit - len(body) - 2; remaining > 0 && output != "" {
+				body += "\n\n" + lastBytes(output, remaining)
+			}
+		}
+		r.sendHealthchecks(check, command, ping, firstBytes(body, limit))
+	}
+}
+
+func (r *resticWrapper) sendHealthchecks(check *config.HealthchecksSection, command, ping, body string) {
+	section := config.SendMonitoringSection{
+		Method:       http.MethodPost,
+		URL:          check.GetPingURL(r.profile.Name, command, ping),
+		SkipTLS:      check.SkipTLS,
+		AttachOutput: "inline", // the body is sent as is
+	}
+	ctx := r.getContext()
+	ctx.Output = body
+	clog.Debugf("sending %q ping of %s to healthchecks", ping, command)
+	endSpan := r.traceSpan("healthchecks", map[string]any{"url.full": section.URL.String()})
+	err := r.sender.Send(section, ctx)
+	endSpan(err)
+	if err != nil {
+		clog.Warningf("healthchecks ping %s returned an error: %s", section.URL.String(), err.Error())
+	}
+}
+
+// healthchecksSummary describes the result of the command in a few lines
+func healthchecksSummary(command string, summary monitor.Summary) string {
+	lines := []string{fmt.Sprintf("%s duration: %s", command, summary.Duration.Round(time.Second))}
+	if summary.ErrorCode != "" {
+		lines = append(lines, "error code: "+summary.ErrorCode)
+	}
+	if summary.SnapshotID != "" {
+		lines = append(lines, "snapshot: "+summary.SnapshotID)
+	}
+	if summary.FilesTotal > 0 || summary.BytesTotal > 0 {
+		lines = append(lines,
+			fmt.Sprintf("files: %d new, %d changed, %d unmodified", summary.FilesNew, summary.FilesChanged, summary.FilesUnmodified),
+			fmt.Sprintf("dirs: %d new, %d changed, %d unmodified", summary.DirsNew, summary.DirsChanged, summary.DirsUnmodified),
+			fmt.Sprintf("added to the repository: %s", util.FormatBytes(summary.BytesAdded)),
+			fmt.Sprintf("processed: %d files, %s", summary.FilesTotal, util.FormatBytes(summary.BytesTotal)),
+		)
+	}
+	if summary.Forget != nil {
+		lines = append(lines, fmt.Sprintf("snapshots: %d kept, %d removed", summary.Forget.SnapshotsKept, summary.Forget.SnapshotsRemoved))
+	}
+	if summary.Check != nil {
+		lines = append(lines, fmt.Sprintf("errors found: %d", summary.Check.Errors))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// firstBytes returns the beginning of the text up to limit bytes
+func firstBytes(text string, limit int) string {
+	if len(text) <= limit {
+		return text
+	}
+	return text[:limit]
+}
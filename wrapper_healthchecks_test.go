@@ -0,0 +1,86 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/monitor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthchecksPings(t *testing.T) {
+	var pings, bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		pings = append(pings, r.Method+" "+r.URL.Path)
+		bodies = append(bodies, string(data))
+	}))
+	defer server.Close()
+
+	profile := config.NewProfile(nil, "name")
+	profile.Backup = &config.BackupSection{}
+	profile.Backup.Healthchecks = &config.HealthchecksSection{UUID: config.NewConfidentialValue("uuid"), Server: server.URL}
+
+	t.Run("success", func(t *testing.T) {
+		pings, bodies = nil, nil
+		wrapper := newResticWrapper(nil, mockBinary, false, profile, "backup", nil, nil)
+		require.NoError(t, wrapper.runProfile())
+		assert.Equal(t, []string{"POST /uuid/start", "POST /uuid"}, pings)
+		assert.Empty(t, bodies[0])
+		assert.Contains(t, bodies[1], "backup duration: ")
+	})
+
+	t.Run("warning", func(t *testing.T) {
+		pings, bodies = nil, nil
+		profile.Backup.NoErrorOnWarning = true
+		defer func() { profile.Backup.NoErrorOnWarning = false }()
+		wrapper := newResticWrapper(nil, mockBinary, false, profile, "backup", []string{"--stderr", "unreadable file", "--exit", "3"}, nil)
+		require.NoError(t, wrapper.runProfile())
+		assert.Equal(t, []string{"POST /uuid/start", "POST /uuid/log", "POST /uuid"}, pings)
+		assert.Contains(t, bodies[1], "unreadable file")
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		pings, bodies = nil, nil
+		wrapper := newResticWrapper(nil, mockBinary, false, profile, "backup", []string{"--stderr", "repository not found", "--exit", "1"}, nil)
+		require.Error(t, wrapper.runProfile())
+		assert.Equal(t, []string{"POST /uuid/start", "POST /uuid/fail"}, pings)
+		assert.Contains(t, bodies[1], "backup duration: ")
+		assert.Contains(t, bodies[1], "repository not found")
+	})
+
+	t.Run("before the command", func(t *testing.T) {
+		pings, bodies = nil, nil
+		profile.RunBefore = []string{"exit 1"}
+		defer func() { profile.RunBefore = nil }()
+		wrapper := newResticWrapper(nil, mockBinary, false, profile, "backup", nil, nil)
+		require.Error(t, wrapper.runProfile())
+		assert.Equal(t, []string{"POST /uuid/fail"}, pings)
+		assert.NotContains(t, bodies[0], "duration")
+	})
+}
+
+func TestHealthchecksSummary(t *testing.T) {
+	summary := healthchecksSummary("backup", monitor.Summary{
+		Duration:   90 * time.Second,
+		SnapshotID: "abcdef12",
+		FilesNew:   2,
+		FilesTotal: 10,
+		BytesAdded: 2048,
+		BytesTotal: 4096,
+	})
+	assert.Equal(t, "backup duration: 1m30s\n"+
+		"snapshot: abcdef12\n"+
+		"files: 2 new, 0 changed, 0 unmodified\n"+
+		"dirs: 0 new, 0 changed, 0 unmodified\n"+
+		"added to the repository: 2.0 KiB\n"+
+		"processed: 10 files, 4.0 KiB", summary)
+
+	assert.Equal(t, "check duration: 5s\nerror code: locked", healthchecksSummary("check", monitor.Summary{Duration: 5 * time.Second, ErrorCode: "locked"}))
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/config"
+)
+
+// errInterrupted is returned when the profile stopped after receiving an interrupt signal
+var errInterrupted = errors.New("interrupted by signal")
+
+// setupInterruptPolicy applies the interrupt policy of the profile to the signals received by resticprofile.
+// The returned function must be called once the profile has finished running.
+func (r *resticWrapper) setupInterruptPolicy() (stop func()) {
+	policy := r.profile.GetInterruptPolicy()
+	if r.sigChan == nil || policy == config.InterruptPolicyForward {
+		return func() {}
+	}
+
+	received := r.sigChan
+	commandSignals := make(chan os.Signal, 1)
+	r.sigChan = commandSignals
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case sig := <-received:
+				first := r.interrupted.CompareAndSwap(false, true)
+				if first && policy == config.InterruptPolicyWait {
+					clog.Warningf("profile '%s': received %s, waiting for the running command to finish (interrupt again to stop it now)", r.profile.Name, sig)
+					continue
+				}
+				select {
+				case commandSignals <- sig:
+				default:
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		r.sigChan = received
+	}
+}
+
+// checkInterrupted returns an error when an interrupt signal was received: the profile must not start any new command
+func (r *resticWrapper) checkInterrupted() error {
+	if r.interrupted.Load() {
+		return fmt.Errorf("profile '%s': %w", r.profile.Name, errInterrupted)
+	}
+	return nil
+}
+
+// skipRunAfterFail returns true when the run-after-fail hooks must not run after an interrupt signal
+func (r *resticWrapper) skipRunAfterFail() bool {
+	return r.interrupted.Load() && r.profile.GetInterruptPolicy() == config.InterruptPolicyExit
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/term"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetInterruptPolicy(t *testing.T) {
+	profile := config.NewProfile(nil, "name")
+	assert.Equal(t, config.InterruptPolicyForward, profile.GetInterruptPolicy())
+	profile.InterruptPolicy = "wait"
+	assert.Equal(t, config.InterruptPolicyWait, profile.GetInterruptPolicy())
+	profile.InterruptPolicy = "Exit"
+	assert.Equal(t, config.InterruptPolicyExit, profile.GetInterruptPolicy())
+	profile.InterruptPolicy = "unknown"
+	assert.Equal(t, config.InterruptPolicyForward, profile.GetInterruptPolicy())
+}
+
+func TestInterruptPolicy(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("signal handling is not supported on Windows")
+	}
+
+	runInterrupted := func(t *testing.T, policy string, runBefore ...string) (string, error) {
+		t.Helper()
+		buffer := &bytes.Buffer{}
+		term.SetOutput(buffer)
+		defer term.SetOutput(os.Stdout)
+
+		profile := config.NewProfile(nil, "name")
+		profile.InterruptPolicy = policy
+		profile.RunBefore = runBefore
+		profile.RunAfterFail = []string{"echo after-fail"}
+		profile.RunFinally = []string{"echo finally"}
+
+		signals := make(chan os.Signal, 1)
+		wrapper := newResticWrapper(nil, "echo", false, profile, "test", nil, signals)
+		go func() {
+			time.Sleep(300 * time.Millisecond)
+			signals <- os.Interrupt
+		}()
+		err := wrapper.runProfile()
+		assert.Equal(t, signals, wrapper.sigChan)
+		return buffer.String(), err
+	}
+
+	t.Run("forward", func(t *testing.T) {
+		start := time.Now()
+		output, err := runInterrupted(t, "", "sleep 5", "echo not running")
+		assert.Less(t, time.Since(start), 4*time.Second, "interrupt not sent to the command")
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, errInterrupted)
+		assert.Equal(t, "after-fail\nfinally\n", output)
+	})
+
+	t.Run("wait", func(t *testing.T) {
+		start := time.Now()
+		output, err := runInterrupted(t, "wait", "sleep 1", "echo not running")
+		assert.GreaterOrEqual(t, time.Since(start), time.Second, "command was interrupted")
+		assert.ErrorIs(t, err, errInterrupted)
+		assert.Equal(t, "after-fail\nfinally\n", output)
+	})
+
+	t.Run("exit", func(t *testing.T) {
+		start := time.Now()
+		output, err := runInterrupted(t, "exit", "sleep 5", "echo not running")
+		assert.Less(t, time.Since(start), 4*time.Second, "interrupt not sent to the command")
+		require.Error(t, err)
+		assert.Equal(t, "finally\n", output)
+	})
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"github.com/creativeprojects/resticprofile/constants"
+	"github.com/creativeprojects/resticprofile/shell"
+)
+
+// jsonSummaryScanner adds the --json flag to the arguments when the summary of the command is read from the
+// structured output of restic ("json-summary"), and returns the scanner of this output (nil otherwise)
+func (r *resticWrapper) jsonSummaryScanner(command string, args *shell.Args) shell.ScanOutput {
+	if !r.profile.UseJSONSummary(command) {
+		return nil
+	}
+	if _, found := args.Get("json"); !found {
+		args.AddFlag("json", "", shell.ArgConfigEscape)
+	}
+	switch command {
+	case constants.CommandBackup:
+		return shell.ScanBackupJson
+	case constants.CommandForget:
+		return shell.ScanForgetJson
+	case constants.CommandCheck:
+		return shell.ScanCheckJson
+	}
+	return nil
+}
+
+// getSummaryEnvironment returns the environment variables describing the summary of the command of the profile
+func (r *resticWrapper) getSummaryEnvironment() (env []string) {
+	values := r.commandSummary.Environment()
+	for _, name := range sortedKeys(values) {
+		env = append(env, name+"="+values[name])
+	}
+	return
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/platform"
+	"github.com/creativeprojects/resticprofile/shell"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONSummaryScanner(t *testing.T) {
+	profile := config.NewProfile(nil, "name")
+	wrapper := newResticWrapper(nil, "restic", false, profile, "forget", nil, nil)
+
+	args := shell.NewArgs()
+	assert.Nil(t, wrapper.jsonSummaryScanner("forget", args))
+	assert.Empty(t, args.ToMap())
+
+	profile.JSONSummary = true
+	assert.NotNil(t, wrapper.jsonSummaryScanner("forget", args))
+	assert.Contains(t, args.ToMap(), "json")
+	assert.NotNil(t, wrapper.jsonSummaryScanner("check", args))
+	assert.NotNil(t, wrapper.jsonSummaryScanner("backup", args))
+	assert.Nil(t, wrapper.jsonSummaryScanner("prune", shell.NewArgs()))
+}
+
+func TestJSONSummaryEnvironment(t *testing.T) {
+	if platform.IsWindows() {
+		t.Skip("shell script")
+	}
+	dir := t.TempDir()
+	binary := filepath.Join(dir, "restic")
+	script := "#!/bin/sh\ncase \"$*\" in *--json*) echo '[{\"keep\":[{\"id\":\"a\"},{\"id\":\"b\"}],\"remove\":[{\"id\":\"c\"}]}]';; esac\n"
+	require.NoError(t, os.WriteFile(binary, []byte(script), 0o700))
+	result := filepath.Join(dir, "result")
+
+	profile := config.NewProfile(nil, "name")
+	profile.JSONSummary = true
+	profile.RunAfter = []string{"echo \"$SUMMARY_SNAPSHOTS_KEPT $SUMMARY_SNAPSHOTS_REMOVED\" > " + result}
+
+	wrapper := newResticWrapper(nil, binary, false, profile, "forget", nil, nil)
+	require.NoError(t, wrapper.runProfile())
+	require.NotNil(t, wrapper.commandSummary.Forget)
+	assert.Equal(t, 2, wrapper.commandSummary.Forget.SnapshotsKept)
+
+	content, err := os.ReadFile(result)
+	require.NoError(t, err)
+	assert.Equal(t, "2 1\n", string(content))
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/constants"
+	"github.com/creativeprojects/resticprofile/monitor/status"
+	"github.com/creativeprojects/resticprofile/shell"
+	"github.com/creativeprojects/resticprofile/util/collect"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	// mountBackgroundFlag is a resticprofile flag (not sent to restic) to keep "mount" running in the background
+	mountBackgroundFlag = "--background"
+	// mountStartupDelay is the time given to restic to fail mounting before the mount is considered running
+	mountStartupDelay = 3 * time.Second
+)
+
+// mountInBackground returns true when the mount command was requested with the --background flag
+func (r *resticWrapper) mountInBackground() bool {
+	return r.command == constants.CommandMount && slices.Contains(r.moreArgs, mountBackgroundFlag)
+}
+
+// getMountBackgroundAction returns the action starting restic mount as a detached child process
+func (r *resticWrapper) getMountBackgroundAction() func() error {
+	return func() error {
+		return r.runMountInBackground()
+	}
+}
+
+// runMountInBackground starts "restic mount" in its own process group and records it in the status file
+func (r *resticWrapper) runMountInBackground() error {
+	if r.profile.StatusFile == "" {
+		return fmt.Errorf("mount in background on profile '%s' requires a 'status-file'", r.profile.Name)
+	}
+
+	// remove our own flag from the arguments sent to restic
+	r.moreArgs = collect.All(r.moreArgs, collect.Not(collect.In(mountBackgroundFlag)))
+
+	mountpoint := getMountpoint(r.moreArgs)
+	if mountpoint == "" {
+		return fmt.Errorf("mount on profile '%s': missing mountpoint", r.profile.Name)
+	}
+
+	// refuse to mount twice
+	current := status.NewStatus(r.profile.StatusFile).Load().Profile(r.profile.Name).Mount
+	if current != nil && processExists(current.PID) {
+		return fmt.Errorf("profile '%s' is already mounted on %q (pid %d)", r.profile.Name, current.Mountpoint, current.PID)
+	}
+
+	clog.Infof("profile '%s': starting 'mount' in background", r.profile.Name)
+	args := r.profile.GetCommandFlags(constants.CommandMount)
+	rCommand := r.prepareCommand(constants.CommandMount, args, true)
+
+	if r.dryRun {
+		_, _, err := runShellCommand(rCommand)
+		return err
+	}
+
+	logFile, err := os.CreateTemp("", "resticprofile-mount-*.log")
+	if err != nil {
+		return fmt.Errorf("cannot create log file for mount: %w", err)
+	}
+	defer logFile.Close()
+
+	shellCmd := shell.NewCommand(rCommand.command, rCommand.args)
+	shellCmd.Shell = rCommand.shell
+	shellBinary, shellArgs, err := shellCmd.GetShellCommand()
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command(shellBinary, shellArgs...)
+	cmd.Env = append(os.Environ(), rCommand.env...)
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	startDetached(cmd)
+
+	start := time.Now()
+	if err = cmd.Start(); err != nil {
+		auditCommand(rCommand, start, err)
+		return newCommandError(rCommand, "", fmt.Errorf("mount on profile '%s': %w", r.profile.Name, err))
+	}
+	pid := cmd.Process.Pid
+
+	// supervise the startup: restic exits quickly when the mount fails
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+
+	select {
+	case err = <-exited:
+		output, _ := os.ReadFile(logFile.Name())
+		_ = os.Remove(logFile.Name())
+		if err == nil {
+			err = errors.New("mount exited unexpectedly")
+		}
+		auditCommand(rCommand, start, err)
+		return newCommandError(rCommand, string(output), fmt.Errorf("mount on profile '%s': %w", r.profile.Name, err))
+
+	case <-time.After(mountStartupDelay):
+		// still running: the mount is considered successful
+		auditCommand(rCommand, start, nil)
+	}
+
+	err = status.NewStatus(r.profile.StatusFile).Update(func(state *status.Status) {
+		state.Profile(r.profile.Name).MountStarted(pid, mountpoint, logFile.Name())
+	})
+	if err != nil {
+		clog.Warningf("saving status file '%s': %v", r.profile.StatusFile, err)
+	}
+	clog.Infof("profile '%s': mounted on %q in background (pid %d), use \"%s.unmount\" to stop", r.profile.Name, mountpoint, pid, r.profile.Name)
+	return nil
+}
+
+// getMountpoint returns the last positional argument (the mountpoint of restic mount)
+func getMountpoint(args []string) (mountpoint string) {
+	if arg := collect.Last(args, func(arg string) bool { return !strings.HasPrefix(arg, "-") }); arg != nil {
+		mountpoint = *arg
+	}
+	return
+}
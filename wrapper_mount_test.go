@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/constants"
+	"github.com/creativeprojects/resticprofile/monitor/status"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetMountpoint(t *testing.T) {
+	testData := []struct {
+		args       []string
+		mountpoint string
+	}{
+		{nil, ""},
+		{[]string{"--allow-other"}, ""},
+		{[]string{"/mnt/restic"}, "/mnt/restic"},
+		{[]string{"--allow-other", "/mnt/restic"}, "/mnt/restic"},
+		{[]string{"/mnt/restic", "--no-default-permissions"}, "/mnt/restic"},
+	}
+	for _, testItem := range testData {
+		assert.Equal(t, testItem.mountpoint, getMountpoint(testItem.args))
+	}
+}
+
+func TestMountInBackground(t *testing.T) {
+	profile := config.NewProfile(nil, "name")
+	wrapper := newResticWrapper(nil, "restic", false, profile, constants.CommandMount, []string{"--background", "/mnt"}, nil)
+	assert.True(t, wrapper.mountInBackground())
+
+	wrapper = newResticWrapper(nil, "restic", false, profile, constants.CommandMount, []string{"/mnt"}, nil)
+	assert.False(t, wrapper.mountInBackground())
+
+	wrapper = newResticWrapper(nil, "restic", false, profile, constants.CommandBackup, []string{"--background"}, nil)
+	assert.False(t, wrapper.mountInBackground())
+}
+
+func TestMountInBackgroundNeedsStatusFile(t *testing.T) {
+	profile := config.NewProfile(nil, "name")
+	wrapper := newResticWrapper(nil, "restic", false, profile, constants.CommandMount, []string{"--background", "/mnt"}, nil)
+	err := wrapper.runMountInBackground()
+	assert.ErrorContains(t, err, "status-file")
+}
+
+func TestUnmountProfileWithDeadProcess(t *testing.T) {
+	profile := config.NewProfile(nil, "name")
+	profile.StatusFile = filepath.Join(t.TempDir(), "status.json")
+
+	state := status.NewStatus(profile.StatusFile)
+	state.Profile(profile.Name).MountStarted(-1, "/mnt", "")
+	require.NoError(t, state.Save())
+
+	require.NoError(t, unmountProfile(profile))
+
+	state = status.NewStatus(profile.StatusFile).Load()
+	assert.Nil(t, state.Profile(profile.Name).Mount)
+}
+
+func TestUnmountAllWithDeadProcesses(t *testing.T) {
+	statusFile := filepath.Join(t.TempDir(), "status.json")
+	cfg, err := config.Load(bytes.NewBufferString(fmt.Sprintf("[first]\nstatus-file = %q\n[second]\nstatus-file = %q\n", statusFile, statusFile)), "toml")
+	require.NoError(t, err)
+
+	state := status.NewStatus(statusFile)
+	state.Profile("first").MountStarted(-1, "/mnt/first", "")
+	state.Profile("second").MountStarted(-1, "/mnt/second", "")
+	require.NoError(t, state.Save())
+
+	unmountAll(cfg)
+
+	state = status.NewStatus(statusFile).Load()
+	assert.Nil(t, state.Profile("first").Mount)
+	assert.Nil(t, state.Profile("second").Mount)
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/shell"
+	"github.com/creativeprojects/resticprofile/util/redact"
+)
+
+// outputCapture keeps the end of the combined output (stdout and stderr) of the commands of a run
+type outputCapture struct {
+	mutex  sync.Mutex
+	buffer *shell.OutputBuffer
+}
+
+func newOutputCapture(limit int) *outputCapture {
+	return &outputCapture{buffer: shell.NewTailBuffer(limit)}
+}
+
+// Write can be called from the stdout and stderr of a command at the same time
+func (c *outputCapture) Write(p []byte) (int, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.buffer.Write(p)
+}
+
+func (c *outputCapture) String() string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.buffer.String()
+}
+
+// startOutputCapture captures the output of the run when a sender attaches it on failure (or for healthchecks.io)
+func (r *resticWrapper) startOutputCapture(monitoring config.SendMonitoringSections) {
+	limit := 0
+	for _, sections := range [][]config.SendMonitoringSection{monitoring.SendAfterFail, monitoring.SendFinally} {
+		for _, section := range sections {
+			if section.AttachOutput != "" && section.GetOutputSize() > limit {
+				limit = section.GetOutputSize()
+			}
+		}
+	}
+	if !monitoring.Healthchecks.IsEmpty() && monitoring.Healthchecks.GetLogSize() > limit {
+		limit = monitoring.Healthchecks.GetLogSize()
+	}
+	if limit > 0 {
+		r.output = newOutputCapture(limit)
+	}
+}
+
+// captureWriter returns the writer receiving the output of the commands, or nil when the output is not captured
+func (r *resticWrapper) captureWriter() io.Writer {
+	if r.output == nil {
+		return nil
+	}
+	return r.output
+}
+
+// attachedOutput returns the end of the output to attach to a sender after a failure
+func (r *resticWrapper) attachedOutput(section config.SendMonitoringSection, err error) string {
+	if err == nil || section.AttachOutput == "" || r.output == nil {
+		return ""
+	}
+	return redact.String(lastBytes(r.output.String(), section.GetOutputSize()))
+}
+
+// lastBytes returns the end of the output up to limit bytes, starting at a new line when possible
+func lastBytes(output string, limit int) string {
+	if len(output) <= limit {
+		return output
+	}
+	output = output[len(output)-limit:]
+	if index := strings.IndexByte(output, '\n'); index >= 0 && index < len(output)-1 {
+		output = output[index+1:]
+	}
+	return output
+}
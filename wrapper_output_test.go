@@ -0,0 +1,56 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLastBytes(t *testing.T) {
+	assert.Equal(t, "short", lastBytes("short", 10))
+	assert.Equal(t, "line 3\n", lastBytes("line 1\nline 2\nline 3\n", 10))
+	assert.Equal(t, "abcdef", lastBytes("0123456789abcdef", 6))
+}
+
+func TestAttachOutputOnFailure(t *testing.T) {
+	bodies := make(chan string, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		bodies <- string(data)
+	}))
+	defer server.Close()
+
+	profile := config.NewProfile(nil, "name")
+	profile.Backup = &config.BackupSection{}
+	profile.Backup.RunBefore = []string{"echo captured output", "exit 1"}
+	profile.Backup.SendAfterFail = []config.SendMonitoringSection{
+		{Method: http.MethodPost, URL: config.NewConfidentialValue(server.URL), AttachOutput: "inline"},
+	}
+	profile.Backup.SendFinally = []config.SendMonitoringSection{
+		{Method: http.MethodPost, URL: config.NewConfidentialValue(server.URL), Body: "no output"},
+	}
+
+	wrapper := newResticWrapper(nil, mockBinary, false, profile, "backup", nil, nil)
+	err := wrapper.runProfile()
+	require.Error(t, err)
+
+	assert.Contains(t, <-bodies, "captured output")
+	assert.Equal(t, "no output", <-bodies)
+}
+
+func TestNoOutputCaptureWithoutAttachment(t *testing.T) {
+	profile := config.NewProfile(nil, "name")
+	profile.Backup = &config.BackupSection{}
+	profile.Backup.SendAfterFail = []config.SendMonitoringSection{{URL: config.NewConfidentialValue("http://localhost")}}
+
+	wrapper := newResticWrapper(nil, mockBinary, false, profile, "backup", nil, nil)
+	wrapper.startOutputCapture(profile.GetMonitoringSections("backup"))
+	assert.Nil(t, wrapper.captureWriter())
+	assert.Empty(t, wrapper.attachedOutput(profile.Backup.SendAfterFail[0], assert.AnError))
+}
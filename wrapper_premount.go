@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/volume"
+)
+
+// withPremount mounts the volumes of the profile before the action, and always unmounts them afterwards (in reverse order)
+func (r *resticWrapper) withPremount(action func() error) func() error {
+	if len(r.profile.Premount) == 0 {
+		return action
+	}
+	return func() (err error) {
+		mounted := make([]*volume.Mounted, 0, len(r.profile.Premount))
+		defer func() {
+			for index := len(mounted) - 1; index >= 0; index-- {
+				if unmountErr := mounted[index].Unmount(); unmountErr != nil {
+					clog.Errorf("profile '%s': %v", r.profile.Name, unmountErr)
+					if err == nil {
+						err = unmountErr
+					}
+				}
+			}
+		}()
+
+		for _, section := range r.profile.Premount {
+			vol := section.GetVolume()
+			if r.dryRun {
+				clog.Infof("dry-run: profile '%s': mount volume %s", r.profile.Name, vol)
+				continue
+			}
+			clog.Infof("profile '%s': mounting volume %s", r.profile.Name, vol)
+			item, err := vol.Mount()
+			if err != nil {
+				return fmt.Errorf("profile '%s': %w", r.profile.Name, err)
+			}
+			mounted = append(mounted, item)
+		}
+		return action()
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/term"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPremountFailureStopsTheRun(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	term.SetOutput(buffer)
+	defer term.SetOutput(os.Stdout)
+
+	profile := config.NewProfile(nil, "name")
+	profile.RunBefore = []string{"echo before"}
+	profile.RunAfterFail = []string{"echo failed"}
+	profile.RunFinally = []string{"echo finally"}
+	profile.Premount = []config.PremountSection{{Type: "smb", Source: "//server/share", Target: "/mnt/share"}}
+
+	wrapper := newResticWrapper(nil, "echo", false, profile, "snapshots", nil, nil)
+	err := wrapper.runProfile()
+	assert.ErrorContains(t, err, `profile 'name': unknown type of volume "smb"`)
+	assert.Equal(t, "failed\nfinally\n", strings.ReplaceAll(buffer.String(), "\r\n", "\n"))
+}
+
+func TestPremountDryRun(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	term.SetOutput(buffer)
+	defer term.SetOutput(os.Stdout)
+
+	profile := config.NewProfile(nil, "name")
+	profile.Premount = []config.PremountSection{{Type: "nfs", Source: "server:/export", Target: "/mnt/backup"}}
+
+	wrapper := newResticWrapper(nil, "echo", true, profile, "snapshots", nil, nil)
+	assert.NoError(t, wrapper.runProfile())
+}
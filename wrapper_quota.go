@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/constants"
+	"github.com/creativeprojects/resticprofile/shell"
+	"github.com/creativeprojects/resticprofile/util"
+)
+
+// Status of the quota of a repository
+const (
+	quotaStatusOK       = "ok"
+	quotaStatusWarning  = "warning"
+	quotaStatusExceeded = "exceeded"
+)
+
+// quotaState is the last usage of the repository, kept in the state file of the quota between runs
+type quotaState struct {
+	Time    time.Time `json:"time"`
+	Used    uint64    `json:"used"`
+	Limit   uint64    `json:"limit"`
+	Status  string    `json:"status"`
+	Blocked bool      `json:"blocked"`
+}
+
+// percent returns the usage of the quota in percent
+func (s *quotaState) percent() float64 {
+	if s.Limit == 0 {
+		return 0
+	}
+	return float64(s.Used) * 100 / float64(s.Limit)
+}
+
+func (s *quotaState) String() string {
+	return fmt.Sprintf("%s of %s (%.1f%%)", util.FormatBytes(s.Used), util.FormatBytes(s.Limit), s.percent())
+}
+
+// repositoryStats is the JSON output of "restic stats --mode raw-data"
+type repositoryStats struct {
+	TotalSize uint64 `json:"total_size"`
+}
+
+// getQuotaStatus returns the status of the quota for the size used in the repository
+func getQuotaStatus(used, limit uint64, warnAt float64) string {
+	switch {
+	case used >= limit:
+		return quotaStatusExceeded
+	case float64(used)*100 >= float64(limit)*warnAt:
+		return quotaStatusWarning
+	default:
+		return quotaStatusOK
+	}
+}
+
+// getQuotaStateFile returns the file keeping the state of the quota of the profile
+func getQuotaStateFile(profile *config.Profile) string {
+	if profile.Quota != nil && profile.Quota.StateFile != "" {
+		return profile.Quota.StateFile
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	hash := sha256.Sum256([]byte(profile.Name + "\n" + profile.Repository.Value()))
+	return filepath.Join(dir, constants.ApplicationName, "quota-"+hex.EncodeToString(hash[:8])+".json")
+}
+
+// loadQuotaState reads the state of the quota, and returns nil when the quota was never checked
+func loadQuotaState(filename string) (*quotaState, error) {
+	if filename == "" {
+		return nil, nil
+	}
+	content, err := os.ReadFile(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read the state of the quota: %w", err)
+	}
+	state := new(quotaState)
+	if err = json.Unmarshal(content, state); err != nil {
+		return nil, fmt.Errorf("cannot read the state of the quota from %q: %w", filename, err)
+	}
+	return state, nil
+}
+
+// saveQuotaState writes the state of the quota
+func saveQuotaState(filename string, state *quotaState) error {
+	if filename == "" {
+		return errors.New("cannot save the state of the quota: no cache directory")
+	}
+	content, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(filename), 0o700); err != nil {
+		return fmt.Errorf("cannot save the state of the quota: %w", err)
+	}
+	if err = os.WriteFile(filename, content, 0o600); err != nil {
+		return fmt.Errorf("cannot save the state of the quota: %w", err)
+	}
+	return nil
+}
+
+// checkQuotaBlock returns an error when the backups of the profile are blocked by an exceeded quota
+func (r *resticWrapper) checkQuotaBlock() error {
+	if r.profile.Quota.IsEmpty() || !r.profile.Quota.BlockBackup {
+		return nil
+	}
+	state, err := loadQuotaState(getQuotaStateFile(r.profile))
+	if err != nil {
+		clog.Warningf("profile '%s': %s", r.profile.Name, err)
+		return nil
+	}
+	if state == nil || !state.Blocked {
+		return nil
+	}
+	err = fmt.Errorf("profile '%s': backup blocked since %s, the repository uses %s of max-repo-size: free some space in the repository, then run \"%s --name %s quota --ack\"",
+		r.profile.Name, state.Time.Format(time.RFC3339), state, constants.ApplicationName, r.profile.Name)
+	if r.dryRun {
+		clog.Warning(err)
+		return nil
+	}
+	return err
+}
+
+// runQuotaCheck reads the size of the repository and reports the usage of the quota.
+// Errors are not fatal to the backup: they're logged.
+func (r *resticWrapper) runQuotaCheck() {
+	quota := r.profile.Quota
+	if r.dryRun || quota.IsEmpty() {
+		return
+	}
+	limit, err := quota.GetMaxRepoSize()
+	if err != nil || limit == 0 {
+		return // reported in the configuration issues
+	}
+	warnAt, err := quota.GetWarnAt()
+	if err != nil {
+		return // reported in the configuration issues
+	}
+	var used uint64
+	if r.repositorySize != nil {
+		used = *r.repositorySize
+	} else {
+		clog.Infof("profile '%s': checking the size of the repository", r.profile.Name)
+		if used, err = r.getRepositorySize(); err != nil {
+			clog.Warningf("profile '%s': cannot check the quota of the repository: %s", r.profile.Name, err)
+			return
+		}
+	}
+
+	state := &quotaState{
+		Time:   time.Now(),
+		Used:   used,
+		Limit:  limit,
+		Status: getQuotaStatus(used, limit, warnAt),
+	}
+	state.Blocked = quota.BlockBackup && state.Status == quotaStatusExceeded
+	r.quota = state
+
+	switch state.Status {
+	case quotaStatusExceeded:
+		clog.Errorf("profile '%s': the repository uses %s: max-repo-size exceeded", r.profile.Name, state)
+		if state.Blocked {
+			clog.Errorf("profile '%s': the next backups are blocked until the quota is acknowledged with \"%s --name %s quota --ack\"",
+				r.profile.Name, constants.ApplicationName, r.profile.Name)
+		}
+	case quotaStatusWarning:
+		clog.Warningf("profile '%s': the repository uses %s, over the warning level of %.0f%%", r.profile.Name, state, warnAt)
+	default:
+		clog.Infof("profile '%s': the repository uses %s", r.profile.Name, state)
+	}
+
+	if err = saveQuotaState(getQuotaStateFile(r.profile), state); err != nil {
+		clog.Warningf("profile '%s': %s", r.profile.Name, err)
+	}
+	if state.Status != quotaStatusOK && len(quota.RunOnQuota) > 0 {
+		if err = r.runShellCommands(quota.RunOnQuota, "run-on-quota", "", nil); err != nil {
+			clog.Error(err)
+		}
+	}
+}
+
+// getRepositorySize returns the size of the data stored in the repository
+func (r *resticWrapper) getRepositorySize() (uint64, error) {
+	args := r.profile.GetCommonFlags()
+	args.AddFlag("json", "", shell.ArgConfigEscape)
+	args.AddFlag("mode", "raw-data", shell.ArgConfigEscape)
+
+	output := &bytes.Buffer{}
+	rCommand := r.prepareCommand(constants.CommandStats, args, false)
+	rCommand.stdout = output
+	_, _, err := runShellCommand(rCommand)
+	if err != nil {
+		return 0, err
+	}
+	return parseRepositorySize(output)
+}
+
+// parseRepositorySize reads the total size from the JSON output of "restic stats"
+func parseRepositorySize(reader io.Reader) (uint64, error) {
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 || line[0] != '{' {
+			continue
+		}
+		stats := repositoryStats{}
+		if err := json.Unmarshal(line, &stats); err == nil {
+			return stats.TotalSize, nil
+		}
+	}
+	return 0, errors.New("no statistics found in the output of restic stats")
+}
+
+// getQuotaEnvironment returns the environment variables describing the last check of the quota
+func (r *resticWrapper) getQuotaEnvironment() []string {
+	if r.quota == nil {
+		return nil
+	}
+	return []string{
+		fmt.Sprintf("%s=%s", constants.EnvQuotaStatus, r.quota.Status),
+		fmt.Sprintf("%s=%d", constants.EnvQuotaUsed, r.quota.Used),
+		fmt.Sprintf("%s=%d", constants.EnvQuotaLimit, r.quota.Limit),
+		fmt.Sprintf("%s=%.1f", constants.EnvQuotaPercent, r.quota.percent()),
+	}
+}
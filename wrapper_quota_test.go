@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetQuotaStatus(t *testing.T) {
+	assert.Equal(t, quotaStatusOK, getQuotaStatus(79, 100, 80))
+	assert.Equal(t, quotaStatusWarning, getQuotaStatus(80, 100, 80))
+	assert.Equal(t, quotaStatusWarning, getQuotaStatus(99, 100, 80))
+	assert.Equal(t, quotaStatusExceeded, getQuotaStatus(100, 100, 80))
+	assert.Equal(t, quotaStatusExceeded, getQuotaStatus(150, 100, 80))
+}
+
+func TestParseRepositorySize(t *testing.T) {
+	size, err := parseRepositorySize(strings.NewReader(`scanning...
+{"total_size":2048,"total_uncompressed_size":4096,"compression_ratio":2,"total_blob_count":10,"snapshots_count":3}
+`))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2048), size)
+
+	_, err = parseRepositorySize(strings.NewReader("no json"))
+	assert.Error(t, err)
+}
+
+func TestQuotaState(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "state", "quota.json")
+
+	state, err := loadQuotaState(filename)
+	require.NoError(t, err)
+	assert.Nil(t, state)
+
+	saved := &quotaState{Time: time.Now().Truncate(time.Second), Used: 90, Limit: 100, Status: quotaStatusWarning}
+	require.NoError(t, saveQuotaState(filename, saved))
+	state, err = loadQuotaState(filename)
+	require.NoError(t, err)
+	assert.Equal(t, saved.Used, state.Used)
+	assert.Equal(t, saved.Status, state.Status)
+	assert.True(t, saved.Time.Equal(state.Time))
+	assert.InDelta(t, 90.0, state.percent(), 0.01)
+}
+
+func TestQuotaStateFile(t *testing.T) {
+	profile := config.NewProfile(nil, "name")
+	profile.Quota = &config.QuotaSection{MaxRepoSize: "1G"}
+	assert.Contains(t, filepath.Base(getQuotaStateFile(profile)), "quota-")
+
+	profile.Quota.StateFile = "/var/lib/quota.json"
+	assert.Equal(t, "/var/lib/quota.json", getQuotaStateFile(profile))
+}
+
+func TestCheckQuotaBlock(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "quota.json")
+	profile := config.NewProfile(nil, "name")
+	profile.Quota = &config.QuotaSection{MaxRepoSize: "100", BlockBackup: true, StateFile: filename}
+	wrapper := newResticWrapper(nil, mockBinary, false, profile, "backup", nil, nil)
+
+	// never checked
+	assert.NoError(t, wrapper.checkQuotaBlock())
+
+	require.NoError(t, saveQuotaState(filename, &quotaState{Time: time.Now(), Used: 120, Limit: 100, Status: quotaStatusExceeded, Blocked: true}))
+	err := wrapper.checkQuotaBlock()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "profile 'name': backup blocked since")
+	assert.Contains(t, err.Error(), "quota --ack")
+
+	// only a warning in dry-run
+	dryRun := newResticWrapper(nil, mockBinary, true, profile, "backup", nil, nil)
+	assert.NoError(t, dryRun.checkQuotaBlock())
+
+	// backups not blocked by the profile
+	profile.Quota.BlockBackup = false
+	assert.NoError(t, wrapper.checkQuotaBlock())
+}
+
+func TestRunProfileBlockedByQuota(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "quota.json")
+	require.NoError(t, saveQuotaState(filename, &quotaState{Time: time.Now(), Used: 120, Limit: 100, Status: quotaStatusExceeded, Blocked: true}))
+
+	profile := config.NewProfile(nil, "name")
+	profile.Backup = &config.BackupSection{}
+	profile.Quota = &config.QuotaSection{MaxRepoSize: "100", BlockBackup: true, StateFile: filename}
+	wrapper := newResticWrapper(nil, mockBinary, false, profile, "backup", nil, nil)
+	err := wrapper.runProfile()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "backup blocked")
+}
+
+func TestQuotaEnvironment(t *testing.T) {
+	wrapper := newResticWrapper(nil, mockBinary, false, config.NewProfile(nil, "name"), "backup", nil, nil)
+	assert.Empty(t, wrapper.getQuotaEnvironment())
+
+	wrapper.quota = &quotaState{Used: 85, Limit: 100, Status: quotaStatusWarning}
+	assert.Equal(t, []string{"QUOTA_STATUS=warning", "QUOTA_USED=85", "QUOTA_LIMIT=100", "QUOTA_PERCENT=85.0"}, wrapper.getQuotaEnvironment())
+	assert.Subset(t, wrapper.getProfileEnvironment(), wrapper.getQuotaEnvironment())
+}
+
+func TestQuotaCommand(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "quota.json")
+	cfg, err := config.Load(bytes.NewBufferString(`
+version = "1"
+[name.quota]
+max-repo-size = "100"
+block-backup = true
+state-file = "`+filepath.ToSlash(filename)+`"
+[other]
+repository = "local:/backup"
+`), "toml")
+	require.NoError(t, err)
+
+	output := &bytes.Buffer{}
+	err = quotaCommand(output, commandRequest{config: cfg, flags: commandLineFlags{name: "name"}})
+	require.NoError(t, err)
+	assert.Contains(t, output.String(), "the quota was never checked")
+
+	require.NoError(t, saveQuotaState(filename, &quotaState{Time: time.Now(), Used: 120, Limit: 100, Status: quotaStatusExceeded, Blocked: true}))
+	output.Reset()
+	err = quotaCommand(output, commandRequest{config: cfg, flags: commandLineFlags{name: "name"}})
+	require.NoError(t, err)
+	assert.Contains(t, output.String(), "(120.0%)")
+	assert.Contains(t, output.String(), "the backups are blocked")
+
+	output.Reset()
+	err = quotaCommand(output, commandRequest{config: cfg, flags: commandLineFlags{name: "name"}, args: []string{"--ack"}})
+	require.NoError(t, err)
+	assert.Contains(t, output.String(), "the backups are no longer blocked")
+	state, err := loadQuotaState(filename)
+	require.NoError(t, err)
+	assert.False(t, state.Blocked)
+
+	err = quotaCommand(output, commandRequest{config: cfg, flags: commandLineFlags{name: "other"}})
+	assert.EqualError(t, err, "profile 'other' has no quota")
+
+	err = quotaCommand(output, commandRequest{config: cfg, args: []string{"--unknown"}})
+	assert.EqualError(t, err, "unknown flag --unknown for quota")
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/constants"
+	"golang.org/x/exp/slices"
+)
+
+// readOnlyCommands are the only restic commands allowed in read-only mode: none of them modifies the repository
+var readOnlyCommands = []string{
+	constants.CommandCat,
+	constants.CommandCheck,
+	constants.CommandDiff,
+	constants.CommandDump,
+	constants.CommandFind,
+	constants.CommandLs,
+	constants.CommandMount,
+	constants.CommandSnapshots,
+	constants.CommandStats,
+	"version",
+}
+
+// isReadOnlyCommand returns true when the restic command never modifies the repository
+func isReadOnlyCommand(command string) bool {
+	return slices.Contains(readOnlyCommands, command)
+}
+
+// setReadOnly only allows the commands that don't modify the repository, and disables all the hooks
+func (r *resticWrapper) setReadOnly() {
+	r.readOnly = true
+}
+
+// checkReadOnly returns an error when the command (or one step of the custom command) is not allowed in read-only mode
+func (r *resticWrapper) checkReadOnly(command string) error {
+	if !r.readOnly {
+		return nil
+	}
+	commands := []string{command}
+	if alias := r.profile.GetCommandAlias(command); alias != nil {
+		commands = commands[:0]
+		for _, step := range alias.Steps() {
+			commands = append(commands, step[0])
+		}
+	}
+	for _, name := range commands {
+		if !isReadOnlyCommand(name) {
+			return fmt.Errorf("profile '%s': command '%s' is not allowed in read-only mode", r.profile.Name, name)
+		}
+	}
+	return nil
+}
+
+// skipHooks returns true when the hooks must not run (read-only mode)
+func (r *resticWrapper) skipHooks(hookType string, count int) bool {
+	if r.readOnly && count > 0 {
+		clog.Debugf("profile '%s': read-only mode, skipping %d %s hook(s)", r.profile.Name, count, hookType)
+		return true
+	}
+	return false
+}
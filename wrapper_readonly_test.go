@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/term"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadOnlyCommands(t *testing.T) {
+	for _, command := range []string{"snapshots", "stats", "check", "ls", "cat", "dump", "version"} {
+		assert.True(t, isReadOnlyCommand(command), command)
+	}
+	for _, command := range []string{"backup", "forget", "prune", "init", "unlock", "copy", "restore", "tag", "key", "rewrite"} {
+		assert.False(t, isReadOnlyCommand(command), command)
+	}
+}
+
+func TestReadOnlyRefusesMutatingCommands(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	term.SetOutput(buffer)
+	profile := config.NewProfile(nil, "name")
+	profile.RunBefore = []string{"echo before"}
+	profile.Commands = map[string]*config.CommandAliasSection{
+		"report":  {Run: []string{"snapshots", "stats"}},
+		"nightly": {Run: []string{"snapshots", "forget"}},
+	}
+
+	for _, command := range []string{"backup", "forget", "prune", "init", "unlock", "nightly"} {
+		t.Run(command, func(t *testing.T) {
+			buffer.Reset()
+			wrapper := newResticWrapper(nil, "echo", false, profile, command, nil, nil)
+			wrapper.setReadOnly()
+			err := wrapper.runProfile()
+			assert.ErrorContains(t, err, "not allowed in read-only mode")
+			assert.Empty(t, buffer.String())
+		})
+	}
+
+	t.Run("report", func(t *testing.T) {
+		buffer.Reset()
+		wrapper := newResticWrapper(nil, "echo", false, profile, "report", nil, nil)
+		wrapper.setReadOnly()
+		require.NoError(t, wrapper.runProfile())
+		assert.Equal(t, "snapshots\nstats\n", strings.ReplaceAll(buffer.String(), "\r\n", "\n"))
+	})
+
+	t.Run("unlock", func(t *testing.T) {
+		wrapper := newResticWrapper(nil, "echo", false, profile, "snapshots", nil, nil)
+		wrapper.setReadOnly()
+		assert.ErrorContains(t, wrapper.runUnlock(), "not allowed in read-only mode")
+	})
+}
+
+func TestReadOnlySkipsHooks(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	term.SetOutput(buffer)
+	profile := config.NewProfile(nil, "name")
+	profile.Initialize = true
+	profile.RunBefore = []string{"echo before"}
+	profile.RunAfter = []string{"echo after"}
+	profile.RunFinally = []string{"echo finally"}
+	profile.Commands = map[string]*config.CommandAliasSection{
+		"report": {Run: []string{"snapshots"}},
+	}
+	profile.Commands["report"].RunBefore = []string{"echo report-before"}
+
+	wrapper := newResticWrapper(nil, "echo", false, profile, "report", nil, nil)
+	wrapper.setReadOnly()
+	require.NoError(t, wrapper.runProfile())
+	assert.Equal(t, "snapshots\n", strings.ReplaceAll(buffer.String(), "\r\n", "\n"))
+}
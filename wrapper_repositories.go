@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/constants"
+)
+
+// runRepositories writes the backup to the additional repositories of the profile, after the main repository:
+// either with another backup, or with a copy of the snapshots of the main repository (skipped when the backup of
+// the main repository failed). A failed repository doesn't stop the next ones: the first error is returned at the end.
+func (r *resticWrapper) runRepositories(names []string, backupSucceeded bool, backupAction func() error) (err error) {
+	defer func() { r.repository = "" }()
+
+	failed := 0
+	for _, name := range names {
+		repository := r.profile.GetRepository(name)
+		if repository == nil {
+			continue
+		}
+		if r.interrupted.Load() {
+			failed++
+			continue
+		}
+		r.repository = name
+		if r.global.Initialize || r.profile.Initialize {
+			_ = r.runInitializeRepository(name) // it's ok if the initialization returned an error
+		}
+
+		var repositoryErr error
+		if repository.IsCopy() {
+			if !backupSucceeded {
+				clog.Warningf("profile '%s': no copy to repository '%s' after the failed backup", r.profile.Name, name)
+				failed++
+				continue
+			}
+			repositoryErr = r.runRepositoryCopy(name)
+		} else {
+			clog.Infof("profile '%s': backup to repository '%s'", r.profile.Name, name)
+			repositoryErr = backupAction()
+		}
+		if repositoryErr != nil {
+			failed++
+			if err == nil {
+				err = repositoryErr
+			}
+		}
+	}
+
+	if failed > 0 {
+		clog.Warningf("profile '%s': %d additional repositories succeeded, %d failed", r.profile.Name, len(names)-failed, failed)
+	} else {
+		clog.Infof("profile '%s': %d additional repositories succeeded", r.profile.Name, len(names))
+	}
+	return
+}
+
+// runInitializeRepository tries to initialize an additional repository
+func (r *resticWrapper) runInitializeRepository(name string) error {
+	clog.Infof("profile '%s': initializing repository '%s' (if not existing)", r.profile.Name, name)
+	args := r.profile.GetRepositoryInitFlags(name)
+	rCommand := r.prepareCommand(constants.CommandInit, args, false)
+	rCommand.capture = r.captureWriter()
+	// don't display any error
+	rCommand.stderr = nil
+	_, stderr, err := runShellCommand(rCommand)
+	if err != nil {
+		return newCommandError(rCommand, stderr, fmt.Errorf("repository '%s' initialization on profile '%s': %w", name, r.profile.Name, err))
+	}
+	return nil
+}
+
+// runRepositoryCopy copies the snapshots of the main repository to an additional repository
+func (r *resticWrapper) runRepositoryCopy(name string) error {
+	clog.Infof("profile '%s': copying snapshots to repository '%s'", r.profile.Name, name)
+	r.start(constants.CommandCopy)
+	args := r.profile.GetRepositoryCopyFlags(name)
+	for {
+		if err := r.checkInterrupted(); err != nil {
+			return err
+		}
+		rCommand := r.prepareCommand(constants.CommandCopy, args, false)
+		rCommand.capture = r.captureWriter()
+		summary, stderr, err := runShellCommand(rCommand)
+		r.executionTime += summary.Duration
+		r.setResticExitCode(err, summary.OutputAnalysis)
+		r.summary(constants.CommandCopy, summary, stderr, err)
+		if err != nil {
+			if r.canRetryAfterError(constants.CommandCopy, summary, err) {
+				continue
+			}
+			return newCommandError(rCommand, stderr, fmt.Errorf("copy to repository '%s' on profile '%s': %w", name, r.profile.Name, err))
+		}
+		return nil
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/monitor/status"
+	"github.com/creativeprojects/resticprofile/platform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunRepositories(t *testing.T) {
+	if platform.IsWindows() {
+		t.Skip("shell script")
+	}
+	dir := t.TempDir()
+	calls := filepath.Join(dir, "calls")
+	binary := filepath.Join(dir, "restic")
+	script := "#!/bin/sh\necho \"$*\" >> " + calls + "\ncase \"$*\" in *offsite*) exit 1;; esac\n"
+	require.NoError(t, os.WriteFile(binary, []byte(script), 0o700))
+	statusFile := filepath.Join(dir, "status.json")
+
+	newProfile := func() *config.Profile {
+		profile := config.NewProfile(nil, "name")
+		profile.Repository = config.NewConfidentialValue("main")
+		profile.StatusFile = statusFile
+		profile.Backup = &config.BackupSection{}
+		profile.Repositories = map[string]*config.RepositorySection{
+			"usb":     {Repository: config.NewConfidentialValue("usb"), Mode: config.RepositoryModeCopy},
+			"nas":     {Repository: config.NewConfidentialValue("nas"), PasswordFile: "nas.key"},
+			"offsite": {Repository: config.NewConfidentialValue("offsite")},
+		}
+		return profile
+	}
+
+	t.Run("backup and copy", func(t *testing.T) {
+		profile := newProfile()
+		wrapper := newResticWrapper(nil, binary, false, profile, "backup", nil, nil)
+		wrapper.addProgress(status.NewProgress(profile, status.NewStatus(statusFile)))
+		err := wrapper.runProfile()
+		assert.ErrorContains(t, err, "backup on profile 'name'")
+		assert.Empty(t, wrapper.repository)
+
+		content, err := os.ReadFile(calls)
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			"backup --repo=main",
+			"backup --password-file=nas.key --repo=nas",
+			"backup --repo=offsite",
+			"copy --repo=main --repo2=usb",
+		}, strings.Split(strings.TrimSpace(string(content)), "\n"))
+
+		state := status.NewStatus(statusFile).Load()
+		require.Contains(t, state.Profiles, "name")
+		assert.True(t, state.Profiles["name"].Backup.Success)
+		require.Contains(t, state.Profiles, "name@nas")
+		assert.True(t, state.Profiles["name@nas"].Backup.Success)
+		require.Contains(t, state.Profiles, "name@offsite")
+		assert.False(t, state.Profiles["name@offsite"].Backup.Success)
+	})
+
+	t.Run("no copy after failed backup", func(t *testing.T) {
+		require.NoError(t, os.Remove(calls))
+		profile := newProfile()
+		profile.Repository = config.NewConfidentialValue("offsite-main")
+		wrapper := newResticWrapper(nil, binary, false, profile, "backup", nil, nil)
+		assert.Error(t, wrapper.runProfile())
+
+		content, err := os.ReadFile(calls)
+		require.NoError(t, err)
+		assert.NotContains(t, string(content), "copy")
+	})
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/platform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunWithRole(t *testing.T) {
+	if platform.IsWindows() {
+		t.Skip("shell script")
+	}
+	dir := t.TempDir()
+	calls := filepath.Join(dir, "calls")
+	binary := filepath.Join(dir, "restic")
+	script := "#!/bin/sh\necho \"$* $AWS_ACCESS_KEY_ID\" >> " + calls + "\n"
+	require.NoError(t, os.WriteFile(binary, []byte(script), 0o700))
+
+	newProfile := func(role string) *config.Profile {
+		profile := config.NewProfile(nil, "name")
+		profile.Role = role
+		profile.Repository = config.NewConfidentialValue("s3:host/bucket")
+		profile.PasswordFile = "writer.key"
+		profile.Environment = map[string]config.ConfidentialValue{"aws_access_key_id": config.NewConfidentialValue("writer")}
+		profile.RunBefore = []string{"echo before >> " + calls}
+		profile.Backup = &config.BackupSection{}
+		profile.Retention = &config.RetentionSection{AfterBackup: true}
+		return profile
+	}
+	run := func(profile *config.Profile, command string) ([]string, error) {
+		_ = os.Remove(calls)
+		wrapper := newResticWrapper(nil, binary, false, profile, command, nil, nil)
+		err := wrapper.runProfile()
+		content, _ := os.ReadFile(calls)
+		return strings.Split(strings.TrimSpace(string(content)), "\n"), err
+	}
+
+	t.Run("writer", func(t *testing.T) {
+		profile := newProfile(config.RoleWriter)
+		lines, err := run(profile, "forget")
+		assert.EqualError(t, err, "profile 'name': command 'forget' is not allowed with the writer credentials of an append-only repository (add the maintainer credentials, or run it from a maintenance profile)")
+		assert.Equal(t, []string{""}, lines)
+
+		// the retention after the backup is refused as well
+		lines, err = run(profile, "backup")
+		assert.ErrorContains(t, err, "command 'forget' is not allowed with the writer credentials")
+		assert.Equal(t, []string{"before", "backup --password-file=writer.key --repo=s3:host/bucket writer"}, lines)
+	})
+
+	t.Run("writer with maintainer", func(t *testing.T) {
+		profile := newProfile(config.RoleWriter)
+		profile.Maintainer = &config.MaintainerSection{
+			PasswordFile: "maintainer.key",
+			Environment:  map[string]config.ConfidentialValue{"aws_access_key_id": config.NewConfidentialValue("maintainer")},
+		}
+		lines, err := run(profile, "backup")
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			"before",
+			"backup --password-file=writer.key --repo=s3:host/bucket writer",
+			"forget --password-file=maintainer.key --repo=s3:host/bucket maintainer",
+		}, lines)
+
+		lines, err = run(profile, "snapshots")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"before", "snapshots --password-file=writer.key --repo=s3:host/bucket writer"}, lines)
+	})
+
+	t.Run("maintenance", func(t *testing.T) {
+		profile := newProfile(config.RoleMaintenance)
+		lines, err := run(profile, "backup")
+		assert.EqualError(t, err, "profile 'name': command 'backup' is not allowed in a maintenance profile")
+		assert.Equal(t, []string{""}, lines)
+
+		lines, err = run(profile, "prune")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"before", "prune --password-file=writer.key --repo=s3:host/bucket writer"}, lines)
+	})
+}
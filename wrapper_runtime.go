@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/constants"
+	"github.com/creativeprojects/resticprofile/shell"
+	"github.com/creativeprojects/resticprofile/term"
+	"golang.org/x/exp/slices"
+)
+
+// getRunTimeData returns the variables of the run-time templates: they're the same for all the commands of the run
+func (r *resticWrapper) getRunTimeData() config.RunTimeData {
+	if r.runTimeData == nil {
+		data := config.NewRunTimeData(r.profile.Name, r.command, r.scheduleName)
+		r.runTimeData = &data
+	}
+	return *r.runTimeData
+}
+
+// resolveRunTimeValue replaces the run-time templates of the value ({{run .Date}}, etc.).
+// The value is left unchanged when a template cannot be resolved.
+func (r *resticWrapper) resolveRunTimeValue(value string) string {
+	if !config.HasRunTimeTemplate(value) {
+		return value
+	}
+	resolved, err := config.ResolveRunTime(value, r.getRunTimeData())
+	if err != nil {
+		clog.Warningf("profile '%s': %s", r.profile.Name, err)
+		return value
+	}
+	return resolved
+}
+
+// resolveRunTimeValues returns a copy of the values with the run-time templates replaced
+func (r *resticWrapper) resolveRunTimeValues(values []string) []string {
+	if len(values) == 0 {
+		return values
+	}
+	resolved := make([]string, len(values))
+	for i, value := range values {
+		resolved[i] = r.resolveRunTimeValue(value)
+	}
+	return resolved
+}
+
+// resolveRunTimeArgs replaces the run-time templates in the flags and arguments of a restic command
+func (r *resticWrapper) resolveRunTimeArgs(args *shell.Args) {
+	args.Walk(func(_ string, arg *shell.Arg) *shell.Arg {
+		if value := arg.Value(); config.HasRunTimeTemplate(value) {
+			resolved := shell.NewArg(r.resolveRunTimeValue(value), arg.Type())
+			return &resolved
+		}
+		return arg
+	})
+}
+
+// getCommandTags runs the "tag-command" of the backup once per run and returns the lines of their output.
+// The tags are also available in the run-time variable {{run .Tags}}.
+func (r *resticWrapper) getCommandTags() ([]string, error) {
+	if r.commandTags != nil || r.profile.Backup == nil || len(r.profile.Backup.TagCommand) == 0 {
+		return r.commandTags, nil
+	}
+	env := append(os.Environ(), r.getEnvironment()...)
+	env = append(env, r.getProfileEnvironment()...)
+
+	tags := make([]string, 0, len(r.profile.Backup.TagCommand))
+	for i, entry := range r.profile.Backup.TagCommand {
+		clog.Debugf("starting 'tag-command' command %d/%d", i+1, len(r.profile.Backup.TagCommand))
+		hook, err := r.prepareShellCommand(entry)
+		if err != nil {
+			return nil, fmt.Errorf("tag-command: %w", err)
+		}
+		output := &bytes.Buffer{}
+		rCommand := newShellCommand(hook.commandLine, nil, append(slices.Clip(env), hook.env...), r.getShell(), r.dryRun, r.sigChan, r.setPID)
+		rCommand.dir = hook.dir
+		rCommand.stdout = output
+		rCommand.stderr = term.GetErrorOutput()
+		_, stderr, err := runShellCommand(rCommand)
+		hook.cleanup()
+		if err != nil {
+			return nil, newCommandError(rCommand, stderr, fmt.Errorf("tag-command: %w", err))
+		}
+		tags = append(tags, parseCommandTags(output.String())...)
+	}
+	if len(tags) > 0 {
+		clog.Infof("profile '%s': tags from tag-command: %s", r.profile.Name, strings.Join(tags, ", "))
+	}
+
+	r.commandTags = tags
+	data := r.getRunTimeData()
+	data.Tags = strings.Join(tags, ",")
+	r.runTimeData = &data
+	return tags, nil
+}
+
+// addCommandTags adds the tags from the "tag-command" of the backup to the flags of restic
+func (r *resticWrapper) addCommandTags(args *shell.Args) error {
+	tags, err := r.getCommandTags()
+	if err != nil || len(tags) == 0 {
+		return err
+	}
+	existing, _ := args.Get(constants.ParameterTag)
+	values := make([]string, 0, len(existing)+len(tags))
+	for _, arg := range existing {
+		values = append(values, arg.Value())
+	}
+	args.AddFlags(constants.ParameterTag, append(values, tags...), shell.ArgConfigEscape)
+	return nil
+}
+
+// parseCommandTags returns the non-empty lines of the output of a tag command
+func parseCommandTags(output string) (tags []string) {
+	for _, line := range strings.Split(output, "\n") {
+		if tag := strings.TrimSpace(line); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/shell"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveRunTimeArgs(t *testing.T) {
+	profile := config.NewProfile(nil, "name")
+	profile.Backup = &config.BackupSection{Source: []string{"/data/{{run .CommandName}}", "/home"}}
+	wrapper := newResticWrapper(nil, "restic", false, profile, "backup", []string{"--tag={{run .ScheduleName}}"}, nil)
+	wrapper.setScheduleName("name/backup")
+
+	args := shell.NewArgs()
+	args.AddFlags("tag", []string{"{{run .ProfileName}}", "{{run .Date}}", "fixed"}, shell.ArgConfigEscape)
+	args.AddFlag("host", "{{run .Unknown}}", shell.ArgConfigEscape)
+
+	command := wrapper.prepareCommand("backup", args, true)
+	today := time.Now().Format("2006-01-02")
+	assert.Contains(t, command.args, "--tag=name")
+	assert.Contains(t, command.args, "--tag="+today)
+	assert.Contains(t, command.args, "--tag=fixed")
+	assert.Contains(t, command.args, "--tag=name/backup")
+	assert.Contains(t, command.args, "/data/backup")
+	assert.Contains(t, command.args, "/home")
+	// unresolved template is left as it is
+	assert.Contains(t, strings.Join(command.args, " "), "--host={{run")
+
+	// the original arguments are not modified
+	tags, found := args.Get("tag")
+	require.True(t, found)
+	assert.Equal(t, "{{run .ProfileName}}", tags[0].Value())
+
+	// all the commands of the run use the same time
+	first := wrapper.getRunTimeData()
+	assert.Equal(t, first.Now, wrapper.getRunTimeData().Now)
+}
+
+func TestParseCommandTags(t *testing.T) {
+	assert.Empty(t, parseCommandTags(""))
+	assert.Equal(t, []string{"a1b2c3d"}, parseCommandTags("a1b2c3d\n"))
+	assert.Equal(t, []string{"v1.2.3", "main"}, parseCommandTags("  v1.2.3\r\n\nmain \n"))
+}
+
+func TestAddCommandTags(t *testing.T) {
+	profile := config.NewProfile(nil, "name")
+	profile.Backup = &config.BackupSection{TagCommand: []string{"echo a1b2c3d", "echo v1.2.3"}}
+	wrapper := newResticWrapper(nil, "restic", false, profile, "backup", nil, nil)
+
+	args := shell.NewArgs()
+	args.AddFlag("tag", "fixed", shell.ArgConfigEscape)
+	require.NoError(t, wrapper.addCommandTags(args))
+
+	tags, found := args.Get("tag")
+	require.True(t, found)
+	values := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		values = append(values, tag.Value())
+	}
+	assert.Equal(t, []string{"fixed", "a1b2c3d", "v1.2.3"}, values)
+	assert.Equal(t, "a1b2c3d,v1.2.3", wrapper.getRunTimeData().Tags)
+
+	// the commands run only once per run
+	wrapper.profile.Backup.TagCommand = []string{"exit 1"}
+	tagsAgain, err := wrapper.getCommandTags()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a1b2c3d", "v1.2.3"}, tagsAgain)
+}
+
+func TestTagCommandFailure(t *testing.T) {
+	profile := config.NewProfile(nil, "name")
+	profile.Backup = &config.BackupSection{TagCommand: []string{"exit 2"}}
+	wrapper := newResticWrapper(nil, "restic", false, profile, "backup", nil, nil)
+
+	err := wrapper.addCommandTags(shell.NewArgs())
+	assert.ErrorContains(t, err, "tag-command")
+}
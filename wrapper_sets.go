@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/constants"
+	"github.com/creativeprojects/resticprofile/monitor"
+	"github.com/creativeprojects/resticprofile/shell"
+	"github.com/creativeprojects/resticprofile/term"
+	"github.com/creativeprojects/resticprofile/util"
+)
+
+// backupSetResult is the outcome of the backup of one set
+type backupSetResult struct {
+	set     string
+	summary monitor.Summary
+	err     error
+	skipped bool
+}
+
+// getBackupSource returns the sources of the backup set currently running, or the sources of the profile
+func (r *resticWrapper) getBackupSource() []string {
+	if r.backupSet != "" {
+		return r.profile.GetBackupSetSource(r.backupSet)
+	}
+	return r.profile.GetBackupSource()
+}
+
+// getBackupFlags returns the flags of the backup set currently running, or the flags of the backup of the profile
+func (r *resticWrapper) getBackupFlags() *shell.Args {
+	if r.backupSet != "" {
+		return r.profile.GetBackupSetFlags(r.backupSet)
+	}
+	return r.profile.GetCommandFlags(constants.CommandBackup)
+}
+
+// runBackupSets runs one backup per set. A failed set doesn't stop the next ones: the first error is returned at the end.
+func (r *resticWrapper) runBackupSets(sets []string, backupAction func() error) (err error) {
+	defer func() { r.backupSet = "" }()
+
+	results := make([]backupSetResult, 0, len(sets))
+	for _, set := range sets {
+		if r.interrupted.Load() {
+			results = append(results, backupSetResult{set: set, skipped: true})
+			continue
+		}
+		clog.Infof("profile '%s': backup set '%s'", r.profile.Name, set)
+		r.backupSet = set
+		r.backupSummary = monitor.Summary{}
+		r.checkSourceAccess()
+		setErr := backupAction()
+		results = append(results, backupSetResult{set: set, summary: r.backupSummary, err: setErr})
+		if setErr != nil && err == nil {
+			err = setErr
+		}
+	}
+
+	failed := 0
+	for _, result := range results {
+		if result.err != nil || result.skipped {
+			failed++
+		}
+	}
+	if failed > 0 {
+		clog.Warningf("profile '%s': %d backup set(s) succeeded, %d failed", r.profile.Name, len(results)-failed, failed)
+	} else {
+		clog.Infof("profile '%s': %d backup set(s) succeeded", r.profile.Name, len(results))
+	}
+	if displayErr := displayBackupSets(term.GetOutput(), r.profile.Name, results); displayErr != nil {
+		clog.Errorf("cannot display the summary of the backup sets: %s", displayErr)
+	}
+	return
+}
+
+// displayBackupSets writes the table of the statistics of each set
+func displayBackupSets(output io.Writer, profileName string, results []backupSetResult) error {
+	_, _ = fmt.Fprintf(output, "\nBackup sets of profile '%s':\n\n", profileName)
+	w := tabwriter.NewWriter(output, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "  Set\tFiles new\tFiles changed\tAdded\tDuration\tResult")
+	for _, result := range results {
+		if result.skipped {
+			_, _ = fmt.Fprintf(w, "  %s\t\t\t\t\t%s\n", result.set, groupStatusSkipped)
+			continue
+		}
+		status := groupStatusSuccess
+		if result.err != nil {
+			status = groupStatusFailed
+		}
+		summary := result.summary
+		_, _ = fmt.Fprintf(w, "  %s\t%d\t%d\t%s\t%s\t%s\n",
+			result.set,
+			summary.FilesNew,
+			summary.FilesChanged,
+			util.FormatBytes(summary.BytesAdded),
+			util.FormatDuration(summary.Duration.Round(time.Second)),
+			status,
+		)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(output, "")
+	return err
+}
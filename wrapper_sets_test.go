@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/monitor"
+	"github.com/creativeprojects/resticprofile/term"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunBackupSets(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	term.SetOutput(buffer)
+	defer term.SetOutput(os.Stdout)
+
+	profile := config.NewProfile(nil, "name")
+	profile.Backup = &config.BackupSection{
+		Sets: map[string]*config.BackupSetSection{
+			"photos":    {Source: []string{"photos"}, Tag: []string{"photos"}},
+			"documents": {Source: []string{"documents"}, Tag: []string{"documents"}},
+		},
+		SourceAccessCheck: config.SourceAccessCheckOff,
+	}
+
+	wrapper := newResticWrapper(nil, "echo", false, profile, "backup", nil, nil)
+	require.NoError(t, wrapper.runProfile())
+
+	output := strings.ReplaceAll(buffer.String(), "\r\n", "\n")
+	lines := strings.Split(output, "\n")
+	require.GreaterOrEqual(t, len(lines), 2)
+	assert.Equal(t, "backup --tag=documents documents", lines[0])
+	assert.Equal(t, "backup --tag=photos photos", lines[1])
+	assert.Contains(t, output, "Backup sets of profile 'name':")
+	assert.Empty(t, wrapper.backupSet)
+}
+
+func TestDisplayBackupSets(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	results := []backupSetResult{
+		{set: "documents", summary: monitor.Summary{FilesNew: 10, FilesChanged: 2, BytesAdded: 2048}},
+		{set: "photos", err: errors.New("failed")},
+		{set: "videos", skipped: true},
+	}
+	require.NoError(t, displayBackupSets(buffer, "name", results))
+	output := buffer.String()
+	assert.Contains(t, output, "Backup sets of profile 'name':")
+	assert.Regexp(t, `documents\s+10\s+2\s+2\.0 KiB\s+\S+\s+success`, output)
+	assert.Regexp(t, `photos\s+0\s+0\s+0 B\s+\S+\s+failed`, output)
+	assert.Regexp(t, `videos\s+skipped`, output)
+}
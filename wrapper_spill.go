@@ -0,0 +1,145 @@
+package main
+
+import (
+	"os"
+	"strings"
+
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/constants"
+	"github.com/creativeprojects/resticprofile/platform"
+	"github.com/creativeprojects/resticprofile/restic"
+	"github.com/creativeprojects/resticprofile/shell"
+	"github.com/creativeprojects/resticprofile/util"
+)
+
+// maxCommandLineLength is the length of the command line above which long lists of the backup command are written to files.
+// On unix, the command line is sent to the shell as a single argument (limited to 128 KiB by Linux).
+// On Windows, a command line cannot be longer than 32767 characters.
+var maxCommandLineLength = func() int {
+	if platform.IsWindows() {
+		return 32 * 1000
+	}
+	return 120 * 1024
+}()
+
+// spilledFlags are the list flags of the backup command that can be replaced by a file, and the flag reading the file
+var spilledFlags = []struct{ flag, fileFlag string }{
+	{flag: "exclude", fileFlag: "exclude-file"},
+	{flag: "iexclude", fileFlag: "iexclude-file"},
+}
+
+const filesFromVerbatim = "files-from-verbatim"
+
+// spillLongArguments writes the exclude patterns and the backup source to temporary files when the command line would be
+// too long to start restic. They're replaced by the --exclude-file, --iexclude-file and --files-from-verbatim flags.
+// It returns the backup source left on the command line, and a function removing the temporary files.
+func (r *resticWrapper) spillLongArguments(args *shell.Args, source []string) ([]string, func()) {
+	var files []string
+	cleanup := func() {
+		for _, file := range files {
+			if err := os.Remove(file); err != nil {
+				clog.Debugf("cannot remove temporary file: %s", err)
+			}
+		}
+	}
+
+	probe := args.Clone()
+	probe.AddArgs(source, shell.ArgConfigBackupSource)
+	length := commandLineLength(r.resticBinary, constants.CommandBackup, probe.GetAll())
+	if length <= maxCommandLineLength {
+		return source, cleanup
+	}
+	clog.Debugf("command line of %d characters is too long: writing the long lists to temporary files", length)
+
+	spill := func(name string, values []string) (filename string, ok bool) {
+		filename, err := writeListFile(name, values)
+		if err != nil {
+			clog.Warningf("cannot write the %s list to a temporary file: %s", name, err)
+			return "", false
+		}
+		files = append(files, filename)
+		return filename, true
+	}
+
+	for _, spilled := range spilledFlags {
+		values, found := args.Get(spilled.flag)
+		if !found || len(values) == 0 {
+			continue
+		}
+		list := make([]string, 0, len(values))
+		kept := make([]shell.Arg, 0)
+		for _, value := range values {
+			if isVerbatimPattern(value.Value()) {
+				list = append(list, value.Value())
+			} else {
+				kept = append(kept, value)
+			}
+		}
+		if len(list) == 0 {
+			continue
+		}
+		if filename, ok := spill(spilled.flag, list); ok {
+			args.SetArgs(spilled.flag, kept)
+			addFlagValue(args, spilled.fileFlag, filename)
+		}
+	}
+
+	if len(source) > 0 {
+		if err := restic.CheckOption(constants.CommandBackup, filesFromVerbatim, r.getResticVersion()); err != nil {
+			clog.Debugf("backup source stays on the command line: %s", err)
+		} else if filename, ok := spill("source", source); ok {
+			addFlagValue(args, filesFromVerbatim, filename)
+			source = nil
+		}
+	}
+	return source, cleanup
+}
+
+// isVerbatimPattern returns true when restic reads the pattern unchanged from an exclude file. Restic expands the
+// environment variables of the file, skips the lines starting with "#" and trims the spaces around each line, without
+// any way to escape them: such patterns stay on the command line.
+func isVerbatimPattern(pattern string) bool {
+	return pattern != "" &&
+		pattern == strings.TrimSpace(pattern) &&
+		!strings.HasPrefix(pattern, "#") &&
+		!strings.ContainsAny(pattern, "$\r\n")
+}
+
+// addFlagValue adds a value to the flag, keeping the values already set
+func addFlagValue(args *shell.Args, flag, value string) {
+	var values []string
+	if existing, found := args.Get(flag); found {
+		for _, arg := range existing {
+			values = append(values, arg.Value())
+		}
+	}
+	args.AddFlags(flag, append(values, value), shell.ArgConfigEscape)
+}
+
+// commandLineLength returns the length of the command line sent to the shell
+func commandLineLength(binary, command string, arguments []string) int {
+	return len(binary) + len(command) + len(strings.Join(arguments, " ")) + 2
+}
+
+// writeListFile writes one value per line in a new file of the temporary directory, only accessible by the current user
+func writeListFile(name string, values []string) (filename string, err error) {
+	return writeSeparatedListFile(name, values, "\n")
+}
+
+// writeSeparatedListFile writes the values, each one followed by the separator, in a new file of the temporary directory
+func writeSeparatedListFile(name string, values []string, separator string) (filename string, err error) {
+	file, err := util.CreateTempFile(name + "-*.txt")
+	if err != nil {
+		return
+	}
+	filename = file.Name()
+	_, err = file.WriteString(strings.Join(values, separator) + separator)
+	if e := file.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		_ = os.Remove(filename)
+		filename = ""
+	}
+	return
+}
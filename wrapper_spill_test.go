@@ -0,0 +1,94 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/shell"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpillLongArguments(t *testing.T) {
+	defer func(length int) { maxCommandLineLength = length }(maxCommandLineLength)
+	maxCommandLineLength = 160
+
+	profile := config.NewProfile(nil, "name")
+	wrapper := newResticWrapper(nil, "restic", false, profile, "backup", nil, nil)
+
+	newArgs := func() *shell.Args {
+		args := shell.NewArgs()
+		args.AddFlags("exclude", []string{"*.tmp", "$HOME/x", "/home/*/cache", "#tmp"}, shell.ArgConfigKeepGlobQuote)
+		args.AddFlag("exclude-file", "/etc/excludes", shell.ArgConfigEscape)
+		args.AddFlag("tag", "daily", shell.ArgConfigEscape)
+		return args
+	}
+	source := []string{"/home/user/documents", "/home/user/pictures", "/home/user/music with spaces"}
+
+	t.Run("short command line", func(t *testing.T) {
+		args := newArgs()
+		remaining, cleanup := wrapper.spillLongArguments(args, source[:1])
+		defer cleanup()
+		assert.Equal(t, source[:1], remaining)
+		assert.Equal(t, newArgs().GetAll(), args.GetAll())
+	})
+
+	t.Run("long command line", func(t *testing.T) {
+		args := newArgs()
+		remaining, cleanup := wrapper.spillLongArguments(args, source)
+		assert.Empty(t, remaining)
+
+		flags := args.ToMap()
+		// restic would expand the variable and skip the comment in a file
+		assert.Equal(t, []string{"$HOME/x", "#tmp"}, flags["exclude"])
+		assert.Equal(t, []string{"daily"}, flags["tag"])
+		require.Len(t, flags["exclude-file"], 2)
+		assert.Equal(t, "/etc/excludes", flags["exclude-file"][0])
+		require.Len(t, flags["files-from-verbatim"], 1)
+
+		excludes, err := os.ReadFile(flags["exclude-file"][1])
+		require.NoError(t, err)
+		assert.Equal(t, "*.tmp\n/home/*/cache\n", string(excludes))
+
+		sources, err := os.ReadFile(flags["files-from-verbatim"][0])
+		require.NoError(t, err)
+		assert.Equal(t, "/home/user/documents\n/home/user/pictures\n/home/user/music with spaces\n", string(sources))
+
+		cleanup()
+		assert.NoFileExists(t, flags["exclude-file"][1])
+		assert.NoFileExists(t, flags["files-from-verbatim"][0])
+	})
+
+	t.Run("source stays with old restic", func(t *testing.T) {
+		wrapper := newResticWrapper(&config.Global{ResticVersion: "0.11"}, "restic", false, profile, "backup", nil, nil)
+		args := newArgs()
+		remaining, cleanup := wrapper.spillLongArguments(args, source)
+		defer cleanup()
+		assert.Equal(t, source, remaining)
+		assert.NotContains(t, args.ToMap(), "files-from-verbatim")
+		assert.Len(t, args.ToMap()["exclude-file"], 2)
+	})
+}
+
+func TestIsVerbatimPattern(t *testing.T) {
+	testData := []struct {
+		pattern  string
+		verbatim bool
+	}{
+		{"*.tmp", true},
+		{"/home/*/cache", true},
+		{"file#1", true},
+		{"$HOME/x", false},
+		{"/path/${USER}", false},
+		{"#tmp", false},
+		{" #tmp", false},
+		{"trailing ", false},
+		{"", false},
+	}
+	for _, testItem := range testData {
+		t.Run(testItem.pattern, func(t *testing.T) {
+			assert.Equal(t, testItem.verbatim, isVerbatimPattern(testItem.pattern))
+		})
+	}
+}
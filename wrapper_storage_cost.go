@@ -0,0 +1,65 @@
+package main
+
+import (
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/monitor"
+	"github.com/creativeprojects/resticprofile/util"
+)
+
+const (
+	// bytesPerGB is the unit of the price of the storage
+	bytesPerGB = 1 << 30
+	// defaultPackSize is the size of the pack files written by restic when "pack-size" is not set (in MiB)
+	defaultPackSize = 16
+	// metadataWriteOperations is the number of files written by a backup in addition to the pack files (index and snapshot)
+	metadataWriteOperations = 2
+)
+
+// getStorageSummary estimates the monthly cost of the repository and the cost of the write operations of the backup.
+// It returns nil when no price is configured for the repository. Errors are not fatal to the backup: they're logged.
+func (r *resticWrapper) getStorageSummary(backup monitor.Summary) *monitor.StorageSummary {
+	cost := r.profile.GetStorageCost(r.global)
+	if cost == nil || r.dryRun {
+		return nil
+	}
+	size, err := r.getRepositorySize()
+	if err != nil {
+		clog.Warningf("profile '%s': cannot estimate the cost of the repository: %s", r.profile.Name, err)
+		return nil
+	}
+	r.repositorySize = &size
+
+	storage := newStorageSummary(cost, config.GetRepositoryBackend(r.profile.Repository.Value()), size, backup.BytesAdded, r.profile.PackSize)
+	clog.Infof("profile '%s': the repository uses %s on %s: estimated cost %.2f %s per month, %d write operation(s) for this backup (%.4f %s)",
+		r.profile.Name, util.FormatBytes(size), storage.Backend, storage.MonthlyCost, storage.Currency,
+		storage.WriteOperations, storage.OperationsCost, storage.Currency)
+	return storage
+}
+
+// newStorageSummary calculates the estimated costs of a repository of this size, after a backup adding bytesAdded
+func newStorageSummary(cost *config.StorageCostSection, backend string, size, bytesAdded uint64, packSize int) *monitor.StorageSummary {
+	operations := estimateWriteOperations(bytesAdded, packSize)
+	return &monitor.StorageSummary{
+		Backend:         backend,
+		RepositorySize:  size,
+		MonthlyCost:     float64(size) / bytesPerGB * cost.PricePerGB,
+		WriteOperations: operations,
+		OperationsCost:  float64(operations) / 1000 * cost.PricePerOperations,
+		Currency:        cost.GetCurrency(),
+	}
+}
+
+// estimateWriteOperations returns the number of files uploaded by a backup adding bytesAdded to the repository:
+// the pack files (of packSize MiB) and the metadata files. Compression is not taken into account.
+func estimateWriteOperations(bytesAdded uint64, packSize int) uint64 {
+	if packSize <= 0 {
+		packSize = defaultPackSize
+	}
+	packBytes := uint64(packSize) << 20
+	packs := (bytesAdded + packBytes - 1) / packBytes
+	if packs == 0 {
+		return 1 // the snapshot only
+	}
+	return packs + metadataWriteOperations
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/monitor"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEstimateWriteOperations(t *testing.T) {
+	const mib = 1 << 20
+	assert.Equal(t, uint64(1), estimateWriteOperations(0, 0))
+	assert.Equal(t, uint64(3), estimateWriteOperations(1, 0))
+	assert.Equal(t, uint64(3), estimateWriteOperations(16*mib, 0))
+	assert.Equal(t, uint64(4), estimateWriteOperations(16*mib+1, 0))
+	assert.Equal(t, uint64(12), estimateWriteOperations(40*mib, 4))
+}
+
+func TestNewStorageSummary(t *testing.T) {
+	cost := &config.StorageCostSection{PricePerGB: 0.005, PricePerOperations: 0.004, Currency: "EUR"}
+	storage := newStorageSummary(cost, "b2", 200<<30, 32<<20, 0)
+	assert.Equal(t, &monitor.StorageSummary{
+		Backend:         "b2",
+		RepositorySize:  200 << 30,
+		MonthlyCost:     1,
+		WriteOperations: 4,
+		OperationsCost:  0.000016,
+		Currency:        "EUR",
+	}, storage)
+}
+
+func TestStorageSummaryWithoutPrice(t *testing.T) {
+	profile := config.NewProfile(nil, "name")
+	wrapper := newResticWrapper(nil, "restic", false, profile, "backup", nil, nil)
+	assert.Nil(t, wrapper.getStorageSummary(monitor.Summary{BytesAdded: 100}))
+}
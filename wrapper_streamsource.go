@@ -75,6 +75,7 @@ func (r *resticWrapper) prepareCommandStreamSource() (io.ReadCloser, error) {
 			clog.Debugf("starting 'stdin-command' command %d/%d: %s", i+1, len(r.profile.Backup.StdinCommand), sourceCommand)
 			rCommand := newShellCommand(sourceCommand, nil, env, r.getShell(), r.dryRun, commandSignals, nil)
 			rCommand.stdout = bufferedWriter
+			rCommand.rawStdout = true
 			rCommand.stderr = term.GetErrorOutput()
 
 			_, stderr, err := runShellCommand(rCommand)
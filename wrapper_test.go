@@ -19,6 +19,7 @@ import (
 	"github.com/creativeprojects/resticprofile/config"
 	"github.com/creativeprojects/resticprofile/constants"
 	"github.com/creativeprojects/resticprofile/monitor"
+	"github.com/creativeprojects/resticprofile/monitor/history"
 	"github.com/creativeprojects/resticprofile/monitor/status"
 	"github.com/creativeprojects/resticprofile/restic"
 	"github.com/creativeprojects/resticprofile/term"
@@ -623,6 +624,7 @@ func TestRunShellCommands(t *testing.T) {
 	profile.Forget = &config.SectionWithScheduleAndMonitoring{}
 	profile.Init = &config.InitSection{}
 	profile.Prune = &config.SectionWithScheduleAndMonitoring{}
+	profile.VerifyRestore = &config.VerifyRestoreSection{}
 	for name, _ := range profile.OtherSections {
 		profile.OtherSections[name] = new(config.GenericSection)
 	}
@@ -702,6 +704,16 @@ type mockOutputAnalysis struct {
 	monitor.OutputAnalysis
 	lockWho      string
 	lockDuration time.Duration
+	diskFull     bool
+	network      bool
+}
+
+func (m *mockOutputAnalysis) ContainsDiskFull() bool {
+	return m.diskFull
+}
+
+func (m *mockOutputAnalysis) ContainsNetworkFailure() bool {
+	return m.network
 }
 
 func (m *mockOutputAnalysis) ContainsRemoteLockFailure() bool {
@@ -941,6 +953,43 @@ func TestGetProfileEnvironment(t *testing.T) {
 	assert.ElementsMatch(t, []string{"PROFILE_NAME=TestProfile", "PROFILE_COMMAND=TestCommand"}, env)
 }
 
+func TestGetProfileEnvironmentWithConfigIssues(t *testing.T) {
+	profile := config.NewProfile(&config.Config{}, "TestProfile")
+	wrapper := newResticWrapper(nil, "", false, profile, "TestCommand", nil, nil)
+	wrapper.setConfigIssues([]string{"first issue", "second issue"}, nil)
+
+	env := wrapper.getProfileEnvironment()
+	assert.Contains(t, env, "CONFIG_ISSUES=first issue\nsecond issue")
+	assert.Equal(t, []string{"first issue", "second issue"}, wrapper.getContext().ConfigIssues)
+}
+
+func TestRunProfileWithConfigError(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	term.SetOutput(buffer)
+	profile := config.NewProfile(nil, "name")
+	profile.RunBefore = []string{"echo profile run-before"}
+	profile.RunAfterFail = []string{"echo failed: $ERROR_MESSAGE"}
+	profile.OtherSections = map[string]*config.GenericSection{
+		"command": {RunShellCommandsSection: config.RunShellCommandsSection{RunBefore: []string{"echo command run-before"}}},
+	}
+
+	wrapper := newResticWrapper(nil, mockBinary, false, profile, "command", []string{"--exit", "0"}, nil)
+	wrapper.setConfigIssues([]string{"deprecated"}, errors.New("config error"))
+	profile.HistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	records := history.NewHistory(profile.HistoryFile)
+	wrapper.addProgress(history.NewProgress(profile, records))
+
+	err := wrapper.runProfile()
+	assert.EqualError(t, err, "config error")
+	assert.Equal(t, "failed: config error", strings.TrimSpace(strings.ReplaceAll(buffer.String(), "\r\n", "\n")))
+
+	entries, err := records.List(history.Filter{})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.False(t, entries[0].Success)
+	assert.Equal(t, []string{"deprecated"}, entries[0].ConfigIssues)
+}
+
 func TestGetFailEnvironmentNoError(t *testing.T) {
 	profile := config.NewProfile(&config.Config{}, "")
 	wrapper := newResticWrapper(nil, "", false, profile, "", nil, nil)
@@ -956,7 +1005,7 @@ func TestGetFailEnvironmentWithStandardError(t *testing.T) {
 	require.NotNil(t, wrapper)
 
 	env := wrapper.getFailEnvironment(errors.New("test error message 3"))
-	assert.ElementsMatch(t, []string{"ERROR=test error message 3", "ERROR_MESSAGE=test error message 3"}, env)
+	assert.ElementsMatch(t, []string{"ERROR=test error message 3", "ERROR_MESSAGE=test error message 3", "ERROR_CODE=error"}, env)
 }
 
 func TestGetFailEnvironmentWithCommandError(t *testing.T) {
@@ -973,6 +1022,7 @@ func TestGetFailEnvironmentWithCommandError(t *testing.T) {
 	assert.ElementsMatch(t, []string{
 		"ERROR=test error message 4",
 		"ERROR_MESSAGE=test error message 4",
+		"ERROR_CODE=error",
 		"ERROR_COMMANDLINE=\"command\" \"publicArg1\"",
 		"ERROR_EXIT_CODE=-1",
 		"ERROR_STDERR=stderr",
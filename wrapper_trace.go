@@ -0,0 +1,28 @@
+package main
+
+import (
+	"github.com/creativeprojects/resticprofile/monitor/otel"
+)
+
+// setTracer sends a trace of the run to an OpenTelemetry collector
+func (r *resticWrapper) setTracer(tracer *otel.Tracer) {
+	r.tracer = tracer
+	r.addProgress(tracer)
+}
+
+// startTrace starts the trace of the run, and returns the function ending it
+func (r *resticWrapper) startTrace() (end func(err error)) {
+	if r.tracer == nil || r.dryRun {
+		return func(error) {}
+	}
+	r.tracer.StartRun(r.command)
+	return r.tracer.EndRun
+}
+
+// traceSpan starts the span of a step of the run (hook or monitoring request), and returns the function ending it
+func (r *resticWrapper) traceSpan(name string, attributes map[string]any) (end func(err error)) {
+	if r.tracer == nil || r.dryRun {
+		return func(error) {}
+	}
+	return r.tracer.StartSpan(name, attributes).End
+}
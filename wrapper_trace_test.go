@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/monitor/otel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTraceRun(t *testing.T) {
+	bodies := make(chan []byte, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		bodies <- body
+	}))
+	defer server.Close()
+
+	profile := config.NewProfile(nil, "name")
+	profile.OtelEndpoint = config.NewConfidentialValue(server.URL)
+	profile.Backup = &config.BackupSection{}
+	profile.Backup.RunBefore = []string{"echo before"}
+
+	wrapper := newResticWrapper(nil, mockBinary, false, profile, "backup", nil, nil)
+	wrapper.setTracer(otel.NewTracer(profile, "test"))
+	require.NoError(t, wrapper.runProfile())
+
+	trace := struct {
+		ResourceSpans []struct {
+			ScopeSpans []struct {
+				Spans []struct {
+					Name string `json:"name"`
+				} `json:"spans"`
+			} `json:"scopeSpans"`
+		} `json:"resourceSpans"`
+	}{}
+	require.NoError(t, json.Unmarshal(<-bodies, &trace))
+	names := []string{}
+	for _, span := range trace.ResourceSpans[0].ScopeSpans[0].Spans {
+		names = append(names, span.Name)
+	}
+	assert.Equal(t, []string{"name.backup", "run-before backup", "restic backup"}, names)
+}
+
+func TestNoTraceInDryRun(t *testing.T) {
+	profile := config.NewProfile(nil, "name")
+	profile.OtelEndpoint = config.NewConfidentialValue("http://localhost:1")
+	wrapper := newResticWrapper(nil, mockBinary, true, profile, "backup", nil, nil)
+	wrapper.setTracer(otel.NewTracer(profile, "test"))
+	wrapper.startTrace()(nil)
+	wrapper.traceSpan("span", nil)(nil)
+}
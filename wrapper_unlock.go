@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/constants"
+	"github.com/creativeprojects/resticprofile/shell"
+	"github.com/creativeprojects/resticprofile/util/collect"
+	"golang.org/x/exp/slices"
+)
+
+// unlockForceFlag is a resticprofile flag (not sent to restic) allowing to remove the locks of other hosts
+const unlockForceFlag = "--force"
+
+// repositoryLock is the JSON content of a lock of the repository ("restic cat lock")
+type repositoryLock struct {
+	ID        string    `json:"-"`
+	Time      time.Time `json:"time"`
+	Exclusive bool      `json:"exclusive"`
+	Hostname  string    `json:"hostname"`
+	Username  string    `json:"username"`
+	PID       int       `json:"pid"`
+}
+
+func (l repositoryLock) String() string {
+	kind := "non-exclusive"
+	if l.Exclusive {
+		kind = "exclusive"
+	}
+	id := l.ID
+	if len(id) > 8 {
+		id = id[:8]
+	}
+	return fmt.Sprintf("%s lock %s of PID %d on %s by %s, created %s ago", kind, id, l.PID, l.Hostname, l.Username, time.Since(l.Time).Round(time.Second))
+}
+
+// getUnlockAction returns the action checking the locks of the repository before running "restic unlock"
+func (r *resticWrapper) getUnlockAction() func() error {
+	unlockAction := r.getCommandAction(constants.CommandUnlock)
+
+	return func() error {
+		force := slices.Contains(r.moreArgs, unlockForceFlag)
+		// remove our own flag from the arguments sent to restic
+		r.moreArgs = collect.All(r.moreArgs, collect.Not(collect.In(unlockForceFlag)))
+
+		if err := r.checkUnlock(force, r.unlockMinAge()); err != nil {
+			return err
+		}
+		return unlockAction()
+	}
+}
+
+// unlockMinAge returns the age a lock of another host must have to be removed
+func (r *resticWrapper) unlockMinAge() time.Duration {
+	minAge := constants.DefaultResticStaleLockAge
+	if r.global != nil {
+		minAge = r.global.ResticStaleLockAge
+	}
+	if minAge < constants.MinResticStaleLockAge {
+		minAge = constants.MinResticStaleLockAge
+	}
+	return minAge
+}
+
+// checkUnlock lists the locks of the repository and returns an error when one of them must not be removed:
+// locks of a running process of this host are never removed, and locks of other hosts need the force flag
+// and an age of at least minAge.
+func (r *resticWrapper) checkUnlock(force bool, minAge time.Duration) error {
+	if r.dryRun {
+		return nil
+	}
+	locks, err := r.listRepositoryLocks()
+	if err != nil {
+		return fmt.Errorf("profile '%s': cannot verify the locks before unlocking: %w", r.profile.Name, err)
+	}
+	if len(locks) == 0 {
+		clog.Infof("profile '%s': no lock found in the repository", r.profile.Name)
+		return nil
+	}
+	return checkRepositoryLocks(r.profile.Name, locks, force, minAge)
+}
+
+// checkRepositoryLocks returns an error when one of the locks must not be removed
+func checkRepositoryLocks(profileName string, locks []repositoryLock, force bool, minAge time.Duration) error {
+	hostname, _ := os.Hostname()
+	for _, lock := range locks {
+		clog.Infof("profile '%s': found %s", profileName, lock)
+		if hostname != "" && strings.EqualFold(lock.Hostname, hostname) {
+			if processExists(lock.PID) {
+				return fmt.Errorf("profile '%s': refusing to unlock the repository, the %s is held by a running process on this host", profileName, lock)
+			}
+			continue
+		}
+		if !force {
+			return fmt.Errorf("profile '%s': refusing to unlock the repository, the %s cannot be verified from this host: use \"%s --name %s unlock %s\" to remove the locks older than %s",
+				profileName, lock, constants.ApplicationName, profileName, unlockForceFlag, minAge)
+		}
+		if age := time.Since(lock.Time); minAge > 0 && age < minAge {
+			return fmt.Errorf("profile '%s': refusing to unlock the repository, the %s is more recent than %s (restic-stale-lock-age)", profileName, lock, minAge)
+		}
+	}
+	return nil
+}
+
+// listRepositoryLocks returns the locks found in the repository
+func (r *resticWrapper) listRepositoryLocks() ([]repositoryLock, error) {
+	// the extra flags of the command line are for restic unlock
+	moreArgs := r.moreArgs
+	r.moreArgs = nil
+	defer func() { r.moreArgs = moreArgs }()
+
+	args := r.profile.GetCommonFlags()
+	args.AddFlag(constants.ParameterNoLock, "", shell.ArgConfigEscape)
+	args.AddArg("locks", shell.ArgConfigEscape)
+	output := &bytes.Buffer{}
+	rCommand := r.prepareCommand(constants.CommandList, args, false)
+	rCommand.stdout = output
+	if _, _, err := runShellCommand(rCommand); err != nil {
+		return nil, err
+	}
+
+	locks := make([]repositoryLock, 0)
+	scanner := bufio.NewScanner(output)
+	for scanner.Scan() {
+		id := strings.TrimSpace(scanner.Text())
+		if id == "" {
+			continue
+		}
+		lock, err := r.readRepositoryLock(id)
+		if err != nil {
+			return nil, err
+		}
+		locks = append(locks, lock)
+	}
+	return locks, nil
+}
+
+// readRepositoryLock returns the content of the lock
+func (r *resticWrapper) readRepositoryLock(id string) (repositoryLock, error) {
+	args := r.profile.GetCommonFlags()
+	args.AddFlag(constants.ParameterNoLock, "", shell.ArgConfigEscape)
+	args.AddArgs([]string{"lock", id}, shell.ArgConfigEscape)
+	output := &bytes.Buffer{}
+	rCommand := r.prepareCommand(constants.CommandCat, args, false)
+	rCommand.stdout = output
+	lock := repositoryLock{}
+	if _, _, err := runShellCommand(rCommand); err != nil {
+		return lock, err
+	}
+	if err := json.Unmarshal(bytes.TrimSpace(output.Bytes()), &lock); err != nil {
+		return lock, fmt.Errorf("cannot read lock %s: %w", id, err)
+	}
+	if lock.Time.IsZero() {
+		return lock, errors.New("cannot read lock " + id)
+	}
+	lock.ID = id
+	return lock, nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckRepositoryLocks(t *testing.T) {
+	hostname, err := os.Hostname()
+	require.NoError(t, err)
+	old := time.Now().Add(-3 * time.Hour)
+	recent := time.Now().Add(-10 * time.Minute)
+
+	fixtures := []struct {
+		name    string
+		lock    repositoryLock
+		force   bool
+		refused string
+	}{
+		{"running process of this host", repositoryLock{Hostname: hostname, PID: os.Getpid(), Time: old}, true, "held by a running process on this host"},
+		{"dead process of this host", repositoryLock{Hostname: hostname, PID: -1, Time: recent}, false, ""},
+		{"other host without force", repositoryLock{Hostname: "other-host", PID: 10, Time: old}, false, "unlock --force"},
+		{"recent lock of other host", repositoryLock{Hostname: "other-host", PID: 10, Time: recent}, true, "more recent than 2h0m0s"},
+		{"old lock of other host", repositoryLock{Hostname: "other-host", PID: 10, Time: old}, true, ""},
+	}
+	for _, fixture := range fixtures {
+		t.Run(fixture.name, func(t *testing.T) {
+			err := checkRepositoryLocks("name", []repositoryLock{fixture.lock}, fixture.force, 2*time.Hour)
+			if fixture.refused == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, fixture.refused)
+			}
+		})
+	}
+
+	// no age is required for the automatic unlock of stale locks
+	assert.NoError(t, checkRepositoryLocks("name", []repositoryLock{{Hostname: "other-host", Time: recent}}, true, 0))
+}
+
+func TestUnlockMinAge(t *testing.T) {
+	profile := config.NewProfile(nil, "name")
+	wrapper := newResticWrapper(nil, mockBinary, false, profile, constants.CommandUnlock, nil, nil)
+	assert.Equal(t, constants.DefaultResticStaleLockAge, wrapper.unlockMinAge())
+
+	wrapper.global = &config.Global{ResticStaleLockAge: time.Minute}
+	assert.Equal(t, constants.MinResticStaleLockAge, wrapper.unlockMinAge())
+	wrapper.global.ResticStaleLockAge = 5 * time.Hour
+	assert.Equal(t, 5*time.Hour, wrapper.unlockMinAge())
+}
+
+func TestUnlockActionRemovesForceFlag(t *testing.T) {
+	profile := config.NewProfile(nil, "name")
+	wrapper := newResticWrapper(nil, mockBinary, false, profile, constants.CommandUnlock, []string{"--force", "--remove-all"}, nil)
+	require.NoError(t, wrapper.getRunner(constants.CommandUnlock)())
+	assert.Equal(t, []string{"--remove-all"}, wrapper.moreArgs)
+}
+
+func TestRepositoryLockString(t *testing.T) {
+	lock := repositoryLock{ID: "0123456789abcdef", Exclusive: true, Hostname: "host", Username: "user", PID: 12, Time: time.Now().Add(-time.Minute)}
+	assert.Equal(t, "exclusive lock 01234567 of PID 12 on host by user, created 1m0s ago", lock.String())
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/creativeprojects/clog"
+	"github.com/creativeprojects/resticprofile/constants"
+	"github.com/creativeprojects/resticprofile/monitor"
+	"github.com/creativeprojects/resticprofile/shell"
+	"github.com/creativeprojects/resticprofile/util"
+)
+
+// snapshotNode is a file entry of the JSON output of "restic ls"
+type snapshotNode struct {
+	StructType string    `json:"struct_type"`
+	Type       string    `json:"type"`
+	Path       string    `json:"path"`
+	Size       uint64    `json:"size"`
+	ModTime    time.Time `json:"mtime"`
+}
+
+// verifyRestoreResult counts the outcome of a verify-restore run
+type verifyRestoreResult struct {
+	verified, changed, failed int
+	failures                  []string
+}
+
+func (r *resticWrapper) getVerifyRestoreAction() func() error {
+	return func() error {
+		return r.runVerifyRestore()
+	}
+}
+
+// runVerifyRestore restores a random sample of files from a snapshot and verifies their content
+func (r *resticWrapper) runVerifyRestore() error {
+	command := constants.SectionConfigurationVerify
+	section := r.profile.VerifyRestore
+	snapshot := section.GetSnapshot()
+
+	clog.Infof("profile '%s': starting '%s' from snapshot %s", r.profile.Name, command, snapshot)
+	r.start(command)
+	start := time.Now()
+	// the result is always reported to the status file and the monitoring
+	finish := func(summary monitor.Summary, stderr string, err error) error {
+		summary.Duration = time.Since(start)
+		r.summary(command, summary, stderr, err)
+		return err
+	}
+
+	files, err := r.listSnapshotFiles(snapshot)
+	if err != nil {
+		return finish(monitor.Summary{}, "", err)
+	}
+	if r.dryRun {
+		return finish(monitor.Summary{}, "", nil)
+	}
+	if len(files) == 0 {
+		clog.Warningf("profile '%s': no file found in snapshot %s", r.profile.Name, snapshot)
+		return finish(monitor.Summary{}, "", nil)
+	}
+	sample := sampleFiles(files, section.GetSampleSize())
+
+	tempDir, err := util.TempDir()
+	if err != nil {
+		return finish(monitor.Summary{}, "", err)
+	}
+	target, err := os.MkdirTemp(tempDir, "verify-restore-*")
+	if err != nil {
+		return finish(monitor.Summary{}, "", fmt.Errorf("cannot create restore target: %w", err))
+	}
+	defer os.RemoveAll(target)
+
+	args := r.profile.GetCommandFlags(command)
+	args.AddArg(snapshot, shell.ArgConfigEscape)
+	args.AddFlag("target", target, shell.ArgConfigEscape)
+	includes := make([]string, len(sample))
+	for i, file := range sample {
+		includes[i] = escapeIncludePattern(file.Path)
+	}
+	args.AddFlags("include", includes, shell.ArgConfigEscape)
+
+	rCommand := r.prepareCommand(constants.CommandRestore, args, true)
+	rCommand.capture = r.captureWriter()
+	summary, stderr, err := runShellCommand(rCommand)
+	r.executionTime += summary.Duration
+	if err != nil {
+		return finish(summary, stderr, newCommandError(rCommand, stderr, fmt.Errorf("%s on profile '%s': %w", command, r.profile.Name, err)))
+	}
+
+	result := verifyRestoredFiles(target, sample, section != nil && section.CompareLive)
+	clog.Infof("profile '%s': %s verified %d file(s), %d changed on the live filesystem, %d failed",
+		r.profile.Name, command, result.verified, result.changed, result.failed)
+
+	if result.failed > 0 {
+		stderr = strings.Join(result.failures, "\n")
+		err = fmt.Errorf("%s on profile '%s': %d file(s) failed verification", command, r.profile.Name, result.failed)
+	}
+	if err = finish(summary, stderr, err); err == nil {
+		clog.Infof("profile '%s': finished '%s'", r.profile.Name, command)
+	}
+	return err
+}
+
+// escapeIncludePattern escapes the characters of the path having a meaning in the patterns of restic (*, ? and [),
+// so that --include only matches the file itself
+func escapeIncludePattern(path string) string {
+	builder := strings.Builder{}
+	for _, char := range path {
+		switch {
+		case char == '*' || char == '?' || char == '[':
+			builder.WriteString("[" + string(char) + "]")
+		case char == '\\' && runtime.GOOS != "windows":
+			builder.WriteString("\\\\")
+		default:
+			builder.WriteRune(char)
+		}
+	}
+	return builder.String()
+}
+
+// listSnapshotFiles returns the regular files found in the snapshot
+func (r *resticWrapper) listSnapshotFiles(snapshot string) ([]snapshotNode, error) {
+	// the flags of the verify-restore section are given to restore: ls only receives the repository flags
+	args := r.profile.GetCommonFlags()
+	args.AddFlag("json", "", shell.ArgConfigEscape)
+	args.AddArg(snapshot, shell.ArgConfigEscape)
+
+	output := &bytes.Buffer{}
+	rCommand := r.prepareCommand(constants.CommandLs, args, false)
+	rCommand.stdout = output
+	_, stderr, err := runShellCommand(rCommand)
+	if err != nil {
+		return nil, newCommandError(rCommand, stderr, fmt.Errorf("listing snapshot %s on profile '%s': %w", snapshot, r.profile.Name, err))
+	}
+	return parseSnapshotFiles(output)
+}
+
+// parseSnapshotFiles reads the files from the JSON output of "restic ls"
+func parseSnapshotFiles(reader io.Reader) (files []snapshotNode, err error) {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 || line[0] != '{' {
+			continue
+		}
+		node := snapshotNode{}
+		if err = json.Unmarshal(line, &node); err != nil {
+			return nil, fmt.Errorf("cannot parse restic output: %w", err)
+		}
+		if node.StructType == "node" && node.Type == "file" {
+			files = append(files, node)
+		}
+	}
+	err = scanner.Err()
+	return
+}
+
+// sampleFiles picks up to size files at random
+func sampleFiles(files []snapshotNode, size int) []snapshotNode {
+	if size >= len(files) {
+		return files
+	}
+	sample := make([]snapshotNode, size)
+	for i, index := range rand.Perm(len(files))[:size] {
+		sample[i] = files[index]
+	}
+	return sample
+}
+
+// verifyRestoredFiles checks the restored files exist with the expected size, and compares them with the live files
+func verifyRestoredFiles(target string, files []snapshotNode, compareLive bool) (result verifyRestoreResult) {
+	fail := func(format string, args ...any) {
+		result.failed++
+		result.failures = append(result.failures, fmt.Sprintf(format, args...))
+	}
+
+	for _, file := range files {
+		restored := filepath.Join(target, filepath.FromSlash(file.Path))
+		info, err := os.Stat(restored)
+		if err != nil {
+			fail("%s: not restored: %s", file.Path, err)
+			continue
+		}
+		if uint64(info.Size()) != file.Size {
+			fail("%s: restored size %d differs from snapshot size %d", file.Path, info.Size(), file.Size)
+			continue
+		}
+		restoredSum, err := fileChecksum(restored)
+		if err != nil {
+			fail("%s: cannot read restored file: %s", file.Path, err)
+			continue
+		}
+
+		if compareLive {
+			live := liveFilePath(file.Path)
+			if info, err := os.Stat(live); err != nil || !info.ModTime().Equal(file.ModTime) || uint64(info.Size()) != file.Size {
+				// file was modified (or removed) since the snapshot
+				clog.Debugf("%s: live file changed since the snapshot", file.Path)
+				result.changed++
+				continue
+			}
+			liveSum, err := fileChecksum(live)
+			if err != nil {
+				fail("%s: cannot read live file: %s", file.Path, err)
+				continue
+			}
+			if !bytes.Equal(restoredSum, liveSum) {
+				fail("%s: checksum of restored file differs from the live file", file.Path)
+				continue
+			}
+		}
+		result.verified++
+	}
+	return
+}
+
+// liveFilePath converts a path from the snapshot to a path on the local filesystem ("/C/dir" is "C:\dir" on windows)
+func liveFilePath(path string) string {
+	if runtime.GOOS == "windows" && len(path) > 2 && path[0] == '/' && path[2] == '/' {
+		path = path[1:2] + ":" + path[2:]
+	}
+	return filepath.FromSlash(path)
+}
+
+func fileChecksum(filename string) ([]byte, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err = io.Copy(hash, file); err != nil {
+		return nil, err
+	}
+	return hash.Sum(nil), nil
+}
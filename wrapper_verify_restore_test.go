@@ -0,0 +1,149 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/creativeprojects/resticprofile/constants"
+	"github.com/creativeprojects/resticprofile/monitor/status"
+	"github.com/creativeprojects/resticprofile/platform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSnapshotFiles(t *testing.T) {
+	output := `{"time":"2023-01-01T10:00:00Z","paths":["/home"],"struct_type":"snapshot"}
+{"name":"home","type":"dir","path":"/home","struct_type":"node"}
+{"name":"file.txt","type":"file","path":"/home/file.txt","size":12,"mtime":"2023-01-01T09:00:00Z","struct_type":"node"}
+not json
+{"name":"link","type":"symlink","path":"/home/link","struct_type":"node"}
+`
+	files, err := parseSnapshotFiles(strings.NewReader(output))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, "/home/file.txt", files[0].Path)
+	assert.Equal(t, uint64(12), files[0].Size)
+}
+
+func TestSampleFiles(t *testing.T) {
+	files := []snapshotNode{{Path: "/a"}, {Path: "/b"}, {Path: "/c"}}
+	assert.Len(t, sampleFiles(files, 10), 3)
+	sample := sampleFiles(files, 2)
+	assert.Len(t, sample, 2)
+	assert.NotEqual(t, sample[0].Path, sample[1].Path)
+}
+
+func TestLiveFilePath(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		assert.Equal(t, `C:\dir\file`, liveFilePath("/C/dir/file"))
+	} else {
+		assert.Equal(t, "/C/dir/file", liveFilePath("/C/dir/file"))
+	}
+}
+
+func TestVerifyRestoredFiles(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("live paths are not testable on windows")
+	}
+	live := t.TempDir()
+	target := t.TempDir()
+	modTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+
+	writeFile := func(root, name, content string) {
+		filename := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(filename), 0o700))
+		require.NoError(t, os.WriteFile(filename, []byte(content), 0o600))
+		require.NoError(t, os.Chtimes(filename, modTime, modTime))
+	}
+	node := func(name string, size uint64) snapshotNode {
+		return snapshotNode{Path: filepath.ToSlash(filepath.Join(live, name)), Size: size, ModTime: modTime}
+	}
+
+	writeFile(live, "same", "content")
+	writeFile(target+live, "same", "content")
+	writeFile(live, "corrupted", "content")
+	writeFile(target+live, "corrupted", "CONTENT")
+	writeFile(target+live, "removed", "content")
+	writeFile(target+live, "wrong-size", "cont")
+
+	files := []snapshotNode{
+		node("same", 7),
+		node("corrupted", 7),
+		node("removed", 7),
+		node("wrong-size", 7),
+		node("missing", 7),
+	}
+
+	result := verifyRestoredFiles(target, files, true)
+	assert.Equal(t, 1, result.verified)
+	assert.Equal(t, 1, result.changed)
+	assert.Equal(t, 3, result.failed)
+	assert.Len(t, result.failures, 3)
+
+	result = verifyRestoredFiles(target, files, false)
+	assert.Equal(t, 3, result.verified)
+	assert.Equal(t, 0, result.changed)
+	assert.Equal(t, 2, result.failed)
+}
+
+func TestEscapeIncludePattern(t *testing.T) {
+	assert.Equal(t, "/home/user/file.txt", escapeIncludePattern("/home/user/file.txt"))
+	assert.Equal(t, "/home/user/[*]important[?] [[]1].txt", escapeIncludePattern("/home/user/*important? [1].txt"))
+	if runtime.GOOS != "windows" {
+		assert.Equal(t, `/home/user/back\\slash`, escapeIncludePattern(`/home/user/back\slash`))
+	}
+}
+
+func TestVerifyRestoreFailureIsReported(t *testing.T) {
+	if platform.IsWindows() {
+		t.Skip("shell script")
+	}
+	dir := t.TempDir()
+	binary := filepath.Join(dir, "restic")
+	require.NoError(t, os.WriteFile(binary, []byte("#!/bin/sh\necho 'repository not found' >&2\nexit 1\n"), 0o700))
+	statusFile := filepath.Join(dir, "status.json")
+
+	profile := config.NewProfile(nil, "name")
+	profile.StatusFile = statusFile
+	profile.VerifyRestore = &config.VerifyRestoreSection{}
+	wrapper := newResticWrapper(nil, binary, false, profile, constants.SectionConfigurationVerify, nil, nil)
+	wrapper.addProgress(status.NewProgress(profile, status.NewStatus(statusFile)))
+
+	err := wrapper.runVerifyRestore()
+	assert.ErrorContains(t, err, "listing snapshot")
+
+	state := status.NewStatus(statusFile).Load()
+	require.NotNil(t, state.Profile("name").Verify)
+	assert.False(t, state.Profile("name").Verify.Success)
+}
+
+func TestListSnapshotFilesArguments(t *testing.T) {
+	if platform.IsWindows() {
+		t.Skip("shell script")
+	}
+	dir := t.TempDir()
+	binary := filepath.Join(dir, "restic")
+	argsFile := filepath.Join(dir, "args")
+	script := "#!/bin/sh\nfor arg in \"$@\"; do echo \"$arg\" >> " + argsFile + "; done\n" +
+		"echo '{\"struct_type\":\"node\",\"type\":\"file\",\"path\":\"/home/file\"}'\n"
+	require.NoError(t, os.WriteFile(binary, []byte(script), 0o700))
+
+	profile := config.NewProfile(nil, "name")
+	profile.Repository = config.NewConfidentialValue("local:/backup")
+	profile.VerifyRestore = &config.VerifyRestoreSection{}
+	profile.VerifyRestore.OtherFlags = map[string]any{"overwrite": "always"}
+	wrapper := newResticWrapper(nil, binary, false, profile, constants.SectionConfigurationVerify, nil, nil)
+
+	files, err := wrapper.listSnapshotFiles("abcd")
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	content, err := os.ReadFile(argsFile)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ls", "--json", "--repo=local:/backup", "abcd"}, strings.Fields(string(content)))
+}